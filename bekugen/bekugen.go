@@ -0,0 +1,734 @@
+// Package bekugen generates the Go code of beku builders from existing Kubernetes manifests,
+// so the teams with many yaml files can move to beku easily.
+//
+// Every document is generated as a function which returns the finished object, eg:
+//
+//	func newNginxDeployment() (*appsv1.Deployment, error) {
+//		return beku.NewDeployment().
+//			SetNamespaceAndName("default", "nginx").
+//			SetSelector(map[string]string{"app": "nginx"}).
+//			SetContainer("nginx", "nginx:1.15", 80).
+//			Finish()
+//	}
+//
+// The fields which have no beku setter are not generated,they are listed in the comment of the function.
+package bekugen
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// import paths used by the generated code
+const (
+	bekuImport   = "github.com/yulibaozi/beku"
+	appsv1Import = "k8s.io/api/apps/v1"
+	corev1Import = "k8s.io/api/core/v1"
+)
+
+// GenerateCode convert the yaml or json manifest into the functions which create the same objects by beku builders,
+// documents are separated by '---'.
+// supported kinds: Deployment,StatefulSet,DaemonSet,Service,ConfigMap,Secret,Namespace.
+func GenerateCode(manifest []byte) (string, error) {
+	code, _, err := generate(manifest)
+	return code, err
+}
+
+// GenerateFile same as GenerateCode,but return a complete Go file of package pkg with the imports.
+func GenerateFile(pkg string, manifest []byte) (string, error) {
+	code, imports, err := generate(manifest)
+	if err != nil {
+		return "", err
+	}
+	return formatFile(pkg, code, imports)
+}
+
+func generate(manifest []byte) (string, []string, error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(manifest)))
+	g := newGenerator()
+	for index := 0; ; index++ {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("GenerateCode err,read document[%d] failed:%v", index, err)
+		}
+		if err := g.document(doc); err != nil {
+			return "", nil, fmt.Errorf("GenerateCode err,document[%d]:%v", index, err)
+		}
+	}
+	if g.funcs == 0 {
+		return "", nil, fmt.Errorf("GenerateCode err,no document is found")
+	}
+	code, err := format.Source(g.out.Bytes())
+	if err != nil {
+		return "", nil, fmt.Errorf("GenerateCode err,format code failed:%v", err)
+	}
+	return string(code), g.importList(), nil
+}
+
+// formatFile wrap the functions into Go file of package pkg
+func formatFile(pkg, code string, imports []string) (string, error) {
+	var buf bytes.Buffer
+	// the code is the starting point of the builders,so it is not marked as generated code which must not be edited
+	fmt.Fprintf(&buf, "// generated from the manifests by bekugen\n\npackage %s\n\nimport (\n", pkg)
+	for _, path := range imports {
+		switch path {
+		case appsv1Import:
+			fmt.Fprintf(&buf, "\tappsv1 %q\n", path)
+		case corev1Import:
+			fmt.Fprintf(&buf, "\tcorev1 %q\n", path)
+		default:
+			fmt.Fprintf(&buf, "\t%q\n", path)
+		}
+	}
+	fmt.Fprintf(&buf, ")\n\n%s", code)
+	file, err := format.Source(buf.Bytes())
+	if err != nil {
+		return "", err
+	}
+	return string(file), nil
+}
+
+// generator write the functions of the documents
+type generator struct {
+	out     bytes.Buffer
+	imports map[string]bool
+	names   map[string]int
+	funcs   int
+}
+
+func newGenerator() *generator {
+	return &generator{imports: map[string]bool{bekuImport: true}, names: map[string]int{}}
+}
+
+func (g *generator) importList() []string {
+	list := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		list = append(list, path)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// document generate the function of one yaml or json document,empty document is skipped.
+func (g *generator) document(doc []byte) error {
+	jsonDoc, err := yaml.YAMLToJSON(doc)
+	if err != nil {
+		return err
+	}
+	if string(jsonDoc) == "null" {
+		return nil
+	}
+	var typeMeta metav1.TypeMeta
+	if err := json.Unmarshal(jsonDoc, &typeMeta); err != nil {
+		return err
+	}
+	var c *chain
+	switch typeMeta.Kind {
+	case "Deployment":
+		dp := new(appsv1.Deployment)
+		if err := json.Unmarshal(jsonDoc, dp); err != nil {
+			return err
+		}
+		c = deploymentChain(dp)
+	case "StatefulSet":
+		sts := new(appsv1.StatefulSet)
+		if err := json.Unmarshal(jsonDoc, sts); err != nil {
+			return err
+		}
+		c = statefulSetChain(sts)
+	case "DaemonSet":
+		ds := new(appsv1.DaemonSet)
+		if err := json.Unmarshal(jsonDoc, ds); err != nil {
+			return err
+		}
+		c = daemonSetChain(ds)
+	case "Service":
+		svc := new(corev1.Service)
+		if err := json.Unmarshal(jsonDoc, svc); err != nil {
+			return err
+		}
+		c = serviceChain(svc)
+	case "ConfigMap":
+		cm := new(corev1.ConfigMap)
+		if err := json.Unmarshal(jsonDoc, cm); err != nil {
+			return err
+		}
+		c = configMapChain(cm)
+	case "Secret":
+		sec := new(corev1.Secret)
+		if err := json.Unmarshal(jsonDoc, sec); err != nil {
+			return err
+		}
+		c = secretChain(sec)
+	case "Namespace":
+		ns := new(corev1.Namespace)
+		if err := json.Unmarshal(jsonDoc, ns); err != nil {
+			return err
+		}
+		c = namespaceChain(ns)
+	case "":
+		return fmt.Errorf("kind is not allowed to be empty")
+	default:
+		return fmt.Errorf("kind %s is not supported", typeMeta.Kind)
+	}
+	return g.write(c)
+}
+
+// write write the function of the chain
+func (g *generator) write(c *chain) error {
+	left, err := leftover(c.rest)
+	if err != nil {
+		return err
+	}
+	g.imports[c.importPath] = true
+	name := "new" + exportName(c.name) + c.kind
+	if g.names[name]++; g.names[name] > 1 {
+		name += strconv.Itoa(g.names[name])
+	}
+	if g.funcs > 0 {
+		g.out.WriteString("\n")
+	}
+	g.funcs++
+	fmt.Fprintf(&g.out, "// %s create %s %s\n", name, c.kind, c.name)
+	if left != "" {
+		g.out.WriteString("//\n// the fields are not generated,because beku has no setter for them:\n//\n")
+		for _, line := range strings.Split(strings.TrimRight(left, "\n"), "\n") {
+			fmt.Fprintf(&g.out, "//\t%s\n", line)
+		}
+	}
+	fmt.Fprintf(&g.out, "func %s() (%s, error) {\n\treturn beku.%s().\n", name, c.typ, c.builder)
+	for _, call := range c.calls {
+		fmt.Fprintf(&g.out, "\t\t%s.\n", call)
+	}
+	g.out.WriteString("\t\tFinish()\n}\n")
+	return nil
+}
+
+// chain is the beku calls of one object,
+// rest is the object which the generated fields are cleared, the fields left in it are not generated.
+type chain struct {
+	kind, name string
+	typ        string
+	importPath string
+	builder    string
+	calls      []string
+	rest       interface{}
+}
+
+func (c *chain) call(format string, args ...interface{}) {
+	c.calls = append(c.calls, fmt.Sprintf(format, args...))
+}
+
+// meta generate namespace and name,server populated fields are dropped.
+func (c *chain) meta(meta *metav1.ObjectMeta, namespaced bool) {
+	c.name = meta.Name
+	if namespaced && meta.Namespace != "" {
+		c.call("SetNamespaceAndName(%s, %s)", quote(meta.Namespace), quote(meta.Name))
+	} else {
+		c.call("SetName(%s)", quote(meta.Name))
+	}
+	meta.Namespace, meta.Name = "", ""
+	meta.CreationTimestamp = metav1.Time{}
+	meta.ResourceVersion, meta.UID, meta.SelfLink, meta.Generation = "", "", "", 0
+	meta.ManagedFields = nil
+}
+
+// labels generate labels, Namespace has no SetLabels()
+func (c *chain) labels(meta *metav1.ObjectMeta) {
+	if len(meta.Labels) > 0 {
+		c.call("SetLabels(%s)", stringMap(meta.Labels))
+		meta.Labels = nil
+	}
+}
+
+// annotations generate annotations, only the workloads and Service have SetAnnotations()
+func (c *chain) annotations(meta *metav1.ObjectMeta) {
+	if len(meta.Annotations) > 0 {
+		c.call("SetAnnotations(%s)", stringMap(meta.Annotations))
+		meta.Annotations = nil
+	}
+}
+
+// selector generate selector and Pod labels of the workloads,
+// beku set Pod labels by the selector, so Pod labels are generated only when they are the same as matchLabels.
+func (c *chain) selector(selector **metav1.LabelSelector, temp *corev1.PodTemplateSpec) {
+	if *selector == nil {
+		if len(temp.Labels) > 0 {
+			c.call("SetPodLabels(%s)", stringMap(temp.Labels))
+			temp.Labels = nil
+		}
+		return
+	}
+	sel := *selector
+	if len(sel.MatchLabels) > 0 {
+		c.call("SetSelector(%s)", stringMap(sel.MatchLabels))
+		if mapEqual(sel.MatchLabels, temp.Labels) {
+			temp.Labels = nil
+		}
+		sel.MatchLabels = nil
+	}
+	var rest []metav1.LabelSelectorRequirement
+	for _, req := range sel.MatchExpressions {
+		switch req.Operator {
+		case metav1.LabelSelectorOpIn:
+			c.call("MatchIn(%s)", quoteList(append([]string{req.Key}, req.Values...)))
+		case metav1.LabelSelectorOpNotIn:
+			c.call("MatchNotIn(%s)", quoteList(append([]string{req.Key}, req.Values...)))
+		case metav1.LabelSelectorOpExists:
+			c.call("MatchExists(%s)", quote(req.Key))
+		case metav1.LabelSelectorOpDoesNotExist:
+			c.call("MatchDoesNotExist(%s)", quote(req.Key))
+		default:
+			rest = append(rest, req)
+		}
+	}
+	sel.MatchExpressions = rest
+	if len(sel.MatchLabels) == 0 && len(sel.MatchExpressions) == 0 {
+		*selector = nil
+	}
+}
+
+// pod generate Pod template,beku sets probes,envs,resources and volumeMounts on the first container,
+// so they are generated for the first container only.
+func (c *chain) pod(temp *corev1.PodTemplateSpec) {
+	spec := &temp.Spec
+	temp.Namespace = ""
+	for index := range spec.Containers {
+		container := &spec.Containers[index]
+		if len(container.Ports) != 1 || container.Image == "" {
+			continue
+		}
+		c.call("SetContainer(%s, %s, %d)", quote(container.Name), quote(container.Image), container.Ports[0].ContainerPort)
+		container.Name, container.Image = "", ""
+		container.Ports[0].ContainerPort = 0
+		if container.Ports[0].Protocol == corev1.ProtocolTCP {
+			container.Ports[0].Protocol = ""
+		}
+		if index == 0 {
+			c.firstContainer(container)
+		}
+	}
+	for index := range spec.Volumes {
+		volume := &spec.Volumes[index]
+		if volume.PersistentVolumeClaim == nil || volume.PersistentVolumeClaim.ReadOnly {
+			continue
+		}
+		c.call("SetPVClaim(%s, %s)", quote(volume.Name), quote(volume.PersistentVolumeClaim.ClaimName))
+		volume.Name, volume.PersistentVolumeClaim = "", nil
+	}
+	for index := range spec.ImagePullSecrets {
+		c.call("SetImagePullSecrets(%s)", quote(spec.ImagePullSecrets[index].Name))
+		spec.ImagePullSecrets[index].Name = ""
+	}
+	if spec.PriorityClassName != "" {
+		c.call("SetPriorityClassName(%s)", quote(spec.PriorityClassName))
+		spec.PriorityClassName = ""
+	}
+	if spec.RuntimeClassName != nil {
+		c.call("SetRuntimeClassName(%s)", quote(*spec.RuntimeClassName))
+		spec.RuntimeClassName = nil
+	}
+	if spec.SchedulerName != "" {
+		c.call("SetSchedulerName(%s)", quote(spec.SchedulerName))
+		spec.SchedulerName = ""
+	}
+	if spec.TerminationGracePeriodSeconds != nil {
+		c.call("SetTerminationGracePeriodSeconds(%d)", *spec.TerminationGracePeriodSeconds)
+		spec.TerminationGracePeriodSeconds = nil
+	}
+	c.pullPolicy(spec.Containers)
+}
+
+func (c *chain) firstContainer(container *corev1.Container) {
+	envs := map[string]string{}
+	for index := range container.Env {
+		env := &container.Env[index]
+		if env.ValueFrom != nil || strings.TrimSpace(env.Value) == "" {
+			continue
+		}
+		envs[env.Name] = env.Value
+		env.Name, env.Value = "", ""
+	}
+	if len(envs) > 0 {
+		c.call("SetEnvs(%s)", stringMap(envs))
+	}
+	if len(container.Resources.Limits) > 0 {
+		c.call("SetResourceLimit(%s)", resourceMap(container.Resources.Limits))
+		container.Resources.Limits = nil
+	}
+	if len(container.Resources.Requests) > 0 {
+		c.call("SetResourceRequst(%s)", resourceMap(container.Resources.Requests))
+		container.Resources.Requests = nil
+	}
+	c.probe("Liveness", container.LivenessProbe)
+	c.probe("Readness", container.ReadinessProbe)
+	for index := range container.VolumeMounts {
+		mount := &container.VolumeMounts[index]
+		if mount.ReadOnly || mount.SubPath != "" {
+			continue
+		}
+		c.call("SetPVCMounts(%s, %s)", quote(mount.Name), quote(mount.MountPath))
+		mount.Name, mount.MountPath = "", ""
+	}
+}
+
+// probe generate liveness or readiness probe, kind is Liveness or Readness
+func (c *chain) probe(kind string, probe *corev1.Probe) {
+	if probe == nil {
+		return
+	}
+	timing := fmt.Sprintf("%d, %d, %d", probe.InitialDelaySeconds, probe.TimeoutSeconds, probe.PeriodSeconds)
+	switch {
+	case probe.HTTPGet != nil:
+		action := probe.HTTPGet
+		headers := ""
+		if len(action.HTTPHeaders) > 0 {
+			h := make(map[string]string, len(action.HTTPHeaders))
+			for _, header := range action.HTTPHeaders {
+				h[header.Name] = header.Value
+			}
+			headers = ", " + stringMap(h)
+		}
+		if action.Port.StrVal != "" {
+			c.call("SetNamedHTTP%s(%s, %s, %s%s)", kind, quote(action.Port.StrVal), quote(action.Path), timing, headers)
+		} else {
+			c.call("SetHTTP%s(%d, %s, %s%s)", kind, action.Port.IntVal, quote(action.Path), timing, headers)
+		}
+		action.Port.StrVal, action.Port.IntVal, action.Path, action.HTTPHeaders = "", 0, "", nil
+	case probe.TCPSocket != nil:
+		action := probe.TCPSocket
+		if action.Port.StrVal != "" {
+			c.call("SetNamedTCP%s(%s, %s, %s)", kind, quote(action.Host), quote(action.Port.StrVal), timing)
+		} else {
+			c.call("SetTCP%s(%s, %d, %s)", kind, quote(action.Host), action.Port.IntVal, timing)
+		}
+		action.Port.StrVal, action.Port.IntVal, action.Host = "", 0, ""
+	case probe.Exec != nil:
+		c.call("SetCMD%s([]string{%s}, %s)", kind, quoteList(probe.Exec.Command), timing)
+		probe.Exec.Command = nil
+	default:
+		return
+	}
+	probe.InitialDelaySeconds, probe.TimeoutSeconds, probe.PeriodSeconds = 0, 0, 0
+}
+
+// pullPolicy generate ImagePullPolicy() when all containers have the same policy,
+// because beku sets the policy on all containers.
+func (c *chain) pullPolicy(containers []corev1.Container) {
+	if len(containers) == 0 || containers[0].ImagePullPolicy == "" {
+		return
+	}
+	policy := containers[0].ImagePullPolicy
+	for _, container := range containers {
+		if container.ImagePullPolicy != policy {
+			return
+		}
+	}
+	c.call("ImagePullPolicy(beku.Pull%s)", policy)
+	for index := range containers {
+		containers[index].ImagePullPolicy = ""
+	}
+}
+
+func deploymentChain(dp *appsv1.Deployment) *chain {
+	c := &chain{kind: "Deployment", typ: "*appsv1.Deployment", importPath: appsv1Import, builder: "NewDeployment", rest: dp}
+	c.meta(&dp.ObjectMeta, true)
+	c.labels(&dp.ObjectMeta)
+	c.annotations(&dp.ObjectMeta)
+	c.selector(&dp.Spec.Selector, &dp.Spec.Template)
+	if dp.Spec.Replicas != nil {
+		c.call("SetReplicas(%d)", *dp.Spec.Replicas)
+		dp.Spec.Replicas = nil
+	}
+	if dp.Spec.MinReadySeconds > 0 {
+		c.call("SetMinReadySeconds(%d)", dp.Spec.MinReadySeconds)
+		dp.Spec.MinReadySeconds = 0
+	}
+	if dp.Spec.RevisionHistoryLimit != nil {
+		c.call("SetHistoryLimit(%d)", *dp.Spec.RevisionHistoryLimit)
+		dp.Spec.RevisionHistoryLimit = nil
+	}
+	if dp.Spec.ProgressDeadlineSeconds != nil {
+		c.call("SetDeployMaxTime(%d)", *dp.Spec.ProgressDeadlineSeconds)
+		dp.Spec.ProgressDeadlineSeconds = nil
+	}
+	c.pod(&dp.Spec.Template)
+	dp.Status = appsv1.DeploymentStatus{}
+	return c
+}
+
+func statefulSetChain(sts *appsv1.StatefulSet) *chain {
+	c := &chain{kind: "StatefulSet", typ: "*appsv1.StatefulSet", importPath: appsv1Import, builder: "NewSts", rest: sts}
+	c.meta(&sts.ObjectMeta, true)
+	c.labels(&sts.ObjectMeta)
+	c.annotations(&sts.ObjectMeta)
+	c.selector(&sts.Spec.Selector, &sts.Spec.Template)
+	if sts.Spec.Replicas != nil {
+		c.call("SetReplicas(%d)", *sts.Spec.Replicas)
+		sts.Spec.Replicas = nil
+	}
+	c.pod(&sts.Spec.Template)
+	sts.Status = appsv1.StatefulSetStatus{}
+	return c
+}
+
+func daemonSetChain(ds *appsv1.DaemonSet) *chain {
+	c := &chain{kind: "DaemonSet", typ: "*appsv1.DaemonSet", importPath: appsv1Import, builder: "NewDS", rest: ds}
+	c.meta(&ds.ObjectMeta, true)
+	c.labels(&ds.ObjectMeta)
+	c.annotations(&ds.ObjectMeta)
+	c.selector(&ds.Spec.Selector, &ds.Spec.Template)
+	if ds.Spec.MinReadySeconds > 0 {
+		c.call("SetMinReadySeconds(%d)", ds.Spec.MinReadySeconds)
+		ds.Spec.MinReadySeconds = 0
+	}
+	if ds.Spec.RevisionHistoryLimit != nil {
+		c.call("SetHistoryLimit(%d)", *ds.Spec.RevisionHistoryLimit)
+		ds.Spec.RevisionHistoryLimit = nil
+	}
+	c.pod(&ds.Spec.Template)
+	ds.Status = appsv1.DaemonSetStatus{}
+	return c
+}
+
+func serviceChain(svc *corev1.Service) *chain {
+	c := &chain{kind: "Service", typ: "*corev1.Service", importPath: corev1Import, builder: "NewSvc", rest: svc}
+	c.meta(&svc.ObjectMeta, true)
+	c.labels(&svc.ObjectMeta)
+	c.annotations(&svc.ObjectMeta)
+	if len(svc.Spec.Selector) > 0 {
+		c.call("SetSelector(%s)", stringMap(svc.Spec.Selector))
+		svc.Spec.Selector = nil
+	}
+	if svc.Spec.Type != "" {
+		c.call("SetServiceType(beku.ServiceType%s)", svc.Spec.Type)
+		svc.Spec.Type = ""
+	}
+	for index := range svc.Spec.Ports {
+		port := &svc.Spec.Ports[index]
+		if port.TargetPort.StrVal != "" {
+			continue
+		}
+		fields := []string{fmt.Sprintf("Port: %d", port.Port)}
+		if port.Name != "" {
+			fields = append([]string{"Name: " + quote(port.Name)}, fields...)
+		}
+		if port.Protocol != "" {
+			fields = append(fields, "Protocol: "+quote(string(port.Protocol)))
+		}
+		if port.TargetPort.IntVal > 0 {
+			fields = append(fields, fmt.Sprintf("TargetPort: %d", port.TargetPort.IntVal))
+		}
+		if port.NodePort > 0 {
+			fields = append(fields, fmt.Sprintf("NodePort: %d", port.NodePort))
+		}
+		c.call("SetPort(beku.ServicePort{%s})", strings.Join(fields, ", "))
+		*port = corev1.ServicePort{}
+	}
+	if svc.Spec.SessionAffinity != "" {
+		c.call("SetSessionAffinity(beku.ServiceAffinity%s)", svc.Spec.SessionAffinity)
+		svc.Spec.SessionAffinity = ""
+	}
+	if svc.Spec.ClusterIP == corev1.ClusterIPNone {
+		c.call("Headless()")
+		svc.Spec.ClusterIP = ""
+	}
+	svc.Status = corev1.ServiceStatus{}
+	return c
+}
+
+func configMapChain(cm *corev1.ConfigMap) *chain {
+	c := &chain{kind: "ConfigMap", typ: "*corev1.ConfigMap", importPath: corev1Import, builder: "NewCM", rest: cm}
+	c.meta(&cm.ObjectMeta, true)
+	c.labels(&cm.ObjectMeta)
+	if len(cm.Data) > 0 {
+		c.call("SetData(%s)", stringMap(cm.Data))
+		cm.Data = nil
+	}
+	return c
+}
+
+func secretChain(sec *corev1.Secret) *chain {
+	c := &chain{kind: "Secret", typ: "*corev1.Secret", importPath: corev1Import, builder: "NewSecret", rest: sec}
+	c.meta(&sec.ObjectMeta, true)
+	c.labels(&sec.ObjectMeta)
+	if sec.Type != "" {
+		c.call("SetType(%s)", quote(string(sec.Type)))
+		sec.Type = ""
+	}
+	// beku sets data by string, so only utf8 data is generated
+	data := make(map[string]string, len(sec.Data)+len(sec.StringData))
+	for key, value := range sec.Data {
+		if utf8.Valid(value) {
+			data[key] = string(value)
+			delete(sec.Data, key)
+		}
+	}
+	for key, value := range sec.StringData {
+		data[key] = value
+	}
+	sec.StringData = nil
+	if len(data) > 0 {
+		c.call("SetDataString(%s)", stringMap(data))
+	}
+	return c
+}
+
+func namespaceChain(ns *corev1.Namespace) *chain {
+	c := &chain{kind: "Namespace", typ: "*corev1.Namespace", importPath: corev1Import, builder: "NewNs", rest: ns}
+	c.meta(&ns.ObjectMeta, false)
+	ns.Spec = corev1.NamespaceSpec{}
+	ns.Status = corev1.NamespaceStatus{}
+	return c
+}
+
+// leftover get the yaml of the fields which are not generated, the empty values are dropped.
+func leftover(rest interface{}) (string, error) {
+	data, err := json.Marshal(rest)
+	if err != nil {
+		return "", err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", err
+	}
+	delete(fields, "apiVersion")
+	delete(fields, "kind")
+	if prune(fields) == nil {
+		return "", nil
+	}
+	out, err := yaml.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// prune drop the empty values of json value recursively, return nil when the value is empty.
+func prune(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if item = prune(item); item == nil {
+				delete(v, key)
+				continue
+			}
+			v[key] = item
+		}
+		if len(v) == 0 {
+			return nil
+		}
+	case []interface{}:
+		items := v[:0]
+		for _, item := range v {
+			if item = prune(item); item != nil {
+				items = append(items, item)
+			}
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		return items
+	case string:
+		if v == "" {
+			return nil
+		}
+	case float64:
+		if v == 0 {
+			return nil
+		}
+	case bool:
+		if !v {
+			return nil
+		}
+	case nil:
+		return nil
+	}
+	return value
+}
+
+func quote(s string) string { return strconv.Quote(s) }
+
+func quoteList(list []string) string {
+	quoted := make([]string, len(list))
+	for index := range list {
+		quoted[index] = quote(list[index])
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// stringMap get the map literal, the keys are sorted so the code is stable.
+func stringMap(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	items := make([]string, len(keys))
+	for index, key := range keys {
+		items[index] = quote(key) + ": " + quote(m[key])
+	}
+	return "map[string]string{" + strings.Join(items, ", ") + "}"
+}
+
+func resourceMap(list corev1.ResourceList) string {
+	keys := make([]string, 0, len(list))
+	for name := range list {
+		keys = append(keys, string(name))
+	}
+	sort.Strings(keys)
+	items := make([]string, len(keys))
+	for index, key := range keys {
+		quantity := list[corev1.ResourceName(key)]
+		items[index] = quote(key) + ": " + quote(quantity.String())
+	}
+	return "map[beku.ResourceName]string{" + strings.Join(items, ", ") + "}"
+}
+
+func mapEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if v, ok := b[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// exportName get the Go name of Kubernetes object name,eg: my-app.v1 to MyAppV1
+func exportName(name string) string {
+	var buf strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
@@ -0,0 +1,182 @@
+package bekugen
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// annotations written by kubectl and controllers, they are not part of the desired state
+var serverAnnotations = []string{
+	"kubectl.kubernetes.io/last-applied-configuration",
+	"deployment.kubernetes.io/revision",
+}
+
+// GenerateCodeFromCluster get the object from Kubernetes,drop the fields populated by apiServer and controllers,
+// and generate the beku code of it, it is used to move the resources managed by hand into Go.
+// supported kinds are the same as GenerateCode(),namespace is ignored by Namespace.
+// the error of getting the object is wrapped,so it can be checked by k8s.io/apimachinery/pkg/api/errors,eg: IsNotFound().
+func GenerateCodeFromCluster(ctx context.Context, client kubernetes.Interface, gvk schema.GroupVersionKind, namespace, name string) (string, error) {
+	obj, err := getObject(ctx, client, gvk, namespace, name)
+	if err != nil {
+		return "", fmt.Errorf("GenerateCodeFromCluster err,get %s %s/%s failed:%w", gvk.Kind, namespace, name, err)
+	}
+	stripServerFields(obj)
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	manifest, err := yaml.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	return GenerateCode(manifest)
+}
+
+func getObject(ctx context.Context, client kubernetes.Interface, gvk schema.GroupVersionKind, namespace, name string) (runtime.Object, error) {
+	opts := metav1.GetOptions{}
+	switch gvk.Kind {
+	case "Deployment":
+		return client.AppsV1().Deployments(namespace).Get(ctx, name, opts)
+	case "StatefulSet":
+		return client.AppsV1().StatefulSets(namespace).Get(ctx, name, opts)
+	case "DaemonSet":
+		return client.AppsV1().DaemonSets(namespace).Get(ctx, name, opts)
+	case "Service":
+		return client.CoreV1().Services(namespace).Get(ctx, name, opts)
+	case "ConfigMap":
+		return client.CoreV1().ConfigMaps(namespace).Get(ctx, name, opts)
+	case "Secret":
+		return client.CoreV1().Secrets(namespace).Get(ctx, name, opts)
+	case "Namespace":
+		return client.CoreV1().Namespaces().Get(ctx, name, opts)
+	}
+	return nil, fmt.Errorf("kind %s is not supported", gvk.Kind)
+}
+
+// stripServerFields drop status,server populated metadata and the values defaulted by apiServer,
+// so only the fields written by people are generated.
+func stripServerFields(obj runtime.Object) {
+	if accessor, ok := obj.(metav1.Object); ok {
+		accessor.SetUID("")
+		accessor.SetResourceVersion("")
+		accessor.SetGeneration(0)
+		accessor.SetSelfLink("")
+		accessor.SetCreationTimestamp(metav1.Time{})
+		accessor.SetManagedFields(nil)
+		accessor.SetOwnerReferences(nil)
+		if annotations := accessor.GetAnnotations(); len(annotations) > 0 {
+			for _, key := range serverAnnotations {
+				delete(annotations, key)
+			}
+			if len(annotations) == 0 {
+				accessor.SetAnnotations(nil)
+			}
+		}
+	}
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		o.Status = appsv1.DeploymentStatus{}
+		if s := o.Spec.Strategy; s.Type == appsv1.RollingUpdateDeploymentStrategyType &&
+			(s.RollingUpdate == nil || isPercent(s.RollingUpdate.MaxSurge, "25%") && isPercent(s.RollingUpdate.MaxUnavailable, "25%")) {
+			o.Spec.Strategy = appsv1.DeploymentStrategy{}
+		}
+		if o.Spec.RevisionHistoryLimit != nil && *o.Spec.RevisionHistoryLimit == 10 {
+			o.Spec.RevisionHistoryLimit = nil
+		}
+		if o.Spec.ProgressDeadlineSeconds != nil && *o.Spec.ProgressDeadlineSeconds == 600 {
+			o.Spec.ProgressDeadlineSeconds = nil
+		}
+		stripPodDefaults(&o.Spec.Template.Spec)
+	case *appsv1.StatefulSet:
+		o.Status = appsv1.StatefulSetStatus{}
+		if s := o.Spec.UpdateStrategy; s.Type == appsv1.RollingUpdateStatefulSetStrategyType &&
+			(s.RollingUpdate == nil || s.RollingUpdate.Partition == nil || *s.RollingUpdate.Partition == 0) {
+			o.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{}
+		}
+		if o.Spec.PodManagementPolicy == appsv1.OrderedReadyPodManagement {
+			o.Spec.PodManagementPolicy = ""
+		}
+		if o.Spec.RevisionHistoryLimit != nil && *o.Spec.RevisionHistoryLimit == 10 {
+			o.Spec.RevisionHistoryLimit = nil
+		}
+		stripPodDefaults(&o.Spec.Template.Spec)
+	case *appsv1.DaemonSet:
+		o.Status = appsv1.DaemonSetStatus{}
+		if s := o.Spec.UpdateStrategy; s.Type == appsv1.RollingUpdateDaemonSetStrategyType &&
+			(s.RollingUpdate == nil || s.RollingUpdate.MaxUnavailable == nil || s.RollingUpdate.MaxUnavailable.IntValue() == 1) {
+			o.Spec.UpdateStrategy = appsv1.DaemonSetUpdateStrategy{}
+		}
+		if o.Spec.RevisionHistoryLimit != nil && *o.Spec.RevisionHistoryLimit == 10 {
+			o.Spec.RevisionHistoryLimit = nil
+		}
+		stripPodDefaults(&o.Spec.Template.Spec)
+	case *corev1.Service:
+		o.Status = corev1.ServiceStatus{}
+		if o.Spec.ClusterIP != corev1.ClusterIPNone {
+			o.Spec.ClusterIP, o.Spec.ClusterIPs = "", nil
+		}
+		if o.Spec.SessionAffinity == corev1.ServiceAffinityNone {
+			o.Spec.SessionAffinity = ""
+		}
+		if o.Spec.Type == corev1.ServiceTypeClusterIP {
+			o.Spec.Type = ""
+		}
+	case *corev1.Namespace:
+		o.Status = corev1.NamespaceStatus{}
+		o.Spec = corev1.NamespaceSpec{}
+	}
+}
+
+// stripPodDefaults drop the Pod values defaulted by apiServer
+func stripPodDefaults(spec *corev1.PodSpec) {
+	if spec.RestartPolicy == corev1.RestartPolicyAlways {
+		spec.RestartPolicy = ""
+	}
+	if spec.DNSPolicy == corev1.DNSClusterFirst {
+		spec.DNSPolicy = ""
+	}
+	if spec.SchedulerName == corev1.DefaultSchedulerName {
+		spec.SchedulerName = ""
+	}
+	if spec.TerminationGracePeriodSeconds != nil && *spec.TerminationGracePeriodSeconds == corev1.DefaultTerminationGracePeriodSeconds {
+		spec.TerminationGracePeriodSeconds = nil
+	}
+	if spec.SecurityContext != nil && reflect.DeepEqual(*spec.SecurityContext, corev1.PodSecurityContext{}) {
+		spec.SecurityContext = nil
+	}
+	for index := range spec.Containers {
+		container := &spec.Containers[index]
+		if container.TerminationMessagePath == corev1.TerminationMessagePathDefault {
+			container.TerminationMessagePath = ""
+		}
+		if container.TerminationMessagePolicy == corev1.TerminationMessageReadFile {
+			container.TerminationMessagePolicy = ""
+		}
+		for _, probe := range []*corev1.Probe{container.LivenessProbe, container.ReadinessProbe} {
+			if probe == nil {
+				continue
+			}
+			if probe.SuccessThreshold == 1 {
+				probe.SuccessThreshold = 0
+			}
+			if probe.FailureThreshold == 3 {
+				probe.FailureThreshold = 0
+			}
+			if probe.HTTPGet != nil && probe.HTTPGet.Scheme == corev1.URISchemeHTTP {
+				probe.HTTPGet.Scheme = ""
+			}
+		}
+	}
+}
+
+// isPercent check the value is nil or the percent string,eg: 25% is the default of Deployment rolling update
+func isPercent(value *intstr.IntOrString, percent string) bool {
+	return value == nil || value.Type == intstr.String && value.StrVal == percent
+}
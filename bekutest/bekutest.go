@@ -0,0 +1,210 @@
+// Package bekutest provides a fake clientset and assertion utilities,
+// so you can unit-test the code which uses beku builders without a Kubernetes cluster.
+package bekutest
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// ProbeKind is liveness or readiness probe
+type ProbeKind string
+
+// probe kinds
+const (
+	LivenessProbe  ProbeKind = "liveness"
+	ReadinessProbe ProbeKind = "readiness"
+)
+
+// NewFakeClient create a fake clientset with the objects and register it on beku,
+// Release() and Apply() of all builders will use it until the test is cleaned up.
+func NewFakeClient(t testing.TB, objs ...runtime.Object) *fake.Clientset {
+	cs := fake.NewSimpleClientset(objs...)
+	beku.RegisterClientset(cs)
+	t.Cleanup(func() { beku.RegisterClientset(nil) })
+	return cs
+}
+
+// ApplyAndGet apply the builder by beku registered clientset and get the object back from the clientset,
+// builder is one of *beku.Deployment,*beku.StatefulSet,*beku.DaemonSet,*beku.Service,
+// *beku.ConfigMap,*beku.Secret,*beku.Namespace,*beku.PersistentVolume,*beku.PersistentVolumeClaim.
+// the test is failed when Apply() or Get failed.
+func ApplyAndGet(t testing.TB, cs *fake.Clientset, builder interface{}) runtime.Object {
+	t.Helper()
+	obj, err := applyAndGet(cs, builder)
+	if err != nil {
+		t.Fatalf("ApplyAndGet err:%v", err)
+	}
+	return obj
+}
+
+func applyAndGet(cs *fake.Clientset, builder interface{}) (runtime.Object, error) {
+	opts := metav1.GetOptions{}
+	switch b := builder.(type) {
+	case *beku.Deployment:
+		dp, err := b.Apply()
+		if err != nil {
+			return nil, err
+		}
+		return cs.AppsV1().Deployments(dp.GetNamespace()).Get(context.TODO(), dp.GetName(), opts)
+	case *beku.StatefulSet:
+		sts, err := b.Apply()
+		if err != nil {
+			return nil, err
+		}
+		return cs.AppsV1().StatefulSets(sts.GetNamespace()).Get(context.TODO(), sts.GetName(), opts)
+	case *beku.DaemonSet:
+		ds, err := b.Apply()
+		if err != nil {
+			return nil, err
+		}
+		return cs.AppsV1().DaemonSets(ds.GetNamespace()).Get(context.TODO(), ds.GetName(), opts)
+	case *beku.Service:
+		svc, err := b.Apply()
+		if err != nil {
+			return nil, err
+		}
+		return cs.CoreV1().Services(svc.GetNamespace()).Get(context.TODO(), svc.GetName(), opts)
+	case *beku.ConfigMap:
+		cm, err := b.Apply()
+		if err != nil {
+			return nil, err
+		}
+		return cs.CoreV1().ConfigMaps(cm.GetNamespace()).Get(context.TODO(), cm.GetName(), opts)
+	case *beku.Secret:
+		sec, err := b.Apply()
+		if err != nil {
+			return nil, err
+		}
+		return cs.CoreV1().Secrets(sec.GetNamespace()).Get(context.TODO(), sec.GetName(), opts)
+	case *beku.Namespace:
+		ns, err := b.Apply()
+		if err != nil {
+			return nil, err
+		}
+		return cs.CoreV1().Namespaces().Get(context.TODO(), ns.GetName(), opts)
+	case *beku.PersistentVolume:
+		pv, err := b.Apply()
+		if err != nil {
+			return nil, err
+		}
+		return cs.CoreV1().PersistentVolumes().Get(context.TODO(), pv.GetName(), opts)
+	case *beku.PersistentVolumeClaim:
+		pvc, err := b.Apply()
+		if err != nil {
+			return nil, err
+		}
+		return cs.CoreV1().PersistentVolumeClaims(pvc.GetNamespace()).Get(context.TODO(), pvc.GetName(), opts)
+	}
+	return nil, fmt.Errorf("builder type %T is not supported", builder)
+}
+
+// AssertHasProbe assert the first container of the workload has the kind of probe,
+// because beku only set probe on the first container.
+// obj is one of *appsv1.Deployment,*appsv1.StatefulSet,*appsv1.DaemonSet
+func AssertHasProbe(t testing.TB, obj runtime.Object, kind ProbeKind) {
+	t.Helper()
+	temp, _, err := podTemplate(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(temp.Spec.Containers) < 1 {
+		t.Fatalf("%T has no container", obj)
+	}
+	container := temp.Spec.Containers[0]
+	switch kind {
+	case LivenessProbe:
+		if container.LivenessProbe == nil {
+			t.Errorf("container %s has no liveness probe", container.Name)
+		}
+	case ReadinessProbe:
+		if container.ReadinessProbe == nil {
+			t.Errorf("container %s has no readiness probe", container.Name)
+		}
+	default:
+		t.Fatalf("probe kind %s is not supported", kind)
+	}
+}
+
+// AssertSelectorMatchesLabels assert the selector of the workload matches its Pod labels,
+// otherwise Kubernetes apiServer will reject the workload.
+// obj is one of *appsv1.Deployment,*appsv1.StatefulSet,*appsv1.DaemonSet
+func AssertSelectorMatchesLabels(t testing.TB, obj runtime.Object) {
+	t.Helper()
+	temp, selector, err := podTemplate(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if selector == nil {
+		t.Errorf("%T selector is empty", obj)
+		return
+	}
+	sel, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		t.Errorf("%T selector is invalid:%v", obj, err)
+		return
+	}
+	if sel.Empty() || !sel.Matches(labels.Set(temp.GetLabels())) {
+		t.Errorf("%T selector %s does not match Pod labels %v", obj, sel, temp.GetLabels())
+	}
+}
+
+// podTemplate get Pod template and selector of the workload
+func podTemplate(obj runtime.Object) (*corev1.PodTemplateSpec, *metav1.LabelSelector, error) {
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		return &o.Spec.Template, o.Spec.Selector, nil
+	case *appsv1.StatefulSet:
+		return &o.Spec.Template, o.Spec.Selector, nil
+	case *appsv1.DaemonSet:
+		return &o.Spec.Template, o.Spec.Selector, nil
+	}
+	return nil, nil, fmt.Errorf("%T is not a workload with Pod template", obj)
+}
+
+// update rewrite golden files by the finished objects: go test ./... -beku.update
+var update = flag.Bool("beku.update", false, "update the golden files of bekutest.MatchGolden")
+
+// MatchGolden finish the builder and compare the yaml of the object with the golden file,
+// run the test with -beku.update to create or update the golden file.
+func MatchGolden(t testing.TB, builder beku.Builder, golden string) {
+	t.Helper()
+	obj, err := builder.FinishObject()
+	if err != nil {
+		t.Fatalf("MatchGolden err,finish %T failed:%v", builder, err)
+	}
+	got, err := beku.ToYAML(obj)
+	if err != nil {
+		t.Fatalf("MatchGolden err:%v", err)
+	}
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+			t.Fatalf("MatchGolden err:%v", err)
+		}
+		if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+			t.Fatalf("MatchGolden err:%v", err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("MatchGolden err:%v,run the test with -beku.update to create it", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%T does not match golden file %s,run the test with -beku.update to update it\n--- got\n%s\n--- want\n%s", builder, golden, got, want)
+	}
+}
@@ -0,0 +1,199 @@
+package beku
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	goruntime "runtime"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// Builder is implemented by beku builders which create one Kubernetes resource object,
+// eg: *Deployment,*Service,*ConfigMap
+type Builder interface {
+	// Validate check necessary value without changing the builder,all problems are returned
+	Validate() []FieldError
+	// FinishObject same as Finish() of the builder
+	FinishObject() (runtime.Object, error)
+}
+
+// Bundle include many Kubernetes resource objects and builders which are not finished,
+// it is used to handle all resource objects of an application together.
+type Bundle struct {
+	objs     []runtime.Object
+	builders []Builder
+	// last is the objects of the last Add(),After() makes them depend on others
+	last []runtime.Object
+	deps map[runtime.Object][]runtime.Object
+	// rewrites is the registry rewrites of SetRegistryRewrite(),they are applied in FinishAll()
+	rewrites []registryRewrite
+	err      error
+}
+
+// NewBundle create Bundle and chain function call begin with this function.
+func NewBundle() *Bundle { return &Bundle{} }
+
+// Add add Kubernetes resource objects into Bundle, eg: the object returned by Finish()
+// nil object will be ignored.
+func (b *Bundle) Add(objs ...runtime.Object) *Bundle {
+	b.last = nil
+	for _, obj := range objs {
+		if obj == nil || reflect.ValueOf(obj).IsNil() {
+			continue
+		}
+		b.objs = append(b.objs, obj)
+		b.last = append(b.last, obj)
+	}
+	return b
+}
+
+// After make the objects of the last Add() be applied after others by Apply(),
+// eg: NewBundle().Add(cm).Add(dp).After(cm), the Deployment is applied after the ConfigMap is ready.
+// others must be added into Bundle too,the objects which are not in Bundle are ignored by Apply().
+func (b *Bundle) After(others ...runtime.Object) *Bundle {
+	if b.deps == nil {
+		b.deps = make(map[runtime.Object][]runtime.Object)
+	}
+	for _, obj := range b.last {
+		b.deps[obj] = append(b.deps[obj], others...)
+	}
+	return b
+}
+
+// AddBuilders add builders into Bundle, they will be finished by FinishAll()
+func (b *Bundle) AddBuilders(builders ...Builder) *Bundle {
+	for _, builder := range builders {
+		if builder == nil {
+			continue
+		}
+		b.builders = append(b.builders, builder)
+	}
+	return b
+}
+
+// FinishAll call Finish() of all builders concurrently,and add the objects into Bundle in order of AddBuilders().
+// parallelism is the max number of goroutines,default is the number of CPU when parallelism <= 0.
+// all the errors are returned together, and no object is added when any builder is failed.
+func (b *Bundle) FinishAll(ctx context.Context, parallelism int) error {
+	if b.err != nil {
+		return b.err
+	}
+	if parallelism <= 0 {
+		parallelism = goruntime.NumCPU()
+	}
+	var (
+		objs = make([]runtime.Object, len(b.builders))
+		errs = make([]error, len(b.builders)+1)
+		sem  = make(chan struct{}, parallelism)
+		wg   sync.WaitGroup
+	)
+loop:
+	for index, builder := range b.builders {
+		select {
+		case <-ctx.Done():
+			errs[len(b.builders)] = ctx.Err()
+			break loop
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(index int, builder Builder) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			_, done := observeBuilder(ctx, builder)
+			obj, err := builder.FinishObject()
+			done(err)
+			if err != nil {
+				errs[index] = fmt.Errorf("builder[%d] %T: %v", index, builder, err)
+				return
+			}
+			rewriteObjectImages(obj, b.rewrites)
+			objs[index] = obj
+		}(index, builder)
+	}
+	wg.Wait()
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		return err
+	}
+	b.objs = append(b.objs, objs...)
+	b.builders = nil
+	return nil
+}
+
+// Objects get all Kubernetes resource objects in Bundle, the order is the order of Add()
+func (b *Bundle) Objects() []runtime.Object { return b.objs }
+
+// Len get the number of Kubernetes resource objects in Bundle
+func (b *Bundle) Len() int { return len(b.objs) }
+
+// Summary write a table like 'kubectl get' of all resource objects in Bundle,
+// the columns are KIND,NAMESPACE,NAME,REPLICAS/PORTS, it is used in CI logs and review comments.
+// the error of the chain is returned and nothing is written,eg: the error of SetRegistryRewrite().
+func (b *Bundle) Summary(w io.Writer) error {
+	if b.err != nil {
+		return b.err
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tNAMESPACE\tNAME\tREPLICAS/PORTS")
+	for _, obj := range b.objs {
+		namespace, name := "-", "-"
+		if accessor, err := meta.Accessor(obj); err == nil {
+			if verifyString(accessor.GetNamespace()) {
+				namespace = accessor.GetNamespace()
+			}
+			name = accessor.GetName()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", objectKind(obj), namespace, name, replicasOrPorts(obj))
+	}
+	return tw.Flush()
+}
+
+// objectKind get Kind of Kubernetes resource object,
+// when Kind is empty,such as the object is not finished,use the type name.
+func objectKind(obj runtime.Object) string {
+	if kind := obj.GetObjectKind().GroupVersionKind().Kind; kind != "" {
+		return kind
+	}
+	return reflect.Indirect(reflect.ValueOf(obj)).Type().Name()
+}
+
+// replicasOrPorts get workload replicas or service ports
+func replicasOrPorts(obj runtime.Object) string {
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		return replicasString(o.Spec.Replicas)
+	case *appsv1.StatefulSet:
+		return replicasString(o.Spec.Replicas)
+	case *v1.Service:
+		ports := make([]string, 0, len(o.Spec.Ports))
+		for _, port := range o.Spec.Ports {
+			if port.NodePort > 0 {
+				ports = append(ports, fmt.Sprintf("%d:%d/%s", port.Port, port.NodePort, port.Protocol))
+				continue
+			}
+			ports = append(ports, fmt.Sprintf("%d/%s", port.Port, port.Protocol))
+		}
+		if len(ports) > 0 {
+			return strings.Join(ports, ",")
+		}
+	}
+	return "-"
+}
+
+// replicasString Kubernetes defaults replicas to 1 when it is not set
+func replicasString(replicas *int32) string {
+	if replicas == nil {
+		return "1"
+	}
+	return fmt.Sprintf("%d", *replicas)
+}
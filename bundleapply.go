@@ -0,0 +1,229 @@
+package beku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+)
+
+// ReadyPollInterval is the interval of checking the objects are ready in Bundle Apply()
+var ReadyPollInterval = 2 * time.Second
+
+// Stages sort the objects of Bundle into stages by dependencies,
+// the objects in one stage don't depend on each other and the stages are applied in order by Apply().
+// besides After(),the dependencies are:
+// 1. the namespaced objects depend on their Namespace in Bundle
+// 2. the custom resources depend on their CustomResourceDefinition in Bundle
+// the order of the objects in one stage is the order of Add(),an error is returned when dependencies are cyclic.
+func (b *Bundle) Stages() ([][]runtime.Object, error) {
+	index := make(map[runtime.Object]int, len(b.objs))
+	for i, obj := range b.objs {
+		index[obj] = i
+	}
+	namespaces := make(map[string]int)
+	crds := make(map[schema.GroupKind]int)
+	for i, obj := range b.objs {
+		if ns, ok := obj.(*v1.Namespace); ok {
+			namespaces[ns.GetName()] = i
+			continue
+		}
+		if gk, ok := crdGroupKind(obj); ok {
+			crds[gk] = i
+		}
+	}
+	// deps[i] is the indexes of the objects which objs[i] depends on
+	deps := make([]map[int]bool, len(b.objs))
+	for i, obj := range b.objs {
+		deps[i] = make(map[int]bool)
+		for _, dep := range b.deps[obj] {
+			if j, ok := index[dep]; ok && j != i {
+				deps[i][j] = true
+			}
+		}
+		if accessor, err := meta.Accessor(obj); err == nil && accessor.GetNamespace() != "" {
+			if j, ok := namespaces[accessor.GetNamespace()]; ok && j != i {
+				deps[i][j] = true
+			}
+		}
+		if j, ok := crds[obj.GetObjectKind().GroupVersionKind().GroupKind()]; ok && j != i {
+			deps[i][j] = true
+		}
+	}
+	var (
+		stages [][]runtime.Object
+		done   = make([]bool, len(b.objs))
+		left   = len(b.objs)
+	)
+	for left > 0 {
+		var stage []int
+		for i := range b.objs {
+			if !done[i] && allDone(deps[i], done) {
+				stage = append(stage, i)
+			}
+		}
+		if len(stage) == 0 {
+			var cyclic []string
+			for i, obj := range b.objs {
+				if !done[i] {
+					cyclic = append(cyclic, objectName(obj))
+				}
+			}
+			return nil, fmt.Errorf("Bundle dependencies are cyclic among:%s", strings.Join(cyclic, ","))
+		}
+		objs := make([]runtime.Object, 0, len(stage))
+		for _, i := range stage {
+			done[i] = true
+			objs = append(objs, b.objs[i])
+		}
+		left -= len(stage)
+		stages = append(stages, objs)
+	}
+	return stages, nil
+}
+
+func allDone(deps map[int]bool, done []bool) bool {
+	for j := range deps {
+		if !done[j] {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply apply all objects of Bundle on Kubernetes stage by stage in order of Stages(),
+// every object is applied by server-side apply of one Client,so the objects are created when they don't exist
+// and only the fields set in them are changed when they exist.
+// when waitReady is true,the next stage is applied after all objects of the stage are ready,
+// eg: Deployment is available,CustomResourceDefinition is established,the timeout is controlled by ctx.
+// the cluster registered by RegisterK8sClient() is used.
+func (b *Bundle) Apply(ctx context.Context, waitReady bool) error {
+	if len(b.builders) > 0 {
+		return errors.New("Bundle Apply err,the builders are not finished,you should call FinishAll() first")
+	}
+	stages, err := b.Stages()
+	if err != nil {
+		return err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return err
+	}
+	// one Client for all objects,so the discovery is done once
+	applier, err := NewClient(client, nil)
+	if err != nil {
+		return err
+	}
+	for index, stage := range stages {
+		for _, obj := range stage {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if _, err := applier.Apply(ctx, obj); err != nil {
+				return fmt.Errorf("Bundle Apply stage %d %s failed:%v", index, objectName(obj), err)
+			}
+		}
+		if !waitReady || index == len(stages)-1 {
+			continue
+		}
+		for _, obj := range stage {
+			if err := waitObjectReady(ctx, applier, obj); err != nil {
+				return fmt.Errorf("Bundle Apply stage %d %s is not ready:%v", index, objectName(obj), err)
+			}
+		}
+	}
+	return nil
+}
+
+// getDynamicInterface get dynamic client by RegisterK8sClient() config
+func getDynamicInterface() (dynamic.Interface, error) {
+	config := getClientConfig()
+	if config.Host == "" {
+		return nil, errors.New("get kubernetes dynamic client error,Because Host is empty,you can call function RegisterK8sClient() register")
+	}
+	restConfig := &rest.Config{Host: config.Host}
+	if ViaTLS(config.CAData, config.CertData, config.KeyData) {
+		restConfig.TLSClientConfig = rest.TLSClientConfig{CAData: config.CAData, CertData: config.CertData, KeyData: config.KeyData}
+	}
+	return dynamic.NewForConfig(withRateLimit(restConfig))
+}
+
+// waitObjectReady wait until the object is ready,the objects without readiness are ready at once
+func waitObjectReady(ctx context.Context, client *Client, obj runtime.Object) (err error) {
+	ctx, done := observeObject(ctx, ObserveWait, obj)
+	defer func() { done(err) }()
+	ticker := time.NewTicker(ReadyPollInterval)
+	defer ticker.Stop()
+	for {
+		ready, err := objectReady(ctx, client, obj)
+		if err != nil {
+			return err
+		}
+		if ready {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// objectReady check the object on Kubernetes is ready by its health,
+// an error is returned when it is failed,so it is not waited any more.
+func objectReady(ctx context.Context, client *Client, obj runtime.Object) (bool, error) {
+	health := objectHealth(ctx, client, obj)
+	switch health.Status {
+	case HealthCurrent:
+		return true, nil
+	case HealthFailed, HealthUnknown:
+		return false, errors.New(health.Message)
+	}
+	return false, nil
+}
+
+// crdGroupKind get the group and kind of the custom resources defined by the CustomResourceDefinition,
+// ok is false when the object is not CustomResourceDefinition.
+func crdGroupKind(obj runtime.Object) (gk schema.GroupKind, ok bool) {
+	if obj.GetObjectKind().GroupVersionKind().Kind != "CustomResourceDefinition" {
+		return gk, false
+	}
+	content, err := unstructuredContent(obj)
+	if err != nil {
+		return gk, false
+	}
+	gk.Group, _, _ = unstructured.NestedString(content, "spec", "group")
+	gk.Kind, _, _ = unstructured.NestedString(content, "spec", "names", "kind")
+	return gk, gk.Kind != ""
+}
+
+// unstructuredContent get the content of the object as unstructured,
+// the content of *unstructured.Unstructured is copied,eg: the object of CustomResource.
+func unstructuredContent(obj runtime.Object) (map[string]interface{}, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u.DeepCopy().Object, nil
+	}
+	return runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+}
+
+// objectName get kind/namespace/name of the object for errors
+func objectName(obj runtime.Object) string {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return objectKind(obj)
+	}
+	if accessor.GetNamespace() == "" {
+		return objectKind(obj) + "/" + accessor.GetName()
+	}
+	return objectKind(obj) + "/" + accessor.GetNamespace() + "/" + accessor.GetName()
+}
@@ -0,0 +1,204 @@
+package beku
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/dynamic"
+)
+
+// resourceClient get,create,update and delete one kind of resource object in one namespace by the dynamic client of Client,
+// the objects got from Kubernetes are converted into the type of the object it is created for,eg: *appsv1.Deployment.
+type resourceClient struct {
+	resource dynamic.ResourceInterface
+	// typ is the struct type of the objects,nil when they are unstructured
+	typ reflect.Type
+	// fieldManager and force are the server-side apply options of Client
+	fieldManager string
+	force        bool
+}
+
+// applied is the object changed by ApplyAtomic(),pre is the object before it is changed,nil when it is created.
+type applied struct {
+	obj    runtime.Object
+	pre    runtime.Object
+	client *resourceClient
+}
+
+// ApplyAtomic apply all objects of Bundle like Apply() and wait all of them are ready,
+// when any apply or wait is failed,the changed objects are rolled back in reverse order:
+// the updated objects are restored to the objects before ApplyAtomic(), the created objects are deleted.
+// client is the Client of Kubernetes,the registered cluster is used when it is nil,the rollback is not canceled by ctx.
+// the returned error include the rollback errors, the objects failed to roll back need to be fixed manually.
+func (b *Bundle) ApplyAtomic(ctx context.Context, client *Client) error {
+	if len(b.builders) > 0 {
+		return errors.New("Bundle ApplyAtomic err,the builders are not finished,you should call FinishAll() first")
+	}
+	client, err := clientOrDefault(client)
+	if err != nil {
+		return err
+	}
+	stages, err := b.Stages()
+	if err != nil {
+		return err
+	}
+	var changes []applied
+	for index, stage := range stages {
+		for _, obj := range stage {
+			change, err := applyWithPreImage(ctx, client, obj)
+			if change != nil {
+				changes = append(changes, *change)
+			}
+			if err != nil {
+				return rollback(context.WithoutCancel(ctx), changes, fmt.Errorf("Bundle ApplyAtomic stage %d %s failed:%v", index, objectName(obj), err))
+			}
+		}
+		for _, obj := range stage {
+			if err := waitObjectReady(ctx, client, obj); err != nil {
+				return rollback(context.WithoutCancel(ctx), changes, fmt.Errorf("Bundle ApplyAtomic stage %d %s is not ready:%v", index, objectName(obj), err))
+			}
+		}
+	}
+	return nil
+}
+
+// applyWithPreImage get the object on Kubernetes as pre-image and then create or apply it by server-side apply,
+// change is nil when nothing is changed on Kubernetes.
+func applyWithPreImage(ctx context.Context, client *Client, obj runtime.Object) (change *applied, err error) {
+	ctx, done := observeObject(ctx, ObserveApply, obj)
+	defer func() { done(err) }()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := client.resourceClient(obj)
+	if err != nil {
+		return nil, err
+	}
+	pre, err := rc.get(ctx, accessor.GetName())
+	if apierrors.IsNotFound(err) {
+		if _, err := rc.create(ctx, obj); err != nil {
+			return nil, err
+		}
+		return &applied{obj: obj, client: rc}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// only the fields of obj are owned by the field manager,the fields of the other managers are kept
+	if _, err := rc.apply(ctx, obj, rc.force); err != nil {
+		return nil, err
+	}
+	return &applied{obj: obj, pre: pre, client: rc}, nil
+}
+
+// rollback roll back the changes in reverse order,and return cause with the rollback errors
+func rollback(ctx context.Context, changes []applied, cause error) error {
+	errs := []error{cause}
+	for i := len(changes) - 1; i >= 0; i-- {
+		change := changes[i]
+		accessor, _ := meta.Accessor(change.obj)
+		if change.pre == nil {
+			if err := change.client.delete(ctx, accessor.GetName(), metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("rollback delete %s failed:%v", objectName(change.obj), err))
+			}
+			continue
+		}
+		if err := restore(ctx, change); err != nil {
+			errs = append(errs, fmt.Errorf("rollback restore %s failed:%v", objectName(change.obj), err))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// restore apply the pre-image by the field manager of ApplyAtomic(),so the fields it added are removed,
+// the conflicts are overwritten,because the fields of the pre-image are the ones before ApplyAtomic().
+func restore(ctx context.Context, change applied) error {
+	_, err := change.client.apply(ctx, change.pre, true)
+	return err
+}
+
+// resourceClient get the client of the kind and namespace of the object
+func (c *Client) resourceClient(obj runtime.Object) (*resourceClient, error) {
+	_, resource, err := c.resource(obj)
+	if err != nil {
+		return nil, err
+	}
+	rc := &resourceClient{resource: resource, fieldManager: c.fieldManager, force: c.force}
+	if _, ok := obj.(*unstructured.Unstructured); !ok {
+		rc.typ = reflect.TypeOf(obj).Elem()
+	}
+	return rc, nil
+}
+
+func (rc *resourceClient) get(ctx context.Context, name string) (runtime.Object, error) {
+	return rc.typed(rc.resource.Get(ctx, name, metav1.GetOptions{}))
+}
+
+func (rc *resourceClient) create(ctx context.Context, obj runtime.Object) (runtime.Object, error) {
+	content, err := unstructuredContent(obj)
+	if err != nil {
+		return nil, err
+	}
+	return rc.typed(rc.resource.Create(ctx, &unstructured.Unstructured{Object: content}, metav1.CreateOptions{FieldManager: rc.fieldManager}))
+}
+
+func (rc *resourceClient) update(ctx context.Context, obj runtime.Object) (runtime.Object, error) {
+	content, err := unstructuredContent(obj)
+	if err != nil {
+		return nil, err
+	}
+	return rc.typed(rc.resource.Update(ctx, &unstructured.Unstructured{Object: content}, metav1.UpdateOptions{}))
+}
+
+// apply apply obj by server-side apply,force is true the conflicts with the other field managers are overwritten.
+// status,managedFields and resourceVersion are written by Kubernetes,they are removed from the apply configuration,
+// eg: the pre-image got from Kubernetes has them.
+func (rc *resourceClient) apply(ctx context.Context, obj runtime.Object, force bool) (runtime.Object, error) {
+	content, err := unstructuredContent(obj)
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{Object: content}
+	unstructured.RemoveNestedField(u.Object, "status")
+	u.SetManagedFields(nil)
+	u.SetResourceVersion("")
+	data, err := json.Marshal(u.Object)
+	if err != nil {
+		return nil, err
+	}
+	return rc.typed(rc.resource.Patch(ctx, u.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{FieldManager: rc.fieldManager, Force: &force}))
+}
+
+func (rc *resourceClient) delete(ctx context.Context, name string, options metav1.DeleteOptions) error {
+	return rc.resource.Delete(ctx, name, options)
+}
+
+// typed convert the object returned by dynamic client into the type of resourceClient
+func (rc *resourceClient) typed(u *unstructured.Unstructured, err error) (runtime.Object, error) {
+	if err != nil {
+		return nil, err
+	}
+	if rc.typ == nil {
+		return u, nil
+	}
+	obj := reflect.New(rc.typ).Interface().(runtime.Object)
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
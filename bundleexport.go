@@ -0,0 +1,157 @@
+package beku
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ToYAML translate all objects of Bundle into one multi-document yaml in order of Add(),
+// the documents are separated by '---',so it can be used by `kubectl apply -f` or committed into GitOps repository.
+func (b *Bundle) ToYAML() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := b.WriteYAML(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteYAML write the multi-document yaml of ToYAML() into w,eg: os.Stdout
+func (b *Bundle) WriteYAML(w io.Writer) error {
+	if err := b.exportable(); err != nil {
+		return err
+	}
+	yw := NewYAMLWriter(w)
+	for _, obj := range b.objs {
+		if err := yw.Write(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteYAMLTo write the objects and then the builders of Bundle into w as multi-document yaml,
+// unlike WriteYAML(),the builders are not required to be finished by FinishAll(),each of them is finished,
+// written and dropped one by one,so the finished objects are never held in memory together,eg: 50k manifests.
+// it stops at the first error,only the written builders are removed from Bundle,
+// so the failed builder and the rest are kept for FinishAll() or the next WriteYAMLTo().
+func (b *Bundle) WriteYAMLTo(w io.Writer) error {
+	if b.err != nil {
+		return b.err
+	}
+	yw := NewYAMLWriter(w)
+	for _, obj := range b.objs {
+		if err := yw.Write(obj); err != nil {
+			return err
+		}
+	}
+	written := 0
+	defer func() {
+		// the written builders are dropped,so they can be collected while the rest are written
+		b.builders = b.builders[written:]
+		if len(b.builders) == 0 {
+			b.builders = nil
+		}
+	}()
+	for index, builder := range b.builders {
+		_, done := observeBuilder(context.Background(), builder)
+		obj, err := builder.FinishObject()
+		done(err)
+		if err != nil {
+			return fmt.Errorf("builder[%d] %T: %v", index, builder, err)
+		}
+		rewriteObjectImages(obj, b.rewrites)
+		if err := yw.Write(obj); err != nil {
+			return err
+		}
+		b.builders[index] = nil
+		written++
+	}
+	return nil
+}
+
+// YAMLWriter write the objects into w as multi-document yaml one by one,the documents are separated by '---',
+// so the manifests of the bulk generation are streamed instead of held in memory,eg:
+//
+//	yw := beku.NewYAMLWriter(os.Stdout)
+//	for _, tenant := range tenants {
+//		dp := beku.AcquireDeployment()
+//		obj, err := dp.SetNamespaceAndName(tenant, "web").SetPodLabels(labels).SetContainer("web", image, 80).Finish()
+//		...
+//		err = yw.Write(obj)
+//		beku.ReleaseDeployment(dp)
+//	}
+type YAMLWriter struct {
+	w     io.Writer
+	count int
+}
+
+// NewYAMLWriter create YAMLWriter which writes into w
+func NewYAMLWriter(w io.Writer) *YAMLWriter { return &YAMLWriter{w: w} }
+
+// Write write obj as one yaml document
+func (yw *YAMLWriter) Write(obj runtime.Object) error {
+	byts, err := ToYAML(obj)
+	if err != nil {
+		return fmt.Errorf("YAMLWriter ToYAML %s err:%v", objectName(obj), err)
+	}
+	if yw.count > 0 {
+		if _, err := io.WriteString(yw.w, "---\n"); err != nil {
+			return err
+		}
+	}
+	if _, err := yw.w.Write(byts); err != nil {
+		return err
+	}
+	yw.count++
+	return nil
+}
+
+// Count get the number of the documents written
+func (yw *YAMLWriter) Count() int { return yw.count }
+
+// ToJSON translate all objects of Bundle into the json of v1 List in order of Add(),
+// `kubectl apply -f` applies the items of the List.
+func (b *Bundle) ToJSON() ([]byte, error) {
+	if err := b.exportable(); err != nil {
+		return nil, err
+	}
+	list := metav1.List{
+		TypeMeta: metav1.TypeMeta{Kind: "List", APIVersion: "v1"},
+		Items:    make([]runtime.RawExtension, 0, len(b.objs)),
+	}
+	for _, obj := range b.objs {
+		byts, err := ToJSON(obj)
+		if err != nil {
+			return nil, fmt.Errorf("Bundle ToJSON %s err:%v", objectName(obj), err)
+		}
+		list.Items = append(list.Items, runtime.RawExtension{Raw: byts})
+	}
+	byts, err := json.Marshal(list)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, byts, "", "  "); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// exportable check all builders of Bundle are finished,so the exported objects are complete.
+func (b *Bundle) exportable() error {
+	if b.err != nil {
+		return b.err
+	}
+	if len(b.builders) > 0 {
+		return errors.New("Bundle export err,the builders are not finished,you should call FinishAll() first")
+	}
+	return nil
+}
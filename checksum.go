@@ -0,0 +1,64 @@
+package beku
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// ConfigChecksumAnnotation is the Pod template annotation of the checksum of the ConfigMaps and Secrets used by the Pod,
+// when the config is changed,the checksum is changed and the Pods are restarted by rolling update.
+const ConfigChecksumAnnotation = "checksum/config"
+
+// configChecksum get sha256 of the data of the ConfigMaps and Secrets,
+// the keys are sorted,so the checksum only changes when the content is changed.
+func configChecksum(cms []*ConfigMap, secrets []*Secret) (string, error) {
+	hash := sha256.New()
+	for index, cm := range cms {
+		if cm == nil {
+			return "", fieldErrorf("AnnotateConfigChecksum", "cms[%d] is nil", index)
+		}
+		if cm.err != nil {
+			return "", fieldErrorf("AnnotateConfigChecksum", "ConfigMap %s:%v", cm.cm.GetName(), cm.err)
+		}
+		fmt.Fprintf(hash, "ConfigMap/%s/%s\n", cm.cm.GetNamespace(), cm.cm.GetName())
+		writeStrings(hash, cm.cm.Data)
+		writeBytes(hash, cm.cm.BinaryData)
+	}
+	for index, secret := range secrets {
+		if secret == nil {
+			return "", fieldErrorf("AnnotateConfigChecksum", "secrets[%d] is nil", index)
+		}
+		if secret.err != nil {
+			return "", fieldErrorf("AnnotateConfigChecksum", "Secret %s:%v", secret.sc.GetName(), secret.err)
+		}
+		fmt.Fprintf(hash, "Secret/%s/%s\n", secret.sc.GetNamespace(), secret.sc.GetName())
+		writeBytes(hash, secret.sc.Data)
+		writeStrings(hash, secret.sc.StringData)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func writeStrings(w io.Writer, data map[string]string) {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s=%q\n", key, data[key])
+	}
+}
+
+func writeBytes(w io.Writer, data map[string][]byte) {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s=%x\n", key, data[key])
+	}
+}
@@ -0,0 +1,238 @@
+package beku
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/restmapper"
+)
+
+// DefaultFieldManager is the field manager of server-side apply of Client
+const DefaultFieldManager = "beku"
+
+// Client apply the objects produced by Finish() or FinishObject() of any builder on Kubernetes,
+// the resource of the object is found by discovery and it is operated by dynamic client,
+// so the clientset and typed interface of every kind don't need to be wired.
+// the discovery is cached by Client,so reuse one Client for many objects.
+type Client struct {
+	kube    kubernetes.Interface
+	dynamic dynamic.Interface
+	// mapper map the kinds to the resources,the discovery is done on the first object and refreshed on unknown kind
+	mapper       *restmapper.DeferredDiscoveryRESTMapper
+	fieldManager string
+	force        bool
+}
+
+// NewClient create Client by the clientset and dynamic client,
+// the nil one is created by RegisterK8sClient() config,so NewClient(nil, nil) uses the registered cluster.
+func NewClient(kube kubernetes.Interface, dynamicClient dynamic.Interface) (*Client, error) {
+	kube, err := clientOrRegistered(kube)
+	if err != nil {
+		return nil, err
+	}
+	if dynamicClient == nil {
+		if dynamicClient, err = getDynamicInterface(); err != nil {
+			return nil, err
+		}
+	}
+	return &Client{
+		kube:         kube,
+		dynamic:      dynamicClient,
+		mapper:       restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(kube.Discovery())),
+		fieldManager: DefaultFieldManager,
+	}, nil
+}
+
+// clientOrDefault get client,the Client of the cluster registered by RegisterK8sClient() is created when it is nil
+func clientOrDefault(client *Client) (*Client, error) {
+	if client != nil {
+		return client, nil
+	}
+	return NewClient(nil, nil)
+}
+
+// WithFieldManager set the field manager of server-side apply,default is DefaultFieldManager,
+// force is true the conflicts with other field managers are overwritten,eg: the replicas changed by kubectl scale.
+func (c *Client) WithFieldManager(fieldManager string, force bool) *Client {
+	if verifyString(fieldManager) {
+		c.fieldManager = fieldManager
+	}
+	c.force = force
+	return c
+}
+
+// Apply apply the object by server-side apply,it is created when it does not exist,
+// only the fields set in the object are owned by the field manager,the other fields on Kubernetes are kept.
+func (c *Client) Apply(ctx context.Context, obj runtime.Object) (result *unstructured.Unstructured, err error) {
+	ctx, done := observeObject(ctx, ObserveApply, obj)
+	defer func() { done(err) }()
+	return c.apply(ctx, obj, nil)
+}
+
+// DryRunApply apply the object by server-side apply with dryRun=All,the object is checked by the schema,
+// admission webhooks and quota of Kubernetes but it is not persisted,the object which would be applied is returned.
+// the rejected fields are returned as FieldError,so they can be split by FieldErrors(),
+// the other errors are returned as they are,eg: the namespace is not found or the apply is forbidden.
+func (c *Client) DryRunApply(ctx context.Context, obj runtime.Object) (*unstructured.Unstructured, error) {
+	result, err := c.apply(ctx, obj, []string{metav1.DryRunAll})
+	if err != nil {
+		return nil, serverFieldErrors(objectKind(obj), err)
+	}
+	return result, nil
+}
+
+// ValidateAgainstCluster finish the builder and check it by DryRunApply(),
+// so the rejections of CRD schema,webhooks and quota are found before the real apply.
+func (c *Client) ValidateAgainstCluster(ctx context.Context, builder Builder) error {
+	obj, err := builder.FinishObject()
+	if err != nil {
+		return err
+	}
+	_, err = c.DryRunApply(ctx, obj)
+	return err
+}
+
+// apply apply the object by server-side apply,dryRun is nil or []string{metav1.DryRunAll}
+func (c *Client) apply(ctx context.Context, obj runtime.Object, dryRun []string) (*unstructured.Unstructured, error) {
+	u, resource, err := c.resource(obj)
+	if err != nil {
+		return nil, err
+	}
+	// the resourceVersion and managedFields are not allowed in the apply configuration
+	u.SetResourceVersion("")
+	u.SetManagedFields(nil)
+	data, err := json.Marshal(u.Object)
+	if err != nil {
+		return nil, err
+	}
+	force := c.force
+	return resource.Patch(ctx, u.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{FieldManager: c.fieldManager, Force: &force, DryRun: dryRun})
+}
+
+// serverFieldErrors turn the causes of the error returned by Kubernetes into FieldError of the kind,
+// eg: Deployment.spec.template.spec.containers[0].image,the error without causes is returned as it is,
+// so it can be checked by k8s.io/apimachinery/pkg/api/errors,eg: IsNotFound() and IsForbidden().
+func serverFieldErrors(kind string, err error) error {
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) {
+		return err
+	}
+	details := status.Status().Details
+	if details == nil || len(details.Causes) == 0 {
+		return err
+	}
+	var errs error
+	for _, cause := range details.Causes {
+		field := kind
+		if cause.Field != "" {
+			field = kind + "." + cause.Field
+		}
+		errs = appendError(errs, FieldError{Field: field, Message: cause.Message})
+	}
+	return errs
+}
+
+// Create create the object,an error is returned when it exists
+func (c *Client) Create(ctx context.Context, obj runtime.Object) (*unstructured.Unstructured, error) {
+	u, resource, err := c.resource(obj)
+	if err != nil {
+		return nil, err
+	}
+	return resource.Create(ctx, u, metav1.CreateOptions{FieldManager: c.fieldManager})
+}
+
+// Update replace the object on Kubernetes by obj,the resourceVersion of the existing one is used when obj has none,
+// so the fields which are not in obj are removed,use Apply() to keep them.
+func (c *Client) Update(ctx context.Context, obj runtime.Object) (*unstructured.Unstructured, error) {
+	u, resource, err := c.resource(obj)
+	if err != nil {
+		return nil, err
+	}
+	if u.GetResourceVersion() == "" {
+		existing, err := resource.Get(ctx, u.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		u.SetResourceVersion(existing.GetResourceVersion())
+	}
+	return resource.Update(ctx, u, metav1.UpdateOptions{FieldManager: c.fieldManager})
+}
+
+// Get get the object on Kubernetes by the kind,namespace and name of obj
+func (c *Client) Get(ctx context.Context, obj runtime.Object) (*unstructured.Unstructured, error) {
+	u, resource, err := c.resource(obj)
+	if err != nil {
+		return nil, err
+	}
+	return resource.Get(ctx, u.GetName(), metav1.GetOptions{})
+}
+
+// Delete delete the object on Kubernetes by the kind,namespace and name of obj,
+// opts[0] sets the propagation policy and grace period.
+func (c *Client) Delete(ctx context.Context, obj runtime.Object, opts ...DeleteOptions) error {
+	u, resource, err := c.resource(obj)
+	if err != nil {
+		return err
+	}
+	options, err := newDeleteOptions(u.GetKind(), u.GetName(), opts)
+	if err != nil {
+		return err
+	}
+	return resource.Delete(ctx, u.GetName(), *options)
+}
+
+// resource convert obj into unstructured and get the dynamic client of its resource
+func (c *Client) resource(obj runtime.Object) (*unstructured.Unstructured, dynamic.ResourceInterface, error) {
+	if obj == nil {
+		return nil, nil, errors.New("Client err,object is not allowed to be nil")
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !verifyString(accessor.GetName()) {
+		return nil, nil, fmt.Errorf("Client err,the name of %s is not allowed to be empty", objectKind(obj))
+	}
+	content, err := unstructuredContent(obj)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := &unstructured.Unstructured{Object: content}
+	resource, err := c.resourceOf(u)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Client err,%s:%v", objectName(obj), err)
+	}
+	return u, resource, nil
+}
+
+// resourceOf get the dynamic client of the resource of the object,the resource is found by the cached discovery,
+// the discovery is refreshed once when the kind is unknown,eg: the CustomResourceDefinition is just created.
+func (c *Client) resourceOf(u *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+	gvk := u.GroupVersionKind()
+	if gvk.Kind == "" {
+		return nil, errors.New("kind is empty,the object should be finished")
+	}
+	mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		c.mapper.Reset()
+		mapping, err = c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		return c.dynamic.Resource(mapping.Resource).Namespace(u.GetNamespace()), nil
+	}
+	return c.dynamic.Resource(mapping.Resource), nil
+}
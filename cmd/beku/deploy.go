@@ -0,0 +1,252 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/yulibaozi/beku"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// deploySpec is the declarative spec of 'beku deploy -f',the flags set on command line override it.
+//
+//	name: nginx
+//	namespace: web
+//	image: nginx:1.15
+//	port: 80
+//	replicas: 2
+//	env:
+//	  TZ: Asia/Shanghai
+//	expose: true
+//	serviceType: NodePort
+type deploySpec struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace"`
+	Image       string            `json:"image"`
+	Port        int32             `json:"port"`
+	Replicas    int32             `json:"replicas"`
+	Env         map[string]string `json:"env"`
+	Expose      bool              `json:"expose"`
+	ServiceType string            `json:"serviceType"`
+}
+
+// envFlag is repeatable KEY=VALUE flag
+type envFlag map[string]string
+
+func (e envFlag) String() string { return fmt.Sprint(map[string]string(e)) }
+
+func (e envFlag) Set(value string) error {
+	kv := strings.SplitN(value, "=", 2)
+	if len(kv) != 2 {
+		return fmt.Errorf("env %q is not KEY=VALUE", value)
+	}
+	e[kv[0]] = kv[1]
+	return nil
+}
+
+func runDeploy(args []string) error {
+	var (
+		spec   deploySpec
+		client clientFlags
+		env    = envFlag{}
+		fs     = flag.NewFlagSet("deploy", flag.ExitOnError)
+	)
+	file := fs.String("f", "", "deploy spec file,yaml or json")
+	fs.StringVar(&spec.Name, "name", "", "name of Deployment and Service,default is the image name")
+	fs.StringVar(&spec.Namespace, "namespace", "", "namespace,default is 'default'")
+	fs.StringVar(&spec.Image, "image", "", "container image")
+	fs.Var(fsInt32{&spec.Port}, "port", "container port")
+	fs.Var(fsInt32{&spec.Replicas}, "replicas", "replicas of Deployment,default 1")
+	fs.Var(env, "env", "container environment KEY=VALUE,can be repeated")
+	fs.BoolVar(&spec.Expose, "expose", false, "create Service for the Deployment")
+	fs.StringVar(&spec.ServiceType, "type", "", "Service type: ClusterIP,NodePort,LoadBalancer,default ClusterIP")
+	output := fs.String("o", "yaml", "output format: yaml or json")
+	apply := fs.Bool("apply", false, "apply the manifests on Kubernetes instead of printing them")
+	client.register(fs)
+	fs.Parse(args)
+
+	if *file != "" {
+		if err := mergeSpecFile(*file, &spec, fs); err != nil {
+			return err
+		}
+	}
+	for k, v := range env {
+		if spec.Env == nil {
+			spec.Env = map[string]string{}
+		}
+		spec.Env[k] = v
+	}
+	dp, svc, err := buildDeploy(spec)
+	if err != nil {
+		return err
+	}
+	if *apply {
+		return applyDeploy(&client, dp, svc)
+	}
+	return printObjects(os.Stdout, *output, dp, svc)
+}
+
+// fsInt32 is int32 flag
+type fsInt32 struct{ p *int32 }
+
+func (f fsInt32) String() string {
+	if f.p == nil {
+		return "0"
+	}
+	return fmt.Sprint(*f.p)
+}
+
+func (f fsInt32) Set(value string) error {
+	v, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return err
+	}
+	*f.p = int32(v)
+	return nil
+}
+
+// mergeSpecFile read the spec file into spec,the flags set on command line are kept.
+func mergeSpecFile(name string, spec *deploySpec, fs *flag.FlagSet) error {
+	data, err := readInput(name)
+	if err != nil {
+		return err
+	}
+	var fileSpec deploySpec
+	if err := yaml.Unmarshal(data, &fileSpec); err != nil {
+		return fmt.Errorf("read spec %s failed:%v", name, err)
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if set["name"] {
+		fileSpec.Name = spec.Name
+	}
+	if set["namespace"] {
+		fileSpec.Namespace = spec.Namespace
+	}
+	if set["image"] {
+		fileSpec.Image = spec.Image
+	}
+	if set["port"] {
+		fileSpec.Port = spec.Port
+	}
+	if set["replicas"] {
+		fileSpec.Replicas = spec.Replicas
+	}
+	if set["expose"] {
+		fileSpec.Expose = spec.Expose
+	}
+	if set["type"] {
+		fileSpec.ServiceType = spec.ServiceType
+	}
+	*spec = fileSpec
+	return nil
+}
+
+// buildDeploy create Deployment and Service of the spec by beku builders,
+// svc is nil when the spec is not exposed.
+func buildDeploy(spec deploySpec) (*appsv1.Deployment, *corev1.Service, error) {
+	if spec.Image == "" {
+		return nil, nil, errors.New("deploy err,image is not allowed to be empty")
+	}
+	if spec.Name == "" {
+		spec.Name = imageName(spec.Image)
+	}
+	if spec.Namespace == "" {
+		spec.Namespace = "default"
+	}
+	labels := map[string]string{"app": spec.Name}
+	builder := beku.NewDeployment().SetNamespaceAndName(spec.Namespace, spec.Name).
+		SetSelector(labels).SetContainer(spec.Name, spec.Image, spec.Port)
+	if spec.Replicas > 0 {
+		builder.SetReplicas(spec.Replicas)
+	}
+	if len(spec.Env) > 0 {
+		builder.SetEnvs(spec.Env)
+	}
+	dp, err := builder.Finish()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !spec.Expose {
+		return dp, nil, nil
+	}
+	sty := beku.ServiceTypeClusterIP
+	if spec.ServiceType != "" {
+		sty = beku.ServiceType(spec.ServiceType)
+	}
+	svc, err := beku.DeploymentToSvc(dp, sty)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dp, svc, nil
+}
+
+// imageName get the name of the image without registry and tag,eg: nginx of docker.io/library/nginx:1.15
+func imageName(image string) string {
+	if index := strings.LastIndex(image, "/"); index >= 0 {
+		image = image[index+1:]
+	}
+	if index := strings.IndexAny(image, ":@"); index >= 0 {
+		image = image[:index]
+	}
+	return image
+}
+
+func applyDeploy(client *clientFlags, dp *appsv1.Deployment, svc *corev1.Service) error {
+	if err := client.registerClient(); err != nil {
+		return err
+	}
+	dp, err := beku.NewDeployment().Replace(dp).Apply()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "deployment %s/%s applied\n", dp.GetNamespace(), dp.GetName())
+	if svc == nil {
+		return nil
+	}
+	if svc, err = beku.NewSvc().Replace(svc).Apply(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "service %s/%s applied\n", svc.GetNamespace(), svc.GetName())
+	return nil
+}
+
+// printObjects print the objects in yaml documents separated by '---' or json objects one per line
+func printObjects(w io.Writer, format string, dp *appsv1.Deployment, svc *corev1.Service) error {
+	objs := []interface{}{dp}
+	if svc != nil {
+		objs = append(objs, svc)
+	}
+	for index, obj := range objs {
+		var (
+			data []byte
+			err  error
+		)
+		switch format {
+		case "yaml":
+			if index > 0 {
+				fmt.Fprintln(w, "---")
+			}
+			data, err = beku.ToYAML(obj)
+		case "json":
+			data, err = beku.ToJSON(obj)
+			data = append(data, '\n')
+		default:
+			return fmt.Errorf("output format %q is not supported,yaml or json", format)
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
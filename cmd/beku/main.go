@@ -0,0 +1,179 @@
+// Command beku generates and applies Kubernetes manifests by beku builders,
+// so users who don't write Go can use the same validated pipeline.
+//
+//	beku deploy -image nginx -port 80 -expose
+//	beku deploy -f spec.yaml -apply -context production
+//	beku validate -f manifests.yaml
+//	beku schema Deployment > deployment.schema.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/yulibaozi/beku"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const usage = `beku generates and applies Kubernetes manifests.
+
+Usage:
+  beku deploy   [flags]   create Deployment (and Service with -expose) from flags or a spec file
+  beku validate -f FILE   validate every document of the manifest file, '-' is stdin
+  beku schema   KIND [APIVERSION]  print JSON Schema of the manifest of KIND, no KIND lists the kinds
+
+Run 'beku <command> -h' for the flags of the command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "deploy":
+		err = runDeploy(os.Args[2:])
+	case "validate":
+		err = runValidate(os.Args[2:])
+	case "schema":
+		err = runSchema(os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(os.Stdout, usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "beku: unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "beku: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// clientFlags is Kubernetes apiServer flags of the commands which apply manifests,
+// the config is loaded like kubectl: -kubeconfig,$KUBECONFIG or ~/.kube/config,the other flags override it.
+type clientFlags struct {
+	kubeconfig string
+	overrides  clientcmd.ConfigOverrides
+}
+
+func (c *clientFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.kubeconfig, "kubeconfig", "", "kubeconfig file,default $KUBECONFIG or ~/.kube/config")
+	fs.StringVar(&c.overrides.CurrentContext, "context", "", "context of kubeconfig,default the current context")
+	fs.StringVar(&c.overrides.ClusterInfo.Server, "server", os.Getenv("BEKU_SERVER"), "Kubernetes apiServer address,it overrides kubeconfig,default $BEKU_SERVER")
+	fs.StringVar(&c.overrides.ClusterInfo.CertificateAuthority, "ca", "", "CA certificate file of apiServer")
+	fs.StringVar(&c.overrides.AuthInfo.ClientCertificate, "cert", "", "client certificate file")
+	fs.StringVar(&c.overrides.AuthInfo.ClientKey, "key", "", "client key file")
+	fs.StringVar(&c.overrides.AuthInfo.Token, "token", "", "bearer token of apiServer")
+}
+
+// restConfig load apiServer config by the flags
+func (c *clientFlags) restConfig() (*rest.Config, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = c.kubeconfig
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &c.overrides).ClientConfig()
+}
+
+// registerClient register apiServer client on beku by the flags
+func (c *clientFlags) registerClient() error {
+	config, err := c.restConfig()
+	if err != nil {
+		return err
+	}
+	cs, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	beku.RegisterClientset(cs)
+	return nil
+}
+
+// readInput read the file, '-' is stdin
+func readInput(name string) ([]byte, error) {
+	if name == "-" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+	return ioutil.ReadFile(name)
+}
+
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	file := fs.String("f", "-", "manifest file,'-' is stdin")
+	fs.Parse(args)
+	in := os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	count := 0
+	err := beku.StreamManifests(in, func(builder beku.Builder) error {
+		count++
+		result := beku.Check(builder)
+		if err := result.Err(); err != nil {
+			return fmt.Errorf("document[%d] %T: %v", count-1, builder, err)
+		}
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(os.Stderr, "warning: document[%d] %T: %s\n", count-1, builder, warning)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stdout, "%d documents are valid\n", count)
+	return nil
+}
+
+func runSchema(args []string) error {
+	if len(args) == 0 {
+		for _, gvk := range beku.SchemaKinds() {
+			fmt.Fprintln(os.Stdout, gvk.Kind, gvk.GroupVersion().String())
+		}
+		return nil
+	}
+	gvk, err := schemaKind(args)
+	if err != nil {
+		return err
+	}
+	schema, err := beku.SchemaForKind(gvk)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(os.Stdout, "%s\n", schema)
+	return err
+}
+
+// schemaKind get the kind of KIND [APIVERSION],APIVERSION can be omitted when KIND is in only one apiVersion
+func schemaKind(args []string) (schema.GroupVersionKind, error) {
+	if len(args) > 1 {
+		return schema.FromAPIVersionAndKind(args[1], args[0]), nil
+	}
+	var found []schema.GroupVersionKind
+	for _, gvk := range beku.SchemaKinds() {
+		if gvk.Kind == args[0] {
+			found = append(found, gvk)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return schema.GroupVersionKind{}, fmt.Errorf("kind %s is not supported", args[0])
+	case 1:
+		return found[0], nil
+	}
+	apiVersions := make([]string, 0, len(found))
+	for _, gvk := range found {
+		apiVersions = append(apiVersions, gvk.GroupVersion().String())
+	}
+	return schema.GroupVersionKind{}, fmt.Errorf("kind %s is in %s,APIVERSION is required", args[0], strings.Join(apiVersions, ","))
+}
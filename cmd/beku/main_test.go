@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const kubeconfig = `apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: dev
+  cluster: {server: "https://dev.local:6443"}
+- name: prod
+  cluster: {server: "https://prod.local:6443"}
+users:
+- name: dev
+  user: {token: dev-token}
+- name: prod
+  user: {token: prod-token}
+contexts:
+- name: dev
+  context: {cluster: dev, user: dev}
+- name: prod
+  context: {cluster: prod, user: prod}
+`
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func Test_FsInt32(t *testing.T) {
+	var v int32
+	if err := (fsInt32{&v}).Set("8080"); err != nil || v != 8080 {
+		t.Fatalf("8080 should be set,got %d,%v", v, err)
+	}
+	for _, value := range []string{"80abc", "2147483648", "", "1.5"} {
+		if err := (fsInt32{&v}).Set(value); err == nil {
+			t.Errorf("%q is not int32,it should be error", value)
+		}
+	}
+}
+
+func Test_ClientFlags(t *testing.T) {
+	path := writeFile(t, "config", kubeconfig)
+	for _, c := range []struct {
+		args        []string
+		host, token string
+	}{
+		{[]string{"-kubeconfig", path}, "https://dev.local:6443", "dev-token"},
+		{[]string{"-kubeconfig", path, "-context", "prod"}, "https://prod.local:6443", "prod-token"},
+		{[]string{"-kubeconfig", path, "-server", "https://other.local:6443", "-token", "other-token"}, "https://other.local:6443", "other-token"},
+	} {
+		var client clientFlags
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		client.register(fs)
+		if err := fs.Parse(c.args); err != nil {
+			t.Fatal(err)
+		}
+		config, err := client.restConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.Host != c.host || config.BearerToken != c.token {
+			t.Errorf("%v: expect %s %s,got %s %s", c.args, c.host, c.token, config.Host, config.BearerToken)
+		}
+	}
+	var client clientFlags
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	client.register(fs)
+	if err := fs.Parse([]string{"-kubeconfig", path, "-context", "missing"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.restConfig(); err == nil {
+		t.Fatal("the missing context should be error")
+	}
+}
+
+func Test_BuildDeploy(t *testing.T) {
+	dp, svc, err := buildDeploy(deploySpec{Image: "docker.io/library/nginx:1.15", Port: 80, Replicas: 2,
+		Env: map[string]string{"TZ": "UTC"}, Expose: true, ServiceType: "NodePort"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dp.Name != "nginx" || dp.Namespace != "default" || *dp.Spec.Replicas != 2 || dp.Spec.Template.Spec.Containers[0].Env[0].Name != "TZ" {
+		t.Fatalf("unexpected deployment:%v", dp)
+	}
+	if svc == nil || svc.Spec.Type != "NodePort" || svc.Spec.Ports[0].Port != 80 {
+		t.Fatalf("unexpected service:%v", svc)
+	}
+	if _, _, err := buildDeploy(deploySpec{Name: "nginx"}); err == nil {
+		t.Fatal("the spec without image should be error")
+	}
+}
+
+func Test_MergeSpecFile(t *testing.T) {
+	path := writeFile(t, "spec.yaml", "name: web\nimage: nginx:1.15\nport: 80\nreplicas: 3\n")
+	var spec deploySpec
+	fs := flag.NewFlagSet("deploy", flag.ContinueOnError)
+	fs.Var(fsInt32{&spec.Replicas}, "replicas", "")
+	fs.StringVar(&spec.Name, "name", "", "")
+	if err := fs.Parse([]string{"-replicas", "5"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := mergeSpecFile(path, &spec, fs); err != nil {
+		t.Fatal(err)
+	}
+	if spec.Name != "web" || spec.Image != "nginx:1.15" || spec.Port != 80 || spec.Replicas != 5 {
+		t.Fatalf("the flags set on command line should override the spec file:%+v", spec)
+	}
+}
+
+func Test_PrintObjects(t *testing.T) {
+	dp, svc, err := buildDeploy(deploySpec{Image: "nginx", Port: 80, Expose: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := printObjects(&buf, "yaml", dp, svc); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(buf.String(), "---\n") != 1 || !strings.Contains(buf.String(), "kind: Service") {
+		t.Fatalf("unexpected yaml:\n%s", buf.String())
+	}
+	buf.Reset()
+	if err := printObjects(&buf, "json", dp, svc); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(buf.String(), "\n") != 2 {
+		t.Fatalf("expect one json object per line:\n%s", buf.String())
+	}
+	if err := printObjects(&buf, "xml", dp, nil); err == nil {
+		t.Fatal("xml format should be error")
+	}
+}
+
+func Test_RunValidate(t *testing.T) {
+	valid := writeFile(t, "valid.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata: {name: app, namespace: web}\ndata: {mode: prod}\n")
+	if err := runValidate([]string{"-f", valid}); err != nil {
+		t.Fatal(err)
+	}
+	invalid := writeFile(t, "invalid.yaml", "apiVersion: apps/v1\nkind: Deployment\nmetadata: {name: app, namespace: web}\n")
+	if err := runValidate([]string{"-f", invalid}); err == nil {
+		t.Fatal("Deployment without container should be invalid")
+	}
+}
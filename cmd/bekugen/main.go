@@ -0,0 +1,52 @@
+// Command bekugen converts Kubernetes manifests into the Go code of beku builders.
+//
+//	bekugen -package deploy -o zz_manifests.go manifests.yaml
+//	cat manifests.yaml | bekugen
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/yulibaozi/beku/bekugen"
+)
+
+func main() {
+	pkg := flag.String("package", "main", "package name of the generated file")
+	output := flag.String("o", "", "output file,default is stdout")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: bekugen [flags] [manifest file]\n\nThe manifest is read from stdin when no file is given.\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if err := run(*pkg, *output, flag.Arg(0)); err != nil {
+		fmt.Fprintf(os.Stderr, "bekugen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(pkg, output, input string) error {
+	var (
+		manifest []byte
+		err      error
+	)
+	if input == "" || input == "-" {
+		manifest, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		manifest, err = ioutil.ReadFile(input)
+	}
+	if err != nil {
+		return err
+	}
+	code, err := bekugen.GenerateFile(pkg, manifest)
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		_, err = os.Stdout.WriteString(code)
+		return err
+	}
+	return ioutil.WriteFile(output, []byte(code), 0644)
+}
@@ -1,40 +1,71 @@
 package beku
 
 import (
-	"encoding/json"
-	"errors"
+	"context"
+	"fmt"
 
-	"github.com/ghodss/yaml"
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 )
 
 // ConfigMap include Kubernetes resource object ConfigMap(cm) and error.
 type ConfigMap struct {
-	cm  *v1.ConfigMap
-	err error
+	cm        *v1.ConfigMap
+	err       error
+	verifiers []func(*v1.ConfigMap) error
 }
 
 // NewCM create ConfigMap(cm) and chain function call begin with this function.
 func NewCM() *ConfigMap { return &ConfigMap{cm: &v1.ConfigMap{}} }
 
+// ConfigMapFrom create ConfigMap from the existing ConfigMap,eg: the one got by client,
+// so it can be changed by the chain function and released again,
+// cm is deep copied and the fields which are not changed by the chain function are kept.
+func ConfigMapFrom(cm *v1.ConfigMap) *ConfigMap {
+	if cm == nil {
+		return NewCM()
+	}
+	return &ConfigMap{cm: cm.DeepCopy()}
+}
+
 // Finish chain function call end with this function
 // return real ConfigMap(really ConfigMap is Kubernetes resource object ConfigMap(cm) and error)
 // In the function, it will check necessary parameters、input the default field。
 func (obj *ConfigMap) Finish() (cm *v1.ConfigMap, err error) {
-	obj.verify()
+	err = appendError(obj.err, obj.verify())
+	return obj.cm, err
+}
+
+// Validate check ConfigMap necessary value like Finish(),and return the problems as FieldError,
+// but ConfigMap is not changed, no Kind,APIVersion or default field is input,
+// so it can be used to check a partially built ConfigMap.
+func (obj *ConfigMap) Validate() []FieldError {
+	cp := &ConfigMap{cm: obj.cm.DeepCopy(), verifiers: obj.verifiers, err: obj.err}
+	return FieldErrors(appendError(cp.err, cp.verify()))
+}
+
+// FinishObject same as Finish(), but return ConfigMap as runtime.Object,
+// so ConfigMap can be used as Builder, eg: add into Bundle.
+func (obj *ConfigMap) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// FinishUnchecked return ConfigMap without the checks and default fields of Finish(),only Kind and APIVersion are input,
+// it is for the partial ConfigMap which is patched onto the existing one,the error of the chain is still returned.
+func (obj *ConfigMap) FinishUnchecked() (*v1.ConfigMap, error) {
+	obj.cm.Kind, obj.cm.APIVersion = "ConfigMap", "v1"
 	return obj.cm, obj.err
 }
 
 // JSONNew use json data create ConfigMap
 func (obj *ConfigMap) JSONNew(jsonbyts []byte) *ConfigMap {
-	obj.error(json.Unmarshal(jsonbyts, obj.cm))
+	obj.error(decodeJSON(jsonbyts, obj.cm))
 	return obj
 }
 
 // YAMLNew use yaml data create ConfigMap
 func (obj *ConfigMap) YAMLNew(yamlbyts []byte) *ConfigMap {
-	obj.error(yaml.Unmarshal(yamlbyts, obj.cm))
+	obj.error(decodeYAML(yamlbyts, obj.cm))
 	return obj
 }
 
@@ -71,23 +102,43 @@ func (obj *ConfigMap) SetLabels(labels map[string]string) *ConfigMap {
 	return obj
 }
 
+// AddLabel add or overwrite one label of ConfigMap,the other labels are kept,
+// unlike SetLabels() which replaces all labels,eg: the labels loaded by YAMLNew().
+func (obj *ConfigMap) AddLabel(key, value string) *ConfigMap {
+	obj.error(addLabel(obj.cm, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of ConfigMap,the other annotations are kept
+func (obj *ConfigMap) AddAnnotation(key, value string) *ConfigMap {
+	obj.error(addAnnotation(obj.cm, key, value))
+	return obj
+}
+
 // SetData set ConfigMap(cm) data, map[key]value
 func (obj *ConfigMap) SetData(data map[string]string) *ConfigMap {
 	obj.cm.Data = data
 	return obj
 }
 
+// SetBinaryData set ConfigMap(cm) binary data, map[key]bytes,eg: the certificate or the archive which is not UTF-8,
+// the key is not allowed to be in both data and binary data.
+func (obj *ConfigMap) SetBinaryData(data map[string][]byte) *ConfigMap {
+	obj.cm.BinaryData = data
+	return obj
+}
+
 // Release release ConfigMap on Kubernetes
 func (obj *ConfigMap) Release() (*v1.ConfigMap, error) {
 	cm, err := obj.Finish()
 	if err != nil {
 		return nil, err
 	}
-	client, err := GetKubeClient()
+	client, err := getKubeInterface()
 	if err != nil {
 		return nil, err
 	}
-	return client.CoreV1().ConfigMaps(cm.GetNamespace()).Create(cm)
+	return client.CoreV1().ConfigMaps(cm.GetNamespace()).Create(context.TODO(), cm, metav1.CreateOptions{})
 }
 
 // Apply  it will be updated when this resource object exists in K8s,
@@ -97,37 +148,104 @@ func (obj *ConfigMap) Apply() (*v1.ConfigMap, error) {
 	if err != nil {
 		return nil, err
 	}
-	client, err := GetKubeClient()
+	client, err := getKubeInterface()
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = client.CoreV1().ConfigMaps(cm.GetNamespace()).Get(cm.GetName(), metav1.GetOptions{})
+	_, err = client.CoreV1().ConfigMaps(cm.GetNamespace()).Get(context.TODO(), cm.GetName(), metav1.GetOptions{})
 	if err != nil {
-		return client.CoreV1().ConfigMaps(cm.GetNamespace()).Create(cm)
+		return client.CoreV1().ConfigMaps(cm.GetNamespace()).Create(context.TODO(), cm, metav1.CreateOptions{})
 	}
-	return client.CoreV1().ConfigMaps(cm.GetNamespace()).Update(cm)
+	return client.CoreV1().ConfigMaps(cm.GetNamespace()).Update(context.TODO(), cm, metav1.UpdateOptions{})
 }
 
-func (obj *ConfigMap) error(err error) {
+// Watch watch ConfigMap on Kubernetes by its namespace and name,and call handler when it is added,updated or deleted,
+// client is the Kubernetes clientset,the registered one is used when it is nil,it blocks until ctx is done.
+func (obj *ConfigMap) Watch(ctx context.Context, client kubernetes.Interface, handler EventHandler) error {
 	if obj.err != nil {
-		return
+		return obj.err
 	}
-	obj.err = err
+	return watchObjects(ctx, client, []runtime.Object{obj.cm}, handler)
 }
 
-// verify check ConfigMap necessary value,input default field.
-func (obj *ConfigMap) verify() {
+// Delete delete ConfigMap on Kubernetes by its name and namespace,
+// opts[0] sets the propagation policy and grace period.
+func (obj *ConfigMap) Delete(opts ...DeleteOptions) error {
 	if obj.err != nil {
-		return
+		return obj.err
 	}
+	options, err := newDeleteOptions("ConfigMap", obj.cm.GetName(), opts)
+	if err != nil {
+		return err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return err
+	}
+	return client.CoreV1().ConfigMaps(obj.cm.GetNamespace()).Delete(context.TODO(), obj.cm.GetName(), *options)
+}
+
+// String the current ConfigMap as yaml, the pending error is written on the top as comment.
+// it doesn't check and input default field like Finish(), so the ConfigMap may be incomplete.
+func (obj *ConfigMap) String() string { return dump("ConfigMap", obj.cm, obj.err) }
+
+// Dump print the current ConfigMap and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *ConfigMap) Dump() *ConfigMap {
+	fmt.Println(obj.String())
+	return obj
+}
+
+// WithVerifier add the verifier which checks ConfigMap in Finish() and Validate() after the checks of beku,
+// it only affects this builder,so one call site can enforce extra invariants,eg: the image must be from the company registry.
+func (obj *ConfigMap) WithVerifier(verifier func(*v1.ConfigMap) error) *ConfigMap {
+	if verifier == nil {
+		obj.error(fieldError("WithVerifier", "verifier is not allowed to be nil"))
+		return obj
+	}
+	obj.verifiers = append(obj.verifiers, verifier)
+	return obj
+}
+
+func (obj *ConfigMap) error(err error) {
+	obj.err = appendError(obj.err, err)
+}
+
+// verify check ConfigMap necessary value,input default field.
+func (obj *ConfigMap) verify() error {
+	var errs error
 	if !verifyString(obj.cm.Name) {
-		obj.err = errors.New("ConfigMap name is not allowed to be empty")
-		return
+		errs = appendError(errs, fieldError("ConfigMap.Name", "is not allowed to be empty"))
+	}
+	if len(obj.cm.Data) <= 0 && len(obj.cm.BinaryData) <= 0 {
+		errs = appendError(errs, fieldError("ConfigMap.Data", "is not allowed to be empty"))
 	}
-	if len(obj.cm.Data) <= 0 {
-		obj.err = errors.New("ConfigMap.Data is not allowed to be empty")
+	for key := range obj.cm.BinaryData {
+		if _, ok := obj.cm.Data[key]; ok {
+			errs = appendError(errs, fieldErrorf("ConfigMap.BinaryData", "key %s is not allowed to be in both Data and BinaryData", key))
+		}
+	}
+	if errs != nil {
+		return errs
 	}
 	obj.cm.APIVersion = "v1"
 	obj.cm.Kind = "ConfigMap"
+	// the verifiers check the finished object,so they are run after all checks and default fields
+	return obj.runVerifiers()
+}
+
+// runVerifiers run the verifiers added by WithVerifier() in order when ConfigMap is valid,
+// then the verifiers registered by RegisterVerifier().
+func (obj *ConfigMap) runVerifiers() error {
+	var errs error
+	for _, verifier := range obj.verifiers {
+		if err := verifier(obj.cm); err != nil {
+			errs = appendError(errs, fmt.Errorf("ConfigMap %s verifier failed:%v", obj.cm.GetName(), err))
+		}
+	}
+	if err := runRegisteredVerifiers(obj.cm); err != nil {
+		errs = appendError(errs, fmt.Errorf("ConfigMap %s %v", obj.cm.GetName(), err))
+	}
+	return errs
 }
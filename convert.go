@@ -0,0 +1,232 @@
+package beku
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	appsv1beta1 "k8s.io/api/apps/v1beta1"
+	appsv1beta2 "k8s.io/api/apps/v1beta2"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+)
+
+// FinishOption is the option of FinishFor(),eg: WithAPIVersion("apps/v1beta2")
+type FinishOption func(*finishOptions)
+
+type finishOptions struct {
+	apiVersion     string
+	clusterVersion string
+	client         kubernetes.Interface
+	// err is the error of the options,eg: no client is registered for WithClusterOf(nil)
+	err error
+}
+
+// WithAPIVersion output the object as apiVersion,eg: apps/v1beta2 for the old clusters
+func WithAPIVersion(apiVersion string) FinishOption {
+	return func(opts *finishOptions) { opts.apiVersion = apiVersion }
+}
+
+// WithClusterVersion output the object as the newest apiVersion which the Kubernetes version supports,eg: v1.14
+func WithClusterVersion(version string) FinishOption {
+	return func(opts *finishOptions) { opts.clusterVersion = version }
+}
+
+// WithClusterOf output the object as the newest apiVersion which the cluster of client supports,
+// the version of the cluster is got by discovery,the registered client is used when it is nil,
+// FinishFor() returns the error when there is no registered client.
+func WithClusterOf(client kubernetes.Interface) FinishOption {
+	return func(opts *finishOptions) {
+		var err error
+		if opts.client, err = clientOrRegistered(client); err != nil {
+			opts.err = fmt.Errorf("FinishFor err,get the client of WithClusterOf failed:%v", err)
+		}
+	}
+}
+
+// apiVersionSupport is the apiVersion of the kind and the minimal Kubernetes version serving it,newest first
+type apiVersionSupport struct {
+	apiVersion   string
+	major, minor int
+}
+
+// apiVersionsOfKind the apiVersions which beku can output for the kind,newest first
+var apiVersionsOfKind = map[string][]apiVersionSupport{
+	"Deployment": {
+		{"apps/v1", 1, 9},
+		{"apps/v1beta2", 1, 8},
+		{"apps/v1beta1", 1, 6},
+		{"extensions/v1beta1", 1, 0},
+	},
+	"StatefulSet": {
+		{"apps/v1", 1, 9},
+		{"apps/v1beta2", 1, 8},
+		{"apps/v1beta1", 1, 5},
+	},
+	"DaemonSet": {
+		{"apps/v1", 1, 9},
+		{"apps/v1beta2", 1, 8},
+		{"extensions/v1beta1", 1, 0},
+	},
+	"Ingress": {
+		{"networking.k8s.io/v1", 1, 19},
+		{"networking.k8s.io/v1beta1", 1, 14},
+		{"extensions/v1beta1", 1, 0},
+	},
+}
+
+// finishFor convert obj which is finished by the builder into the apiVersion selected by opts,
+// obj is returned without conversion when no option is given.
+func finishFor(obj runtime.Object, opts []FinishOption) (runtime.Object, error) {
+	options := &finishOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.err != nil {
+		return nil, options.err
+	}
+	apiVersion := options.apiVersion
+	if apiVersion == "" {
+		clusterVersion := options.clusterVersion
+		if options.client != nil {
+			info, err := options.client.Discovery().ServerVersion()
+			if err != nil {
+				return nil, fmt.Errorf("FinishFor err,get the version of cluster failed:%v", err)
+			}
+			clusterVersion = info.GitVersion
+		}
+		if clusterVersion == "" {
+			return obj, nil
+		}
+		var err error
+		if apiVersion, err = apiVersionForCluster(objectKind(obj), clusterVersion); err != nil {
+			return nil, err
+		}
+	}
+	return ConvertAPIVersion(obj, apiVersion)
+}
+
+// apiVersionForCluster get the newest apiVersion of the kind which the Kubernetes version serves
+func apiVersionForCluster(kind, version string) (string, error) {
+	match := kubernetesVersionPattern.FindStringSubmatch(version)
+	if match == nil {
+		return "", fmt.Errorf("FinishFor err,version %q is not allowed,eg: v1.14", version)
+	}
+	major, _ := strconv.Atoi(match[1])
+	minor, _ := strconv.Atoi(match[2])
+	for _, support := range apiVersionsOfKind[kind] {
+		if major > support.major || (major == support.major && minor >= support.minor) {
+			return support.apiVersion, nil
+		}
+	}
+	return "", fmt.Errorf("FinishFor err,%s is not supported by Kubernetes %s", kind, version)
+}
+
+// ConvertAPIVersion convert Deployment,StatefulSet,DaemonSet(apps/v1) or Ingress(networking.k8s.io/v1)
+// into the legacy apiVersion for the old clusters,eg: apps/v1beta2,extensions/v1beta1,networking.k8s.io/v1beta1,
+// the object is returned when it is already apiVersion, status is not converted.
+func ConvertAPIVersion(obj runtime.Object, apiVersion string) (runtime.Object, error) {
+	kind := objectKind(obj)
+	supported := false
+	for _, support := range apiVersionsOfKind[kind] {
+		supported = supported || support.apiVersion == apiVersion
+	}
+	if !supported {
+		return nil, fmt.Errorf("ConvertAPIVersion err,%s can't be converted into %s", kind, apiVersion)
+	}
+	if obj.GetObjectKind().GroupVersionKind().GroupVersion().String() == apiVersion {
+		return obj, nil
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, fmt.Errorf("ConvertAPIVersion err,%v", err)
+	}
+	delete(content, "status")
+	content["apiVersion"], content["kind"] = apiVersion, kind
+	if kind == "Ingress" {
+		legacyIngressBackends(content)
+	}
+	var target runtime.Object
+	switch kind + " " + apiVersion {
+	case "Deployment apps/v1beta2":
+		target = &appsv1beta2.Deployment{}
+	case "Deployment apps/v1beta1":
+		target = &appsv1beta1.Deployment{}
+	case "Deployment extensions/v1beta1":
+		target = &extensionsv1beta1.Deployment{}
+	case "StatefulSet apps/v1beta2":
+		target = &appsv1beta2.StatefulSet{}
+	case "StatefulSet apps/v1beta1":
+		target = &appsv1beta1.StatefulSet{}
+	case "DaemonSet apps/v1beta2":
+		target = &appsv1beta2.DaemonSet{}
+	case "DaemonSet extensions/v1beta1":
+		target = &extensionsv1beta1.DaemonSet{}
+	case "Ingress networking.k8s.io/v1beta1":
+		target = &networkingv1beta1.Ingress{}
+	case "Ingress extensions/v1beta1":
+		target = &extensionsv1beta1.Ingress{}
+	default:
+		return nil, fmt.Errorf("ConvertAPIVersion err,%s is not %s", kind, apiVersionsOfKind[kind][0].apiVersion)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, target); err != nil {
+		return nil, fmt.Errorf("ConvertAPIVersion %s into %s err,%v", kind, apiVersion, err)
+	}
+	return target, nil
+}
+
+// legacyIngressBackends turn the backends of Ingress(networking.k8s.io/v1) into the v1beta1 form:
+// spec.defaultBackend is spec.backend and service.name,service.port are serviceName,servicePort.
+func legacyIngressBackends(content map[string]interface{}) {
+	spec, ok := content["spec"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	if backend, ok := spec["defaultBackend"].(map[string]interface{}); ok {
+		spec["backend"] = legacyIngressBackend(backend)
+		delete(spec, "defaultBackend")
+	}
+	rules, _ := spec["rules"].([]interface{})
+	for _, rule := range rules {
+		http, _ := rule.(map[string]interface{})["http"].(map[string]interface{})
+		paths, _ := http["paths"].([]interface{})
+		for _, path := range paths {
+			path := path.(map[string]interface{})
+			if backend, ok := path["backend"].(map[string]interface{}); ok {
+				path["backend"] = legacyIngressBackend(backend)
+			}
+		}
+	}
+}
+
+func legacyIngressBackend(backend map[string]interface{}) map[string]interface{} {
+	service, ok := backend["service"].(map[string]interface{})
+	if !ok {
+		return backend
+	}
+	legacy := map[string]interface{}{"serviceName": service["name"]}
+	if port, ok := service["port"].(map[string]interface{}); ok {
+		if name, ok := port["name"].(string); ok && name != "" {
+			legacy["servicePort"] = name
+		} else if number, ok := port["number"]; ok {
+			legacy["servicePort"] = toInt64(number)
+		}
+	}
+	return legacy
+}
+
+// toInt64 get the int64 of the number in unstructured,eg: int64,float64 and json.Number
+func toInt64(number interface{}) int64 {
+	switch n := normalizeNumbers(number).(type) {
+	case int64:
+		return n
+	case float64:
+		return int64(n)
+	case json.Number:
+		i, _ := n.Int64()
+		return i
+	}
+	return 0
+}
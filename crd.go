@@ -0,0 +1,266 @@
+package beku
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// CRD include CustomResourceDefinition(apiextensions.k8s.io/v1) as unstructured and error,
+// so the CRD and its custom resources built by NewUnstructured() are released together,eg: in Bundle.
+type CRD struct {
+	crd *unstructured.Unstructured
+	err error
+}
+
+// NewCRD create CustomResourceDefinition and chain function call begin with this function.
+func NewCRD() *CRD {
+	crd := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	crd.SetAPIVersion("apiextensions.k8s.io/v1")
+	crd.SetKind("CustomResourceDefinition")
+	return &CRD{crd: crd}
+}
+
+// Finish chain function call end with this function
+// return CustomResourceDefinition as unstructured and error
+// In the function, the name is set as <plural>.<group> and the scope is Namespaced by default.
+func (obj *CRD) Finish() (crd *unstructured.Unstructured, err error) {
+	err = appendError(obj.err, obj.verify())
+	return obj.crd, err
+}
+
+// Validate check CRD necessary value like Finish(),and return the problems as FieldError,
+// but CRD is not changed.
+func (obj *CRD) Validate() []FieldError {
+	cp := &CRD{crd: obj.crd.DeepCopy(), err: obj.err}
+	return FieldErrors(appendError(cp.err, cp.verify()))
+}
+
+// FinishObject same as Finish(), but return CRD as runtime.Object,
+// so CRD can be used as Builder, eg: add into Bundle.
+func (obj *CRD) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// JSONNew use json data create CustomResourceDefinition
+func (obj *CRD) JSONNew(jsonbyts []byte) *CRD {
+	content := make(map[string]interface{})
+	if err := decodeJSON(jsonbyts, &content); err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.replaceContent(content)
+}
+
+// YAMLNew use yaml data create CustomResourceDefinition
+func (obj *CRD) YAMLNew(yamlbyts []byte) *CRD {
+	content := make(map[string]interface{})
+	if err := decodeYAML(yamlbyts, &content); err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.replaceContent(content)
+}
+
+func (obj *CRD) replaceContent(content map[string]interface{}) *CRD {
+	crd := &unstructured.Unstructured{Object: normalizeNumbers(content).(map[string]interface{})}
+	if crd.GetAPIVersion() != "apiextensions.k8s.io/v1" || crd.GetKind() != "CustomResourceDefinition" {
+		obj.error(fmt.Errorf("the data is %s %s,not apiextensions.k8s.io/v1 CustomResourceDefinition", crd.GetAPIVersion(), crd.GetKind()))
+		return obj
+	}
+	obj.crd = crd
+	return obj
+}
+
+// SetGroup set the API group of the custom resources,eg: stable.example.com
+func (obj *CRD) SetGroup(group string) *CRD {
+	if errs := validation.IsDNS1123Subdomain(group); len(errs) > 0 || !strings.Contains(group, ".") {
+		obj.error(fieldErrorf("SetGroup", "group %s should be the domain with dot,eg: stable.example.com", group))
+		return obj
+	}
+	obj.setField(group, "spec", "group")
+	return obj
+}
+
+// SetNames set the names of the custom resources,kind is CamelCase,eg: CronTab,
+// plural and singular are lowercase,eg: crontabs and crontab,singular is the lowercase kind when it is empty,
+// shortNames are used by kubectl,eg: ct.
+func (obj *CRD) SetNames(kind, plural, singular string, shortNames ...string) *CRD {
+	if !verifyString(kind) || !verifyString(plural) {
+		obj.error(fieldError("SetNames", "kind and plural are not allowed to be empty"))
+		return obj
+	}
+	if singular == "" {
+		singular = strings.ToLower(kind)
+	}
+	for _, name := range append([]string{plural, singular}, shortNames...) {
+		if errs := validation.IsDNS1035Label(name); len(errs) > 0 {
+			obj.error(fieldErrorf("SetNames", "%s is invalid:%s", name, strings.Join(errs, ",")))
+			return obj
+		}
+	}
+	names := map[string]interface{}{"kind": kind, "plural": plural, "singular": singular}
+	if len(shortNames) > 0 {
+		names["shortNames"] = stringsToInterfaces(shortNames)
+	}
+	obj.setField(names, "spec", "names")
+	return obj
+}
+
+// SetScope set the scope of the custom resources,scope is Namespaced or Cluster
+func (obj *CRD) SetScope(scope string) *CRD {
+	if scope != "Namespaced" && scope != "Cluster" {
+		obj.error(fieldErrorf("SetScope", "scope %s is not allowed,only Namespaced and Cluster", scope))
+		return obj
+	}
+	obj.setField(scope, "spec", "scope")
+	return obj
+}
+
+// AddVersion add the version of the custom resources,the version of the same name is replaced,
+// served is whether the version is served by the REST API,storage is whether the objects are stored in this version,
+// only one version can be storage.
+// schema is openAPIV3Schema,eg: map[string]interface{}{"type": "object","properties": ...},
+// the object which preserves unknown fields is used when it is nil.
+func (obj *CRD) AddVersion(name string, served, storage bool, schema map[string]interface{}) *CRD {
+	if errs := validation.IsDNS1035Label(name); len(errs) > 0 {
+		obj.error(fieldErrorf("AddVersion", "version %s is invalid:%s", name, strings.Join(errs, ",")))
+		return obj
+	}
+	if schema == nil {
+		schema = map[string]interface{}{"type": "object", "x-kubernetes-preserve-unknown-fields": true}
+	}
+	jsonSchema, err := toJSONValue(schema)
+	if err != nil {
+		obj.error(fieldErrorf("AddVersion", "version %s:%v", name, err))
+		return obj
+	}
+	version := map[string]interface{}{
+		"name":    name,
+		"served":  served,
+		"storage": storage,
+		"schema":  map[string]interface{}{"openAPIV3Schema": jsonSchema},
+	}
+	versions := obj.versions()
+	for i, item := range versions {
+		if existing, ok := item.(map[string]interface{}); ok && existing["name"] == name {
+			versions[i] = version
+			obj.setField(versions, "spec", "versions")
+			return obj
+		}
+	}
+	obj.setField(append(versions, version), "spec", "versions")
+	return obj
+}
+
+// SetStatusSubresource enable the status subresource of the version,
+// so the status is only changed by /status and metadata.generation is not changed by it.
+func (obj *CRD) SetStatusSubresource(version string) *CRD {
+	versions := obj.versions()
+	for _, item := range versions {
+		if existing, ok := item.(map[string]interface{}); ok && existing["name"] == version {
+			if err := unstructured.SetNestedMap(existing, map[string]interface{}{}, "subresources", "status"); err != nil {
+				obj.error(fieldErrorf("SetStatusSubresource", "%v", err))
+				return obj
+			}
+			obj.setField(versions, "spec", "versions")
+			return obj
+		}
+	}
+	obj.error(fieldErrorf("SetStatusSubresource", "version %s is not added by AddVersion()", version))
+	return obj
+}
+
+// SetLabels set CRD labels
+func (obj *CRD) SetLabels(labels map[string]string) *CRD {
+	obj.crd.SetLabels(labels)
+	return obj
+}
+
+// AddLabel add or overwrite one label of CRD,the other labels are kept
+func (obj *CRD) AddLabel(key, value string) *CRD {
+	obj.error(addLabel(obj.crd, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of CRD,the other annotations are kept
+func (obj *CRD) AddAnnotation(key, value string) *CRD {
+	obj.error(addAnnotation(obj.crd, key, value))
+	return obj
+}
+
+// String the current CRD as yaml, the pending error is written on the top as comment.
+func (obj *CRD) String() string {
+	return dump("CustomResourceDefinition", obj.crd, obj.err)
+}
+
+// Dump print the current CRD and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *CRD) Dump() *CRD {
+	fmt.Println(obj.String())
+	return obj
+}
+
+func (obj *CRD) error(err error) {
+	obj.err = appendError(obj.err, err)
+}
+
+func (obj *CRD) setField(value interface{}, fields ...string) {
+	if err := unstructured.SetNestedField(obj.crd.Object, value, fields...); err != nil {
+		obj.error(err)
+	}
+}
+
+// versions get spec.versions,it is nil when there is no version
+func (obj *CRD) versions() []interface{} {
+	versions, _, _ := unstructured.NestedSlice(obj.crd.Object, "spec", "versions")
+	return versions
+}
+
+func (obj *CRD) verify() error {
+	var errs error
+	group, _, _ := unstructured.NestedString(obj.crd.Object, "spec", "group")
+	if !verifyString(group) {
+		errs = appendError(errs, fieldError("CustomResourceDefinition.Spec.Group", "is not allowed to be empty,set it by SetGroup()"))
+	}
+	plural, _, _ := unstructured.NestedString(obj.crd.Object, "spec", "names", "plural")
+	kind, _, _ := unstructured.NestedString(obj.crd.Object, "spec", "names", "kind")
+	if !verifyString(plural) || !verifyString(kind) {
+		errs = appendError(errs, fieldError("CustomResourceDefinition.Spec.Names", "are not allowed to be empty,set them by SetNames()"))
+	}
+	// the name of CustomResourceDefinition must be <plural>.<group>
+	if name := obj.crd.GetName(); name != "" && name != plural+"."+group {
+		errs = appendError(errs, fieldErrorf("CustomResourceDefinition.Name", "should be %s.%s,got %s", plural, group, name))
+	}
+	versions := obj.versions()
+	if len(versions) == 0 {
+		errs = appendError(errs, fieldError("CustomResourceDefinition.Spec.Versions", "are not allowed to be empty,add them by AddVersion()"))
+	}
+	storage := 0
+	for _, item := range versions {
+		if version, ok := item.(map[string]interface{}); ok && version["storage"] == true {
+			storage++
+		}
+	}
+	if len(versions) > 0 && storage != 1 {
+		errs = appendError(errs, fieldErrorf("CustomResourceDefinition.Spec.Versions", "should have exactly one storage version,got %d", storage))
+	}
+	if errs != nil {
+		return errs
+	}
+	obj.crd.SetName(plural + "." + group)
+	if scope, _, _ := unstructured.NestedString(obj.crd.Object, "spec", "scope"); scope == "" {
+		obj.setField("Namespaced", "spec", "scope")
+	}
+	return nil
+}
+
+// stringsToInterfaces translate []string into []interface{} which unstructured supports
+func stringsToInterfaces(strs []string) []interface{} {
+	values := make([]interface{}, 0, len(strs))
+	for _, str := range strs {
+		values = append(values, str)
+	}
+	return values
+}
@@ -0,0 +1,304 @@
+package beku
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// CronJob include Kubernetes resource object CronJob and error
+type CronJob struct {
+	cj  *batchv1.CronJob
+	err error
+}
+
+// NewCronJob create CronJob and chain function call begin with this function,
+// the Pod of CronJob is built by Job builder and set by SetJobTemplate().
+func NewCronJob() *CronJob { return &CronJob{cj: &batchv1.CronJob{}} }
+
+// CronJobFrom create CronJob from the existing CronJob,eg: the one got by client,
+// so it can be changed by the chain function and released again,
+// cj is deep copied and the fields which are not changed by the chain function are kept.
+func CronJobFrom(cj *batchv1.CronJob) *CronJob {
+	if cj == nil {
+		return NewCronJob()
+	}
+	return &CronJob{cj: cj.DeepCopy()}
+}
+
+// Finish Chain function call end with this function
+// return real CronJob(really CronJob is kubernetes resource object CronJob and error
+// In the function, it will check necessary parameters、input the default field
+func (obj *CronJob) Finish() (*batchv1.CronJob, error) {
+	return obj.cj, appendError(obj.err, obj.verify())
+}
+
+// Validate check CronJob necessary value like Finish(),and return the problems as FieldError,
+// but CronJob is not changed, no Kind,APIVersion or default field is input,
+// so it can be used to check a partially built CronJob.
+func (obj *CronJob) Validate() []FieldError {
+	cp := &CronJob{cj: obj.cj.DeepCopy(), err: obj.err}
+	return FieldErrors(appendError(cp.err, cp.verify()))
+}
+
+// FinishObject same as Finish(), but return CronJob as runtime.Object,
+// so CronJob can be used as Builder, eg: add into Bundle.
+func (obj *CronJob) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// FinishUnchecked return CronJob without the checks and default fields of Finish(),only Kind and APIVersion are input,
+// it is for the partial CronJob which is patched onto the existing one,the error of the chain is still returned.
+func (obj *CronJob) FinishUnchecked() (*batchv1.CronJob, error) {
+	obj.cj.Kind, obj.cj.APIVersion = "CronJob", "batch/v1"
+	return obj.cj, obj.err
+}
+
+// JSONNew use json data create CronJob
+func (obj *CronJob) JSONNew(jsonbyts []byte) *CronJob {
+	obj.error(decodeJSON(jsonbyts, obj.cj))
+	return obj
+}
+
+// YAMLNew use yaml data create CronJob
+func (obj *CronJob) YAMLNew(yamlbyts []byte) *CronJob {
+	obj.error(decodeYAML(yamlbyts, obj.cj))
+	return obj
+}
+
+// Replace replace CronJob by Kubernetes resource object
+func (obj *CronJob) Replace(cj *batchv1.CronJob) *CronJob {
+	if cj != nil {
+		obj.cj = cj
+	}
+	return obj
+}
+
+// SetName set CronJob name
+func (obj *CronJob) SetName(name string) *CronJob {
+	obj.cj.SetName(name)
+	return obj
+}
+
+// SetNamespace set CronJob namespace,default namespace is 'default'
+func (obj *CronJob) SetNamespace(namespace string) *CronJob {
+	obj.cj.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set CronJob namespace and name
+func (obj *CronJob) SetNamespaceAndName(namespace, name string) *CronJob {
+	obj.cj.SetName(name)
+	obj.cj.SetNamespace(namespace)
+	return obj
+}
+
+// SetLabels set CronJob labels
+func (obj *CronJob) SetLabels(labels map[string]string) *CronJob {
+	obj.cj.SetLabels(labels)
+	return obj
+}
+
+// AddLabel add or overwrite one label of CronJob,the other labels are kept
+func (obj *CronJob) AddLabel(key, value string) *CronJob {
+	obj.error(addLabel(obj.cj, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of CronJob,the other annotations are kept
+func (obj *CronJob) AddAnnotation(key, value string) *CronJob {
+	obj.error(addAnnotation(obj.cj, key, value))
+	return obj
+}
+
+// SetSchedule set the schedule of CronJob in cron format,eg: "*/5 * * * *" or "@hourly"
+func (obj *CronJob) SetSchedule(schedule string) *CronJob {
+	schedule = strings.TrimSpace(schedule)
+	if err := verifySchedule(schedule); err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.cj.Spec.Schedule = schedule
+	return obj
+}
+
+// SetTimeZone set the time zone of the schedule,eg: "Asia/Shanghai",default is the time zone of kube-controller-manager
+func (obj *CronJob) SetTimeZone(timeZone string) *CronJob {
+	if !verifyString(timeZone) {
+		obj.error(fieldError("SetTimeZone", "timeZone is not allowed to be empty"))
+		return obj
+	}
+	obj.cj.Spec.TimeZone = &timeZone
+	return obj
+}
+
+// SetConcurrencyPolicy set how to treat the concurrent Jobs when the last one is still running,
+// Allow runs them concurrently,Forbid skips the new one,Replace replaces the running one,default is Allow.
+func (obj *CronJob) SetConcurrencyPolicy(policy string) *CronJob {
+	switch batchv1.ConcurrencyPolicy(policy) {
+	case batchv1.AllowConcurrent, batchv1.ForbidConcurrent, batchv1.ReplaceConcurrent:
+		obj.cj.Spec.ConcurrencyPolicy = batchv1.ConcurrencyPolicy(policy)
+	default:
+		obj.error(fieldErrorf("SetConcurrencyPolicy", "policy %s is not allowed,only Allow,Forbid and Replace", policy))
+	}
+	return obj
+}
+
+// SetSuspend suspend CronJob,no Job is created until it is resumed,the running Jobs are not affected
+func (obj *CronJob) SetSuspend(suspend bool) *CronJob {
+	obj.cj.Spec.Suspend = &suspend
+	return obj
+}
+
+// SetStartingDeadlineSeconds set the Job which misses its schedule more than sec seconds is not started
+func (obj *CronJob) SetStartingDeadlineSeconds(sec int64) *CronJob {
+	if sec < 1 {
+		obj.error(fieldError("SetStartingDeadlineSeconds", "sec must be greater than 0"))
+		return obj
+	}
+	obj.cj.Spec.StartingDeadlineSeconds = &sec
+	return obj
+}
+
+// SetHistoryLimit set how many successful and failed Jobs are kept,default is 3 and 1
+func (obj *CronJob) SetHistoryLimit(successful, failed int32) *CronJob {
+	if successful < 0 || failed < 0 {
+		obj.error(fieldError("SetHistoryLimit", "limit is not allowed to be negative"))
+		return obj
+	}
+	obj.cj.Spec.SuccessfulJobsHistoryLimit = &successful
+	obj.cj.Spec.FailedJobsHistoryLimit = &failed
+	return obj
+}
+
+// SetJobTemplate set the Job created by CronJob on schedule,the Job is built by NewJob() without name,
+// its labels,annotations and spec are copied,so the Job builder can be used again.
+func (obj *CronJob) SetJobTemplate(job *Job) *CronJob {
+	if job == nil {
+		obj.error(fieldError("SetJobTemplate", "Job is not allowed to be nil"))
+		return obj
+	}
+	if job.err != nil {
+		obj.error(fieldErrorf("SetJobTemplate", "Job has error:%v", job.err))
+		return obj
+	}
+	obj.cj.Spec.JobTemplate = batchv1.JobTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: copyLabels(job.job.GetLabels()), Annotations: copyLabels(job.job.GetAnnotations())},
+		Spec:       *job.job.Spec.DeepCopy(),
+	}
+	return obj
+}
+
+// Release release CronJob on Kubernetes
+func (obj *CronJob) Release() (*batchv1.CronJob, error) {
+	cj, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return nil, err
+	}
+	return client.BatchV1().CronJobs(cj.GetNamespace()).Create(context.TODO(), cj, metav1.CreateOptions{})
+}
+
+// Apply  it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist.
+func (obj *CronJob) Apply() (*batchv1.CronJob, error) {
+	cj, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return nil, err
+	}
+	_, err = client.BatchV1().CronJobs(cj.GetNamespace()).Get(context.TODO(), cj.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.BatchV1().CronJobs(cj.GetNamespace()).Create(context.TODO(), cj, metav1.CreateOptions{})
+	}
+	return client.BatchV1().CronJobs(cj.GetNamespace()).Update(context.TODO(), cj, metav1.UpdateOptions{})
+}
+
+// Delete delete CronJob on Kubernetes by its name and namespace,
+// opts[0] sets the propagation policy and grace period.
+func (obj *CronJob) Delete(opts ...DeleteOptions) error {
+	if obj.err != nil {
+		return obj.err
+	}
+	options, err := newDeleteOptions("CronJob", obj.cj.GetName(), opts)
+	if err != nil {
+		return err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return err
+	}
+	return client.BatchV1().CronJobs(obj.cj.GetNamespace()).Delete(context.TODO(), obj.cj.GetName(), *options)
+}
+
+// String the current CronJob as yaml, the pending error is written on the top as comment.
+// it doesn't check and input default field like Finish(), so the CronJob may be incomplete.
+func (obj *CronJob) String() string { return dump("CronJob", obj.cj, obj.err) }
+
+// Dump print the current CronJob and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *CronJob) Dump() *CronJob {
+	fmt.Println(obj.String())
+	return obj
+}
+
+func (obj *CronJob) error(err error) {
+	obj.err = appendError(obj.err, err)
+}
+
+// verify check CronJob necessary value, input the default field,all problems are returned at once.
+func (obj *CronJob) verify() error {
+	var errs error
+	if !verifyString(obj.cj.GetName()) {
+		errs = appendError(errs, fieldError("CronJob.Name", "is not allowed to be empty"))
+	}
+	// the names of Jobs are the name of CronJob with 11 characters suffix,so the name is limited to 52 characters
+	if len(obj.cj.GetName()) > 52 {
+		errs = appendError(errs, fieldErrorf("CronJob.Name", "%s is not allowed to be longer than 52 characters", obj.cj.GetName()))
+	}
+	if !verifyString(obj.cj.Spec.Schedule) {
+		errs = appendError(errs, fieldError("CronJob.Spec.Schedule", "is not allowed to be empty,you can call SetSchedule() set it"))
+	}
+	template := &obj.cj.Spec.JobTemplate
+	if errs = appendError(errs, verifyJobTemplate("CronJob.Spec.JobTemplate", &template.Spec, template.Annotations)); errs != nil {
+		return errs
+	}
+	delete(template.Annotations, ImagePullPolicyKey)
+	obj.cj.Kind = "CronJob"
+	obj.cj.APIVersion = "batch/v1"
+	// the registered verifiers check the finished object,so they are run after all checks and default fields
+	return obj.runVerifiers()
+}
+
+// cronMacros is the predefined schedules supported by CronJob
+var cronMacros = map[string]bool{"@yearly": true, "@annually": true, "@monthly": true, "@weekly": true, "@daily": true, "@midnight": true, "@hourly": true}
+
+// verifySchedule check the schedule is a macro or has 5 fields,the fields are checked by Kubernetes
+func verifySchedule(schedule string) error {
+	if cronMacros[schedule] {
+		return nil
+	}
+	if strings.Contains(schedule, "TZ=") {
+		return fieldError("SetSchedule", "TZ in schedule is not allowed,you can call SetTimeZone() set it")
+	}
+	if fields := strings.Fields(schedule); len(fields) != 5 {
+		return fieldErrorf("SetSchedule", "schedule %q is not allowed,it must be a macro like @hourly or has 5 fields", schedule)
+	}
+	return nil
+}
+
+// runVerifiers run the verifiers registered by RegisterVerifier() in order when CronJob is valid
+func (obj *CronJob) runVerifiers() error {
+	if err := runRegisteredVerifiers(obj.cj); err != nil {
+		return fmt.Errorf("CronJob %s %v", obj.cj.GetName(), err)
+	}
+	return nil
+}
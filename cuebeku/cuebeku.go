@@ -0,0 +1,83 @@
+// Package cuebeku creates beku builders from CUE,
+// so the manifests written in CUE are checked and applied by the same builders as the Go programs.
+//
+//	builders, err := cuebeku.FromCUE(src)
+//	if err != nil {
+//		return err
+//	}
+//	bundle := beku.NewBundle().AddBuilders(builders...)
+//
+// it is a separate package,so the programs which don't use CUE don't depend on it.
+package cuebeku
+
+import (
+	"fmt"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"github.com/yulibaozi/beku"
+)
+
+// FromCUE evaluate the CUE source and create the builder of every Kubernetes resource object in it.
+// the value can be one object,a list of objects or a struct whose fields are objects (nested structs are allowed),
+// eg: objects: { web: {kind: "Deployment",...}, web_svc: {kind: "Service",...} },
+// the builders are in the order of the fields, hidden fields and definitions are skipped.
+// the value must be concrete, eg: `replicas: int` without value is an error.
+// the supported kinds are listed by beku.Kinds().
+func FromCUE(src []byte) ([]beku.Builder, error) {
+	value := cuecontext.New().CompileBytes(src, cue.Filename("source.cue"))
+	if err := value.Err(); err != nil {
+		return nil, fmt.Errorf("FromCUE err,evaluate failed:%v", err)
+	}
+	if err := value.Validate(cue.Concrete(true)); err != nil {
+		return nil, fmt.Errorf("FromCUE err,value is not concrete:%v", err)
+	}
+	var builders []beku.Builder
+	if err := documents(value, "value", &builders); err != nil {
+		return nil, fmt.Errorf("FromCUE err,%v", err)
+	}
+	return builders, nil
+}
+
+// documents create the builders of the objects in value,path is the path of value for errors
+func documents(value cue.Value, path string, builders *[]beku.Builder) error {
+	switch value.Kind() {
+	case cue.ListKind:
+		iter, err := value.List()
+		if err != nil {
+			return fmt.Errorf("%s:%v", path, err)
+		}
+		for index := 0; iter.Next(); index++ {
+			if err := documents(iter.Value(), fmt.Sprintf("%s[%d]", path, index), builders); err != nil {
+				return err
+			}
+		}
+		return nil
+	case cue.StructKind:
+		if value.LookupPath(cue.ParsePath("kind")).Exists() {
+			doc, err := value.MarshalJSON()
+			if err != nil {
+				return fmt.Errorf("%s:%v", path, err)
+			}
+			builder, err := beku.NewBuilder(doc)
+			if err != nil {
+				return fmt.Errorf("%s:%v", path, err)
+			}
+			if builder != nil {
+				*builders = append(*builders, builder)
+			}
+			return nil
+		}
+		iter, err := value.Fields()
+		if err != nil {
+			return fmt.Errorf("%s:%v", path, err)
+		}
+		for iter.Next() {
+			if err := documents(iter.Value(), path+"."+iter.Selector().String(), builders); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%s is %s,not Kubernetes resource object", path, value.Kind())
+}
@@ -0,0 +1,51 @@
+package cuebeku_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	"github.com/yulibaozi/beku/cuebeku"
+)
+
+func builderKinds(t *testing.T, builders []beku.Builder) string {
+	var kinds []string
+	for _, builder := range builders {
+		obj, err := builder.FinishObject()
+		if err != nil {
+			t.Fatal(err)
+		}
+		kinds = append(kinds, obj.GetObjectKind().GroupVersionKind().Kind)
+	}
+	return strings.Join(kinds, ",")
+}
+
+func Test_FromCUE(t *testing.T) {
+	src := `
+_name: "http"
+objects: {
+	svc: {
+		apiVersion: "v1"
+		kind:       "Service"
+		metadata: name: _name
+		spec: ports: [{port: 80}]
+	}
+	cm: {
+		apiVersion: "v1"
+		kind:       "ConfigMap"
+		metadata: name: _name
+		data: key: "value"
+	}
+}
+`
+	builders, err := cuebeku.FromCUE([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kinds := builderKinds(t, builders); kinds != "Service,ConfigMap" {
+		t.Fatalf("expect Service,ConfigMap, got %s", kinds)
+	}
+	if _, err := cuebeku.FromCUE([]byte(`replicas: int`)); err == nil {
+		t.Fatal("not concrete value should be error")
+	}
+}
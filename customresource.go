@@ -0,0 +1,361 @@
+package beku
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// CustomResource include any Kubernetes resource object as unstructured and error,
+// it is used to create the custom resources which have no builder in beku, eg: cert-manager Certificate.
+type CustomResource struct {
+	cr     *unstructured.Unstructured
+	checks []func(cr *unstructured.Unstructured) error
+	err    error
+}
+
+// NewCustomResource create the custom resource of gvk and chain function call begin with this function.
+func NewCustomResource(gvk schema.GroupVersionKind) *CustomResource {
+	obj := &CustomResource{cr: &unstructured.Unstructured{Object: map[string]interface{}{}}}
+	if !verifyString(gvk.Version) || !verifyString(gvk.Kind) {
+		obj.error(fieldError("NewCustomResource", "version and kind are not allowed to be empty"))
+		return obj
+	}
+	obj.cr.SetGroupVersionKind(gvk)
+	return obj
+}
+
+// NewUnstructured create the custom resource of group,version and kind,it is the same as NewCustomResource(),
+// group is empty for the core API,eg: NewUnstructured("stable.example.com", "v1", "CronTab").
+func NewUnstructured(group, version, kind string) *CustomResource {
+	return NewCustomResource(schema.GroupVersionKind{Group: group, Version: version, Kind: kind})
+}
+
+// Finish chain function call end with this function
+// return the custom resource as unstructured and error
+// In the function, it will check necessary parameters and run the checks added by AddCheck().
+func (obj *CustomResource) Finish() (cr *unstructured.Unstructured, err error) {
+	err = appendError(obj.err, obj.verify())
+	return obj.cr, err
+}
+
+// Validate check CustomResource necessary value like Finish(),and return the problems as FieldError,
+// but CustomResource is not changed.
+func (obj *CustomResource) Validate() []FieldError {
+	cp := &CustomResource{cr: obj.cr.DeepCopy(), checks: obj.checks, err: obj.err}
+	return FieldErrors(appendError(cp.err, cp.verify()))
+}
+
+// FinishObject same as Finish(), but return CustomResource as runtime.Object,
+// so CustomResource can be used as Builder, eg: add into Bundle.
+func (obj *CustomResource) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// FinishUnchecked return CustomResource without the checks of Finish() and AddCheck(),
+// it is for the partial custom resource which is patched onto the existing one,the error of the chain is still returned.
+func (obj *CustomResource) FinishUnchecked() (*unstructured.Unstructured, error) {
+	return obj.cr, obj.err
+}
+
+// JSONNew use json data create CustomResource,
+// the apiVersion and kind in data must be the same as NewCustomResource()
+func (obj *CustomResource) JSONNew(jsonbyts []byte) *CustomResource {
+	content := make(map[string]interface{})
+	if err := decodeJSON(jsonbyts, &content); err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.replaceContent(content)
+}
+
+// YAMLNew use yaml data create CustomResource,
+// the apiVersion and kind in data must be the same as NewCustomResource()
+func (obj *CustomResource) YAMLNew(yamlbyts []byte) *CustomResource {
+	content := make(map[string]interface{})
+	if err := decodeYAML(yamlbyts, &content); err != nil {
+		obj.error(err)
+		return obj
+	}
+	return obj.replaceContent(content)
+}
+
+// Replace replace CustomResource by unstructured,
+// the apiVersion and kind of it must be the same as NewCustomResource()
+func (obj *CustomResource) Replace(cr *unstructured.Unstructured) *CustomResource {
+	if cr == nil {
+		return obj
+	}
+	return obj.replaceContent(cr.Object)
+}
+
+func (obj *CustomResource) replaceContent(content map[string]interface{}) *CustomResource {
+	gvk := obj.cr.GroupVersionKind()
+	cr := &unstructured.Unstructured{Object: normalizeNumbers(content).(map[string]interface{})}
+	if got := cr.GroupVersionKind(); got != gvk {
+		obj.error(fmt.Errorf("CustomResource is %s,but the data is %s", gvk, got))
+		return obj
+	}
+	obj.cr = cr
+	return obj
+}
+
+// SetName set CustomResource name
+func (obj *CustomResource) SetName(name string) *CustomResource {
+	obj.cr.SetName(name)
+	return obj
+}
+
+// SetNamespace set CustomResource namespace, don't set it when the custom resource is cluster scoped
+func (obj *CustomResource) SetNamespace(namespace string) *CustomResource {
+	obj.cr.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set CustomResource namespace and name
+func (obj *CustomResource) SetNamespaceAndName(namespace, name string) *CustomResource {
+	obj.cr.SetName(name)
+	obj.cr.SetNamespace(namespace)
+	return obj
+}
+
+// SetLabels set CustomResource labels
+func (obj *CustomResource) SetLabels(labels map[string]string) *CustomResource {
+	obj.cr.SetLabels(labels)
+	return obj
+}
+
+// AddLabel add or overwrite one label of CustomResource,the other labels are kept,
+// unlike SetLabels() which replaces all labels,eg: the labels loaded by YAMLNew().
+func (obj *CustomResource) AddLabel(key, value string) *CustomResource {
+	obj.error(addLabel(obj.cr, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of CustomResource,the other annotations are kept
+func (obj *CustomResource) AddAnnotation(key, value string) *CustomResource {
+	obj.error(addAnnotation(obj.cr, key, value))
+	return obj
+}
+
+// SetAnnotations set CustomResource annotations
+func (obj *CustomResource) SetAnnotations(annotations map[string]string) *CustomResource {
+	obj.cr.SetAnnotations(annotations)
+	return obj
+}
+
+// SetField set the value of the field by the path separated by '.',eg: SetField("spec.replicas", 3),
+// the missing maps in the path are created, use '\.' for the dot in key,eg: "metadata.labels.app\.kubernetes\.io/name",
+// value can be any value which can be marshaled into json,eg: int,[]string,v1.Container.
+// list index in path is not supported, set the whole list instead.
+func (obj *CustomResource) SetField(path string, value interface{}) *CustomResource {
+	fields, err := splitFieldPath(path)
+	if err != nil {
+		obj.error(fieldErrorf("SetField", "%v", err))
+		return obj
+	}
+	jsonValue, err := toJSONValue(value)
+	if err != nil {
+		obj.error(fieldErrorf("SetField", "path %s:%v", path, err))
+		return obj
+	}
+	if err := unstructured.SetNestedField(obj.cr.Object, jsonValue, fields...); err != nil {
+		obj.error(fieldErrorf("SetField", "path %s:%v", path, err))
+	}
+	return obj
+}
+
+// SetNestedField set the value of the field by the path separated by '.',it is the same as SetField()
+func (obj *CustomResource) SetNestedField(path string, value interface{}) *CustomResource {
+	return obj.SetField(path, value)
+}
+
+// SetNestedMap merge values into the map of the path,the keys which are not in values are kept,
+// eg: SetNestedMap("spec.resources.limits", map[string]interface{}{"cpu": "1"})
+func (obj *CustomResource) SetNestedMap(path string, values map[string]interface{}) *CustomResource {
+	fields, err := splitFieldPath(path)
+	if err != nil {
+		obj.error(fieldErrorf("SetNestedMap", "%v", err))
+		return obj
+	}
+	existing, _, err := unstructured.NestedFieldNoCopy(obj.cr.Object, fields...)
+	if err != nil {
+		obj.error(fieldErrorf("SetNestedMap", "path %s:%v", path, err))
+		return obj
+	}
+	merged, ok := existing.(map[string]interface{})
+	if existing != nil && !ok {
+		obj.error(fieldErrorf("SetNestedMap", "path %s:the field is %T,not map", path, existing))
+		return obj
+	}
+	if merged == nil {
+		merged = make(map[string]interface{}, len(values))
+	}
+	for key, value := range values {
+		jsonValue, err := toJSONValue(value)
+		if err != nil {
+			obj.error(fieldErrorf("SetNestedMap", "path %s:%v", path, err))
+			return obj
+		}
+		merged[key] = jsonValue
+	}
+	if err := unstructured.SetNestedMap(obj.cr.Object, merged, fields...); err != nil {
+		obj.error(fieldErrorf("SetNestedMap", "path %s:%v", path, err))
+	}
+	return obj
+}
+
+// RemoveField remove the field by the path separated by '.'
+func (obj *CustomResource) RemoveField(path string) *CustomResource {
+	fields, err := splitFieldPath(path)
+	if err != nil {
+		obj.error(fieldErrorf("RemoveField", "%v", err))
+		return obj
+	}
+	unstructured.RemoveNestedField(obj.cr.Object, fields...)
+	return obj
+}
+
+// RequireFields check the fields of the paths exist in Finish(),it is the shortcut of AddCheck()
+func (obj *CustomResource) RequireFields(paths ...string) *CustomResource {
+	for _, path := range paths {
+		fields, err := splitFieldPath(path)
+		if err != nil {
+			obj.error(fieldErrorf("RequireFields", "%v", err))
+			return obj
+		}
+		path := path
+		obj.AddCheck(func(cr *unstructured.Unstructured) error {
+			if _, found, _ := unstructured.NestedFieldNoCopy(cr.Object, fields...); !found {
+				return fmt.Errorf("%s is not allowed to be empty", path)
+			}
+			return nil
+		})
+	}
+	return obj
+}
+
+// AddCheck add the check which is run in Finish() and Validate() in order,
+// it is used to check the fields required by the CRD,because beku doesn't know its schema.
+func (obj *CustomResource) AddCheck(check func(cr *unstructured.Unstructured) error) *CustomResource {
+	if check == nil {
+		obj.error(fieldError("AddCheck", "check is not allowed to be nil"))
+		return obj
+	}
+	obj.checks = append(obj.checks, check)
+	return obj
+}
+
+// String the current CustomResource as yaml, the pending error is written on the top as comment.
+func (obj *CustomResource) String() string {
+	return dump(obj.cr.GetKind(), obj.cr, obj.err)
+}
+
+// Dump print the current CustomResource and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *CustomResource) Dump() *CustomResource {
+	fmt.Println(obj.String())
+	return obj
+}
+
+func (obj *CustomResource) error(err error) {
+	obj.err = appendError(obj.err, err)
+}
+
+func (obj *CustomResource) verify() error {
+	var errs error
+	kind := obj.cr.GetKind()
+	if !verifyString(obj.cr.GetName()) {
+		errs = appendError(errs, fieldError(kind+".Name", "is not allowed to be empty"))
+	}
+	for _, check := range obj.checks {
+		if err := check(obj.cr); err != nil {
+			errs = appendError(errs, fmt.Errorf("%s %s check failed:%v", kind, obj.cr.GetName(), err))
+		}
+	}
+	return errs
+}
+
+// splitFieldPath split the path by '.', '\.' is the dot in field name
+func splitFieldPath(path string) ([]string, error) {
+	if !verifyString(path) {
+		return nil, errors.New("path is not allowed to be empty")
+	}
+	var (
+		fields []string
+		field  strings.Builder
+	)
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path) && path[i+1] == '.':
+			field.WriteByte('.')
+			i++
+		case path[i] == '.':
+			fields = append(fields, field.String())
+			field.Reset()
+		default:
+			field.WriteByte(path[i])
+		}
+	}
+	fields = append(fields, field.String())
+	for _, f := range fields {
+		if f == "" {
+			return nil, fmt.Errorf("path %s has empty field", path)
+		}
+	}
+	return fields, nil
+}
+
+// toJSONValue translate value into the value which unstructured supports:
+// map[string]interface{},[]interface{},string,int64,float64,bool and nil
+func toJSONValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil, string, bool, int64, float64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	}
+	byts, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(byts))
+	decoder.UseNumber()
+	var jsonValue interface{}
+	if err := decoder.Decode(&jsonValue); err != nil {
+		return nil, err
+	}
+	return normalizeNumbers(jsonValue), nil
+}
+
+// normalizeNumbers translate json.Number and float64 integers into int64,
+// so the fields can be read by unstructured.NestedInt64
+func normalizeNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeNumbers(item)
+		}
+	case []interface{}:
+		for index, item := range v {
+			v[index] = normalizeNumbers(item)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v)
+		}
+	}
+	return value
+}
@@ -1,41 +1,91 @@
 package beku
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
+	"fmt"
 
-	"github.com/ghodss/yaml"
 	"k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 )
 
 // DaemonSet include Kubernets resource object DaemonSet and error
 type DaemonSet struct {
-	ds  *v1.DaemonSet
-	err error
+	PodSetters[*DaemonSet]
+	ds        *v1.DaemonSet
+	err       error
+	verifiers []func(*v1.DaemonSet) error
 }
 
 // NewDS create DaemonSet(ds) and chain function call begin with this function.
-func NewDS() *DaemonSet { return &DaemonSet{ds: &v1.DaemonSet{}} }
+func NewDS() *DaemonSet { return (&DaemonSet{ds: &v1.DaemonSet{}}).withPodSetters() }
+
+// DaemonSetFrom create DaemonSet from the existing DaemonSet,eg: the one got by client,
+// so it can be changed by the chain function and released again,
+// ds is deep copied and the fields which are not changed by the chain function are kept.
+func DaemonSetFrom(ds *v1.DaemonSet) *DaemonSet {
+	if ds == nil {
+		return NewDS()
+	}
+	return (&DaemonSet{ds: ds.DeepCopy()}).withPodSetters()
+}
 
 // Finish Chain function call end with this function
 // return real DaemonSet(really DaemonSet is kubernetes resource object DaemonSet and error
 // In the function, it will check necessary parameters、input the default field
 func (obj *DaemonSet) Finish() (*v1.DaemonSet, error) {
-	obj.verify()
+	return obj.ds, appendError(obj.err, obj.verify())
+}
+
+// Validate check DaemonSet necessary value like Finish(),and return the problems as FieldError,
+// but DaemonSet is not changed, no Kind,APIVersion or default field is input,
+// so it can be used to check a partially built DaemonSet.
+func (obj *DaemonSet) Validate() []FieldError {
+	cp := (&DaemonSet{ds: obj.ds.DeepCopy(), verifiers: obj.verifiers, err: obj.err}).withPodSetters()
+	return FieldErrors(appendError(cp.err, cp.verify()))
+}
+
+// Clone deep copy DaemonSet with the pending error,the clone and DaemonSet don't share any field,eg: containers and volumes,
+// so the clones of one base builder can be specialized in parallel goroutines,eg: per-tenant DaemonSets.
+// the builder itself is not safe for concurrent use,clone it for every goroutine.
+func (obj *DaemonSet) Clone() *DaemonSet {
+	return (&DaemonSet{ds: obj.ds.DeepCopy(), err: obj.err, verifiers: append([]func(*v1.DaemonSet) error(nil), obj.verifiers...)}).withPodSetters()
+}
+
+// FinishObject same as Finish(), but return DaemonSet as runtime.Object,
+// so DaemonSet can be used as Builder, eg: add into Bundle.
+func (obj *DaemonSet) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// FinishFor same as Finish(), but output DaemonSet as the apiVersion selected by opts,eg: extensions/v1beta1 for the old clusters,
+// WithAPIVersion() select it directly,WithClusterVersion() and WithClusterOf() select the newest one the cluster serves.
+func (obj *DaemonSet) FinishFor(opts ...FinishOption) (runtime.Object, error) {
+	ds, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	return finishFor(ds, opts)
+}
+
+// FinishUnchecked return DaemonSet without the checks and default fields of Finish(),only Kind and APIVersion are input,
+// it is for the partial DaemonSet which is patched onto the existing one,the error of the chain is still returned.
+func (obj *DaemonSet) FinishUnchecked() (*v1.DaemonSet, error) {
+	obj.ds.Kind, obj.ds.APIVersion = "DaemonSet", "apps/v1"
 	return obj.ds, obj.err
 }
 
 // JSONNew use json data create DaemonSet
 func (obj *DaemonSet) JSONNew(jsonbyts []byte) *DaemonSet {
-	obj.error(json.Unmarshal(jsonbyts, obj.ds))
+	obj.error(decodeJSON(jsonbyts, obj.ds))
 	return obj
 }
 
 // YAMLNew use yaml data create DaemonSet
 func (obj *DaemonSet) YAMLNew(yamlbyts []byte) *DaemonSet {
-	obj.error(yaml.Unmarshal(yamlbyts, obj.ds))
+	obj.error(decodeYAML(yamlbyts, obj.ds))
 	return obj
 }
 
@@ -73,10 +123,80 @@ func (obj *DaemonSet) SetLabels(labels map[string]string) *DaemonSet {
 	return obj
 }
 
+// AddLabel add or overwrite one label of DaemonSet,the other labels are kept,
+// unlike SetLabels() which replaces all labels,eg: the labels loaded by YAMLNew().
+func (obj *DaemonSet) AddLabel(key, value string) *DaemonSet {
+	obj.error(addLabel(obj.ds, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of DaemonSet,the other annotations are kept
+func (obj *DaemonSet) AddAnnotation(key, value string) *DaemonSet {
+	obj.error(addAnnotation(obj.ds, key, value))
+	return obj
+}
+
+// MergeLabels add or overwrite the labels of DaemonSet,the other labels are kept,unlike SetLabels() which replaces all labels
+func (obj *DaemonSet) MergeLabels(labels map[string]string) *DaemonSet {
+	obj.error(mergeLabels(obj.ds, labels))
+	return obj
+}
+
+// MergeAnnotations add or overwrite the annotations of DaemonSet,the other annotations are kept
+func (obj *DaemonSet) MergeAnnotations(annotations map[string]string) *DaemonSet {
+	obj.error(mergeAnnotations(obj.ds, annotations))
+	return obj
+}
+
+// MergePodLabels add or overwrite the labels of the Pod template,the other labels and the selector are kept,
+// the labels of the selector are not allowed to be changed,otherwise the Pods are not selected any more.
+func (obj *DaemonSet) MergePodLabels(labels map[string]string) *DaemonSet {
+	obj.error(mergePodLabels(&obj.ds.Spec.Template, obj.ds.Spec.Selector, labels))
+	return obj
+}
+
+// SetAppKubernetesLabels set the recommended app.kubernetes.io labels on DaemonSet and the Pod template,eg: name mysql,
+// version 5.7.21,component database and part-of wordpress,the empty values except name are skipped,the selector is not changed.
+func (obj *DaemonSet) SetAppKubernetesLabels(name, version, component, partOf string) *DaemonSet {
+	labels, err := appKubernetesLabels(name, version, component, partOf)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.error(mergeLabels(obj.ds, labels))
+	obj.error(mergePodLabels(&obj.ds.Spec.Template, obj.ds.Spec.Selector, labels))
+	return obj
+}
+
+// SetPrometheusScrape set the prometheus.io annotations of the Pod template,so the Pods are scraped by
+// the Prometheus annotation-based discovery,port is the metrics port,path default /metrics.
+func (obj *DaemonSet) SetPrometheusScrape(port int32, path string) *DaemonSet {
+	annotations, err := prometheusScrapeAnnotations(port, path)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.error(mergeAnnotations(&obj.ds.Spec.Template, annotations))
+	return obj
+}
+
+// AnnotateConfigChecksum write the checksum of the data of the ConfigMaps and Secrets used by the Pods
+// as Pod template annotation checksum/config,so the Pods are restarted when the config is changed without renaming them.
+// call it after the data of the ConfigMaps and Secrets are set.
+func (obj *DaemonSet) AnnotateConfigChecksum(cms []*ConfigMap, secrets []*Secret) *DaemonSet {
+	checksum, err := configChecksum(cms, secrets)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.error(addAnnotation(&obj.ds.Spec.Template, ConfigChecksumAnnotation, checksum))
+	return obj
+}
+
 // SetSelector set DaemonSet(ds) Selector and Set Pod Label
 // The Pod that matches the seletor will be selected, DaemonSet will controller the Pod.
 func (obj *DaemonSet) SetSelector(selector map[string]string) *DaemonSet {
-	obj.ds.Spec.Template.SetLabels(selector)
+	obj.ds.Spec.Template.SetLabels(copyLabels(selector))
 	if obj.ds.Spec.Selector == nil {
 		obj.ds.Spec.Selector = &metav1.LabelSelector{
 			MatchLabels: selector,
@@ -93,135 +213,135 @@ func (obj *DaemonSet) SetPodLabels(labels map[string]string) *DaemonSet {
 	return obj
 }
 
-// SetContainer set DaemonSet container
-// name Not required when only one Container,you can input "".
-// when many container this Field is necessary and cann't repeat
-// image is necessary, image very important
-// containerPort container port,this is necessary
-func (obj *DaemonSet) SetContainer(name, image string, containerPort int32) *DaemonSet {
-	obj.error(setContainer(&obj.ds.Spec.Template, name, image, containerPort))
-	return obj
-}
-
-// SetAnnotations set DaemonSet annotations
-func (obj *DaemonSet) SetAnnotations(annotations map[string]string) *DaemonSet {
-	if len(obj.ds.Annotations) <= 0 {
-		obj.ds.Annotations = annotations
+// SetPodTemplate replace the Pod template of DaemonSet by PodTemplate which is built by NewPodTemplate(),
+// PodTemplate is copied,so it can be attached to many workloads,
+// the labels of PodTemplate are used as selector when the selector is not set.
+func (obj *DaemonSet) SetPodTemplate(tpl *PodTemplate) *DaemonSet {
+	template, err := podTemplateOf(tpl)
+	if err != nil {
+		obj.error(err)
 		return obj
 	}
-	for key, value := range annotations {
-		obj.ds.Annotations[key] = value
+	obj.ds.Spec.Template = *template
+	if obj.ds.Spec.Selector == nil && len(template.GetLabels()) > 0 {
+		obj.SetSelector(template.GetLabels())
 	}
 	return obj
 }
 
-// SetPodQos set pod  quality of service
-// qosClass: is quality of service,the value only 'Guaranteed','Burstable' and 'BestEffort'
-// autoSet: If your previous settings do not meet the requirements of PodQoS, we will automatically set
-func (obj *DaemonSet) SetPodQos(qosClass string, autoSet ...bool) *DaemonSet {
-	obj.SetAnnotations(setQosMap(obj.ds.Annotations, qosClass, autoSet...))
+// MatchIn add a match expression on DaemonSet selector: the value of label key is one of values,
+// it can be called many times and all the expressions are ANDed.
+func (obj *DaemonSet) MatchIn(key string, values ...string) *DaemonSet {
+	obj.error(addMatchExpression(&obj.ds.Spec.Selector, key, LabelSelectorOpIn, values))
 	return obj
 }
 
-// SetHTTPLiveness set container liveness of http style
-// port: required
-// path: http request URL,eg: /api/v1/posts/1
-// initDelaySec: how long time after the first start of the program the probe is executed for the first time.(sec)
-// timeoutSec: http request timeout seconds,defaults to 1 second. Minimum value is 1.
-// periodSec: how often does the probe??defaults to 1 second. Minimum value is 1,Except for the first time?
-// headers: headers[0] is HTTP Header, do not fill if you do not need to set
-// on the other hand, only **first container** will be set livenessProbe
-func (obj *DaemonSet) SetHTTPLiveness(port int, path string, initDelaySec, timeoutSec, periodSec int32, headers ...map[string]string) *DaemonSet {
-	setLiveness(&obj.ds.Spec.Template, httpProbe(port, path, initDelaySec, timeoutSec, periodSec, headers...))
+// MatchNotIn add a match expression on DaemonSet selector: the value of label key is not any of values
+func (obj *DaemonSet) MatchNotIn(key string, values ...string) *DaemonSet {
+	obj.error(addMatchExpression(&obj.ds.Spec.Selector, key, LabelSelectorOpNotIn, values))
 	return obj
 }
 
-// SetCMDLiveness set container liveness of cmd style
-// cmd: execute liveness probe as commond line
-// timeoutSec: http request timeout seconds,defaults to 1 second. Minimum value is 1.
-// periodSec: how often does the probe??defaults to 1 second. Minimum value is 1,Except for the first time?
-// headers: headers[0] is HTTP Header, do not fill if you do not need to set
-// on the other hand, only **first container** will be set livenessProbe
-func (obj *DaemonSet) SetCMDLiveness(cmd []string, initDelaySec, timeoutSec, periodSec int32) *DaemonSet {
-	setLiveness(&obj.ds.Spec.Template, cmdProbe(cmd, initDelaySec, timeoutSec, periodSec))
+// MatchExists add a match expression on DaemonSet selector: the label key exists
+func (obj *DaemonSet) MatchExists(key string) *DaemonSet {
+	obj.error(addMatchExpression(&obj.ds.Spec.Selector, key, LabelSelectorOpExists, nil))
 	return obj
 }
 
-// SetTCPLiveness set container liveness of tcp style
-// host: default is ""
-// port: required
-// timeoutSec: http request timeout seconds,defaults to 1 second. Minimum value is 1.
-// periodSec: how often does the probe??defaults to 1 second. Minimum value is 1,Except for the first time?
-// headers: headers[0] is HTTP Header, do not fill if you do not need to set
-// on the other hand, only **first container** will be set livenessProbe
-func (obj *DaemonSet) SetTCPLiveness(host string, port int, initDelaySec, timeoutSec, periodSec int32) *DaemonSet {
-	setLiveness(&obj.ds.Spec.Template, tcpProbe(host, port, initDelaySec, timeoutSec, periodSec))
+// MatchDoesNotExist add a match expression on DaemonSet selector: the label key does not exist
+func (obj *DaemonSet) MatchDoesNotExist(key string) *DaemonSet {
+	obj.error(addMatchExpression(&obj.ds.Spec.Selector, key, LabelSelectorOpDoesNotExist, nil))
 	return obj
 }
 
-// SetHTTPReadness set container readness
-// initDelaySec: how long time after the first start of the program the probe is executed for the first time.(sec)
-// timeoutSec: http request timeout seconds,defaults to 1 second. Minimum value is 1.
-// periodSec: how often does the probe??defaults to 1 second. Minimum value is 1,Except for the first time?
-// on the other hand, only **first container** will be set livenessProbe
-func (obj *DaemonSet) SetHTTPReadness(port int, path string, initDelaySec, timeoutSec, periodSec int32, headers ...map[string]string) *DaemonSet {
-	setReadness(&obj.ds.Spec.Template, httpProbe(port, path, initDelaySec, timeoutSec, periodSec, headers...))
+// SetContainer set DaemonSet container
+// name Not required when only one Container,you can input "".
+// when many container this Field is necessary and cann't repeat
+// image is necessary, image very important
+// containerPort container port,this is necessary
+func (obj *DaemonSet) SetContainer(name, image string, containerPort int32) *DaemonSet {
+	obj.error(setContainer(&obj.ds.Spec.Template, name, image, containerPort))
 	return obj
 }
 
-// SetCMDReadness set container readness of cmd style
-// cmd: execute readness probe as commond line
-// timeoutSec: http request timeout seconds,defaults to 1 second. Minimum value is 1.
-// periodSec: how often does the probe? defaults to 1 second. Minimum value is 1,Except for the first time?
-// headers: headers[0] is HTTP Header, do not fill if you do not need to set
-// on the other hand, only **first container** will be set livenessProbe
-func (obj *DaemonSet) SetCMDReadness(cmd []string, initDelaySec, timeoutSec, periodSec int32) *DaemonSet {
-	setReadness(&obj.ds.Spec.Template, cmdProbe(cmd, initDelaySec, timeoutSec, periodSec))
+// SetHostPort expose the containerPort of the container on every node by hostPort,
+// eg: ingress controller and node-local listener,call it after SetContainer(),hostPort 0 removes it.
+// hostPort is only allowed in DaemonSet,because the Pods of Deployment and StatefulSet on the same node conflict.
+func (obj *DaemonSet) SetHostPort(container string, containerPort, hostPort int32) *DaemonSet {
+	obj.error(setHostPort(&obj.ds.Spec.Template, container, containerPort, hostPort))
 	return obj
 }
 
-// SetTCPReadness set container readness of tcp style
-// host: default is ""
-// port: required
-// timeoutSec: http request timeout seconds,defaults to 1 second. Minimum value is 1.
-// periodSec: how often does the probe? defaults to 1 second. Minimum value is 1,Except for the first time?
-// headers: headers[0] is HTTP Header, do not fill if you do not need to set
-// on the other hand, only **first container** will be set livenessProbe
-func (obj *DaemonSet) SetTCPReadness(host string, port int, initDelaySec, timeoutSec, periodSec int32) *DaemonSet {
-	setReadness(&obj.ds.Spec.Template, tcpProbe(host, port, initDelaySec, timeoutSec, periodSec))
+// SetAnnotations set DaemonSet annotations
+func (obj *DaemonSet) SetAnnotations(annotations map[string]string) *DaemonSet {
+	if len(obj.ds.Annotations) <= 0 {
+		obj.ds.Annotations = annotations
+		return obj
+	}
+	for key, value := range annotations {
+		obj.ds.Annotations[key] = value
+	}
 	return obj
 }
 
-// SetPVClaim set DaemonSet PersistentVolumeClaimVolumeSource
-// params:
-// volumeName: this is Custom field,you can define VolumeSource name,will be used of the container MountPath,
-// claimName: this is PersistentVolumeClaim(PVC) name,the PVC and DaemonSet must on same namespace and exist.
-func (obj *DaemonSet) SetPVClaim(volumeName, claimName string) *DaemonSet {
-	obj.error(setPVClaim(&obj.ds.Spec.Template, volumeName, claimName))
+// SetChangeCause set the change-cause annotation of DaemonSet,
+// it is shown as CHANGE-CAUSE of the revision by `kubectl rollout history`,eg: "release v1.2: fix login".
+func (obj *DaemonSet) SetChangeCause(msg string) *DaemonSet {
+	if !verifyString(msg) {
+		obj.error(fieldError("SetChangeCause", "msg is not allowed to be empty"))
+		return obj
+	}
+	obj.ds.Annotations = setChangeCause(obj.ds.Annotations, msg)
 	return obj
 }
 
-//SetPVCMounts mount PersistentVolumeClaim on container
-// params:
-// volumeName:the param is SetPVClaim() function volumeName,and when you call SetPVCMounts function you must call SetPVClaim function,and no order.
-// on the other hand SetPVCMounts() function only mount first Container,and On the Container you can volumeMount many PersistentVolumeClaim.
-// mountPath: runtime container dir eg:/var/lib/mysql
-func (obj *DaemonSet) SetPVCMounts(volumeName, mountPath string) *DaemonSet {
-	obj.error(setPVCMounts(&obj.ds.Spec.Template, volumeName, mountPath))
+// History get the rollout history of DaemonSet on Kubernetes in ascending order of revision,like `kubectl rollout history`,
+// client is the Kubernetes clientset,the registered one is used when it is nil.
+func (obj *DaemonSet) History(client kubernetes.Interface) ([]RolloutRevision, error) {
+	if !verifyString(obj.ds.GetName()) {
+		return nil, errors.New("DaemonSet History err,name is not allowed to be empty")
+	}
+	client, err := clientOrRegistered(client)
+	if err != nil {
+		return nil, err
+	}
+	return daemonSetHistory(client, obj.ds.GetNamespace(), obj.ds.GetName())
+}
+
+// SetPodQos set pod  quality of service
+// qosClass: is quality of service,the value only 'Guaranteed','Burstable' and 'BestEffort'
+// autoSet: If your previous settings do not meet the requirements of PodQoS, we will automatically set
+func (obj *DaemonSet) SetPodQos(qosClass string, autoSet ...bool) *DaemonSet {
+	obj.SetAnnotations(setQosMap(obj.ds.Annotations, qosClass, autoSet...))
 	return obj
 }
 
-// SetPodPriorityClass set DaemonSet Pod Priority
-// priorityClassName is Kubernetes resource object PriorityClass name
-// priorityClassName must already exists in kubernetes cluster
-func (obj *DaemonSet) SetPodPriorityClass(priorityClassName string) *DaemonSet {
-	obj.error(setPodPriorityClass(&obj.ds.Spec.Template, priorityClassName))
+// Resize resize the container of the running Pods of DaemonSet on Kubernetes in place,
+// the container is restarted only when its resize policy of the changed resource is RestartContainer.
+// the Pod template is not changed, call SetResourceLimit() and Apply() to keep the resources in the next rollout.
+// client is the Kubernetes clientset,the registered one is used when it is nil,Kubernetes 1.33+ is required.
+func (obj *DaemonSet) Resize(ctx context.Context, client kubernetes.Interface, container string, newResources ResourceRequirements) error {
+	selector := obj.ds.Spec.Selector
+	if selector == nil {
+		selector = &metav1.LabelSelector{MatchLabels: obj.ds.Spec.Template.GetLabels()}
+	}
+	client, err := clientOrRegistered(client)
+	if err != nil {
+		return err
+	}
+	return resizePods(ctx, client, obj.ds.GetNamespace(), selector, container, newResources)
+}
+
+// SetHostname set the hostname of the Pods of DaemonSet,the Pod name is used when it is not set
+func (obj *DaemonSet) SetHostname(hostname string) *DaemonSet {
+	obj.error(setHostname(&obj.ds.Spec.Template, hostname))
 	return obj
 }
 
-// SetEnvs set Pod Environmental variable
-func (obj *DaemonSet) SetEnvs(envMap map[string]string) *DaemonSet {
-	obj.error(setEnvs(&obj.ds.Spec.Template, envMap))
+// SetSubdomain set the subdomain of the Pods of DaemonSet,the Pods are resolved as <hostname>.<subdomain>
+// when the headless Service of the subdomain name selects them.
+func (obj *DaemonSet) SetSubdomain(subdomain string) *DaemonSet {
+	obj.error(setSubdomain(&obj.ds.Spec.Template, subdomain))
 	return obj
 }
 
@@ -244,9 +364,14 @@ func (obj *DaemonSet) SetHistoryLimit(limit int32) *DaemonSet {
 	return obj
 }
 
-// SetImagePullSecrets set pod pull secret
-func (obj *DaemonSet) SetImagePullSecrets(secretName string) *DaemonSet {
-	setImagePullSecrets(&obj.ds.Spec.Template, secretName)
+// SetImagePullPolicy set the pull policy of all containers of DaemonSet,policy is Always,Never or IfNotPresent,
+// it is the same as ImagePullPolicy() but the policy is checked,default is IfNotPresent.
+func (obj *DaemonSet) SetImagePullPolicy(policy string) *DaemonSet {
+	if err := verifyPullPolicy("SetImagePullPolicy", policy); err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.error(addAnnotation(obj.ds, ImagePullPolicyKey, policy))
 	return obj
 }
 
@@ -265,11 +390,11 @@ func (obj *DaemonSet) Release() (*v1.DaemonSet, error) {
 	if err != nil {
 		return nil, err
 	}
-	client, err := GetKubeClient()
+	client, err := getKubeInterface()
 	if err != nil {
 		return nil, err
 	}
-	return client.AppsV1().DaemonSets(ds.GetNamespace()).Create(ds)
+	return client.AppsV1().DaemonSets(ds.GetNamespace()).Create(context.TODO(), ds, metav1.CreateOptions{})
 }
 
 // GetPodLabel get pod labels
@@ -284,68 +409,199 @@ func (obj *DaemonSet) Apply() (*v1.DaemonSet, error) {
 	if err != nil {
 		return nil, err
 	}
-	client, err := GetKubeClient()
+	client, err := getKubeInterface()
 	if err != nil {
 		return nil, err
 	}
-	_, err = client.AppsV1().DaemonSets(ds.GetNamespace()).Get(ds.GetName(), metav1.GetOptions{})
+	existing, err := client.AppsV1().DaemonSets(ds.GetNamespace()).Get(context.TODO(), ds.GetName(), metav1.GetOptions{})
 	if err != nil {
-		return client.AppsV1().DaemonSets(ds.GetNamespace()).Create(ds)
+		return client.AppsV1().DaemonSets(ds.GetNamespace()).Create(context.TODO(), ds, metav1.CreateOptions{})
 	}
-	return client.AppsV1().DaemonSets(ds.GetNamespace()).Update(ds)
+	if err := verifySelectorUnchanged("DaemonSet", existing.Spec.Selector, ds.Spec.Selector); err != nil {
+		return nil, err
+	}
+	return client.AppsV1().DaemonSets(ds.GetNamespace()).Update(context.TODO(), ds, metav1.UpdateOptions{})
 }
 
-func (obj *DaemonSet) error(err error) {
+// DryRunApply finish DaemonSet and apply it by server-side apply with dryRun=All,so it is checked by Kubernetes but not persisted,
+// client is the Client of Kubernetes,the registered cluster is used when it is nil.
+func (obj *DaemonSet) DryRunApply(ctx context.Context, client *Client) (*unstructured.Unstructured, error) {
+	ds, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		if client, err = NewClient(nil, nil); err != nil {
+			return nil, err
+		}
+	}
+	return client.DryRunApply(ctx, ds)
+}
+
+// ValidateAgainstCluster check DaemonSet by the schema,admission webhooks and quota of Kubernetes like DryRunApply(),
+// the rejected fields are returned as FieldError,so they can be split by FieldErrors().
+func (obj *DaemonSet) ValidateAgainstCluster(ctx context.Context, client *Client) error {
+	_, err := obj.DryRunApply(ctx, client)
+	return err
+}
+
+// ApplyRecreate apply DaemonSet like Apply(),but when its selector is changed,which Apply() rejects because it is immutable,
+// the existing one is deleted in foreground and DaemonSet is created after its Pods are deleted,so the Pods are unavailable meanwhile.
+// client is the Client of Kubernetes,the registered cluster is used when it is nil,the wait of deletion is controlled by ctx.
+func (obj *DaemonSet) ApplyRecreate(ctx context.Context, client *Client) (*v1.DaemonSet, error) {
+	ds, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err = clientOrDefault(client)
+	if err != nil {
+		return nil, err
+	}
+	if err := recreateOnSelectorChange(ctx, client, ds); err != nil {
+		return nil, err
+	}
+	result, err := applyRecreated(ctx, client, ds)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*v1.DaemonSet), nil
+}
+
+// Watch watch DaemonSet on Kubernetes by its namespace and name,and call handler when it is added,updated or deleted,
+// client is the Kubernetes clientset,the registered one is used when it is nil,it blocks until ctx is done.
+func (obj *DaemonSet) Watch(ctx context.Context, client kubernetes.Interface, handler EventHandler) error {
 	if obj.err != nil {
-		return
+		return obj.err
 	}
-	obj.err = err
+	return watchObjects(ctx, client, []runtime.Object{obj.ds}, handler)
 }
 
-// verify check service necessary value, input the default field and input related data.
-func (obj *DaemonSet) verify() {
+// WatchPods watch the Pods selected by the selector of DaemonSet and call handler when they are added,updated or deleted,
+// eg: restart counting or log collection,the usage of client and ctx is the same as Watch().
+func (obj *DaemonSet) WatchPods(ctx context.Context, client kubernetes.Interface, handler EventHandler) error {
 	if obj.err != nil {
-		return
+		return obj.err
 	}
+	return watchPods(ctx, client, "DaemonSet", obj.ds.GetNamespace(), obj.ds.Spec.Selector, handler)
+}
+
+// Delete delete DaemonSet on Kubernetes by its name and namespace,
+// opts[0] sets the propagation policy and grace period,
+// eg: DeleteOptions{Propagation: PropagationOrphan} deletes DaemonSet and keeps its Pods.
+func (obj *DaemonSet) Delete(opts ...DeleteOptions) error {
+	if obj.err != nil {
+		return obj.err
+	}
+	options, err := newDeleteOptions("DaemonSet", obj.ds.GetName(), opts)
+	if err != nil {
+		return err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return err
+	}
+	return client.AppsV1().DaemonSets(obj.ds.GetNamespace()).Delete(context.TODO(), obj.ds.GetName(), *options)
+}
+
+// PatchAgainst finish DaemonSet and generate the strategic merge patch against the existing DaemonSet,eg: the one got by client,
+// the patch only changes the fields set by the chain function,the other fields of existing are kept,
+// so the fields managed by the other actors are not stomped,send it by Patch() with types.StrategicMergePatchType.
+func (obj *DaemonSet) PatchAgainst(existing *v1.DaemonSet) ([]byte, error) {
+	ds, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, errors.New("PatchAgainst err,existing DaemonSet is not allowed to be nil")
+	}
+	return patchAgainst(existing, ds)
+}
+
+// String the current DaemonSet as yaml, the pending error is written on the top as comment.
+// it doesn't check and input default field like Finish(), so the DaemonSet may be incomplete.
+func (obj *DaemonSet) String() string { return dump("DaemonSet", obj.ds, obj.err) }
+
+// Dump print the current DaemonSet and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *DaemonSet) Dump() *DaemonSet {
+	fmt.Println(obj.String())
+	return obj
+}
+
+// WithVerifier add the verifier which checks DaemonSet in Finish() and Validate() after the checks of beku,
+// it only affects this builder,so one call site can enforce extra invariants,eg: the image must be from the company registry.
+func (obj *DaemonSet) WithVerifier(verifier func(*v1.DaemonSet) error) *DaemonSet {
+	if verifier == nil {
+		obj.error(fieldError("WithVerifier", "verifier is not allowed to be nil"))
+		return obj
+	}
+	obj.verifiers = append(obj.verifiers, verifier)
+	return obj
+}
+
+func (obj *DaemonSet) error(err error) {
+	obj.err = appendError(obj.err, err)
+}
+
+// withPodSetters bind the pod-level setters to DaemonSet
+func (obj *DaemonSet) withPodSetters() *DaemonSet {
+	obj.PodSetters = PodSetters[*DaemonSet]{builder: obj}
+	return obj
+}
+
+// podTemplate get Pod template to change it
+func (obj *DaemonSet) podTemplate() *corev1.PodTemplateSpec { return &obj.ds.Spec.Template }
+
+// verify check service necessary value, input the default field and input related data.
+func (obj *DaemonSet) verify() error {
+	var errs error
 	if !verifyString(obj.ds.Name) {
-		obj.err = errors.New("DaemonSet.Name is not allowed to be empty")
-		return
+		errs = appendError(errs, fieldError("DaemonSet.Name", "is not allowed to be empty"))
 	}
-	if obj.ds.Spec.Template.Spec.Containers == nil || len(obj.ds.Spec.Template.Spec.Containers) < 1 {
-		obj.err = errors.New("DaemonSet.Spec.Template.Spec.Containers is not allowed to be empty")
-		return
+	errs = appendError(errs, verifyPodSpec("DaemonSet.Spec.Template.Spec", obj.ds.Spec.Template.Spec))
+	podLabels := obj.GetPodLabel()
+	if len(podLabels) < 1 {
+		errs = appendError(errs, fieldError("DaemonSet.Spec.Template.Labels", "is not allowed to be empty,you can call SetPodLabels input"))
+	} else if obj.ds.Spec.Selector == nil {
+		obj.SetSelector(podLabels)
 	}
-	if len(obj.GetPodLabel()) < 1 {
-		obj.err = errors.New("Pod Labels is not allowed to be empty,you can call SetPodLabels input")
-		return
+	if obj.ds.Spec.Selector != nil {
+		errs = appendError(errs, verifySelector("DaemonSet", obj.ds.Spec.Selector, podLabels))
 	}
 	//check qos set,if err!=nil, check need auto set qos
 	presentQos, err := qosCheck(obj.ds.Annotations[qosKey], obj.ds.Spec.Template.Spec)
-	if err != nil {
-		if obj.ds.Annotations[autoQosKey] == "true" {
-			err := obj.autoSetQos(presentQos)
-			if err != nil {
-				obj.err = err
-				return
-			}
-		} else {
-			obj.err = err
-			return
-		}
+	if err != nil && obj.ds.Annotations[autoQosKey] == "true" {
+		err = obj.autoSetQos(presentQos)
 	}
+	errs = appendError(errs, inField("DaemonSet.Spec.Template.Spec", err))
+	if errs != nil {
+		return errs
+	}
+	rewritePodImages(&obj.ds.Spec.Template.Spec, func() *corev1.PodSpec { return &obj.ds.Spec.Template.Spec })
 	obj.ds.Kind = "DaemonSet"
 	obj.ds.APIVersion = "app/v1"
-	if obj.ds.Annotations[ImagePullPolicyKey] == "" {
-		for index := range obj.ds.Spec.Template.Spec.Containers {
-			obj.ds.Spec.Template.Spec.Containers[index].ImagePullPolicy = corev1.PullIfNotPresent
-		}
-		return
-	}
-	policy := PullPolicy(obj.ds.Annotations[ImagePullPolicyKey]).ToK8s()
 	for index := range obj.ds.Spec.Template.Spec.Containers {
-		obj.ds.Spec.Template.Spec.Containers[index].ImagePullPolicy = policy
+		container := &obj.ds.Spec.Template.Spec.Containers[index]
+		container.ImagePullPolicy = containerPullPolicy(obj.ds.Annotations, container.ImagePullPolicy)
 	}
 	delete(obj.ds.Annotations, ImagePullPolicyKey)
+	// the verifiers check the finished object,so they are run after all checks and default fields
+	return obj.runVerifiers()
+}
+
+// runVerifiers run the verifiers added by WithVerifier() in order when DaemonSet is valid,
+// then the verifiers registered by RegisterVerifier().
+func (obj *DaemonSet) runVerifiers() error {
+	var errs error
+	for _, verifier := range obj.verifiers {
+		if err := verifier(obj.ds); err != nil {
+			errs = appendError(errs, fmt.Errorf("DaemonSet %s verifier failed:%v", obj.ds.GetName(), err))
+		}
+	}
+	if err := runRegisteredVerifiers(obj.ds); err != nil {
+		errs = appendError(errs, fmt.Errorf("DaemonSet %s %v", obj.ds.GetName(), err))
+	}
+	return errs
 }
 
 // autoSetQos auto set Pod of Deployment QOS
@@ -0,0 +1,63 @@
+package beku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+// AddEphemeralContainer add the ephemeral container into the running Pod to debug it,like `kubectl debug`,
+// eg: the image with shell and tools for the distroless container,the ephemeral container is not in the Pod template
+// and it can't be changed or removed after it is added.
+// targetContainer is the container whose process namespace is shared,it is empty for the Pod namespace,
+// cmd is empty for the entrypoint of image. client is the Kubernetes clientset,the registered one is used when it is nil.
+func AddEphemeralContainer(client kubernetes.Interface, namespace, podName, name, image, targetContainer string, cmd ...string) (*v1.Pod, error) {
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return nil, fmt.Errorf("AddEphemeralContainer err,name %q is not allowed:%s", name, strings.Join(errs, ","))
+	}
+	if !verifyString(image) {
+		return nil, errors.New("AddEphemeralContainer err,image is not allowed to be empty")
+	}
+	client, err := clientOrRegistered(client)
+	if err != nil {
+		return nil, err
+	}
+	pod, err := client.CoreV1().Pods(namespace).Get(context.TODO(), podName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("AddEphemeralContainer get Pod %s/%s err,%v", namespace, podName, err)
+	}
+	if err = addEphemeralContainer(pod, name, image, targetContainer, cmd); err != nil {
+		return nil, err
+	}
+	return client.CoreV1().Pods(namespace).UpdateEphemeralContainers(context.TODO(), podName, pod, metav1.UpdateOptions{})
+}
+
+// addEphemeralContainer append the ephemeral container into the Pod,the name must be unique in the Pod
+func addEphemeralContainer(pod *v1.Pod, name, image, targetContainer string, cmd []string) error {
+	if containerIndex(pod.Spec.Containers, name) >= 0 || containerIndex(pod.Spec.InitContainers, name) >= 0 {
+		return fmt.Errorf("AddEphemeralContainer err,container name %q is duplicated", name)
+	}
+	for _, container := range pod.Spec.EphemeralContainers {
+		if container.Name == name {
+			return fmt.Errorf("AddEphemeralContainer err,container name %q is duplicated", name)
+		}
+	}
+	if targetContainer != "" && containerIndex(pod.Spec.Containers, targetContainer) < 0 {
+		return fmt.Errorf("AddEphemeralContainer err,target container %q is not found", targetContainer)
+	}
+	container := v1.EphemeralContainer{TargetContainerName: targetContainer}
+	container.Name = name
+	container.Image = image
+	container.Command = append([]string(nil), cmd...)
+	// the debug container is used by kubectl attach,so it needs stdin and tty
+	container.Stdin = true
+	container.TTY = true
+	pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, container)
+	return nil
+}
@@ -0,0 +1,88 @@
+package beku
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/yaml"
+)
+
+// the limits of the documents loaded by JSONNew(),YAMLNew() and StreamManifests(),
+// they protect the programs which load the documents uploaded by users from very large or deeply nested documents.
+var (
+	// MaxDocumentSize is the max bytes of one yaml or json document,default 4MiB.
+	MaxDocumentSize = 4 << 20
+	// MaxDocumentDepth is the max nesting depth of objects and arrays in one document.
+	MaxDocumentDepth = 64
+)
+
+// decodeJSON decode json document into v with the limits,
+// the panic of decoding is recovered and returned as error.
+func decodeJSON(data []byte, v interface{}) (err error) {
+	defer recoverDecode(&err)
+	if err := checkJSON(data); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// decodeYAML decode yaml document into v with the limits
+func decodeYAML(data []byte, v interface{}) (err error) {
+	jsonDoc, err := yamlToJSON(data)
+	if err != nil {
+		return err
+	}
+	return decodeJSON(jsonDoc, v)
+}
+
+// yamlToJSON translate yaml document into json with the limits,
+// the json is checked again because yaml aliases can expand a small document into a very large one.
+func yamlToJSON(data []byte) (jsonDoc []byte, err error) {
+	defer recoverDecode(&err)
+	if len(data) > MaxDocumentSize {
+		return nil, fmt.Errorf("document size %d bytes exceeds the limit %d bytes", len(data), MaxDocumentSize)
+	}
+	if jsonDoc, err = yaml.YAMLToJSON(data); err != nil {
+		return nil, fmt.Errorf("document is not valid yaml:%v", err)
+	}
+	if err := checkJSON(jsonDoc); err != nil {
+		return nil, err
+	}
+	return jsonDoc, nil
+}
+
+// checkJSON check the size and the nesting depth of json document
+func checkJSON(data []byte) error {
+	if len(data) > MaxDocumentSize {
+		return fmt.Errorf("document size %d bytes exceeds the limit %d bytes", len(data), MaxDocumentSize)
+	}
+	depth, inString, escaped := 0, false, false
+	for _, c := range data {
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			if c == '\\' {
+				escaped = true
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '{' || c == '[':
+			if depth++; depth > MaxDocumentDepth {
+				return fmt.Errorf("document nesting depth exceeds the limit %d", MaxDocumentDepth)
+			}
+		case c == '}' || c == ']':
+			depth--
+		}
+	}
+	return nil
+}
+
+// recoverDecode return the panic of decoding as error
+func recoverDecode(err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("decode document panic:%v", r)
+	}
+}
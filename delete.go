@@ -0,0 +1,54 @@
+package beku
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// PropagationForeground the object is deleted after its dependents are deleted,eg: Pods of StatefulSet
+	PropagationForeground = "Foreground"
+	// PropagationBackground the object is deleted at once and its dependents are deleted by garbage collector
+	PropagationBackground = "Background"
+	// PropagationOrphan the object is deleted and its dependents are kept,eg: delete StatefulSet without killing Pods
+	PropagationOrphan = "Orphan"
+)
+
+// DeleteOptions is the options of Delete() of builders,the zero fields are not set
+type DeleteOptions struct {
+	// Propagation is PropagationForeground,PropagationBackground or PropagationOrphan,
+	// the default policy of the kind is used when it is empty.
+	Propagation string
+	// GracePeriodSeconds is the seconds before the object is deleted,0 means delete immediately,
+	// the default of the kind is used when it is nil.
+	GracePeriodSeconds *int64
+}
+
+// newDeleteOptions create delete options of Kubernetes by opts[0] for deleting the object of kind
+func newDeleteOptions(kind, name string, opts []DeleteOptions) (*metav1.DeleteOptions, error) {
+	if !verifyString(name) {
+		return nil, fmt.Errorf("%s Delete err,name is not allowed to be empty", kind)
+	}
+	options := &metav1.DeleteOptions{}
+	if len(opts) <= 0 {
+		return options, nil
+	}
+	switch opts[0].Propagation {
+	case "":
+	case PropagationForeground, PropagationBackground, PropagationOrphan:
+		propagation := metav1.DeletionPropagation(opts[0].Propagation)
+		options.PropagationPolicy = &propagation
+	default:
+		return nil, fmt.Errorf("%s Delete err,propagation %s is not allowed,only %s,%s or %s",
+			kind, opts[0].Propagation, PropagationForeground, PropagationBackground, PropagationOrphan)
+	}
+	if opts[0].GracePeriodSeconds != nil {
+		if *opts[0].GracePeriodSeconds < 0 {
+			return nil, fmt.Errorf("%s Delete err,GracePeriodSeconds is not allowed to be negative", kind)
+		}
+		grace := *opts[0].GracePeriodSeconds
+		options.GracePeriodSeconds = &grace
+	}
+	return options, nil
+}
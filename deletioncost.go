@@ -0,0 +1,68 @@
+package beku
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// PodDeletionCostAnnotation is the annotation of Pod,ReplicaSet deletes the Pods with lower cost first when it scales down
+const PodDeletionCostAnnotation = "controller.kubernetes.io/pod-deletion-cost"
+
+// setPodDeletionCost set the deletion cost annotation on Pod template,the cost must be in range of int32
+func setPodDeletionCost(podTemp *v1.PodTemplateSpec, cost int) error {
+	if cost < math.MinInt32 || cost > math.MaxInt32 {
+		return fmt.Errorf("SetPodDeletionCost err,cost %d is out of range of int32", cost)
+	}
+	if podTemp.Annotations == nil {
+		podTemp.Annotations = make(map[string]string, 1)
+	}
+	podTemp.Annotations[PodDeletionCostAnnotation] = strconv.Itoa(cost)
+	return nil
+}
+
+// setPodsDeletionCost patch the deletion cost annotation on the Pods,key is Pod name and value is the cost,
+// only the Pods selected by selector are allowed,so the Pods of other workloads are not changed by mistake.
+func setPodsDeletionCost(client kubernetes.Interface, namespace string, selector *metav1.LabelSelector, costs map[string]int) error {
+	podSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return err
+	}
+	if podSelector.Empty() {
+		return errors.New("SetPodsDeletionCost err,selector is not allowed to be empty")
+	}
+	pods := client.CoreV1().Pods(namespace)
+	for name, cost := range costs {
+		if cost < math.MinInt32 || cost > math.MaxInt32 {
+			return fmt.Errorf("SetPodsDeletionCost err,cost %d of Pod %s is out of range of int32", cost, name)
+		}
+		pod, err := pods.Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("SetPodsDeletionCost err,get Pod %s failed:%v", name, err)
+		}
+		if !podSelector.Matches(labels.Set(pod.Labels)) {
+			return fmt.Errorf("SetPodsDeletionCost err,Pod %s is not selected by %s", name, podSelector)
+		}
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]string{PodDeletionCostAnnotation: strconv.Itoa(cost)},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := pods.Patch(context.TODO(), name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return fmt.Errorf("SetPodsDeletionCost err,patch Pod %s failed:%v", name, err)
+		}
+	}
+	return nil
+}
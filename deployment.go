@@ -1,49 +1,125 @@
 package beku
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
+	"fmt"
 
-	"github.com/ghodss/yaml"
 	"k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 )
 
 // Deployment include Kubernetes resource object Deployment and error
 type Deployment struct {
-	dp  *v1.Deployment
-	err error
+	PodSetters[*Deployment]
+	dp        *v1.Deployment
+	err       error
+	verifiers []func(*v1.Deployment) error
+	presets   []Preset
+	// shared is true when dp.Spec is shared with Template, and the spec is copied before the first change.
+	shared bool
 }
 
 // NewDeployment create Deployment and Chain function call begin with this function.
-func NewDeployment() *Deployment { return &Deployment{dp: &v1.Deployment{}} }
+func NewDeployment() *Deployment { return (&Deployment{dp: &v1.Deployment{}}).withPodSetters() }
+
+// DeploymentFrom create Deployment from the existing Deployment,eg: the one got by client,
+// so it can be changed by the chain function and released again,
+// dp is deep copied and the fields which are not changed by the chain function are kept.
+func DeploymentFrom(dp *v1.Deployment) *Deployment {
+	if dp == nil {
+		return NewDeployment()
+	}
+	return (&Deployment{dp: dp.DeepCopy()}).withPodSetters()
+}
 
 // Finish Chain function call end with this function
 // return Kubernetes resource object Deployment and error.
 // In the function, it will check necessary parametersainput the default field
+// the spec shared with Template is verified lazily,it is only checked again after the instance changes it,
+// and it is copied before return,so the returned Deployment can be changed freely.
 func (obj *Deployment) Finish() (dp *v1.Deployment, err error) {
-	obj.verify()
-	dp, err = obj.dp, obj.err
-	return
+	err = appendError(obj.err, obj.verify())
+	obj.copyOnWrite()
+	return obj.dp, err
+}
+
+// Validate check Deployment necessary value like Finish(),and return the problems as FieldError,
+// but Deployment is not changed, no Kind,APIVersion or default field is input,
+// so it can be used to check a partially built Deployment.
+func (obj *Deployment) Validate() []FieldError {
+	cp := (&Deployment{dp: obj.dp.DeepCopy(), verifiers: obj.verifiers, presets: obj.presets, err: obj.err}).withPodSetters()
+	return FieldErrors(appendError(cp.err, cp.verify()))
+}
+
+// Clone deep copy Deployment with the pending error,the clone and Deployment don't share any field,eg: containers and volumes,
+// so the clones of one base builder can be specialized in parallel goroutines,eg: per-tenant Deployments.
+// the builder itself is not safe for concurrent use,clone it for every goroutine.
+func (obj *Deployment) Clone() *Deployment {
+	return (&Deployment{dp: obj.dp.DeepCopy(), err: obj.err,
+		verifiers: append([]func(*v1.Deployment) error(nil), obj.verifiers...), presets: append([]Preset(nil), obj.presets...)}).withPodSetters()
+}
+
+// Reset clear Deployment to the state of NewDeployment(),so the builder can be reused for the next Deployment,
+// the capacity of the containers and volumes is kept,so the Deployment returned by Finish() must not be used after it.
+func (obj *Deployment) Reset() *Deployment {
+	if obj.dp == nil || obj.shared {
+		// the spec shared with Template can't be reused
+		*obj = Deployment{dp: &v1.Deployment{}}
+		return obj.withPodSetters()
+	}
+	containers, volumes := obj.dp.Spec.Template.Spec.Containers[:0], obj.dp.Spec.Template.Spec.Volumes[:0]
+	*obj.dp = v1.Deployment{}
+	obj.dp.Spec.Template.Spec.Containers, obj.dp.Spec.Template.Spec.Volumes = containers, volumes
+	*obj = Deployment{dp: obj.dp}
+	return obj.withPodSetters()
+}
+
+// FinishObject same as Finish(), but return Deployment as runtime.Object,
+// so Deployment can be used as Builder, eg: add into Bundle.
+func (obj *Deployment) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// FinishFor same as Finish(), but output Deployment as the apiVersion selected by opts,eg: apps/v1beta2 for the old clusters,
+// WithAPIVersion() select it directly,WithClusterVersion() and WithClusterOf() select the newest one the cluster serves.
+func (obj *Deployment) FinishFor(opts ...FinishOption) (runtime.Object, error) {
+	dp, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	return finishFor(dp, opts)
+}
+
+// FinishUnchecked return Deployment without the checks and default fields of Finish(),only Kind and APIVersion are input,
+// it is for the partial Deployment which is patched onto the existing one,the error of the chain is still returned.
+func (obj *Deployment) FinishUnchecked() (*v1.Deployment, error) {
+	obj.dp.Kind, obj.dp.APIVersion = "Deployment", "apps/v1"
+	return obj.dp, obj.err
 }
 
 // JSONNew use json data create Deployment
 func (obj *Deployment) JSONNew(jsonbyts []byte) *Deployment {
-	obj.error(json.Unmarshal(jsonbyts, obj.dp))
+	obj.copyOnWrite()
+	obj.error(decodeJSON(jsonbyts, obj.dp))
 	return obj
 }
 
 // YAMLNew use yaml data create Deployment
 func (obj *Deployment) YAMLNew(yamlbyts []byte) *Deployment {
-	obj.error(yaml.Unmarshal(yamlbyts, obj.dp))
+	obj.copyOnWrite()
+	obj.error(decodeYAML(yamlbyts, obj.dp))
 	return obj
 }
 
 // Replace replace Deployment by Kubernetes resource object
 func (obj *Deployment) Replace(dp *v1.Deployment) *Deployment {
 	if dp != nil {
-		obj.dp = dp
+		obj.dp, obj.shared = dp, false
 	}
 	return obj
 }
@@ -74,24 +150,95 @@ func (obj *Deployment) SetLabels(labels map[string]string) *Deployment {
 	return obj
 }
 
+// AddLabel add or overwrite one label of Deployment,the other labels are kept,
+// unlike SetLabels() which replaces all labels,eg: the labels loaded by YAMLNew().
+func (obj *Deployment) AddLabel(key, value string) *Deployment {
+	obj.error(addLabel(obj.dp, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of Deployment,the other annotations are kept
+func (obj *Deployment) AddAnnotation(key, value string) *Deployment {
+	obj.error(addAnnotation(obj.dp, key, value))
+	return obj
+}
+
+// MergeLabels add or overwrite the labels of Deployment,the other labels are kept,unlike SetLabels() which replaces all labels
+func (obj *Deployment) MergeLabels(labels map[string]string) *Deployment {
+	obj.error(mergeLabels(obj.dp, labels))
+	return obj
+}
+
+// MergeAnnotations add or overwrite the annotations of Deployment,the other annotations are kept
+func (obj *Deployment) MergeAnnotations(annotations map[string]string) *Deployment {
+	obj.error(mergeAnnotations(obj.dp, annotations))
+	return obj
+}
+
+// MergePodLabels add or overwrite the labels of the Pod template,the other labels and the selector are kept,
+// the labels of the selector are not allowed to be changed,otherwise the Pods are not selected any more.
+func (obj *Deployment) MergePodLabels(labels map[string]string) *Deployment {
+	obj.error(mergePodLabels(obj.podTemplate(), obj.dp.Spec.Selector, labels))
+	return obj
+}
+
+// SetAppKubernetesLabels set the recommended app.kubernetes.io labels on Deployment and the Pod template,eg: name mysql,
+// version 5.7.21,component database and part-of wordpress,the empty values except name are skipped,the selector is not changed.
+func (obj *Deployment) SetAppKubernetesLabels(name, version, component, partOf string) *Deployment {
+	labels, err := appKubernetesLabels(name, version, component, partOf)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.error(mergeLabels(obj.dp, labels))
+	obj.error(mergePodLabels(obj.podTemplate(), obj.dp.Spec.Selector, labels))
+	return obj
+}
+
+// SetPrometheusScrape set the prometheus.io annotations of the Pod template,so the Pods are scraped by
+// the Prometheus annotation-based discovery,port is the metrics port,path default /metrics.
+func (obj *Deployment) SetPrometheusScrape(port int32, path string) *Deployment {
+	annotations, err := prometheusScrapeAnnotations(port, path)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.error(mergeAnnotations(obj.podTemplate(), annotations))
+	return obj
+}
+
+// AnnotateConfigChecksum write the checksum of the data of the ConfigMaps and Secrets used by the Pods
+// as Pod template annotation checksum/config,so the Pods are restarted when the config is changed without renaming them.
+// call it after the data of the ConfigMaps and Secrets are set.
+func (obj *Deployment) AnnotateConfigChecksum(cms []*ConfigMap, secrets []*Secret) *Deployment {
+	checksum, err := configChecksum(cms, secrets)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.error(addAnnotation(obj.podTemplate(), ConfigChecksumAnnotation, checksum))
+	return obj
+}
+
 // SetSelector set Deployment selector
 // set:
 // 1. Deployment.Spec.Selector
 // 2. Deployment.Spec.Template.Label(the Field is Pod Labels.)
 // and you can not be SetLabels
 func (obj *Deployment) SetSelector(labels map[string]string) *Deployment {
+	obj.copyOnWrite()
 	if len(labels) <= 0 {
-		obj.error(errors.New("SetSelector err,label is not allowed to be empty"))
+		obj.error(fieldError("SetSelector", "label is not allowed to be empty"))
 		return obj
 	}
 	if obj.dp.Spec.Selector == nil {
 		obj.dp.Spec.Selector = &metav1.LabelSelector{
 			MatchLabels: labels,
 		}
-		obj.dp.Spec.Template.SetLabels(labels)
+		obj.dp.Spec.Template.SetLabels(copyLabels(labels))
 		return obj
 	}
-	obj.dp.Spec.Template.SetLabels(labels)
+	obj.dp.Spec.Template.SetLabels(copyLabels(labels))
 	obj.dp.Spec.Selector.MatchLabels = labels
 	return obj
 }
@@ -108,12 +255,51 @@ func (obj *Deployment) SetAnnotations(annotations map[string]string) *Deployment
 	return obj
 }
 
+// SetChangeCause set the change-cause annotation of Deployment,
+// it is shown as CHANGE-CAUSE of the revision by `kubectl rollout history`,eg: "release v1.2: fix login".
+func (obj *Deployment) SetChangeCause(msg string) *Deployment {
+	if !verifyString(msg) {
+		obj.error(fieldError("SetChangeCause", "msg is not allowed to be empty"))
+		return obj
+	}
+	obj.dp.Annotations = setChangeCause(obj.dp.Annotations, msg)
+	return obj
+}
+
+// History get the rollout history of Deployment on Kubernetes in ascending order of revision,like `kubectl rollout history`,
+// client is the Kubernetes clientset,the registered one is used when it is nil.
+func (obj *Deployment) History(client kubernetes.Interface) ([]RolloutRevision, error) {
+	if !verifyString(obj.dp.GetName()) {
+		return nil, errors.New("Deployment History err,name is not allowed to be empty")
+	}
+	client, err := clientOrRegistered(client)
+	if err != nil {
+		return nil, err
+	}
+	return deploymentHistory(client, obj.dp.GetNamespace(), obj.dp.GetName())
+}
+
 // SetReplicas set Deployment replicas default 1
 func (obj *Deployment) SetReplicas(replicas int32) *Deployment {
 	obj.dp.Spec.Replicas = &replicas
 	return obj
 }
 
+// AutoscaleTo finish Deployment and create the HorizontalPodAutoscaler which scales it between min and max replicas
+// to keep the average cpu usage at cpuPct percent of the cpu requests,the HPA has the same namespace and name as Deployment,
+// eg: dp, hpa, err := NewDeployment()...SetResourceRequests("500m", "256Mi").AutoscaleTo(2, 10, 80)
+func (obj *Deployment) AutoscaleTo(min, max, cpuPct int32) (*v1.Deployment, *autoscalingv2.HorizontalPodAutoscaler, error) {
+	dp, err := obj.Finish()
+	if err != nil {
+		return nil, nil, err
+	}
+	hpa, err := autoscaleDeployment(obj, min, max, cpuPct)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dp, hpa, nil
+}
+
 // SetMinReadySeconds set Deployment minreadyseconds default 600
 func (obj *Deployment) SetMinReadySeconds(sec int32) *Deployment {
 	if sec < 0 {
@@ -123,92 +309,56 @@ func (obj *Deployment) SetMinReadySeconds(sec int32) *Deployment {
 	return obj
 }
 
-// SetHistoryLimit set Deployment history version numbers, limit default 10
-// the field is used to Rollback
-func (obj *Deployment) SetHistoryLimit(limit int32) *Deployment {
-	if limit <= 0 {
-		limit = 10
+// SetStrategyRollingUpdate set Deployment to replace the Pods by rolling update,
+// maxSurge is how many Pods can be created over replicas,maxUnavailable is how many Pods can be unavailable during update,
+// they are absolute numbers or percentages of replicas,eg: SetStrategyRollingUpdate("25%", "0"),they are not allowed to be both 0.
+func (obj *Deployment) SetStrategyRollingUpdate(maxSurge, maxUnavailable string) *Deployment {
+	surge, err := parseIntOrPercent("SetStrategyRollingUpdate", "maxSurge", maxSurge)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	unavailable, err := parseIntOrPercent("SetStrategyRollingUpdate", "maxUnavailable", maxUnavailable)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	if isZeroIntOrPercent(surge) && isZeroIntOrPercent(unavailable) {
+		obj.error(fieldError("SetStrategyRollingUpdate", "maxSurge and maxUnavailable are not allowed to be both 0"))
+		return obj
+	}
+	obj.dp.Spec.Strategy = v1.DeploymentStrategy{
+		Type:          v1.RollingUpdateDeploymentStrategyType,
+		RollingUpdate: &v1.RollingUpdateDeployment{MaxSurge: &surge, MaxUnavailable: &unavailable},
 	}
-	obj.dp.Spec.RevisionHistoryLimit = &limit
-	return obj
-}
-
-// SetHTTPLiveness set container liveness of http style
-// port: required
-// path: http request URL,eg: /api/v1/posts/1
-// initDelaySec: how long time after the first start of the program the probe is executed for the first time.(sec)
-// timeoutSec: http request timeout seconds,defaults to 1 second. Minimum value is 1.
-// periodSec: how often does the probe? defaults to 1 second. Minimum value is 1,Except for the first time?
-// headers: headers[0] is HTTP Header, do not fill if you do not need to set
-// on the other hand, only **first container** will be set livenessProbe
-func (obj *Deployment) SetHTTPLiveness(port int, path string, initDelaySec, timeoutSec, periodSec int32, headers ...map[string]string) *Deployment {
-	setLiveness(&obj.dp.Spec.Template, httpProbe(port, path, initDelaySec, timeoutSec, periodSec, headers...))
-	return obj
-}
-
-// SetCMDLiveness set container liveness of cmd style
-// cmd: execute liveness probe as commond line
-// timeoutSec: http request timeout seconds,defaults to 1 second. Minimum value is 1.
-// periodSec: how often does the probe??defaults to 1 second. Minimum value is 1,Except for the first time?
-// headers: headers[0] is HTTP Header, do not fill if you do not need to set
-// on the other hand, only **first container** will be set livenessProbe
-func (obj *Deployment) SetCMDLiveness(cmd []string, initDelaySec, timeoutSec, periodSec int32) *Deployment {
-	setLiveness(&obj.dp.Spec.Template, cmdProbe(cmd, initDelaySec, timeoutSec, periodSec))
-	return obj
-}
-
-// SetTCPLiveness set container liveness of tcp style
-// host: default is ""
-// port: required
-// timeoutSec: http request timeout seconds,defaults to 1 second. Minimum value is 1.
-// periodSec: how often does the probe??defaults to 1 second. Minimum value is 1,Except for the first time?
-// headers: headers[0] is HTTP Header, do not fill if you do not need to set
-// on the other hand, only **first container** will be set livenessProbe
-func (obj *Deployment) SetTCPLiveness(host string, port int, initDelaySec, timeoutSec, periodSec int32) *Deployment {
-	setLiveness(&obj.dp.Spec.Template, tcpProbe(host, port, initDelaySec, timeoutSec, periodSec))
-	return obj
-}
-
-// SetHTTPReadness set container readness
-// initDelaySec: how long time after the first start of the program the probe is executed for the first time.(sec)
-// timeoutSec: http request timeout seconds,defaults to 1 second. Minimum value is 1.
-// periodSec: how often does the probe??defaults to 1 second. Minimum value is 1,Except for the first time?
-// on the other hand, only **first container** will be set livenessProbe
-func (obj *Deployment) SetHTTPReadness(port int, path string, initDelaySec, timeoutSec, periodSec int32, headers ...map[string]string) *Deployment {
-	setReadness(&obj.dp.Spec.Template, httpProbe(port, path, initDelaySec, timeoutSec, periodSec, headers...))
 	return obj
 }
 
-// SetCMDReadness set container readness of cmd style
-// cmd: execute readness probe as commond line
-// timeoutSec: http request timeout seconds,defaults to 1 second. Minimum value is 1.
-// periodSec: how often does the probe? defaults to 1 second. Minimum value is 1,Except for the first time?
-// headers: headers[0] is HTTP Header, do not fill if you do not need to set
-// on the other hand, only **first container** will be set livenessProbe
-func (obj *Deployment) SetCMDReadness(cmd []string, initDelaySec, timeoutSec, periodSec int32) *Deployment {
-	setReadness(&obj.dp.Spec.Template, cmdProbe(cmd, initDelaySec, timeoutSec, periodSec))
+// SetStrategyRecreate set Deployment to kill all old Pods before the new ones are created,
+// eg: the application can't run two versions together,the Pods are unavailable during update.
+func (obj *Deployment) SetStrategyRecreate() *Deployment {
+	obj.dp.Spec.Strategy = v1.DeploymentStrategy{Type: v1.RecreateDeploymentStrategyType}
 	return obj
 }
 
-// SetTCPReadness set container readness of tcp style
-// host: default is ""
-// port: required
-// timeoutSec: http request timeout seconds,defaults to 1 second. Minimum value is 1.
-// periodSec: how often does the probe? defaults to 1 second. Minimum value is 1,Except for the first time?
-// headers: headers[0] is HTTP Header, do not fill if you do not need to set
-// on the other hand, only **first container** will be set livenessProbe
-func (obj *Deployment) SetTCPReadness(host string, port int, initDelaySec, timeoutSec, periodSec int32) *Deployment {
-	setReadness(&obj.dp.Spec.Template, tcpProbe(host, port, initDelaySec, timeoutSec, periodSec))
+// SetHistoryLimit set Deployment history version numbers, limit default 10
+// the old ReplicaSets are kept for RollbackToRevision() of Client
+func (obj *Deployment) SetHistoryLimit(limit int32) *Deployment {
+	if limit <= 0 {
+		limit = 10
+	}
+	obj.dp.Spec.RevisionHistoryLimit = &limit
 	return obj
 }
 
 // SetMatchExpressions set Deployment match expressions
 // the field is used to set complicated Label.
 func (obj *Deployment) SetMatchExpressions(ents []LabelSelectorRequirement) *Deployment {
+	obj.copyOnWrite()
 	if len(ents) <= 0 {
 		return obj
 	}
-	requirements := make([]metav1.LabelSelectorRequirement, 0)
+	requirements := make([]metav1.LabelSelectorRequirement, 0, len(ents))
 	for index := range ents {
 		requirements = append(requirements, metav1.LabelSelectorRequirement{
 			Key:      ents[index].Key,
@@ -226,6 +376,35 @@ func (obj *Deployment) SetMatchExpressions(ents []LabelSelectorRequirement) *Dep
 	return obj
 }
 
+// MatchIn add a match expression on Deployment selector: the value of label key is one of values,
+// it can be called many times and all the expressions are ANDed.
+func (obj *Deployment) MatchIn(key string, values ...string) *Deployment {
+	obj.copyOnWrite()
+	obj.error(addMatchExpression(&obj.dp.Spec.Selector, key, LabelSelectorOpIn, values))
+	return obj
+}
+
+// MatchNotIn add a match expression on Deployment selector: the value of label key is not any of values
+func (obj *Deployment) MatchNotIn(key string, values ...string) *Deployment {
+	obj.copyOnWrite()
+	obj.error(addMatchExpression(&obj.dp.Spec.Selector, key, LabelSelectorOpNotIn, values))
+	return obj
+}
+
+// MatchExists add a match expression on Deployment selector: the label key exists
+func (obj *Deployment) MatchExists(key string) *Deployment {
+	obj.copyOnWrite()
+	obj.error(addMatchExpression(&obj.dp.Spec.Selector, key, LabelSelectorOpExists, nil))
+	return obj
+}
+
+// MatchDoesNotExist add a match expression on Deployment selector: the label key does not exist
+func (obj *Deployment) MatchDoesNotExist(key string) *Deployment {
+	obj.copyOnWrite()
+	obj.error(addMatchExpression(&obj.dp.Spec.Selector, key, LabelSelectorOpDoesNotExist, nil))
+	return obj
+}
+
 // SetDeployMaxTime set Deployment deploy max time,default 600s.
 // If real deploy time more than this value,Deployment controller return err:ProgressDeadlineExceeded
 // and Pod will Redeploy.
@@ -252,9 +431,30 @@ func (obj *Deployment) SetPodLabels(labels map[string]string) *Deployment {
 	return obj
 }
 
-// SetImagePullSecrets set pod pull secret
-func (obj *Deployment) SetImagePullSecrets(secretName string) *Deployment {
-	setImagePullSecrets(&obj.dp.Spec.Template, secretName)
+// SetPodTemplate replace the Pod template of Deployment by PodTemplate which is built by NewPodTemplate(),
+// PodTemplate is copied,so it can be attached to many workloads,
+// the labels of PodTemplate are used as selector when the selector is not set.
+func (obj *Deployment) SetPodTemplate(tpl *PodTemplate) *Deployment {
+	template, err := podTemplateOf(tpl)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	*obj.podTemplate() = *template
+	if obj.dp.Spec.Selector == nil && len(template.GetLabels()) > 0 {
+		obj.SetSelector(template.GetLabels())
+	}
+	return obj
+}
+
+// SetImagePullPolicy set the pull policy of all containers of Deployment,policy is Always,Never or IfNotPresent,
+// it is the same as ImagePullPolicy() but the policy is checked,default is IfNotPresent.
+func (obj *Deployment) SetImagePullPolicy(policy string) *Deployment {
+	if err := verifyPullPolicy("SetImagePullPolicy", policy); err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.error(addAnnotation(obj.dp, ImagePullPolicyKey, policy))
 	return obj
 }
 
@@ -263,38 +463,139 @@ func (obj *Deployment) GetPodLabel() map[string]string {
 	return obj.dp.Spec.Template.GetLabels()
 }
 
-// SetPodPriorityClass set Deployment Pod Priority
-// priorityClassName is Kubernetes resource object PriorityClass name
-// priorityClassName must already exists in kubernetes cluster
-func (obj *Deployment) SetPodPriorityClass(priorityClassName string) *Deployment {
-	obj.error(setPodPriorityClass(&obj.dp.Spec.Template, priorityClassName))
+// SetHostname set the hostname of the Pods of Deployment,the Pod name is used when it is not set
+func (obj *Deployment) SetHostname(hostname string) *Deployment {
+	obj.error(setHostname(obj.podTemplate(), hostname))
 	return obj
 }
 
-// SetPVClaim set Deployment PersistentVolumeClaimVolumeSource
-// params:
-// volumeName: this is Custom field,you can define VolumeSource name,will be used of the container MountPath,
-// claimName: this is PersistentVolumeClaim(PVC) name,the PVC and Deployment must on same namespace and exist.
-func (obj *Deployment) SetPVClaim(volumeName, claimName string) *Deployment {
-	obj.error(setPVClaim(&obj.dp.Spec.Template, volumeName, claimName))
+// SetSubdomain set the subdomain of the Pods of Deployment,the Pods are resolved as <hostname>.<subdomain>
+// when the headless Service of the subdomain name selects them.
+func (obj *Deployment) SetSubdomain(subdomain string) *Deployment {
+	obj.error(setSubdomain(obj.podTemplate(), subdomain))
 	return obj
 }
 
-//SetPVCMounts mount PersistentVolumeClaim on container
-// params:
-// volumeName:the param is SetPVClaim() function volumeName,and when you call SetPVCMounts function you must call SetPVClaim function,and no order.
-// on the other hand SetPVCMounts() function only mount first Container,and On the Container you can volumeMount many PersistentVolumeClaim.
-// mountPath: runtime container dir eg:/var/lib/mysql
-func (obj *Deployment) SetPVCMounts(volumeName, mountPath string) *Deployment {
-	obj.error(setPVCMounts(&obj.dp.Spec.Template, volumeName, mountPath))
+// SetPodDeletionCost set the deletion cost of all Pods of Deployment,
+// ReplicaSet deletes the Pods with lower cost first when it scales down, the default cost is 0.
+// use SetPodsDeletionCost() to set the cost of the running Pods one by one.
+func (obj *Deployment) SetPodDeletionCost(cost int) *Deployment {
+	obj.error(setPodDeletionCost(obj.podTemplate(), cost))
 	return obj
 }
 
-func (obj *Deployment) error(err error) {
-	if obj.err != nil {
-		return
+// SetPodsDeletionCost set the deletion cost of the running Pods of Deployment on Kubernetes,
+// key is Pod name and value is the cost,eg: the Pods on the expensive nodes have high cost so they are kept when scales down.
+// the Pods must be selected by the selector of Deployment, client is the Kubernetes clientset,the registered one is used when it is nil.
+func (obj *Deployment) SetPodsDeletionCost(client kubernetes.Interface, costs map[string]int) error {
+	selector := obj.dp.Spec.Selector
+	if selector == nil {
+		selector = &metav1.LabelSelector{MatchLabels: obj.GetPodLabel()}
+	}
+	client, err := clientOrRegistered(client)
+	if err != nil {
+		return err
+	}
+	return setPodsDeletionCost(client, obj.dp.GetNamespace(), selector, costs)
+}
+
+// Resize resize the container of the running Pods of Deployment on Kubernetes in place,
+// the container is restarted only when its resize policy of the changed resource is RestartContainer.
+// the Pod template is not changed, call SetResourceLimit() and Apply() to keep the resources in the next rollout.
+// client is the Kubernetes clientset,the registered one is used when it is nil,Kubernetes 1.33+ is required.
+func (obj *Deployment) Resize(ctx context.Context, client kubernetes.Interface, container string, newResources ResourceRequirements) error {
+	selector := obj.dp.Spec.Selector
+	if selector == nil {
+		selector = &metav1.LabelSelector{MatchLabels: obj.dp.Spec.Template.GetLabels()}
+	}
+	client, err := clientOrRegistered(client)
+	if err != nil {
+		return err
+	}
+	return resizePods(ctx, client, obj.dp.GetNamespace(), selector, container, newResources)
+}
+
+// SpreadAcrossZones spread the Pods evenly across topology.kubernetes.io/zone,
+// the number of Pods in any two zones differs by at most maxSkew,call it after SetPodLabels().
+func (obj *Deployment) SpreadAcrossZones(maxSkew int) *Deployment {
+	obj.error(setSpreadAcrossZones(obj.podTemplate(), maxSkew))
+	return obj
+}
+
+// SetNodeName run the Pods on the node directly without the scheduler,eg: debug or node-pinned utility Pod,
+// the taints and resources of the node are not checked,so use node affinity in normal cases,empty name removes it.
+func (obj *Deployment) SetNodeName(name string) *Deployment {
+	obj.error(setNodeName(obj.podTemplate(), name))
+	return obj
+}
+
+// PatchAgainst finish Deployment and generate the strategic merge patch against the existing Deployment,eg: the one got by client,
+// the patch only changes the fields set by the chain function,the other fields of existing are kept,
+// so the fields managed by the other actors are not stomped,send it by Patch() with types.StrategicMergePatchType.
+func (obj *Deployment) PatchAgainst(existing *v1.Deployment) ([]byte, error) {
+	dp, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, errors.New("PatchAgainst err,existing Deployment is not allowed to be nil")
 	}
-	obj.err = err
+	return patchAgainst(existing, dp)
+}
+
+// String the current Deployment as yaml, the pending error is written on the top as comment.
+// it doesn't check and input default field like Finish(), so the Deployment may be incomplete.
+func (obj *Deployment) String() string { return dump("Deployment", obj.dp, obj.err) }
+
+// Dump print the current Deployment and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *Deployment) Dump() *Deployment {
+	fmt.Println(obj.String())
+	return obj
+}
+
+// WithVerifier add the verifier which checks Deployment in Finish() and Validate() after the checks of beku,
+// it only affects this builder,so one call site can enforce extra invariants,eg: the image must be from the company registry.
+func (obj *Deployment) WithVerifier(verifier func(*v1.Deployment) error) *Deployment {
+	if verifier == nil {
+		obj.error(fieldError("WithVerifier", "verifier is not allowed to be nil"))
+		return obj
+	}
+	obj.verifiers = append(obj.verifiers, verifier)
+	return obj
+}
+
+// WithPreset add the Presets which are applied in Finish() on the fields which are not set,eg: WithPreset(ProductionWebService),
+// the setters override them no matter they are called before or after WithPreset(),the later Preset doesn't override the former.
+func (obj *Deployment) WithPreset(presets ...Preset) *Deployment {
+	obj.presets = append(obj.presets, presets...)
+	return obj
+}
+
+// PDB finish Deployment and create the PodDisruptionBudget of PDBMaxUnavailable of the Presets,
+// it has the same namespace,name and selector as Deployment.
+func (obj *Deployment) PDB() (*policyv1.PodDisruptionBudget, error) {
+	dp, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	for _, preset := range obj.presets {
+		if preset.PDBMaxUnavailable != "" {
+			return NewPDB().SetNamespaceAndName(dp.GetNamespace(), dp.GetName()).SetSelector(dp.Spec.Selector.MatchLabels).
+				SetMaxUnavailable(preset.PDBMaxUnavailable).Finish()
+		}
+	}
+	return nil, errors.New("PDB err,no Preset has PDBMaxUnavailable,you can call WithPreset() add it")
+}
+
+func (obj *Deployment) error(err error) {
+	obj.err = appendError(obj.err, err)
+}
+
+// withPodSetters bind the pod-level setters to Deployment
+func (obj *Deployment) withPodSetters() *Deployment {
+	obj.PodSetters = PodSetters[*Deployment]{builder: obj}
+	return obj
 }
 
 // ImagePullPolicy  Deployment  pull image policy:Always,Never,IfNotPresent
@@ -311,35 +612,26 @@ func (obj *Deployment) ImagePullPolicy(pullPolicy PullPolicy) *Deployment {
 // image:image is image name ,must input image
 // containerPort: image expose containerPort,must input containerPort
 func (obj *Deployment) SetContainer(name, image string, containerPort int32) *Deployment {
-	obj.error(setContainer(&obj.dp.Spec.Template, name, image, containerPort))
+	obj.error(setContainer(obj.podTemplate(), name, image, containerPort))
 	return obj
 }
 
 // SetContainerOne set one container
 func (obj *Deployment) SetContainerOne(container corev1.Container) *Deployment {
-	if obj.dp.Spec.Template.Spec.Containers == nil {
-		obj.dp.Spec.Template.Spec.Containers = []corev1.Container{container}
-		return obj
-	}
+	obj.copyOnWrite()
 	obj.dp.Spec.Template.Spec.Containers = append(obj.dp.Spec.Template.Spec.Containers, container)
 	return obj
 }
 
 // SetResourceLimit set container of deployment resource limit,eg:CPU and MEMORY
 func (obj *Deployment) SetResourceLimit(limits map[ResourceName]string) *Deployment {
-	obj.error(setResourceLimit(&obj.dp.Spec.Template, limits))
+	obj.error(setResourceLimit(obj.podTemplate(), limits))
 	return obj
 }
 
 // SetResourceRequst set container of deployment resource request,only CPU and MEMORY
 func (obj *Deployment) SetResourceRequst(requests map[ResourceName]string) *Deployment {
-	obj.error(setResourceRequests(&obj.dp.Spec.Template, requests))
-	return obj
-}
-
-// SetEnvs set Pod Environmental variable
-func (obj *Deployment) SetEnvs(envMap map[string]string) *Deployment {
-	obj.error(setEnvs(&obj.dp.Spec.Template, envMap))
+	obj.error(setResourceRequests(obj.podTemplate(), requests))
 	return obj
 }
 
@@ -349,11 +641,11 @@ func (obj *Deployment) Release() (*v1.Deployment, error) {
 	if err != nil {
 		return nil, err
 	}
-	client, err := GetKubeClient()
+	client, err := getKubeInterface()
 	if err != nil {
 		return nil, err
 	}
-	return client.AppsV1().Deployments(dp.GetNamespace()).Create(dp)
+	return client.AppsV1().Deployments(dp.GetNamespace()).Create(context.TODO(), dp, metav1.CreateOptions{})
 }
 
 // Apply  it will be updated when this resource object exists in K8s,
@@ -363,69 +655,191 @@ func (obj *Deployment) Apply() (*v1.Deployment, error) {
 	if err != nil {
 		return nil, err
 	}
-	client, err := GetKubeClient()
+	client, err := getKubeInterface()
+	if err != nil {
+		return nil, err
+	}
+	existing, err := client.AppsV1().Deployments(dp.GetNamespace()).Get(context.TODO(), dp.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.AppsV1().Deployments(dp.GetNamespace()).Create(context.TODO(), dp, metav1.CreateOptions{})
+	}
+	if err := verifySelectorUnchanged("Deployment", existing.Spec.Selector, dp.Spec.Selector); err != nil {
+		return nil, err
+	}
+	keepRevision(&existing.ObjectMeta, &dp.ObjectMeta)
+	return client.AppsV1().Deployments(dp.GetNamespace()).Update(context.TODO(), dp, metav1.UpdateOptions{})
+}
+
+// DryRunApply finish Deployment and apply it by server-side apply with dryRun=All,so it is checked by Kubernetes but not persisted,
+// client is the Client of Kubernetes,the registered cluster is used when it is nil.
+func (obj *Deployment) DryRunApply(ctx context.Context, client *Client) (*unstructured.Unstructured, error) {
+	dp, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		if client, err = NewClient(nil, nil); err != nil {
+			return nil, err
+		}
+	}
+	return client.DryRunApply(ctx, dp)
+}
+
+// ValidateAgainstCluster check Deployment by the schema,admission webhooks and quota of Kubernetes like DryRunApply(),
+// the rejected fields are returned as FieldError,so they can be split by FieldErrors().
+func (obj *Deployment) ValidateAgainstCluster(ctx context.Context, client *Client) error {
+	_, err := obj.DryRunApply(ctx, client)
+	return err
+}
+
+// ApplyRecreate apply Deployment like Apply(),but when its selector is changed,which Apply() rejects because it is immutable,
+// the existing one is deleted in foreground and Deployment is created after its Pods are deleted,so the Pods are unavailable meanwhile.
+// client is the Client of Kubernetes,the registered cluster is used when it is nil,the wait of deletion is controlled by ctx.
+func (obj *Deployment) ApplyRecreate(ctx context.Context, client *Client) (*v1.Deployment, error) {
+	dp, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err = clientOrDefault(client)
 	if err != nil {
 		return nil, err
 	}
-	_, err = client.AppsV1().Deployments(dp.GetNamespace()).Get(dp.GetName(), metav1.GetOptions{})
+	if err := recreateOnSelectorChange(ctx, client, dp); err != nil {
+		return nil, err
+	}
+	result, err := applyRecreated(ctx, client, dp)
 	if err != nil {
-		return client.AppsV1().Deployments(dp.GetNamespace()).Create(dp)
+		return nil, err
 	}
-	return client.AppsV1().Deployments(dp.GetNamespace()).Update(dp)
+	return result.(*v1.Deployment), nil
 }
 
-// verify check service necessary value, input the default field and input related data.
-func (obj *Deployment) verify() {
+// Watch watch Deployment on Kubernetes by its namespace and name,and call handler when it is added,updated or deleted,
+// client is the Kubernetes clientset,the registered one is used when it is nil,it blocks until ctx is done.
+func (obj *Deployment) Watch(ctx context.Context, client kubernetes.Interface, handler EventHandler) error {
 	if obj.err != nil {
-		return
+		return obj.err
 	}
-	if !verifyString(obj.dp.GetName()) {
-		obj.err = errors.New("Deployment name is not allowed to be empty")
-		return
+	return watchObjects(ctx, client, []runtime.Object{obj.dp}, handler)
+}
+
+// WatchPods watch the Pods selected by the selector of Deployment and call handler when they are added,updated or deleted,
+// eg: restart counting or log collection,the usage of client and ctx is the same as Watch().
+func (obj *Deployment) WatchPods(ctx context.Context, client kubernetes.Interface, handler EventHandler) error {
+	if obj.err != nil {
+		return obj.err
 	}
-	if len(obj.dp.Spec.Template.GetLabels()) < 1 {
-		obj.err = errors.New("Deployment.Spec.Templata.Labels is not allowed to be empty")
-		return
+	return watchPods(ctx, client, "Deployment", obj.dp.GetNamespace(), obj.dp.Spec.Selector, handler)
+}
+
+// Delete delete Deployment on Kubernetes by its name and namespace,
+// opts[0] sets the propagation policy and grace period,
+// eg: DeleteOptions{Propagation: PropagationForeground} returns after its ReplicaSets and Pods are deleted.
+func (obj *Deployment) Delete(opts ...DeleteOptions) error {
+	if obj.err != nil {
+		return obj.err
 	}
-	if obj.dp.Spec.Template.Spec.Containers == nil || len(obj.dp.Spec.Template.Spec.Containers) < 1 {
-		obj.err = errors.New("Deployment.Spec.Template.Spec.Containers is not allowed to be empty")
-		return
+	options, err := newDeleteOptions("Deployment", obj.dp.GetName(), opts)
+	if err != nil {
+		return err
 	}
-	if obj.dp.Spec.Selector == nil {
-		obj.SetSelector(obj.GetPodLabel())
+	client, err := getKubeInterface()
+	if err != nil {
+		return err
 	}
+	return client.AppsV1().Deployments(obj.dp.GetNamespace()).Delete(context.TODO(), obj.dp.GetName(), *options)
+}
 
+// verify check service necessary value, input the default field and input related data,
+// all problems are returned at once,the default fields are only input when Deployment is valid.
+func (obj *Deployment) verify() error {
+	var errs error
+	if !verifyString(obj.dp.GetName()) {
+		errs = appendError(errs, fieldError("Deployment.Name", "is not allowed to be empty"))
+	}
+	for _, preset := range obj.presets {
+		errs = appendError(errs, applyPreset(obj.podTemplate(), preset))
+	}
+	// the spec still shared with Template is verified by NewTemplate(),so the instances skip the checks of it,
+	// all changes of the spec copy it first,eg: SetContainer() and WithPreset(),then it is checked again.
+	if !obj.shared {
+		errs = appendError(errs, obj.verifySpec())
+	}
 	//check qos set,if err!=nil, check need auto set qos
 	presentQos, err := qosCheck(obj.dp.Annotations[qosKey], obj.dp.Spec.Template.Spec)
-	if err != nil {
-		if obj.dp.Annotations[autoQosKey] == "true" {
-			err := obj.autoSetQos(presentQos)
-			if err != nil {
-				obj.err = err
-				return
-			}
-		} else {
-			obj.err = err
-			return
-		}
+	if err != nil && obj.dp.Annotations[autoQosKey] == "true" {
+		err = obj.autoSetQos(presentQos)
 	}
+	errs = appendError(errs, inField("Deployment.Spec.Template.Spec", err))
+	if errs != nil {
+		return errs
+	}
+	rewritePodImages(&obj.dp.Spec.Template.Spec, func() *corev1.PodSpec { return &obj.podTemplate().Spec })
 	obj.dp.Kind = "Deployment"
 	obj.dp.APIVersion = "apps/v1"
-	if obj.dp.Annotations[ImagePullPolicyKey] == "" {
-		for index := range obj.dp.Spec.Template.Spec.Containers {
-			obj.dp.Spec.Template.Spec.Containers[index].ImagePullPolicy = corev1.PullIfNotPresent
+	// only change the containers which policy is different,so the spec shared with Template is not copied
+	for index, container := range obj.dp.Spec.Template.Spec.Containers {
+		if policy := containerPullPolicy(obj.dp.Annotations, container.ImagePullPolicy); policy != container.ImagePullPolicy {
+			obj.podTemplate().Spec.Containers[index].ImagePullPolicy = policy
 		}
-		return
-	}
-	policy := PullPolicy(obj.dp.Annotations[ImagePullPolicyKey]).ToK8s()
-	for index := range obj.dp.Spec.Template.Spec.Containers {
-		obj.dp.Spec.Template.Spec.Containers[index].ImagePullPolicy = policy
 	}
 	delete(obj.dp.Annotations, ImagePullPolicyKey)
+	// the verifiers check the finished object,so they are run after all checks and default fields
+	return obj.runVerifiers()
+}
 
+// verifySpec check the pod template and the selector of Deployment,the selector is input by the pod labels when it is nil
+func (obj *Deployment) verifySpec() error {
+	var errs error
+	podLabels := obj.GetPodLabel()
+	if len(podLabels) < 1 {
+		errs = appendError(errs, fieldError("Deployment.Spec.Template.Labels", "is not allowed to be empty"))
+	}
+	errs = appendError(errs, verifyPodSpec("Deployment.Spec.Template.Spec", obj.dp.Spec.Template.Spec))
+	errs = appendError(errs, inField("Deployment.Spec.Template.Spec", verifyHostPorts("Deployment", obj.dp.Spec.Template.Spec)))
+	if obj.dp.Spec.Selector == nil && len(podLabels) > 0 {
+		obj.SetSelector(podLabels)
+	}
+	if obj.dp.Spec.Selector != nil {
+		errs = appendError(errs, verifySelector("Deployment", obj.dp.Spec.Selector, podLabels))
+	}
+	return errs
+}
+
+// runVerifiers run the verifiers added by WithVerifier() in order when Deployment is valid,
+// then the verifiers registered by RegisterVerifier().
+func (obj *Deployment) runVerifiers() error {
+	var errs error
+	for _, verifier := range obj.verifiers {
+		if err := verifier(obj.dp); err != nil {
+			errs = appendError(errs, fmt.Errorf("Deployment %s verifier failed:%v", obj.dp.GetName(), err))
+		}
+	}
+	if err := runRegisteredVerifiers(obj.dp); err != nil {
+		errs = appendError(errs, fmt.Errorf("Deployment %s %v", obj.dp.GetName(), err))
+	}
+	return errs
+}
+
+// copyOnWrite copy the spec which is shared with Template before changing it
+func (obj *Deployment) copyOnWrite() {
+	if !obj.shared {
+		return
+	}
+	obj.dp.Spec = *obj.dp.Spec.DeepCopy()
+	obj.shared = false
+}
+
+// podTemplate get Pod template to change it, the spec shared with Template is copied first.
+func (obj *Deployment) podTemplate() *corev1.PodTemplateSpec {
+	obj.copyOnWrite()
+	return &obj.dp.Spec.Template
 }
 
 // autoSetQos auto set Pod of Deployment QOS
 func (obj *Deployment) autoSetQos(presentQos string) error {
-	return autoSetQos(obj.dp.Annotations[qosKey], presentQos, &obj.dp.Spec.Template.Spec)
+	if qosRanks[presentQos] >= qosRanks[obj.dp.Annotations[qosKey]] {
+		return nil
+	}
+	return autoSetQos(obj.dp.Annotations[qosKey], presentQos, &obj.podTemplate().Spec)
 }
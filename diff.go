@@ -0,0 +1,164 @@
+package beku
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// Diff generate the patch which turns oldObj into newObj,the patch can be sent by Patch() of client-go with patchType,
+// patchType is types.StrategicMergePatchType or types.JSONPatchType(RFC 6902),
+// the strategic merge patch is only for the built-in kinds,use JSON patch for CustomResource.
+// the fields of oldObj which are not in newObj are removed by the patch,use PatchAgainst() of the builder to keep them.
+func Diff(oldObj, newObj runtime.Object, patchType types.PatchType) ([]byte, error) {
+	if oldObj == nil || newObj == nil {
+		return nil, fmt.Errorf("Diff err,oldObj and newObj are not allowed to be nil")
+	}
+	oldJSON, err := json.Marshal(oldObj)
+	if err != nil {
+		return nil, fmt.Errorf("Diff err,%v", err)
+	}
+	newJSON, err := json.Marshal(newObj)
+	if err != nil {
+		return nil, fmt.Errorf("Diff err,%v", err)
+	}
+	return diffJSON(oldJSON, newJSON, newObj, patchType)
+}
+
+// patchAgainst generate the strategic merge patch which only changes the fields set in obj,
+// the other fields of existing,eg: the fields managed by other controllers and status,are kept.
+func patchAgainst(existing, obj runtime.Object) ([]byte, error) {
+	existingJSON, err := json.Marshal(existing)
+	if err != nil {
+		return nil, fmt.Errorf("PatchAgainst err,%v", err)
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, fmt.Errorf("PatchAgainst err,%v", err)
+	}
+	// null in the merge patch deletes the field,eg: creationTimestamp,and status is not changed by the builder
+	delete(content, "status")
+	removeNulls(content)
+	objJSON, err := json.Marshal(content)
+	if err != nil {
+		return nil, fmt.Errorf("PatchAgainst err,%v", err)
+	}
+	merged, err := strategicpatch.StrategicMergePatch(existingJSON, objJSON, obj)
+	if err != nil {
+		return nil, fmt.Errorf("PatchAgainst err,%v", err)
+	}
+	return diffJSON(existingJSON, merged, obj, types.StrategicMergePatchType)
+}
+
+// diffJSON generate the patch of patchType between the json of the objects,dataStruct is the type of the objects
+func diffJSON(oldJSON, newJSON []byte, dataStruct interface{}, patchType types.PatchType) ([]byte, error) {
+	switch patchType {
+	case types.StrategicMergePatchType:
+		patch, err := strategicpatch.CreateTwoWayMergePatch(oldJSON, newJSON, dataStruct)
+		if err != nil {
+			return nil, fmt.Errorf("Diff err,%v", err)
+		}
+		return patch, nil
+	case types.JSONPatchType:
+		var oldValue, newValue interface{}
+		if err := json.Unmarshal(oldJSON, &oldValue); err != nil {
+			return nil, fmt.Errorf("Diff err,%v", err)
+		}
+		if err := json.Unmarshal(newJSON, &newValue); err != nil {
+			return nil, fmt.Errorf("Diff err,%v", err)
+		}
+		return json.Marshal(jsonPatchOps(nil, "", oldValue, newValue))
+	default:
+		return nil, fmt.Errorf("Diff err,patchType %s is not allowed,only %s and %s", patchType, types.StrategicMergePatchType, types.JSONPatchType)
+	}
+}
+
+// jsonPatchOp is one operation of RFC 6902 JSON patch
+type jsonPatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// jsonPatchOps append the operations which turn oldValue into newValue at path,
+// the objects are compared key by key in order,the arrays of the same length element by element,
+// the other arrays and values are replaced.
+func jsonPatchOps(ops []jsonPatchOp, path string, oldValue, newValue interface{}) []jsonPatchOp {
+	if reflect.DeepEqual(oldValue, newValue) {
+		return ops
+	}
+	switch newTyped := newValue.(type) {
+	case map[string]interface{}:
+		oldTyped, ok := oldValue.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(oldTyped)+len(newTyped))
+		for key := range oldTyped {
+			keys = append(keys, key)
+		}
+		for key := range newTyped {
+			if _, ok := oldTyped[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := path + "/" + escapeJSONPointer(key)
+			oldChild, inOld := oldTyped[key]
+			newChild, inNew := newTyped[key]
+			switch {
+			case !inNew || (newChild == nil && oldChild != nil):
+				ops = append(ops, jsonPatchOp{Op: "remove", Path: child})
+			case !inOld:
+				// null is the same as the missing field
+				if newChild != nil {
+					ops = append(ops, jsonPatchOp{Op: "add", Path: child, Value: newChild})
+				}
+			default:
+				ops = jsonPatchOps(ops, child, oldChild, newChild)
+			}
+		}
+		return ops
+	case []interface{}:
+		oldTyped, ok := oldValue.([]interface{})
+		if !ok || len(oldTyped) != len(newTyped) {
+			break
+		}
+		for index := range newTyped {
+			ops = jsonPatchOps(ops, path+"/"+strconv.Itoa(index), oldTyped[index], newTyped[index])
+		}
+		return ops
+	}
+	return append(ops, jsonPatchOp{Op: "replace", Path: path, Value: newValue})
+}
+
+// escapeJSONPointer escape ~ and / of the key in JSON pointer,eg: the label key app.kubernetes.io/name
+func escapeJSONPointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+// removeNulls remove the null fields of the object recursively
+func removeNulls(content map[string]interface{}) {
+	for key, value := range content {
+		switch typed := value.(type) {
+		case nil:
+			delete(content, key)
+		case map[string]interface{}:
+			removeNulls(typed)
+		case []interface{}:
+			for _, item := range typed {
+				if child, ok := item.(map[string]interface{}); ok {
+					removeNulls(child)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package beku
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"strings"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// maxDNSNameservers is the max number of nameservers of Pod dnsConfig
+	maxDNSNameservers = 3
+	// maxDNSSearches is the max number of search domains of Pod dnsConfig
+	maxDNSSearches = 32
+)
+
+// addHostAlias add the hostnames of ip into /etc/hosts of the Pod,eg: the internal hostnames of legacy services,
+// the hostnames are merged into the existing alias of the same ip.
+func addHostAlias(podTemp *v1.PodTemplateSpec, ip string, hostnames []string) error {
+	if net.ParseIP(ip) == nil {
+		return fieldErrorf("AddHostAlias", "ip %q is not allowed", ip)
+	}
+	if len(hostnames) == 0 {
+		return fieldErrorf("AddHostAlias", "hostnames of %s are not allowed to be empty", ip)
+	}
+	for _, hostname := range hostnames {
+		if errs := validation.IsDNS1123Subdomain(hostname); len(errs) > 0 {
+			return fieldErrorf("AddHostAlias", "hostname %q is not allowed:%s", hostname, strings.Join(errs, ","))
+		}
+	}
+	aliases := podTemp.Spec.HostAliases
+	index := len(aliases)
+	for i := range aliases {
+		if aliases[i].IP == ip {
+			index = i
+			break
+		}
+	}
+	if index == len(aliases) {
+		podTemp.Spec.HostAliases = append(aliases, v1.HostAlias{IP: ip})
+	}
+	alias := &podTemp.Spec.HostAliases[index]
+	existing := sets.NewString(alias.Hostnames...)
+	for _, hostname := range hostnames {
+		if !existing.Has(hostname) {
+			existing.Insert(hostname)
+			alias.Hostnames = append(alias.Hostnames, hostname)
+		}
+	}
+	return nil
+}
+
+// setDNSPolicy set the DNS policy of the Pod,policy is ClusterFirst,ClusterFirstWithHostNet,Default or None,
+// the nameservers of SetDNSConfig() are required by None.
+func setDNSPolicy(podTemp *v1.PodTemplateSpec, policy string) error {
+	switch v1.DNSPolicy(policy) {
+	case v1.DNSClusterFirst, v1.DNSClusterFirstWithHostNet, v1.DNSDefault, v1.DNSNone:
+	default:
+		return fieldErrorf("SetDNSPolicy", "policy %s is not allowed,only ClusterFirst,ClusterFirstWithHostNet,Default and None", policy)
+	}
+	podTemp.Spec.DNSPolicy = v1.DNSPolicy(policy)
+	return nil
+}
+
+// setDNSConfig set the nameservers,search domains and options of resolv.conf of the Pod,
+// they are merged with the ones of DNS policy,options is the option name and value,the empty value is the option without value,eg: ndots:2 and single-request.
+func setDNSConfig(podTemp *v1.PodTemplateSpec, nameservers, searches []string, options map[string]string) error {
+	if len(nameservers) > maxDNSNameservers {
+		return fieldErrorf("SetDNSConfig", "the number of nameservers is not allowed to be more than %d", maxDNSNameservers)
+	}
+	for _, nameserver := range nameservers {
+		if net.ParseIP(nameserver) == nil {
+			return fieldErrorf("SetDNSConfig", "nameserver %q is not allowed,it must be ip", nameserver)
+		}
+	}
+	if len(searches) > maxDNSSearches {
+		return fieldErrorf("SetDNSConfig", "the number of searches is not allowed to be more than %d", maxDNSSearches)
+	}
+	for _, search := range searches {
+		if errs := validation.IsDNS1123Subdomain(strings.TrimSuffix(search, ".")); len(errs) > 0 {
+			return fieldErrorf("SetDNSConfig", "search %q is not allowed:%s", search, strings.Join(errs, ","))
+		}
+	}
+	names := make([]string, 0, len(options))
+	for name := range options {
+		if !verifyString(name) {
+			return fieldError("SetDNSConfig", "option name is not allowed to be empty")
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	config := &v1.PodDNSConfig{
+		Nameservers: append([]string(nil), nameservers...),
+		Searches:    append([]string(nil), searches...),
+	}
+	for _, name := range names {
+		option := v1.PodDNSConfigOption{Name: name}
+		if value := options[name]; value != "" {
+			option.Value = &value
+		}
+		config.Options = append(config.Options, option)
+	}
+	podTemp.Spec.DNSConfig = config
+	return nil
+}
+
+// setHostname set the hostname of the Pod,the Pod name is used when it is not set
+func setHostname(podTemp *v1.PodTemplateSpec, hostname string) error {
+	if errs := validation.IsDNS1123Label(hostname); len(errs) > 0 {
+		return fieldErrorf("SetHostname", "hostname %q is not allowed:%s", hostname, strings.Join(errs, ","))
+	}
+	podTemp.Spec.Hostname = hostname
+	return nil
+}
+
+// setSubdomain set the subdomain of the Pod,the FQDN of the Pod is <hostname>.<subdomain>.<namespace>.svc.<cluster-domain>
+// when the headless Service of the subdomain name selects it.
+func setSubdomain(podTemp *v1.PodTemplateSpec, subdomain string) error {
+	if errs := validation.IsDNS1123Label(subdomain); len(errs) > 0 {
+		return fieldErrorf("SetSubdomain", "subdomain %q is not allowed:%s", subdomain, strings.Join(errs, ","))
+	}
+	podTemp.Spec.Subdomain = subdomain
+	return nil
+}
+
+// setHostNetwork set the Pod uses the network namespace of the node,
+// the DNS policy is ClusterFirstWithHostNet when it is not set,so the Pod can still resolve the Services.
+func setHostNetwork(podTemp *v1.PodTemplateSpec, hostNetwork bool) {
+	podTemp.Spec.HostNetwork = hostNetwork
+	if hostNetwork && podTemp.Spec.DNSPolicy == "" {
+		podTemp.Spec.DNSPolicy = v1.DNSClusterFirstWithHostNet
+	}
+}
+
+// verifyDNS check the Pod of DNS policy None has nameservers,because it has no resolv.conf of the policy
+func verifyDNS(pod v1.PodSpec) error {
+	if pod.DNSPolicy != v1.DNSNone {
+		return nil
+	}
+	if pod.DNSConfig == nil || len(pod.DNSConfig.Nameservers) == 0 {
+		return errors.New("DNS policy None is not allowed without nameservers,you can call SetDNSConfig() input")
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package beku
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// the functions for the builders in the other packages,eg: istiobeku,
+// so they load documents,collect errors and dump objects like the builders of beku,
+// and they are registered by RegisterKind().
+
+// DecodeJSON decode json document into v with the limits of MaxDocumentSize and MaxDocumentDepth
+func DecodeJSON(data []byte, v interface{}) error { return decodeJSON(data, v) }
+
+// DecodeYAML decode yaml document into v with the limits of MaxDocumentSize and MaxDocumentDepth
+func DecodeYAML(data []byte, v interface{}) error { return decodeYAML(data, v) }
+
+// AppendError append err into errs like the builders of beku,so Finish() returns all problems of the chain at once,
+// nil err is ignored.
+func AppendError(errs, err error) error { return appendError(errs, err) }
+
+// AddLabel add or overwrite one label of the object after the key and value are checked,the other labels are kept
+func AddLabel(object metav1.Object, key, value string) error { return addLabel(object, key, value) }
+
+// AddAnnotation add or overwrite one annotation of the object after the key is checked,the other annotations are kept
+func AddAnnotation(object metav1.Object, key, value string) error {
+	return addAnnotation(object, key, value)
+}
+
+// Dump translate the object into yaml which is annotated with the builder name,
+// the pending error is written on the top as comment,it is String() of the builders.
+func Dump(name string, obj interface{}, err error) string { return dump(name, obj, err) }
@@ -0,0 +1,274 @@
+package beku
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ExternalSecretObject is ExternalSecret of External Secrets Operator(external-secrets.io/v1beta1),
+// the operator reads the values from the secret store,eg: Vault,AWS Secrets Manager, and creates the Secret,
+// so no secret value is in the manifest.
+type ExternalSecretObject struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              ExternalSecretSpec `json:"spec"`
+}
+
+// ExternalSecretSpec is the spec of ExternalSecret
+type ExternalSecretSpec struct {
+	SecretStoreRef  SecretStoreRef           `json:"secretStoreRef"`
+	Target          ExternalSecretTarget     `json:"target,omitempty"`
+	RefreshInterval *metav1.Duration         `json:"refreshInterval,omitempty"`
+	Data            []ExternalSecretData     `json:"data,omitempty"`
+	DataFrom        []ExternalSecretDataFrom `json:"dataFrom,omitempty"`
+}
+
+// SecretStoreRef is the SecretStore or ClusterSecretStore which the values are read from
+type SecretStoreRef struct {
+	Name string          `json:"name"`
+	Kind SecretStoreKind `json:"kind,omitempty"`
+}
+
+// ExternalSecretTarget is the Secret created by the operator
+type ExternalSecretTarget struct {
+	Name string `json:"name,omitempty"`
+}
+
+// ExternalSecretData map one value of the secret store to the key of the Secret
+type ExternalSecretData struct {
+	SecretKey string                  `json:"secretKey"`
+	RemoteRef ExternalSecretRemoteRef `json:"remoteRef"`
+}
+
+// ExternalSecretDataFrom extract all the properties of the remote key into the Secret
+type ExternalSecretDataFrom struct {
+	Extract *ExternalSecretRemoteRef `json:"extract,omitempty"`
+}
+
+// ExternalSecretRemoteRef is the key and property in the secret store
+type ExternalSecretRemoteRef struct {
+	Key      string `json:"key"`
+	Property string `json:"property,omitempty"`
+}
+
+// DeepCopyObject implement runtime.Object
+func (in *ExternalSecretObject) DeepCopyObject() runtime.Object { return in.DeepCopy() }
+
+// DeepCopy copy ExternalSecret
+func (in *ExternalSecretObject) DeepCopy() *ExternalSecretObject {
+	if in == nil {
+		return nil
+	}
+	out := &ExternalSecretObject{TypeMeta: in.TypeMeta}
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec.SecretStoreRef, out.Spec.Target = in.Spec.SecretStoreRef, in.Spec.Target
+	if in.Spec.RefreshInterval != nil {
+		interval := *in.Spec.RefreshInterval
+		out.Spec.RefreshInterval = &interval
+	}
+	if in.Spec.Data != nil {
+		out.Spec.Data = append([]ExternalSecretData(nil), in.Spec.Data...)
+	}
+	for _, from := range in.Spec.DataFrom {
+		if from.Extract != nil {
+			extract := *from.Extract
+			from.Extract = &extract
+		}
+		out.Spec.DataFrom = append(out.Spec.DataFrom, from)
+	}
+	return out
+}
+
+// SecretStoreKind is the kind of the secret store
+type SecretStoreKind string
+
+const (
+	// SecretStoreKindNamespaced SecretStore in the namespace of ExternalSecret,default
+	SecretStoreKindNamespaced SecretStoreKind = "SecretStore"
+	// SecretStoreKindCluster ClusterSecretStore which can be used by all namespaces
+	SecretStoreKindCluster SecretStoreKind = "ClusterSecretStore"
+)
+
+// ExternalSecret include ExternalSecret of External Secrets Operator and error.
+type ExternalSecret struct {
+	es  *ExternalSecretObject
+	err error
+}
+
+// NewExternalSecret create ExternalSecret and chain function call begin with this function.
+func NewExternalSecret() *ExternalSecret { return &ExternalSecret{es: &ExternalSecretObject{}} }
+
+// Finish chain function call end with this function
+// return real ExternalSecret and error
+// In the function, it will check necessary parameters、input the default field。
+func (obj *ExternalSecret) Finish() (es *ExternalSecretObject, err error) {
+	err = appendError(obj.err, obj.verify())
+	return obj.es, err
+}
+
+// Validate check ExternalSecret necessary value like Finish(),and return the problems as FieldError,
+// but ExternalSecret is not changed.
+func (obj *ExternalSecret) Validate() []FieldError {
+	cp := &ExternalSecret{es: obj.es.DeepCopy(), err: obj.err}
+	return FieldErrors(appendError(cp.err, cp.verify()))
+}
+
+// FinishObject same as Finish(), but return ExternalSecret as runtime.Object,
+// so ExternalSecret can be used as Builder, eg: add into Bundle.
+func (obj *ExternalSecret) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// FinishUnchecked return ExternalSecret without the checks and default fields of Finish(),only Kind and APIVersion are input,
+// it is for the partial ExternalSecret which is patched onto the existing one,the error of the chain is still returned.
+func (obj *ExternalSecret) FinishUnchecked() (*ExternalSecretObject, error) {
+	obj.es.Kind, obj.es.APIVersion = "ExternalSecret", "external-secrets.io/v1beta1"
+	return obj.es, obj.err
+}
+
+// JSONNew use json data create ExternalSecret
+func (obj *ExternalSecret) JSONNew(jsonbyts []byte) *ExternalSecret {
+	obj.error(decodeJSON(jsonbyts, obj.es))
+	return obj
+}
+
+// YAMLNew use yaml data create ExternalSecret
+func (obj *ExternalSecret) YAMLNew(yamlbyts []byte) *ExternalSecret {
+	obj.error(decodeYAML(yamlbyts, obj.es))
+	return obj
+}
+
+// SetName set ExternalSecret name
+func (obj *ExternalSecret) SetName(name string) *ExternalSecret {
+	obj.es.SetName(name)
+	return obj
+}
+
+// SetNamespace set ExternalSecret namespace
+func (obj *ExternalSecret) SetNamespace(namespace string) *ExternalSecret {
+	obj.es.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set ExternalSecret namespace and name
+func (obj *ExternalSecret) SetNamespaceAndName(namespace, name string) *ExternalSecret {
+	obj.es.SetName(name)
+	obj.es.SetNamespace(namespace)
+	return obj
+}
+
+// SetLabels set ExternalSecret labels
+func (obj *ExternalSecret) SetLabels(labels map[string]string) *ExternalSecret {
+	obj.es.SetLabels(labels)
+	return obj
+}
+
+// AddLabel add or overwrite one label of ExternalSecret,the other labels are kept,
+// unlike SetLabels() which replaces all labels,eg: the labels loaded by YAMLNew().
+func (obj *ExternalSecret) AddLabel(key, value string) *ExternalSecret {
+	obj.error(addLabel(obj.es, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of ExternalSecret,the other annotations are kept
+func (obj *ExternalSecret) AddAnnotation(key, value string) *ExternalSecret {
+	obj.error(addAnnotation(obj.es, key, value))
+	return obj
+}
+
+// SetStoreRef set the secret store which the values are read from
+func (obj *ExternalSecret) SetStoreRef(name string, kind SecretStoreKind) *ExternalSecret {
+	if kind != SecretStoreKindNamespaced && kind != SecretStoreKindCluster {
+		obj.error(fieldErrorf("SetStoreRef", "kind %s is not supported", kind))
+		return obj
+	}
+	obj.es.Spec.SecretStoreRef = SecretStoreRef{Name: name, Kind: kind}
+	return obj
+}
+
+// SetTarget set the name of the Secret created by the operator,default is the name of ExternalSecret
+func (obj *ExternalSecret) SetTarget(secretName string) *ExternalSecret {
+	obj.es.Spec.Target.Name = secretName
+	return obj
+}
+
+// SetRefreshInterval set how often the values are read from the secret store again,0 means never
+func (obj *ExternalSecret) SetRefreshInterval(interval time.Duration) *ExternalSecret {
+	if interval < 0 {
+		obj.error(fieldError("SetRefreshInterval", "interval is not allowed to be negative"))
+		return obj
+	}
+	obj.es.Spec.RefreshInterval = &metav1.Duration{Duration: interval}
+	return obj
+}
+
+// AddData map the property of the remote key in the secret store to secretKey of the Secret,
+// property can be empty when the remote value is not structured.
+func (obj *ExternalSecret) AddData(secretKey, remoteKey, property string) *ExternalSecret {
+	if !verifyString(secretKey) || !verifyString(remoteKey) {
+		obj.error(fieldError("AddData", "secretKey and remoteKey are not allowed to be empty"))
+		return obj
+	}
+	obj.es.Spec.Data = append(obj.es.Spec.Data, ExternalSecretData{
+		SecretKey: secretKey,
+		RemoteRef: ExternalSecretRemoteRef{Key: remoteKey, Property: property},
+	})
+	return obj
+}
+
+// AddDataFrom extract all the properties of the remote key into the Secret
+func (obj *ExternalSecret) AddDataFrom(remoteKey string) *ExternalSecret {
+	if !verifyString(remoteKey) {
+		obj.error(fieldError("AddDataFrom", "remoteKey is not allowed to be empty"))
+		return obj
+	}
+	obj.es.Spec.DataFrom = append(obj.es.Spec.DataFrom, ExternalSecretDataFrom{Extract: &ExternalSecretRemoteRef{Key: remoteKey}})
+	return obj
+}
+
+// String the current ExternalSecret as yaml, the pending error is written on the top as comment.
+func (obj *ExternalSecret) String() string { return dump("ExternalSecret", obj.es, obj.err) }
+
+// Dump print the current ExternalSecret and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *ExternalSecret) Dump() *ExternalSecret {
+	fmt.Println(obj.String())
+	return obj
+}
+
+func (obj *ExternalSecret) error(err error) {
+	obj.err = appendError(obj.err, err)
+}
+
+func (obj *ExternalSecret) verify() error {
+	var errs error
+	if !verifyString(obj.es.GetName()) {
+		errs = appendError(errs, fieldError("ExternalSecret.Name", "is not allowed to be empty"))
+	}
+	if !verifyString(obj.es.Spec.SecretStoreRef.Name) {
+		errs = appendError(errs, fieldError("ExternalSecret.Spec.SecretStoreRef", "is not allowed to be empty,you can call SetStoreRef()"))
+	}
+	if len(obj.es.Spec.Data) <= 0 && len(obj.es.Spec.DataFrom) <= 0 {
+		errs = appendError(errs, fieldError("ExternalSecret.Spec.Data", "is not allowed to be empty,you can call AddData() or AddDataFrom()"))
+	}
+	keys := make(map[string]bool, len(obj.es.Spec.Data))
+	for _, data := range obj.es.Spec.Data {
+		if keys[data.SecretKey] {
+			errs = appendError(errs, fieldErrorf("ExternalSecret.Spec.Data", "secretKey %s is duplicated", data.SecretKey))
+		}
+		keys[data.SecretKey] = true
+	}
+	if errs != nil {
+		return errs
+	}
+	if obj.es.Spec.SecretStoreRef.Kind == "" {
+		obj.es.Spec.SecretStoreRef.Kind = SecretStoreKindNamespaced
+	}
+	if obj.es.Spec.Target.Name == "" {
+		obj.es.Spec.Target.Name = obj.es.GetName()
+	}
+	obj.es.Kind = "ExternalSecret"
+	obj.es.APIVersion = "external-secrets.io/v1beta1"
+	return nil
+}
@@ -0,0 +1,89 @@
+package beku
+
+import (
+	"errors"
+	"fmt"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// FieldError is one problem of the builder,Field is the setter or the field path of the object,
+// eg: SetReplicas or Deployment.Spec.Template.Spec.Containers,Message is the problem.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// Error implement error
+func (e FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// fieldError create FieldError of the setter or the field path,eg: fieldError("SetReplicas", "replicas must be greater than 0")
+func fieldError(field, message string) error { return FieldError{Field: field, Message: message} }
+
+// fieldErrorf create FieldError of the setter or the field path with the formatted message
+func fieldErrorf(field, format string, args ...interface{}) error {
+	return FieldError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// inField turn err into FieldError of field,the field of FieldError is prefixed by it,nil err returns nil.
+func inField(field string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var fieldErr FieldError
+	if !errors.As(err, &fieldErr) {
+		return fieldError(field, err.Error())
+	}
+	if fieldErr.Field != "" {
+		fieldErr.Field = field + "." + fieldErr.Field
+	} else {
+		fieldErr.Field = field
+	}
+	return fieldErr
+}
+
+// FieldErrors split the error returned by Finish() into FieldError,
+// so the problems can be shown one by one,eg: in the web UI which drives beku.
+// the setters and the checks of Finish() create FieldError where the problem happens,
+// the error which is not FieldError is kept as Message,nil error returns nil.
+func FieldErrors(err error) []FieldError {
+	if err == nil {
+		return nil
+	}
+	errs := []error{err}
+	var agg utilerrors.Aggregate
+	if errors.As(err, &agg) {
+		errs = utilerrors.Flatten(agg).Errors()
+	}
+	fieldErrs := make([]FieldError, 0, len(errs))
+	for _, e := range errs {
+		var fieldErr FieldError
+		if errors.As(e, &fieldErr) {
+			fieldErrs = append(fieldErrs, fieldErr)
+			continue
+		}
+		fieldErrs = append(fieldErrs, FieldError{Message: e.Error()})
+	}
+	return fieldErrs
+}
+
+// appendError append err into errs,errs is utilerrors.Aggregate when it has more than one error,
+// so Finish() returns all problems of the chain at once,nil err is ignored.
+func appendError(errs, err error) error {
+	if err == nil {
+		return errs
+	}
+	if errs == nil {
+		return err
+	}
+	list := []error{errs}
+	if agg, ok := errs.(utilerrors.Aggregate); ok {
+		list = agg.Errors()
+	}
+	return utilerrors.NewAggregate(append(list[:len(list):len(list)], err))
+}
@@ -1,6 +1,7 @@
 package beku
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -8,10 +9,11 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/ghodss/yaml"
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/api/core/v1"
 	apiresource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
 
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes"
@@ -30,6 +32,23 @@ func ToJSON(v interface{}) (byts []byte, err error) {
 	return
 }
 
+// dump translate the object into yaml which is annotated with the builder name,
+// the pending error is written on the top as comment.
+func dump(name string, o interface{}, err error) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# beku %s\n", name)
+	if err != nil {
+		fmt.Fprintf(&buf, "# error: %v\n", err)
+	}
+	byts, yerr := ToYAML(o)
+	if yerr != nil {
+		fmt.Fprintf(&buf, "# ToYAML error: %v\n", yerr)
+		return buf.String()
+	}
+	buf.Write(byts)
+	return buf.String()
+}
+
 // JSONToYAML json data translate into yaml
 func JSONToYAML(jbyts []byte) (ybyts []byte, err error) {
 	ybyts, err = yaml.JSONToYAML(jbyts)
@@ -113,7 +132,7 @@ func Base64Decode(src string) ([]byte, error) {
 
 // ResourceMapsToK8s to K8s resourceList
 func ResourceMapsToK8s(maps map[ResourceName]string) (v1.ResourceList, error) {
-	data := make(v1.ResourceList, 0)
+	data := make(v1.ResourceList, len(maps))
 	for k, v := range maps {
 		q, err := apiresource.ParseQuantity(v)
 		if err != nil {
@@ -131,6 +150,32 @@ func ResourceMapsToK8s(maps map[ResourceName]string) (v1.ResourceList, error) {
 	return data, nil
 }
 
+// parseIntOrPercent parse the absolute number or percentage,eg: "1" or "25%",
+// the number is not allowed to be negative and the percentage is not allowed to be over 100%.
+func parseIntOrPercent(method, field, value string) (intstr.IntOrString, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasSuffix(value, "%") {
+		percent, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+		if err != nil || percent < 0 || percent > 100 {
+			return intstr.IntOrString{}, fieldErrorf(method, "%s %q is not allowed,the percentage should be 0%%-100%%", field, value)
+		}
+		return FromString(value), nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 {
+		return intstr.IntOrString{}, fieldErrorf(method, "%s %q is not allowed,it should be a non-negative number or percentage", field, value)
+	}
+	return FromInt(number), nil
+}
+
+// isZeroIntOrPercent the value is 0 or 0%
+func isZeroIntOrPercent(value intstr.IntOrString) bool {
+	if value.Type == intstr.String {
+		return value.StrVal == "0%"
+	}
+	return value.IntVal == 0
+}
+
 // FromInt creates an IntOrString object with an int32 value. It is
 // your responsibility not to call this method with a value greater
 // than int32.
@@ -155,16 +200,42 @@ func Parse(val string) intstr.IntOrString {
 }
 
 // httpProbe  container health check  readness and liveness  http probe
-func httpProbe(port int, path string, initDelaySec, timeoutSec, periodSec int32, headers ...map[string]string) *v1.Probe {
+// port can be a number or the name of a container port
+func httpProbe(port intstr.IntOrString, path string, initDelaySec, timeoutSec, periodSec int32, headers ...map[string]string) *v1.Probe {
 	if initDelaySec <= 0 {
 		initDelaySec = 30
 	}
-	return &v1.Probe{
-		Handler:             v1.Handler{HTTPGet: &v1.HTTPGetAction{Path: path, Port: FromInt(port), HTTPHeaders: mapsToHeaders(headers)}},
-		InitialDelaySeconds: initDelaySec,
-		TimeoutSeconds:      timeoutSec,
-		PeriodSeconds:       periodSec,
-	}
+	// allocate the probe and its action together
+	p := &struct {
+		probe  v1.Probe
+		action v1.HTTPGetAction
+	}{action: v1.HTTPGetAction{Path: path, Port: port, HTTPHeaders: mapsToHeaders(headers)}}
+	p.probe = probeTiming(initDelaySec, timeoutSec, periodSec)
+	p.probe.HTTPGet = &p.action
+	return &p.probe
+}
+
+// startupProbe turn the probe into startup probe,it is executed at once without initial delay,
+// and the container has failureThreshold*periodSeconds to start before it is killed
+func startupProbe(probe *v1.Probe, failureThreshold int32) *v1.Probe {
+	probe.InitialDelaySeconds = 0
+	probe.FailureThreshold = failureThreshold
+	return probe
+}
+
+// execHandler create lifecycle hook which executes the command in the container
+func execHandler(cmd []string) *v1.LifecycleHandler {
+	return &v1.LifecycleHandler{Exec: &v1.ExecAction{Command: cmd}}
+}
+
+// httpHandler create lifecycle hook which requests the http path of the container port
+func httpHandler(port int, path string) *v1.LifecycleHandler {
+	return &v1.LifecycleHandler{HTTPGet: &v1.HTTPGetAction{Path: path, Port: FromInt(port)}}
+}
+
+// probeTiming create probe with delay,timeout and period
+func probeTiming(initDelaySec, timeoutSec, periodSec int32) v1.Probe {
+	return v1.Probe{InitialDelaySeconds: initDelaySec, TimeoutSeconds: timeoutSec, PeriodSeconds: periodSec}
 }
 
 func mapsToHeaders(headers []map[string]string) []v1.HTTPHeader {
@@ -175,8 +246,12 @@ func mapsToHeaders(headers []map[string]string) []v1.HTTPHeader {
 }
 
 func mapToHeaders(header map[string]string) []v1.HTTPHeader {
-	var headers []v1.HTTPHeader
-	for key := range header {
+	if len(header) <= 0 {
+		return nil
+	}
+	// headers are sorted by name,so the same map always creates the same probe
+	headers := make([]v1.HTTPHeader, 0, len(header))
+	for _, key := range sortedKeys(header) {
 		headers = append(headers, v1.HTTPHeader{Name: key, Value: header[key]})
 	}
 	return headers
@@ -187,43 +262,78 @@ func cmdProbe(cmd []string, initDelaySec, timeoutSec, periodSec int32) *v1.Probe
 	if initDelaySec <= 0 {
 		initDelaySec = 30
 	}
-	return &v1.Probe{
-		Handler:             v1.Handler{Exec: &v1.ExecAction{Command: cmd}},
-		InitialDelaySeconds: initDelaySec,
-		TimeoutSeconds:      timeoutSec,
-		PeriodSeconds:       periodSec,
-	}
+	// allocate the probe and its action together
+	p := &struct {
+		probe  v1.Probe
+		action v1.ExecAction
+	}{action: v1.ExecAction{Command: cmd}}
+	p.probe = probeTiming(initDelaySec, timeoutSec, periodSec)
+	p.probe.Exec = &p.action
+	return &p.probe
 }
 
 // tcpProbe container health check readness and liveness tcp probe
-func tcpProbe(host string, port int, initDelaySec, timeoutSec, periodSec int32) *v1.Probe {
+// port can be a number or the name of a container port
+func tcpProbe(host string, port intstr.IntOrString, initDelaySec, timeoutSec, periodSec int32) *v1.Probe {
 	if initDelaySec <= 0 {
 		initDelaySec = 30
 	}
-	return &v1.Probe{
-		Handler:             v1.Handler{TCPSocket: &v1.TCPSocketAction{Port: FromInt(port), Host: host}},
-		InitialDelaySeconds: initDelaySec,
-		TimeoutSeconds:      timeoutSec,
-		PeriodSeconds:       periodSec,
+	// allocate the probe and its action together
+	p := &struct {
+		probe  v1.Probe
+		action v1.TCPSocketAction
+	}{action: v1.TCPSocketAction{Port: port, Host: host}}
+	p.probe = probeTiming(initDelaySec, timeoutSec, periodSec)
+	p.probe.TCPSocket = &p.action
+	return &p.probe
+}
+
+// addMatchExpression append a label selector requirement on the selector, the selector will be created when it is nil.
+// the values must not be empty when operator is In or NotIn.
+func addMatchExpression(selector **metav1.LabelSelector, key string, operator LabelSelectorOperator, values []string) error {
+	if !verifyString(key) {
+		return fieldError("Match"+string(operator), "key is not allowed to be empty")
+	}
+	if (operator == LabelSelectorOpIn || operator == LabelSelectorOpNotIn) && len(values) < 1 {
+		return fieldError("Match"+string(operator), "values is not allowed to be empty")
 	}
+	if *selector == nil {
+		*selector = &metav1.LabelSelector{}
+	}
+	(*selector).MatchExpressions = append((*selector).MatchExpressions, metav1.LabelSelectorRequirement{
+		Key:      key,
+		Operator: metav1.LabelSelectorOperator(operator),
+		// values is copied,so the caller can reuse its slice
+		Values: append([]string(nil), values...),
+	})
+	return nil
 }
+
 func verifyString(str string) bool          { return !(str == "" || len(str) <= 0) }
 func verifyMap(maps map[string]string) bool { return len(maps) > 0 }
 
+func copyLabels(labels map[string]string) map[string]string {
+	cp := make(map[string]string, len(labels))
+	for key, value := range labels {
+		cp[key] = value
+	}
+	return cp
+}
+
 func mapToEnvs(envMap map[string]string) ([]v1.EnvVar, error) {
 	if len(envMap) <= 0 {
-		return nil, errors.New("SetEnvs error, envMap is not allowed to be empty")
+		return nil, fieldError("SetEnvs", "envMap is not allowed to be empty")
 	}
-	var envs []v1.EnvVar
-	for k, v := range envMap {
+	// envs has exactly one item per key, fill it by index without growing it,
+	// envs are sorted by name,so the same map always creates the same container,eg: Diff() and $(VAR) references.
+	envs := make([]v1.EnvVar, len(envMap))
+	for index, k := range sortedKeys(envMap) {
+		v := envMap[k]
 		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
 		if k == "" || v == "" {
-			return nil, fmt.Errorf("SetEnvs error, key or value is not allowed to be empty,data(%s:%s)", k, v)
+			return nil, fieldErrorf("SetEnvs", "key or value is not allowed to be empty,data(%s:%s)", k, v)
 		}
-		envs = append(envs, v1.EnvVar{Name: k, Value: v})
-	}
-	if len(envs) <= 0 {
-		return nil, fmt.Errorf("SetEnvs error, envs is not allowed to be empty")
+		envs[index] = v1.EnvVar{Name: k, Value: v}
 	}
 	return envs, nil
 }
@@ -238,6 +348,9 @@ type client struct {
 
 var defaultClient = new(client)
 
+// clientset is registered by RegisterClientset(),it has higher priority than RegisterK8sClient()
+var clientset kubernetes.Interface
+
 func getClientConfig() *client {
 	return defaultClient
 }
@@ -254,27 +367,43 @@ func GetKubeClient() (*kubernetes.Clientset, error) {
 	return getKubeClient(config.Host)
 }
 
+// getKubeInterface get the registered clientset,
+// if no clientset is registered, get Kubernetes apiServer client by RegisterK8sClient() config
+func getKubeInterface() (kubernetes.Interface, error) {
+	if clientset != nil {
+		return clientset, nil
+	}
+	return GetKubeClient()
+}
+
+// RegisterClientset register a Kubernetes clientset on Beku, Release() and Apply() will use it,
+// eg: the fake clientset of k8s.io/client-go/kubernetes/fake is used to test without a cluster.
+// call RegisterClientset(nil) to use RegisterK8sClient() config again.
+func RegisterClientset(cs kubernetes.Interface) {
+	clientset = cs
+}
+
 // ViaTLS  verify Kubernetes apiServer cert
 func ViaTLS(ca, cert, key []byte) bool {
 	return len(ca) > 1 && len(cert) > 1 && len(key) > 1
 }
 
 func getTLSKubeClient(host string, ca, cert, key []byte) (*kubernetes.Clientset, error) {
-	return kubernetes.NewForConfig(&rest.Config{
+	return kubernetes.NewForConfig(withRateLimit(&rest.Config{
 		Host: host,
 		TLSClientConfig: rest.TLSClientConfig{
 			CAData:   ca,
 			CertData: cert,
 			KeyData:  key,
 		},
-	})
+	}))
 
 }
 
 func getKubeClient(host string) (*kubernetes.Clientset, error) {
-	return kubernetes.NewForConfig(&rest.Config{
+	return kubernetes.NewForConfig(withRateLimit(&rest.Config{
 		Host: host,
-	})
+	}))
 }
 
 // RegisterK8sClient register k8s apiServer Client on Beku
@@ -0,0 +1,223 @@
+package beku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	storv1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// HealthStatus is the kstatus-like status of resource object on Kubernetes
+type HealthStatus string
+
+const (
+	// HealthCurrent the object is fully reconciled,eg: Deployment is available,Job is complete
+	HealthCurrent HealthStatus = "Current"
+	// HealthInProgress the object is being reconciled
+	HealthInProgress HealthStatus = "InProgress"
+	// HealthFailed the object can't be reconciled,eg: Deployment exceeded its progress deadline,Job is failed
+	HealthFailed HealthStatus = "Failed"
+	// HealthNotFound the object doesn't exist on Kubernetes
+	HealthNotFound HealthStatus = "NotFound"
+	// HealthUnknown the status can't be got,eg: apiServer is unreachable
+	HealthUnknown HealthStatus = "Unknown"
+)
+
+// healthRank the higher the number,the worse the status, the status of Bundle is the worst one
+var healthRank = map[HealthStatus]int{
+	HealthCurrent:    0,
+	HealthInProgress: 1,
+	HealthUnknown:    2,
+	HealthNotFound:   3,
+	HealthFailed:     4,
+}
+
+// ResourceHealth is the health of one resource object
+type ResourceHealth struct {
+	Kind      string       `json:"kind"`
+	Namespace string       `json:"namespace,omitempty"`
+	Name      string       `json:"name"`
+	Status    HealthStatus `json:"status"`
+	Message   string       `json:"message,omitempty"`
+}
+
+// HealthReport is the health of all resource objects of Bundle,
+// Status is the worst status of them,so CD can gate on it: only Current means healthy.
+type HealthReport struct {
+	Status    HealthStatus     `json:"status"`
+	Resources []ResourceHealth `json:"resources"`
+}
+
+// Healthy the status of all resource objects is Current
+func (report HealthReport) Healthy() bool { return report.Status == HealthCurrent }
+
+// Health get the health of all objects of Bundle on Kubernetes,
+// client is the Client of Kubernetes,the registered cluster is used when it is nil.
+// the error of getting one object is reported as Unknown status of it, error is returned only when ctx is done.
+func (b *Bundle) Health(ctx context.Context, client *Client) (HealthReport, error) {
+	report := HealthReport{Status: HealthCurrent}
+	if len(b.builders) > 0 {
+		return report, errors.New("Bundle Health err,the builders are not finished,you should call FinishAll() first")
+	}
+	client, err := clientOrDefault(client)
+	if err != nil {
+		return report, err
+	}
+	for _, obj := range b.objs {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		health := objectHealth(ctx, client, obj)
+		if healthRank[health.Status] > healthRank[report.Status] {
+			report.Status = health.Status
+		}
+		report.Resources = append(report.Resources, health)
+	}
+	return report, nil
+}
+
+// objectHealth get the object from Kubernetes and evaluate its health
+func objectHealth(ctx context.Context, client *Client, obj runtime.Object) ResourceHealth {
+	health := ResourceHealth{Kind: objectKind(obj), Status: HealthUnknown}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		health.Message = err.Error()
+		return health
+	}
+	health.Namespace, health.Name = accessor.GetNamespace(), accessor.GetName()
+	rc, err := client.resourceClient(obj)
+	if err != nil {
+		health.Message = err.Error()
+		return health
+	}
+	current, err := rc.get(ctx, health.Name)
+	if apierrors.IsNotFound(err) {
+		health.Status, health.Message = HealthNotFound, fmt.Sprintf("%s is not found", objectName(obj))
+		return health
+	}
+	if err != nil {
+		health.Message = err.Error()
+		return health
+	}
+	health.Status, health.Message = evaluateHealth(ctx, client, current)
+	return health
+}
+
+// evaluateHealth evaluate the health of the object got from Kubernetes
+func evaluateHealth(ctx context.Context, client *Client, obj runtime.Object) (HealthStatus, string) {
+	switch o := obj.(type) {
+	case *v1.Namespace:
+		if o.Status.Phase == v1.NamespaceTerminating {
+			return HealthInProgress, "Namespace is terminating"
+		}
+		return HealthCurrent, ""
+	case *v1.PersistentVolumeClaim:
+		switch o.Status.Phase {
+		case v1.ClaimBound:
+			return HealthCurrent, ""
+		case v1.ClaimLost:
+			return HealthFailed, "PersistentVolumeClaim lost its PersistentVolume"
+		}
+		// the claim of WaitForFirstConsumer storage class is bound after the Pod is scheduled
+		if o.Spec.StorageClassName != nil {
+			sc, err := client.kube.StorageV1().StorageClasses().Get(ctx, *o.Spec.StorageClassName, metav1.GetOptions{})
+			if err == nil && sc.VolumeBindingMode != nil && *sc.VolumeBindingMode == storv1.VolumeBindingWaitForFirstConsumer {
+				return HealthCurrent, "PersistentVolumeClaim waits for first consumer"
+			}
+		}
+		return HealthInProgress, "PersistentVolumeClaim is not bound"
+	case *appsv1.Deployment:
+		for _, condition := range o.Status.Conditions {
+			if condition.Type == appsv1.DeploymentProgressing && condition.Status == v1.ConditionFalse {
+				return HealthFailed, condition.Message
+			}
+		}
+		replicas := replicasOrDefault(o.Spec.Replicas)
+		if o.Status.ObservedGeneration < o.Generation {
+			return HealthInProgress, "Deployment spec is not observed"
+		}
+		if o.Status.UpdatedReplicas < replicas || o.Status.AvailableReplicas < replicas {
+			return HealthInProgress, fmt.Sprintf("Deployment %d/%d replicas are updated,%d/%d are available",
+				o.Status.UpdatedReplicas, replicas, o.Status.AvailableReplicas, replicas)
+		}
+		return HealthCurrent, ""
+	case *appsv1.StatefulSet:
+		replicas := replicasOrDefault(o.Spec.Replicas)
+		if o.Status.ObservedGeneration < o.Generation {
+			return HealthInProgress, "StatefulSet spec is not observed"
+		}
+		if o.Status.UpdatedReplicas < replicas || o.Status.ReadyReplicas < replicas {
+			return HealthInProgress, fmt.Sprintf("StatefulSet %d/%d replicas are updated,%d/%d are ready",
+				o.Status.UpdatedReplicas, replicas, o.Status.ReadyReplicas, replicas)
+		}
+		return HealthCurrent, ""
+	case *appsv1.DaemonSet:
+		desired := o.Status.DesiredNumberScheduled
+		if o.Status.ObservedGeneration < o.Generation {
+			return HealthInProgress, "DaemonSet spec is not observed"
+		}
+		if o.Status.UpdatedNumberScheduled < desired || o.Status.NumberReady < desired {
+			return HealthInProgress, fmt.Sprintf("DaemonSet %d/%d pods are updated,%d/%d are ready",
+				o.Status.UpdatedNumberScheduled, desired, o.Status.NumberReady, desired)
+		}
+		return HealthCurrent, ""
+	case *batchv1.Job:
+		for _, condition := range o.Status.Conditions {
+			if condition.Status != v1.ConditionTrue {
+				continue
+			}
+			switch condition.Type {
+			case batchv1.JobComplete:
+				return HealthCurrent, ""
+			case batchv1.JobFailed:
+				return HealthFailed, condition.Message
+			}
+		}
+		return HealthInProgress, fmt.Sprintf("Job %d pods are active,%d are succeeded", o.Status.Active, o.Status.Succeeded)
+	case *unstructured.Unstructured:
+		return evaluateConditions(o)
+	}
+	return HealthCurrent, ""
+}
+
+// evaluateConditions evaluate the health of the object without builder by the conventional conditions:
+// Stalled=True is failed,Ready=False or Reconciling=True is in progress,
+// CustomResourceDefinition is current when it is Established.
+func evaluateConditions(u *unstructured.Unstructured) (HealthStatus, string) {
+	status := objectConditions(u.Object)
+	message := func(conditionType string) string {
+		msg, _ := status[conditionType]["message"].(string)
+		return msg
+	}
+	is := func(conditionType, value string) bool {
+		return status[conditionType] != nil && status[conditionType]["status"] == value
+	}
+	switch {
+	case u.GetKind() == "CustomResourceDefinition" && !is("Established", "True"):
+		return HealthInProgress, "CustomResourceDefinition is not established"
+	case is("Stalled", "True"):
+		return HealthFailed, message("Stalled")
+	case is("Reconciling", "True"):
+		return HealthInProgress, message("Reconciling")
+	case is("Ready", "False"):
+		return HealthInProgress, message("Ready")
+	}
+	return HealthCurrent, ""
+}
+
+// replicasOrDefault Kubernetes defaults replicas to 1 when it is not set
+func replicasOrDefault(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
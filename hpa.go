@@ -0,0 +1,370 @@
+package beku
+
+import (
+	"context"
+	"fmt"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// HPA include Kubernetes resource object HorizontalPodAutoscaler(autoscaling/v2) and error
+type HPA struct {
+	hpa *autoscalingv2.HorizontalPodAutoscaler
+	err error
+}
+
+// NewHPA create HorizontalPodAutoscaler and chain function call begin with this function.
+func NewHPA() *HPA { return &HPA{hpa: &autoscalingv2.HorizontalPodAutoscaler{}} }
+
+// HPAFrom create HPA from the existing HorizontalPodAutoscaler,eg: the one got by client,
+// so it can be changed by the chain function and released again,
+// hpa is deep copied and the fields which are not changed by the chain function are kept.
+func HPAFrom(hpa *autoscalingv2.HorizontalPodAutoscaler) *HPA {
+	if hpa == nil {
+		return NewHPA()
+	}
+	return &HPA{hpa: hpa.DeepCopy()}
+}
+
+// Finish Chain function call end with this function
+// return real HPA(really HPA is kubernetes resource object HorizontalPodAutoscaler and error
+// In the function, it will check necessary parameters、input the default field
+func (obj *HPA) Finish() (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	err := appendError(obj.err, obj.verify())
+	return obj.hpa, err
+}
+
+// Validate check HPA necessary value like Finish(),and return the problems as FieldError,
+// but HPA is not changed, no Kind,APIVersion or default field is input,
+// so it can be used to check a partially built HPA.
+func (obj *HPA) Validate() []FieldError {
+	cp := &HPA{hpa: obj.hpa.DeepCopy(), err: obj.err}
+	return FieldErrors(appendError(cp.err, cp.verify()))
+}
+
+// FinishObject same as Finish(), but return HPA as runtime.Object,
+// so HPA can be used as Builder, eg: add into Bundle.
+func (obj *HPA) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// FinishUnchecked return HPA without the checks and default fields of Finish(),only Kind and APIVersion are input,
+// it is for the partial HPA which is patched onto the existing one,the error of the chain is still returned.
+func (obj *HPA) FinishUnchecked() (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	obj.hpa.Kind, obj.hpa.APIVersion = "HorizontalPodAutoscaler", "autoscaling/v2"
+	return obj.hpa, obj.err
+}
+
+// JSONNew use json data create HPA
+func (obj *HPA) JSONNew(jsonbyts []byte) *HPA {
+	obj.error(decodeJSON(jsonbyts, obj.hpa))
+	return obj
+}
+
+// YAMLNew use yaml data create HPA
+func (obj *HPA) YAMLNew(yamlbyts []byte) *HPA {
+	obj.error(decodeYAML(yamlbyts, obj.hpa))
+	return obj
+}
+
+// Replace replace HPA by Kubernetes resource object
+func (obj *HPA) Replace(hpa *autoscalingv2.HorizontalPodAutoscaler) *HPA {
+	if hpa != nil {
+		obj.hpa = hpa
+	}
+	return obj
+}
+
+// SetName set HPA name
+func (obj *HPA) SetName(name string) *HPA {
+	obj.hpa.SetName(name)
+	return obj
+}
+
+// SetNamespace set HPA namespace,default namespace is 'default'
+func (obj *HPA) SetNamespace(namespace string) *HPA {
+	obj.hpa.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set HPA namespace and name
+func (obj *HPA) SetNamespaceAndName(namespace, name string) *HPA {
+	obj.hpa.SetName(name)
+	obj.hpa.SetNamespace(namespace)
+	return obj
+}
+
+// SetLabels set HPA labels
+func (obj *HPA) SetLabels(labels map[string]string) *HPA {
+	obj.hpa.SetLabels(labels)
+	return obj
+}
+
+// AddLabel add or overwrite one label of HPA,the other labels are kept
+func (obj *HPA) AddLabel(key, value string) *HPA {
+	obj.error(addLabel(obj.hpa, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of HPA,the other annotations are kept
+func (obj *HPA) AddAnnotation(key, value string) *HPA {
+	obj.error(addAnnotation(obj.hpa, key, value))
+	return obj
+}
+
+// SetScaleTarget set the workload scaled by HPA,kind is Deployment or StatefulSet,
+// it is in the same namespace as HPA.
+func (obj *HPA) SetScaleTarget(kind, name string) *HPA {
+	if kind != "Deployment" && kind != "StatefulSet" {
+		obj.error(fieldErrorf("SetScaleTarget", "kind %s is not allowed,only Deployment and StatefulSet", kind))
+		return obj
+	}
+	if !verifyString(name) {
+		obj.error(fieldError("SetScaleTarget", "name is not allowed to be empty"))
+		return obj
+	}
+	obj.hpa.Spec.ScaleTargetRef = autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: kind, Name: name}
+	return obj
+}
+
+// SetScaleTargetDeployment set the Deployment scaled by HPA
+func (obj *HPA) SetScaleTargetDeployment(name string) *HPA {
+	return obj.SetScaleTarget("Deployment", name)
+}
+
+// SetMinMaxReplicas set the range of replicas,0 < min <= max
+func (obj *HPA) SetMinMaxReplicas(min, max int32) *HPA {
+	if min < 1 || max < min {
+		obj.error(fieldErrorf("SetMinMaxReplicas", "min %d and max %d are not allowed,range: 0 < min <= max", min, max))
+		return obj
+	}
+	obj.hpa.Spec.MinReplicas = &min
+	obj.hpa.Spec.MaxReplicas = max
+	return obj
+}
+
+// SetTargetCPUUtilization scale the workload to keep the average cpu usage at pct percent of the cpu requests,
+// so the containers must request cpu.
+func (obj *HPA) SetTargetCPUUtilization(pct int32) *HPA {
+	return obj.setUtilization("SetTargetCPUUtilization", v1.ResourceCPU, pct)
+}
+
+// SetTargetMemoryUtilization scale the workload to keep the average memory usage at pct percent of the memory requests
+func (obj *HPA) SetTargetMemoryUtilization(pct int32) *HPA {
+	return obj.setUtilization("SetTargetMemoryUtilization", v1.ResourceMemory, pct)
+}
+
+// SetTargetMemoryAverage scale the workload to keep the average memory usage of Pods at value,eg: 512Mi
+func (obj *HPA) SetTargetMemoryAverage(value string) *HPA {
+	quantity, err := hpaQuantity("SetTargetMemoryAverage", value)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.setMetric(autoscalingv2.MetricSpec{
+		Type:     autoscalingv2.ResourceMetricSourceType,
+		Resource: &autoscalingv2.ResourceMetricSource{Name: v1.ResourceMemory, Target: averageValueTarget(quantity)},
+	})
+	return obj
+}
+
+// AddPodsMetric scale the workload to keep the average of the custom metric of Pods at averageValue,
+// eg: AddPodsMetric("http_requests_per_second", "100"),the metric is served by custom metrics API like prometheus-adapter.
+func (obj *HPA) AddPodsMetric(metricName, averageValue string) *HPA {
+	if !verifyString(metricName) {
+		obj.error(fieldError("AddPodsMetric", "metricName is not allowed to be empty"))
+		return obj
+	}
+	quantity, err := hpaQuantity("AddPodsMetric", averageValue)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.setMetric(autoscalingv2.MetricSpec{
+		Type: autoscalingv2.PodsMetricSourceType,
+		Pods: &autoscalingv2.PodsMetricSource{Metric: autoscalingv2.MetricIdentifier{Name: metricName}, Target: averageValueTarget(quantity)},
+	})
+	return obj
+}
+
+// AddExternalMetric scale the workload by the metric outside Kubernetes,eg: the length of message queue,
+// the metric is divided by the replicas and kept at averageValue,selector selects the series of the metric and can be nil.
+func (obj *HPA) AddExternalMetric(metricName string, selector map[string]string, averageValue string) *HPA {
+	if !verifyString(metricName) {
+		obj.error(fieldError("AddExternalMetric", "metricName is not allowed to be empty"))
+		return obj
+	}
+	quantity, err := hpaQuantity("AddExternalMetric", averageValue)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	metric := autoscalingv2.MetricIdentifier{Name: metricName}
+	if len(selector) > 0 {
+		metric.Selector = &metav1.LabelSelector{MatchLabels: copyLabels(selector)}
+	}
+	obj.setMetric(autoscalingv2.MetricSpec{
+		Type:     autoscalingv2.ExternalMetricSourceType,
+		External: &autoscalingv2.ExternalMetricSource{Metric: metric, Target: averageValueTarget(quantity)},
+	})
+	return obj
+}
+
+// Release release HPA on Kubernetes
+func (obj *HPA) Release() (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	hpa, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return nil, err
+	}
+	return client.AutoscalingV2().HorizontalPodAutoscalers(hpa.GetNamespace()).Create(context.TODO(), hpa, metav1.CreateOptions{})
+}
+
+// Apply  it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist.
+func (obj *HPA) Apply() (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	hpa, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return nil, err
+	}
+	_, err = client.AutoscalingV2().HorizontalPodAutoscalers(hpa.GetNamespace()).Get(context.TODO(), hpa.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.AutoscalingV2().HorizontalPodAutoscalers(hpa.GetNamespace()).Create(context.TODO(), hpa, metav1.CreateOptions{})
+	}
+	return client.AutoscalingV2().HorizontalPodAutoscalers(hpa.GetNamespace()).Update(context.TODO(), hpa, metav1.UpdateOptions{})
+}
+
+// Delete delete HPA on Kubernetes by its name and namespace,
+// opts[0] sets the propagation policy and grace period.
+func (obj *HPA) Delete(opts ...DeleteOptions) error {
+	if obj.err != nil {
+		return obj.err
+	}
+	options, err := newDeleteOptions("HorizontalPodAutoscaler", obj.hpa.GetName(), opts)
+	if err != nil {
+		return err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return err
+	}
+	return client.AutoscalingV2().HorizontalPodAutoscalers(obj.hpa.GetNamespace()).Delete(context.TODO(), obj.hpa.GetName(), *options)
+}
+
+// String the current HPA as yaml, the pending error is written on the top as comment.
+// it doesn't check and input default field like Finish(), so the HPA may be incomplete.
+func (obj *HPA) String() string { return dump("HorizontalPodAutoscaler", obj.hpa, obj.err) }
+
+// Dump print the current HPA and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *HPA) Dump() *HPA {
+	fmt.Println(obj.String())
+	return obj
+}
+
+func (obj *HPA) error(err error) {
+	obj.err = appendError(obj.err, err)
+}
+
+// verify check HPA necessary value, input the default field.
+func (obj *HPA) verify() error {
+	var errs error
+	if !verifyString(obj.hpa.GetName()) {
+		errs = appendError(errs, fieldError("HorizontalPodAutoscaler.Name", "is not allowed to be empty"))
+	}
+	if !verifyString(obj.hpa.Spec.ScaleTargetRef.Name) {
+		errs = appendError(errs, fieldError("HorizontalPodAutoscaler.Spec.ScaleTargetRef", "is not allowed to be empty,you can call SetScaleTargetDeployment() set it"))
+	}
+	if obj.hpa.Spec.MaxReplicas < 1 {
+		errs = appendError(errs, fieldError("HorizontalPodAutoscaler.Spec.MaxReplicas", "is not allowed to be empty,you can call SetMinMaxReplicas() set it"))
+	}
+	if len(obj.hpa.Spec.Metrics) == 0 {
+		errs = appendError(errs, fieldError("HorizontalPodAutoscaler.Spec.Metrics", "is not allowed to be empty,you can call SetTargetCPUUtilization() set it"))
+	}
+	if errs != nil {
+		return errs
+	}
+	obj.hpa.Kind = "HorizontalPodAutoscaler"
+	obj.hpa.APIVersion = "autoscaling/v2"
+	return nil
+}
+
+// setUtilization set the target utilization of the resource
+func (obj *HPA) setUtilization(method string, name v1.ResourceName, pct int32) *HPA {
+	if pct < 1 {
+		obj.error(fieldErrorf(method, "pct must be greater than 0"))
+		return obj
+	}
+	obj.setMetric(autoscalingv2.MetricSpec{
+		Type: autoscalingv2.ResourceMetricSourceType,
+		Resource: &autoscalingv2.ResourceMetricSource{
+			Name:   name,
+			Target: autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType, AverageUtilization: &pct},
+		},
+	})
+	return obj
+}
+
+// setMetric add the metric,the metric of the same resource or name is replaced
+func (obj *HPA) setMetric(metric autoscalingv2.MetricSpec) {
+	key := metricKey(metric)
+	for index := range obj.hpa.Spec.Metrics {
+		if metricKey(obj.hpa.Spec.Metrics[index]) == key {
+			obj.hpa.Spec.Metrics[index] = metric
+			return
+		}
+	}
+	obj.hpa.Spec.Metrics = append(obj.hpa.Spec.Metrics, metric)
+}
+
+// metricKey get the type and name of the metric
+func metricKey(metric autoscalingv2.MetricSpec) string {
+	switch {
+	case metric.Resource != nil:
+		return string(metric.Type) + "/" + string(metric.Resource.Name)
+	case metric.Pods != nil:
+		return string(metric.Type) + "/" + metric.Pods.Metric.Name
+	case metric.External != nil:
+		return string(metric.Type) + "/" + metric.External.Metric.Name
+	case metric.Object != nil:
+		return string(metric.Type) + "/" + metric.Object.Metric.Name
+	}
+	return string(metric.Type)
+}
+
+// hpaQuantity parse the target value of metric,it must be greater than 0
+func hpaQuantity(method, value string) (resource.Quantity, error) {
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return quantity, fieldErrorf(method, "value %q is not allowed:%v", value, err)
+	}
+	if quantity.Sign() <= 0 {
+		return quantity, fieldErrorf(method, "value %q must be greater than 0", value)
+	}
+	return quantity, nil
+}
+
+func averageValueTarget(quantity resource.Quantity) autoscalingv2.MetricTarget {
+	return autoscalingv2.MetricTarget{Type: autoscalingv2.AverageValueMetricType, AverageValue: &quantity}
+}
+
+// autoscaleDeployment create HPA of the Deployment by cpu utilization,
+// all containers must request cpu,otherwise the utilization can't be computed.
+func autoscaleDeployment(dp *Deployment, min, max, cpuPct int32) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	for _, container := range dp.dp.Spec.Template.Spec.Containers {
+		if _, ok := container.Resources.Requests[v1.ResourceCPU]; !ok {
+			return nil, fmt.Errorf("AutoscaleTo err,container %s doesn't request cpu,you can call SetResourceRequests() set it", container.Name)
+		}
+	}
+	return NewHPA().SetNamespaceAndName(dp.dp.GetNamespace(), dp.dp.GetName()).SetScaleTargetDeployment(dp.dp.GetName()).
+		SetMinMaxReplicas(min, max).SetTargetCPUUtilization(cpuPct).Finish()
+}
@@ -0,0 +1,324 @@
+package beku
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Ingress include Kubernetes resource object Ingress(networking.k8s.io/v1) and error
+type Ingress struct {
+	ing *networkingv1.Ingress
+	err error
+}
+
+// NewIngress create Ingress and chain function call begin with this function.
+func NewIngress() *Ingress { return &Ingress{ing: &networkingv1.Ingress{}} }
+
+// IngressFrom create Ingress from the existing Ingress,eg: the one got by client,
+// so it can be changed by the chain function and released again,
+// ing is deep copied and the fields which are not changed by the chain function are kept.
+func IngressFrom(ing *networkingv1.Ingress) *Ingress {
+	if ing == nil {
+		return NewIngress()
+	}
+	return &Ingress{ing: ing.DeepCopy()}
+}
+
+// Finish Chain function call end with this function
+// return real Ingress(really Ingress is kubernetes resource object Ingress and error
+// In the function, it will check necessary parameters、input the default field
+func (obj *Ingress) Finish() (*networkingv1.Ingress, error) {
+	err := appendError(obj.err, obj.verify())
+	return obj.ing, err
+}
+
+// Validate check Ingress necessary value like Finish(),and return the problems as FieldError,
+// but Ingress is not changed, no Kind,APIVersion or default field is input,
+// so it can be used to check a partially built Ingress.
+func (obj *Ingress) Validate() []FieldError {
+	cp := &Ingress{ing: obj.ing.DeepCopy(), err: obj.err}
+	return FieldErrors(appendError(cp.err, cp.verify()))
+}
+
+// FinishObject same as Finish(), but return Ingress as runtime.Object,
+// so Ingress can be used as Builder, eg: add into Bundle.
+func (obj *Ingress) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// FinishFor same as Finish(), but output Ingress as the apiVersion selected by opts,eg: networking.k8s.io/v1beta1 for the old clusters,
+// WithAPIVersion() select it directly,WithClusterVersion() and WithClusterOf() select the newest one the cluster serves.
+func (obj *Ingress) FinishFor(opts ...FinishOption) (runtime.Object, error) {
+	ing, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	return finishFor(ing, opts)
+}
+
+// FinishUnchecked return Ingress without the checks and default fields of Finish(),only Kind and APIVersion are input,
+// it is for the partial Ingress which is patched onto the existing one,the error of the chain is still returned.
+func (obj *Ingress) FinishUnchecked() (*networkingv1.Ingress, error) {
+	obj.ing.Kind, obj.ing.APIVersion = "Ingress", "networking.k8s.io/v1"
+	return obj.ing, obj.err
+}
+
+// JSONNew use json data create Ingress
+func (obj *Ingress) JSONNew(jsonbyts []byte) *Ingress {
+	obj.error(decodeJSON(jsonbyts, obj.ing))
+	return obj
+}
+
+// YAMLNew use yaml data create Ingress
+func (obj *Ingress) YAMLNew(yamlbyts []byte) *Ingress {
+	obj.error(decodeYAML(yamlbyts, obj.ing))
+	return obj
+}
+
+// Replace replace Ingress by Kubernetes resource object
+func (obj *Ingress) Replace(ing *networkingv1.Ingress) *Ingress {
+	if ing != nil {
+		obj.ing = ing
+	}
+	return obj
+}
+
+// SetName set Ingress name
+func (obj *Ingress) SetName(name string) *Ingress {
+	obj.ing.SetName(name)
+	return obj
+}
+
+// SetNamespace set Ingress namespace,default namespace is 'default'
+func (obj *Ingress) SetNamespace(namespace string) *Ingress {
+	obj.ing.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set Ingress namespace and name
+func (obj *Ingress) SetNamespaceAndName(namespace, name string) *Ingress {
+	obj.ing.SetName(name)
+	obj.ing.SetNamespace(namespace)
+	return obj
+}
+
+// SetLabels set Ingress labels
+func (obj *Ingress) SetLabels(labels map[string]string) *Ingress {
+	obj.ing.SetLabels(labels)
+	return obj
+}
+
+// AddLabel add or overwrite one label of Ingress,the other labels are kept
+func (obj *Ingress) AddLabel(key, value string) *Ingress {
+	obj.error(addLabel(obj.ing, key, value))
+	return obj
+}
+
+// SetAnnotations set Ingress annotations,eg: the annotations of ingress controller like nginx.ingress.kubernetes.io/rewrite-target
+func (obj *Ingress) SetAnnotations(annotations map[string]string) *Ingress {
+	obj.ing.SetAnnotations(annotations)
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of Ingress,the other annotations are kept
+func (obj *Ingress) AddAnnotation(key, value string) *Ingress {
+	obj.error(addAnnotation(obj.ing, key, value))
+	return obj
+}
+
+// SetIngressClass set the IngressClass which implements Ingress,eg: nginx,
+// the default IngressClass of the cluster is used when it is not set.
+func (obj *Ingress) SetIngressClass(className string) *Ingress {
+	if errs := validation.IsDNS1123Subdomain(className); len(errs) > 0 {
+		obj.error(fieldErrorf("SetIngressClass", "className %q is not allowed:%s", className, strings.Join(errs, ",")))
+		return obj
+	}
+	obj.ing.Spec.IngressClassName = &className
+	return obj
+}
+
+// SetDefaultBackend set the Service port which serves the requests not matching any rule
+func (obj *Ingress) SetDefaultBackend(serviceName string, servicePort int32) *Ingress {
+	backend, err := ingressBackend("SetDefaultBackend", serviceName, servicePort)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.ing.Spec.DefaultBackend = &backend
+	return obj
+}
+
+// AddRule route the requests of host and path to the Service port,
+// host is empty for all hosts and can be wildcard like *.example.com,
+// pathType is Prefix,Exact or ImplementationSpecific,default is Prefix,
+// the paths of the same host are in the same rule,eg: AddRule("example.com", "/api", "Prefix", "api", 80).
+func (obj *Ingress) AddRule(host, path, pathType, serviceName string, servicePort int32) *Ingress {
+	if host != "" {
+		if errs := validation.IsDNS1123Subdomain(strings.TrimPrefix(host, "*.")); len(errs) > 0 {
+			obj.error(fieldErrorf("AddRule", "host %q is not allowed:%s", host, strings.Join(errs, ",")))
+			return obj
+		}
+	}
+	if !strings.HasPrefix(path, "/") {
+		obj.error(fieldErrorf("AddRule", "path %q is not allowed,it must begin with /", path))
+		return obj
+	}
+	typ := networkingv1.PathType(pathType)
+	switch typ {
+	case "":
+		typ = networkingv1.PathTypePrefix
+	case networkingv1.PathTypePrefix, networkingv1.PathTypeExact, networkingv1.PathTypeImplementationSpecific:
+	default:
+		obj.error(fieldErrorf("AddRule", "pathType %s is not allowed,only Prefix,Exact and ImplementationSpecific", pathType))
+		return obj
+	}
+	backend, err := ingressBackend("AddRule", serviceName, servicePort)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	httpPath := networkingv1.HTTPIngressPath{Path: path, PathType: &typ, Backend: backend}
+	for index := range obj.ing.Spec.Rules {
+		rule := &obj.ing.Spec.Rules[index]
+		if rule.Host != host {
+			continue
+		}
+		if rule.HTTP == nil {
+			rule.HTTP = &networkingv1.HTTPIngressRuleValue{}
+		}
+		rule.HTTP.Paths = append(rule.HTTP.Paths, httpPath)
+		return obj
+	}
+	obj.ing.Spec.Rules = append(obj.ing.Spec.Rules, networkingv1.IngressRule{
+		Host:             host,
+		IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{Paths: []networkingv1.HTTPIngressPath{httpPath}}},
+	})
+	return obj
+}
+
+// SetTLS terminate TLS of hosts by the certificate in the Secret of secretName,the Secret is kubernetes.io/tls type
+// in the same namespace,hosts are the hosts in the certificate,calling it again with the same Secret replaces the hosts.
+func (obj *Ingress) SetTLS(secretName string, hosts ...string) *Ingress {
+	if errs := validation.IsDNS1123Subdomain(secretName); len(errs) > 0 {
+		obj.error(fieldErrorf("SetTLS", "secretName %q is not allowed:%s", secretName, strings.Join(errs, ",")))
+		return obj
+	}
+	tls := networkingv1.IngressTLS{SecretName: secretName, Hosts: append([]string(nil), hosts...)}
+	for index := range obj.ing.Spec.TLS {
+		if obj.ing.Spec.TLS[index].SecretName == secretName {
+			obj.ing.Spec.TLS[index] = tls
+			return obj
+		}
+	}
+	obj.ing.Spec.TLS = append(obj.ing.Spec.TLS, tls)
+	return obj
+}
+
+// Release release Ingress on Kubernetes
+func (obj *Ingress) Release() (*networkingv1.Ingress, error) {
+	ing, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return nil, err
+	}
+	return client.NetworkingV1().Ingresses(ing.GetNamespace()).Create(context.TODO(), ing, metav1.CreateOptions{})
+}
+
+// Apply  it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist.
+func (obj *Ingress) Apply() (*networkingv1.Ingress, error) {
+	ing, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return nil, err
+	}
+	_, err = client.NetworkingV1().Ingresses(ing.GetNamespace()).Get(context.TODO(), ing.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.NetworkingV1().Ingresses(ing.GetNamespace()).Create(context.TODO(), ing, metav1.CreateOptions{})
+	}
+	return client.NetworkingV1().Ingresses(ing.GetNamespace()).Update(context.TODO(), ing, metav1.UpdateOptions{})
+}
+
+// Delete delete Ingress on Kubernetes by its name and namespace,
+// opts[0] sets the propagation policy and grace period.
+func (obj *Ingress) Delete(opts ...DeleteOptions) error {
+	if obj.err != nil {
+		return obj.err
+	}
+	options, err := newDeleteOptions("Ingress", obj.ing.GetName(), opts)
+	if err != nil {
+		return err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return err
+	}
+	return client.NetworkingV1().Ingresses(obj.ing.GetNamespace()).Delete(context.TODO(), obj.ing.GetName(), *options)
+}
+
+// String the current Ingress as yaml, the pending error is written on the top as comment.
+// it doesn't check and input default field like Finish(), so the Ingress may be incomplete.
+func (obj *Ingress) String() string { return dump("Ingress", obj.ing, obj.err) }
+
+// Dump print the current Ingress and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *Ingress) Dump() *Ingress {
+	fmt.Println(obj.String())
+	return obj
+}
+
+func (obj *Ingress) error(err error) {
+	obj.err = appendError(obj.err, err)
+}
+
+// verify check Ingress necessary value, input the default field.
+func (obj *Ingress) verify() error {
+	var errs error
+	if !verifyString(obj.ing.GetName()) {
+		errs = appendError(errs, fieldError("Ingress.Name", "is not allowed to be empty"))
+	}
+	if len(obj.ing.Spec.Rules) == 0 && obj.ing.Spec.DefaultBackend == nil {
+		errs = appendError(errs, fieldError("Ingress.Spec.Rules", "is not allowed to be empty without default backend,you can call AddRule() add it"))
+	}
+	hosts := make(map[string]bool, len(obj.ing.Spec.Rules))
+	for _, rule := range obj.ing.Spec.Rules {
+		hosts[rule.Host] = true
+	}
+	for _, tls := range obj.ing.Spec.TLS {
+		for _, host := range tls.Hosts {
+			if !hosts[host] {
+				errs = appendError(errs, fieldErrorf("Ingress.Spec.TLS", "host %s of Secret %s has no rule,you can call AddRule() add it", host, tls.SecretName))
+			}
+		}
+	}
+	if errs != nil {
+		return errs
+	}
+	obj.ing.Kind = "Ingress"
+	obj.ing.APIVersion = "networking.k8s.io/v1"
+	return nil
+}
+
+// ingressBackend get the backend of the Service port
+func ingressBackend(method, serviceName string, servicePort int32) (networkingv1.IngressBackend, error) {
+	if errs := validation.IsDNS1035Label(serviceName); len(errs) > 0 {
+		return networkingv1.IngressBackend{}, fieldErrorf(method, "serviceName %q is not allowed:%s", serviceName, strings.Join(errs, ","))
+	}
+	if servicePort <= 0 || servicePort >= 65536 {
+		return networkingv1.IngressBackend{}, fieldErrorf(method, "servicePort range: 0 < servicePort < 65536")
+	}
+	return networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+		Name: serviceName,
+		Port: networkingv1.ServiceBackendPort{Number: servicePort},
+	}}, nil
+}
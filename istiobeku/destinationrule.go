@@ -0,0 +1,192 @@
+package istiobeku
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/yulibaozi/beku"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DestinationRule include Istio DestinationRule and error,
+// it defines the subsets and traffic policies of the host, such as TLS.
+type DestinationRule struct {
+	dr  *v1alpha3.DestinationRule
+	err error
+}
+
+// NewDestinationRule create Istio DestinationRule and chain function call begin with this function.
+func NewDestinationRule() *DestinationRule {
+	return &DestinationRule{dr: &v1alpha3.DestinationRule{}}
+}
+
+// Finish chain function call end with this function
+// return real DestinationRule(really DestinationRule is Istio resource object DestinationRule and error)
+// In the function, it will check necessary parameters、input the default field。
+func (obj *DestinationRule) Finish() (dr *v1alpha3.DestinationRule, err error) {
+	err = beku.AppendError(obj.err, obj.verify())
+	return obj.dr, err
+}
+
+// Validate check DestinationRule necessary value like Finish(),and return the problems as FieldError,
+// but DestinationRule is not changed.
+func (obj *DestinationRule) Validate() []beku.FieldError {
+	cp := &DestinationRule{dr: obj.dr.DeepCopy(), err: obj.err}
+	return beku.FieldErrors(beku.AppendError(cp.err, cp.verify()))
+}
+
+// FinishObject same as Finish(), but return DestinationRule as runtime.Object,
+// so DestinationRule can be used as Builder, eg: add into Bundle.
+func (obj *DestinationRule) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// FinishUnchecked return DestinationRule without the checks and default fields of Finish(),only Kind and APIVersion are input,
+// it is for the partial DestinationRule which is patched onto the existing one,the error of the chain is still returned.
+func (obj *DestinationRule) FinishUnchecked() (*v1alpha3.DestinationRule, error) {
+	obj.dr.Kind, obj.dr.APIVersion = "DestinationRule", "networking.istio.io/v1alpha3"
+	return obj.dr, obj.err
+}
+
+// JSONNew use json data create DestinationRule
+func (obj *DestinationRule) JSONNew(jsonbyts []byte) *DestinationRule {
+	obj.error(beku.DecodeJSON(jsonbyts, obj.dr))
+	return obj
+}
+
+// YAMLNew use yaml data create DestinationRule
+func (obj *DestinationRule) YAMLNew(yamlbyts []byte) *DestinationRule {
+	obj.error(beku.DecodeYAML(yamlbyts, obj.dr))
+	return obj
+}
+
+// Replace replace DestinationRule by Istio resource object
+func (obj *DestinationRule) Replace(dr *v1alpha3.DestinationRule) *DestinationRule {
+	if dr != nil {
+		obj.dr = dr
+	}
+	return obj
+}
+
+// SetName set DestinationRule name
+func (obj *DestinationRule) SetName(name string) *DestinationRule {
+	obj.dr.SetName(name)
+	return obj
+}
+
+// SetNamespace set DestinationRule namespace
+func (obj *DestinationRule) SetNamespace(namespace string) *DestinationRule {
+	obj.dr.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set DestinationRule namespace and name
+func (obj *DestinationRule) SetNamespaceAndName(namespace, name string) *DestinationRule {
+	obj.dr.SetName(name)
+	obj.dr.SetNamespace(namespace)
+	return obj
+}
+
+// SetLabels set DestinationRule labels
+func (obj *DestinationRule) SetLabels(labels map[string]string) *DestinationRule {
+	obj.dr.SetLabels(labels)
+	return obj
+}
+
+// AddLabel add or overwrite one label of DestinationRule,the other labels are kept,
+// unlike SetLabels() which replaces all labels,eg: the labels loaded by YAMLNew().
+func (obj *DestinationRule) AddLabel(key, value string) *DestinationRule {
+	obj.error(beku.AddLabel(obj.dr, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of DestinationRule,the other annotations are kept
+func (obj *DestinationRule) AddAnnotation(key, value string) *DestinationRule {
+	obj.error(beku.AddAnnotation(obj.dr, key, value))
+	return obj
+}
+
+// SetHost set the host which the rule is applied to,
+// eg: the short name of Kubernetes Service "reviews",or FQDN "reviews.default.svc.cluster.local"
+func (obj *DestinationRule) SetHost(host string) *DestinationRule {
+	obj.dr.Spec.Host = host
+	return obj
+}
+
+// AddSubset add a subset of the host, the subset is the Pods with the labels,
+// eg: AddSubset("v1", map[string]string{"version": "v1"}), it is used by VirtualService AddHTTPRoute()
+func (obj *DestinationRule) AddSubset(name string, labels map[string]string) *DestinationRule {
+	if name == "" {
+		obj.error(errors.New("AddSubset err,name is not allowed to be empty"))
+		return obj
+	}
+	if len(labels) == 0 {
+		obj.error(fmt.Errorf("AddSubset err,subset %s labels is not allowed to be empty", name))
+		return obj
+	}
+	obj.dr.Spec.Subsets = append(obj.dr.Spec.Subsets, &networking.Subset{Name: name, Labels: labels})
+	return obj
+}
+
+// SetTLSMode set the TLS mode of the connections to the host
+func (obj *DestinationRule) SetTLSMode(mode TLSMode) *DestinationRule {
+	if obj.dr.Spec.TrafficPolicy == nil {
+		obj.dr.Spec.TrafficPolicy = &networking.TrafficPolicy{}
+	}
+	obj.dr.Spec.TrafficPolicy.Tls = &networking.ClientTLSSettings{Mode: mode.ToIstio()}
+	return obj
+}
+
+// SetSubsetTLSMode set the TLS mode of the connections to the subset,it overrides SetTLSMode(),
+// the subset must be added by AddSubset() first.
+func (obj *DestinationRule) SetSubsetTLSMode(subset string, mode TLSMode) *DestinationRule {
+	for _, s := range obj.dr.Spec.Subsets {
+		if s.Name != subset {
+			continue
+		}
+		if s.TrafficPolicy == nil {
+			s.TrafficPolicy = &networking.TrafficPolicy{}
+		}
+		s.TrafficPolicy.Tls = &networking.ClientTLSSettings{Mode: mode.ToIstio()}
+		return obj
+	}
+	obj.error(fmt.Errorf("SetSubsetTLSMode err,subset %s is not found,you can call AddSubset() add it", subset))
+	return obj
+}
+
+// String the current DestinationRule as yaml, the pending error is written on the top as comment.
+func (obj *DestinationRule) String() string { return beku.Dump("DestinationRule", obj.dr, obj.err) }
+
+// Dump print the current DestinationRule and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *DestinationRule) Dump() *DestinationRule {
+	fmt.Println(obj.String())
+	return obj
+}
+
+func (obj *DestinationRule) error(err error) {
+	obj.err = beku.AppendError(obj.err, err)
+}
+
+func (obj *DestinationRule) verify() error {
+	var errs error
+	if obj.dr.GetName() == "" {
+		errs = beku.AppendError(errs, beku.FieldError{Field: "DestinationRule.Name", Message: "is not allowed to be empty"})
+	}
+	if obj.dr.Spec.Host == "" {
+		errs = beku.AppendError(errs, beku.FieldError{Field: "DestinationRule.Spec.Host", Message: "is not allowed to be empty,you can call SetHost()"})
+	}
+	names := make(map[string]bool, len(obj.dr.Spec.Subsets))
+	for _, subset := range obj.dr.Spec.Subsets {
+		if names[subset.Name] {
+			errs = beku.AppendError(errs, beku.FieldError{Field: "DestinationRule.Spec.Subsets", Message: fmt.Sprintf("subset %s is duplicated", subset.Name)})
+		}
+		names[subset.Name] = true
+	}
+	if errs != nil {
+		return errs
+	}
+	obj.dr.Kind = "DestinationRule"
+	obj.dr.APIVersion = "networking.istio.io/v1alpha3"
+	return nil
+}
@@ -0,0 +1,56 @@
+// Package istiobeku provides the builders of Istio VirtualService and DestinationRule,
+// they are registered into beku when the package is imported,
+// so StreamManifests(),NewBuilder() and SchemaForKind() of beku support them:
+//
+//	import _ "github.com/yulibaozi/beku/istiobeku"
+//
+// it is a separate package,so the programs which don't use Istio don't depend on it.
+package istiobeku
+
+import (
+	"github.com/yulibaozi/beku"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+)
+
+func init() {
+	for _, err := range []error{
+		beku.RegisterKind("VirtualService", "networking.istio.io/v1alpha3", v1alpha3.VirtualService{},
+			func(doc []byte) beku.Builder { return NewVirtualService().JSONNew(doc) }),
+		beku.RegisterKind("DestinationRule", "networking.istio.io/v1alpha3", v1alpha3.DestinationRule{},
+			func(doc []byte) beku.Builder { return NewDestinationRule().JSONNew(doc) }),
+	} {
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// TLSMode is the TLS mode of the connections to the upstream service of Istio DestinationRule
+type TLSMode string
+
+const (
+	// TLSModeDisable do not setup a TLS connection to the upstream endpoint.
+	TLSModeDisable TLSMode = "DISABLE"
+	// TLSModeSimple originate a TLS connection to the upstream endpoint.
+	TLSModeSimple TLSMode = "SIMPLE"
+	// TLSModeMutual secure connections to the upstream using mutual TLS by presenting client certificates.
+	TLSModeMutual TLSMode = "MUTUAL"
+	// TLSModeIstioMutual secure connections to the upstream using mutual TLS by Istio certificates.
+	TLSModeIstioMutual TLSMode = "ISTIO_MUTUAL"
+)
+
+var tlsModes = map[TLSMode]networking.ClientTLSSettings_TLSmode{
+	TLSModeDisable:     networking.ClientTLSSettings_DISABLE,
+	TLSModeSimple:      networking.ClientTLSSettings_SIMPLE,
+	TLSModeMutual:      networking.ClientTLSSettings_MUTUAL,
+	TLSModeIstioMutual: networking.ClientTLSSettings_ISTIO_MUTUAL,
+}
+
+// ToIstio translate into Istio TLS mode,default ISTIO_MUTUAL
+func (mode TLSMode) ToIstio() networking.ClientTLSSettings_TLSmode {
+	if m, ok := tlsModes[mode]; ok {
+		return m
+	}
+	return networking.ClientTLSSettings_ISTIO_MUTUAL
+}
@@ -0,0 +1,47 @@
+package istiobeku_test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	"github.com/yulibaozi/beku/istiobeku"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+)
+
+func Test_VirtualServiceRoutes(t *testing.T) {
+	vs, err := istiobeku.NewVirtualService().SetNamespaceAndName("roc", "reviews").SetHosts("reviews").
+		AddHTTPRoute("/", "reviews", "v1", 90).AddHTTPRoute("/", "reviews", "v2", 10).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vs.Spec.Http) != 1 || len(vs.Spec.Http[0].Route) != 2 {
+		t.Fatalf("the destinations with the same prefix should be in one route,got %d routes", len(vs.Spec.Http))
+	}
+	_, err = istiobeku.NewVirtualService().SetNamespaceAndName("roc", "reviews").SetHosts("reviews").
+		AddHTTPRoute("/", "reviews", "v1", 90).AddHTTPRoute("/", "reviews", "v2", 20).Finish()
+	if err == nil {
+		t.Fatal("weights sum to 110, Finish should return error")
+	}
+}
+
+func Test_DestinationRuleSubsets(t *testing.T) {
+	_, err := istiobeku.NewDestinationRule().SetNamespaceAndName("roc", "reviews").SetHost("reviews").
+		AddSubset("v1", map[string]string{"version": "v1"}).SetSubsetTLSMode("v2", istiobeku.TLSModeIstioMutual).Finish()
+	if err == nil {
+		t.Fatal("subset v2 is not added, Finish should return error")
+	}
+}
+
+func Test_IstioKindsRegistered(t *testing.T) {
+	doc := []byte("apiVersion: networking.istio.io/v1alpha3\nkind: VirtualService\nmetadata:\n  name: reviews\nspec:\n  hosts: [reviews]\n")
+	builder, err := beku.NewBuilder(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := builder.(*istiobeku.VirtualService); !ok {
+		t.Fatalf("expect VirtualService builder,got %T", builder)
+	}
+	if _, err := beku.SchemaForKind(v1alpha3.SchemeGroupVersion.WithKind("DestinationRule")); err != nil {
+		t.Fatal(err)
+	}
+}
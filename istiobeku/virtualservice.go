@@ -0,0 +1,210 @@
+package istiobeku
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/yulibaozi/beku"
+	networking "istio.io/api/networking/v1alpha3"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// VirtualService include Istio VirtualService and error,
+// it defines the traffic routing of the hosts in the service mesh.
+type VirtualService struct {
+	vs  *v1alpha3.VirtualService
+	err error
+}
+
+// NewVirtualService create Istio VirtualService and chain function call begin with this function.
+func NewVirtualService() *VirtualService {
+	return &VirtualService{vs: &v1alpha3.VirtualService{}}
+}
+
+// Finish chain function call end with this function
+// return real VirtualService(really VirtualService is Istio resource object VirtualService and error)
+// In the function, it will check necessary parameters、input the default field。
+func (obj *VirtualService) Finish() (vs *v1alpha3.VirtualService, err error) {
+	err = beku.AppendError(obj.err, obj.verify())
+	return obj.vs, err
+}
+
+// Validate check VirtualService necessary value like Finish(),and return the problems as FieldError,
+// but VirtualService is not changed.
+func (obj *VirtualService) Validate() []beku.FieldError {
+	cp := &VirtualService{vs: obj.vs.DeepCopy(), err: obj.err}
+	return beku.FieldErrors(beku.AppendError(cp.err, cp.verify()))
+}
+
+// FinishObject same as Finish(), but return VirtualService as runtime.Object,
+// so VirtualService can be used as Builder, eg: add into Bundle.
+func (obj *VirtualService) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// FinishUnchecked return VirtualService without the checks and default fields of Finish(),only Kind and APIVersion are input,
+// it is for the partial VirtualService which is patched onto the existing one,the error of the chain is still returned.
+func (obj *VirtualService) FinishUnchecked() (*v1alpha3.VirtualService, error) {
+	obj.vs.Kind, obj.vs.APIVersion = "VirtualService", "networking.istio.io/v1alpha3"
+	return obj.vs, obj.err
+}
+
+// JSONNew use json data create VirtualService
+func (obj *VirtualService) JSONNew(jsonbyts []byte) *VirtualService {
+	obj.error(beku.DecodeJSON(jsonbyts, obj.vs))
+	return obj
+}
+
+// YAMLNew use yaml data create VirtualService
+func (obj *VirtualService) YAMLNew(yamlbyts []byte) *VirtualService {
+	obj.error(beku.DecodeYAML(yamlbyts, obj.vs))
+	return obj
+}
+
+// Replace replace VirtualService by Istio resource object
+func (obj *VirtualService) Replace(vs *v1alpha3.VirtualService) *VirtualService {
+	if vs != nil {
+		obj.vs = vs
+	}
+	return obj
+}
+
+// SetName set VirtualService name
+func (obj *VirtualService) SetName(name string) *VirtualService {
+	obj.vs.SetName(name)
+	return obj
+}
+
+// SetNamespace set VirtualService namespace
+func (obj *VirtualService) SetNamespace(namespace string) *VirtualService {
+	obj.vs.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set VirtualService namespace and name
+func (obj *VirtualService) SetNamespaceAndName(namespace, name string) *VirtualService {
+	obj.vs.SetName(name)
+	obj.vs.SetNamespace(namespace)
+	return obj
+}
+
+// SetLabels set VirtualService labels
+func (obj *VirtualService) SetLabels(labels map[string]string) *VirtualService {
+	obj.vs.SetLabels(labels)
+	return obj
+}
+
+// AddLabel add or overwrite one label of VirtualService,the other labels are kept,
+// unlike SetLabels() which replaces all labels,eg: the labels loaded by YAMLNew().
+func (obj *VirtualService) AddLabel(key, value string) *VirtualService {
+	obj.error(beku.AddLabel(obj.vs, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of VirtualService,the other annotations are kept
+func (obj *VirtualService) AddAnnotation(key, value string) *VirtualService {
+	obj.error(beku.AddAnnotation(obj.vs, key, value))
+	return obj
+}
+
+// SetHosts set the destination hosts which the traffic is routed for,
+// eg: the short name of Kubernetes Service "reviews",or FQDN "reviews.default.svc.cluster.local"
+func (obj *VirtualService) SetHosts(hosts ...string) *VirtualService {
+	obj.vs.Spec.Hosts = hosts
+	return obj
+}
+
+// SetGateways set the gateways which the routes are applied to, default is the sidecars in the mesh
+func (obj *VirtualService) SetGateways(gateways ...string) *VirtualService {
+	obj.vs.Spec.Gateways = gateways
+	return obj
+}
+
+// AddHTTPRoute route the http requests which uri has the prefix matchPrefix to the subset of destHost,
+// empty matchPrefix matches all requests.
+// the destinations added with the same matchPrefix are in one route and split the traffic by weight,
+// eg: AddHTTPRoute("/", "reviews", "v1", 90).AddHTTPRoute("/", "reviews", "v2", 10)
+// subset is the subset name of DestinationRule,it can be empty.
+func (obj *VirtualService) AddHTTPRoute(matchPrefix, destHost, subset string, weight int32) *VirtualService {
+	if destHost == "" {
+		obj.error(errors.New("AddHTTPRoute err,destHost is not allowed to be empty"))
+		return obj
+	}
+	if weight < 0 || weight > 100 {
+		obj.error(fmt.Errorf("AddHTTPRoute err,weight %d is not in range 0-100", weight))
+		return obj
+	}
+	dest := &networking.HTTPRouteDestination{
+		Destination: &networking.Destination{Host: destHost, Subset: subset},
+		Weight:      weight,
+	}
+	for _, route := range obj.vs.Spec.Http {
+		if routePrefix(route) == matchPrefix {
+			route.Route = append(route.Route, dest)
+			return obj
+		}
+	}
+	route := &networking.HTTPRoute{Route: []*networking.HTTPRouteDestination{dest}}
+	if matchPrefix != "" {
+		route.Match = []*networking.HTTPMatchRequest{{
+			Uri: &networking.StringMatch{MatchType: &networking.StringMatch_Prefix{Prefix: matchPrefix}},
+		}}
+	}
+	obj.vs.Spec.Http = append(obj.vs.Spec.Http, route)
+	return obj
+}
+
+// routePrefix get the uri prefix of the route added by AddHTTPRoute(),
+// it is "" when the route matches all requests.
+func routePrefix(route *networking.HTTPRoute) string {
+	if len(route.Match) != 1 || route.Match[0].Uri == nil {
+		return ""
+	}
+	return route.Match[0].Uri.GetPrefix()
+}
+
+// String the current VirtualService as yaml, the pending error is written on the top as comment.
+func (obj *VirtualService) String() string { return beku.Dump("VirtualService", obj.vs, obj.err) }
+
+// Dump print the current VirtualService and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *VirtualService) Dump() *VirtualService {
+	fmt.Println(obj.String())
+	return obj
+}
+
+func (obj *VirtualService) error(err error) {
+	obj.err = beku.AppendError(obj.err, err)
+}
+
+func (obj *VirtualService) verify() error {
+	var errs error
+	if obj.vs.GetName() == "" {
+		errs = beku.AppendError(errs, beku.FieldError{Field: "VirtualService.Name", Message: "is not allowed to be empty"})
+	}
+	if len(obj.vs.Spec.Hosts) <= 0 {
+		errs = beku.AppendError(errs, beku.FieldError{Field: "VirtualService.Spec.Hosts", Message: "is not allowed to be empty,you can call SetHosts()"})
+	}
+	for index, route := range obj.vs.Spec.Http {
+		if len(route.Route) <= 0 {
+			errs = beku.AppendError(errs, beku.FieldError{Field: "VirtualService.Spec.Http", Message: fmt.Sprintf("http[%d] has no destination", index)})
+			continue
+		}
+		if len(route.Route) == 1 {
+			continue
+		}
+		// Istio requires the weights of the destinations in one route sum to 100
+		var total int32
+		for _, dest := range route.Route {
+			total += dest.Weight
+		}
+		if total != 100 {
+			errs = beku.AppendError(errs, beku.FieldError{Field: "VirtualService.Spec.Http", Message: fmt.Sprintf("http[%d] weights sum to %d,it must be 100", index, total)})
+		}
+	}
+	if errs != nil {
+		return errs
+	}
+	obj.vs.Kind = "VirtualService"
+	obj.vs.APIVersion = "networking.istio.io/v1alpha3"
+	return nil
+}
@@ -0,0 +1,412 @@
+package beku
+
+import (
+	"context"
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Job include Kubernetes resource object Job and error
+type Job struct {
+	PodSetters[*Job]
+	job *batchv1.Job
+	err error
+}
+
+// NewJob create Job and chain function call begin with this function.
+func NewJob() *Job { return (&Job{job: &batchv1.Job{}}).withPodSetters() }
+
+// JobFrom create Job from the existing Job,eg: the one got by client,
+// so it can be changed by the chain function and released again,
+// job is deep copied and the fields which are not changed by the chain function are kept.
+func JobFrom(job *batchv1.Job) *Job {
+	if job == nil {
+		return NewJob()
+	}
+	return (&Job{job: job.DeepCopy()}).withPodSetters()
+}
+
+// Finish Chain function call end with this function
+// return real Job(really Job is kubernetes resource object Job and error
+// In the function, it will check necessary parameters、input the default field
+func (obj *Job) Finish() (*batchv1.Job, error) {
+	return obj.job, appendError(obj.err, obj.verify())
+}
+
+// Validate check Job necessary value like Finish(),and return the problems as FieldError,
+// but Job is not changed, no Kind,APIVersion or default field is input,
+// so it can be used to check a partially built Job.
+func (obj *Job) Validate() []FieldError {
+	cp := (&Job{job: obj.job.DeepCopy(), err: obj.err}).withPodSetters()
+	return FieldErrors(appendError(cp.err, cp.verify()))
+}
+
+// Clone deep copy Job with the pending error,the clone and Job don't share any field,eg: containers and volumes,
+// so the clones of one base builder can be specialized in parallel goroutines,eg: per-tenant Jobs.
+// the builder itself is not safe for concurrent use,clone it for every goroutine.
+func (obj *Job) Clone() *Job {
+	return (&Job{job: obj.job.DeepCopy(), err: obj.err}).withPodSetters()
+}
+
+// FinishObject same as Finish(), but return Job as runtime.Object,
+// so Job can be used as Builder, eg: add into Bundle.
+func (obj *Job) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// FinishUnchecked return Job without the checks and default fields of Finish(),only Kind and APIVersion are input,
+// it is for the partial Job which is patched onto the existing one,the error of the chain is still returned.
+func (obj *Job) FinishUnchecked() (*batchv1.Job, error) {
+	obj.job.Kind, obj.job.APIVersion = "Job", "batch/v1"
+	return obj.job, obj.err
+}
+
+// JSONNew use json data create Job
+func (obj *Job) JSONNew(jsonbyts []byte) *Job {
+	obj.error(decodeJSON(jsonbyts, obj.job))
+	return obj
+}
+
+// YAMLNew use yaml data create Job
+func (obj *Job) YAMLNew(yamlbyts []byte) *Job {
+	obj.error(decodeYAML(yamlbyts, obj.job))
+	return obj
+}
+
+// Replace replace Job by Kubernetes resource object
+func (obj *Job) Replace(job *batchv1.Job) *Job {
+	if job != nil {
+		obj.job = job
+	}
+	return obj
+}
+
+// SetName set Job name
+func (obj *Job) SetName(name string) *Job {
+	obj.job.SetName(name)
+	return obj
+}
+
+// SetNamespace set Job namespace,default namespace is 'default'
+func (obj *Job) SetNamespace(namespace string) *Job {
+	obj.job.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set Job namespace and name
+func (obj *Job) SetNamespaceAndName(namespace, name string) *Job {
+	obj.job.SetName(name)
+	obj.job.SetNamespace(namespace)
+	return obj
+}
+
+// SetLabels set Job labels
+func (obj *Job) SetLabels(labels map[string]string) *Job {
+	obj.job.SetLabels(labels)
+	return obj
+}
+
+// AddLabel add or overwrite one label of Job,the other labels are kept
+func (obj *Job) AddLabel(key, value string) *Job {
+	obj.error(addLabel(obj.job, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of Job,the other annotations are kept
+func (obj *Job) AddAnnotation(key, value string) *Job {
+	obj.error(addAnnotation(obj.job, key, value))
+	return obj
+}
+
+// SetPodLabels set Pod labels of Job,the selector of Job is generated by Kubernetes,so it is not set
+func (obj *Job) SetPodLabels(labels map[string]string) *Job {
+	obj.job.Spec.Template.SetLabels(labels)
+	return obj
+}
+
+// SetPodTemplate replace the Pod template of Job by PodTemplate which is built by NewPodTemplate(),
+// PodTemplate is copied,so it can be attached to many workloads.
+func (obj *Job) SetPodTemplate(tpl *PodTemplate) *Job {
+	template, err := podTemplateOf(tpl)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.job.Spec.Template = *template
+	return obj
+}
+
+// MergeLabels add or overwrite the labels of Job,the other labels are kept,unlike SetLabels() which replaces all labels
+func (obj *Job) MergeLabels(labels map[string]string) *Job {
+	obj.error(mergeLabels(obj.job, labels))
+	return obj
+}
+
+// MergeAnnotations add or overwrite the annotations of Job,the other annotations are kept
+func (obj *Job) MergeAnnotations(annotations map[string]string) *Job {
+	obj.error(mergeAnnotations(obj.job, annotations))
+	return obj
+}
+
+// MergePodLabels add or overwrite the labels of the Pod template,the other labels and the selector are kept,
+// the labels of the selector are not allowed to be changed,otherwise the Pods are not selected any more.
+func (obj *Job) MergePodLabels(labels map[string]string) *Job {
+	obj.error(mergePodLabels(&obj.job.Spec.Template, obj.job.Spec.Selector, labels))
+	return obj
+}
+
+// SetAppKubernetesLabels set the recommended app.kubernetes.io labels on Job and the Pod template,eg: name mysql,
+// version 5.7.21,component database and part-of wordpress,the empty values except name are skipped,the selector is not changed.
+func (obj *Job) SetAppKubernetesLabels(name, version, component, partOf string) *Job {
+	labels, err := appKubernetesLabels(name, version, component, partOf)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.error(mergeLabels(obj.job, labels))
+	obj.error(mergePodLabels(&obj.job.Spec.Template, obj.job.Spec.Selector, labels))
+	return obj
+}
+
+// SetContainer set Job container,the container of Job usually listens no port,so no containerPort is set,
+// name: container name,when many container this Field is necessary and cann't repeat
+// image: container image,this is necessary
+func (obj *Job) SetContainer(name, image string) *Job {
+	if !verifyString(image) {
+		obj.error(fieldError("SetContainer", "image is not allowed to be empty"))
+		return obj
+	}
+	addContainer(&obj.job.Spec.Template, v1.Container{Name: name, Image: image})
+	return obj
+}
+
+// SetResourceLimit set container of Job resource limit,eg:CPU and MEMORY
+func (obj *Job) SetResourceLimit(limits map[ResourceName]string) *Job {
+	obj.error(setResourceLimit(&obj.job.Spec.Template, limits))
+	return obj
+}
+
+// SetResourceRequst set container of Job resource request,only CPU and MEMORY
+func (obj *Job) SetResourceRequst(requests map[ResourceName]string) *Job {
+	obj.error(setResourceRequests(&obj.job.Spec.Template, requests))
+	return obj
+}
+
+// SetHostname set the hostname of the Pods of Job,the Pod name is used when it is not set
+func (obj *Job) SetHostname(hostname string) *Job {
+	obj.error(setHostname(&obj.job.Spec.Template, hostname))
+	return obj
+}
+
+// SetSubdomain set the subdomain of the Pods of Job,the Pods are resolved as <hostname>.<subdomain>
+// when the headless Service of the subdomain name selects them.
+func (obj *Job) SetSubdomain(subdomain string) *Job {
+	obj.error(setSubdomain(&obj.job.Spec.Template, subdomain))
+	return obj
+}
+
+// SetImagePullPolicy set the pull policy of all containers of Job,policy is Always,Never or IfNotPresent,
+// it is the same as ImagePullPolicy() but the policy is checked,default is IfNotPresent.
+func (obj *Job) SetImagePullPolicy(policy string) *Job {
+	if err := verifyPullPolicy("SetImagePullPolicy", policy); err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.error(addAnnotation(obj.job, ImagePullPolicyKey, policy))
+	return obj
+}
+
+// ImagePullPolicy Job pull image policy:Always,Never,IfNotPresent
+func (obj *Job) ImagePullPolicy(pullPolicy PullPolicy) *Job {
+	obj.error(addAnnotation(obj.job, ImagePullPolicyKey, string(pullPolicy)))
+	return obj
+}
+
+// SetRestartPolicy set restart policy of Pod,only OnFailure and Never are allowed by Job,default is OnFailure
+func (obj *Job) SetRestartPolicy(policy string) *Job {
+	restartPolicy := v1.RestartPolicy(policy)
+	if restartPolicy != v1.RestartPolicyOnFailure && restartPolicy != v1.RestartPolicyNever {
+		obj.error(fieldErrorf("SetRestartPolicy", "policy %s is not allowed,only OnFailure and Never", policy))
+		return obj
+	}
+	obj.job.Spec.Template.Spec.RestartPolicy = restartPolicy
+	return obj
+}
+
+// SetCompletions set how many Pods must be finished successfully,default is 1
+func (obj *Job) SetCompletions(completions int32) *Job {
+	if completions < 1 {
+		obj.error(fieldError("SetCompletions", "completions must be greater than 0"))
+		return obj
+	}
+	obj.job.Spec.Completions = &completions
+	return obj
+}
+
+// SetParallelism set how many Pods run in parallel at most,default is 1
+func (obj *Job) SetParallelism(parallelism int32) *Job {
+	if parallelism < 1 {
+		obj.error(fieldError("SetParallelism", "parallelism must be greater than 0"))
+		return obj
+	}
+	obj.job.Spec.Parallelism = &parallelism
+	return obj
+}
+
+// SetBackoffLimit set how many times the failed Pod is retried before Job is failed,default is 6
+func (obj *Job) SetBackoffLimit(limit int32) *Job {
+	if limit < 0 {
+		obj.error(fieldError("SetBackoffLimit", "limit is not allowed to be negative"))
+		return obj
+	}
+	obj.job.Spec.BackoffLimit = &limit
+	return obj
+}
+
+// SetTTLAfterFinished set Job is deleted with its Pods sec seconds after it is finished,
+// 0 deletes it immediately,it is not deleted when it is not set.
+func (obj *Job) SetTTLAfterFinished(sec int32) *Job {
+	if sec < 0 {
+		obj.error(fieldError("SetTTLAfterFinished", "sec is not allowed to be negative"))
+		return obj
+	}
+	obj.job.Spec.TTLSecondsAfterFinished = &sec
+	return obj
+}
+
+// SetActiveDeadlineSeconds set Job is failed and its Pods are killed when it runs longer than sec seconds
+func (obj *Job) SetActiveDeadlineSeconds(sec int64) *Job {
+	if sec < 1 {
+		obj.error(fieldError("SetActiveDeadlineSeconds", "sec must be greater than 0"))
+		return obj
+	}
+	obj.job.Spec.ActiveDeadlineSeconds = &sec
+	return obj
+}
+
+// Release release Job on Kubernetes
+func (obj *Job) Release() (*batchv1.Job, error) {
+	job, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return nil, err
+	}
+	return client.BatchV1().Jobs(job.GetNamespace()).Create(context.TODO(), job, metav1.CreateOptions{})
+}
+
+// Apply  it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist,the Pod template of Job is immutable,so only its metadata and parallelism can be updated.
+func (obj *Job) Apply() (*batchv1.Job, error) {
+	job, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return nil, err
+	}
+	_, err = client.BatchV1().Jobs(job.GetNamespace()).Get(context.TODO(), job.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.BatchV1().Jobs(job.GetNamespace()).Create(context.TODO(), job, metav1.CreateOptions{})
+	}
+	return client.BatchV1().Jobs(job.GetNamespace()).Update(context.TODO(), job, metav1.UpdateOptions{})
+}
+
+// Delete delete Job on Kubernetes by its name and namespace,
+// opts[0] sets the propagation policy and grace period,
+// eg: DeleteOptions{Propagation: PropagationBackground} deletes Job and its Pods.
+func (obj *Job) Delete(opts ...DeleteOptions) error {
+	if obj.err != nil {
+		return obj.err
+	}
+	options, err := newDeleteOptions("Job", obj.job.GetName(), opts)
+	if err != nil {
+		return err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return err
+	}
+	return client.BatchV1().Jobs(obj.job.GetNamespace()).Delete(context.TODO(), obj.job.GetName(), *options)
+}
+
+// String the current Job as yaml, the pending error is written on the top as comment.
+// it doesn't check and input default field like Finish(), so the Job may be incomplete.
+func (obj *Job) String() string { return dump("Job", obj.job, obj.err) }
+
+// Dump print the current Job and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *Job) Dump() *Job {
+	fmt.Println(obj.String())
+	return obj
+}
+
+func (obj *Job) error(err error) {
+	obj.err = appendError(obj.err, err)
+}
+
+// withPodSetters bind the pod-level setters to Job
+func (obj *Job) withPodSetters() *Job {
+	obj.PodSetters = PodSetters[*Job]{builder: obj}
+	return obj
+}
+
+// podTemplate get Pod template to change it
+func (obj *Job) podTemplate() *v1.PodTemplateSpec { return &obj.job.Spec.Template }
+
+// verify check Job necessary value, input the default field,all problems are returned at once.
+func (obj *Job) verify() error {
+	var errs error
+	if !verifyString(obj.job.GetName()) {
+		errs = fieldError("Job.Name", "is not allowed to be empty")
+	}
+	if errs = appendError(errs, verifyJobTemplate("Job", &obj.job.Spec, obj.job.Annotations)); errs != nil {
+		return errs
+	}
+	delete(obj.job.Annotations, ImagePullPolicyKey)
+	obj.job.Kind = "Job"
+	obj.job.APIVersion = "batch/v1"
+	// the registered verifiers check the finished object,so they are run after all checks and default fields
+	return obj.runVerifiers()
+}
+
+// verifyJobTemplate check the Pod template of Job and input the default restart policy and image pull policy,
+// it is shared by Job and the job template of CronJob,jobField is the path of the job spec in FieldError,eg: Job,
+// annotations is where ImagePullPolicy() records the policy.
+func verifyJobTemplate(jobField string, spec *batchv1.JobSpec, annotations map[string]string) error {
+	pod := &spec.Template.Spec
+	field := jobField + ".Spec.Template.Spec"
+	errs := verifyPodSpec(field, *pod)
+	for _, container := range pod.Containers {
+		if !verifyString(container.Image) {
+			errs = appendError(errs, fieldErrorf(field+".Containers", "container %q image is not allowed to be empty,you can call SetContainer() set it", container.Name))
+		}
+	}
+	if pod.RestartPolicy == v1.RestartPolicyAlways {
+		errs = appendError(errs, fieldError(field+".RestartPolicy", "Always is not allowed,only OnFailure and Never"))
+	}
+	if errs != nil {
+		return errs
+	}
+	if pod.RestartPolicy == "" {
+		pod.RestartPolicy = v1.RestartPolicyOnFailure
+	}
+	rewritePodImages(pod, func() *v1.PodSpec { return pod })
+	for index := range pod.Containers {
+		pod.Containers[index].ImagePullPolicy = containerPullPolicy(annotations, pod.Containers[index].ImagePullPolicy)
+	}
+	return nil
+}
+
+// runVerifiers run the verifiers registered by RegisterVerifier() in order when Job is valid
+func (obj *Job) runVerifiers() error {
+	if err := runRegisteredVerifiers(obj.job); err != nil {
+		return fmt.Errorf("Job %s %v", obj.job.GetName(), err)
+	}
+	return nil
+}
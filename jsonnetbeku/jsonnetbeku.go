@@ -0,0 +1,92 @@
+// Package jsonnetbeku creates beku builders from Jsonnet,
+// so the manifests written in Jsonnet are checked and applied by the same builders as the Go programs.
+//
+//	builders, err := jsonnetbeku.FromJsonnet(src, map[string]string{"env": "prod"})
+//	if err != nil {
+//		return err
+//	}
+//	bundle := beku.NewBundle().AddBuilders(builders...)
+//
+// it is a separate package,so the programs which don't use Jsonnet don't depend on it.
+package jsonnetbeku
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/google/go-jsonnet"
+	"github.com/yulibaozi/beku"
+)
+
+// FromJsonnet evaluate the Jsonnet source with the external variables and create the builder of every
+// Kubernetes resource object in it, extVars are read by std.extVar() in the source.
+// the result can be one object,a list of objects or an object whose fields are objects (nested objects are allowed),
+// the builders of the fields are in the order of the field names, as Jsonnet outputs them.
+// imports are relative to the current directory.
+// the supported kinds are listed by beku.Kinds().
+func FromJsonnet(src []byte, extVars map[string]string) ([]beku.Builder, error) {
+	vm := jsonnet.MakeVM()
+	for key, value := range extVars {
+		vm.ExtVar(key, value)
+	}
+	output, err := vm.EvaluateAnonymousSnippet("source.jsonnet", string(src))
+	if err != nil {
+		return nil, fmt.Errorf("FromJsonnet err,evaluate failed:%v", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader([]byte(output)))
+	// keep the numbers as they are,eg: large integers
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("FromJsonnet err,%v", err)
+	}
+	var builders []beku.Builder
+	if err := documents(value, "value", &builders); err != nil {
+		return nil, fmt.Errorf("FromJsonnet err,%v", err)
+	}
+	return builders, nil
+}
+
+// documents create the builders of the objects in the decoded json value,path is the path of value for errors
+func documents(value interface{}, path string, builders *[]beku.Builder) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		for index, item := range v {
+			if err := documents(item, fmt.Sprintf("%s[%d]", path, index), builders); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[string]interface{}:
+		if _, ok := v["kind"]; ok {
+			doc, err := json.Marshal(v)
+			if err != nil {
+				return fmt.Errorf("%s:%v", path, err)
+			}
+			builder, err := beku.NewBuilder(doc)
+			if err != nil {
+				return fmt.Errorf("%s:%v", path, err)
+			}
+			if builder != nil {
+				*builders = append(*builders, builder)
+			}
+			return nil
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if err := documents(v[key], path+"."+key, builders); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%s is %T,not Kubernetes resource object", path, value)
+}
@@ -0,0 +1,41 @@
+package jsonnetbeku_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	"github.com/yulibaozi/beku/jsonnetbeku"
+)
+
+func builderKinds(t *testing.T, builders []beku.Builder) string {
+	var kinds []string
+	for _, builder := range builders {
+		obj, err := builder.FinishObject()
+		if err != nil {
+			t.Fatal(err)
+		}
+		kinds = append(kinds, obj.GetObjectKind().GroupVersionKind().Kind)
+	}
+	return strings.Join(kinds, ",")
+}
+
+func Test_FromJsonnet(t *testing.T) {
+	src := `
+local name = std.extVar("name");
+[
+  { apiVersion: "v1", kind: "ConfigMap", metadata: { name: name }, data: { key: "value" } },
+  { apiVersion: "v1", kind: "Service", metadata: { name: name }, spec: { ports: [{ port: 80 }] } },
+]
+`
+	builders, err := jsonnetbeku.FromJsonnet([]byte(src), map[string]string{"name": "http"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kinds := builderKinds(t, builders); kinds != "ConfigMap,Service" {
+		t.Fatalf("expect ConfigMap,Service, got %s", kinds)
+	}
+	if _, err := jsonnetbeku.FromJsonnet([]byte(`std.extVar("missing")`), nil); err == nil {
+		t.Fatal("missing external variable should be error")
+	}
+}
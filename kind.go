@@ -0,0 +1,196 @@
+package beku
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	storv1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// kindEntry is the builder and the schema of a kind
+type kindEntry struct {
+	// obj is the resource object type for SchemaForKind(),nil when the kind has no Go type,eg: CustomResourceDefinition
+	obj interface{}
+	// newBuilder create the builder from the json document of the object
+	newBuilder func(jsonDoc []byte) Builder
+}
+
+// kindRegistry is the kinds which have builder,it is the only table of them,
+// StreamManifests(),NewBuilder() and SchemaForKind() use it,the other packages add their kinds by RegisterKind().
+// it is keyed by GroupVersionKind,because the same kind may be in many groups,eg: Service of knative is not v1 Service.
+var kindRegistry = struct {
+	sync.RWMutex
+	kinds map[schema.GroupVersionKind]kindEntry
+}{kinds: map[schema.GroupVersionKind]kindEntry{
+	{Version: "v1", Kind: "Namespace"}: {v1.Namespace{},
+		func(doc []byte) Builder { return NewNs().JSONNew(doc) }},
+	{Version: "v1", Kind: "ServiceAccount"}: {v1.ServiceAccount{},
+		func(doc []byte) Builder { return NewServiceAccount().JSONNew(doc) }},
+	{Version: "v1", Kind: "Service"}: {v1.Service{},
+		func(doc []byte) Builder { return NewSvc().JSONNew(doc) }},
+	{Version: "v1", Kind: "ConfigMap"}: {v1.ConfigMap{},
+		func(doc []byte) Builder { return NewCM().JSONNew(doc) }},
+	{Version: "v1", Kind: "Secret"}: {v1.Secret{},
+		func(doc []byte) Builder { return NewSecret().JSONNew(doc) }},
+	{Version: "v1", Kind: "PersistentVolume"}: {v1.PersistentVolume{},
+		func(doc []byte) Builder { return NewPV().JSONNew(doc) }},
+	{Version: "v1", Kind: "PersistentVolumeClaim"}: {v1.PersistentVolumeClaim{},
+		func(doc []byte) Builder { return NewPVC().JSONNew(doc) }},
+	{Group: "apps", Version: "v1", Kind: "Deployment"}: {appsv1.Deployment{},
+		func(doc []byte) Builder { return NewDeployment().JSONNew(doc) }},
+	{Group: "apps", Version: "v1", Kind: "StatefulSet"}: {appsv1.StatefulSet{},
+		func(doc []byte) Builder { return NewSts().JSONNew(doc) }},
+	{Group: "apps", Version: "v1", Kind: "DaemonSet"}: {appsv1.DaemonSet{},
+		func(doc []byte) Builder { return NewDS().JSONNew(doc) }},
+	{Group: "batch", Version: "v1", Kind: "Job"}: {batchv1.Job{},
+		func(doc []byte) Builder { return NewJob().JSONNew(doc) }},
+	{Group: "batch", Version: "v1", Kind: "CronJob"}: {batchv1.CronJob{},
+		func(doc []byte) Builder { return NewCronJob().JSONNew(doc) }},
+	{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}: {networkingv1.Ingress{},
+		func(doc []byte) Builder { return NewIngress().JSONNew(doc) }},
+	{Group: "autoscaling", Version: "v2", Kind: "HorizontalPodAutoscaler"}: {autoscalingv2.HorizontalPodAutoscaler{},
+		func(doc []byte) Builder { return NewHPA().JSONNew(doc) }},
+	{Group: "policy", Version: "v1", Kind: "PodDisruptionBudget"}: {policyv1.PodDisruptionBudget{},
+		func(doc []byte) Builder { return NewPDB().JSONNew(doc) }},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "Role"}: {rbacv1.Role{},
+		func(doc []byte) Builder { return NewRole().JSONNew(doc) }},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}: {rbacv1.ClusterRole{},
+		func(doc []byte) Builder { return NewClusterRole().JSONNew(doc) }},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "RoleBinding"}: {rbacv1.RoleBinding{},
+		func(doc []byte) Builder { return NewRoleBinding().JSONNew(doc) }},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRoleBinding"}: {rbacv1.ClusterRoleBinding{},
+		func(doc []byte) Builder { return NewClusterRoleBinding().JSONNew(doc) }},
+	{Group: "storage.k8s.io", Version: "v1", Kind: "StorageClass"}: {storv1.StorageClass{},
+		func(doc []byte) Builder { return NewStorageClass().JSONNew(doc) }},
+	{Group: "scheduling.k8s.io", Version: "v1", Kind: "PriorityClass"}: {schedulingv1.PriorityClass{},
+		func(doc []byte) Builder { return NewPriorityClass().JSONNew(doc) }},
+	{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}: {nil,
+		func(doc []byte) Builder { return NewCRD().JSONNew(doc) }},
+	{Group: "bitnami.com", Version: "v1alpha1", Kind: "SealedSecret"}: {SealedSecretObject{},
+		func(doc []byte) Builder { return NewSealedSecret().JSONNew(doc) }},
+	{Group: "external-secrets.io", Version: "v1beta1", Kind: "ExternalSecret"}: {ExternalSecretObject{},
+		func(doc []byte) Builder { return NewExternalSecret().JSONNew(doc) }},
+	{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"}: {RolloutObject{},
+		func(doc []byte) Builder { return NewRollout().JSONNew(doc) }},
+}}
+
+// RegisterKind register the builder of kind in apiVersion,so StreamManifests(),NewBuilder() and SchemaForKind() support it,
+// it is for the packages which have builders of the other kinds,eg: the builders of the custom resources of an operator.
+// obj is the resource object type of kind for SchemaForKind(),it can be nil when kind has no Go type,
+// newBuilder create the builder from the json document of the object,eg:
+//
+//	beku.RegisterKind("Certificate", "cert-manager.io/v1", certmanagerv1.Certificate{},
+//		func(doc []byte) beku.Builder { return NewCertificate().JSONNew(doc) })
+func RegisterKind(kind, apiVersion string, obj interface{}, newBuilder func(jsonDoc []byte) Builder) error {
+	if !verifyString(kind) || !verifyString(apiVersion) {
+		return errors.New("RegisterKind failed,kind and apiVersion are not allowed to be empty")
+	}
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return fmt.Errorf("RegisterKind failed,%v", err)
+	}
+	if newBuilder == nil {
+		return fmt.Errorf("RegisterKind failed,newBuilder of %s is not allowed to be nil", kind)
+	}
+	gvk := gv.WithKind(kind)
+	kindRegistry.Lock()
+	defer kindRegistry.Unlock()
+	if _, ok := kindRegistry.kinds[gvk]; ok {
+		return fmt.Errorf("RegisterKind failed,kind %s of %s is already registered", kind, apiVersion)
+	}
+	kindRegistry.kinds[gvk] = kindEntry{obj: obj, newBuilder: newBuilder}
+	return nil
+}
+
+// KindRegistered check whether the kind of group and version has builder,eg: it is built in beku or registered by RegisterKind()
+func KindRegistered(gvk schema.GroupVersionKind) bool {
+	_, err := lookupKind(gvk)
+	return err == nil
+}
+
+// Kinds get the kinds which have builder,in alphabetical order of kind,then group and version
+func Kinds() []schema.GroupVersionKind {
+	kindRegistry.RLock()
+	defer kindRegistry.RUnlock()
+	kinds := make([]schema.GroupVersionKind, 0, len(kindRegistry.kinds))
+	for gvk := range kindRegistry.kinds {
+		kinds = append(kinds, gvk)
+	}
+	sortKinds(kinds)
+	return kinds
+}
+
+// NewBuilder create the builder of the yaml or json document by its apiVersion and kind,
+// return nil builder when the document is empty, eg: only comments.
+// the supported kinds are listed by Kinds().
+func NewBuilder(doc []byte) (Builder, error) {
+	jsonDoc, err := yamlToJSON(doc)
+	if err != nil {
+		return nil, err
+	}
+	if string(jsonDoc) == "null" {
+		return nil, nil
+	}
+	var typeMeta metav1.TypeMeta
+	if err := json.Unmarshal(jsonDoc, &typeMeta); err != nil {
+		return nil, err
+	}
+	if typeMeta.Kind == "" {
+		return nil, errors.New("kind is not allowed to be empty")
+	}
+	if typeMeta.APIVersion == "" {
+		return nil, fmt.Errorf("apiVersion of kind %s is not allowed to be empty", typeMeta.Kind)
+	}
+	entry, err := lookupKind(schema.FromAPIVersionAndKind(typeMeta.APIVersion, typeMeta.Kind))
+	if err != nil {
+		return nil, err
+	}
+	return entry.newBuilder(jsonDoc), nil
+}
+
+// lookupKind get the entry of gvk,the error tells whether the kind is unknown or only its apiVersion is unsupported
+func lookupKind(gvk schema.GroupVersionKind) (kindEntry, error) {
+	kindRegistry.RLock()
+	defer kindRegistry.RUnlock()
+	if entry, ok := kindRegistry.kinds[gvk]; ok {
+		return entry, nil
+	}
+	var apiVersions []string
+	for registered := range kindRegistry.kinds {
+		if registered.Kind == gvk.Kind {
+			apiVersions = append(apiVersions, registered.GroupVersion().String())
+		}
+	}
+	if len(apiVersions) == 0 {
+		return kindEntry{}, fmt.Errorf("kind %s is not supported", gvk.Kind)
+	}
+	sort.Strings(apiVersions)
+	return kindEntry{}, fmt.Errorf("unsupported apiVersion %s of kind %s,supported apiVersions:%s",
+		gvk.GroupVersion().String(), gvk.Kind, strings.Join(apiVersions, ","))
+}
+
+func sortKinds(kinds []schema.GroupVersionKind) {
+	sort.Slice(kinds, func(i, j int) bool {
+		if kinds[i].Kind != kinds[j].Kind {
+			return kinds[i].Kind < kinds[j].Kind
+		}
+		if kinds[i].Group != kinds[j].Group {
+			return kinds[i].Group < kinds[j].Group
+		}
+		return kinds[i].Version < kinds[j].Version
+	})
+}
@@ -0,0 +1,187 @@
+package beku
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// KustomizeOptions is the options of WriteKustomize(),the empty fields are derived from the metadata of the objects,
+// set NoDerive to write the fields as they are.
+type KustomizeOptions struct {
+	// Namespace is namespace of kustomization.yaml,it is derived when all namespaced objects are in one namespace
+	Namespace string
+	// NamePrefix is namePrefix of kustomization.yaml,it is derived when all names start with the same "<prefix>-",
+	// the prefix is removed from the names in the resource files,kustomize adds it back.
+	NamePrefix string
+	// CommonLabels is commonLabels of kustomization.yaml,it is derived from the labels of all objects,
+	// the labels which are not in the selectors of all objects are not derived,
+	// because kustomize adds commonLabels into the selectors too and the selectors are immutable.
+	CommonLabels map[string]string
+	NoDerive     bool
+}
+
+// kustomization is the kustomization.yaml written by WriteKustomize()
+type kustomization struct {
+	APIVersion   string            `json:"apiVersion"`
+	Kind         string            `json:"kind"`
+	Namespace    string            `json:"namespace,omitempty"`
+	NamePrefix   string            `json:"namePrefix,omitempty"`
+	CommonLabels map[string]string `json:"commonLabels,omitempty"`
+	Resources    []string          `json:"resources"`
+}
+
+// WriteKustomize write the objects of Bundle as kustomize base into dir,one yaml file for each object,
+// eg: deployment-http.yaml,and kustomization.yaml which lists the files in order of Add(),
+// so the base can be consumed by GitOps pipeline,eg: `kustomize build dir`,dir is created when it doesn't exist.
+func (b *Bundle) WriteKustomize(dir string, opts KustomizeOptions) error {
+	if !verifyString(dir) {
+		return errors.New("WriteKustomize err,dir is not allowed to be empty")
+	}
+	if err := b.exportable(); err != nil {
+		return err
+	}
+	if len(b.objs) == 0 {
+		return errors.New("WriteKustomize err,Bundle is empty")
+	}
+	metas := make([]metav1.Object, 0, len(b.objs))
+	contents := make([]map[string]interface{}, 0, len(b.objs))
+	for _, obj := range b.objs {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return fmt.Errorf("WriteKustomize %s err:%v", objectKind(obj), err)
+		}
+		content, err := unstructuredContent(obj)
+		if err != nil {
+			return fmt.Errorf("WriteKustomize %s err:%v", objectName(obj), err)
+		}
+		metas = append(metas, accessor)
+		contents = append(contents, content)
+	}
+	if !opts.NoDerive {
+		if opts.Namespace == "" {
+			opts.Namespace = commonNamespace(metas)
+		}
+		if opts.NamePrefix == "" {
+			opts.NamePrefix = commonNamePrefix(metas)
+		}
+		if opts.CommonLabels == nil {
+			opts.CommonLabels = commonSelectorLabels(contents)
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("WriteKustomize err,%v", err)
+	}
+	k := kustomization{
+		APIVersion:   "kustomize.config.k8s.io/v1beta1",
+		Kind:         "Kustomization",
+		Namespace:    opts.Namespace,
+		NamePrefix:   opts.NamePrefix,
+		CommonLabels: opts.CommonLabels,
+		Resources:    make([]string, 0, len(contents)),
+	}
+	files := make(map[string]bool, len(contents))
+	for index, content := range contents {
+		u := &unstructured.Unstructured{Object: content}
+		u.SetName(strings.TrimPrefix(u.GetName(), opts.NamePrefix))
+		file := strings.ToLower(u.GetKind()) + "-" + u.GetName() + ".yaml"
+		if files[file] {
+			file = strings.ToLower(u.GetKind()) + "-" + u.GetNamespace() + "-" + u.GetName() + ".yaml"
+		}
+		if files[file] {
+			return fmt.Errorf("WriteKustomize err,%s is duplicated in Bundle", objectName(b.objs[index]))
+		}
+		files[file] = true
+		byts, err := ToYAML(u.Object)
+		if err != nil {
+			return fmt.Errorf("WriteKustomize %s err:%v", objectName(b.objs[index]), err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), byts, 0644); err != nil {
+			return fmt.Errorf("WriteKustomize err,%v", err)
+		}
+		k.Resources = append(k.Resources, file)
+	}
+	byts, err := ToYAML(k)
+	if err != nil {
+		return fmt.Errorf("WriteKustomize err,%v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "kustomization.yaml"), byts, 0644); err != nil {
+		return fmt.Errorf("WriteKustomize err,%v", err)
+	}
+	return nil
+}
+
+// commonNamespace get the namespace of all namespaced objects,it is empty when they are in different namespaces
+func commonNamespace(metas []metav1.Object) string {
+	namespace := ""
+	for _, m := range metas {
+		switch {
+		case m.GetNamespace() == "":
+			// the cluster scoped objects,eg: Namespace
+		case namespace == "":
+			namespace = m.GetNamespace()
+		case namespace != m.GetNamespace():
+			return ""
+		}
+	}
+	return namespace
+}
+
+// commonNamePrefix get the "<prefix>-" which all names start with,eg: prod- of prod-http and prod-config
+func commonNamePrefix(metas []metav1.Object) string {
+	first := metas[0].GetName()
+	index := strings.Index(first, "-")
+	if index <= 0 || index == len(first)-1 {
+		return ""
+	}
+	prefix := first[:index+1]
+	for _, m := range metas[1:] {
+		if !strings.HasPrefix(m.GetName(), prefix) || m.GetName() == prefix {
+			return ""
+		}
+	}
+	return prefix
+}
+
+// commonSelectorLabels get the labels which all objects have,and which all selectors have too,
+// eg: spec.selector.matchLabels of Deployment and spec.selector of Service.
+func commonSelectorLabels(contents []map[string]interface{}) map[string]string {
+	var common map[string]string
+	intersect := func(labels map[string]string) {
+		if common == nil {
+			common = make(map[string]string, len(labels))
+			for key, value := range labels {
+				common[key] = value
+			}
+			return
+		}
+		for key, value := range common {
+			if labels[key] != value {
+				delete(common, key)
+			}
+		}
+	}
+	for _, content := range contents {
+		labels, _, _ := unstructured.NestedStringMap(content, "metadata", "labels")
+		intersect(labels)
+		if _, found, _ := unstructured.NestedFieldNoCopy(content, "spec", "selector"); !found {
+			continue
+		}
+		// the selector without matchLabels,eg: only matchExpressions,accepts no common label
+		selector, found, _ := unstructured.NestedStringMap(content, "spec", "selector", "matchLabels")
+		if !found {
+			selector, _, _ = unstructured.NestedStringMap(content, "spec", "selector")
+		}
+		intersect(selector)
+	}
+	if len(common) == 0 {
+		return nil
+	}
+	return common
+}
@@ -0,0 +1,68 @@
+// Package kustomizebeku runs kustomize in-process and creates beku builders from its output,
+// so the kustomize overlays are checked and applied by the same builders as the Go programs.
+//
+//	bundle, err := kustomizebeku.FromKustomizeDir("deploy/overlays/prod")
+//	if err != nil {
+//		return err
+//	}
+//	if err := bundle.FinishAll(ctx, 4); err != nil {
+//		return err
+//	}
+//
+// it is a separate package,so the programs which don't use kustomize don't depend on it,
+// Bundle.WriteKustomize() of beku writes a kustomize base without it.
+package kustomizebeku
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/yulibaozi/beku"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+// FromKustomizeDir run kustomize build on the directory in-process, same as `kustomize build path`,
+// and add the builder of every resource object into Bundle in the order of kustomize output.
+// the kinds which have builder in beku use their builders,eg: Deployment,Namespace,
+// the others use CustomResource,so all objects can be finished,validated and applied by beku.
+// the builders are not finished,you should call FinishAll() of Bundle.
+func FromKustomizeDir(path string) (*beku.Bundle, error) {
+	if path == "" {
+		return nil, errors.New("FromKustomizeDir err,path is not allowed to be empty")
+	}
+	resMap, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(filesys.MakeFsOnDisk(), path)
+	if err != nil {
+		return nil, fmt.Errorf("FromKustomizeDir err,kustomize build %s failed:%v", path, err)
+	}
+	bundle := beku.NewBundle()
+	for index, res := range resMap.Resources() {
+		doc, err := res.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("FromKustomizeDir err,resource[%d] %s/%s:%v", index, res.GetKind(), res.GetName(), err)
+		}
+		builder, err := anyBuilder(doc)
+		if err != nil {
+			return nil, fmt.Errorf("FromKustomizeDir err,resource[%d] %s/%s:%v", index, res.GetKind(), res.GetName(), err)
+		}
+		bundle.AddBuilders(builder)
+	}
+	return bundle, nil
+}
+
+// anyBuilder create the builder of the json document by its kind,
+// CustomResource is used when the kind has no builder in beku.
+func anyBuilder(doc []byte) (beku.Builder, error) {
+	var typeMeta metav1.TypeMeta
+	if err := json.Unmarshal(doc, &typeMeta); err != nil {
+		return nil, err
+	}
+	gvk := schema.FromAPIVersionAndKind(typeMeta.APIVersion, typeMeta.Kind)
+	if beku.KindRegistered(gvk) {
+		return beku.NewBuilder(doc)
+	}
+	return beku.NewCustomResource(gvk).JSONNew(doc), nil
+}
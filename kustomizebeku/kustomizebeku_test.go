@@ -0,0 +1,82 @@
+package kustomizebeku_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	"github.com/yulibaozi/beku/kustomizebeku"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+func Test_FromKustomizeDir(t *testing.T) {
+	bundle, err := kustomizebeku.FromKustomizeDir("testdata/kustomize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bundle.FinishAll(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{"Namespace/roc", "ConfigMap/prod-http", "Certificate/prod-http"}
+	if bundle.Len() != len(expect) {
+		t.Fatalf("expect %d objects, got %d", len(expect), bundle.Len())
+	}
+	for _, obj := range bundle.Objects() {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := obj.GetObjectKind().GroupVersionKind().Kind + "/" + accessor.GetName()
+		found := false
+		for _, name := range expect {
+			found = found || name == got
+		}
+		if !found {
+			t.Errorf("unexpected object %s", got)
+		}
+		if got != "Namespace/roc" && accessor.GetNamespace() != "roc" {
+			t.Errorf("%s namespace should be roc, got %s", got, accessor.GetNamespace())
+		}
+	}
+	if _, err := kustomizebeku.FromKustomizeDir("testdata/missing"); err == nil {
+		t.Fatal("missing directory should be error")
+	}
+}
+
+func Test_BundleWriteKustomize(t *testing.T) {
+	bundle := beku.NewBundle().AddBuilders(
+		beku.NewCM().SetNamespaceAndName("roc", "prod-config").SetLabels(map[string]string{"team": "web", "tier": "config"}).
+			SetData(map[string]string{"MODE": "prod"}),
+		beku.NewDeployment().SetNamespaceAndName("roc", "prod-http").SetLabels(map[string]string{"team": "web"}).
+			SetPodLabels(map[string]string{"team": "web", "app": "http"}).SetContainer("http", "nginx", 80),
+	)
+	if err := bundle.FinishAll(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := bundle.WriteKustomize(dir, beku.KustomizeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	rebuilt, err := kustomizebeku.FromKustomizeDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rebuilt.FinishAll(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if rebuilt.Len() != 2 {
+		t.Fatalf("expect 2 objects, got %d", rebuilt.Len())
+	}
+	for _, obj := range rebuilt.Objects() {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if accessor.GetName() != "prod-config" && accessor.GetName() != "prod-http" {
+			t.Errorf("the name prefix should be added back by kustomize,got %s", accessor.GetName())
+		}
+		if accessor.GetNamespace() != "roc" || accessor.GetLabels()["team"] != "web" {
+			t.Errorf("%s should be in roc with team label,got %s %v", accessor.GetName(), accessor.GetNamespace(), accessor.GetLabels())
+		}
+	}
+}
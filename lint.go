@@ -0,0 +1,101 @@
+package beku
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Result is the result of Check() and Lint(),
+// Errors are fatal,Finish() fails with them,Warnings are the settings allowed by Kubernetes but risky,
+// so CI can fail on Errors but only annotate Warnings.
+type Result struct {
+	Errors   []string `json:"errors,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// OK there is no error,the warnings are allowed
+func (r Result) OK() bool { return len(r.Errors) == 0 }
+
+// Err get the errors as one error,nil when there is no error
+func (r Result) Err() error {
+	if r.OK() {
+		return nil
+	}
+	return errors.New(strings.Join(r.Errors, ";"))
+}
+
+// Check validate the builder like Validate() and lint its object like Lint() when it is valid,
+// the builder is finished when it is valid,so call it at the end of the chain.
+func Check(builder Builder) Result {
+	if fieldErrs := builder.Validate(); len(fieldErrs) > 0 {
+		errs := make([]string, 0, len(fieldErrs))
+		for _, fieldErr := range fieldErrs {
+			errs = append(errs, fieldErr.Error())
+		}
+		return Result{Errors: errs}
+	}
+	obj, err := builder.FinishObject()
+	if err != nil {
+		return Result{Errors: []string{err.Error()}}
+	}
+	return Lint(obj)
+}
+
+// Lint check the finished object for the settings which are allowed by Kubernetes but risky,
+// and return them as Warnings of Result,eg: privileged container,container without resource limits,Pod on spot nodes.
+// unlike Validate(),the warnings don't stop Finish(),so CI can show them without failing.
+func Lint(obj runtime.Object) Result {
+	var result Result
+	spec := podSpecOf(obj)
+	if spec == nil {
+		return result
+	}
+	containers := append(append([]v1.Container(nil), spec.InitContainers...), spec.Containers...)
+	for _, container := range containers {
+		if len(container.Resources.Limits) == 0 {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("container %q has no resource limits set", container.Name))
+		}
+		sc := container.SecurityContext
+		if sc == nil {
+			continue
+		}
+		if sc.Privileged != nil && *sc.Privileged {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("container %q is privileged,it has all capabilities of the host", container.Name))
+		}
+		if sc.AllowPrivilegeEscalation != nil && *sc.AllowPrivilegeEscalation {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("container %q allows privilege escalation", container.Name))
+		}
+	}
+	if runsOnSpot(spec) {
+		result.Warnings = append(result.Warnings, "Pod runs on spot nodes which can be reclaimed at any time,"+
+			"you should run more than 1 replica and add a PodDisruptionBudget,eg: maxUnavailable 1")
+	}
+	return result
+}
+
+// podSpecOf get the Pod spec of the workload,return nil when the object has no Pod spec
+func podSpecOf(obj runtime.Object) *v1.PodSpec {
+	switch o := obj.(type) {
+	case *v1.Pod:
+		return &o.Spec
+	case *appsv1.Deployment:
+		return &o.Spec.Template.Spec
+	case *appsv1.StatefulSet:
+		return &o.Spec.Template.Spec
+	case *appsv1.DaemonSet:
+		return &o.Spec.Template.Spec
+	case *batchv1.Job:
+		return &o.Spec.Template.Spec
+	case *batchv1.CronJob:
+		return &o.Spec.JobTemplate.Spec.Template.Spec
+	case *RolloutObject:
+		return &o.Spec.Template.Spec
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package beku
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// addLabel add or overwrite one label of the object,the other labels are kept,
+// unlike SetLabels() of builders which replaces all labels.
+func addLabel(object metav1.Object, key, value string) error {
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		return fieldErrorf("AddLabel", "key %q is not allowed:%s", key, strings.Join(errs, ","))
+	}
+	if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+		return fieldErrorf("AddLabel", "value %q of key %s is not allowed:%s", value, key, strings.Join(errs, ","))
+	}
+	labels := object.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string, 1)
+	}
+	labels[key] = value
+	object.SetLabels(labels)
+	return nil
+}
+
+// addAnnotation add or overwrite one annotation of the object,the other annotations are kept
+func addAnnotation(object metav1.Object, key, value string) error {
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		return fieldErrorf("AddAnnotation", "key %q is not allowed:%s", key, strings.Join(errs, ","))
+	}
+	annotations := object.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string, 1)
+	}
+	annotations[key] = value
+	object.SetAnnotations(annotations)
+	return nil
+}
+
+const (
+	// AppNameLabel is the recommended label of the application name,eg: mysql
+	AppNameLabel = "app.kubernetes.io/name"
+	// AppVersionLabel is the recommended label of the application version,eg: 5.7.21
+	AppVersionLabel = "app.kubernetes.io/version"
+	// AppComponentLabel is the recommended label of the component in the architecture,eg: database
+	AppComponentLabel = "app.kubernetes.io/component"
+	// AppPartOfLabel is the recommended label of the higher level application this one is part of,eg: wordpress
+	AppPartOfLabel = "app.kubernetes.io/part-of"
+	// AppManagedByLabel is the recommended label of the tool managing the application,eg: helm
+	AppManagedByLabel = "app.kubernetes.io/managed-by"
+
+	// PrometheusScrapeAnnotation is the annotation which makes the Pods or Service scraped by the Prometheus annotation-based discovery
+	PrometheusScrapeAnnotation = "prometheus.io/scrape"
+	// PrometheusPortAnnotation is the annotation of the port which Prometheus scrapes
+	PrometheusPortAnnotation = "prometheus.io/port"
+	// PrometheusPathAnnotation is the annotation of the metrics path which Prometheus scrapes,default /metrics
+	PrometheusPathAnnotation = "prometheus.io/path"
+)
+
+// mergeLabels add or overwrite the labels of the object,the other labels are kept
+func mergeLabels(object metav1.Object, labels map[string]string) error {
+	for _, key := range sortedKeys(labels) {
+		if err := addLabel(object, key, labels[key]); err != nil {
+			return fmt.Errorf("Merge%s", strings.TrimPrefix(err.Error(), "Add"))
+		}
+	}
+	return nil
+}
+
+// mergeAnnotations add or overwrite the annotations of the object,the other annotations are kept
+func mergeAnnotations(object metav1.Object, annotations map[string]string) error {
+	for _, key := range sortedKeys(annotations) {
+		if err := addAnnotation(object, key, annotations[key]); err != nil {
+			return fmt.Errorf("Merge%s", strings.TrimPrefix(err.Error(), "Add"))
+		}
+	}
+	return nil
+}
+
+// mergePodLabels add or overwrite the labels of the Pod template,the labels of selector can't be changed,
+// otherwise the Pods are not selected by the workload any more.
+func mergePodLabels(podTemp *v1.PodTemplateSpec, selector *metav1.LabelSelector, labels map[string]string) error {
+	if selector != nil {
+		for key, value := range labels {
+			if selected, ok := selector.MatchLabels[key]; ok && selected != value {
+				return fieldErrorf("MergePodLabels", "label %s=%s is not allowed,it is %s in the selector", key, value, selected)
+			}
+		}
+	}
+	return mergeLabels(podTemp, labels)
+}
+
+// appKubernetesLabels get the recommended app.kubernetes.io labels,the empty values are skipped except name
+func appKubernetesLabels(name, version, component, partOf string) (map[string]string, error) {
+	if !verifyString(name) {
+		return nil, fieldErrorf("SetAppKubernetesLabels", "name is not allowed to be empty")
+	}
+	labels := map[string]string{AppNameLabel: name}
+	for key, value := range map[string]string{AppVersionLabel: version, AppComponentLabel: component, AppPartOfLabel: partOf} {
+		if value != "" {
+			labels[key] = value
+		}
+	}
+	return labels, nil
+}
+
+// prometheusScrapeAnnotations get the annotations of the Prometheus annotation-based discovery,path default /metrics
+func prometheusScrapeAnnotations(port int32, path string) (map[string]string, error) {
+	if port <= 0 || port > 65535 {
+		return nil, fieldErrorf("SetPrometheusScrape", "port %d is not allowed,it must be 1-65535", port)
+	}
+	if path == "" {
+		path = "/metrics"
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fieldErrorf("SetPrometheusScrape", "path %q is not allowed,it must start with /", path)
+	}
+	return map[string]string{
+		PrometheusScrapeAnnotation: "true",
+		PrometheusPortAnnotation:   strconv.Itoa(int(port)),
+		PrometheusPathAnnotation:   path,
+	}, nil
+}
+
+// sortedKeys get the keys of m in order,so the first error of the maps is stable
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
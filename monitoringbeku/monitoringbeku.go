@@ -0,0 +1,44 @@
+// Package monitoringbeku provides the builders of ServiceMonitor and PodMonitor of Prometheus Operator,
+// they are registered into beku when the package is imported,
+// so StreamManifests(),NewBuilder() and SchemaForKind() of beku support them:
+//
+//	import _ "github.com/yulibaozi/beku/monitoringbeku"
+//
+// it is a separate package,so the programs which don't use Prometheus Operator don't depend on it.
+package monitoringbeku
+
+import (
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/yulibaozi/beku"
+)
+
+func init() {
+	for _, err := range []error{
+		beku.RegisterKind("ServiceMonitor", "monitoring.coreos.com/v1", monitoringv1.ServiceMonitor{},
+			func(doc []byte) beku.Builder { return NewServiceMonitor().JSONNew(doc) }),
+		beku.RegisterKind("PodMonitor", "monitoring.coreos.com/v1", monitoringv1.PodMonitor{},
+			func(doc []byte) beku.Builder { return NewPodMonitor().JSONNew(doc) }),
+	} {
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RelabelAction is the action of Prometheus relabeling
+type RelabelAction string
+
+const (
+	// RelabelReplace set targetLabel to replacement when regex matches the source labels,default
+	RelabelReplace RelabelAction = "replace"
+	// RelabelKeep drop the targets which source labels don't match regex
+	RelabelKeep RelabelAction = "keep"
+	// RelabelDrop drop the targets which source labels match regex
+	RelabelDrop RelabelAction = "drop"
+	// RelabelLabelMap copy the labels which names match regex to the names given by replacement
+	RelabelLabelMap RelabelAction = "labelmap"
+	// RelabelLabelDrop remove the labels which names match regex
+	RelabelLabelDrop RelabelAction = "labeldrop"
+	// RelabelLabelKeep remove the labels which names don't match regex
+	RelabelLabelKeep RelabelAction = "labelkeep"
+)
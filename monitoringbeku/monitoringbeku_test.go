@@ -0,0 +1,37 @@
+package monitoringbeku_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yulibaozi/beku"
+	"github.com/yulibaozi/beku/monitoringbeku"
+)
+
+func Test_ServiceMonitorSelectService(t *testing.T) {
+	svc := beku.NewSvc().SetNamespaceAndName("roc", "nginx").SetLabels(map[string]string{"app": "nginx"}).
+		SetSelector(map[string]string{"app": "nginx"})
+	sm, err := monitoringbeku.NewServiceMonitor().SetNamespaceAndName("monitoring", "nginx").SelectService(svc).
+		AddEndpoint("metrics", "/metrics", 30*time.Second).
+		AddRelabeling(monitoringbeku.RelabelReplace, []string{"__meta_kubernetes_pod_node_name"}, "", "node", "$1").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sm.Spec.Selector.MatchLabels["app"] != "nginx" {
+		t.Fatal("selector is not derived from Service labels")
+	}
+	if len(sm.Spec.NamespaceSelector.MatchNames) != 1 || sm.Spec.NamespaceSelector.MatchNames[0] != "roc" {
+		t.Fatalf("namespace selector should be the namespace of Service,got %v", sm.Spec.NamespaceSelector.MatchNames)
+	}
+	if sm.Spec.Endpoints[0].Interval != "30s" {
+		t.Fatalf("interval should be 30s,got %s", sm.Spec.Endpoints[0].Interval)
+	}
+}
+
+func Test_PodMonitorRelabeling(t *testing.T) {
+	_, err := monitoringbeku.NewPodMonitor().SetNamespaceAndName("roc", "nginx").SetSelector(map[string]string{"app": "nginx"}).
+		AddRelabeling(monitoringbeku.RelabelDrop, []string{"__name__"}, "go_.*", "", "").AddEndpoint("metrics", "", 0).Finish()
+	if err == nil {
+		t.Fatal("relabeling is added before endpoint, Finish should return error")
+	}
+}
@@ -0,0 +1,212 @@
+package monitoringbeku
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/yulibaozi/beku"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// PodMonitor include PodMonitor of Prometheus Operator and error,
+// Prometheus scrapes the Pods selected by it directly, no Service is needed.
+type PodMonitor struct {
+	pm  *monitoringv1.PodMonitor
+	err error
+}
+
+// NewPodMonitor create PodMonitor and chain function call begin with this function.
+func NewPodMonitor() *PodMonitor {
+	return &PodMonitor{pm: &monitoringv1.PodMonitor{}}
+}
+
+// Finish chain function call end with this function
+// return real PodMonitor(really PodMonitor is Prometheus Operator resource object PodMonitor and error)
+// In the function, it will check necessary parameters、input the default field。
+func (obj *PodMonitor) Finish() (pm *monitoringv1.PodMonitor, err error) {
+	err = beku.AppendError(obj.err, obj.verify())
+	return obj.pm, err
+}
+
+// Validate check PodMonitor necessary value like Finish(),and return the problems as FieldError,
+// but PodMonitor is not changed.
+func (obj *PodMonitor) Validate() []beku.FieldError {
+	cp := &PodMonitor{pm: obj.pm.DeepCopy(), err: obj.err}
+	return beku.FieldErrors(beku.AppendError(cp.err, cp.verify()))
+}
+
+// FinishObject same as Finish(), but return PodMonitor as runtime.Object,
+// so PodMonitor can be used as Builder, eg: add into Bundle.
+func (obj *PodMonitor) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// FinishUnchecked return PodMonitor without the checks and default fields of Finish(),only Kind and APIVersion are input,
+// it is for the partial PodMonitor which is patched onto the existing one,the error of the chain is still returned.
+func (obj *PodMonitor) FinishUnchecked() (*monitoringv1.PodMonitor, error) {
+	obj.pm.Kind, obj.pm.APIVersion = "PodMonitor", "monitoring.coreos.com/v1"
+	return obj.pm, obj.err
+}
+
+// JSONNew use json data create PodMonitor
+func (obj *PodMonitor) JSONNew(jsonbyts []byte) *PodMonitor {
+	obj.error(beku.DecodeJSON(jsonbyts, obj.pm))
+	return obj
+}
+
+// YAMLNew use yaml data create PodMonitor
+func (obj *PodMonitor) YAMLNew(yamlbyts []byte) *PodMonitor {
+	obj.error(beku.DecodeYAML(yamlbyts, obj.pm))
+	return obj
+}
+
+// Replace replace PodMonitor by Prometheus Operator resource object
+func (obj *PodMonitor) Replace(pm *monitoringv1.PodMonitor) *PodMonitor {
+	if pm != nil {
+		obj.pm = pm
+	}
+	return obj
+}
+
+// SetName set PodMonitor name
+func (obj *PodMonitor) SetName(name string) *PodMonitor {
+	obj.pm.SetName(name)
+	return obj
+}
+
+// SetNamespace set PodMonitor namespace
+func (obj *PodMonitor) SetNamespace(namespace string) *PodMonitor {
+	obj.pm.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set PodMonitor namespace and name
+func (obj *PodMonitor) SetNamespaceAndName(namespace, name string) *PodMonitor {
+	obj.pm.SetName(name)
+	obj.pm.SetNamespace(namespace)
+	return obj
+}
+
+// SetLabels set PodMonitor labels,
+// the labels must match podMonitorSelector of Prometheus,otherwise it is ignored.
+func (obj *PodMonitor) SetLabels(labels map[string]string) *PodMonitor {
+	obj.pm.SetLabels(labels)
+	return obj
+}
+
+// AddLabel add or overwrite one label of PodMonitor,the other labels are kept,
+// unlike SetLabels() which replaces all labels,eg: the labels loaded by YAMLNew().
+func (obj *PodMonitor) AddLabel(key, value string) *PodMonitor {
+	obj.error(beku.AddLabel(obj.pm, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of PodMonitor,the other annotations are kept
+func (obj *PodMonitor) AddAnnotation(key, value string) *PodMonitor {
+	obj.error(beku.AddAnnotation(obj.pm, key, value))
+	return obj
+}
+
+// SetSelector set the labels of the Pods which are scraped
+func (obj *PodMonitor) SetSelector(labels map[string]string) *PodMonitor {
+	if len(labels) <= 0 {
+		obj.error(errors.New("SetSelector err,label is not allowed to be empty"))
+		return obj
+	}
+	obj.pm.Spec.Selector.MatchLabels = labels
+	return obj
+}
+
+// SetNamespaceSelector set the namespaces of the Pods which are scraped,
+// default is the namespace of PodMonitor, no namespaces means all namespaces.
+func (obj *PodMonitor) SetNamespaceSelector(namespaces ...string) *PodMonitor {
+	obj.pm.Spec.NamespaceSelector = monitoringv1.NamespaceSelector{Any: len(namespaces) == 0, MatchNames: namespaces}
+	return obj
+}
+
+// SelectService derive the selector from Service: the Pods selected by the Service are scraped,
+// so call it after Service SetSelector() and SetNamespace().
+func (obj *PodMonitor) SelectService(svc *beku.Service) *PodMonitor {
+	if svc == nil {
+		obj.error(errors.New("SelectService err,Service is not allowed to be nil"))
+		return obj
+	}
+	service, _ := svc.FinishUnchecked()
+	if len(service.Spec.Selector) <= 0 {
+		obj.error(errors.New("SelectService err,Service selector is empty"))
+		return obj
+	}
+	obj.pm.Spec.Selector.MatchLabels = copyLabels(service.Spec.Selector)
+	if ns := service.GetNamespace(); ns != "" && ns != obj.pm.GetNamespace() {
+		obj.pm.Spec.NamespaceSelector = monitoringv1.NamespaceSelector{MatchNames: []string{ns}}
+	}
+	return obj
+}
+
+// AddEndpoint add the endpoint which is scraped,
+// port is the name of container port,path default /metrics,interval 0 means the global interval of Prometheus.
+func (obj *PodMonitor) AddEndpoint(port, path string, interval time.Duration) *PodMonitor {
+	if port == "" {
+		obj.error(errors.New("AddEndpoint err,port is not allowed to be empty"))
+		return obj
+	}
+	if interval < 0 {
+		obj.error(errors.New("AddEndpoint err,interval is not allowed to be negative"))
+		return obj
+	}
+	obj.pm.Spec.PodMetricsEndpoints = append(obj.pm.Spec.PodMetricsEndpoints, monitoringv1.PodMetricsEndpoint{
+		Port:     port,
+		Path:     path,
+		Interval: promDuration(interval),
+	})
+	return obj
+}
+
+// AddRelabeling add the relabeling on the last endpoint added by AddEndpoint(),it is applied before scraping.
+func (obj *PodMonitor) AddRelabeling(action RelabelAction, sourceLabels []string, regex, targetLabel, replacement string) *PodMonitor {
+	if len(obj.pm.Spec.PodMetricsEndpoints) <= 0 {
+		obj.error(errors.New("AddRelabeling err,no endpoint,you should call AddEndpoint() first"))
+		return obj
+	}
+	relabel, err := newRelabelConfig(action, sourceLabels, regex, targetLabel, replacement)
+	if err != nil {
+		obj.error(fmt.Errorf("AddRelabeling err,%v", err))
+		return obj
+	}
+	endpoint := &obj.pm.Spec.PodMetricsEndpoints[len(obj.pm.Spec.PodMetricsEndpoints)-1]
+	endpoint.RelabelConfigs = append(endpoint.RelabelConfigs, relabel)
+	return obj
+}
+
+// String the current PodMonitor as yaml, the pending error is written on the top as comment.
+func (obj *PodMonitor) String() string { return beku.Dump("PodMonitor", obj.pm, obj.err) }
+
+// Dump print the current PodMonitor and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *PodMonitor) Dump() *PodMonitor {
+	fmt.Println(obj.String())
+	return obj
+}
+
+func (obj *PodMonitor) error(err error) {
+	obj.err = beku.AppendError(obj.err, err)
+}
+
+func (obj *PodMonitor) verify() error {
+	var errs error
+	if obj.pm.GetName() == "" {
+		errs = beku.AppendError(errs, beku.FieldError{Field: "PodMonitor.Name", Message: "is not allowed to be empty"})
+	}
+	if !verifyLabelSelector(obj.pm.Spec.Selector) {
+		errs = beku.AppendError(errs, beku.FieldError{Field: "PodMonitor.Spec.Selector", Message: "is not allowed to be empty,you can call SetSelector() or SelectService()"})
+	}
+	if len(obj.pm.Spec.PodMetricsEndpoints) <= 0 {
+		errs = beku.AppendError(errs, beku.FieldError{Field: "PodMonitor.Spec.PodMetricsEndpoints", Message: "is not allowed to be empty,you can call AddEndpoint()"})
+	}
+	if errs != nil {
+		return errs
+	}
+	obj.pm.Kind = "PodMonitor"
+	obj.pm.APIVersion = "monitoring.coreos.com/v1"
+	return nil
+}
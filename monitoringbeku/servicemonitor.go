@@ -0,0 +1,265 @@
+package monitoringbeku
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/yulibaozi/beku"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ServiceMonitor include ServiceMonitor of Prometheus Operator and error,
+// Prometheus scrapes the endpoints of the Services selected by it.
+type ServiceMonitor struct {
+	sm  *monitoringv1.ServiceMonitor
+	err error
+}
+
+// NewServiceMonitor create ServiceMonitor and chain function call begin with this function.
+func NewServiceMonitor() *ServiceMonitor {
+	return &ServiceMonitor{sm: &monitoringv1.ServiceMonitor{}}
+}
+
+// Finish chain function call end with this function
+// return real ServiceMonitor(really ServiceMonitor is Prometheus Operator resource object ServiceMonitor and error)
+// In the function, it will check necessary parameters、input the default field。
+func (obj *ServiceMonitor) Finish() (sm *monitoringv1.ServiceMonitor, err error) {
+	err = beku.AppendError(obj.err, obj.verify())
+	return obj.sm, err
+}
+
+// Validate check ServiceMonitor necessary value like Finish(),and return the problems as FieldError,
+// but ServiceMonitor is not changed.
+func (obj *ServiceMonitor) Validate() []beku.FieldError {
+	cp := &ServiceMonitor{sm: obj.sm.DeepCopy(), err: obj.err}
+	return beku.FieldErrors(beku.AppendError(cp.err, cp.verify()))
+}
+
+// FinishObject same as Finish(), but return ServiceMonitor as runtime.Object,
+// so ServiceMonitor can be used as Builder, eg: add into Bundle.
+func (obj *ServiceMonitor) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// FinishUnchecked return ServiceMonitor without the checks and default fields of Finish(),only Kind and APIVersion are input,
+// it is for the partial ServiceMonitor which is patched onto the existing one,the error of the chain is still returned.
+func (obj *ServiceMonitor) FinishUnchecked() (*monitoringv1.ServiceMonitor, error) {
+	obj.sm.Kind, obj.sm.APIVersion = "ServiceMonitor", "monitoring.coreos.com/v1"
+	return obj.sm, obj.err
+}
+
+// JSONNew use json data create ServiceMonitor
+func (obj *ServiceMonitor) JSONNew(jsonbyts []byte) *ServiceMonitor {
+	obj.error(beku.DecodeJSON(jsonbyts, obj.sm))
+	return obj
+}
+
+// YAMLNew use yaml data create ServiceMonitor
+func (obj *ServiceMonitor) YAMLNew(yamlbyts []byte) *ServiceMonitor {
+	obj.error(beku.DecodeYAML(yamlbyts, obj.sm))
+	return obj
+}
+
+// Replace replace ServiceMonitor by Prometheus Operator resource object
+func (obj *ServiceMonitor) Replace(sm *monitoringv1.ServiceMonitor) *ServiceMonitor {
+	if sm != nil {
+		obj.sm = sm
+	}
+	return obj
+}
+
+// SetName set ServiceMonitor name
+func (obj *ServiceMonitor) SetName(name string) *ServiceMonitor {
+	obj.sm.SetName(name)
+	return obj
+}
+
+// SetNamespace set ServiceMonitor namespace
+func (obj *ServiceMonitor) SetNamespace(namespace string) *ServiceMonitor {
+	obj.sm.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set ServiceMonitor namespace and name
+func (obj *ServiceMonitor) SetNamespaceAndName(namespace, name string) *ServiceMonitor {
+	obj.sm.SetName(name)
+	obj.sm.SetNamespace(namespace)
+	return obj
+}
+
+// SetLabels set ServiceMonitor labels,
+// the labels must match serviceMonitorSelector of Prometheus,otherwise it is ignored.
+func (obj *ServiceMonitor) SetLabels(labels map[string]string) *ServiceMonitor {
+	obj.sm.SetLabels(labels)
+	return obj
+}
+
+// AddLabel add or overwrite one label of ServiceMonitor,the other labels are kept,
+// unlike SetLabels() which replaces all labels,eg: the labels loaded by YAMLNew().
+func (obj *ServiceMonitor) AddLabel(key, value string) *ServiceMonitor {
+	obj.error(beku.AddLabel(obj.sm, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of ServiceMonitor,the other annotations are kept
+func (obj *ServiceMonitor) AddAnnotation(key, value string) *ServiceMonitor {
+	obj.error(beku.AddAnnotation(obj.sm, key, value))
+	return obj
+}
+
+// SetSelector set the labels of the Services which are scraped
+func (obj *ServiceMonitor) SetSelector(labels map[string]string) *ServiceMonitor {
+	if len(labels) <= 0 {
+		obj.error(errors.New("SetSelector err,label is not allowed to be empty"))
+		return obj
+	}
+	obj.sm.Spec.Selector.MatchLabels = labels
+	return obj
+}
+
+// SetNamespaceSelector set the namespaces of the Services which are scraped,
+// default is the namespace of ServiceMonitor, no namespaces means all namespaces.
+func (obj *ServiceMonitor) SetNamespaceSelector(namespaces ...string) *ServiceMonitor {
+	obj.sm.Spec.NamespaceSelector = monitoringv1.NamespaceSelector{Any: len(namespaces) == 0, MatchNames: namespaces}
+	return obj
+}
+
+// SelectService derive the selector from Service: the Service labels are the selector
+// and the Service namespace is the namespace selector, so call it after Service SetLabels() and SetNamespace().
+func (obj *ServiceMonitor) SelectService(svc *beku.Service) *ServiceMonitor {
+	if svc == nil {
+		obj.error(errors.New("SelectService err,Service is not allowed to be nil"))
+		return obj
+	}
+	service, _ := svc.FinishUnchecked()
+	if len(service.GetLabels()) <= 0 {
+		obj.error(errors.New("SelectService err,Service labels is empty,ServiceMonitor selects Service by labels"))
+		return obj
+	}
+	obj.sm.Spec.Selector.MatchLabels = copyLabels(service.GetLabels())
+	if ns := service.GetNamespace(); ns != "" && ns != obj.sm.GetNamespace() {
+		obj.sm.Spec.NamespaceSelector = monitoringv1.NamespaceSelector{MatchNames: []string{ns}}
+	}
+	return obj
+}
+
+// AddEndpoint add the endpoint which is scraped,
+// port is the name of Service port,path default /metrics,interval 0 means the global interval of Prometheus.
+func (obj *ServiceMonitor) AddEndpoint(port, path string, interval time.Duration) *ServiceMonitor {
+	if port == "" {
+		obj.error(errors.New("AddEndpoint err,port is not allowed to be empty"))
+		return obj
+	}
+	if interval < 0 {
+		obj.error(errors.New("AddEndpoint err,interval is not allowed to be negative"))
+		return obj
+	}
+	obj.sm.Spec.Endpoints = append(obj.sm.Spec.Endpoints, monitoringv1.Endpoint{
+		Port:     port,
+		Path:     path,
+		Interval: promDuration(interval),
+	})
+	return obj
+}
+
+// AddRelabeling add the relabeling on the last endpoint added by AddEndpoint(),it is applied before scraping.
+func (obj *ServiceMonitor) AddRelabeling(action RelabelAction, sourceLabels []string, regex, targetLabel, replacement string) *ServiceMonitor {
+	if len(obj.sm.Spec.Endpoints) <= 0 {
+		obj.error(errors.New("AddRelabeling err,no endpoint,you should call AddEndpoint() first"))
+		return obj
+	}
+	relabel, err := newRelabelConfig(action, sourceLabels, regex, targetLabel, replacement)
+	if err != nil {
+		obj.error(fmt.Errorf("AddRelabeling err,%v", err))
+		return obj
+	}
+	endpoint := &obj.sm.Spec.Endpoints[len(obj.sm.Spec.Endpoints)-1]
+	endpoint.RelabelConfigs = append(endpoint.RelabelConfigs, relabel)
+	return obj
+}
+
+// String the current ServiceMonitor as yaml, the pending error is written on the top as comment.
+func (obj *ServiceMonitor) String() string { return beku.Dump("ServiceMonitor", obj.sm, obj.err) }
+
+// Dump print the current ServiceMonitor and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *ServiceMonitor) Dump() *ServiceMonitor {
+	fmt.Println(obj.String())
+	return obj
+}
+
+func (obj *ServiceMonitor) error(err error) {
+	obj.err = beku.AppendError(obj.err, err)
+}
+
+func (obj *ServiceMonitor) verify() error {
+	var errs error
+	if obj.sm.GetName() == "" {
+		errs = beku.AppendError(errs, beku.FieldError{Field: "ServiceMonitor.Name", Message: "is not allowed to be empty"})
+	}
+	if !verifyLabelSelector(obj.sm.Spec.Selector) {
+		errs = beku.AppendError(errs, beku.FieldError{Field: "ServiceMonitor.Spec.Selector", Message: "is not allowed to be empty,you can call SetSelector() or SelectService()"})
+	}
+	if len(obj.sm.Spec.Endpoints) <= 0 {
+		errs = beku.AppendError(errs, beku.FieldError{Field: "ServiceMonitor.Spec.Endpoints", Message: "is not allowed to be empty,you can call AddEndpoint()"})
+	}
+	if errs != nil {
+		return errs
+	}
+	obj.sm.Kind = "ServiceMonitor"
+	obj.sm.APIVersion = "monitoring.coreos.com/v1"
+	return nil
+}
+
+// promDuration format duration as Prometheus duration,eg: 30s,1500ms, it is empty when duration is 0
+func promDuration(duration time.Duration) monitoringv1.Duration {
+	switch {
+	case duration <= 0:
+		return ""
+	case duration%time.Second == 0:
+		return monitoringv1.Duration(fmt.Sprintf("%ds", duration/time.Second))
+	}
+	return monitoringv1.Duration(fmt.Sprintf("%dms", duration/time.Millisecond))
+}
+
+func newRelabelConfig(action RelabelAction, sourceLabels []string, regex, targetLabel, replacement string) (*monitoringv1.RelabelConfig, error) {
+	switch action {
+	case RelabelReplace:
+		if targetLabel == "" {
+			return nil, fmt.Errorf("targetLabel is not allowed to be empty when action is %s", action)
+		}
+	case RelabelKeep, RelabelDrop:
+		if len(sourceLabels) <= 0 {
+			return nil, fmt.Errorf("sourceLabels is not allowed to be empty when action is %s", action)
+		}
+	case RelabelLabelMap, RelabelLabelDrop, RelabelLabelKeep:
+		if regex == "" {
+			return nil, fmt.Errorf("regex is not allowed to be empty when action is %s", action)
+		}
+	default:
+		return nil, fmt.Errorf("action %s is not supported", action)
+	}
+	relabel := &monitoringv1.RelabelConfig{
+		Action:      string(action),
+		Regex:       regex,
+		TargetLabel: targetLabel,
+		Replacement: replacement,
+	}
+	for _, label := range sourceLabels {
+		relabel.SourceLabels = append(relabel.SourceLabels, monitoringv1.LabelName(label))
+	}
+	return relabel, nil
+}
+
+func verifyLabelSelector(selector metav1.LabelSelector) bool {
+	return len(selector.MatchLabels) > 0 || len(selector.MatchExpressions) > 0
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	cp := make(map[string]string, len(labels))
+	for key, value := range labels {
+		cp[key] = value
+	}
+	return cp
+}
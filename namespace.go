@@ -1,12 +1,29 @@
 package beku
 
 import (
-	"errors"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
 
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// the levels of Pod Security Standards
+const (
+	PodSecurityPrivileged = "privileged"
+	PodSecurityBaseline   = "baseline"
+	PodSecurityRestricted = "restricted"
+)
+
+// podSecurityLabelPrefix is the prefix of the labels of Pod Security Admission on Namespace
+const podSecurityLabelPrefix = "pod-security.kubernetes.io/"
+
+// podSecurityVersion is the version of Pod Security Standards,latest or Kubernetes minor version,eg: v1.28
+var podSecurityVersion = regexp.MustCompile(`^(latest|v1\.(0|[1-9][0-9]*))$`)
+
 // Namespace include Kubernets resource object Namespace and err
 type Namespace struct {
 	ns  *v1.Namespace
@@ -16,31 +33,135 @@ type Namespace struct {
 // NewNs create Namespace and Chain function call begin with this function.
 func NewNs() *Namespace { return &Namespace{ns: &v1.Namespace{}} }
 
+// NamespaceFrom create Namespace from the existing Namespace,eg: the one got by client,
+// so it can be changed by the chain function and released again,
+// ns is deep copied and the fields which are not changed by the chain function are kept.
+func NamespaceFrom(ns *v1.Namespace) *Namespace {
+	if ns == nil {
+		return NewNs()
+	}
+	return &Namespace{ns: ns.DeepCopy()}
+}
+
 // Finish Chain function call end with this function
 // return Kubernetes resource object Namespace and error.
 // In the function, it will check necessary parametersăinput the default field
 func (obj *Namespace) Finish() (*v1.Namespace, error) {
-	obj.verify()
+	err := appendError(obj.err, obj.verify())
+	return obj.ns, err
+}
+
+// Validate check Namespace necessary value like Finish(),and return the problems as FieldError,
+// but Namespace is not changed, no Kind,APIVersion or default field is input,
+// so it can be used to check a partially built Namespace.
+func (obj *Namespace) Validate() []FieldError {
+	cp := &Namespace{ns: obj.ns.DeepCopy(), err: obj.err}
+	return FieldErrors(appendError(cp.err, cp.verify()))
+}
+
+// FinishObject same as Finish(), but return Namespace as runtime.Object,
+// so Namespace can be used as Builder, eg: add into Bundle.
+func (obj *Namespace) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// FinishUnchecked return Namespace without the checks and default fields of Finish(),only Kind and APIVersion are input,
+// it is for the partial Namespace which is patched onto the existing one,the error of the chain is still returned.
+func (obj *Namespace) FinishUnchecked() (*v1.Namespace, error) {
+	obj.ns.Kind, obj.ns.APIVersion = "Namespace", "v1"
 	return obj.ns, obj.err
 }
 
+// JSONNew use json data create Namespace
+func (obj *Namespace) JSONNew(jsonbyts []byte) *Namespace {
+	obj.error(decodeJSON(jsonbyts, obj.ns))
+	return obj
+}
+
+// YAMLNew use yaml data create Namespace
+func (obj *Namespace) YAMLNew(yamlbyts []byte) *Namespace {
+	obj.error(decodeYAML(yamlbyts, obj.ns))
+	return obj
+}
+
+// Replace replace Namespace by Kubernetes resource object
+func (obj *Namespace) Replace(ns *v1.Namespace) *Namespace {
+	if ns != nil {
+		obj.ns = ns
+	}
+	return obj
+}
+
 // SetName set namespace name
 func (obj *Namespace) SetName(name string) *Namespace {
 	obj.ns.SetName(name)
 	return obj
 }
 
+// SetLabels set Namespace labels,the labels of Pod Security Admission set by SetPodSecurityLevel() are replaced too
+func (obj *Namespace) SetLabels(labels map[string]string) *Namespace {
+	obj.ns.SetLabels(labels)
+	return obj
+}
+
+// AddLabel add or overwrite one label of Namespace,the other labels are kept
+func (obj *Namespace) AddLabel(key, value string) *Namespace {
+	obj.error(addLabel(obj.ns, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of Namespace,the other annotations are kept
+func (obj *Namespace) AddAnnotation(key, value string) *Namespace {
+	obj.error(addAnnotation(obj.ns, key, value))
+	return obj
+}
+
+// SetPodSecurityLevel set the Pod Security Admission labels pod-security.kubernetes.io/* of Namespace,
+// enforce rejects the Pods which violate the level,audit records them in audit log and warn shows warnings to users.
+// the level is privileged,baseline or restricted,it can be followed by the version of the standard,eg: restricted:v1.28,
+// the version is latest by default,empty level keeps the label of the mode unchanged.
+func (obj *Namespace) SetPodSecurityLevel(enforce, audit, warn string) *Namespace {
+	modes := []struct{ mode, level string }{{"enforce", enforce}, {"audit", audit}, {"warn", warn}}
+	for _, m := range modes {
+		if m.level == "" {
+			continue
+		}
+		level, version := m.level, ""
+		if index := strings.Index(level, ":"); index >= 0 {
+			level, version = level[:index], level[index+1:]
+		}
+		if level != PodSecurityPrivileged && level != PodSecurityBaseline && level != PodSecurityRestricted {
+			obj.error(fieldErrorf("SetPodSecurityLevel", "%s level %q is not allowed,only %s,%s or %s", m.mode, level, PodSecurityPrivileged, PodSecurityBaseline, PodSecurityRestricted))
+			return obj
+		}
+		if version != "" && !podSecurityVersion.MatchString(version) {
+			obj.error(fieldErrorf("SetPodSecurityLevel", "%s version %q is not allowed,only latest or Kubernetes minor version,eg: v1.28", m.mode, version))
+			return obj
+		}
+		labels := obj.ns.GetLabels()
+		if labels == nil {
+			labels = make(map[string]string, 2)
+		}
+		labels[podSecurityLabelPrefix+m.mode] = level
+		if version != "" {
+			labels[podSecurityLabelPrefix+m.mode+"-version"] = version
+		} else {
+			delete(labels, podSecurityLabelPrefix+m.mode+"-version")
+		}
+		obj.ns.SetLabels(labels)
+	}
+	return obj
+}
+
 // Release release Namespace on Kubernetes
 func (obj *Namespace) Release() (*v1.Namespace, error) {
 	ns, err := obj.Finish()
 	if err != nil {
 		return nil, err
 	}
-	client, err := GetKubeClient()
+	client, err := getKubeInterface()
 	if err != nil {
 		return nil, err
 	}
-	return client.CoreV1().Namespaces().Create(ns)
+	return client.CoreV1().Namespaces().Create(context.TODO(), ns, metav1.CreateOptions{})
 }
 
 // Apply  it will be updated when this resource object exists in K8s,
@@ -50,22 +171,54 @@ func (obj *Namespace) Apply() (*v1.Namespace, error) {
 	if err != nil {
 		return nil, err
 	}
-	client, err := GetKubeClient()
+	client, err := getKubeInterface()
 	if err != nil {
 		return nil, err
 	}
-	_, err = client.CoreV1().Namespaces().Get(ns.GetName(), metav1.GetOptions{})
+	_, err = client.CoreV1().Namespaces().Get(context.TODO(), ns.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.CoreV1().Namespaces().Create(context.TODO(), ns, metav1.CreateOptions{})
+	}
+	return client.CoreV1().Namespaces().Update(context.TODO(), ns, metav1.UpdateOptions{})
+}
+
+// Delete delete Namespace on Kubernetes by its name,
+// opts[0] sets the propagation policy and grace period.
+func (obj *Namespace) Delete(opts ...DeleteOptions) error {
+	if obj.err != nil {
+		return obj.err
+	}
+	options, err := newDeleteOptions("Namespace", obj.ns.GetName(), opts)
+	if err != nil {
+		return err
+	}
+	client, err := getKubeInterface()
 	if err != nil {
-		return client.CoreV1().Namespaces().Create(ns)
+		return err
 	}
-	return client.CoreV1().Namespaces().Update(ns)
+	return client.CoreV1().Namespaces().Delete(context.TODO(), obj.ns.GetName(), *options)
+}
+
+// String the current Namespace as yaml, the pending error is written on the top as comment.
+// it doesn't check and input default field like Finish(), so the Namespace may be incomplete.
+func (obj *Namespace) String() string { return dump("Namespace", obj.ns, obj.err) }
+
+// Dump print the current Namespace and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *Namespace) Dump() *Namespace {
+	fmt.Println(obj.String())
+	return obj
+}
+
+func (obj *Namespace) error(err error) {
+	obj.err = appendError(obj.err, err)
 }
 
-func (obj *Namespace) verify() {
+func (obj *Namespace) verify() error {
 	if obj.ns.GetName() == "" {
-		obj.err = errors.New("Namespace.Name is not allowed to be empty")
-		return
+		return fieldError("Namespace.Name", "is not allowed to be empty")
 	}
 	obj.ns.APIVersion = "v1"
 	obj.ns.Kind = "Namespace"
+	return nil
 }
@@ -0,0 +1,74 @@
+package beku
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// ObserveFinish is Finish() of one builder in Bundle FinishAll()
+	ObserveFinish = "finish"
+	// ObserveApply is the apply of one object in Bundle Apply() and ApplyAtomic()
+	ObserveApply = "apply"
+	// ObserveWait is the wait of one object is ready in Bundle Apply() and ApplyAtomic()
+	ObserveWait = "wait"
+)
+
+// Observation is one operation of beku,Name and Namespace are empty for ObserveFinish,
+// because the object is not finished when it begins.
+type Observation struct {
+	Operation string
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// Observer observe the operations of beku,eg: record the tracing spans and metrics,
+// the OpenTelemetry Observer is in package github.com/yulibaozi/beku/otelbeku.
+type Observer interface {
+	// Start is called when the operation begins,the returned context is passed to the operation,
+	// and the returned function is called with the error of the operation when it ends.
+	Start(ctx context.Context, observation Observation) (context.Context, func(err error))
+}
+
+var observer = struct {
+	sync.RWMutex
+	o Observer
+}{}
+
+// RegisterObserver register the Observer of the operations of Bundle,nil removes it.
+// there is no Observer by default, so beku doesn't depend on any tracing or metrics library.
+func RegisterObserver(o Observer) {
+	observer.Lock()
+	defer observer.Unlock()
+	observer.o = o
+}
+
+// observe start the observation by the registered Observer,it does nothing when there is no Observer
+func observe(ctx context.Context, observation Observation) (context.Context, func(err error)) {
+	observer.RLock()
+	o := observer.o
+	observer.RUnlock()
+	if o == nil {
+		return ctx, func(error) {}
+	}
+	return o.Start(ctx, observation)
+}
+
+// observeObject start the observation of the operation of the object
+func observeObject(ctx context.Context, operation string, obj runtime.Object) (context.Context, func(err error)) {
+	observation := Observation{Operation: operation, Kind: objectKind(obj)}
+	if accessor, err := meta.Accessor(obj); err == nil {
+		observation.Namespace, observation.Name = accessor.GetNamespace(), accessor.GetName()
+	}
+	return observe(ctx, observation)
+}
+
+// observeBuilder start the observation of Finish() of the builder,Kind is the type name of the builder,eg: Deployment
+func observeBuilder(ctx context.Context, builder Builder) (context.Context, func(err error)) {
+	return observe(ctx, Observation{Operation: ObserveFinish, Kind: reflect.Indirect(reflect.ValueOf(builder)).Type().Name()})
+}
@@ -0,0 +1,156 @@
+// Package openshiftbeku converts between the Deployment of beku and OpenShift DeploymentConfig,
+// so the same builders are used for the clusters which still run DeploymentConfig:
+//
+//	dc, err := openshiftbeku.ToDeploymentConfig(beku.NewDeployment().SetName("http")...)
+//
+// it is a separate package,so the programs which don't use OpenShift don't depend on it.
+package openshiftbeku
+
+import (
+	"errors"
+	"fmt"
+
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	"github.com/yulibaozi/beku"
+	"k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// ToDeploymentConfig finish Deployment and convert it into OpenShift DeploymentConfig,
+// the DeploymentConfig has a ConfigChange trigger, so it is rolled out on template change like Deployment.
+// the metadata written by Kubernetes,eg: resourceVersion and uid,is not copied,so the DeploymentConfig can be created.
+// Deployment selector with match expressions can't be converted,because DeploymentConfig selector is a label map.
+func ToDeploymentConfig(builder *beku.Deployment) (*ocappsv1.DeploymentConfig, error) {
+	if builder == nil {
+		return nil, errors.New("ToDeploymentConfig err,Deployment is not allowed to be nil")
+	}
+	dp, err := builder.Finish()
+	if err != nil {
+		return nil, err
+	}
+	if dp.Spec.Selector != nil && len(dp.Spec.Selector.MatchExpressions) > 0 {
+		return nil, errors.New("ToDeploymentConfig err,DeploymentConfig selector doesn't support match expressions")
+	}
+	dc := &ocappsv1.DeploymentConfig{
+		TypeMeta:   metav1.TypeMeta{Kind: "DeploymentConfig", APIVersion: ocappsv1.SchemeGroupVersion.String()},
+		ObjectMeta: userMeta(dp.ObjectMeta),
+		Spec: ocappsv1.DeploymentConfigSpec{
+			Replicas:             1,
+			MinReadySeconds:      dp.Spec.MinReadySeconds,
+			RevisionHistoryLimit: copyInt32(dp.Spec.RevisionHistoryLimit),
+			Paused:               dp.Spec.Paused,
+			Template:             dp.Spec.Template.DeepCopy(),
+			Triggers:             ocappsv1.DeploymentTriggerPolicies{{Type: ocappsv1.DeploymentTriggerOnConfigChange}},
+		},
+	}
+	if dp.Spec.Replicas != nil {
+		dc.Spec.Replicas = *dp.Spec.Replicas
+	}
+	if dp.Spec.Selector != nil {
+		dc.Spec.Selector = copyLabels(dp.Spec.Selector.MatchLabels)
+	}
+	switch dp.Spec.Strategy.Type {
+	case v1.RecreateDeploymentStrategyType:
+		dc.Spec.Strategy.Type = ocappsv1.DeploymentStrategyTypeRecreate
+	default:
+		dc.Spec.Strategy.Type = ocappsv1.DeploymentStrategyTypeRolling
+		params := &ocappsv1.RollingDeploymentStrategyParams{}
+		if rolling := dp.Spec.Strategy.RollingUpdate; rolling != nil {
+			params.MaxSurge, params.MaxUnavailable = copyIntOrString(rolling.MaxSurge), copyIntOrString(rolling.MaxUnavailable)
+		}
+		// the rollout of DeploymentConfig is failed after TimeoutSeconds,it is the nearest to progress deadline
+		if dp.Spec.ProgressDeadlineSeconds != nil {
+			timeout := int64(*dp.Spec.ProgressDeadlineSeconds)
+			params.TimeoutSeconds = &timeout
+		}
+		dc.Spec.Strategy.RollingParams = params
+	}
+	return dc, nil
+}
+
+// FromDeploymentConfig create Deployment from OpenShift DeploymentConfig,so it can be changed by the chain function,
+// ImageChange triggers are dropped,because Deployment has no triggers, update the image by SetContainer() instead.
+// the metadata written by Kubernetes,eg: resourceVersion and uid,is not copied,dc is not changed.
+// DeploymentConfig with Custom strategy or Test is not supported.
+func FromDeploymentConfig(dc *ocappsv1.DeploymentConfig) (*beku.Deployment, error) {
+	if dc == nil {
+		return nil, errors.New("FromDeploymentConfig err,DeploymentConfig is not allowed to be nil")
+	}
+	if dc.Spec.Template == nil {
+		return nil, errors.New("FromDeploymentConfig err,DeploymentConfig template is not allowed to be empty")
+	}
+	if dc.Spec.Test {
+		return nil, errors.New("FromDeploymentConfig err,DeploymentConfig for test is not supported")
+	}
+	replicas := dc.Spec.Replicas
+	dp := &v1.Deployment{
+		ObjectMeta: userMeta(dc.ObjectMeta),
+		Spec: v1.DeploymentSpec{
+			Replicas:             &replicas,
+			MinReadySeconds:      dc.Spec.MinReadySeconds,
+			RevisionHistoryLimit: copyInt32(dc.Spec.RevisionHistoryLimit),
+			Paused:               dc.Spec.Paused,
+			Template:             *dc.Spec.Template.DeepCopy(),
+		},
+	}
+	if len(dc.Spec.Selector) > 0 {
+		dp.Spec.Selector = &metav1.LabelSelector{MatchLabels: copyLabels(dc.Spec.Selector)}
+	}
+	switch dc.Spec.Strategy.Type {
+	case ocappsv1.DeploymentStrategyTypeRecreate:
+		dp.Spec.Strategy.Type = v1.RecreateDeploymentStrategyType
+	case ocappsv1.DeploymentStrategyTypeRolling, "":
+		dp.Spec.Strategy.Type = v1.RollingUpdateDeploymentStrategyType
+		if params := dc.Spec.Strategy.RollingParams; params != nil {
+			dp.Spec.Strategy.RollingUpdate = &v1.RollingUpdateDeployment{
+				MaxSurge: copyIntOrString(params.MaxSurge), MaxUnavailable: copyIntOrString(params.MaxUnavailable),
+			}
+			if params.TimeoutSeconds != nil {
+				deadline := int32(*params.TimeoutSeconds)
+				dp.Spec.ProgressDeadlineSeconds = &deadline
+			}
+		}
+	default:
+		return nil, fmt.Errorf("FromDeploymentConfig err,strategy %s is not supported", dc.Spec.Strategy.Type)
+	}
+	return beku.NewDeployment().Replace(dp), nil
+}
+
+// userMeta deep copy the metadata without the fields written by Kubernetes,
+// eg: resourceVersion,uid and managedFields,they belong to the source object and are rejected on creation.
+func userMeta(meta metav1.ObjectMeta) metav1.ObjectMeta {
+	cp := *meta.DeepCopy()
+	cp.ResourceVersion, cp.UID, cp.SelfLink, cp.Generation = "", "", "", 0
+	cp.CreationTimestamp = metav1.Time{}
+	cp.DeletionTimestamp, cp.DeletionGracePeriodSeconds = nil, nil
+	cp.ManagedFields = nil
+	return cp
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+	cp := make(map[string]string, len(labels))
+	for key, value := range labels {
+		cp[key] = value
+	}
+	return cp
+}
+
+func copyInt32(i *int32) *int32 {
+	if i == nil {
+		return nil
+	}
+	cp := *i
+	return &cp
+}
+
+func copyIntOrString(value *intstr.IntOrString) *intstr.IntOrString {
+	if value == nil {
+		return nil
+	}
+	cp := *value
+	return &cp
+}
@@ -0,0 +1,151 @@
+package openshiftbeku_test
+
+import (
+	"testing"
+
+	ocappsv1 "github.com/openshift/api/apps/v1"
+	"github.com/yulibaozi/beku"
+	"github.com/yulibaozi/beku/openshiftbeku"
+	"k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newDeployment() *beku.Deployment {
+	return beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx:1.15", 80).SetReplicas(3).SetStrategyRollingUpdate("25%", "1")
+}
+
+func Test_DeploymentConfigRoundTrip(t *testing.T) {
+	dc, err := openshiftbeku.ToDeploymentConfig(newDeployment())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dc.Kind != "DeploymentConfig" || dc.Spec.Replicas != 3 || dc.Spec.Selector["app"] != "http" {
+		t.Fatalf("unexpected DeploymentConfig:%+v", dc)
+	}
+	if dc.Spec.Strategy.Type != ocappsv1.DeploymentStrategyTypeRolling || dc.Spec.Strategy.RollingParams.MaxSurge.String() != "25%" {
+		t.Fatalf("the rolling strategy is not converted:%+v", dc.Spec.Strategy)
+	}
+	builder, err := openshiftbeku.FromDeploymentConfig(dc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dp, err := builder.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *dp.Spec.Replicas != 3 || dp.Spec.Selector.MatchLabels["app"] != "http" || dp.Spec.Strategy.RollingUpdate.MaxUnavailable.IntValue() != 1 {
+		t.Fatalf("unexpected Deployment:%+v", dp.Spec)
+	}
+	if dp.Spec.Template.Spec.Containers[0].Image != "nginx:1.15" {
+		t.Fatalf("the template is not converted:%v", dp.Spec.Template.Spec.Containers)
+	}
+}
+
+func Test_DeploymentConfigServerMetadata(t *testing.T) {
+	dp, err := newDeployment().Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dp.ResourceVersion, dp.UID, dp.Generation = "42", types.UID("uid"), 7
+	dc, err := openshiftbeku.ToDeploymentConfig(beku.DeploymentFrom(dp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dc.ResourceVersion != "" || dc.UID != "" || dc.Generation != 0 {
+		t.Fatalf("the metadata written by Kubernetes should not be copied:%+v", dc.ObjectMeta)
+	}
+	dc.Spec.Selector["app"] = "changed"
+	if dp.Spec.Selector.MatchLabels["app"] != "http" {
+		t.Fatal("the selector of DeploymentConfig should not be shared with Deployment")
+	}
+	dc.Spec.Selector["app"] = "http"
+	dc.ResourceVersion, dc.UID = "43", types.UID("dc-uid")
+	builder, err := openshiftbeku.FromDeploymentConfig(dc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := builder.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back.ResourceVersion != "" || back.UID != "" {
+		t.Fatalf("the metadata written by Kubernetes should not be copied:%+v", back.ObjectMeta)
+	}
+	back.Spec.Selector.MatchLabels["app"] = "changed"
+	if dc.Spec.Selector["app"] != "http" {
+		t.Fatal("the selector of Deployment should not be shared with DeploymentConfig")
+	}
+}
+
+func Test_DeploymentConfigUnsupported(t *testing.T) {
+	if _, err := openshiftbeku.ToDeploymentConfig(newDeployment().MatchIn("tier", "web")); err == nil {
+		t.Fatal("selector with match expressions should be error")
+	}
+	if _, err := openshiftbeku.FromDeploymentConfig(&ocappsv1.DeploymentConfig{}); err == nil {
+		t.Fatal("DeploymentConfig without template should be error")
+	}
+	dc, err := openshiftbeku.ToDeploymentConfig(newDeployment().SetStrategyRecreate())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dc.Spec.Strategy.Type = ocappsv1.DeploymentStrategyTypeCustom
+	if _, err := openshiftbeku.FromDeploymentConfig(dc); err == nil {
+		t.Fatal("custom strategy should be error")
+	}
+	dc.Spec.Strategy.Type = ocappsv1.DeploymentStrategyTypeRecreate
+	builder, err := openshiftbeku.FromDeploymentConfig(dc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dp, err := builder.Finish(); err != nil || dp.Spec.Strategy.Type != v1.RecreateDeploymentStrategyType {
+		t.Fatalf("expect Recreate strategy,got %v %v", dp, err)
+	}
+}
+
+func Test_DeploymentConfigTriggers(t *testing.T) {
+	dp, err := newDeployment().SetMinReadySeconds(10).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := int32(300)
+	dp.Spec.ProgressDeadlineSeconds = &deadline
+	dc, err := openshiftbeku.ToDeploymentConfig(beku.DeploymentFrom(dp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dc.Spec.Triggers) != 1 || dc.Spec.Triggers[0].Type != ocappsv1.DeploymentTriggerOnConfigChange {
+		t.Fatalf("expect only the ConfigChange trigger,got %+v", dc.Spec.Triggers)
+	}
+	if timeout := dc.Spec.Strategy.RollingParams.TimeoutSeconds; timeout == nil || *timeout != 300 || dc.Spec.MinReadySeconds != 10 {
+		t.Fatalf("the progress deadline should be the rolling timeout,got %+v", dc.Spec)
+	}
+	dc.Spec.Triggers = append(dc.Spec.Triggers, ocappsv1.DeploymentTriggerPolicy{
+		Type: ocappsv1.DeploymentTriggerOnImageChange,
+		ImageChangeParams: &ocappsv1.DeploymentTriggerImageChangeParams{
+			Automatic: true, ContainerNames: []string{"http"}, LastTriggeredImage: "nginx:1.16",
+		},
+	})
+	builder, err := openshiftbeku.FromDeploymentConfig(dc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := builder.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the ImageChange trigger is dropped,the image of the template is kept
+	if back.Spec.Template.Spec.Containers[0].Image != "nginx:1.15" || *back.Spec.ProgressDeadlineSeconds != 300 || back.Spec.MinReadySeconds != 10 {
+		t.Fatalf("unexpected Deployment:%+v", back.Spec)
+	}
+	dc.Spec.Test = true
+	if _, err := openshiftbeku.FromDeploymentConfig(dc); err == nil {
+		t.Fatal("DeploymentConfig for test should be error")
+	}
+	if _, err := openshiftbeku.ToDeploymentConfig(nil); err == nil {
+		t.Fatal("nil Deployment should be error")
+	}
+	if _, err := openshiftbeku.FromDeploymentConfig(nil); err == nil {
+		t.Fatal("nil DeploymentConfig should be error")
+	}
+}
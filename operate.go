@@ -0,0 +1,150 @@
+package beku
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// RestartedAtAnnotation is the Pod template annotation written by `kubectl rollout restart`
+const RestartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+// Scale set the replicas of the workload on Kubernetes,like `kubectl scale`,
+// obj is the workload which has spec.replicas,eg: Deployment,StatefulSet,ReplicaSet or the custom resource,
+// only the kind,namespace and name of obj are used.
+func (c *Client) Scale(ctx context.Context, obj runtime.Object, replicas int32) (*unstructured.Unstructured, error) {
+	if replicas < 0 {
+		return nil, fmt.Errorf("Scale err,replicas %d is not allowed to be negative", replicas)
+	}
+	u, resource, err := c.resource(obj)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := json.Marshal(map[string]interface{}{"spec": map[string]interface{}{"replicas": replicas}})
+	if err != nil {
+		return nil, err
+	}
+	return resource.Patch(ctx, u.GetName(), types.MergePatchType, patch, metav1.PatchOptions{FieldManager: c.fieldManager})
+}
+
+// RestartRollout restart the Pods of Deployment,StatefulSet or DaemonSet by rolling update,like `kubectl rollout restart`,
+// the Pod template annotation RestartedAtAnnotation is set to now,only the kind,namespace and name of obj are used.
+func (c *Client) RestartRollout(ctx context.Context, obj runtime.Object) (*unstructured.Unstructured, error) {
+	switch kind := objectKind(obj); kind {
+	case "Deployment", "StatefulSet", "DaemonSet":
+	default:
+		return nil, fmt.Errorf("RestartRollout err,%s is not allowed,only Deployment,StatefulSet and DaemonSet", kind)
+	}
+	u, resource, err := c.resource(obj)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"annotations": map[string]string{RestartedAtAnnotation: time.Now().Format(time.RFC3339)},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resource.Patch(ctx, u.GetName(), types.MergePatchType, patch, metav1.PatchOptions{FieldManager: c.fieldManager})
+}
+
+// RollbackToRevision roll the Deployment back to the Pod template of revision,like `kubectl rollout undo --to-revision`,
+// revision 0 is the previous revision,the revisions are kept in ReplicaSets,so they are limited by SetHistoryLimit().
+// the change-cause of the revision is written on the Deployment,and the live Deployment is returned
+// without change when the revision is the current Pod template.
+func (c *Client) RollbackToRevision(ctx context.Context, dp *appsv1.Deployment, revision int64) (*appsv1.Deployment, error) {
+	if dp == nil || !verifyString(dp.GetName()) {
+		return nil, fmt.Errorf("RollbackToRevision err,Deployment name is not allowed to be empty")
+	}
+	if revision < 0 {
+		return nil, fmt.Errorf("RollbackToRevision err,revision %d is not allowed to be negative", revision)
+	}
+	deployments := c.kube.AppsV1().Deployments(dp.GetNamespace())
+	live, err := deployments.Get(ctx, dp.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if live.Spec.Paused {
+		return nil, fmt.Errorf("RollbackToRevision err,Deployment %s is paused,resume it first", live.GetName())
+	}
+	rs, err := c.revisionReplicaSet(ctx, live, revision)
+	if err != nil {
+		return nil, err
+	}
+	template := rs.Spec.Template.DeepCopy()
+	// pod-template-hash is added to the template of ReplicaSet by Deployment controller
+	delete(template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+	if equality.Semantic.DeepEqual(template, &live.Spec.Template) {
+		return live, nil
+	}
+	ops := []jsonPatchOp{{Op: "replace", Path: "/spec/template", Value: template}}
+	if cause, ok := rs.Annotations[ChangeCauseAnnotation]; ok {
+		if live.Annotations == nil {
+			ops = append(ops, jsonPatchOp{Op: "add", Path: "/metadata/annotations", Value: map[string]string{ChangeCauseAnnotation: cause}})
+		} else {
+			ops = append(ops, jsonPatchOp{Op: "add", Path: "/metadata/annotations/" + escapeJSONPointer(ChangeCauseAnnotation), Value: cause})
+		}
+	}
+	patch, err := json.Marshal(ops)
+	if err != nil {
+		return nil, err
+	}
+	return deployments.Patch(ctx, live.GetName(), types.JSONPatchType, patch, metav1.PatchOptions{})
+}
+
+// revisionReplicaSet get the ReplicaSet of the revision of the Deployment,revision 0 is the previous revision
+func (c *Client) revisionReplicaSet(ctx context.Context, dp *appsv1.Deployment, revision int64) (*appsv1.ReplicaSet, error) {
+	selector, err := metav1.LabelSelectorAsSelector(dp.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	rsList, err := c.kube.AppsV1().ReplicaSets(dp.GetNamespace()).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	revisions := make(map[int64]*appsv1.ReplicaSet, len(rsList.Items))
+	var current, previous int64
+	for index := range rsList.Items {
+		rs := &rsList.Items[index]
+		if !metav1.IsControlledBy(rs, dp) {
+			continue
+		}
+		rev, err := strconv.ParseInt(rs.Annotations[RevisionAnnotation], 10, 64)
+		if err != nil {
+			continue
+		}
+		revisions[rev] = rs
+		switch {
+		case rev > current:
+			previous, current = current, rev
+		case rev > previous && rev < current:
+			previous = rev
+		}
+	}
+	if revision == 0 {
+		if previous == 0 {
+			return nil, fmt.Errorf("RollbackToRevision err,Deployment %s has no previous revision", dp.GetName())
+		}
+		revision = previous
+	}
+	rs, ok := revisions[revision]
+	if !ok {
+		return nil, fmt.Errorf("RollbackToRevision err,revision %d of Deployment %s is not found", revision, dp.GetName())
+	}
+	return rs, nil
+}
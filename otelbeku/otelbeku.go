@@ -0,0 +1,87 @@
+// Package otelbeku provides the OpenTelemetry Observer of beku,
+// so platform teams can measure manifest-generation latency and apply failure rates across pipelines.
+//
+//	if err := otelbeku.Register(); err != nil {
+//		return err
+//	}
+//
+// every Finish,apply and wait of Bundle is a span named beku.<operation>,
+// and it is counted by beku.operations and timed by beku.operation.duration,
+// both have the attributes beku.operation,beku.kind and error.
+package otelbeku
+
+import (
+	"context"
+	"time"
+
+	"github.com/yulibaozi/beku"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracer and meter
+const instrumentationName = "github.com/yulibaozi/beku"
+
+// Observer record the operations of beku as OpenTelemetry spans and metrics
+type Observer struct {
+	tracer     trace.Tracer
+	operations metric.Int64Counter
+	duration   metric.Float64Histogram
+}
+
+// New create Observer by the tracer and meter,
+// use otel.Tracer() and otel.Meter() to get them from the global providers.
+func New(tracer trace.Tracer, meter metric.Meter) (*Observer, error) {
+	operations, err := meter.Int64Counter("beku.operations",
+		metric.WithDescription("The number of beku operations,error is true when the operation is failed"))
+	if err != nil {
+		return nil, err
+	}
+	duration, err := meter.Float64Histogram("beku.operation.duration",
+		metric.WithDescription("The duration of beku operations"), metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	return &Observer{tracer: tracer, operations: operations, duration: duration}, nil
+}
+
+// Register create Observer by the global tracer and meter providers of OpenTelemetry and register it into beku
+func Register() error {
+	o, err := New(otel.Tracer(instrumentationName), otel.Meter(instrumentationName))
+	if err != nil {
+		return err
+	}
+	beku.RegisterObserver(o)
+	return nil
+}
+
+// Start start the span of the operation, the span is ended and the metrics are recorded when the returned function is called
+func (o *Observer) Start(ctx context.Context, observation beku.Observation) (context.Context, func(err error)) {
+	attrs := []attribute.KeyValue{
+		attribute.String("beku.operation", observation.Operation),
+		attribute.String("beku.kind", observation.Kind),
+	}
+	spanAttrs := append([]attribute.KeyValue{}, attrs...)
+	if observation.Namespace != "" {
+		spanAttrs = append(spanAttrs, attribute.String("beku.namespace", observation.Namespace))
+	}
+	if observation.Name != "" {
+		spanAttrs = append(spanAttrs, attribute.String("beku.name", observation.Name))
+	}
+	begin := time.Now()
+	ctx, span := o.tracer.Start(ctx, "beku."+observation.Operation, trace.WithAttributes(spanAttrs...))
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		// namespace and name are not metric attributes,they have high cardinality
+		metricAttrs := metric.WithAttributes(append(attrs, attribute.Bool("error", err != nil))...)
+		o.operations.Add(ctx, 1, metricAttrs)
+		o.duration.Record(ctx, time.Since(begin).Seconds(), metricAttrs)
+	}
+}
@@ -0,0 +1,190 @@
+package beku
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Parameterized is the beku program which builds one object from the values,eg: replicas and image,
+// so the manifests of dev,stage and prod are rendered by the same program without string templating of yaml.
+type Parameterized struct {
+	build    func(p *Params) Builder
+	defaults map[string]interface{}
+}
+
+// Params give the values to the build function of Parameterized,
+// the value which is missing or of the wrong type is returned as error by Render(),
+// the zero value is returned to the build function in this case,so the chain can go on.
+type Params struct {
+	values map[string]interface{}
+	used   map[string]bool
+	err    error
+}
+
+// Parameterize create Parameterized with the build function,
+// the build function reads the values by Params and returns the builder,eg:
+//
+//	beku.Parameterize(func(p *beku.Params) beku.Builder {
+//		return beku.NewDeployment().SetName(p.String("name")).SetReplicas(p.Int32("replicas"))
+//	})
+func Parameterize(build func(p *Params) Builder) *Parameterized {
+	return &Parameterized{build: build, defaults: make(map[string]interface{})}
+}
+
+// Default set the default value of the param which is used when it is not in the values of Render()
+func (t *Parameterized) Default(name string, value interface{}) *Parameterized {
+	t.defaults[name] = value
+	return t
+}
+
+// Render run the build function with values and the defaults,and return the finished object,
+// the values which are not read by the build function are rejected,eg: the typo of param name.
+func (t *Parameterized) Render(values map[string]interface{}) (runtime.Object, error) {
+	if t.build == nil {
+		return nil, errors.New("Render err,the build function of Parameterize() is not allowed to be nil")
+	}
+	p := &Params{values: make(map[string]interface{}, len(t.defaults)+len(values)), used: make(map[string]bool)}
+	for name, value := range t.defaults {
+		p.values[name] = value
+	}
+	for name, value := range values {
+		p.values[name] = value
+	}
+	builder := t.build(p)
+	unknown := make([]string, 0)
+	for name := range values {
+		if !p.used[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		p.error(name, "is not a param of the program")
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	if builder == nil {
+		return nil, errors.New("Render err,the build function returned nil builder")
+	}
+	return builder.FinishObject()
+}
+
+// RenderYAML same as Render(),but the values are yaml,eg: the values file of one environment
+func (t *Parameterized) RenderYAML(valuesYAML []byte) (runtime.Object, error) {
+	values := make(map[string]interface{})
+	if err := decodeYAML(valuesYAML, &values); err != nil {
+		return nil, fmt.Errorf("RenderYAML err,%v", err)
+	}
+	return t.Render(values)
+}
+
+// Param get the value of the param as it is,it is nil when the param is missing
+func (p *Params) Param(name string) interface{} {
+	p.used[name] = true
+	return p.values[name]
+}
+
+// String get the value of the param as string
+func (p *Params) String(name string) string {
+	value, ok := p.lookup(name)
+	if !ok {
+		return ""
+	}
+	str, ok := value.(string)
+	if !ok {
+		p.error(name, fmt.Sprintf("should be string,got %T", value))
+	}
+	return str
+}
+
+// Int get the value of the param as int,the float number which is not integer is rejected
+func (p *Params) Int(name string) int {
+	value, ok := p.lookup(name)
+	if !ok {
+		return 0
+	}
+	switch v := value.(type) {
+	case int:
+		return v
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case float64:
+		// the numbers of json and yaml values are float64
+		if v == math.Trunc(v) {
+			return int(v)
+		}
+	}
+	p.error(name, fmt.Sprintf("should be integer,got %v(%T)", value, value))
+	return 0
+}
+
+// Int32 get the value of the param as int32,eg: replicas
+func (p *Params) Int32(name string) int32 {
+	i := p.Int(name)
+	if i > math.MaxInt32 || i < math.MinInt32 {
+		p.error(name, fmt.Sprintf("%d is out of int32", i))
+		return 0
+	}
+	return int32(i)
+}
+
+// Bool get the value of the param as bool
+func (p *Params) Bool(name string) bool {
+	value, ok := p.lookup(name)
+	if !ok {
+		return false
+	}
+	b, ok := value.(bool)
+	if !ok {
+		p.error(name, fmt.Sprintf("should be bool,got %T", value))
+	}
+	return b
+}
+
+// StringMap get the value of the param as map[string]string,eg: labels,
+// the values of map[string]interface{} must be string.
+func (p *Params) StringMap(name string) map[string]string {
+	value, ok := p.lookup(name)
+	if !ok {
+		return nil
+	}
+	switch v := value.(type) {
+	case map[string]string:
+		return v
+	case map[string]interface{}:
+		m := make(map[string]string, len(v))
+		for key, item := range v {
+			str, ok := item.(string)
+			if !ok {
+				p.error(name, fmt.Sprintf("%s should be string,got %T", key, item))
+				return nil
+			}
+			m[key] = str
+		}
+		return m
+	}
+	p.error(name, fmt.Sprintf("should be map of string,got %T", value))
+	return nil
+}
+
+// lookup get the value of the param,ok is false when the param is missing
+func (p *Params) lookup(name string) (interface{}, bool) {
+	p.used[name] = true
+	value, ok := p.values[name]
+	if !ok || value == nil {
+		p.error(name, "is not allowed to be empty,set it in values or by Default()")
+		return nil, false
+	}
+	return value, true
+}
+
+func (p *Params) error(name, message string) {
+	p.err = appendError(p.err, FieldError{Field: "param." + name, Message: message})
+}
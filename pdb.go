@@ -0,0 +1,247 @@
+package beku
+
+import (
+	"context"
+	"fmt"
+
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// PodDisruptionBudget include Kubernetes resource object PodDisruptionBudget(policy/v1) and error
+type PodDisruptionBudget struct {
+	pdb *policyv1.PodDisruptionBudget
+	err error
+}
+
+// NewPDB create PodDisruptionBudget(pdb) and chain function call begin with this function.
+func NewPDB() *PodDisruptionBudget {
+	return &PodDisruptionBudget{pdb: &policyv1.PodDisruptionBudget{}}
+}
+
+// PodDisruptionBudgetFrom create PodDisruptionBudget from the existing PodDisruptionBudget,eg: the one got by client,
+// so it can be changed by the chain function and released again,
+// pdb is deep copied and the fields which are not changed by the chain function are kept.
+func PodDisruptionBudgetFrom(pdb *policyv1.PodDisruptionBudget) *PodDisruptionBudget {
+	if pdb == nil {
+		return NewPDB()
+	}
+	return &PodDisruptionBudget{pdb: pdb.DeepCopy()}
+}
+
+// Finish Chain function call end with this function
+// return real PodDisruptionBudget(really PodDisruptionBudget is kubernetes resource object PodDisruptionBudget and error
+// In the function, it will check necessary parameters、input the default field
+func (obj *PodDisruptionBudget) Finish() (*policyv1.PodDisruptionBudget, error) {
+	err := appendError(obj.err, obj.verify())
+	return obj.pdb, err
+}
+
+// Validate check PodDisruptionBudget necessary value like Finish(),and return the problems as FieldError,
+// but PodDisruptionBudget is not changed, no Kind,APIVersion or default field is input,
+// so it can be used to check a partially built PodDisruptionBudget.
+func (obj *PodDisruptionBudget) Validate() []FieldError {
+	cp := &PodDisruptionBudget{pdb: obj.pdb.DeepCopy(), err: obj.err}
+	return FieldErrors(appendError(cp.err, cp.verify()))
+}
+
+// FinishObject same as Finish(), but return PodDisruptionBudget as runtime.Object,
+// so PodDisruptionBudget can be used as Builder, eg: add into Bundle.
+func (obj *PodDisruptionBudget) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// FinishUnchecked return PodDisruptionBudget without the checks and default fields of Finish(),only Kind and APIVersion are input,
+// it is for the partial PodDisruptionBudget which is patched onto the existing one,the error of the chain is still returned.
+func (obj *PodDisruptionBudget) FinishUnchecked() (*policyv1.PodDisruptionBudget, error) {
+	obj.pdb.Kind, obj.pdb.APIVersion = "PodDisruptionBudget", "policy/v1"
+	return obj.pdb, obj.err
+}
+
+// JSONNew use json data create PodDisruptionBudget
+func (obj *PodDisruptionBudget) JSONNew(jsonbyts []byte) *PodDisruptionBudget {
+	obj.error(decodeJSON(jsonbyts, obj.pdb))
+	return obj
+}
+
+// YAMLNew use yaml data create PodDisruptionBudget
+func (obj *PodDisruptionBudget) YAMLNew(yamlbyts []byte) *PodDisruptionBudget {
+	obj.error(decodeYAML(yamlbyts, obj.pdb))
+	return obj
+}
+
+// Replace replace PodDisruptionBudget by Kubernetes resource object
+func (obj *PodDisruptionBudget) Replace(pdb *policyv1.PodDisruptionBudget) *PodDisruptionBudget {
+	if pdb != nil {
+		obj.pdb = pdb
+	}
+	return obj
+}
+
+// SetName set PodDisruptionBudget name
+func (obj *PodDisruptionBudget) SetName(name string) *PodDisruptionBudget {
+	obj.pdb.SetName(name)
+	return obj
+}
+
+// SetNamespace set PodDisruptionBudget namespace,default namespace is 'default'
+func (obj *PodDisruptionBudget) SetNamespace(namespace string) *PodDisruptionBudget {
+	obj.pdb.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set PodDisruptionBudget namespace and name
+func (obj *PodDisruptionBudget) SetNamespaceAndName(namespace, name string) *PodDisruptionBudget {
+	obj.pdb.SetName(name)
+	obj.pdb.SetNamespace(namespace)
+	return obj
+}
+
+// SetLabels set PodDisruptionBudget labels
+func (obj *PodDisruptionBudget) SetLabels(labels map[string]string) *PodDisruptionBudget {
+	obj.pdb.SetLabels(labels)
+	return obj
+}
+
+// AddLabel add or overwrite one label of PodDisruptionBudget,the other labels are kept
+func (obj *PodDisruptionBudget) AddLabel(key, value string) *PodDisruptionBudget {
+	obj.error(addLabel(obj.pdb, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of PodDisruptionBudget,the other annotations are kept
+func (obj *PodDisruptionBudget) AddAnnotation(key, value string) *PodDisruptionBudget {
+	obj.error(addAnnotation(obj.pdb, key, value))
+	return obj
+}
+
+// SetSelector set the labels of the Pods which are protected,it is the Pod labels of the workload,
+// eg: the labels of SetPodLabels() of Deployment.
+func (obj *PodDisruptionBudget) SetSelector(labels map[string]string) *PodDisruptionBudget {
+	if len(labels) <= 0 {
+		obj.error(fieldError("SetSelector", "labels are not allowed to be empty"))
+		return obj
+	}
+	obj.pdb.Spec.Selector = &metav1.LabelSelector{MatchLabels: copyLabels(labels)}
+	return obj
+}
+
+// SetMinAvailable set the number or percentage of the Pods which must be available during the voluntary disruption,
+// eg: node drain,minAvailable is a number like 2 or a percentage like 50%,it is exclusive with SetMaxUnavailable().
+func (obj *PodDisruptionBudget) SetMinAvailable(minAvailable string) *PodDisruptionBudget {
+	value, err := parseIntOrPercent("SetMinAvailable", "minAvailable", minAvailable)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.pdb.Spec.MinAvailable = &value
+	return obj
+}
+
+// SetMaxUnavailable set the number or percentage of the Pods which can be unavailable during the voluntary disruption,
+// maxUnavailable is a number like 1 or a percentage like 25%,it is exclusive with SetMinAvailable().
+func (obj *PodDisruptionBudget) SetMaxUnavailable(maxUnavailable string) *PodDisruptionBudget {
+	value, err := parseIntOrPercent("SetMaxUnavailable", "maxUnavailable", maxUnavailable)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.pdb.Spec.MaxUnavailable = &value
+	return obj
+}
+
+// SetUnhealthyPodEvictionPolicy set when the unhealthy Pods can be evicted,
+// policy is IfHealthyBudget(default) or AlwaysAllow which lets the node drain not blocked by the crashing Pods.
+func (obj *PodDisruptionBudget) SetUnhealthyPodEvictionPolicy(policy string) *PodDisruptionBudget {
+	typ := policyv1.UnhealthyPodEvictionPolicyType(policy)
+	if typ != policyv1.IfHealthyBudget && typ != policyv1.AlwaysAllow {
+		obj.error(fieldErrorf("SetUnhealthyPodEvictionPolicy", "policy %s is not allowed,only IfHealthyBudget and AlwaysAllow", policy))
+		return obj
+	}
+	obj.pdb.Spec.UnhealthyPodEvictionPolicy = &typ
+	return obj
+}
+
+// Release release PodDisruptionBudget on Kubernetes
+func (obj *PodDisruptionBudget) Release() (*policyv1.PodDisruptionBudget, error) {
+	pdb, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return nil, err
+	}
+	return client.PolicyV1().PodDisruptionBudgets(pdb.GetNamespace()).Create(context.TODO(), pdb, metav1.CreateOptions{})
+}
+
+// Apply  it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist.
+func (obj *PodDisruptionBudget) Apply() (*policyv1.PodDisruptionBudget, error) {
+	pdb, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return nil, err
+	}
+	existing, err := client.PolicyV1().PodDisruptionBudgets(pdb.GetNamespace()).Get(context.TODO(), pdb.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.PolicyV1().PodDisruptionBudgets(pdb.GetNamespace()).Create(context.TODO(), pdb, metav1.CreateOptions{})
+	}
+	// the update of PodDisruptionBudget needs resourceVersion
+	pdb.ResourceVersion = existing.ResourceVersion
+	return client.PolicyV1().PodDisruptionBudgets(pdb.GetNamespace()).Update(context.TODO(), pdb, metav1.UpdateOptions{})
+}
+
+// Delete delete PodDisruptionBudget on Kubernetes by its name and namespace,
+// opts[0] sets the propagation policy and grace period.
+func (obj *PodDisruptionBudget) Delete(opts ...DeleteOptions) error {
+	if obj.err != nil {
+		return obj.err
+	}
+	options, err := newDeleteOptions("PodDisruptionBudget", obj.pdb.GetName(), opts)
+	if err != nil {
+		return err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return err
+	}
+	return client.PolicyV1().PodDisruptionBudgets(obj.pdb.GetNamespace()).Delete(context.TODO(), obj.pdb.GetName(), *options)
+}
+
+// String the current PodDisruptionBudget as yaml, the pending error is written on the top as comment.
+// it doesn't check and input default field like Finish(), so the PodDisruptionBudget may be incomplete.
+func (obj *PodDisruptionBudget) String() string { return dump("PodDisruptionBudget", obj.pdb, obj.err) }
+
+// Dump print the current PodDisruptionBudget and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *PodDisruptionBudget) Dump() *PodDisruptionBudget {
+	fmt.Println(obj.String())
+	return obj
+}
+
+func (obj *PodDisruptionBudget) error(err error) {
+	obj.err = appendError(obj.err, err)
+}
+
+// verify check PodDisruptionBudget necessary value, input the default field.
+func (obj *PodDisruptionBudget) verify() error {
+	var errs error
+	if !verifyString(obj.pdb.GetName()) {
+		errs = appendError(errs, fieldError("PodDisruptionBudget.Name", "is not allowed to be empty"))
+	}
+	if obj.pdb.Spec.Selector == nil {
+		errs = appendError(errs, fieldError("PodDisruptionBudget.Spec.Selector", "is not allowed to be empty,you can call SetSelector() set it"))
+	}
+	if (obj.pdb.Spec.MinAvailable == nil) == (obj.pdb.Spec.MaxUnavailable == nil) {
+		errs = appendError(errs, fieldError("PodDisruptionBudget.Spec", "needs one of minAvailable and maxUnavailable,you can call SetMinAvailable() or SetMaxUnavailable()"))
+	}
+	if errs != nil {
+		return errs
+	}
+	obj.pdb.Kind = "PodDisruptionBudget"
+	obj.pdb.APIVersion = "policy/v1"
+	return nil
+}
@@ -1,14 +1,12 @@
 package beku
 
 import (
-	"encoding/json"
-	"errors"
+	"context"
 	"fmt"
 
-	"github.com/ghodss/yaml"
-	"github.com/yulibaozi/mapper"
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // PersistentVolume include Kubernetes resource object PersistentVolume(pv) and error.
@@ -20,23 +18,52 @@ type PersistentVolume struct {
 // NewPV create PersistentVolume and chain function call begin with this function.
 func NewPV() *PersistentVolume { return &PersistentVolume{pv: &v1.PersistentVolume{}} }
 
+// PersistentVolumeFrom create PersistentVolume from the existing PersistentVolume,eg: the one got by client,
+// so it can be changed by the chain function and released again,
+// pv is deep copied and the fields which are not changed by the chain function are kept.
+func PersistentVolumeFrom(pv *v1.PersistentVolume) *PersistentVolume {
+	if pv == nil {
+		return NewPV()
+	}
+	return &PersistentVolume{pv: pv.DeepCopy()}
+}
+
 // Finish chain function call end with this function
 // return Kubernetes resource object PersistentVolume(pv) and error.
 // In the function, it will check necessary parameters、input the default field。
 func (obj *PersistentVolume) Finish() (*v1.PersistentVolume, error) {
-	obj.verify()
+	err := appendError(obj.err, obj.verify())
+	return obj.pv, err
+}
+
+// Validate check PersistentVolume necessary value like Finish(),and return the problems as FieldError,
+// but PersistentVolume is not changed, no Kind,APIVersion or default field is input,
+// so it can be used to check a partially built PersistentVolume.
+func (obj *PersistentVolume) Validate() []FieldError {
+	cp := &PersistentVolume{pv: obj.pv.DeepCopy(), err: obj.err}
+	return FieldErrors(appendError(cp.err, cp.verify()))
+}
+
+// FinishObject same as Finish(), but return PersistentVolume as runtime.Object,
+// so PersistentVolume can be used as Builder, eg: add into Bundle.
+func (obj *PersistentVolume) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// FinishUnchecked return PersistentVolume without the checks and default fields of Finish(),only Kind and APIVersion are input,
+// it is for the partial PersistentVolume which is patched onto the existing one,the error of the chain is still returned.
+func (obj *PersistentVolume) FinishUnchecked() (*v1.PersistentVolume, error) {
+	obj.pv.Kind, obj.pv.APIVersion = "PersistentVolume", "v1"
 	return obj.pv, obj.err
 }
 
 // JSONNew use json data create PersistentVolume(pv)
 func (obj *PersistentVolume) JSONNew(jsonbyte []byte) *PersistentVolume {
-	obj.error(json.Unmarshal(jsonbyte, obj.pv))
+	obj.error(decodeJSON(jsonbyte, obj.pv))
 	return obj
 }
 
 // YAMLNew use yaml data create PersistentVolume(pv)
 func (obj *PersistentVolume) YAMLNew(yamlbyts []byte) *PersistentVolume {
-	obj.error(yaml.Unmarshal(yamlbyts, obj.pv))
+	obj.error(decodeYAML(yamlbyts, obj.pv))
 	return obj
 }
 
@@ -54,6 +81,19 @@ func (obj *PersistentVolume) SetLabels(labels map[string]string) *PersistentVolu
 	return obj
 }
 
+// AddLabel add or overwrite one label of PersistentVolume,the other labels are kept,
+// unlike SetLabels() which replaces all labels,eg: the labels loaded by YAMLNew().
+func (obj *PersistentVolume) AddLabel(key, value string) *PersistentVolume {
+	obj.error(addLabel(obj.pv, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of PersistentVolume,the other annotations are kept
+func (obj *PersistentVolume) AddAnnotation(key, value string) *PersistentVolume {
+	obj.error(addAnnotation(obj.pv, key, value))
+	return obj
+}
+
 // GetLabels get PersistentVolume(pv) labels
 func (obj *PersistentVolume) GetLabels() map[string]string {
 	return obj.pv.GetLabels()
@@ -78,37 +118,31 @@ func (obj *PersistentVolume) SetAnnotations(annotations map[string]string) *Pers
 
 // SetAccessMode set PersistentVolume(pv) access mode, only one
 func (obj *PersistentVolume) SetAccessMode(mode PersistentVolumeAccessMode) *PersistentVolume {
-	obj.pv.Spec.AccessModes = []v1.PersistentVolumeAccessMode{mode.ToK8s()}
-	return obj
+	return obj.SetAccessModes([]PersistentVolumeAccessMode{mode})
 }
 
 // SetAccessModes set PersistentVolume(pv) access mode, many modes
 func (obj *PersistentVolume) SetAccessModes(modes []PersistentVolumeAccessMode) *PersistentVolume {
-	var objModes []v1.PersistentVolumeAccessMode
-	for _, m := range modes {
-		objModes = append(objModes, m.ToK8s())
+	k8sModes, err := toAccessModes("SetAccessModes", modes)
+	if err != nil {
+		obj.error(err)
+		return obj
 	}
-	obj.pv.Spec.AccessModes = objModes
+	obj.pv.Spec.AccessModes = k8sModes
 	return obj
 }
 
 // SetNFS set PersistentVolume(pv) volume source is nfs
 func (obj *PersistentVolume) SetNFS(nfs *NFSVolumeSource) *PersistentVolume {
 	if !verifyString(nfs.Server) {
-		obj.error(errors.New("SetNFS err, nfs server is not allowed to be empty"))
+		obj.error(fieldError("SetNFS", "nfs server is not allowed to be empty"))
 		return obj
 	}
 	if !verifyString(nfs.Path) {
-		obj.error(errors.New("SetNFS err, nfs path is not allowed to be empty"))
-		return obj
-	}
-	nfsv := new(v1.NFSVolumeSource)
-	err := mapper.Mapper(nfs, nfsv)
-	if err != nil {
-		obj.error(fmt.Errorf("SetNFS err:%v", err))
+		obj.error(fieldError("SetNFS", "nfs path is not allowed to be empty"))
 		return obj
 	}
-	obj.pv.Spec.PersistentVolumeSource.NFS = nfsv
+	obj.pv.Spec.PersistentVolumeSource.NFS = &v1.NFSVolumeSource{Server: nfs.Server, Path: nfs.Path, ReadOnly: nfs.ReadOnly}
 	return obj
 }
 
@@ -116,7 +150,7 @@ func (obj *PersistentVolume) SetNFS(nfs *NFSVolumeSource) *PersistentVolume {
 func (obj *PersistentVolume) SetCapacity(capMaps map[ResourceName]string) *PersistentVolume {
 	data, err := ResourceMapsToK8s(capMaps)
 	if err != nil {
-		obj.error(fmt.Errorf("SetCapacity err:%v", err))
+		obj.error(fieldErrorf("SetCapacity", "%v", err))
 		return obj
 	}
 	obj.pv.Spec.Capacity = data
@@ -126,7 +160,7 @@ func (obj *PersistentVolume) SetCapacity(capMaps map[ResourceName]string) *Persi
 // SetCephFS set PersistentVolume(pv) volume source is ceph
 func (obj *PersistentVolume) SetCephFS(cephFs *CephFSPersistentVolumeSource) *PersistentVolume {
 	if len(cephFs.Monitors) < 1 {
-		obj.error(errors.New("SetCephFS err,cephFS monitor is not allowed to be empty"))
+		obj.error(fieldError("SetCephFS", "cephFS monitor is not allowed to be empty"))
 		return obj
 	}
 	ceph := &v1.CephFSPersistentVolumeSource{
@@ -155,15 +189,15 @@ func (obj *PersistentVolume) SetReclaimPolicy(reclaimPolicy PersistentVolumeRecl
 // SetRBD  set PersistentVolume(pv) volume source is RBD
 func (obj *PersistentVolume) SetRBD(rbd *RBDPersistentVolumeSource) *PersistentVolume {
 	if len(rbd.CephMonitors) < 1 {
-		obj.error(errors.New("SetRBD err, CephMonitor is not allowed to be empty"))
+		obj.error(fieldError("SetRBD", "CephMonitor is not allowed to be empty"))
 		return obj
 	}
 	if !verifyString(rbd.RBDImage) {
-		obj.error(errors.New("SetRBD err, RBDImage is not allowed to be empty"))
+		obj.error(fieldError("SetRBD", "RBDImage is not allowed to be empty"))
 		return obj
 	}
 	if !verifyString(rbd.FSType) {
-		obj.error(errors.New("SetRBD err, RBD.FSType is not allowed to be empty,maybe you can input one of  'ext4', 'xfs', 'ntfs'"))
+		obj.error(fieldError("SetRBD", "RBD.FSType is not allowed to be empty,maybe you can input one of  'ext4', 'xfs', 'ntfs'"))
 		return obj
 	}
 	rbds := &v1.RBDPersistentVolumeSource{
@@ -185,17 +219,70 @@ func (obj *PersistentVolume) SetRBD(rbd *RBDPersistentVolumeSource) *PersistentV
 	return obj
 }
 
+// SetHostPath set PersistentVolume(pv) volume source is the directory or file on the node,it is only for the single node cluster,
+// hostPathType is one of DirectoryOrCreate,Directory,FileOrCreate,File,Socket,CharDevice,BlockDevice,empty is not checked.
+func (obj *PersistentVolume) SetHostPath(path, hostPathType string) *PersistentVolume {
+	source, err := hostPathSource("SetHostPath", path, hostPathType)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.pv.Spec.PersistentVolumeSource.HostPath = source
+	return obj
+}
+
+// SetCSI set PersistentVolume(pv) volume source is the volume provisioned by CSI driver,eg: ebs.csi.aws.com,
+// volumeHandle is the id of the volume in the driver,attributes are passed to the driver and can be nil.
+func (obj *PersistentVolume) SetCSI(driver, volumeHandle, fsType string, attributes map[string]string) *PersistentVolume {
+	if !verifyString(driver) {
+		obj.error(fieldError("SetCSI", "driver is not allowed to be empty"))
+		return obj
+	}
+	if !verifyString(volumeHandle) {
+		obj.error(fieldError("SetCSI", "volumeHandle is not allowed to be empty"))
+		return obj
+	}
+	obj.pv.Spec.PersistentVolumeSource.CSI = &v1.CSIPersistentVolumeSource{
+		Driver:           driver,
+		VolumeHandle:     volumeHandle,
+		FSType:           fsType,
+		VolumeAttributes: copyLabels(attributes),
+	}
+	return obj
+}
+
+// SetStorageClassName set PersistentVolume(pv) storageclass name,only the PersistentVolumeClaim of the class can bind it
+func (obj *PersistentVolume) SetStorageClassName(classname string) *PersistentVolume {
+	if !verifyString(classname) {
+		obj.error(fieldError("SetStorageClassName", "StorageClassName is not allowed to be empty"))
+		return obj
+	}
+	obj.pv.Spec.StorageClassName = classname
+	return obj
+}
+
+// SetVolumeMode set PersistentVolume(pv) volume mode,have Block and Filesystem mode
+func (obj *PersistentVolume) SetVolumeMode(volumeMode PersistentVolumeMode) *PersistentVolume {
+	m := volumeMode.ToK8s()
+	if m == nil {
+		obj.error(fieldErrorf("SetVolumeMode", "the volumeMode: %v is not allowed", volumeMode))
+		return obj
+	}
+	obj.pv.Spec.VolumeMode = m
+	return obj
+}
+
 // Release release PersistentVolume on Kubernetes
 func (obj *PersistentVolume) Release() (*v1.PersistentVolume, error) {
 	pv, err := obj.Finish()
 	if err != nil {
 		return nil, err
 	}
-	client, err := GetKubeClient()
+	client, err := getKubeInterface()
 	if err != nil {
 		return nil, err
 	}
-	return client.CoreV1().PersistentVolumes().Create(pv)
+	return client.CoreV1().PersistentVolumes().Create(context.TODO(), pv, metav1.CreateOptions{})
 }
 
 // Apply  it will be updated when this resource object exists in K8s,
@@ -205,46 +292,69 @@ func (obj *PersistentVolume) Apply() (*v1.PersistentVolume, error) {
 	if err != nil {
 		return nil, err
 	}
-	client, err := GetKubeClient()
+	client, err := getKubeInterface()
 	if err != nil {
 		return nil, err
 	}
-	_, err = client.CoreV1().PersistentVolumes().Get(pv.GetName(), metav1.GetOptions{})
+	_, err = client.CoreV1().PersistentVolumes().Get(context.TODO(), pv.GetName(), metav1.GetOptions{})
 	if err != nil {
-		return client.CoreV1().PersistentVolumes().Create(pv)
+		return client.CoreV1().PersistentVolumes().Create(context.TODO(), pv, metav1.CreateOptions{})
 	}
-	return client.CoreV1().PersistentVolumes().Update(pv)
+	return client.CoreV1().PersistentVolumes().Update(context.TODO(), pv, metav1.UpdateOptions{})
 }
 
-func (obj *PersistentVolume) error(err error) {
+// Delete delete PersistentVolume on Kubernetes by its name,
+// opts[0] sets the propagation policy and grace period.
+func (obj *PersistentVolume) Delete(opts ...DeleteOptions) error {
 	if obj.err != nil {
-		return
+		return obj.err
 	}
-	obj.err = err
+	options, err := newDeleteOptions("PersistentVolume", obj.pv.GetName(), opts)
+	if err != nil {
+		return err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return err
+	}
+	return client.CoreV1().PersistentVolumes().Delete(context.TODO(), obj.pv.GetName(), *options)
+}
+
+// String the current PersistentVolume as yaml, the pending error is written on the top as comment.
+// it doesn't check and input default field like Finish(), so the PersistentVolume may be incomplete.
+func (obj *PersistentVolume) String() string { return dump("PersistentVolume", obj.pv, obj.err) }
+
+// Dump print the current PersistentVolume and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *PersistentVolume) Dump() *PersistentVolume {
+	fmt.Println(obj.String())
+	return obj
+}
+
+func (obj *PersistentVolume) error(err error) {
+	obj.err = appendError(obj.err, err)
 }
 
 // verify check service necessary value, input the default field and input related data.
-func (obj *PersistentVolume) verify() {
-	if obj.err != nil {
-		return
-	}
+func (obj *PersistentVolume) verify() error {
+	var errs error
 	if !verifyString(obj.pv.GetName()) {
-		obj.err = errors.New("PersistentVolume.Name is not allowed to be empty")
-		return
+		errs = appendError(errs, fieldError("PersistentVolume.Name", "is not allowed to be empty"))
 	}
-	if obj.pv.Spec.AccessModes == nil || len(obj.pv.Spec.AccessModes) < 1 {
-		obj.err = errors.New("PersistentVolume.Spec.AccessModes is not allowed to be empty")
-		return
+	if len(obj.pv.Spec.AccessModes) < 1 {
+		errs = appendError(errs, fieldError("PersistentVolume.Spec.AccessModes", "is not allowed to be empty"))
 	}
-	if obj.pv.Spec.Capacity == nil || len(obj.pv.Spec.Capacity) < 1 {
-		obj.err = errors.New("PersistentVolume.Spec.Capacity is not allowed to be empty")
-		return
+	if len(obj.pv.Spec.Capacity) < 1 {
+		errs = appendError(errs, fieldError("PersistentVolume.Spec.Capacity", "is not allowed to be empty"))
 	}
 	var objs v1.PersistentVolumeSource
 	if obj.pv.Spec.PersistentVolumeSource == objs {
-		obj.err = errors.New("PersistentVolume.Spec.PersistentVolumeSource is not allowed to be empty")
-		return
+		errs = appendError(errs, fieldError("PersistentVolume.Spec.PersistentVolumeSource", "is not allowed to be empty"))
+	}
+	if errs != nil {
+		return errs
 	}
 	obj.pv.Kind = "PersistentVolume"
 	obj.pv.APIVersion = "v1"
+	return nil
 }
@@ -1,13 +1,13 @@
 package beku
 
 import (
-	"encoding/json"
-	"errors"
+	"context"
 	"fmt"
 
-	"github.com/ghodss/yaml"
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // PersistentVolumeClaim include kubernetes resource object PersistentVolumeClaim(pvc) and error.
@@ -19,23 +19,52 @@ type PersistentVolumeClaim struct {
 // NewPVC create PersistentVolumeClaim(pvc) and chain function call begin with this function.
 func NewPVC() *PersistentVolumeClaim { return &PersistentVolumeClaim{pvc: &v1.PersistentVolumeClaim{}} }
 
+// PersistentVolumeClaimFrom create PersistentVolumeClaim from the existing PersistentVolumeClaim,eg: the one got by client,
+// so it can be changed by the chain function and released again,
+// pvc is deep copied and the fields which are not changed by the chain function are kept.
+func PersistentVolumeClaimFrom(pvc *v1.PersistentVolumeClaim) *PersistentVolumeClaim {
+	if pvc == nil {
+		return NewPVC()
+	}
+	return &PersistentVolumeClaim{pvc: pvc.DeepCopy()}
+}
+
 // Finish Chain function call end with this function
 // return Kubernetes resource object PersistentVolumeClaim(pvc) and error.
 // In the function, it will check necessary parameters?input the default field?
 func (obj *PersistentVolumeClaim) Finish() (*v1.PersistentVolumeClaim, error) {
-	obj.verify()
+	err := appendError(obj.err, obj.verify())
+	return obj.pvc, err
+}
+
+// Validate check PersistentVolumeClaim necessary value like Finish(),and return the problems as FieldError,
+// but PersistentVolumeClaim is not changed, no Kind,APIVersion or default field is input,
+// so it can be used to check a partially built PersistentVolumeClaim.
+func (obj *PersistentVolumeClaim) Validate() []FieldError {
+	cp := &PersistentVolumeClaim{pvc: obj.pvc.DeepCopy(), err: obj.err}
+	return FieldErrors(appendError(cp.err, cp.verify()))
+}
+
+// FinishObject same as Finish(), but return PersistentVolumeClaim as runtime.Object,
+// so PersistentVolumeClaim can be used as Builder, eg: add into Bundle.
+func (obj *PersistentVolumeClaim) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// FinishUnchecked return PersistentVolumeClaim without the checks and default fields of Finish(),only Kind and APIVersion are input,
+// it is for the partial PersistentVolumeClaim which is patched onto the existing one,the error of the chain is still returned.
+func (obj *PersistentVolumeClaim) FinishUnchecked() (*v1.PersistentVolumeClaim, error) {
+	obj.pvc.Kind, obj.pvc.APIVersion = "PersistentVolumeClaim", "v1"
 	return obj.pvc, obj.err
 }
 
 // JSONNew use json data create PersistentVolumeClaim(pvc)
 func (obj *PersistentVolumeClaim) JSONNew(jsonbyts []byte) *PersistentVolumeClaim {
-	obj.error(json.Unmarshal(jsonbyts, obj.pvc))
+	obj.error(decodeJSON(jsonbyts, obj.pvc))
 	return obj
 }
 
 // YAMLNew use yaml data create PersistentVolumeClaim(pvc)
 func (obj *PersistentVolumeClaim) YAMLNew(yamlbyts []byte) *PersistentVolumeClaim {
-	obj.error(yaml.Unmarshal(yamlbyts, obj.pvc))
+	obj.error(decodeYAML(yamlbyts, obj.pvc))
 	return obj
 }
 
@@ -82,6 +111,19 @@ func (obj *PersistentVolumeClaim) SetLabels(labels map[string]string) *Persisten
 	return obj
 }
 
+// AddLabel add or overwrite one label of PersistentVolumeClaim,the other labels are kept,
+// unlike SetLabels() which replaces all labels,eg: the labels loaded by YAMLNew().
+func (obj *PersistentVolumeClaim) AddLabel(key, value string) *PersistentVolumeClaim {
+	obj.error(addLabel(obj.pvc, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of PersistentVolumeClaim,the other annotations are kept
+func (obj *PersistentVolumeClaim) AddAnnotation(key, value string) *PersistentVolumeClaim {
+	obj.error(addAnnotation(obj.pvc, key, value))
+	return obj
+}
+
 // GetLabels get PersistentVolumeClaim(pvc) labels
 func (obj *PersistentVolumeClaim) GetLabels() map[string]string {
 	return obj.pvc.GetLabels()
@@ -95,17 +137,17 @@ func (obj *PersistentVolumeClaim) SetAnnotations(annotations map[string]string)
 
 // SetAccessMode set PersistentVolumeClaim(pvc) access mode, only one
 func (obj *PersistentVolumeClaim) SetAccessMode(mode PersistentVolumeAccessMode) *PersistentVolumeClaim {
-	obj.pvc.Spec.AccessModes = []v1.PersistentVolumeAccessMode{mode.ToK8s()}
-	return obj
+	return obj.SetAccessModes([]PersistentVolumeAccessMode{mode})
 }
 
 // SetAccessModes set PersistentVolumeClaim(pvc) accessModes, many modes
 func (obj *PersistentVolumeClaim) SetAccessModes(modes []PersistentVolumeAccessMode) *PersistentVolumeClaim {
-	var objModes []v1.PersistentVolumeAccessMode
-	for _, m := range modes {
-		objModes = append(objModes, m.ToK8s())
+	k8sModes, err := toAccessModes("SetAccessModes", modes)
+	if err != nil {
+		obj.error(err)
+		return obj
 	}
-	obj.pvc.Spec.AccessModes = objModes
+	obj.pvc.Spec.AccessModes = k8sModes
 	return obj
 }
 
@@ -113,7 +155,7 @@ func (obj *PersistentVolumeClaim) SetAccessModes(modes []PersistentVolumeAccessM
 func (obj *PersistentVolumeClaim) SetVolumeMode(volumeMode PersistentVolumeMode) *PersistentVolumeClaim {
 	m := volumeMode.ToK8s()
 	if m == nil {
-		obj.error(fmt.Errorf("SetVolumeMode err: the volumeMode: %v is not allowed", volumeMode))
+		obj.error(fieldErrorf("SetVolumeMode", "the volumeMode: %v is not allowed", volumeMode))
 		return obj
 	}
 	obj.pvc.Spec.VolumeMode = m
@@ -124,7 +166,7 @@ func (obj *PersistentVolumeClaim) SetVolumeMode(volumeMode PersistentVolumeMode)
 func (obj *PersistentVolumeClaim) SetResourceLimits(limits map[ResourceName]string) *PersistentVolumeClaim {
 	data, err := ResourceMapsToK8s(limits)
 	if err != nil {
-		obj.error(fmt.Errorf("SetResourceLimit err:%v", err))
+		obj.error(fieldErrorf("SetResourceLimit", "%v", err))
 		return obj
 	}
 	obj.pvc.Spec.Resources.Limits = data
@@ -135,7 +177,7 @@ func (obj *PersistentVolumeClaim) SetResourceLimits(limits map[ResourceName]stri
 func (obj *PersistentVolumeClaim) SetResourceRequests(requests map[ResourceName]string) *PersistentVolumeClaim {
 	data, err := ResourceMapsToK8s(requests)
 	if err != nil {
-		obj.error(fmt.Errorf("SetResourceRequests err:%v", err))
+		obj.error(fieldErrorf("SetResourceRequests", "%v", err))
 		return obj
 	}
 	obj.pvc.Spec.Resources.Requests = data
@@ -145,17 +187,41 @@ func (obj *PersistentVolumeClaim) SetResourceRequests(requests map[ResourceName]
 // SetStorageClassName set PersistentVolumeClaim(pvc) storageclasss name
 func (obj *PersistentVolumeClaim) SetStorageClassName(classname string) *PersistentVolumeClaim {
 	if classname == "" || len(classname) <= 0 {
-		obj.error(errors.New("SetStorageClassName err, StorageClassName is not allowed to be empty"))
+		obj.error(fieldError("SetStorageClassName", "StorageClassName is not allowed to be empty"))
 		return obj
 	}
 	obj.pvc.Spec.StorageClassName = &classname
 	return obj
 }
 
+// SetStorageClass same as SetStorageClassName()
+func (obj *PersistentVolumeClaim) SetStorageClass(classname string) *PersistentVolumeClaim {
+	return obj.SetStorageClassName(classname)
+}
+
+// SetRequestStorage set PersistentVolumeClaim(pvc) request storage size,eg: 10Gi,
+// the other resource requests are kept.
+func (obj *PersistentVolumeClaim) SetRequestStorage(size string) *PersistentVolumeClaim {
+	quantity, err := resource.ParseQuantity(size)
+	if err != nil {
+		obj.error(fieldErrorf("SetRequestStorage", "size %q is not allowed:%v", size, err))
+		return obj
+	}
+	if quantity.Sign() <= 0 {
+		obj.error(fieldErrorf("SetRequestStorage", "size %q must be greater than 0", size))
+		return obj
+	}
+	if obj.pvc.Spec.Resources.Requests == nil {
+		obj.pvc.Spec.Resources.Requests = v1.ResourceList{}
+	}
+	obj.pvc.Spec.Resources.Requests[v1.ResourceStorage] = quantity
+	return obj
+}
+
 // SetSelector set PersistentVolumeClaim(pvc) selector
 func (obj *PersistentVolumeClaim) SetSelector(labels map[string]string) *PersistentVolumeClaim {
 	if len(labels) < 1 {
-		obj.error(errors.New("SetSelector error, labels is not allowed to be empty"))
+		obj.error(fieldError("SetSelector", "labels is not allowed to be empty"))
 		return obj
 	}
 	if obj.pvc.Spec.Selector == nil {
@@ -180,7 +246,7 @@ func (obj *PersistentVolumeClaim) GetSelector() map[string]string {
 // SetMatchExpressions set Deployment match expressions
 // the field is used to set complicated Label.
 func (obj *PersistentVolumeClaim) SetMatchExpressions(ents []LabelSelectorRequirement) *PersistentVolumeClaim {
-	requirements := make([]metav1.LabelSelectorRequirement, 0)
+	requirements := make([]metav1.LabelSelectorRequirement, 0, len(ents))
 	for index := range ents {
 		requirements = append(requirements, metav1.LabelSelectorRequirement{
 			Key:      ents[index].Key,
@@ -198,17 +264,42 @@ func (obj *PersistentVolumeClaim) SetMatchExpressions(ents []LabelSelectorRequir
 	return obj
 }
 
+// MatchIn add a match expression on PersistentVolumeClaim selector: the value of label key is one of values,
+// it can be called many times and all the expressions are ANDed.
+func (obj *PersistentVolumeClaim) MatchIn(key string, values ...string) *PersistentVolumeClaim {
+	obj.error(addMatchExpression(&obj.pvc.Spec.Selector, key, LabelSelectorOpIn, values))
+	return obj
+}
+
+// MatchNotIn add a match expression on PersistentVolumeClaim selector: the value of label key is not any of values
+func (obj *PersistentVolumeClaim) MatchNotIn(key string, values ...string) *PersistentVolumeClaim {
+	obj.error(addMatchExpression(&obj.pvc.Spec.Selector, key, LabelSelectorOpNotIn, values))
+	return obj
+}
+
+// MatchExists add a match expression on PersistentVolumeClaim selector: the label key exists
+func (obj *PersistentVolumeClaim) MatchExists(key string) *PersistentVolumeClaim {
+	obj.error(addMatchExpression(&obj.pvc.Spec.Selector, key, LabelSelectorOpExists, nil))
+	return obj
+}
+
+// MatchDoesNotExist add a match expression on PersistentVolumeClaim selector: the label key does not exist
+func (obj *PersistentVolumeClaim) MatchDoesNotExist(key string) *PersistentVolumeClaim {
+	obj.error(addMatchExpression(&obj.pvc.Spec.Selector, key, LabelSelectorOpDoesNotExist, nil))
+	return obj
+}
+
 // Release release PersistentVolumeClaim on Kubernetes
 func (obj *PersistentVolumeClaim) Release() (*v1.PersistentVolumeClaim, error) {
 	pvc, err := obj.Finish()
 	if err != nil {
 		return nil, err
 	}
-	client, err := GetKubeClient()
+	client, err := getKubeInterface()
 	if err != nil {
 		return nil, err
 	}
-	return client.CoreV1().PersistentVolumeClaims(pvc.GetNamespace()).Create(pvc)
+	return client.CoreV1().PersistentVolumeClaims(pvc.GetNamespace()).Create(context.TODO(), pvc, metav1.CreateOptions{})
 }
 
 // Apply  it will be updated when this resource object exists in K8s,
@@ -218,42 +309,67 @@ func (obj *PersistentVolumeClaim) Apply() (*v1.PersistentVolumeClaim, error) {
 	if err != nil {
 		return nil, err
 	}
-	client, err := GetKubeClient()
+	client, err := getKubeInterface()
 	if err != nil {
 		return nil, err
 	}
-	_, err = client.CoreV1().PersistentVolumeClaims(pvc.GetNamespace()).Get(pvc.GetName(), metav1.GetOptions{})
+	_, err = client.CoreV1().PersistentVolumeClaims(pvc.GetNamespace()).Get(context.TODO(), pvc.GetName(), metav1.GetOptions{})
 	if err != nil {
-		return client.CoreV1().PersistentVolumeClaims(pvc.GetNamespace()).Create(pvc)
+		return client.CoreV1().PersistentVolumeClaims(pvc.GetNamespace()).Create(context.TODO(), pvc, metav1.CreateOptions{})
 	}
-	return client.CoreV1().PersistentVolumeClaims(pvc.GetNamespace()).Update(pvc)
+	return client.CoreV1().PersistentVolumeClaims(pvc.GetNamespace()).Update(context.TODO(), pvc, metav1.UpdateOptions{})
 }
 
-func (obj *PersistentVolumeClaim) error(err error) {
+// Delete delete PersistentVolumeClaim on Kubernetes by its name and namespace,
+// opts[0] sets the propagation policy and grace period.
+func (obj *PersistentVolumeClaim) Delete(opts ...DeleteOptions) error {
 	if obj.err != nil {
-		return
+		return obj.err
+	}
+	options, err := newDeleteOptions("PersistentVolumeClaim", obj.pvc.GetName(), opts)
+	if err != nil {
+		return err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return err
 	}
-	obj.err = err
-	return
+	return client.CoreV1().PersistentVolumeClaims(obj.pvc.GetNamespace()).Delete(context.TODO(), obj.pvc.GetName(), *options)
+}
+
+// String the current PersistentVolumeClaim as yaml, the pending error is written on the top as comment.
+// it doesn't check and input default field like Finish(), so the PersistentVolumeClaim may be incomplete.
+func (obj *PersistentVolumeClaim) String() string {
+	return dump("PersistentVolumeClaim", obj.pvc, obj.err)
+}
+
+// Dump print the current PersistentVolumeClaim and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *PersistentVolumeClaim) Dump() *PersistentVolumeClaim {
+	fmt.Println(obj.String())
+	return obj
+}
+
+func (obj *PersistentVolumeClaim) error(err error) {
+	obj.err = appendError(obj.err, err)
 }
 
 // verify check service necessary value, input the default field and input related data.
-func (obj *PersistentVolumeClaim) verify() {
-	if obj.err != nil {
-		return
-	}
+func (obj *PersistentVolumeClaim) verify() error {
+	var errs error
 	if !verifyString(obj.pvc.GetName()) {
-		obj.err = errors.New("pvc name is not allowed to be empty")
-		return
+		errs = appendError(errs, fieldError("PersistentVolumeClaim.Name", "is not allowed to be empty"))
 	}
-	if obj.pvc.Spec.AccessModes == nil || len(obj.pvc.Spec.AccessModes) < 1 {
-		obj.err = errors.New("pvc accessModes is not allowed to be empty")
-		return
+	if len(obj.pvc.Spec.AccessModes) < 1 {
+		errs = appendError(errs, fieldError("PersistentVolumeClaim.Spec.AccessModes", "is not allowed to be empty"))
 	}
 	if obj.pvc.Spec.Resources.Limits == nil && obj.pvc.Spec.Resources.Requests == nil {
-		obj.err = errors.New("both limits and requests is empty not allowed")
-		return
+		errs = appendError(errs, fieldError("PersistentVolumeClaim.Spec.Resources", "both limits and requests is empty not allowed"))
+	}
+	if errs != nil {
+		return errs
 	}
 	obj.pvc.Kind = "PersistentVolumeClaim"
 	obj.pvc.APIVersion = "v1"
+	return nil
 }
@@ -0,0 +1,797 @@
+package beku
+
+import (
+	"k8s.io/api/core/v1"
+)
+
+// podBuilder is the builder which has Pod template,eg: Deployment,StatefulSet,DaemonSet,Job and PodTemplate
+type podBuilder interface {
+	// podTemplate get Pod template to change it
+	podTemplate() *v1.PodTemplateSpec
+	error(err error)
+}
+
+// PodSetters is the pod-level setters of the builders which have Pod template,
+// it is embedded in PodTemplate,Deployment,StatefulSet,DaemonSet and Job,so they share one implementation,
+// the setters return the builder B,so the chain function call goes on,eg:
+//
+//	beku.NewDeployment().SetName("app").SetContainer("app", "nginx:1.25", 80).SetServiceAccount("app").Finish()
+type PodSetters[B podBuilder] struct {
+	builder B
+}
+
+func (obj PodSetters[B]) template() *v1.PodTemplateSpec { return obj.builder.podTemplate() }
+
+func (obj PodSetters[B]) error(err error) { obj.builder.error(err) }
+
+// AddPodLabel add or overwrite one label of the Pod template,the other labels are kept,
+// the selector set by SetSelector() or SetPodLabels() is not changed.
+func (obj PodSetters[B]) AddPodLabel(key, value string) B {
+	obj.error(addLabel(obj.template(), key, value))
+	return obj.builder
+}
+
+// AddPodAnnotation add or overwrite one annotation of the Pod template,the other annotations are kept
+func (obj PodSetters[B]) AddPodAnnotation(key, value string) B {
+	obj.error(addAnnotation(obj.template(), key, value))
+	return obj.builder
+}
+
+// SetHTTPLiveness set container liveness of http style
+// port: required
+// path: http request URL,eg: /api/v1/posts/1
+// initDelaySec: how long time after the first start of the program the probe is executed for the first time.(sec)
+// timeoutSec: http request timeout seconds,defaults to 1 second. Minimum value is 1.
+// periodSec: how often does the probe? defaults to 1 second. Minimum value is 1,Except for the first time?
+// headers: headers[0] is HTTP Header, do not fill if you do not need to set
+// on the other hand, only **first container** will be set livenessProbe
+func (obj PodSetters[B]) SetHTTPLiveness(port int, path string, initDelaySec, timeoutSec, periodSec int32, headers ...map[string]string) B {
+	obj.error(setLiveness(obj.template(), httpProbe(FromInt(port), path, initDelaySec, timeoutSec, periodSec, headers...)))
+	return obj.builder
+}
+
+// SetLivenessOptions set the options of the liveness probe,eg: TerminationGracePeriodSeconds,
+// call it after SetXXXLiveness(),only **first container** is set like SetXXXLiveness().
+func (obj PodSetters[B]) SetLivenessOptions(opts ProbeOptions) B {
+	obj.error(setLivenessOptions(obj.template(), opts))
+	return obj.builder
+}
+
+// SetHTTPStartup set container startup probe of http style,liveness and readiness probes wait until it succeeds,
+// so slow-starting container,eg: JVM application,isn't killed by liveness probe before it starts.
+// port: required
+// path: http request URL,eg: /actuator/health
+// failureThreshold: the container is killed when it doesn't start in failureThreshold*periodSec seconds
+// periodSec: how often does the probe? defaults to 10 seconds.
+// headers: headers[0] is HTTP Header, do not fill if you do not need to set
+// on the other hand, only **first container** will be set startupProbe
+func (obj PodSetters[B]) SetHTTPStartup(port int, path string, failureThreshold, periodSec int32, headers ...map[string]string) B {
+	if failureThreshold < 0 || periodSec < 0 {
+		obj.error(fieldError("SetHTTPStartup", "failureThreshold and periodSec are not allowed to be negative"))
+		return obj.builder
+	}
+	obj.error(setStartup(obj.template(), startupProbe(httpProbe(FromInt(port), path, 0, 0, periodSec, headers...), failureThreshold)))
+	return obj.builder
+}
+
+// SetCMDStartup set container startup probe of cmd style
+// cmd: execute startup probe as commond line
+// other params same as SetHTTPStartup
+func (obj PodSetters[B]) SetCMDStartup(cmd []string, failureThreshold, periodSec int32) B {
+	if failureThreshold < 0 || periodSec < 0 {
+		obj.error(fieldError("SetCMDStartup", "failureThreshold and periodSec are not allowed to be negative"))
+		return obj.builder
+	}
+	obj.error(setStartup(obj.template(), startupProbe(cmdProbe(cmd, 0, 0, periodSec), failureThreshold)))
+	return obj.builder
+}
+
+// SetTCPStartup set container startup probe of tcp style
+// host: default is ""
+// port: required
+// other params same as SetHTTPStartup
+func (obj PodSetters[B]) SetTCPStartup(host string, port int, failureThreshold, periodSec int32) B {
+	if failureThreshold < 0 || periodSec < 0 {
+		obj.error(fieldError("SetTCPStartup", "failureThreshold and periodSec are not allowed to be negative"))
+		return obj.builder
+	}
+	obj.error(setStartup(obj.template(), startupProbe(tcpProbe(host, FromInt(port), 0, 0, periodSec), failureThreshold)))
+	return obj.builder
+}
+
+// SetStartupOptions set the options of the startup probe,eg: FailureThreshold,
+// call it after SetXXXStartup(),only **first container** is set like SetXXXStartup().
+func (obj PodSetters[B]) SetStartupOptions(opts ProbeOptions) B {
+	obj.error(setProbeOptions(obj.template(), "Startup", opts))
+	return obj.builder
+}
+
+// SetReadnessOptions set the options of the readiness probe,eg: SuccessThreshold and FailureThreshold,
+// call it after SetXXXReadness(),only **first container** is set like SetXXXReadness().
+func (obj PodSetters[B]) SetReadnessOptions(opts ProbeOptions) B {
+	obj.error(setProbeOptions(obj.template(), "Readness", opts))
+	return obj.builder
+}
+
+// SetCMDLiveness set container liveness of cmd style
+// cmd: execute liveness probe as commond line
+// timeoutSec: http request timeout seconds,defaults to 1 second. Minimum value is 1.
+// periodSec: how often does the probe??defaults to 1 second. Minimum value is 1,Except for the first time?
+// headers: headers[0] is HTTP Header, do not fill if you do not need to set
+// on the other hand, only **first container** will be set livenessProbe
+func (obj PodSetters[B]) SetCMDLiveness(cmd []string, initDelaySec, timeoutSec, periodSec int32) B {
+	obj.error(setLiveness(obj.template(), cmdProbe(cmd, initDelaySec, timeoutSec, periodSec)))
+	return obj.builder
+}
+
+// SetTCPLiveness set container liveness of tcp style
+// host: default is ""
+// port: required
+// timeoutSec: http request timeout seconds,defaults to 1 second. Minimum value is 1.
+// periodSec: how often does the probe??defaults to 1 second. Minimum value is 1,Except for the first time?
+// headers: headers[0] is HTTP Header, do not fill if you do not need to set
+// on the other hand, only **first container** will be set livenessProbe
+func (obj PodSetters[B]) SetTCPLiveness(host string, port int, initDelaySec, timeoutSec, periodSec int32) B {
+	obj.error(setLiveness(obj.template(), tcpProbe(host, FromInt(port), initDelaySec, timeoutSec, periodSec)))
+	return obj.builder
+}
+
+// SetCommand set the entrypoint of the container,it overrides ENTRYPOINT of the image,only **first container** will be set
+func (obj PodSetters[B]) SetCommand(command []string) B {
+	obj.error(setCommand(obj.template(), command))
+	return obj.builder
+}
+
+// SetArgs set the arguments of the entrypoint,it overrides CMD of the image,only **first container** will be set
+func (obj PodSetters[B]) SetArgs(args []string) B {
+	obj.error(setArgs(obj.template(), args))
+	return obj.builder
+}
+
+// SetPostStartExec execute cmd in the container immediately after it is created,
+// the container is killed when cmd fails,only **first container** will be set postStart hook
+func (obj PodSetters[B]) SetPostStartExec(cmd []string) B {
+	obj.error(setLifecycle(obj.template(), "SetPostStartExec", execHandler(cmd)))
+	return obj.builder
+}
+
+// SetPostStartHTTP request the http path of the container port immediately after it is created,
+// only **first container** will be set postStart hook
+func (obj PodSetters[B]) SetPostStartHTTP(port int, path string) B {
+	obj.error(setLifecycle(obj.template(), "SetPostStartHTTP", httpHandler(port, path)))
+	return obj.builder
+}
+
+// SetPreStopExec execute cmd in the container before it is terminated,only **first container** will be set preStop hook
+func (obj PodSetters[B]) SetPreStopExec(cmd []string) B {
+	obj.error(setLifecycle(obj.template(), "SetPreStopExec", execHandler(cmd)))
+	return obj.builder
+}
+
+// SetPreStopHTTP request the http path of the container port before it is terminated,eg: /shutdown,
+// only **first container** will be set preStop hook
+func (obj PodSetters[B]) SetPreStopHTTP(port int, path string) B {
+	obj.error(setLifecycle(obj.template(), "SetPreStopHTTP", httpHandler(port, path)))
+	return obj.builder
+}
+
+// SetHTTPReadness set container readness
+// initDelaySec: how long time after the first start of the program the probe is executed for the first time.(sec)
+// timeoutSec: http request timeout seconds,defaults to 1 second. Minimum value is 1.
+// periodSec: how often does the probe??defaults to 1 second. Minimum value is 1,Except for the first time?
+// on the other hand, only **first container** will be set livenessProbe
+func (obj PodSetters[B]) SetHTTPReadness(port int, path string, initDelaySec, timeoutSec, periodSec int32, headers ...map[string]string) B {
+	obj.error(setReadness(obj.template(), httpProbe(FromInt(port), path, initDelaySec, timeoutSec, periodSec, headers...)))
+	return obj.builder
+}
+
+// SetCMDReadness set container readness of cmd style
+// cmd: execute readness probe as commond line
+// timeoutSec: http request timeout seconds,defaults to 1 second. Minimum value is 1.
+// periodSec: how often does the probe? defaults to 1 second. Minimum value is 1,Except for the first time?
+// headers: headers[0] is HTTP Header, do not fill if you do not need to set
+// on the other hand, only **first container** will be set livenessProbe
+func (obj PodSetters[B]) SetCMDReadness(cmd []string, initDelaySec, timeoutSec, periodSec int32) B {
+	obj.error(setReadness(obj.template(), cmdProbe(cmd, initDelaySec, timeoutSec, periodSec)))
+	return obj.builder
+}
+
+// SetTCPReadness set container readness of tcp style
+// host: default is ""
+// port: required
+// timeoutSec: http request timeout seconds,defaults to 1 second. Minimum value is 1.
+// periodSec: how often does the probe? defaults to 1 second. Minimum value is 1,Except for the first time?
+// headers: headers[0] is HTTP Header, do not fill if you do not need to set
+// on the other hand, only **first container** will be set livenessProbe
+func (obj PodSetters[B]) SetTCPReadness(host string, port int, initDelaySec, timeoutSec, periodSec int32) B {
+	obj.error(setReadness(obj.template(), tcpProbe(host, FromInt(port), initDelaySec, timeoutSec, periodSec)))
+	return obj.builder
+}
+
+// SetNamedHTTPLiveness set container liveness of http style and the probe port is a container port name,
+// so the probe is still right when the port number is changed.
+// portName: required, must be the name of a port declared on the first container
+// other params same as SetHTTPLiveness
+func (obj PodSetters[B]) SetNamedHTTPLiveness(portName, path string, initDelaySec, timeoutSec, periodSec int32, headers ...map[string]string) B {
+	if !verifyString(portName) {
+		obj.error(fieldError("SetNamedHTobj.template()iveness", "portName is not allowed to be empty"))
+		return obj.builder
+	}
+	obj.error(setLiveness(obj.template(), httpProbe(FromString(portName), path, initDelaySec, timeoutSec, periodSec, headers...)))
+	return obj.builder
+}
+
+// SetNamedTCPLiveness set container liveness of tcp style and the probe port is a container port name
+// portName: required, must be the name of a port declared on the first container
+// other params same as SetTCPLiveness
+func (obj PodSetters[B]) SetNamedTCPLiveness(host, portName string, initDelaySec, timeoutSec, periodSec int32) B {
+	if !verifyString(portName) {
+		obj.error(fieldError("SetNamedTCPLiveness", "portName is not allowed to be empty"))
+		return obj.builder
+	}
+	obj.error(setLiveness(obj.template(), tcpProbe(host, FromString(portName), initDelaySec, timeoutSec, periodSec)))
+	return obj.builder
+}
+
+// SetNamedHTTPReadness set container readness of http style and the probe port is a container port name
+// portName: required, must be the name of a port declared on the first container
+// other params same as SetHTTPReadness
+func (obj PodSetters[B]) SetNamedHTTPReadness(portName, path string, initDelaySec, timeoutSec, periodSec int32, headers ...map[string]string) B {
+	if !verifyString(portName) {
+		obj.error(fieldError("SetNamedHTTPReadness", "portName is not allowed to be empty"))
+		return obj.builder
+	}
+	obj.error(setReadness(obj.template(), httpProbe(FromString(portName), path, initDelaySec, timeoutSec, periodSec, headers...)))
+	return obj.builder
+}
+
+// SetNamedTCPReadness set container readness of tcp style and the probe port is a container port name
+// portName: required, must be the name of a port declared on the first container
+// other params same as SetTCPReadness
+func (obj PodSetters[B]) SetNamedTCPReadness(host, portName string, initDelaySec, timeoutSec, periodSec int32) B {
+	if !verifyString(portName) {
+		obj.error(fieldError("SetNamedTCPReadness", "portName is not allowed to be empty"))
+		return obj.builder
+	}
+	obj.error(setReadness(obj.template(), tcpProbe(host, FromString(portName), initDelaySec, timeoutSec, periodSec)))
+	return obj.builder
+}
+
+// SetImagePullSecrets add the Secrets of kubernetes.io/dockerconfigjson type which are used to pull the images
+func (obj PodSetters[B]) SetImagePullSecrets(secretNames ...string) B {
+	obj.error(setImagePullSecrets(obj.template(), secretNames))
+	return obj.builder
+}
+
+// SetImagePullPolicyFor set the pull policy of the container named container,eg: Always for the sidecar of latest tag,
+// it is overridden by SetImagePullPolicy() and ImagePullPolicy().
+func (obj PodSetters[B]) SetImagePullPolicyFor(container, policy string) B {
+	obj.error(setImagePullPolicyFor(obj.template(), container, policy))
+	return obj.builder
+}
+
+// SetPodPriorityClass set Pod Priority
+// priorityClassName is Kubernetes resource object PriorityClass name
+// priorityClassName must already exists in kubernetes cluster
+//
+// Deprecated: use SetPriorityClassName()
+func (obj PodSetters[B]) SetPodPriorityClass(priorityClassName string) B {
+	obj.error(setPodPriorityClass(obj.template(), priorityClassName))
+	return obj.builder
+}
+
+// SetServiceAccount set the ServiceAccount of Pod,it must be in the same namespace as the workload
+func (obj PodSetters[B]) SetServiceAccount(name string) B {
+	obj.error(setServiceAccount(obj.template(), name))
+	return obj.builder
+}
+
+// SetPriorityClassName set the PriorityClass of the Pods of PodTemplate,the PriorityClass must exist in Kubernetes,
+// it is created by NewPriorityClass().
+func (obj PodSetters[B]) SetPriorityClassName(name string) B {
+	obj.error(setPriorityClassName(obj.template(), name))
+	return obj.builder
+}
+
+// SetRuntimeClassName set the RuntimeClass of the Pods of PodTemplate,eg: gvisor for the sandboxed containers
+func (obj PodSetters[B]) SetRuntimeClassName(name string) B {
+	obj.error(setRuntimeClassName(obj.template(), name))
+	return obj.builder
+}
+
+// SetTerminationGracePeriodSeconds set the seconds which the Pods of PodTemplate wait for the containers to exit after SIGTERM,
+// Kubernetes defaults it to 30.
+func (obj PodSetters[B]) SetTerminationGracePeriodSeconds(seconds int64) B {
+	obj.error(setTerminationGracePeriodSeconds(obj.template(), seconds))
+	return obj.builder
+}
+
+// SetSchedulerName set the scheduler of the Pods of PodTemplate,the default scheduler is used when it is not set
+func (obj PodSetters[B]) SetSchedulerName(name string) B {
+	obj.error(setSchedulerName(obj.template(), name))
+	return obj.builder
+}
+
+// AddHostAlias add the hostnames of ip into /etc/hosts of the Pods of PodTemplate,eg: the internal hostnames of legacy services
+func (obj PodSetters[B]) AddHostAlias(ip string, hostnames ...string) B {
+	obj.error(addHostAlias(obj.template(), ip, hostnames))
+	return obj.builder
+}
+
+// SetDNSPolicy set the DNS policy of the Pods of PodTemplate,policy is ClusterFirst,ClusterFirstWithHostNet,Default or None
+func (obj PodSetters[B]) SetDNSPolicy(policy string) B {
+	obj.error(setDNSPolicy(obj.template(), policy))
+	return obj.builder
+}
+
+// SetDNSConfig set the nameservers,search domains and options of resolv.conf of the Pods of PodTemplate,
+// options is the option name and value,eg: map[string]string{"ndots": "2"}.
+func (obj PodSetters[B]) SetDNSConfig(nameservers, searches []string, options map[string]string) B {
+	obj.error(setDNSConfig(obj.template(), nameservers, searches, options))
+	return obj.builder
+}
+
+// SetHostNetwork set the Pods of PodTemplate use the network namespace of the node,
+// the DNS policy is ClusterFirstWithHostNet when it is not set.
+func (obj PodSetters[B]) SetHostNetwork(hostNetwork bool) B {
+	setHostNetwork(obj.template(), hostNetwork)
+	return obj.builder
+}
+
+// SetResizePolicy set the in-place resize policy of cpu and memory of the container,
+// policy is ResizeNotRequired or ResizeRestartContainer,empty policy is not set,
+// eg: SetResizePolicy("app", ResizeNotRequired, ResizeRestartContainer) restarts the container only when memory is resized.
+func (obj PodSetters[B]) SetResizePolicy(container, cpuPolicy, memPolicy string) B {
+	obj.error(setResizePolicy(obj.template(), container, cpuPolicy, memPolicy))
+	return obj.builder
+}
+
+// AddResourceClaim declare the Dynamic Resource Allocation claim of the Pods,
+// name is the claim name used by UseResourceClaim(),claimTemplate is ResourceClaimTemplate name in the same namespace,
+// every Pod gets its own ResourceClaim created from the template,eg: one GPU per Pod.
+func (obj PodSetters[B]) AddResourceClaim(name, claimTemplate string) B {
+	obj.error(addResourceClaim(obj.template(), name, claimTemplate))
+	return obj.builder
+}
+
+// UseResourceClaim make the containers use the resource claim declared by AddResourceClaim(),
+// the first container is used when containers is empty, call it after SetContainer().
+func (obj PodSetters[B]) UseResourceClaim(name string, containers ...string) B {
+	obj.error(useResourceClaim(obj.template(), name, containers...))
+	return obj.builder
+}
+
+// SetConfigMapVolume declare the volume of ConfigMap,mount it by SetVolumeMount() with volumeName
+func (obj PodSetters[B]) SetConfigMapVolume(volumeName, configMapName string, opts ...VolumeOptions) B {
+	obj.error(setConfigMapVolume(obj.template(), volumeName, configMapName, opts))
+	return obj.builder
+}
+
+// SetSecretVolume declare the volume of Secret,mount it by SetVolumeMount() with volumeName
+func (obj PodSetters[B]) SetSecretVolume(volumeName, secretName string, opts ...VolumeOptions) B {
+	obj.error(setSecretVolume(obj.template(), volumeName, secretName, opts))
+	return obj.builder
+}
+
+// SetEmptyDirVolume declare the empty directory volume which lives as long as Pod,mount it by SetVolumeMount() with volumeName
+func (obj PodSetters[B]) SetEmptyDirVolume(volumeName, medium, sizeLimit string) B {
+	obj.error(setEmptyDirVolume(obj.template(), volumeName, medium, sizeLimit))
+	return obj.builder
+}
+
+// SetHostPathVolume declare the volume of the directory or file on the node,mount it by SetVolumeMount() with volumeName,
+// hostPathType is one of DirectoryOrCreate,Directory,FileOrCreate,File,Socket,CharDevice,BlockDevice,empty is not checked.
+func (obj PodSetters[B]) SetHostPathVolume(volumeName, path, hostPathType string) B {
+	obj.error(setHostPathVolume(obj.template(), volumeName, path, hostPathType))
+	return obj.builder
+}
+
+// SetNFSVolume declare the volume of the path exported by NFS server,mount it by SetVolumeMount() with volumeName
+func (obj PodSetters[B]) SetNFSVolume(volumeName, server, path string, readOnly bool) B {
+	obj.error(setNFSVolume(obj.template(), volumeName, server, path, readOnly))
+	return obj.builder
+}
+
+// SetDownwardAPIVolume declare the volume of Pod fields,mount it by SetVolumeMount() with volumeName,
+// fields is the relative file path to the field path,eg: map[string]string{"labels": "metadata.labels"}.
+func (obj PodSetters[B]) SetDownwardAPIVolume(volumeName string, fields map[string]string) B {
+	obj.error(setDownwardAPIVolume(obj.template(), volumeName, fields))
+	return obj.builder
+}
+
+// SetProjectedVolume declare the volume which projects several ConfigMaps,Secrets and service account token into one directory,
+// mount it by SetVolumeMount() with volumeName,eg: SetProjectedVolume("all", ProjectedSource{ConfigMap: "conf"}, ProjectedSource{Secret: "creds"}).
+func (obj PodSetters[B]) SetProjectedVolume(volumeName string, sources ...ProjectedSource) B {
+	obj.error(setProjectedVolume(obj.template(), volumeName, sources))
+	return obj.builder
+}
+
+// SetVolumeMount mount the volume of any type on container,only **first container** will be mounted
+func (obj PodSetters[B]) SetVolumeMount(volumeName, mountPath string) B {
+	obj.error(setVolumeMount(obj.template(), volumeName, mountPath))
+	return obj.builder
+}
+
+// SetFSGroup set fsGroup of Pod security context,the mounted volumes are owned by the group gid
+func (obj PodSetters[B]) SetFSGroup(gid int64) B {
+	obj.error(setFSGroup(obj.template(), gid))
+	return obj.builder
+}
+
+// SetFSGroupChangePolicy set how the ownership of volumes is changed to fsGroup,
+// policy is FSGroupChangeOnRootMismatch or FSGroupChangeAlways,it is used with SetFSGroup().
+func (obj PodSetters[B]) SetFSGroupChangePolicy(policy string) B {
+	obj.error(setFSGroupChangePolicy(obj.template(), policy))
+	return obj.builder
+}
+
+// SetSupplementalGroups set the groups of the first process of every container besides its primary group
+func (obj PodSetters[B]) SetSupplementalGroups(gids ...int64) B {
+	obj.error(setSupplementalGroups(obj.template(), gids))
+	return obj.builder
+}
+
+// ApplyRestrictedSecurityProfile harden the Pod to match the restricted Pod Security Standard in one call:
+// runAsNonRoot,seccomp RuntimeDefault,drop ALL capabilities,no privilege escalation and read-only root filesystem
+// on all containers,call it after the containers are set,the application can only write into volumes.
+func (obj PodSetters[B]) ApplyRestrictedSecurityProfile() B {
+	obj.error(applyRestrictedSecurityProfile(obj.template()))
+	return obj.builder
+}
+
+// SetCapabilities set the linux capabilities to add and drop of the container,the "CAP_" prefix is optional,
+// eg: SetCapabilities("app", []string{"NET_BIND_SERVICE"}, []string{"ALL"}),it replaces the capabilities set before.
+func (obj PodSetters[B]) SetCapabilities(container string, add, drop []string) B {
+	obj.error(setCapabilities(obj.template(), container, add, drop))
+	return obj.builder
+}
+
+// SetPodSecurityContext set the user,group and fsGroup of all containers of Pod,
+// runAsNonRoot is true the container running as root is refused to start,eg: SetPodSecurityContext(1000, 3000, 2000, true).
+// zero means unset,because id 0 is root,so the ones set before are kept,eg: SetPodSecurityContext(1000, 0, 0, true) keeps SetFSGroup().
+func (obj PodSetters[B]) SetPodSecurityContext(runAsUser, runAsGroup, fsGroup int64, runAsNonRoot bool) B {
+	obj.error(setPodSecurityContext(obj.template(), runAsUser, runAsGroup, fsGroup, runAsNonRoot))
+	return obj.builder
+}
+
+// SetContainerSecurityContext set the user and group of the container,they override SetPodSecurityContext()
+func (obj PodSetters[B]) SetContainerSecurityContext(container string, runAsUser, runAsGroup int64, runAsNonRoot bool) B {
+	obj.error(setContainerSecurityContext(obj.template(), container, runAsUser, runAsGroup, runAsNonRoot))
+	return obj.builder
+}
+
+// AddCapabilities add the linux capabilities to the container,the capabilities set before are kept,
+// eg: AddCapabilities("app", "NET_BIND_SERVICE").
+func (obj PodSetters[B]) AddCapabilities(container string, capabilities ...string) B {
+	obj.error(addCapabilities(obj.template(), "AddCapabilities", container, capabilities, false))
+	return obj.builder
+}
+
+// DropCapabilities drop the linux capabilities of the container,the capabilities set before are kept,
+// eg: DropCapabilities("app", "ALL").
+func (obj PodSetters[B]) DropCapabilities(container string, capabilities ...string) B {
+	obj.error(addCapabilities(obj.template(), "DropCapabilities", container, capabilities, true))
+	return obj.builder
+}
+
+// SetPrivileged set the container privileged,the privileged container has all capabilities of the host,
+// Lint() warns it.
+func (obj PodSetters[B]) SetPrivileged(container string, privileged bool) B {
+	obj.error(setContainerSecurityFlag(obj.template(), "SetPrivileged", container, privileged,
+		func(sc *v1.SecurityContext, value *bool) { sc.Privileged = value }))
+	return obj.builder
+}
+
+// SetAllowPrivilegeEscalation set whether the process of the container can gain more privileges than its parent
+func (obj PodSetters[B]) SetAllowPrivilegeEscalation(container string, allow bool) B {
+	obj.error(setContainerSecurityFlag(obj.template(), "SetAllowPrivilegeEscalation", container, allow,
+		func(sc *v1.SecurityContext, value *bool) { sc.AllowPrivilegeEscalation = value }))
+	return obj.builder
+}
+
+// SetReadOnlyRootFilesystem set the root filesystem of the container read-only
+func (obj PodSetters[B]) SetReadOnlyRootFilesystem(container string, readOnly bool) B {
+	obj.error(setContainerSecurityFlag(obj.template(), "SetReadOnlyRootFilesystem", container, readOnly,
+		func(sc *v1.SecurityContext, value *bool) { sc.ReadOnlyRootFilesystem = value }))
+	return obj.builder
+}
+
+// SetSELinuxOptions set SELinux label of all containers of Pod,empty field is not set,
+// eg: SetSELinuxOptions("", "", "", "s0:c123,c456") for the MCS label of volumes.
+func (obj PodSetters[B]) SetSELinuxOptions(user, role, type_, level string) B {
+	obj.error(setPodSELinuxOptions(obj.template(), user, role, type_, level))
+	return obj.builder
+}
+
+// SetContainerSELinuxOptions set SELinux label of the container,it overrides SetSELinuxOptions()
+func (obj PodSetters[B]) SetContainerSELinuxOptions(container, user, role, type_, level string) B {
+	obj.error(setContainerSELinuxOptions(obj.template(), container, user, role, type_, level))
+	return obj.builder
+}
+
+// TargetArch require the Pod is scheduled on the nodes of arches,eg: TargetArch("arm64") for arm64-only image,
+// it sets kubernetes.io/arch node affinity and tolerates the kubernetes.io/arch taint of the arches.
+func (obj PodSetters[B]) TargetArch(arches ...string) B {
+	obj.error(setTargetArch(obj.template(), arches))
+	return obj.builder
+}
+
+// RunOnSpot run the Pod on the spot nodes of the provider registered by RegisterSpotProvider() to save cost,
+// required is true the Pod only runs on spot nodes,false it prefers them but can run on on-demand nodes.
+// spot nodes can be reclaimed at any time,Lint() suggests a PodDisruptionBudget for it.
+func (obj PodSetters[B]) RunOnSpot(required bool) B {
+	obj.error(setRunOnSpot(obj.template(), required))
+	return obj.builder
+}
+
+// SetNodeSelector set the node labels which the node must have to run the Pod,eg: {"disktype": "ssd"},nil removes it
+func (obj PodSetters[B]) SetNodeSelector(selector map[string]string) B {
+	obj.error(setNodeSelector(obj.template(), selector))
+	return obj.builder
+}
+
+// SetNodeAffinity require the node whose label key matches operator and values to run the Pod,
+// operator is In,NotIn,Exists,DoesNotExist,Gt or Lt,eg: SetNodeAffinity("node.kubernetes.io/instance-type", "In", "m5.large", "m5.xlarge").
+func (obj PodSetters[B]) SetNodeAffinity(key, operator string, values ...string) B {
+	obj.error(setNodeAffinity(obj.template(), "SetNodeAffinity", 0, key, operator, values))
+	return obj.builder
+}
+
+// PreferNodeAffinity prefer the node whose label key matches operator and values,the usage is the same as SetNodeAffinity(),
+// weight is 1-100,the node with higher total weight is preferred.
+func (obj PodSetters[B]) PreferNodeAffinity(weight int32, key, operator string, values ...string) B {
+	obj.error(setNodeAffinity(obj.template(), "PreferNodeAffinity", weight, key, operator, values))
+	return obj.builder
+}
+
+// SetPodAffinity require the Pod runs in the same topology domain with the Pods selected by labels,
+// eg: SetPodAffinity("kubernetes.io/hostname", map[string]string{"app": "cache"}) runs it on the node of the cache.
+func (obj PodSetters[B]) SetPodAffinity(topologyKey string, labels map[string]string) B {
+	obj.error(setPodAffinity(obj.template(), "SetPodAffinity", false, 0, topologyKey, labels))
+	return obj.builder
+}
+
+// PreferPodAffinity prefer the Pod runs in the same topology domain with the Pods selected by labels,weight is 1-100
+func (obj PodSetters[B]) PreferPodAffinity(weight int32, topologyKey string, labels map[string]string) B {
+	obj.error(setPodAffinity(obj.template(), "PreferPodAffinity", false, weight, topologyKey, labels))
+	return obj.builder
+}
+
+// SetPodAntiAffinity require the Pod doesn't run in the same topology domain with the Pods selected by labels,
+// eg: SetPodAntiAffinity("kubernetes.io/hostname", podLabels) runs the replicas on different nodes.
+func (obj PodSetters[B]) SetPodAntiAffinity(topologyKey string, labels map[string]string) B {
+	obj.error(setPodAffinity(obj.template(), "SetPodAntiAffinity", true, 0, topologyKey, labels))
+	return obj.builder
+}
+
+// PreferPodAntiAffinity prefer the Pod doesn't run in the same topology domain with the Pods selected by labels,weight is 1-100
+func (obj PodSetters[B]) PreferPodAntiAffinity(weight int32, topologyKey string, labels map[string]string) B {
+	obj.error(setPodAffinity(obj.template(), "PreferPodAntiAffinity", true, weight, topologyKey, labels))
+	return obj.builder
+}
+
+// SetTolerations replace the tolerations of the Pod,so it can run on the nodes with the matching taints,nil removes them
+func (obj PodSetters[B]) SetTolerations(tolerations []Toleration) B {
+	obj.error(setTolerations(obj.template(), tolerations))
+	return obj.builder
+}
+
+// SetTopologySpreadConstraint spread the Pods evenly across the domains of topologyKey,eg: kubernetes.io/hostname or
+// topology.kubernetes.io/zone,the number of Pods in any two domains differs by at most maxSkew,
+// whenUnsatisfiable is DoNotSchedule or ScheduleAnyway,the constraint of the same topologyKey is replaced,call it after SetLabels().
+func (obj PodSetters[B]) SetTopologySpreadConstraint(maxSkew int, topologyKey, whenUnsatisfiable string) B {
+	obj.error(setTopologySpreadConstraint(obj.template(), "SetTopologySpreadConstraint", maxSkew, topologyKey, whenUnsatisfiable))
+	return obj.builder
+}
+
+// SetPVClaim declare the volume of PersistentVolumeClaim,mount it by SetVolumeMount() with volumeName
+func (obj PodSetters[B]) SetPVClaim(volumeName, claimName string) B {
+	obj.error(setPVClaim(obj.template(), volumeName, claimName))
+	return obj.builder
+}
+
+// SetPVCMounts mount PersistentVolumeClaim on container
+// params:
+// volumeName:the param is SetPVClaim() function volumeName,and when you call SetPVCMounts function you must call SetPVClaim function,and no order.
+// on the other hand SetPVCMounts() function only mount first Container,and On the Container you can volumeMount many PersistentVolumeClaim.
+// mountPath: runtime container dir eg:/var/lib/mysql
+//
+// Deprecated: use SetVolumeMount(),it mounts the volume of any type.
+func (obj PodSetters[B]) SetPVCMounts(volumeName, mountPath string) B {
+	obj.error(setPVCMounts(obj.template(), volumeName, mountPath))
+	return obj.builder
+}
+
+// SetInitContainer add the init container,the init containers run in order and each must succeed before the containers
+// are started,eg: SetInitContainer("migrate", "app:v1", []string{"./migrate", "up"}),cmd is empty for the entrypoint of image.
+func (obj PodSetters[B]) SetInitContainer(name, image string, cmd []string) B {
+	obj.error(setInitContainer(obj.template(), name, image, cmd))
+	return obj.builder
+}
+
+// SetInitEnvs set the envs of the init container by its name,the env with the same name is replaced,the others are kept
+func (obj PodSetters[B]) SetInitEnvs(container string, envMap map[string]string) B {
+	obj.error(setInitEnvs(obj.template(), container, envMap))
+	return obj.builder
+}
+
+// SetInitVolumeMount mount the volume on the init container by its name,
+// eg: the emptyDir volume which is shared with the containers.
+func (obj PodSetters[B]) SetInitVolumeMount(container, volumeName, mountPath string) B {
+	obj.error(setInitVolumeMount(obj.template(), container, volumeName, mountPath))
+	return obj.builder
+}
+
+// SetResourceLimits set cpu and memory limit of all containers,eg: SetResourceLimits("500m", "512Mi"),
+// the empty one is not set,the other resources are kept,the quantity is checked when it is set.
+func (obj PodSetters[B]) SetResourceLimits(cpu, memory string) B {
+	obj.error(setCPUMemory(obj.template(), "SetResourceLimits", cpu, memory, true))
+	return obj.builder
+}
+
+// SetResourceRequests set cpu and memory request of all containers,the usage is the same as SetResourceLimits()
+func (obj PodSetters[B]) SetResourceRequests(cpu, memory string) B {
+	obj.error(setCPUMemory(obj.template(), "SetResourceRequests", cpu, memory, false))
+	return obj.builder
+}
+
+// SetExtendedResource set the extended resource of first container,eg: SetExtendedResource(ResourceGPU, "1"),
+// extended resource is an integer and can't be overcommitted,so both its limit and request are set.
+func (obj PodSetters[B]) SetExtendedResource(name ResourceName, quantity string) B {
+	obj.error(setExtendedResource(obj.template(), name, quantity))
+	return obj.builder
+}
+
+// SetEnvFromResourceField add the environment variable of the container which value is its own resource by downward API,
+// resource is limits.cpu,limits.memory,requests.cpu,requests.memory,etc,divisor is optional,
+// eg: SetEnvFromResourceField("GOMAXPROCS", "app", "limits.cpu", "1") and ("MEM_LIMIT_MB", "app", "limits.memory", "1Mi").
+func (obj PodSetters[B]) SetEnvFromResourceField(envName, container, resource, divisor string) B {
+	obj.error(setEnvFromResourceField(obj.template(), envName, container, resource, divisor))
+	return obj.builder
+}
+
+// SetEnvs set Pod Environmental variable,the envs are added into all containers,
+// the variable with the same name is replaced and the others are kept.
+func (obj PodSetters[B]) SetEnvs(envMap map[string]string) B {
+	obj.error(setEnvs(obj.template(), envMap))
+	return obj.builder
+}
+
+// AddEnv add the environment variable,the variable with the same name is replaced,only **first container** will be set
+func (obj PodSetters[B]) AddEnv(name, value string) B {
+	obj.error(addEnv(obj.template(), "AddEnv", v1.EnvVar{Name: name, Value: value}))
+	return obj.builder
+}
+
+// AddEnvFromFieldRef add the environment variable which value is the field of Pod by downward API,
+// fieldPath is metadata.name,metadata.namespace,spec.nodeName,status.podIP,metadata.labels['app'],etc,
+// eg: AddEnvFromFieldRef("POD_IP", "status.podIP"),only **first container** will be set
+func (obj PodSetters[B]) AddEnvFromFieldRef(name, fieldPath string) B {
+	obj.error(addEnvFromFieldRef(obj.template(), name, fieldPath))
+	return obj.builder
+}
+
+// AddEnvFromResourceField add the environment variable which value is the resource of the first container,
+// params same as SetEnvFromResourceField(),eg: AddEnvFromResourceField("GOMAXPROCS", "limits.cpu", "1").
+func (obj PodSetters[B]) AddEnvFromResourceField(name, resource, divisor string) B {
+	if len(obj.template().Spec.Containers) < 1 {
+		obj.error(fieldError("AddEnvFromResourceField", "the container is not set,you can call SetContainer() first"))
+		return obj.builder
+	}
+	obj.error(setEnvFromResourceField(obj.template(), name, obj.template().Spec.Containers[0].Name, resource, divisor))
+	return obj.builder
+}
+
+// AddEnvFromSecretKey add the environment variable which value is the key of Secret in the same namespace,
+// eg: AddEnvFromSecretKey("DB_PASSWORD", "mysql", "password"),only **first container** will be set
+func (obj PodSetters[B]) AddEnvFromSecretKey(name, secretName, key string) B {
+	obj.error(addEnvFromKey(obj.template(), "AddEnvFromSecretKey", name, secretName, key, true))
+	return obj.builder
+}
+
+// AddEnvFromConfigMapKey add the environment variable which value is the key of ConfigMap in the same namespace,
+// only **first container** will be set
+func (obj PodSetters[B]) AddEnvFromConfigMapKey(name, configMapName, key string) B {
+	obj.error(addEnvFromKey(obj.template(), "AddEnvFromConfigMapKey", name, configMapName, key, false))
+	return obj.builder
+}
+
+// SetEnvFromConfigMap add all keys of the ConfigMap as environment variables of first container,
+// the ConfigMap must be in the same namespace,the Pod is not started until it exists.
+func (obj PodSetters[B]) SetEnvFromConfigMap(configMapName string) B {
+	obj.error(setEnvFrom(obj.template(), "SetEnvFromConfigMap", configMapName, v1.EnvFromSource{
+		ConfigMapRef: &v1.ConfigMapEnvSource{LocalObjectReference: v1.LocalObjectReference{Name: configMapName}},
+	}))
+	return obj.builder
+}
+
+// SetEnvFromSecret add all keys of the Secret as environment variables of first container,
+// the Secret must be in the same namespace,the Pod is not started until it exists.
+func (obj PodSetters[B]) SetEnvFromSecret(secretName string) B {
+	obj.error(setEnvFrom(obj.template(), "SetEnvFromSecret", secretName, v1.EnvFromSource{
+		SecretRef: &v1.SecretEnvSource{LocalObjectReference: v1.LocalObjectReference{Name: secretName}},
+	}))
+	return obj.builder
+}
+
+// SetHTTPLivenessFor set the liveness of http style on the container named container,
+// the other params are the same as SetHTTPLiveness(),eg: the liveness of the sidecar.
+func (obj PodSetters[B]) SetHTTPLivenessFor(container string, port int, path string, initDelaySec, timeoutSec, periodSec int32, headers ...map[string]string) B {
+	obj.error(setLivenessFor(obj.template(), container, httpProbe(FromInt(port), path, initDelaySec, timeoutSec, periodSec, headers...)))
+	return obj.builder
+}
+
+// SetCMDLivenessFor set the liveness of cmd style on the container named container
+func (obj PodSetters[B]) SetCMDLivenessFor(container string, cmd []string, initDelaySec, timeoutSec, periodSec int32) B {
+	obj.error(setLivenessFor(obj.template(), container, cmdProbe(cmd, initDelaySec, timeoutSec, periodSec)))
+	return obj.builder
+}
+
+// SetTCPLivenessFor set the liveness of tcp style on the container named container
+func (obj PodSetters[B]) SetTCPLivenessFor(container, host string, port int, initDelaySec, timeoutSec, periodSec int32) B {
+	obj.error(setLivenessFor(obj.template(), container, tcpProbe(host, FromInt(port), initDelaySec, timeoutSec, periodSec)))
+	return obj.builder
+}
+
+// SetHTTPReadnessFor set the readness of http style on the container named container
+func (obj PodSetters[B]) SetHTTPReadnessFor(container string, port int, path string, initDelaySec, timeoutSec, periodSec int32, headers ...map[string]string) B {
+	obj.error(setReadnessFor(obj.template(), container, httpProbe(FromInt(port), path, initDelaySec, timeoutSec, periodSec, headers...)))
+	return obj.builder
+}
+
+// SetCMDReadnessFor set the readness of cmd style on the container named container
+func (obj PodSetters[B]) SetCMDReadnessFor(container string, cmd []string, initDelaySec, timeoutSec, periodSec int32) B {
+	obj.error(setReadnessFor(obj.template(), container, cmdProbe(cmd, initDelaySec, timeoutSec, periodSec)))
+	return obj.builder
+}
+
+// SetTCPReadnessFor set the readness of tcp style on the container named container
+func (obj PodSetters[B]) SetTCPReadnessFor(container, host string, port int, initDelaySec, timeoutSec, periodSec int32) B {
+	obj.error(setReadnessFor(obj.template(), container, tcpProbe(host, FromInt(port), initDelaySec, timeoutSec, periodSec)))
+	return obj.builder
+}
+
+// SetEnvsFor set the envs of the container by its name,the env with the same name is replaced
+func (obj PodSetters[B]) SetEnvsFor(container string, envMap map[string]string) B {
+	obj.error(setEnvsFor(obj.template(), container, envMap))
+	return obj.builder
+}
+
+// SetPVCMountsFor mount the volume on the container named container,
+// eg: the log volume shared by the application and the log collector sidecar.
+func (obj PodSetters[B]) SetPVCMountsFor(container, volumeName, mountPath string) B {
+	obj.error(setPVCMountsFor(obj.template(), container, volumeName, mountPath))
+	return obj.builder
+}
+
+// InjectSidecar append the container of sidecar,eg: the log shipper defined once for all workloads,
+// the shared volumes of sidecar are mounted on all containers,so call it after SetContainer().
+func (obj PodSetters[B]) InjectSidecar(sidecar Sidecar) B {
+	obj.error(injectSidecar(obj.template(), sidecar))
+	return obj.builder
+}
+
+// AddContainerPort add the named port on the container set by SetContainer(),protocol is TCP,UDP or SCTP,default TCP,
+// eg: AddContainerPort("grpc", 9090, "TCP").AddContainerPort("metrics", 9100, ""),the names can be the target ports of Service.
+func (obj PodSetters[B]) AddContainerPort(name string, port int32, protocol string) B {
+	obj.error(addFirstContainerPort(obj.template(), name, port, protocol))
+	return obj.builder
+}
+
+// AddContainerPortFor add the named port on the container named container,eg: the metrics port of the sidecar
+func (obj PodSetters[B]) AddContainerPortFor(container, name string, port int32, protocol string) B {
+	obj.error(addContainerPortFor(obj.template(), container, name, port, protocol))
+	return obj.builder
+}
+
+// SetResourceLimitFor set the resource limit of the container named container,it replaces the limit set before
+func (obj PodSetters[B]) SetResourceLimitFor(container string, limits map[ResourceName]string) B {
+	obj.error(setResourceLimitFor(obj.template(), container, limits))
+	return obj.builder
+}
+
+// SetResourceRequstFor set the resource request of the container named container,it replaces the request set before
+func (obj PodSetters[B]) SetResourceRequstFor(container string, requests map[ResourceName]string) B {
+	obj.error(setResourceRequestsFor(obj.template(), container, requests))
+	return obj.builder
+}
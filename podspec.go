@@ -4,59 +4,171 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
-func setImagePullSecrets(podTemp *v1.PodTemplateSpec, secretName string) {
-	if len(podTemp.Spec.ImagePullSecrets) <= 0 {
-		podTemp.Spec.ImagePullSecrets = []v1.LocalObjectReference{{Name: secretName}}
-		return
+// setImagePullSecrets add the Secrets of the private registries into the Pod,the added ones are skipped
+func setImagePullSecrets(podTemp *v1.PodTemplateSpec, secretNames []string) error {
+	if len(secretNames) <= 0 {
+		return fieldError("SetImagePullSecrets", "secret names are not allowed to be empty")
+	}
+	for _, name := range secretNames {
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return fieldErrorf("SetImagePullSecrets", "secret name %q is not allowed:%s", name, strings.Join(errs, ","))
+		}
+	}
+	for _, name := range secretNames {
+		found := false
+		for _, ref := range podTemp.Spec.ImagePullSecrets {
+			if ref.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			podTemp.Spec.ImagePullSecrets = append(podTemp.Spec.ImagePullSecrets, v1.LocalObjectReference{Name: name})
+		}
 	}
-	podTemp.Spec.ImagePullSecrets = append(podTemp.Spec.ImagePullSecrets, v1.LocalObjectReference{Name: secretName})
+	return nil
+}
+
+// verifyPullPolicy check the pull policy is Always,Never or IfNotPresent
+func verifyPullPolicy(method, policy string) error {
+	if _, ok := pullPolicys[policy]; !ok {
+		return fieldErrorf(method, "policy %s is not allowed,only Always,Never and IfNotPresent", policy)
+	}
+	return nil
+}
+
+func setImagePullPolicyFor(podTemp *v1.PodTemplateSpec, name, policy string) error {
+	if err := verifyPullPolicy("SetImagePullPolicyFor", policy); err != nil {
+		return err
+	}
+	container, err := namedContainer(podTemp, "SetImagePullPolicyFor", name)
+	if err != nil {
+		return err
+	}
+	container.ImagePullPolicy = pullPolicys[policy]
+	return nil
+}
+
+// containerPullPolicy get the pull policy of the container when the workload is finished,
+// the policy of ImagePullPolicy() or SetImagePullPolicy() in annotations overrides all containers,
+// otherwise the policy of the container is kept and the empty one is IfNotPresent.
+func containerPullPolicy(annotations map[string]string, current v1.PullPolicy) v1.PullPolicy {
+	if annotations[ImagePullPolicyKey] != "" {
+		return PullPolicy(annotations[ImagePullPolicyKey]).ToK8s()
+	}
+	if current != "" {
+		return current
+	}
+	return v1.PullIfNotPresent
 }
 
 // setContainer set container
 func setContainer(podTemp *v1.PodTemplateSpec, name, image string, containerPort int32) error {
 	// This must be a valid port number, 0 < x < 65536.
 	if containerPort <= 0 || containerPort >= 65536 {
-		return errors.New("SetContainer err, container Port range: 0 < containerPort < 65536")
+		return fieldError("SetContainer", "container Port range: 0 < containerPort < 65536")
 	}
 	if !verifyString(image) {
-		return errors.New("SetContainer err, image is not allowed to be empty")
+		return fieldError("SetContainer", "image is not allowed to be empty")
 
 	}
 	port := v1.ContainerPort{ContainerPort: containerPort}
-	container := v1.Container{
+	addContainer(podTemp, v1.Container{
 		Name:  name,
 		Image: image,
 		Ports: []v1.ContainerPort{port},
+	})
+	return nil
+}
+
+// setInitContainer append the init container,the init containers run in order and each must succeed
+// before the containers are started,eg: database migration or waiting for the dependency.
+func setInitContainer(podTemp *v1.PodTemplateSpec, name, image string, cmd []string) error {
+	if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return fieldErrorf("SetInitContainer", "name %q is not allowed:%s", name, strings.Join(errs, ","))
+	}
+	if !verifyString(image) {
+		return fieldError("SetInitContainer", "image is not allowed to be empty")
+	}
+	if containerIndex(podTemp.Spec.InitContainers, name) >= 0 || containerIndex(podTemp.Spec.Containers, name) >= 0 {
+		return fieldErrorf("SetInitContainer", "container name %q is duplicated", name)
+	}
+	podTemp.Spec.InitContainers = append(podTemp.Spec.InitContainers, v1.Container{
+		Name:    name,
+		Image:   image,
+		Command: append([]string(nil), cmd...),
+	})
+	return nil
+}
+
+// namedInitContainer get the init container by its name
+func namedInitContainer(podTemp *v1.PodTemplateSpec, method, name string) (*v1.Container, error) {
+	index := containerIndex(podTemp.Spec.InitContainers, name)
+	if index < 0 {
+		return nil, fieldErrorf(method, "init container %q is not found,you can call SetInitContainer() first", name)
+	}
+	return &podTemp.Spec.InitContainers[index], nil
+}
+
+// setInitEnvs add the envs into the init container,the env with the same name is replaced,the others are kept
+func setInitEnvs(podTemp *v1.PodTemplateSpec, name string, envMap map[string]string) error {
+	container, err := namedInitContainer(podTemp, "SetInitEnvs", name)
+	if err != nil {
+		return err
 	}
+	return mergeEnvs(container, envMap)
+}
+
+// setInitVolumeMount mount the volume on the init container
+func setInitVolumeMount(podTemp *v1.PodTemplateSpec, name, volumeName, mountPath string) error {
+	container, err := namedInitContainer(podTemp, "SetInitVolumeMount", name)
+	if err != nil {
+		return err
+	}
+	if !verifyString(volumeName) || !strings.HasPrefix(mountPath, "/") {
+		return fieldError("SetInitVolumeMount", "volumeName is not allowed to be empty and mountPath must be absolute")
+	}
+	container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{Name: volumeName, MountPath: mountPath})
+	return nil
+}
+
+// addContainer fill the container into the first container without image,
+// it is created by the probe setters called before SetContainer(),or append it.
+func addContainer(podTemp *v1.PodTemplateSpec, container v1.Container) {
 	containersLen := len(podTemp.Spec.Containers)
 	if containersLen < 1 {
-		podTemp.Spec.Containers = []v1.Container{container}
-		return nil
+		// the capacity kept by Reset() is reused
+		podTemp.Spec.Containers = append(podTemp.Spec.Containers[:0], container)
+		return
 	}
 	for index := 0; index < containersLen; index++ {
 		img := strings.TrimSpace(podTemp.Spec.Containers[index].Image)
 		if img == "" || len(img) <= 0 {
-			podTemp.Spec.Containers[index].Name = name
-			podTemp.Spec.Containers[index].Image = image
-			podTemp.Spec.Containers[index].Ports = []v1.ContainerPort{port}
-			return nil
+			podTemp.Spec.Containers[index].Name = container.Name
+			podTemp.Spec.Containers[index].Image = container.Image
+			podTemp.Spec.Containers[index].Ports = container.Ports
+			return
 		}
 	}
 	podTemp.Spec.Containers = append(podTemp.Spec.Containers, container)
-	return nil
 }
 
 func setResourceLimit(podTemp *v1.PodTemplateSpec, limits map[ResourceName]string) error {
 	data, err := ResourceMapsToK8s(limits)
 	if err != nil {
-		return fmt.Errorf("SetResourceLimit err:%v", err)
+		return fieldErrorf("SetResourceLimit", "%v", err)
 	}
 	containerLen := len(podTemp.Spec.Containers)
 	if containerLen < 1 {
@@ -74,7 +186,7 @@ func setResourceLimit(podTemp *v1.PodTemplateSpec, limits map[ResourceName]strin
 func setResourceRequests(podTemp *v1.PodTemplateSpec, requests map[ResourceName]string) error {
 	data, err := ResourceMapsToK8s(requests)
 	if err != nil {
-		return fmt.Errorf("SetResourceLimit err:%v", err)
+		return fieldErrorf("SetResourceLimit", "%v", err)
 	}
 	containerLen := len(podTemp.Spec.Containers)
 	if containerLen < 1 {
@@ -88,6 +200,111 @@ func setResourceRequests(podTemp *v1.PodTemplateSpec, requests map[ResourceName]
 	}
 	return nil
 }
+
+// setCPUMemory set cpu and memory of the limits or requests of all containers,the empty one is not set,
+// the other resources of the containers are kept,eg: the extended resources.
+func setCPUMemory(podTemp *v1.PodTemplateSpec, method, cpu, memory string, limits bool) error {
+	if cpu == "" && memory == "" {
+		return fieldErrorf(method, "cpu and memory are not allowed to be both empty")
+	}
+	list := make(v1.ResourceList, 2)
+	for name, value := range map[v1.ResourceName]string{v1.ResourceCPU: cpu, v1.ResourceMemory: memory} {
+		if value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(value)
+		if err != nil {
+			return fieldErrorf(method, "%s %q is not allowed:%v", name, value, err)
+		}
+		if quantity.Sign() <= 0 {
+			return fieldErrorf(method, "%s %q must be greater than 0", name, value)
+		}
+		list[name] = quantity
+	}
+	if len(podTemp.Spec.Containers) < 1 {
+		podTemp.Spec.Containers = []v1.Container{{}}
+	}
+	for index := range podTemp.Spec.Containers {
+		resources := &podTemp.Spec.Containers[index].Resources
+		if limits {
+			resources.Limits = mergeResourceList(resources.Limits, list)
+		} else {
+			resources.Requests = mergeResourceList(resources.Requests, list)
+		}
+	}
+	return nil
+}
+
+// setExtendedResource set the extended resource of the first container,eg: nvidia.com/gpu,
+// extended resource can't be overcommitted,so its request is the same as its limit.
+func setExtendedResource(podTemp *v1.PodTemplateSpec, name ResourceName, value string) error {
+	if err := verifyExtendedResourceName(string(name)); err != nil {
+		return err
+	}
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return fieldErrorf("SetExtendedResource", "quantity %q is not allowed:%v", value, err)
+	}
+	if quantity.Sign() <= 0 || quantity.MilliValue()%1000 != 0 {
+		return fieldErrorf("SetExtendedResource", "quantity %q must be a positive integer,extended resource can't be fractional", value)
+	}
+	if len(podTemp.Spec.Containers) < 1 {
+		podTemp.Spec.Containers = []v1.Container{{}}
+	}
+	list := v1.ResourceList{v1.ResourceName(name): quantity}
+	resources := &podTemp.Spec.Containers[0].Resources
+	resources.Limits = mergeResourceList(resources.Limits, list)
+	resources.Requests = mergeResourceList(resources.Requests, list)
+	return nil
+}
+
+// verifyExtendedResourceName check the name is fully-qualified and not in kubernetes.io domain,
+// the names in kubernetes.io domain are reserved for the native resources.
+func verifyExtendedResourceName(name string) error {
+	if !strings.Contains(name, "/") {
+		return fieldErrorf("SetExtendedResource", "name %q is not allowed,it must be fully-qualified,eg: nvidia.com/gpu", name)
+	}
+	if strings.HasPrefix(name, "kubernetes.io/") || strings.HasPrefix(name, "requests.") {
+		return fieldErrorf("SetExtendedResource", "name %q is not allowed,kubernetes.io domain and requests. prefix are reserved", name)
+	}
+	if errs := validation.IsQualifiedName(name); len(errs) > 0 {
+		return fieldErrorf("SetExtendedResource", "name %q is not allowed:%s", name, strings.Join(errs, ","))
+	}
+	return nil
+}
+
+// mergeResourceList copy list and overwrite it with the resources of update,
+// the list may be shared with other containers by SetResourceLimit(),so it is not changed.
+func mergeResourceList(list, update v1.ResourceList) v1.ResourceList {
+	merged := make(v1.ResourceList, len(list)+len(update))
+	for name, quantity := range list {
+		merged[name] = quantity
+	}
+	for name, quantity := range update {
+		merged[name] = quantity.DeepCopy()
+	}
+	return merged
+}
+
+// setEnvFrom add the envs from all keys of ConfigMap or Secret into the first container,
+// the same source is only added once,the keys which are not valid env names are skipped by Kubernetes.
+func setEnvFrom(podTemp *v1.PodTemplateSpec, method, name string, source v1.EnvFromSource) error {
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fieldErrorf(method, "name %q is not allowed:%s", name, strings.Join(errs, ","))
+	}
+	if len(podTemp.Spec.Containers) < 1 {
+		podTemp.Spec.Containers = []v1.Container{{}}
+	}
+	container := &podTemp.Spec.Containers[0]
+	for _, existing := range container.EnvFrom {
+		if reflect.DeepEqual(existing, source) {
+			return nil
+		}
+	}
+	container.EnvFrom = append(container.EnvFrom, source)
+	return nil
+}
+
 func setPodPriorityClass(podTemp *v1.PodTemplateSpec, priorityClassName string) error {
 	if !verifyString(priorityClassName) {
 		return errors.New("Set Pod PriorityClass err,priorityClassName is not allowed to be empty")
@@ -97,21 +314,162 @@ func setPodPriorityClass(podTemp *v1.PodTemplateSpec, priorityClassName string)
 
 }
 
+// setPriorityClassName set the PriorityClass of Pod,the Pods of higher priority preempt the lower ones when the nodes are full,
+// the PriorityClass is created by NewPriorityClass().
+func setPriorityClassName(podTemp *v1.PodTemplateSpec, name string) error {
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fieldErrorf("SetPriorityClassName", "name %q is not allowed:%s", name, strings.Join(errs, ","))
+	}
+	podTemp.Spec.PriorityClassName = name
+	return nil
+}
+
+// setRuntimeClassName set the RuntimeClass of Pod,eg: gvisor or kata for the sandboxed containers
+func setRuntimeClassName(podTemp *v1.PodTemplateSpec, name string) error {
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fieldErrorf("SetRuntimeClassName", "name %q is not allowed:%s", name, strings.Join(errs, ","))
+	}
+	podTemp.Spec.RuntimeClassName = &name
+	return nil
+}
+
+// setTerminationGracePeriodSeconds set the seconds which Pod waits for the containers to exit after SIGTERM,
+// the containers are killed after it,0 kills them at once,Kubernetes defaults it to 30.
+func setTerminationGracePeriodSeconds(podTemp *v1.PodTemplateSpec, seconds int64) error {
+	if seconds < 0 {
+		return fieldErrorf("SetTerminationGracePeriodSeconds", "seconds %d is not allowed to be negative", seconds)
+	}
+	podTemp.Spec.TerminationGracePeriodSeconds = &seconds
+	return nil
+}
+
+// setSchedulerName set the scheduler of Pod,the default scheduler is used when it is not set
+func setSchedulerName(podTemp *v1.PodTemplateSpec, name string) error {
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fieldErrorf("SetSchedulerName", "name %q is not allowed:%s", name, strings.Join(errs, ","))
+	}
+	podTemp.Spec.SchedulerName = name
+	return nil
+}
+
+// setServiceAccount set the ServiceAccount of Pod,the permissions of Pod are granted to the ServiceAccount by RoleBinding
+func setServiceAccount(podTemp *v1.PodTemplateSpec, name string) error {
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fieldErrorf("SetServiceAccount", "name %q is not allowed:%s", name, strings.Join(errs, ","))
+	}
+	podTemp.Spec.ServiceAccountName = name
+	return nil
+}
+
+// setEnvs add the envs into all containers,the env with the same name is replaced,the others are kept
 func setEnvs(podTemp *v1.PodTemplateSpec, envMap map[string]string) error {
 	envs, err := mapToEnvs(envMap)
 	if err != nil {
 		return err
 	}
-	containerLen := len(podTemp.Spec.Containers)
-	if containerLen < 1 {
+	if len(podTemp.Spec.Containers) < 1 {
 		podTemp.Spec.Containers = []v1.Container{{Env: envs}}
 		return nil
 	}
-	for index := 0; index < containerLen; index++ {
-		if podTemp.Spec.Containers[index].Env == nil {
-			podTemp.Spec.Containers[index].Env = envs
+	for index := range podTemp.Spec.Containers {
+		if err := mergeEnvs(&podTemp.Spec.Containers[index], envMap); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// envFieldPaths is the fields of Pod which can be read by fieldRef of downward API,
+// metadata.labels['<KEY>'] and metadata.annotations['<KEY>'] are allowed too.
+var envFieldPaths = map[string]bool{
+	"metadata.name": true, "metadata.namespace": true, "metadata.uid": true,
+	"spec.nodeName": true, "spec.serviceAccountName": true,
+	"status.hostIP": true, "status.hostIPs": true, "status.podIP": true, "status.podIPs": true,
+}
+
+// addEnv add the env into the first container,the env with the same name is replaced
+func addEnv(podTemp *v1.PodTemplateSpec, method string, env v1.EnvVar) error {
+	if errs := validation.IsEnvVarName(env.Name); len(errs) > 0 {
+		return fieldErrorf(method, "name %q is not allowed:%s", env.Name, strings.Join(errs, ","))
+	}
+	if len(podTemp.Spec.Containers) < 1 {
+		podTemp.Spec.Containers = []v1.Container{{}}
+	}
+	putEnv(&podTemp.Spec.Containers[0], env)
+	return nil
+}
+
+// addEnvFromFieldRef add the env of the Pod field into the first container,eg: status.podIP
+func addEnvFromFieldRef(podTemp *v1.PodTemplateSpec, name, fieldPath string) error {
+	labelOrAnnotation := (strings.HasPrefix(fieldPath, "metadata.labels['") || strings.HasPrefix(fieldPath, "metadata.annotations['")) &&
+		strings.HasSuffix(fieldPath, "']")
+	if !envFieldPaths[fieldPath] && !labelOrAnnotation {
+		return fieldErrorf("AddEnvFromFieldRef", "fieldPath %s is not allowed,eg: metadata.name,status.podIP or metadata.labels['app']", fieldPath)
+	}
+	return addEnv(podTemp, "AddEnvFromFieldRef", v1.EnvVar{Name: name, ValueFrom: &v1.EnvVarSource{
+		FieldRef: &v1.ObjectFieldSelector{FieldPath: fieldPath},
+	}})
+}
+
+// addEnvFromKey add the env of the key of ConfigMap or Secret into the first container
+func addEnvFromKey(podTemp *v1.PodTemplateSpec, method, name, source, key string, secret bool) error {
+	if errs := validation.IsDNS1123Subdomain(source); len(errs) > 0 {
+		return fieldErrorf(method, "name %q is not allowed:%s", source, strings.Join(errs, ","))
+	}
+	if errs := validation.IsConfigMapKey(key); len(errs) > 0 {
+		return fieldErrorf(method, "key %q is not allowed:%s", key, strings.Join(errs, ","))
+	}
+	valueFrom := &v1.EnvVarSource{}
+	if secret {
+		valueFrom.SecretKeyRef = &v1.SecretKeySelector{Key: key}
+		valueFrom.SecretKeyRef.Name = source
+	} else {
+		valueFrom.ConfigMapKeyRef = &v1.ConfigMapKeySelector{Key: key}
+		valueFrom.ConfigMapKeyRef.Name = source
+	}
+	return addEnv(podTemp, method, v1.EnvVar{Name: name, ValueFrom: valueFrom})
+}
+
+// putEnv add the env into the container,the env with the same name is replaced,
+// the envs are copied,they may be shared with other containers by SetEnvs()
+func putEnv(container *v1.Container, env v1.EnvVar) {
+	envs := make([]v1.EnvVar, 0, len(container.Env)+1)
+	for _, item := range container.Env {
+		if item.Name != env.Name {
+			envs = append(envs, item)
+		}
+	}
+	container.Env = append(envs, env)
+}
+
+// resourceFields is the resources of container which can be read by resourceFieldRef of downward API
+var resourceFields = map[string]bool{
+	"limits.cpu": true, "limits.memory": true, "limits.ephemeral-storage": true,
+	"requests.cpu": true, "requests.memory": true, "requests.ephemeral-storage": true,
+}
+
+// setEnvFromResourceField add the environment variable of the resource of the container by downward API,
+// the variable is added into the container itself, divisor is optional,eg: "1Mi" for memory in MiB.
+func setEnvFromResourceField(podTemp *v1.PodTemplateSpec, envName, container, field, divisor string) error {
+	if !verifyString(envName) {
+		return fieldError("SetEnvFromResourceField", "envName is not allowed to be empty")
+	}
+	if !resourceFields[field] {
+		return fieldErrorf("SetEnvFromResourceField", "resource %s is not allowed,it should be limits.cpu,limits.memory,requests.cpu,etc", field)
+	}
+	index := containerIndex(podTemp.Spec.Containers, container)
+	if index < 0 {
+		return fieldErrorf("SetEnvFromResourceField", "container %s is not found", container)
+	}
+	selector := &v1.ResourceFieldSelector{ContainerName: container, Resource: field}
+	if divisor != "" {
+		quantity, err := resource.ParseQuantity(divisor)
+		if err != nil {
+			return fieldErrorf("SetEnvFromResourceField", "divisor %s is not allowed:%v", divisor, err)
 		}
+		selector.Divisor = quantity
 	}
+	putEnv(&podTemp.Spec.Containers[index], v1.EnvVar{Name: envName, ValueFrom: &v1.EnvVarSource{ResourceFieldRef: selector}})
 	return nil
 }
 
@@ -132,6 +490,55 @@ func setPVCMounts(podTemp *v1.PodTemplateSpec, volumeName, mountPath string) err
 	return nil
 }
 
+// addResourceClaim declare the Dynamic Resource Allocation claim of Pod,the claim is created from claimTemplate for every Pod
+func addResourceClaim(podTemp *v1.PodTemplateSpec, name, claimTemplate string) error {
+	if !verifyString(name) || !verifyString(claimTemplate) {
+		return fieldError("AddResourceClaim", "name and claimTemplate are not allowed to be empty")
+	}
+	for _, claim := range podTemp.Spec.ResourceClaims {
+		if claim.Name == name {
+			return fieldErrorf("AddResourceClaim", "claim %s is duplicated", name)
+		}
+	}
+	podTemp.Spec.ResourceClaims = append(podTemp.Spec.ResourceClaims, v1.PodResourceClaim{
+		Name:                      name,
+		ResourceClaimTemplateName: &claimTemplate,
+	})
+	return nil
+}
+
+// useResourceClaim make the containers use the resource claim of Pod,the first container is used when containers is empty
+func useResourceClaim(podTemp *v1.PodTemplateSpec, name string, containers ...string) error {
+	if !verifyString(name) {
+		return fieldError("UseResourceClaim", "name is not allowed to be empty")
+	}
+	if len(podTemp.Spec.Containers) <= 0 {
+		return fieldError("UseResourceClaim", "the container is not set,you can call SetContainer() first")
+	}
+	indexes := []int{0}
+	if len(containers) > 0 {
+		indexes = indexes[:0]
+		for _, container := range containers {
+			index := containerIndex(podTemp.Spec.Containers, container)
+			if index < 0 {
+				return fieldErrorf("UseResourceClaim", "container %s is not found", container)
+			}
+			indexes = append(indexes, index)
+		}
+	}
+	for _, index := range indexes {
+		resources := &podTemp.Spec.Containers[index].Resources
+		used := false
+		for _, claim := range resources.Claims {
+			used = used || claim.Name == name
+		}
+		if !used {
+			resources.Claims = append(resources.Claims, v1.ResourceClaim{Name: name})
+		}
+	}
+	return nil
+}
+
 func setPVClaim(podTemp *v1.PodTemplateSpec, volumeName, claimName string) error {
 	volume := v1.Volume{
 		Name: volumeName,
@@ -150,6 +557,226 @@ func setPVClaim(podTemp *v1.PodTemplateSpec, volumeName, claimName string) error
 	return nil
 }
 
+// setConfigMapVolume declare the volume of ConfigMap on Pod
+func setConfigMapVolume(podTemp *v1.PodTemplateSpec, volumeName, configMapName string, opts []VolumeOptions) error {
+	if !verifyString(volumeName) || !verifyString(configMapName) {
+		return fieldError("SetConfigMapVolume", "volumeName and configMapName are not allowed to be empty")
+	}
+	defaultMode, items, err := volumeProjection(opts)
+	if err != nil {
+		return fieldErrorf("SetConfigMapVolume", "%v", err)
+	}
+	source := &v1.ConfigMapVolumeSource{DefaultMode: defaultMode, Items: items}
+	source.Name = configMapName
+	return addVolume(podTemp, v1.Volume{Name: volumeName, VolumeSource: v1.VolumeSource{ConfigMap: source}})
+}
+
+// setSecretVolume declare the volume of Secret on Pod
+func setSecretVolume(podTemp *v1.PodTemplateSpec, volumeName, secretName string, opts []VolumeOptions) error {
+	if !verifyString(volumeName) || !verifyString(secretName) {
+		return fieldError("SetSecretVolume", "volumeName and secretName are not allowed to be empty")
+	}
+	defaultMode, items, err := volumeProjection(opts)
+	if err != nil {
+		return fieldErrorf("SetSecretVolume", "%v", err)
+	}
+	source := &v1.SecretVolumeSource{SecretName: secretName, DefaultMode: defaultMode, Items: items}
+	return addVolume(podTemp, v1.Volume{Name: volumeName, VolumeSource: v1.VolumeSource{Secret: source}})
+}
+
+// setEmptyDirVolume declare the empty directory volume which lives as long as Pod,
+// medium is empty for the node disk or Memory for tmpfs,sizeLimit is empty for no limit
+func setEmptyDirVolume(podTemp *v1.PodTemplateSpec, volumeName, medium, sizeLimit string) error {
+	if !verifyString(volumeName) {
+		return fieldError("SetEmptyDirVolume", "volumeName is not allowed to be empty")
+	}
+	source := &v1.EmptyDirVolumeSource{Medium: v1.StorageMedium(medium)}
+	switch source.Medium {
+	case v1.StorageMediumDefault, v1.StorageMediumMemory, v1.StorageMediumHugePages:
+	default:
+		return fieldErrorf("SetEmptyDirVolume", "medium %s is not allowed,only Memory,HugePages or empty", medium)
+	}
+	if sizeLimit != "" {
+		quantity, err := resource.ParseQuantity(sizeLimit)
+		if err != nil {
+			return fieldErrorf("SetEmptyDirVolume", "sizeLimit %s is not allowed:%v", sizeLimit, err)
+		}
+		source.SizeLimit = &quantity
+	}
+	return addVolume(podTemp, v1.Volume{Name: volumeName, VolumeSource: v1.VolumeSource{EmptyDir: source}})
+}
+
+// setHostPathVolume declare the volume of the directory or file on the node
+func setHostPathVolume(podTemp *v1.PodTemplateSpec, volumeName, path, hostPathType string) error {
+	if !verifyString(volumeName) {
+		return fieldError("SetHostPathVolume", "volumeName is not allowed to be empty")
+	}
+	source, err := hostPathSource("SetHostPathVolume", path, hostPathType)
+	if err != nil {
+		return err
+	}
+	return addVolume(podTemp, v1.Volume{Name: volumeName, VolumeSource: v1.VolumeSource{HostPath: source}})
+}
+
+// hostPathSource check the path and type of host path,the path must be absolute,
+// hostPathType is one of DirectoryOrCreate,Directory,FileOrCreate,File,Socket,CharDevice,BlockDevice,empty is not checked.
+func hostPathSource(method, path, hostPathType string) (*v1.HostPathVolumeSource, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, fieldErrorf(method, "path %q is not allowed,it must be absolute", path)
+	}
+	typ := v1.HostPathType(hostPathType)
+	switch typ {
+	case v1.HostPathUnset, v1.HostPathDirectoryOrCreate, v1.HostPathDirectory, v1.HostPathFileOrCreate, v1.HostPathFile,
+		v1.HostPathSocket, v1.HostPathCharDev, v1.HostPathBlockDev:
+	default:
+		return nil, fieldErrorf(method, "hostPathType %s is not allowed", hostPathType)
+	}
+	return &v1.HostPathVolumeSource{Path: path, Type: &typ}, nil
+}
+
+// setNFSVolume declare the volume of the path exported by NFS server
+func setNFSVolume(podTemp *v1.PodTemplateSpec, volumeName, server, path string, readOnly bool) error {
+	if !verifyString(volumeName) || !verifyString(server) {
+		return fieldError("SetNFSVolume", "volumeName and server are not allowed to be empty")
+	}
+	if !strings.HasPrefix(path, "/") {
+		return fieldErrorf("SetNFSVolume", "path %q is not allowed,it must be absolute", path)
+	}
+	source := &v1.NFSVolumeSource{Server: server, Path: path, ReadOnly: readOnly}
+	return addVolume(podTemp, v1.Volume{Name: volumeName, VolumeSource: v1.VolumeSource{NFS: source}})
+}
+
+// setDownwardAPIVolume declare the volume of Pod fields,fields is the relative file path to the field path of Pod
+func setDownwardAPIVolume(podTemp *v1.PodTemplateSpec, volumeName string, fields map[string]string) error {
+	if !verifyString(volumeName) || len(fields) <= 0 {
+		return fieldError("SetDownwardAPIVolume", "volumeName and fields are not allowed to be empty")
+	}
+	paths := make([]string, 0, len(fields))
+	for path := range fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	items := make([]v1.DownwardAPIVolumeFile, 0, len(fields))
+	for _, path := range paths {
+		if !verifyString(path) || strings.HasPrefix(path, "/") || strings.Contains(path, "..") {
+			return fieldErrorf("SetDownwardAPIVolume", "path %q is not allowed,it must be relative and not contain '..'", path)
+		}
+		fieldPath := fields[path]
+		if !strings.HasPrefix(fieldPath, "metadata.") {
+			return fieldErrorf("SetDownwardAPIVolume", "fieldPath %q is not allowed,it must be the field of metadata,eg: metadata.labels", fieldPath)
+		}
+		items = append(items, v1.DownwardAPIVolumeFile{Path: path, FieldRef: &v1.ObjectFieldSelector{FieldPath: fieldPath}})
+	}
+	source := &v1.DownwardAPIVolumeSource{Items: items}
+	return addVolume(podTemp, v1.Volume{Name: volumeName, VolumeSource: v1.VolumeSource{DownwardAPI: source}})
+}
+
+// setProjectedVolume declare the volume which projects several ConfigMaps,Secrets and service account token into one directory
+func setProjectedVolume(podTemp *v1.PodTemplateSpec, volumeName string, sources []ProjectedSource) error {
+	if !verifyString(volumeName) || len(sources) <= 0 {
+		return fieldError("SetProjectedVolume", "volumeName and sources are not allowed to be empty")
+	}
+	projections := make([]v1.VolumeProjection, 0, len(sources))
+	for _, source := range sources {
+		set := 0
+		for _, name := range []string{source.ConfigMap, source.Secret, source.ServiceAccountToken} {
+			if name != "" {
+				set++
+			}
+		}
+		if set != 1 {
+			return fieldError("SetProjectedVolume", "only one of ConfigMap,Secret and ServiceAccountToken of source is allowed to be set")
+		}
+		_, items, err := volumeProjection([]VolumeOptions{{Items: source.Items}})
+		if err != nil {
+			return fieldErrorf("SetProjectedVolume", "%v", err)
+		}
+		var projection v1.VolumeProjection
+		switch {
+		case source.ConfigMap != "":
+			projection.ConfigMap = &v1.ConfigMapProjection{Items: items}
+			projection.ConfigMap.Name = source.ConfigMap
+		case source.Secret != "":
+			projection.Secret = &v1.SecretProjection{Items: items}
+			projection.Secret.Name = source.Secret
+		default:
+			if strings.HasPrefix(source.ServiceAccountToken, "/") || strings.Contains(source.ServiceAccountToken, "..") {
+				return fieldErrorf("SetProjectedVolume", "token path %s is not allowed,it must be relative and not contain '..'", source.ServiceAccountToken)
+			}
+			token := &v1.ServiceAccountTokenProjection{Path: source.ServiceAccountToken, Audience: source.Audience}
+			if source.ExpirationSeconds != 0 {
+				if source.ExpirationSeconds < 600 {
+					return fieldErrorf("SetProjectedVolume", "ExpirationSeconds %d of token is not allowed,it must be at least 600", source.ExpirationSeconds)
+				}
+				expiration := source.ExpirationSeconds
+				token.ExpirationSeconds = &expiration
+			}
+			projection.ServiceAccountToken = token
+		}
+		projections = append(projections, projection)
+	}
+	source := &v1.ProjectedVolumeSource{Sources: projections}
+	return addVolume(podTemp, v1.Volume{Name: volumeName, VolumeSource: v1.VolumeSource{Projected: source}})
+}
+
+// setVolumeMount mount the volume of any type on the first container
+func setVolumeMount(podTemp *v1.PodTemplateSpec, volumeName, mountPath string) error {
+	if !verifyString(volumeName) || !strings.HasPrefix(mountPath, "/") {
+		return fieldError("SetVolumeMount", "volumeName is not allowed to be empty and mountPath must be absolute")
+	}
+	return setPVCMounts(podTemp, volumeName, mountPath)
+}
+
+// volumeProjection get defaultMode and items of opts[0],the modes must be in range of 0 to 0777
+func volumeProjection(opts []VolumeOptions) (*int32, []v1.KeyToPath, error) {
+	if len(opts) <= 0 {
+		return nil, nil, nil
+	}
+	var defaultMode *int32
+	if opts[0].DefaultMode != 0 {
+		if opts[0].DefaultMode < 0 || opts[0].DefaultMode > 0777 {
+			return nil, nil, fmt.Errorf("defaultMode %#o is not allowed,it must be in range of 0 to 0777", opts[0].DefaultMode)
+		}
+		mode := opts[0].DefaultMode
+		defaultMode = &mode
+	}
+	var items []v1.KeyToPath
+	paths := make(map[string]bool, len(opts[0].Items))
+	for _, item := range opts[0].Items {
+		if !verifyString(item.Key) || !verifyString(item.Path) {
+			return nil, nil, errors.New("item key and path are not allowed to be empty")
+		}
+		if strings.HasPrefix(item.Path, "/") || strings.Contains(item.Path, "..") {
+			return nil, nil, fmt.Errorf("item path %s is not allowed,it must be relative and not contain '..'", item.Path)
+		}
+		if paths[item.Path] {
+			return nil, nil, fmt.Errorf("item path %s is duplicated", item.Path)
+		}
+		paths[item.Path] = true
+		keyToPath := v1.KeyToPath{Key: item.Key, Path: item.Path}
+		if item.Mode != 0 {
+			if item.Mode < 0 || item.Mode > 0777 {
+				return nil, nil, fmt.Errorf("item %s mode %#o is not allowed,it must be in range of 0 to 0777", item.Key, item.Mode)
+			}
+			mode := item.Mode
+			keyToPath.Mode = &mode
+		}
+		items = append(items, keyToPath)
+	}
+	return defaultMode, items, nil
+}
+
+// addVolume add the volume on Pod,the volume name must be unique
+func addVolume(podTemp *v1.PodTemplateSpec, volume v1.Volume) error {
+	for _, existing := range podTemp.Spec.Volumes {
+		if existing.Name == volume.Name {
+			return fmt.Errorf("volume %s is duplicated", volume.Name)
+		}
+	}
+	podTemp.Spec.Volumes = append(podTemp.Spec.Volumes, volume)
+	return nil
+}
+
 func setLiveness(podTemp *v1.PodTemplateSpec, probe *v1.Probe) error {
 	if len(podTemp.Spec.Containers) <= 0 {
 		podTemp.Spec.Containers = []v1.Container{{LivenessProbe: probe}}
@@ -158,6 +785,55 @@ func setLiveness(podTemp *v1.PodTemplateSpec, probe *v1.Probe) error {
 	podTemp.Spec.Containers[0].LivenessProbe = probe
 	return nil
 }
+
+// setLivenessOptions set the options on the liveness probe of the first container
+func setLivenessOptions(podTemp *v1.PodTemplateSpec, opts ProbeOptions) error {
+	return setProbeOptions(podTemp, "Liveness", opts)
+}
+
+// setProbeOptions set the options on the Liveness,Readness or Startup probe of the first container,
+// only readiness probe allows SuccessThreshold more than 1 and only it doesn't allow TerminationGracePeriodSeconds.
+func setProbeOptions(podTemp *v1.PodTemplateSpec, kind string, opts ProbeOptions) error {
+	method := "Set" + kind + "Options"
+	if len(podTemp.Spec.Containers) <= 0 {
+		return fieldErrorf(method, "%s probe is not set,you can call SetXXX%s() first", strings.ToLower(kind), kind)
+	}
+	container := &podTemp.Spec.Containers[0]
+	var probe **v1.Probe
+	switch kind {
+	case "Liveness":
+		probe = &container.LivenessProbe
+	case "Readness":
+		probe = &container.ReadinessProbe
+	case "Startup":
+		probe = &container.StartupProbe
+	}
+	if *probe == nil {
+		return fieldErrorf(method, "%s probe is not set,you can call SetXXX%s() first", strings.ToLower(kind), kind)
+	}
+	if opts.TerminationGracePeriodSeconds < 0 || opts.FailureThreshold < 0 || opts.SuccessThreshold < 0 {
+		return fieldErrorf(method, "TerminationGracePeriodSeconds,FailureThreshold and SuccessThreshold are not allowed to be negative")
+	}
+	if kind == "Readness" && opts.TerminationGracePeriodSeconds > 0 {
+		return fieldErrorf(method, "TerminationGracePeriodSeconds is not allowed on readiness probe")
+	}
+	if kind != "Readness" && opts.SuccessThreshold > 1 {
+		return fieldErrorf(method, "SuccessThreshold must be 1 on %s probe", strings.ToLower(kind))
+	}
+	cp := (*probe).DeepCopy()
+	if opts.TerminationGracePeriodSeconds > 0 {
+		cp.TerminationGracePeriodSeconds = &opts.TerminationGracePeriodSeconds
+	}
+	if opts.FailureThreshold > 0 {
+		cp.FailureThreshold = opts.FailureThreshold
+	}
+	if opts.SuccessThreshold > 0 {
+		cp.SuccessThreshold = opts.SuccessThreshold
+	}
+	*probe = cp
+	return nil
+}
+
 func setReadness(podTemp *v1.PodTemplateSpec, probe *v1.Probe) error {
 	if len(podTemp.Spec.Containers) <= 0 {
 		podTemp.Spec.Containers = []v1.Container{{ReadinessProbe: probe}}
@@ -167,6 +843,390 @@ func setReadness(podTemp *v1.PodTemplateSpec, probe *v1.Probe) error {
 	return nil
 }
 
+// setStartup set the startup probe of the first container,liveness and readiness probes wait until it succeeds
+func setStartup(podTemp *v1.PodTemplateSpec, probe *v1.Probe) error {
+	if len(podTemp.Spec.Containers) <= 0 {
+		podTemp.Spec.Containers = []v1.Container{{StartupProbe: probe}}
+		return nil
+	}
+	podTemp.Spec.Containers[0].StartupProbe = probe
+	return nil
+}
+
+// setCommand set the entrypoint of the first container,it overrides ENTRYPOINT of the image
+func setCommand(podTemp *v1.PodTemplateSpec, command []string) error {
+	if len(command) <= 0 {
+		return fieldError("SetCommand", "command is not allowed to be empty")
+	}
+	if len(podTemp.Spec.Containers) <= 0 {
+		podTemp.Spec.Containers = []v1.Container{{}}
+	}
+	podTemp.Spec.Containers[0].Command = append([]string(nil), command...)
+	return nil
+}
+
+// setArgs set the arguments of the entrypoint of the first container,it overrides CMD of the image
+func setArgs(podTemp *v1.PodTemplateSpec, args []string) error {
+	if len(podTemp.Spec.Containers) <= 0 {
+		podTemp.Spec.Containers = []v1.Container{{}}
+	}
+	podTemp.Spec.Containers[0].Args = append([]string(nil), args...)
+	return nil
+}
+
+// setLifecycle set the postStart or preStop hook of the first container,the other hook is kept
+func setLifecycle(podTemp *v1.PodTemplateSpec, method string, handler *v1.LifecycleHandler) error {
+	switch {
+	case handler.Exec != nil && len(handler.Exec.Command) <= 0:
+		return fieldErrorf(method, "cmd is not allowed to be empty")
+	case handler.HTTPGet != nil && (handler.HTTPGet.Port.IntValue() <= 0 || handler.HTTPGet.Port.IntValue() >= 65536):
+		return fieldErrorf(method, "port range: 0 < port < 65536")
+	}
+	if len(podTemp.Spec.Containers) <= 0 {
+		podTemp.Spec.Containers = []v1.Container{{}}
+	}
+	container := &podTemp.Spec.Containers[0]
+	lifecycle := &v1.Lifecycle{}
+	if container.Lifecycle != nil {
+		lifecycle = container.Lifecycle.DeepCopy()
+	}
+	if strings.HasPrefix(method, "SetPostStart") {
+		lifecycle.PostStart = handler
+	} else {
+		lifecycle.PreStop = handler
+	}
+	container.Lifecycle = lifecycle
+	return nil
+}
+
+// namedContainer get the container by its name,so the container-level setters can target the sidecars,
+// unlike the setters without container name which only set the first container.
+func namedContainer(podTemp *v1.PodTemplateSpec, method, name string) (*v1.Container, error) {
+	index := containerIndex(podTemp.Spec.Containers, name)
+	if index < 0 {
+		return nil, fieldErrorf(method, "container %q is not found,you can call SetContainer() first", name)
+	}
+	return &podTemp.Spec.Containers[index], nil
+}
+
+func setLivenessFor(podTemp *v1.PodTemplateSpec, name string, probe *v1.Probe) error {
+	container, err := namedContainer(podTemp, "SetLivenessFor", name)
+	if err != nil {
+		return err
+	}
+	container.LivenessProbe = probe
+	return nil
+}
+
+func setReadnessFor(podTemp *v1.PodTemplateSpec, name string, probe *v1.Probe) error {
+	container, err := namedContainer(podTemp, "SetReadnessFor", name)
+	if err != nil {
+		return err
+	}
+	container.ReadinessProbe = probe
+	return nil
+}
+
+// setEnvsFor add the envs into the container,the env with the same name is replaced,the others are kept
+func setEnvsFor(podTemp *v1.PodTemplateSpec, name string, envMap map[string]string) error {
+	container, err := namedContainer(podTemp, "SetEnvsFor", name)
+	if err != nil {
+		return err
+	}
+	return mergeEnvs(container, envMap)
+}
+
+// mergeEnvs add the envs into the container,the env with the same name is replaced,the others are kept
+func mergeEnvs(container *v1.Container, envMap map[string]string) error {
+	envs, err := mapToEnvs(envMap)
+	if err != nil {
+		return err
+	}
+	// copy the envs,they may be shared with other containers by SetEnvs()
+	merged := make([]v1.EnvVar, 0, len(container.Env)+len(envs))
+	for _, item := range container.Env {
+		if _, ok := envMap[item.Name]; !ok {
+			merged = append(merged, item)
+		}
+	}
+	container.Env = append(merged, envs...)
+	return nil
+}
+
+func setPVCMountsFor(podTemp *v1.PodTemplateSpec, name, volumeName, mountPath string) error {
+	container, err := namedContainer(podTemp, "SetPVCMountsFor", name)
+	if err != nil {
+		return err
+	}
+	if !verifyString(volumeName) || !verifyString(mountPath) {
+		return fieldError("SetPVCMountsFor", "volumeName and mountPath are not allowed to be empty")
+	}
+	container.VolumeMounts = append(container.VolumeMounts[:len(container.VolumeMounts):len(container.VolumeMounts)],
+		v1.VolumeMount{Name: volumeName, MountPath: mountPath})
+	return nil
+}
+
+func setResourceLimitFor(podTemp *v1.PodTemplateSpec, name string, limits map[ResourceName]string) error {
+	container, err := namedContainer(podTemp, "SetResourceLimitFor", name)
+	if err != nil {
+		return err
+	}
+	data, err := ResourceMapsToK8s(limits)
+	if err != nil {
+		return fieldErrorf("SetResourceLimitFor", "%v", err)
+	}
+	container.Resources.Limits = data
+	return nil
+}
+
+func setResourceRequestsFor(podTemp *v1.PodTemplateSpec, name string, requests map[ResourceName]string) error {
+	container, err := namedContainer(podTemp, "SetResourceRequstFor", name)
+	if err != nil {
+		return err
+	}
+	data, err := ResourceMapsToK8s(requests)
+	if err != nil {
+		return fieldErrorf("SetResourceRequstFor", "%v", err)
+	}
+	container.Resources.Requests = data
+	return nil
+}
+
+// verifyContainers check container names and containerPort/protocol pairs are unique in the Pod,
+// and the volumeMounts of the init containers and containers reference declared volumes.
+// claimTemplates is the names of StatefulSet volumeClaimTemplates,they can be mounted as volumes too.
+func verifyContainers(pod v1.PodSpec, claimTemplates ...string) error {
+	volumes := make(map[string]bool, len(pod.Volumes)+len(claimTemplates))
+	for _, volume := range pod.Volumes {
+		volumes[volume.Name] = true
+	}
+	for _, name := range claimTemplates {
+		volumes[name] = true
+	}
+	claims := make(map[string]bool, len(pod.ResourceClaims))
+	for _, claim := range pod.ResourceClaims {
+		claims[claim.Name] = true
+	}
+	names := make(map[string]bool, len(pod.InitContainers)+len(pod.Containers))
+	for _, container := range pod.InitContainers {
+		if names[container.Name] {
+			return fmt.Errorf("init container name %q is duplicated,container name must be unique in the Pod", container.Name)
+		}
+		names[container.Name] = true
+		for _, mount := range container.VolumeMounts {
+			if !volumes[mount.Name] {
+				return fmt.Errorf("init container %q volumeMount %q is not allowed,the volume is not declared,you can call SetPVClaim() declare it", container.Name, mount.Name)
+			}
+		}
+	}
+	// the key is containerPort/protocol, a struct key avoids formatting a string for every port
+	type portKey struct {
+		port     int32
+		protocol v1.Protocol
+	}
+	ports := make(map[portKey]string)
+	for _, container := range pod.Containers {
+		if names[container.Name] {
+			return fmt.Errorf("container name %q is duplicated,container name must be unique in the Pod", container.Name)
+		}
+		names[container.Name] = true
+		for _, port := range container.Ports {
+			protocol := port.Protocol
+			if protocol == "" {
+				protocol = v1.ProtocolTCP
+			}
+			key := portKey{port: port.ContainerPort, protocol: protocol}
+			if owner, ok := ports[key]; ok {
+				return fmt.Errorf("container %q port %d/%s is duplicated,it is already used by container %q", container.Name, key.port, key.protocol, owner)
+			}
+			ports[key] = container.Name
+		}
+		for _, mount := range container.VolumeMounts {
+			if !volumes[mount.Name] {
+				return fmt.Errorf("container %q volumeMount %q is not allowed,the volume is not declared,you can call SetPVClaim() declare it", container.Name, mount.Name)
+			}
+		}
+		for _, claim := range container.Resources.Claims {
+			if !claims[claim.Name] {
+				return fmt.Errorf("container %q resource claim %q is not allowed,the claim is not declared,you can call AddResourceClaim() declare it", container.Name, claim.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// setHostPort expose the containerPort of the container on the node by hostPort,
+// eg: ingress controller and node-local agent,hostPort 0 removes it.
+func setHostPort(podTemp *v1.PodTemplateSpec, container string, containerPort, hostPort int32) error {
+	if hostPort < 0 || hostPort >= 65536 {
+		return fieldError("SetHostPort", "hostPort range: 0 < hostPort < 65536")
+	}
+	index := containerIndex(podTemp.Spec.Containers, container)
+	if index < 0 {
+		return fieldErrorf("SetHostPort", "container %q is not found,you can call SetContainer() first", container)
+	}
+	for i, port := range podTemp.Spec.Containers[index].Ports {
+		if port.ContainerPort == containerPort {
+			podTemp.Spec.Containers[index].Ports[i].HostPort = hostPort
+			return nil
+		}
+	}
+	return fieldErrorf("SetHostPort", "container %q has no port %d", container, containerPort)
+}
+
+// addContainerPort add the port of name on the container,protocol is TCP,UDP or SCTP,default TCP,
+// the port without name set by SetContainer() is named when it is the same port,eg: SetContainer("app", image, 8080) and http 8080.
+func addContainerPort(method string, container *v1.Container, name string, containerPort int32, protocol string) error {
+	if containerPort <= 0 || containerPort >= 65536 {
+		return fieldErrorf(method, "container Port range: 0 < containerPort < 65536")
+	}
+	if name != "" {
+		if errs := validation.IsValidPortName(name); len(errs) > 0 {
+			return fieldErrorf(method, "port name %q is not allowed:%s", name, strings.Join(errs, ","))
+		}
+	}
+	proto, err := protocolOf(method, protocol)
+	if err != nil {
+		return err
+	}
+	for index := range container.Ports {
+		port := &container.Ports[index]
+		if name != "" && port.Name == name {
+			return fieldErrorf(method, "port name %q is duplicated in container %s", name, container.Name)
+		}
+		if port.ContainerPort != containerPort || (port.Protocol != proto && !(port.Protocol == "" && proto == v1.ProtocolTCP)) {
+			continue
+		}
+		if port.Name != "" || name == "" {
+			return fieldErrorf(method, "port %d/%s is duplicated in container %s", containerPort, proto, container.Name)
+		}
+		port.Name, port.Protocol = name, proto
+		return nil
+	}
+	container.Ports = append(container.Ports, v1.ContainerPort{Name: name, ContainerPort: containerPort, Protocol: proto})
+	return nil
+}
+
+// addFirstContainerPort add the port on the first container,it is the application container set by SetContainer()
+func addFirstContainerPort(podTemp *v1.PodTemplateSpec, name string, containerPort int32, protocol string) error {
+	if len(podTemp.Spec.Containers) < 1 {
+		return fieldError("AddContainerPort", "container is not set,you can call SetContainer() first")
+	}
+	return addContainerPort("AddContainerPort", &podTemp.Spec.Containers[0], name, containerPort, protocol)
+}
+
+// addContainerPortFor add the port on the container named container,eg: the metrics port of the sidecar
+func addContainerPortFor(podTemp *v1.PodTemplateSpec, container, name string, containerPort int32, protocol string) error {
+	c, err := namedContainer(podTemp, "AddContainerPortFor", container)
+	if err != nil {
+		return err
+	}
+	return addContainerPort("AddContainerPortFor", c, name, containerPort, protocol)
+}
+
+// protocolOf get the Kubernetes protocol of TCP,UDP or SCTP,empty protocol is TCP
+func protocolOf(method, protocol string) (v1.Protocol, error) {
+	if protocol == "" {
+		return v1.ProtocolTCP, nil
+	}
+	proto, ok := pros[Protocol(strings.ToUpper(protocol))]
+	if !ok {
+		return "", fieldErrorf(method, "protocol %s is not allowed,only TCP,UDP and SCTP", protocol)
+	}
+	return proto, nil
+}
+
+// verifyHostPorts check the workload which runs many Pods on one node doesn't use hostPort,
+// because the Pods on the same node conflict and can't be scheduled,hostPort is for DaemonSet.
+// the Pod of hostNetwork is allowed,its hostPort is defaulted to containerPort by Kubernetes.
+func verifyHostPorts(kind string, pod v1.PodSpec) error {
+	for _, container := range append(append([]v1.Container(nil), pod.InitContainers...), pod.Containers...) {
+		for _, port := range container.Ports {
+			if port.HostPort == 0 || (pod.HostNetwork && port.HostPort == port.ContainerPort) {
+				continue
+			}
+			return fmt.Errorf("%s container %q hostPort %d is not allowed,hostPort is only allowed in DaemonSet,the Pods on the same node conflict", kind, container.Name, port.HostPort)
+		}
+	}
+	return nil
+}
+
+// verifyPodSpec check the Pod spec of the workload,all problems are returned as FieldError of field,
+// eg: Deployment.Spec.Template.Spec,claimTemplates is the names of StatefulSet volumeClaimTemplates.
+func verifyPodSpec(field string, pod v1.PodSpec, claimTemplates ...string) error {
+	var errs error
+	if len(pod.Containers) < 1 {
+		errs = fieldError(field+".Containers", "is not allowed to be empty,you can call SetContainer() add it")
+	}
+	for _, err := range []error{verifyContainers(pod, claimTemplates...), verifyProbePorts(pod), verifySecurityContext(pod), verifyDNS(pod)} {
+		errs = appendError(errs, inField(field, err))
+	}
+	return errs
+}
+
+// verifySelector check the selector matches Pod template labels,
+// because Kubernetes apiServer rejects the workload which selector does not match its Pod labels.
+func verifySelector(kind string, selector *metav1.LabelSelector, podLabels map[string]string) error {
+	sel, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return fieldErrorf(kind+".Spec.Selector", "is not allowed,err:%v", err)
+	}
+	if sel.Empty() {
+		return fieldError(kind+".Spec.Selector", "is not allowed to be empty")
+	}
+	if !sel.Matches(labels.Set(podLabels)) {
+		return fieldErrorf(kind+".Spec.Selector", "(%s) does not match Pod labels %v,please check SetSelector(),SetPodLabels() and match expressions", sel, podLabels)
+	}
+	return nil
+}
+
+// verifySelectorUnchanged check the selector of existing workload is not changed,
+// because the selector is immutable after the workload is created.
+func verifySelectorUnchanged(kind string, existing, selector *metav1.LabelSelector) error {
+	if reflect.DeepEqual(existing, selector) {
+		return nil
+	}
+	existSel, _ := metav1.LabelSelectorAsSelector(existing)
+	newSel, _ := metav1.LabelSelectorAsSelector(selector)
+	return fmt.Errorf("%s.Spec.Selector is immutable after creation,existing selector(%s) is not allowed to change to (%s),"+
+		"you can call ApplyRecreate() to delete and create it", kind, existSel, newSel)
+}
+
+// verifyProbePorts check probes that reference a port by name,
+// the name must be declared on the ports of the same container
+func verifyProbePorts(pod v1.PodSpec) error {
+	for _, container := range pod.Containers {
+		names := make(map[string]bool, len(container.Ports))
+		for _, port := range container.Ports {
+			names[port.Name] = true
+		}
+		for _, probe := range []*v1.Probe{container.LivenessProbe, container.ReadinessProbe} {
+			port, ok := probePort(probe)
+			if !ok || port.Type != intstr.String {
+				continue
+			}
+			if !names[port.StrVal] {
+				return fmt.Errorf("container %s probe port %q is not allowed,it must be the name of a container port", container.Name, port.StrVal)
+			}
+		}
+	}
+	return nil
+}
+
+// probePort get the port of http or tcp probe
+func probePort(probe *v1.Probe) (intstr.IntOrString, bool) {
+	if probe == nil {
+		return intstr.IntOrString{}, false
+	}
+	if probe.HTTPGet != nil {
+		return probe.HTTPGet.Port, true
+	}
+	if probe.TCPSocket != nil {
+		return probe.TCPSocket.Port, true
+	}
+	return intstr.IntOrString{}, false
+}
+
 var supportedQoSComputeResources = sets.NewString(string(ResourceCPU), string(ResourceMemory))
 
 // QOSList is a set of (resource name, QoS class) pairs.
@@ -192,12 +1252,12 @@ func GetPodQOS(pod v1.PodSpec) v1.PodQOSClass {
 				continue
 			}
 			if quantity.Cmp(zeroQuantity) == 1 {
-				delta := quantity.Copy()
+				delta := quantity.DeepCopy()
 				if _, exists := requests[name]; !exists {
-					requests[name] = *delta
+					requests[name] = delta
 				} else {
 					delta.Add(requests[name])
-					requests[name] = *delta
+					requests[name] = delta
 				}
 			}
 		}
@@ -209,12 +1269,12 @@ func GetPodQOS(pod v1.PodSpec) v1.PodQOSClass {
 			}
 			if quantity.Cmp(zeroQuantity) == 1 {
 				qosLimitsFound.Insert(string(name))
-				delta := quantity.Copy()
+				delta := quantity.DeepCopy()
 				if _, exists := limits[name]; !exists {
-					limits[name] = *delta
+					limits[name] = delta
 				} else {
 					delta.Add(limits[name])
-					limits[name] = *delta
+					limits[name] = delta
 				}
 			}
 		}
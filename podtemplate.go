@@ -0,0 +1,154 @@
+package beku
+
+import (
+	"fmt"
+
+	"k8s.io/api/core/v1"
+)
+
+// PodTemplate include Kubernetes Pod template and error,
+// it is built once and attached to Deployment,StatefulSet,DaemonSet or Job by their SetPodTemplate(),
+// the pod-level setters of PodTemplate and the workloads are PodSetters which they embed.
+type PodTemplate struct {
+	PodSetters[*PodTemplate]
+	tpl *v1.PodTemplateSpec
+	err error
+}
+
+// NewPodTemplate create PodTemplate and chain function call begin with this function.
+func NewPodTemplate() *PodTemplate {
+	return (&PodTemplate{tpl: &v1.PodTemplateSpec{}}).withPodSetters()
+}
+
+// Finish Chain function call end with this function
+// return Pod template and error,
+// In the function, it will check necessary parameters,the workload checks the rest when it is finished.
+func (obj *PodTemplate) Finish() (*v1.PodTemplateSpec, error) {
+	return obj.tpl, appendError(obj.err, obj.verify())
+}
+
+// Clone deep copy PodTemplate with the pending error,the clone and PodTemplate don't share any field,eg: containers and volumes,
+// so the clones of one base builder can be specialized in parallel goroutines,eg: per-tenant PodTemplates.
+// the builder itself is not safe for concurrent use,clone it for every goroutine.
+func (obj *PodTemplate) Clone() *PodTemplate {
+	return (&PodTemplate{tpl: obj.tpl.DeepCopy(), err: obj.err}).withPodSetters()
+}
+
+// Replace replace PodTemplate by Kubernetes Pod template
+func (obj *PodTemplate) Replace(tpl *v1.PodTemplateSpec) *PodTemplate {
+	if tpl != nil {
+		obj.tpl = tpl
+	}
+	return obj
+}
+
+// SetLabels set Pod labels,the workload without selector uses them as its selector
+func (obj *PodTemplate) SetLabels(labels map[string]string) *PodTemplate {
+	obj.tpl.SetLabels(labels)
+	return obj
+}
+
+// AddLabel add or overwrite one label of Pod,the other labels are kept
+func (obj *PodTemplate) AddLabel(key, value string) *PodTemplate {
+	obj.error(addLabel(obj.tpl, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of Pod,the other annotations are kept
+func (obj *PodTemplate) AddAnnotation(key, value string) *PodTemplate {
+	obj.error(addAnnotation(obj.tpl, key, value))
+	return obj
+}
+
+// SetContainer add container,the first container has higher status like the workloads
+// name:name is container name ,default ""
+// image:image is image name ,must input image
+// containerPort: image expose containerPort,must input containerPort
+func (obj *PodTemplate) SetContainer(name, image string, containerPort int32) *PodTemplate {
+	obj.error(setContainer(obj.tpl, name, image, containerPort))
+	return obj
+}
+
+// SetHostname set the hostname of the Pods of PodTemplate,the Pod name is used when it is not set
+func (obj *PodTemplate) SetHostname(hostname string) *PodTemplate {
+	obj.error(setHostname(obj.tpl, hostname))
+	return obj
+}
+
+// SetSubdomain set the subdomain of the Pods of PodTemplate,the Pods are resolved as <hostname>.<subdomain>
+// when the headless Service of the subdomain name selects them.
+func (obj *PodTemplate) SetSubdomain(subdomain string) *PodTemplate {
+	obj.error(setSubdomain(obj.tpl, subdomain))
+	return obj
+}
+
+// SetResourceLimit set container resource limit,eg:CPU and MEMORY,only **first container** will be set
+func (obj *PodTemplate) SetResourceLimit(limits map[ResourceName]string) *PodTemplate {
+	obj.error(setResourceLimit(obj.tpl, limits))
+	return obj
+}
+
+// SetResourceRequests set container resource request,only CPU and MEMORY,only **first container** will be set
+func (obj *PodTemplate) SetResourceRequests(requests map[ResourceName]string) *PodTemplate {
+	obj.error(setResourceRequests(obj.tpl, requests))
+	return obj
+}
+
+// SetProbeOptions set the options of the Liveness,Readness or Startup probe of the first container,
+// eg: SetProbeOptions("Startup", ProbeOptions{FailureThreshold: 30}).
+func (obj *PodTemplate) SetProbeOptions(kind string, opts ProbeOptions) *PodTemplate {
+	switch kind {
+	case "Liveness", "Readness", "Startup":
+		obj.error(setProbeOptions(obj.tpl, kind, opts))
+	default:
+		obj.error(fieldErrorf("SetProbeOptions", "kind %s is not allowed,only Liveness,Readness and Startup", kind))
+	}
+	return obj
+}
+
+// String the current PodTemplate as yaml, the pending error is written on the top as comment.
+func (obj *PodTemplate) String() string { return dump("PodTemplate", obj.tpl, obj.err) }
+
+// Dump print the current PodTemplate and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *PodTemplate) Dump() *PodTemplate {
+	fmt.Println(obj.String())
+	return obj
+}
+
+func (obj *PodTemplate) error(err error) {
+	obj.err = appendError(obj.err, err)
+}
+
+// withPodSetters bind the pod-level setters to PodTemplate
+func (obj *PodTemplate) withPodSetters() *PodTemplate {
+	obj.PodSetters = PodSetters[*PodTemplate]{builder: obj}
+	return obj
+}
+
+// podTemplate get Pod template to change it
+func (obj *PodTemplate) podTemplate() *v1.PodTemplateSpec { return obj.tpl }
+
+// verify check PodTemplate necessary value,all problems are returned at once
+func (obj *PodTemplate) verify() error {
+	// the volumeMounts may reference StatefulSet volumeClaimTemplates,the workload checks them when it is finished
+	var mounts []string
+	for _, container := range append(append([]v1.Container(nil), obj.tpl.Spec.InitContainers...), obj.tpl.Spec.Containers...) {
+		for _, mount := range container.VolumeMounts {
+			mounts = append(mounts, mount.Name)
+		}
+	}
+	return verifyPodSpec("PodTemplate.Spec", obj.tpl.Spec, mounts...)
+}
+
+// podTemplateOf finish PodTemplate and copy it,so one PodTemplate can be attached to many workloads
+func podTemplateOf(tpl *PodTemplate) (*v1.PodTemplateSpec, error) {
+	if tpl == nil {
+		return nil, fieldError("SetPodTemplate", "PodTemplate is not allowed to be nil")
+	}
+	template, err := tpl.Finish()
+	if err != nil {
+		return nil, err
+	}
+	return template.DeepCopy(), nil
+}
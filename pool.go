@@ -0,0 +1,39 @@
+package beku
+
+import "sync"
+
+// the pools of the builders,they reduce the allocations of the bulk generation,eg: 50k Deployments and Services of the tenants
+var (
+	deploymentPool = sync.Pool{New: func() interface{} { return NewDeployment() }}
+	servicePool    = sync.Pool{New: func() interface{} { return NewSvc() }}
+)
+
+// AcquireDeployment get Deployment from the pool,it is the same as NewDeployment(),
+// but the builder released by ReleaseDeployment() is reused.
+func AcquireDeployment() *Deployment {
+	return deploymentPool.Get().(*Deployment)
+}
+
+// ReleaseDeployment reset Deployment and put it back to the pool,
+// the Deployment returned by Finish() must not be used after it,so write it first,eg: by YAMLWriter.
+func ReleaseDeployment(obj *Deployment) {
+	if obj == nil {
+		return
+	}
+	deploymentPool.Put(obj.Reset())
+}
+
+// AcquireService get Service from the pool,it is the same as NewSvc(),
+// but the builder released by ReleaseService() is reused.
+func AcquireService() *Service {
+	return servicePool.Get().(*Service)
+}
+
+// ReleaseService reset Service and put it back to the pool,
+// the Service returned by Finish() must not be used after it,so write it first,eg: by YAMLWriter.
+func ReleaseService(obj *Service) {
+	if obj == nil {
+		return
+	}
+	servicePool.Put(obj.Reset())
+}
@@ -0,0 +1,94 @@
+package beku
+
+import (
+	"k8s.io/api/core/v1"
+)
+
+// Preset is a curated set of defaults,eg: the golden path of the team for the web services,
+// it is applied in Finish() only on the fields which are not set,so the setters override it
+// no matter they are called before or after WithPreset(),the zero fields of Preset are not applied.
+type Preset struct {
+	// Name is the name of Preset,it is in the error of Preset
+	Name string
+	// Requests is the resource requests of the containers,eg: {"cpu": "100m", "memory": "128Mi"},
+	// the resource which has limit is not set,so the QoS of the container is not changed.
+	Requests map[ResourceName]string
+	// AntiAffinity prefer the Pods are scheduled on the different nodes when the Pod has no anti-affinity.
+	AntiAffinity bool
+	// ProbePath is the http path of the liveness and readiness probes on the first port of the first container,
+	// the probe which is set is not changed.
+	ProbePath string
+	// NonRoot run the containers as non-root user when the Pod and containers don't set runAsNonRoot and runAsUser.
+	NonRoot bool
+	// PDBMaxUnavailable is the maxUnavailable of PodDisruptionBudget returned by PDB() of the builder,eg: 1 or 25%.
+	PDBMaxUnavailable string
+}
+
+// ProductionWebService is the Preset of the stateless web service in production,
+// it sets requests,anti-affinity,probes on /healthz,non-root user and PodDisruptionBudget.
+var ProductionWebService = Preset{
+	Name:              "ProductionWebService",
+	Requests:          map[ResourceName]string{ResourceCPU: "100m", ResourceMemory: "128Mi"},
+	AntiAffinity:      true,
+	ProbePath:         "/healthz",
+	NonRoot:           true,
+	PDBMaxUnavailable: "1",
+}
+
+// applyPreset set the defaults of Preset on the fields of Pod template which are not set
+func applyPreset(podTemp *v1.PodTemplateSpec, preset Preset) error {
+	if len(preset.Requests) > 0 {
+		requests, err := ResourceMapsToK8s(preset.Requests)
+		if err != nil {
+			return fieldErrorf("WithPreset", "preset %s:%v", preset.Name, err)
+		}
+		for index := range podTemp.Spec.Containers {
+			resources := &podTemp.Spec.Containers[index].Resources
+			defaults := v1.ResourceList{}
+			for name, quantity := range requests {
+				_, requested := resources.Requests[name]
+				_, limited := resources.Limits[name]
+				if !requested && !limited {
+					defaults[name] = quantity
+				}
+			}
+			if len(defaults) > 0 {
+				resources.Requests = mergeResourceList(resources.Requests, defaults)
+			}
+		}
+	}
+	if preset.AntiAffinity && len(podTemp.GetLabels()) > 0 &&
+		(podTemp.Spec.Affinity == nil || podTemp.Spec.Affinity.PodAntiAffinity == nil) {
+		if err := setPodAffinity(podTemp, "Preset "+preset.Name, true, 100, HostnameLabel, podTemp.GetLabels()); err != nil {
+			return err
+		}
+	}
+	if preset.ProbePath != "" && len(podTemp.Spec.Containers) > 0 && len(podTemp.Spec.Containers[0].Ports) > 0 {
+		container := &podTemp.Spec.Containers[0]
+		port := int(container.Ports[0].ContainerPort)
+		if container.LivenessProbe == nil {
+			container.LivenessProbe = httpProbe(FromInt(port), preset.ProbePath, 0, 0, 0)
+		}
+		if container.ReadinessProbe == nil {
+			container.ReadinessProbe = httpProbe(FromInt(port), preset.ProbePath, 5, 0, 0)
+		}
+	}
+	if preset.NonRoot && !runAsUserSet(podTemp.Spec) {
+		nonRoot := true
+		podSecurityContext(podTemp).RunAsNonRoot = &nonRoot
+	}
+	return nil
+}
+
+// runAsUserSet check the Pod or any container sets runAsNonRoot or runAsUser
+func runAsUserSet(pod v1.PodSpec) bool {
+	if sc := pod.SecurityContext; sc != nil && (sc.RunAsNonRoot != nil || sc.RunAsUser != nil) {
+		return true
+	}
+	for _, container := range append(append([]v1.Container(nil), pod.InitContainers...), pod.Containers...) {
+		if sc := container.SecurityContext; sc != nil && (sc.RunAsNonRoot != nil || sc.RunAsUser != nil) {
+			return true
+		}
+	}
+	return false
+}
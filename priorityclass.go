@@ -1,28 +1,72 @@
 package beku
 
 import (
-	"errors"
+	"context"
+	"fmt"
 
-	"k8s.io/kubernetes/pkg/apis/scheduling"
+	"k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // PriorityClass defines the mapping from a priority class name to the priority
 // integer value. The value can be any valid integer and err
 type PriorityClass struct {
-	pc  *scheduling.PriorityClass
+	pc  *schedulingv1.PriorityClass
 	err error
 }
 
 // NewPriorityClass create PriorityClass and Chain function call begin with this function.
-func NewPriorityClass() *PriorityClass { return &PriorityClass{pc: &scheduling.PriorityClass{}} }
+func NewPriorityClass() *PriorityClass { return &PriorityClass{pc: &schedulingv1.PriorityClass{}} }
+
+// PriorityClassFrom create PriorityClass from the existing PriorityClass,eg: the one got by client,
+// so it can be changed by the chain function and released again,
+// pc is deep copied and the fields which are not changed by the chain function are kept.
+func PriorityClassFrom(pc *schedulingv1.PriorityClass) *PriorityClass {
+	if pc == nil {
+		return NewPriorityClass()
+	}
+	return &PriorityClass{pc: pc.DeepCopy()}
+}
 
 // Finish Chain function call end with this function
 // return real PriorityClass(really service is kubernetes resource object PriorityClass and error
 // In the function, it will check necessary parametersainput the default field
-func (obj *PriorityClass) Finish() (pc *scheduling.PriorityClass, err error) {
-	obj.verify()
-	pc, err = obj.pc, obj.err
-	return
+func (obj *PriorityClass) Finish() (pc *schedulingv1.PriorityClass, err error) {
+	err = appendError(obj.err, obj.verify())
+	return obj.pc, err
+}
+
+// Validate check PriorityClass necessary value like Finish(),and return the problems as FieldError,
+// but PriorityClass is not changed, no Kind,APIVersion or default field is input,
+// so it can be used to check a partially built PriorityClass.
+func (obj *PriorityClass) Validate() []FieldError {
+	cp := &PriorityClass{pc: obj.pc.DeepCopy(), err: obj.err}
+	return FieldErrors(appendError(cp.err, cp.verify()))
+}
+
+// FinishObject same as Finish(), but return PriorityClass as runtime.Object,
+// so PriorityClass can be used as Builder, eg: add into Bundle.
+func (obj *PriorityClass) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// FinishUnchecked return PriorityClass without the checks and default fields of Finish(),only Kind and APIVersion are input,
+// it is for the partial PriorityClass which is patched onto the existing one,the error of the chain is still returned.
+func (obj *PriorityClass) FinishUnchecked() (*schedulingv1.PriorityClass, error) {
+	obj.pc.Kind, obj.pc.APIVersion = "PriorityClass", "scheduling.k8s.io/v1"
+	return obj.pc, obj.err
+}
+
+// JSONNew use json data create PriorityClass
+func (obj *PriorityClass) JSONNew(jsonbyts []byte) *PriorityClass {
+	obj.error(decodeJSON(jsonbyts, obj.pc))
+	return obj
+}
+
+// YAMLNew use yaml data create PriorityClass
+func (obj *PriorityClass) YAMLNew(yamlbyts []byte) *PriorityClass {
+	obj.error(decodeYAML(yamlbyts, obj.pc))
+	return obj
 }
 
 // SetName set priorityClass name
@@ -32,10 +76,10 @@ func (obj *PriorityClass) SetName(name string) *PriorityClass {
 }
 
 // SetValue set priorityClass priority value,The higher the value, the higher the priority.
-// The value range of 0<=prioriry<1000000000
+// The value range of 0<=prioriry<=1000000000,the higher values are reserved for the system PriorityClasses
 func (obj *PriorityClass) SetValue(prioriry int32) *PriorityClass {
-	if prioriry < 0 && prioriry > 1000000000 {
-		obj.error(errors.New("PriorityClass.Value must be in the range of 0 to 1000000000"))
+	if prioriry < 0 || prioriry > 1000000000 {
+		obj.error(fieldError("PriorityClass.Value", "must be in the range of 0 to 1000000000"))
 		return obj
 	}
 	obj.pc.Value = prioriry
@@ -67,22 +111,75 @@ func (obj *PriorityClass) SetDescription(desc string) *PriorityClass {
 	return obj
 }
 
-func (obj *PriorityClass) error(err error) {
-	if obj.err != nil {
-		return
+// SetPreemptionPolicy set whether the Pods of PriorityClass preempt the lower priority Pods,
+// policy is PreemptLowerPriority or Never,the Pods of Never are only scheduled ahead in the queue.
+func (obj *PriorityClass) SetPreemptionPolicy(policy string) *PriorityClass {
+	switch v1.PreemptionPolicy(policy) {
+	case v1.PreemptLowerPriority, v1.PreemptNever:
+	default:
+		obj.error(fieldErrorf("SetPreemptionPolicy", "policy %s is not allowed,only PreemptLowerPriority and Never", policy))
+		return obj
 	}
-	obj.err = err
+	preemption := v1.PreemptionPolicy(policy)
+	obj.pc.PreemptionPolicy = &preemption
+	return obj
+}
+
+// Release release PriorityClass on Kubernetes
+func (obj *PriorityClass) Release() (*schedulingv1.PriorityClass, error) {
+	pc, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return nil, err
+	}
+	return client.SchedulingV1().PriorityClasses().Create(context.TODO(), pc, metav1.CreateOptions{})
 }
 
-func (obj *PriorityClass) verify() {
-	if obj.err != nil {
-		return
+// Apply  it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist,the value of PriorityClass can't be changed by update.
+func (obj *PriorityClass) Apply() (*schedulingv1.PriorityClass, error) {
+	pc, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return nil, err
+	}
+	existing, err := client.SchedulingV1().PriorityClasses().Get(context.TODO(), pc.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.SchedulingV1().PriorityClasses().Create(context.TODO(), pc, metav1.CreateOptions{})
+	}
+	if existing.Value != pc.Value {
+		return nil, fmt.Errorf("PriorityClass %s Apply err,value %d can't be changed to %d,delete and create it again", pc.GetName(), existing.Value, pc.Value)
 	}
+	pc.ResourceVersion = existing.ResourceVersion
+	return client.SchedulingV1().PriorityClasses().Update(context.TODO(), pc, metav1.UpdateOptions{})
+}
+
+// String the current PriorityClass as yaml, the pending error is written on the top as comment.
+// it doesn't check and input default field like Finish(), so the PriorityClass may be incomplete.
+func (obj *PriorityClass) String() string { return dump("PriorityClass", obj.pc, obj.err) }
+
+// Dump print the current PriorityClass and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *PriorityClass) Dump() *PriorityClass {
+	fmt.Println(obj.String())
+	return obj
+}
+
+func (obj *PriorityClass) error(err error) {
+	obj.err = appendError(obj.err, err)
+}
 
+func (obj *PriorityClass) verify() error {
 	if !verifyString(obj.pc.GetName()) {
-		obj.err = errors.New("pc.Name is not allowed to be empty")
-		return
+		return fieldError("PriorityClass.Name", "is not allowed to be empty")
 	}
-	obj.pc.APIVersion = "scheduling.k8s.io/v1beta1"
+	obj.pc.APIVersion = "scheduling.k8s.io/v1"
 	obj.pc.Kind = "PriorityClass"
+	return nil
 }
@@ -0,0 +1,112 @@
+package beku
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+const (
+	// OperationRead is get,list and watch requests
+	OperationRead = "read"
+	// OperationWrite is create,update and patch requests
+	OperationWrite = "write"
+	// OperationDelete is delete requests
+	OperationDelete = "delete"
+)
+
+// rateLimits is the rate limiters shared by all clients created by RegisterK8sClient() config,
+// because beku creates the client for every operation,the limit of one client doesn't work.
+var rateLimits = struct {
+	sync.RWMutex
+	all        flowcontrol.RateLimiter
+	operations map[string]flowcontrol.RateLimiter
+}{operations: make(map[string]flowcontrol.RateLimiter)}
+
+// RegisterRateLimit set QPS and burst of all requests to Kubernetes apiServer of the client registered by RegisterK8sClient(),
+// so the bulk applies,eg: Bundle Apply(),are throttled by beku instead of API Priority and Fairness of apiServer.
+// qps 0 removes the limit and the default of client-go(5 QPS,10 burst) is used.
+// the clientset registered by RegisterClientset() is not limited,it has its own config.
+func RegisterRateLimit(qps float32, burst int) error {
+	limiter, err := newRateLimiter("RegisterRateLimit", qps, burst)
+	if err != nil {
+		return err
+	}
+	rateLimits.Lock()
+	defer rateLimits.Unlock()
+	rateLimits.all = limiter
+	return nil
+}
+
+// RegisterOperationRateLimit set QPS and burst of one kind of operation,
+// operation is OperationRead,OperationWrite or OperationDelete,eg: limit writes to 2 QPS but keep reads fast.
+// the request waits for both the operation limit and RegisterRateLimit(),qps 0 removes the limit of the operation.
+func RegisterOperationRateLimit(operation string, qps float32, burst int) error {
+	if operation != OperationRead && operation != OperationWrite && operation != OperationDelete {
+		return fmt.Errorf("RegisterOperationRateLimit err,operation %s is not allowed,only %s,%s or %s", operation, OperationRead, OperationWrite, OperationDelete)
+	}
+	limiter, err := newRateLimiter("RegisterOperationRateLimit", qps, burst)
+	if err != nil {
+		return err
+	}
+	rateLimits.Lock()
+	defer rateLimits.Unlock()
+	if limiter == nil {
+		delete(rateLimits.operations, operation)
+		return nil
+	}
+	rateLimits.operations[operation] = limiter
+	return nil
+}
+
+func newRateLimiter(method string, qps float32, burst int) (flowcontrol.RateLimiter, error) {
+	if qps == 0 {
+		return nil, nil
+	}
+	if qps < 0 || burst < 1 {
+		return nil, fmt.Errorf("%s err,qps is not allowed to be negative and burst must be at least 1", method)
+	}
+	return flowcontrol.NewTokenBucketRateLimiter(qps, burst), nil
+}
+
+// withRateLimit set the registered rate limiters into config
+func withRateLimit(config *rest.Config) *rest.Config {
+	rateLimits.RLock()
+	defer rateLimits.RUnlock()
+	if rateLimits.all != nil {
+		config.RateLimiter = rateLimits.all
+	}
+	if len(rateLimits.operations) > 0 {
+		operations := make(map[string]flowcontrol.RateLimiter, len(rateLimits.operations))
+		for operation, limiter := range rateLimits.operations {
+			operations[operation] = limiter
+		}
+		config.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+			return &operationLimiter{next: rt, limiters: operations}
+		}
+	}
+	return config
+}
+
+// operationLimiter wait for the limiter of the operation of the request before sending it
+type operationLimiter struct {
+	next     http.RoundTripper
+	limiters map[string]flowcontrol.RateLimiter
+}
+
+func (l *operationLimiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	operation := OperationWrite
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		operation = OperationRead
+	case http.MethodDelete:
+		operation = OperationDelete
+	}
+	if limiter, ok := l.limiters[operation]; ok {
+		limiter.Accept()
+	}
+	return l.next.RoundTrip(req)
+}
@@ -0,0 +1,110 @@
+package beku
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// recreateOnSelectorChange delete the workload on Kubernetes when its selector is different from obj,
+// because the selector is immutable,the workload with new selector can only be created after the old one is deleted.
+// the old one is deleted in foreground,it returns after the old Pods are deleted,so the old and new Pods don't run together.
+func recreateOnSelectorChange(ctx context.Context, client *Client, obj runtime.Object) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	rc, err := client.resourceClient(obj)
+	if err != nil {
+		return err
+	}
+	current, err := rc.get(ctx, accessor.GetName())
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	kind := objectKind(obj)
+	if verifySelectorUnchanged(kind, workloadSelector(current), workloadSelector(obj)) == nil {
+		return nil
+	}
+	currentAccessor, err := meta.Accessor(current)
+	if err != nil {
+		return err
+	}
+	policy := metav1.DeletePropagationForeground
+	uid := currentAccessor.GetUID()
+	// the precondition makes sure the object read above is deleted,not the one created by others after it
+	options := &metav1.DeleteOptions{PropagationPolicy: &policy, Preconditions: &metav1.Preconditions{UID: &uid}}
+	if err := rc.delete(ctx, accessor.GetName(), *options); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("%s recreate err,delete %s failed:%v", kind, objectName(obj), err)
+	}
+	ticker := time.NewTicker(ReadyPollInterval)
+	defer ticker.Stop()
+	for {
+		_, err := rc.get(ctx, accessor.GetName())
+		if apierrors.IsNotFound(err) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s recreate err,%s is not deleted:%v", kind, objectName(obj), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+	accessor.SetResourceVersion("")
+	accessor.SetUID("")
+	return nil
+}
+
+// workloadSelector get the selector of the workload,nil when the object is not workload
+func workloadSelector(obj runtime.Object) *metav1.LabelSelector {
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		return o.Spec.Selector
+	case *appsv1.StatefulSet:
+		return o.Spec.Selector
+	case *appsv1.DaemonSet:
+		return o.Spec.Selector
+	}
+	return nil
+}
+
+// applyRecreated create the workload when it doesn't exist,otherwise update it,
+// the revision written by Deployment controller is kept,it is called after recreateOnSelectorChange().
+func applyRecreated(ctx context.Context, client *Client, obj runtime.Object) (runtime.Object, error) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := client.resourceClient(obj)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := rc.get(ctx, accessor.GetName())
+	if apierrors.IsNotFound(err) {
+		return rc.create(ctx, obj)
+	}
+	if err != nil {
+		return nil, err
+	}
+	existingAccessor, err := meta.Accessor(existing)
+	if err != nil {
+		return nil, err
+	}
+	if dp, ok := obj.(*appsv1.Deployment); ok {
+		keepRevision(&existing.(*appsv1.Deployment).ObjectMeta, &dp.ObjectMeta)
+	}
+	accessor.SetResourceVersion(existingAccessor.GetResourceVersion())
+	return rc.update(ctx, obj)
+}
@@ -0,0 +1,152 @@
+package beku
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// dockerHub is the registry of the images without registry,eg: nginx is docker.io/library/nginx
+const dockerHub = "docker.io"
+
+// registryRewrite rewrite the images of registry prefix from to prefix to
+type registryRewrite struct {
+	from string
+	to   string
+}
+
+// registryRewrites is the rewrites registered by RegisterRegistryRewrite()
+var registryRewrites = struct {
+	sync.RWMutex
+	rules []registryRewrite
+}{}
+
+// RegisterRegistryRewrite rewrite the images of the containers whose registry prefix is from to the prefix to
+// in Finish() of Deployment,StatefulSet and DaemonSet,eg: docker.io -> registry.internal/mirror for air-gapped clusters.
+// from docker.io also matches the images without registry,eg: nginx is rewritten to registry.internal/mirror/library/nginx.
+// the rewrites are tried in order of registration and the first matched one is used,empty to removes the rewrite of from.
+func RegisterRegistryRewrite(from, to string) error {
+	rule, err := newRegistryRewrite("RegisterRegistryRewrite", from, to)
+	if err != nil {
+		return err
+	}
+	registryRewrites.Lock()
+	defer registryRewrites.Unlock()
+	registryRewrites.rules = setRegistryRewrite(registryRewrites.rules, rule)
+	return nil
+}
+
+// SetRegistryRewrite rewrite the images of the objects finished by FinishAll() like RegisterRegistryRewrite(),
+// it only affects this Bundle,eg: Pod,Job and Rollout are rewritten too.
+// the images are rewritten after Finish() of the builders,so from should match the images rewritten by RegisterRegistryRewrite(),
+// and the objects added by Add() are not rewritten.
+func (b *Bundle) SetRegistryRewrite(from, to string) *Bundle {
+	rule, err := newRegistryRewrite("Bundle SetRegistryRewrite", from, to)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	b.rewrites = setRegistryRewrite(b.rewrites, rule)
+	return b
+}
+
+func newRegistryRewrite(method, from, to string) (registryRewrite, error) {
+	from, to = strings.TrimSuffix(from, "/"), strings.TrimSuffix(to, "/")
+	if !verifyString(from) {
+		return registryRewrite{}, errors.New(method + " err,from is not allowed to be empty")
+	}
+	return registryRewrite{from: from, to: to}, nil
+}
+
+// setRegistryRewrite replace the rewrite of the same from,or append it,the rewrite with empty to is removed.
+// the rules are copied,so the rules read by others are not changed.
+func setRegistryRewrite(rules []registryRewrite, rule registryRewrite) []registryRewrite {
+	updated := make([]registryRewrite, 0, len(rules)+1)
+	found := false
+	for _, existing := range rules {
+		if existing.from != rule.from {
+			updated = append(updated, existing)
+			continue
+		}
+		found = true
+		if rule.to != "" {
+			updated = append(updated, rule)
+		}
+	}
+	if !found && rule.to != "" {
+		updated = append(updated, rule)
+	}
+	return updated
+}
+
+// rewriteImage rewrite the image by the first matched rewrite,ok is false when no rewrite matches
+func rewriteImage(image string, rules []registryRewrite) (string, bool) {
+	for _, rule := range rules {
+		if image == rule.from || strings.HasPrefix(image, rule.from+"/") {
+			return rule.to + strings.TrimPrefix(image, rule.from), true
+		}
+		if rule.from == dockerHub && !hasRegistry(image) {
+			if !strings.Contains(image, "/") {
+				image = "library/" + image
+			}
+			return rule.to + "/" + image, true
+		}
+	}
+	return image, false
+}
+
+// hasRegistry the first component of the image is registry host,eg: quay.io/coreos/etcd,localhost:5000/app
+func hasRegistry(image string) bool {
+	index := strings.Index(image, "/")
+	if index < 0 {
+		return false
+	}
+	host := image[:index]
+	return strings.ContainsAny(host, ".:") || host == "localhost"
+}
+
+// rewritePodImages rewrite the images of the containers of spec by the registered rewrites,
+// mutable get the spec to change,so the spec shared with others is only copied when an image is rewritten.
+func rewritePodImages(spec *v1.PodSpec, mutable func() *v1.PodSpec) {
+	registryRewrites.RLock()
+	rules := registryRewrites.rules
+	registryRewrites.RUnlock()
+	rewritePodSpecImages(spec, mutable, rules)
+}
+
+func rewritePodSpecImages(spec *v1.PodSpec, mutable func() *v1.PodSpec, rules []registryRewrite) {
+	if len(rules) == 0 {
+		return
+	}
+	for index, container := range spec.InitContainers {
+		if image, ok := rewriteImage(container.Image, rules); ok {
+			mutable().InitContainers[index].Image = image
+		}
+	}
+	for index, container := range spec.Containers {
+		if image, ok := rewriteImage(container.Image, rules); ok {
+			mutable().Containers[index].Image = image
+		}
+	}
+}
+
+// rewriteObjectImages rewrite the images of the finished object by the rewrites of Bundle,
+// the containers may be shared with Template and its other instances,so they are copied before the first rewrite.
+func rewriteObjectImages(obj runtime.Object, rules []registryRewrite) {
+	spec := podSpecOf(obj)
+	if spec == nil {
+		return
+	}
+	copied := false
+	rewritePodSpecImages(spec, func() *v1.PodSpec {
+		if !copied {
+			spec.InitContainers = append([]v1.Container(nil), spec.InitContainers...)
+			spec.Containers = append([]v1.Container(nil), spec.Containers...)
+			copied = true
+		}
+		return spec
+	}, rules)
+}
@@ -0,0 +1,114 @@
+package beku
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// setResizePolicy set the resize policy of cpu and memory of the container,empty policy is not set,
+// policy is ResizeNotRequired or ResizeRestartContainer.
+func setResizePolicy(podTemp *v1.PodTemplateSpec, container, cpuPolicy, memPolicy string) error {
+	index := containerIndex(podTemp.Spec.Containers, container)
+	if index < 0 {
+		return fmt.Errorf("SetResizePolicy err,container %s is not found", container)
+	}
+	if cpuPolicy == "" && memPolicy == "" {
+		return errors.New("SetResizePolicy err,cpuPolicy and memPolicy are not allowed to be empty both")
+	}
+	policies := podTemp.Spec.Containers[index].ResizePolicy
+	for resourceName, policy := range map[v1.ResourceName]string{v1.ResourceCPU: cpuPolicy, v1.ResourceMemory: memPolicy} {
+		if policy == "" {
+			continue
+		}
+		if policy != ResizeNotRequired && policy != ResizeRestartContainer {
+			return fmt.Errorf("SetResizePolicy err,%s policy %s is not allowed,only %s or %s", resourceName, policy, ResizeNotRequired, ResizeRestartContainer)
+		}
+		policies = setContainerResizePolicy(policies, resourceName, v1.ResourceResizeRestartPolicy(policy))
+	}
+	podTemp.Spec.Containers[index].ResizePolicy = policies
+	return nil
+}
+
+func setContainerResizePolicy(policies []v1.ContainerResizePolicy, resourceName v1.ResourceName, policy v1.ResourceResizeRestartPolicy) []v1.ContainerResizePolicy {
+	for index := range policies {
+		if policies[index].ResourceName == resourceName {
+			policies[index].RestartPolicy = policy
+			return policies
+		}
+	}
+	return append(policies, v1.ContainerResizePolicy{ResourceName: resourceName, RestartPolicy: policy})
+}
+
+func containerIndex(containers []v1.Container, name string) int {
+	for index := range containers {
+		if containers[index].Name == name {
+			return index
+		}
+	}
+	return -1
+}
+
+// resizePods resize the container of the Pods selected by selector in place by the resize subresource of Pod,
+// the container is restarted only when its resize policy of the changed resource is RestartContainer.
+func resizePods(ctx context.Context, client kubernetes.Interface, namespace string, selector *metav1.LabelSelector, container string, resources ResourceRequirements) error {
+	if !verifyString(container) {
+		return errors.New("Resize err,container is not allowed to be empty")
+	}
+	requirements := make(map[string]v1.ResourceList, 2)
+	if len(resources.Limits) > 0 {
+		limits, err := ResourceMapsToK8s(resources.Limits)
+		if err != nil {
+			return fmt.Errorf("Resize err,limits:%v", err)
+		}
+		requirements["limits"] = limits
+	}
+	if len(resources.Requests) > 0 {
+		requests, err := ResourceMapsToK8s(resources.Requests)
+		if err != nil {
+			return fmt.Errorf("Resize err,requests:%v", err)
+		}
+		requirements["requests"] = requests
+	}
+	if len(requirements) == 0 {
+		return errors.New("Resize err,limits and requests are not allowed to be empty both")
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{map[string]interface{}{"name": container, "resources": requirements}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	podSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return err
+	}
+	if podSelector.Empty() {
+		return errors.New("Resize err,selector is not allowed to be empty")
+	}
+	pods := client.CoreV1().Pods(namespace)
+	podList, err := pods.List(ctx, metav1.ListOptions{LabelSelector: podSelector.String()})
+	if err != nil {
+		return fmt.Errorf("Resize err,list Pods failed:%v", err)
+	}
+	for _, pod := range podList.Items {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if containerIndex(pod.Spec.Containers, container) < 0 {
+			return fmt.Errorf("Resize err,container %s is not found in Pod %s", container, pod.Name)
+		}
+		if _, err := pods.Patch(ctx, pod.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{}, "resize"); err != nil {
+			return fmt.Errorf("Resize err,resize Pod %s failed:%v", pod.Name, err)
+		}
+	}
+	return nil
+}
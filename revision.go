@@ -0,0 +1,141 @@
+package beku
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// ChangeCauseAnnotation is the annotation shown as CHANGE-CAUSE by `kubectl rollout history`
+	ChangeCauseAnnotation = "kubernetes.io/change-cause"
+	// RevisionAnnotation is the annotation of revision which Deployment controller writes on Deployment and ReplicaSet
+	RevisionAnnotation = "deployment.kubernetes.io/revision"
+)
+
+// RolloutRevision is one revision in rollout history of Deployment,StatefulSet or DaemonSet
+type RolloutRevision struct {
+	Revision          int64     `json:"revision"`
+	ChangeCause       string    `json:"changeCause,omitempty"`
+	CreationTimestamp time.Time `json:"creationTimestamp"`
+}
+
+// setChangeCause set change-cause annotation into annotations,return the new annotations when it is nil
+func setChangeCause(annotations map[string]string, msg string) map[string]string {
+	if annotations == nil {
+		annotations = make(map[string]string, 1)
+	}
+	annotations[ChangeCauseAnnotation] = msg
+	return annotations
+}
+
+// keepRevision keep the revision written by Deployment controller when the Deployment is updated,
+// otherwise update removes it and the controller has to write it again.
+func keepRevision(existing *metav1.ObjectMeta, update *metav1.ObjectMeta) {
+	revision, ok := existing.Annotations[RevisionAnnotation]
+	if !ok {
+		return
+	}
+	if _, ok := update.Annotations[RevisionAnnotation]; ok {
+		return
+	}
+	if update.Annotations == nil {
+		update.Annotations = make(map[string]string, 1)
+	}
+	update.Annotations[RevisionAnnotation] = revision
+}
+
+// clientOrRegistered get client,the registered one is used when client is nil
+func clientOrRegistered(client kubernetes.Interface) (kubernetes.Interface, error) {
+	if client != nil {
+		return client, nil
+	}
+	return getKubeInterface()
+}
+
+// deploymentHistory get the revisions of the Deployment from its ReplicaSets,like `kubectl rollout history deployment`
+func deploymentHistory(client kubernetes.Interface, namespace, name string) ([]RolloutRevision, error) {
+	dp, err := client.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(dp.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	rsList, err := client.AppsV1().ReplicaSets(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	var history []RolloutRevision
+	for index := range rsList.Items {
+		rs := &rsList.Items[index]
+		if !metav1.IsControlledBy(rs, dp) {
+			continue
+		}
+		revision, err := strconv.ParseInt(rs.Annotations[RevisionAnnotation], 10, 64)
+		if err != nil {
+			continue
+		}
+		history = append(history, RolloutRevision{
+			Revision:          revision,
+			ChangeCause:       rs.Annotations[ChangeCauseAnnotation],
+			CreationTimestamp: rs.CreationTimestamp.Time,
+		})
+	}
+	sortRevisions(history)
+	return history, nil
+}
+
+// controllerRevisionHistory get the revisions of StatefulSet or DaemonSet from its ControllerRevisions,
+// like `kubectl rollout history statefulset`.
+func controllerRevisionHistory(client kubernetes.Interface, owner metav1.Object, selector *metav1.LabelSelector) ([]RolloutRevision, error) {
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	crList, err := client.AppsV1().ControllerRevisions(owner.GetNamespace()).List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector.String()})
+	if err != nil {
+		return nil, err
+	}
+	var history []RolloutRevision
+	for index := range crList.Items {
+		cr := &crList.Items[index]
+		if !metav1.IsControlledBy(cr, owner) {
+			continue
+		}
+		history = append(history, RolloutRevision{
+			Revision:          cr.Revision,
+			ChangeCause:       cr.Annotations[ChangeCauseAnnotation],
+			CreationTimestamp: cr.CreationTimestamp.Time,
+		})
+	}
+	sortRevisions(history)
+	return history, nil
+}
+
+func sortRevisions(history []RolloutRevision) {
+	sort.Slice(history, func(i, j int) bool { return history[i].Revision < history[j].Revision })
+}
+
+// statefulSetHistory get the revisions of the StatefulSet
+func statefulSetHistory(client kubernetes.Interface, namespace, name string) ([]RolloutRevision, error) {
+	sts, err := client.AppsV1().StatefulSets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return controllerRevisionHistory(client, sts, sts.Spec.Selector)
+}
+
+// daemonSetHistory get the revisions of the DaemonSet
+func daemonSetHistory(client kubernetes.Interface, namespace, name string) ([]RolloutRevision, error) {
+	ds, err := client.AppsV1().DaemonSets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return controllerRevisionHistory(client, ds, ds.Spec.Selector)
+}
@@ -0,0 +1,436 @@
+package beku
+
+import (
+	"context"
+	"fmt"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Role include Kubernetes resource object Role(rbac.authorization.k8s.io/v1) and error,
+// Role grants the permissions in its namespace.
+type Role struct {
+	role *rbacv1.Role
+	err  error
+}
+
+// NewRole create Role and chain function call begin with this function.
+func NewRole() *Role { return &Role{role: &rbacv1.Role{}} }
+
+// RoleFrom create Role from the existing Role,eg: the one got by client,
+// so it can be changed by the chain function and released again,
+// role is deep copied and the fields which are not changed by the chain function are kept.
+func RoleFrom(role *rbacv1.Role) *Role {
+	if role == nil {
+		return NewRole()
+	}
+	return &Role{role: role.DeepCopy()}
+}
+
+// Finish Chain function call end with this function
+// return real Role(really Role is kubernetes resource object Role and error
+// In the function, it will check necessary parameters、input the default field
+func (obj *Role) Finish() (*rbacv1.Role, error) {
+	err := appendError(obj.err, obj.verify())
+	return obj.role, err
+}
+
+// Validate check Role necessary value like Finish(),and return the problems as FieldError,
+// but Role is not changed, no Kind,APIVersion or default field is input,
+// so it can be used to check a partially built Role.
+func (obj *Role) Validate() []FieldError {
+	cp := &Role{role: obj.role.DeepCopy(), err: obj.err}
+	return FieldErrors(appendError(cp.err, cp.verify()))
+}
+
+// FinishObject same as Finish(), but return Role as runtime.Object,
+// so Role can be used as Builder, eg: add into Bundle.
+func (obj *Role) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// JSONNew use json data create Role
+func (obj *Role) JSONNew(jsonbyts []byte) *Role {
+	obj.error(decodeJSON(jsonbyts, obj.role))
+	return obj
+}
+
+// YAMLNew use yaml data create Role
+func (obj *Role) YAMLNew(yamlbyts []byte) *Role {
+	obj.error(decodeYAML(yamlbyts, obj.role))
+	return obj
+}
+
+// Replace replace Role by Kubernetes resource object
+func (obj *Role) Replace(role *rbacv1.Role) *Role {
+	if role != nil {
+		obj.role = role
+	}
+	return obj
+}
+
+// SetName set Role name
+func (obj *Role) SetName(name string) *Role {
+	obj.role.SetName(name)
+	return obj
+}
+
+// SetNamespace set Role namespace,default namespace is 'default'
+func (obj *Role) SetNamespace(namespace string) *Role {
+	obj.role.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set Role namespace and name
+func (obj *Role) SetNamespaceAndName(namespace, name string) *Role {
+	obj.role.SetName(name)
+	obj.role.SetNamespace(namespace)
+	return obj
+}
+
+// SetLabels set Role labels
+func (obj *Role) SetLabels(labels map[string]string) *Role {
+	obj.role.SetLabels(labels)
+	return obj
+}
+
+// AddLabel add or overwrite one label of Role,the other labels are kept
+func (obj *Role) AddLabel(key, value string) *Role {
+	obj.error(addLabel(obj.role, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of Role,the other annotations are kept
+func (obj *Role) AddAnnotation(key, value string) *Role {
+	obj.error(addAnnotation(obj.role, key, value))
+	return obj
+}
+
+// AddRule grant verbs on resources of apiGroups,the core group is "",
+// eg: AddRule([]string{"get", "list", "watch"}, []string{""}, []string{"pods", "pods/log"}).
+func (obj *Role) AddRule(verbs, apiGroups, resources []string) *Role {
+	rule, err := policyRule("AddRule", verbs, apiGroups, resources, nil)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.role.Rules = append(obj.role.Rules, rule)
+	return obj
+}
+
+// AddResourceNameRule same as AddRule(),but only the resources of resourceNames are granted,
+// eg: AddResourceNameRule([]string{"get"}, []string{""}, []string{"configmaps"}, "app-config").
+func (obj *Role) AddResourceNameRule(verbs, apiGroups, resources []string, resourceNames ...string) *Role {
+	rule, err := policyRule("AddResourceNameRule", verbs, apiGroups, resources, resourceNames)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.role.Rules = append(obj.role.Rules, rule)
+	return obj
+}
+
+// Release release Role on Kubernetes
+func (obj *Role) Release() (*rbacv1.Role, error) {
+	role, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return nil, err
+	}
+	return client.RbacV1().Roles(role.GetNamespace()).Create(context.TODO(), role, metav1.CreateOptions{})
+}
+
+// Apply  it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist.
+func (obj *Role) Apply() (*rbacv1.Role, error) {
+	role, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return nil, err
+	}
+	_, err = client.RbacV1().Roles(role.GetNamespace()).Get(context.TODO(), role.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.RbacV1().Roles(role.GetNamespace()).Create(context.TODO(), role, metav1.CreateOptions{})
+	}
+	return client.RbacV1().Roles(role.GetNamespace()).Update(context.TODO(), role, metav1.UpdateOptions{})
+}
+
+// Delete delete Role on Kubernetes by its name and namespace,
+// opts[0] sets the propagation policy and grace period.
+func (obj *Role) Delete(opts ...DeleteOptions) error {
+	if obj.err != nil {
+		return obj.err
+	}
+	options, err := newDeleteOptions("Role", obj.role.GetName(), opts)
+	if err != nil {
+		return err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return err
+	}
+	return client.RbacV1().Roles(obj.role.GetNamespace()).Delete(context.TODO(), obj.role.GetName(), *options)
+}
+
+// String the current Role as yaml, the pending error is written on the top as comment.
+// it doesn't check and input default field like Finish(), so the Role may be incomplete.
+func (obj *Role) String() string { return dump("Role", obj.role, obj.err) }
+
+// Dump print the current Role and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *Role) Dump() *Role {
+	fmt.Println(obj.String())
+	return obj
+}
+
+func (obj *Role) error(err error) {
+	obj.err = appendError(obj.err, err)
+}
+
+// verify check Role necessary value, input the default field.
+func (obj *Role) verify() error {
+	var errs error
+	if !verifyString(obj.role.GetName()) {
+		errs = appendError(errs, fieldError("Role.Name", "is not allowed to be empty"))
+	}
+	if len(obj.role.Rules) == 0 {
+		errs = appendError(errs, fieldError("Role.Rules", "is not allowed to be empty,you can call AddRule() add it"))
+	}
+	if errs != nil {
+		return errs
+	}
+	obj.role.Kind = "Role"
+	obj.role.APIVersion = "rbac.authorization.k8s.io/v1"
+	return nil
+}
+
+// ClusterRole include Kubernetes resource object ClusterRole(rbac.authorization.k8s.io/v1) and error,
+// ClusterRole grants the permissions in all namespaces,on cluster scoped resources and non-resource URLs.
+type ClusterRole struct {
+	role *rbacv1.ClusterRole
+	err  error
+}
+
+// NewClusterRole create ClusterRole and chain function call begin with this function.
+func NewClusterRole() *ClusterRole { return &ClusterRole{role: &rbacv1.ClusterRole{}} }
+
+// ClusterRoleFrom create ClusterRole from the existing ClusterRole,eg: the one got by client,
+// so it can be changed by the chain function and released again,
+// role is deep copied and the fields which are not changed by the chain function are kept.
+func ClusterRoleFrom(role *rbacv1.ClusterRole) *ClusterRole {
+	if role == nil {
+		return NewClusterRole()
+	}
+	return &ClusterRole{role: role.DeepCopy()}
+}
+
+// Finish Chain function call end with this function
+// return real ClusterRole(really ClusterRole is kubernetes resource object ClusterRole and error
+// In the function, it will check necessary parameters、input the default field
+func (obj *ClusterRole) Finish() (*rbacv1.ClusterRole, error) {
+	err := appendError(obj.err, obj.verify())
+	return obj.role, err
+}
+
+// Validate check ClusterRole necessary value like Finish(),and return the problems as FieldError,
+// but ClusterRole is not changed, no Kind,APIVersion or default field is input,
+// so it can be used to check a partially built ClusterRole.
+func (obj *ClusterRole) Validate() []FieldError {
+	cp := &ClusterRole{role: obj.role.DeepCopy(), err: obj.err}
+	return FieldErrors(appendError(cp.err, cp.verify()))
+}
+
+// FinishObject same as Finish(), but return ClusterRole as runtime.Object,
+// so ClusterRole can be used as Builder, eg: add into Bundle.
+func (obj *ClusterRole) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// JSONNew use json data create ClusterRole
+func (obj *ClusterRole) JSONNew(jsonbyts []byte) *ClusterRole {
+	obj.error(decodeJSON(jsonbyts, obj.role))
+	return obj
+}
+
+// YAMLNew use yaml data create ClusterRole
+func (obj *ClusterRole) YAMLNew(yamlbyts []byte) *ClusterRole {
+	obj.error(decodeYAML(yamlbyts, obj.role))
+	return obj
+}
+
+// Replace replace ClusterRole by Kubernetes resource object
+func (obj *ClusterRole) Replace(role *rbacv1.ClusterRole) *ClusterRole {
+	if role != nil {
+		obj.role = role
+	}
+	return obj
+}
+
+// SetName set ClusterRole name
+func (obj *ClusterRole) SetName(name string) *ClusterRole {
+	obj.role.SetName(name)
+	return obj
+}
+
+// SetLabels set ClusterRole labels
+func (obj *ClusterRole) SetLabels(labels map[string]string) *ClusterRole {
+	obj.role.SetLabels(labels)
+	return obj
+}
+
+// AddLabel add or overwrite one label of ClusterRole,the other labels are kept,
+// eg: rbac.authorization.k8s.io/aggregate-to-view aggregates the rules into the default view ClusterRole.
+func (obj *ClusterRole) AddLabel(key, value string) *ClusterRole {
+	obj.error(addLabel(obj.role, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of ClusterRole,the other annotations are kept
+func (obj *ClusterRole) AddAnnotation(key, value string) *ClusterRole {
+	obj.error(addAnnotation(obj.role, key, value))
+	return obj
+}
+
+// AddRule grant verbs on resources of apiGroups,the core group is "",
+// eg: AddRule([]string{"get", "list", "watch"}, []string{""}, []string{"nodes"}).
+func (obj *ClusterRole) AddRule(verbs, apiGroups, resources []string) *ClusterRole {
+	rule, err := policyRule("AddRule", verbs, apiGroups, resources, nil)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.role.Rules = append(obj.role.Rules, rule)
+	return obj
+}
+
+// AddResourceNameRule same as AddRule(),but only the resources of resourceNames are granted
+func (obj *ClusterRole) AddResourceNameRule(verbs, apiGroups, resources []string, resourceNames ...string) *ClusterRole {
+	rule, err := policyRule("AddResourceNameRule", verbs, apiGroups, resources, resourceNames)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.role.Rules = append(obj.role.Rules, rule)
+	return obj
+}
+
+// AddNonResourceRule grant verbs on the non-resource URLs,eg: AddNonResourceRule([]string{"get"}, "/healthz", "/metrics")
+func (obj *ClusterRole) AddNonResourceRule(verbs []string, urls ...string) *ClusterRole {
+	if len(verbs) == 0 || len(urls) == 0 {
+		obj.error(fieldError("AddNonResourceRule", "verbs and urls are not allowed to be empty"))
+		return obj
+	}
+	obj.role.Rules = append(obj.role.Rules, rbacv1.PolicyRule{
+		Verbs:           append([]string(nil), verbs...),
+		NonResourceURLs: append([]string(nil), urls...),
+	})
+	return obj
+}
+
+// Release release ClusterRole on Kubernetes
+func (obj *ClusterRole) Release() (*rbacv1.ClusterRole, error) {
+	role, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return nil, err
+	}
+	return client.RbacV1().ClusterRoles().Create(context.TODO(), role, metav1.CreateOptions{})
+}
+
+// Apply  it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist.
+func (obj *ClusterRole) Apply() (*rbacv1.ClusterRole, error) {
+	role, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return nil, err
+	}
+	_, err = client.RbacV1().ClusterRoles().Get(context.TODO(), role.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.RbacV1().ClusterRoles().Create(context.TODO(), role, metav1.CreateOptions{})
+	}
+	return client.RbacV1().ClusterRoles().Update(context.TODO(), role, metav1.UpdateOptions{})
+}
+
+// Delete delete ClusterRole on Kubernetes by its name,
+// opts[0] sets the propagation policy and grace period.
+func (obj *ClusterRole) Delete(opts ...DeleteOptions) error {
+	if obj.err != nil {
+		return obj.err
+	}
+	options, err := newDeleteOptions("ClusterRole", obj.role.GetName(), opts)
+	if err != nil {
+		return err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return err
+	}
+	return client.RbacV1().ClusterRoles().Delete(context.TODO(), obj.role.GetName(), *options)
+}
+
+// String the current ClusterRole as yaml, the pending error is written on the top as comment.
+// it doesn't check and input default field like Finish(), so the ClusterRole may be incomplete.
+func (obj *ClusterRole) String() string { return dump("ClusterRole", obj.role, obj.err) }
+
+// Dump print the current ClusterRole and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *ClusterRole) Dump() *ClusterRole {
+	fmt.Println(obj.String())
+	return obj
+}
+
+func (obj *ClusterRole) error(err error) {
+	obj.err = appendError(obj.err, err)
+}
+
+// verify check ClusterRole necessary value, input the default field.
+func (obj *ClusterRole) verify() error {
+	var errs error
+	if !verifyString(obj.role.GetName()) {
+		errs = appendError(errs, fieldError("ClusterRole.Name", "is not allowed to be empty"))
+	}
+	if len(obj.role.Rules) == 0 && obj.role.AggregationRule == nil {
+		errs = appendError(errs, fieldError("ClusterRole.Rules", "is not allowed to be empty,you can call AddRule() add it"))
+	}
+	if errs != nil {
+		return errs
+	}
+	obj.role.Kind = "ClusterRole"
+	obj.role.APIVersion = "rbac.authorization.k8s.io/v1"
+	return nil
+}
+
+// policyRule create the rule of Role and ClusterRole,empty apiGroups is the core group
+func policyRule(method string, verbs, apiGroups, resources, resourceNames []string) (rbacv1.PolicyRule, error) {
+	if len(verbs) == 0 {
+		return rbacv1.PolicyRule{}, fieldErrorf(method, "verbs are not allowed to be empty")
+	}
+	if len(resources) == 0 {
+		return rbacv1.PolicyRule{}, fieldErrorf(method, "resources are not allowed to be empty")
+	}
+	for _, verb := range verbs {
+		if !verifyString(verb) {
+			return rbacv1.PolicyRule{}, fieldErrorf(method, "verb is not allowed to be empty")
+		}
+	}
+	if len(apiGroups) == 0 {
+		apiGroups = []string{""}
+	}
+	return rbacv1.PolicyRule{
+		Verbs:         append([]string(nil), verbs...),
+		APIGroups:     append([]string(nil), apiGroups...),
+		Resources:     append([]string(nil), resources...),
+		ResourceNames: append([]string(nil), resourceNames...),
+	}, nil
+}
@@ -0,0 +1,499 @@
+package beku
+
+import (
+	"context"
+	"fmt"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// RoleBinding include Kubernetes resource object RoleBinding(rbac.authorization.k8s.io/v1) and error,
+// RoleBinding grants the permissions of Role or ClusterRole to the subjects in its namespace.
+type RoleBinding struct {
+	rb  *rbacv1.RoleBinding
+	err error
+}
+
+// NewRoleBinding create RoleBinding and chain function call begin with this function.
+func NewRoleBinding() *RoleBinding { return &RoleBinding{rb: &rbacv1.RoleBinding{}} }
+
+// RoleBindingFrom create RoleBinding from the existing RoleBinding,eg: the one got by client,
+// so it can be changed by the chain function and released again,
+// rb is deep copied and the fields which are not changed by the chain function are kept.
+func RoleBindingFrom(rb *rbacv1.RoleBinding) *RoleBinding {
+	if rb == nil {
+		return NewRoleBinding()
+	}
+	return &RoleBinding{rb: rb.DeepCopy()}
+}
+
+// Finish Chain function call end with this function
+// return real RoleBinding(really RoleBinding is kubernetes resource object RoleBinding and error
+// In the function, it will check necessary parameters、input the default field
+func (obj *RoleBinding) Finish() (*rbacv1.RoleBinding, error) {
+	err := appendError(obj.err, obj.verify())
+	return obj.rb, err
+}
+
+// Validate check RoleBinding necessary value like Finish(),and return the problems as FieldError,
+// but RoleBinding is not changed, no Kind,APIVersion or default field is input,
+// so it can be used to check a partially built RoleBinding.
+func (obj *RoleBinding) Validate() []FieldError {
+	cp := &RoleBinding{rb: obj.rb.DeepCopy(), err: obj.err}
+	return FieldErrors(appendError(cp.err, cp.verify()))
+}
+
+// FinishObject same as Finish(), but return RoleBinding as runtime.Object,
+// so RoleBinding can be used as Builder, eg: add into Bundle.
+func (obj *RoleBinding) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// JSONNew use json data create RoleBinding
+func (obj *RoleBinding) JSONNew(jsonbyts []byte) *RoleBinding {
+	obj.error(decodeJSON(jsonbyts, obj.rb))
+	return obj
+}
+
+// YAMLNew use yaml data create RoleBinding
+func (obj *RoleBinding) YAMLNew(yamlbyts []byte) *RoleBinding {
+	obj.error(decodeYAML(yamlbyts, obj.rb))
+	return obj
+}
+
+// Replace replace RoleBinding by Kubernetes resource object
+func (obj *RoleBinding) Replace(rb *rbacv1.RoleBinding) *RoleBinding {
+	if rb != nil {
+		obj.rb = rb
+	}
+	return obj
+}
+
+// SetName set RoleBinding name
+func (obj *RoleBinding) SetName(name string) *RoleBinding {
+	obj.rb.SetName(name)
+	return obj
+}
+
+// SetNamespace set RoleBinding namespace,default namespace is 'default'
+func (obj *RoleBinding) SetNamespace(namespace string) *RoleBinding {
+	obj.rb.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set RoleBinding namespace and name
+func (obj *RoleBinding) SetNamespaceAndName(namespace, name string) *RoleBinding {
+	obj.rb.SetName(name)
+	obj.rb.SetNamespace(namespace)
+	return obj
+}
+
+// SetLabels set RoleBinding labels
+func (obj *RoleBinding) SetLabels(labels map[string]string) *RoleBinding {
+	obj.rb.SetLabels(labels)
+	return obj
+}
+
+// AddLabel add or overwrite one label of RoleBinding,the other labels are kept
+func (obj *RoleBinding) AddLabel(key, value string) *RoleBinding {
+	obj.error(addLabel(obj.rb, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of RoleBinding,the other annotations are kept
+func (obj *RoleBinding) AddAnnotation(key, value string) *RoleBinding {
+	obj.error(addAnnotation(obj.rb, key, value))
+	return obj
+}
+
+// SetRole grant the permissions of the Role in the same namespace
+func (obj *RoleBinding) SetRole(name string) *RoleBinding {
+	ref, err := roleRef("SetRole", "Role", name)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.rb.RoleRef = ref
+	return obj
+}
+
+// SetClusterRole grant the permissions of the ClusterRole in the namespace of RoleBinding,
+// eg: SetClusterRole("view") makes the subjects read the namespace.
+func (obj *RoleBinding) SetClusterRole(name string) *RoleBinding {
+	ref, err := roleRef("SetClusterRole", "ClusterRole", name)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.rb.RoleRef = ref
+	return obj
+}
+
+// BindServiceAccount add the ServiceAccount into subjects,empty namespace is the namespace of RoleBinding
+func (obj *RoleBinding) BindServiceAccount(namespace, name string) *RoleBinding {
+	subjects, err := addSubject("BindServiceAccount", obj.rb.Subjects, rbacv1.ServiceAccountKind, namespace, name)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.rb.Subjects = subjects
+	return obj
+}
+
+// BindUser add the user into subjects
+func (obj *RoleBinding) BindUser(name string) *RoleBinding {
+	subjects, err := addSubject("BindUser", obj.rb.Subjects, rbacv1.UserKind, "", name)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.rb.Subjects = subjects
+	return obj
+}
+
+// BindGroup add the group into subjects,eg: system:authenticated
+func (obj *RoleBinding) BindGroup(name string) *RoleBinding {
+	subjects, err := addSubject("BindGroup", obj.rb.Subjects, rbacv1.GroupKind, "", name)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.rb.Subjects = subjects
+	return obj
+}
+
+// Release release RoleBinding on Kubernetes
+func (obj *RoleBinding) Release() (*rbacv1.RoleBinding, error) {
+	rb, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return nil, err
+	}
+	return client.RbacV1().RoleBindings(rb.GetNamespace()).Create(context.TODO(), rb, metav1.CreateOptions{})
+}
+
+// Apply  it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist,the role of the existing one can't be changed.
+func (obj *RoleBinding) Apply() (*rbacv1.RoleBinding, error) {
+	rb, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return nil, err
+	}
+	_, err = client.RbacV1().RoleBindings(rb.GetNamespace()).Get(context.TODO(), rb.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.RbacV1().RoleBindings(rb.GetNamespace()).Create(context.TODO(), rb, metav1.CreateOptions{})
+	}
+	return client.RbacV1().RoleBindings(rb.GetNamespace()).Update(context.TODO(), rb, metav1.UpdateOptions{})
+}
+
+// Delete delete RoleBinding on Kubernetes by its name and namespace,
+// opts[0] sets the propagation policy and grace period.
+func (obj *RoleBinding) Delete(opts ...DeleteOptions) error {
+	if obj.err != nil {
+		return obj.err
+	}
+	options, err := newDeleteOptions("RoleBinding", obj.rb.GetName(), opts)
+	if err != nil {
+		return err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return err
+	}
+	return client.RbacV1().RoleBindings(obj.rb.GetNamespace()).Delete(context.TODO(), obj.rb.GetName(), *options)
+}
+
+// String the current RoleBinding as yaml, the pending error is written on the top as comment.
+// it doesn't check and input default field like Finish(), so the RoleBinding may be incomplete.
+func (obj *RoleBinding) String() string { return dump("RoleBinding", obj.rb, obj.err) }
+
+// Dump print the current RoleBinding and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *RoleBinding) Dump() *RoleBinding {
+	fmt.Println(obj.String())
+	return obj
+}
+
+func (obj *RoleBinding) error(err error) {
+	obj.err = appendError(obj.err, err)
+}
+
+// verify check RoleBinding necessary value, input the default field.
+func (obj *RoleBinding) verify() error {
+	var errs error
+	if !verifyString(obj.rb.GetName()) {
+		errs = appendError(errs, fieldError("RoleBinding.Name", "is not allowed to be empty"))
+	}
+	if !verifyString(obj.rb.RoleRef.Name) {
+		errs = appendError(errs, fieldError("RoleBinding.RoleRef", "is not allowed to be empty,you can call SetRole() set it"))
+	}
+	if len(obj.rb.Subjects) == 0 {
+		errs = appendError(errs, fieldError("RoleBinding.Subjects", "is not allowed to be empty,you can call BindServiceAccount() add it"))
+	}
+	if errs != nil {
+		return errs
+	}
+	// the ServiceAccount without namespace is in the namespace of RoleBinding
+	for index := range obj.rb.Subjects {
+		subject := &obj.rb.Subjects[index]
+		if subject.Kind == rbacv1.ServiceAccountKind && subject.Namespace == "" {
+			subject.Namespace = obj.rb.GetNamespace()
+			if subject.Namespace == "" {
+				subject.Namespace = metav1.NamespaceDefault
+			}
+		}
+	}
+	obj.rb.Kind = "RoleBinding"
+	obj.rb.APIVersion = "rbac.authorization.k8s.io/v1"
+	return nil
+}
+
+// ClusterRoleBinding include Kubernetes resource object ClusterRoleBinding(rbac.authorization.k8s.io/v1) and error,
+// ClusterRoleBinding grants the permissions of ClusterRole to the subjects in all namespaces.
+type ClusterRoleBinding struct {
+	crb *rbacv1.ClusterRoleBinding
+	err error
+}
+
+// NewClusterRoleBinding create ClusterRoleBinding and chain function call begin with this function.
+func NewClusterRoleBinding() *ClusterRoleBinding {
+	return &ClusterRoleBinding{crb: &rbacv1.ClusterRoleBinding{}}
+}
+
+// ClusterRoleBindingFrom create ClusterRoleBinding from the existing ClusterRoleBinding,eg: the one got by client,
+// so it can be changed by the chain function and released again,
+// crb is deep copied and the fields which are not changed by the chain function are kept.
+func ClusterRoleBindingFrom(crb *rbacv1.ClusterRoleBinding) *ClusterRoleBinding {
+	if crb == nil {
+		return NewClusterRoleBinding()
+	}
+	return &ClusterRoleBinding{crb: crb.DeepCopy()}
+}
+
+// Finish Chain function call end with this function
+// return real ClusterRoleBinding(really ClusterRoleBinding is kubernetes resource object ClusterRoleBinding and error
+// In the function, it will check necessary parameters、input the default field
+func (obj *ClusterRoleBinding) Finish() (*rbacv1.ClusterRoleBinding, error) {
+	err := appendError(obj.err, obj.verify())
+	return obj.crb, err
+}
+
+// Validate check ClusterRoleBinding necessary value like Finish(),and return the problems as FieldError,
+// but ClusterRoleBinding is not changed, no Kind,APIVersion or default field is input,
+// so it can be used to check a partially built ClusterRoleBinding.
+func (obj *ClusterRoleBinding) Validate() []FieldError {
+	cp := &ClusterRoleBinding{crb: obj.crb.DeepCopy(), err: obj.err}
+	return FieldErrors(appendError(cp.err, cp.verify()))
+}
+
+// FinishObject same as Finish(), but return ClusterRoleBinding as runtime.Object,
+// so ClusterRoleBinding can be used as Builder, eg: add into Bundle.
+func (obj *ClusterRoleBinding) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// JSONNew use json data create ClusterRoleBinding
+func (obj *ClusterRoleBinding) JSONNew(jsonbyts []byte) *ClusterRoleBinding {
+	obj.error(decodeJSON(jsonbyts, obj.crb))
+	return obj
+}
+
+// YAMLNew use yaml data create ClusterRoleBinding
+func (obj *ClusterRoleBinding) YAMLNew(yamlbyts []byte) *ClusterRoleBinding {
+	obj.error(decodeYAML(yamlbyts, obj.crb))
+	return obj
+}
+
+// Replace replace ClusterRoleBinding by Kubernetes resource object
+func (obj *ClusterRoleBinding) Replace(crb *rbacv1.ClusterRoleBinding) *ClusterRoleBinding {
+	if crb != nil {
+		obj.crb = crb
+	}
+	return obj
+}
+
+// SetName set ClusterRoleBinding name
+func (obj *ClusterRoleBinding) SetName(name string) *ClusterRoleBinding {
+	obj.crb.SetName(name)
+	return obj
+}
+
+// SetLabels set ClusterRoleBinding labels
+func (obj *ClusterRoleBinding) SetLabels(labels map[string]string) *ClusterRoleBinding {
+	obj.crb.SetLabels(labels)
+	return obj
+}
+
+// AddLabel add or overwrite one label of ClusterRoleBinding,the other labels are kept
+func (obj *ClusterRoleBinding) AddLabel(key, value string) *ClusterRoleBinding {
+	obj.error(addLabel(obj.crb, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of ClusterRoleBinding,the other annotations are kept
+func (obj *ClusterRoleBinding) AddAnnotation(key, value string) *ClusterRoleBinding {
+	obj.error(addAnnotation(obj.crb, key, value))
+	return obj
+}
+
+// SetClusterRole grant the permissions of the ClusterRole
+func (obj *ClusterRoleBinding) SetClusterRole(name string) *ClusterRoleBinding {
+	ref, err := roleRef("SetClusterRole", "ClusterRole", name)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.crb.RoleRef = ref
+	return obj
+}
+
+// BindServiceAccount add the ServiceAccount into subjects,namespace is required because ClusterRoleBinding has no namespace
+func (obj *ClusterRoleBinding) BindServiceAccount(namespace, name string) *ClusterRoleBinding {
+	if !verifyString(namespace) {
+		obj.error(fieldError("BindServiceAccount", "namespace is not allowed to be empty"))
+		return obj
+	}
+	subjects, err := addSubject("BindServiceAccount", obj.crb.Subjects, rbacv1.ServiceAccountKind, namespace, name)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.crb.Subjects = subjects
+	return obj
+}
+
+// BindUser add the user into subjects
+func (obj *ClusterRoleBinding) BindUser(name string) *ClusterRoleBinding {
+	subjects, err := addSubject("BindUser", obj.crb.Subjects, rbacv1.UserKind, "", name)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.crb.Subjects = subjects
+	return obj
+}
+
+// BindGroup add the group into subjects,eg: system:authenticated
+func (obj *ClusterRoleBinding) BindGroup(name string) *ClusterRoleBinding {
+	subjects, err := addSubject("BindGroup", obj.crb.Subjects, rbacv1.GroupKind, "", name)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.crb.Subjects = subjects
+	return obj
+}
+
+// Release release ClusterRoleBinding on Kubernetes
+func (obj *ClusterRoleBinding) Release() (*rbacv1.ClusterRoleBinding, error) {
+	crb, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return nil, err
+	}
+	return client.RbacV1().ClusterRoleBindings().Create(context.TODO(), crb, metav1.CreateOptions{})
+}
+
+// Apply  it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist,the role of the existing one can't be changed.
+func (obj *ClusterRoleBinding) Apply() (*rbacv1.ClusterRoleBinding, error) {
+	crb, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return nil, err
+	}
+	_, err = client.RbacV1().ClusterRoleBindings().Get(context.TODO(), crb.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.RbacV1().ClusterRoleBindings().Create(context.TODO(), crb, metav1.CreateOptions{})
+	}
+	return client.RbacV1().ClusterRoleBindings().Update(context.TODO(), crb, metav1.UpdateOptions{})
+}
+
+// Delete delete ClusterRoleBinding on Kubernetes by its name,
+// opts[0] sets the propagation policy and grace period.
+func (obj *ClusterRoleBinding) Delete(opts ...DeleteOptions) error {
+	if obj.err != nil {
+		return obj.err
+	}
+	options, err := newDeleteOptions("ClusterRoleBinding", obj.crb.GetName(), opts)
+	if err != nil {
+		return err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return err
+	}
+	return client.RbacV1().ClusterRoleBindings().Delete(context.TODO(), obj.crb.GetName(), *options)
+}
+
+// String the current ClusterRoleBinding as yaml, the pending error is written on the top as comment.
+// it doesn't check and input default field like Finish(), so the ClusterRoleBinding may be incomplete.
+func (obj *ClusterRoleBinding) String() string { return dump("ClusterRoleBinding", obj.crb, obj.err) }
+
+// Dump print the current ClusterRoleBinding and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *ClusterRoleBinding) Dump() *ClusterRoleBinding {
+	fmt.Println(obj.String())
+	return obj
+}
+
+func (obj *ClusterRoleBinding) error(err error) {
+	obj.err = appendError(obj.err, err)
+}
+
+// verify check ClusterRoleBinding necessary value, input the default field.
+func (obj *ClusterRoleBinding) verify() error {
+	var errs error
+	if !verifyString(obj.crb.GetName()) {
+		errs = appendError(errs, fieldError("ClusterRoleBinding.Name", "is not allowed to be empty"))
+	}
+	if !verifyString(obj.crb.RoleRef.Name) {
+		errs = appendError(errs, fieldError("ClusterRoleBinding.RoleRef", "is not allowed to be empty,you can call SetClusterRole() set it"))
+	}
+	if len(obj.crb.Subjects) == 0 {
+		errs = appendError(errs, fieldError("ClusterRoleBinding.Subjects", "is not allowed to be empty,you can call BindServiceAccount() add it"))
+	}
+	if errs != nil {
+		return errs
+	}
+	obj.crb.Kind = "ClusterRoleBinding"
+	obj.crb.APIVersion = "rbac.authorization.k8s.io/v1"
+	return nil
+}
+
+// roleRef create the reference of Role or ClusterRole
+func roleRef(method, kind, name string) (rbacv1.RoleRef, error) {
+	if !verifyString(name) {
+		return rbacv1.RoleRef{}, fieldErrorf(method, "name is not allowed to be empty")
+	}
+	return rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: kind, Name: name}, nil
+}
+
+// addSubject add the subject into subjects,the same subject is added once,
+// User and Group are in rbac.authorization.k8s.io group,ServiceAccount is in core group.
+func addSubject(method string, subjects []rbacv1.Subject, kind, namespace, name string) ([]rbacv1.Subject, error) {
+	if !verifyString(name) {
+		return nil, fieldErrorf(method, "name is not allowed to be empty")
+	}
+	subject := rbacv1.Subject{Kind: kind, Namespace: namespace, Name: name}
+	if kind != rbacv1.ServiceAccountKind {
+		subject.APIGroup = rbacv1.GroupName
+	}
+	for _, s := range subjects {
+		if s == subject {
+			return subjects, nil
+		}
+	}
+	return append(subjects, subject), nil
+}
@@ -0,0 +1,536 @@
+package beku
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// RolloutObject is Rollout of Argo Rollouts(argoproj.io/v1alpha1),
+// it is a Deployment with canary or blueGreen progressive delivery.
+type RolloutObject struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              RolloutSpec `json:"spec"`
+}
+
+// RolloutSpec is the spec of Rollout
+type RolloutSpec struct {
+	Replicas             *int32                `json:"replicas,omitempty"`
+	Selector             *metav1.LabelSelector `json:"selector"`
+	Template             v1.PodTemplateSpec    `json:"template"`
+	MinReadySeconds      int32                 `json:"minReadySeconds,omitempty"`
+	RevisionHistoryLimit *int32                `json:"revisionHistoryLimit,omitempty"`
+	Strategy             RolloutStrategy       `json:"strategy"`
+}
+
+// RolloutStrategy only one of Canary and BlueGreen can be set
+type RolloutStrategy struct {
+	Canary    *CanaryStrategy    `json:"canary,omitempty"`
+	BlueGreen *BlueGreenStrategy `json:"blueGreen,omitempty"`
+}
+
+// CanaryStrategy shift the traffic to the new version step by step
+type CanaryStrategy struct {
+	CanaryService  string                     `json:"canaryService,omitempty"`
+	StableService  string                     `json:"stableService,omitempty"`
+	Steps          []CanaryStep               `json:"steps,omitempty"`
+	Analysis       *RolloutAnalysisBackground `json:"analysis,omitempty"`
+	MaxSurge       *intstr.IntOrString        `json:"maxSurge,omitempty"`
+	MaxUnavailable *intstr.IntOrString        `json:"maxUnavailable,omitempty"`
+}
+
+// CanaryStep is one step of canary, only one of the fields can be set
+type CanaryStep struct {
+	SetWeight *int32           `json:"setWeight,omitempty"`
+	Pause     *RolloutPause    `json:"pause,omitempty"`
+	Analysis  *RolloutAnalysis `json:"analysis,omitempty"`
+}
+
+// RolloutPause pause the rollout,it is paused until promoted manually when Duration is nil
+type RolloutPause struct {
+	Duration *intstr.IntOrString `json:"duration,omitempty"`
+}
+
+// BlueGreenStrategy run the new version beside the old one and switch the active service at once
+type BlueGreenStrategy struct {
+	ActiveService         string           `json:"activeService"`
+	PreviewService        string           `json:"previewService,omitempty"`
+	AutoPromotionEnabled  *bool            `json:"autoPromotionEnabled,omitempty"`
+	ScaleDownDelaySeconds *int32           `json:"scaleDownDelaySeconds,omitempty"`
+	PrePromotionAnalysis  *RolloutAnalysis `json:"prePromotionAnalysis,omitempty"`
+	PostPromotionAnalysis *RolloutAnalysis `json:"postPromotionAnalysis,omitempty"`
+}
+
+// RolloutAnalysis run the AnalysisTemplates,the rollout is aborted when the analysis is failed
+type RolloutAnalysis struct {
+	Templates []AnalysisTemplateRef `json:"templates"`
+	Args      []AnalysisRunArgument `json:"args,omitempty"`
+}
+
+// RolloutAnalysisBackground run the analysis in background from StartingStep of canary
+type RolloutAnalysisBackground struct {
+	RolloutAnalysis `json:",inline"`
+	StartingStep    *int32 `json:"startingStep,omitempty"`
+}
+
+// AnalysisTemplateRef reference AnalysisTemplate or ClusterAnalysisTemplate
+type AnalysisTemplateRef struct {
+	TemplateName string `json:"templateName"`
+	ClusterScope bool   `json:"clusterScope,omitempty"`
+}
+
+// AnalysisRunArgument is the argument passed to the AnalysisTemplates
+type AnalysisRunArgument struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+}
+
+// DeepCopyObject implement runtime.Object
+func (in *RolloutObject) DeepCopyObject() runtime.Object { return in.DeepCopy() }
+
+// DeepCopy copy Rollout
+func (in *RolloutObject) DeepCopy() *RolloutObject {
+	if in == nil {
+		return nil
+	}
+	out := &RolloutObject{TypeMeta: in.TypeMeta}
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec.Replicas = copyInt32(in.Spec.Replicas)
+	out.Spec.Selector = in.Spec.Selector.DeepCopy()
+	in.Spec.Template.DeepCopyInto(&out.Spec.Template)
+	out.Spec.MinReadySeconds = in.Spec.MinReadySeconds
+	out.Spec.RevisionHistoryLimit = copyInt32(in.Spec.RevisionHistoryLimit)
+	if canary := in.Spec.Strategy.Canary; canary != nil {
+		out.Spec.Strategy.Canary = &CanaryStrategy{CanaryService: canary.CanaryService, StableService: canary.StableService}
+		for _, step := range canary.Steps {
+			cp := CanaryStep{SetWeight: copyInt32(step.SetWeight), Analysis: step.Analysis.DeepCopy()}
+			if step.Pause != nil {
+				cp.Pause = &RolloutPause{Duration: copyIntOrString(step.Pause.Duration)}
+			}
+			out.Spec.Strategy.Canary.Steps = append(out.Spec.Strategy.Canary.Steps, cp)
+		}
+		if canary.Analysis != nil {
+			out.Spec.Strategy.Canary.Analysis = &RolloutAnalysisBackground{
+				RolloutAnalysis: *canary.Analysis.RolloutAnalysis.DeepCopy(),
+				StartingStep:    copyInt32(canary.Analysis.StartingStep),
+			}
+		}
+		out.Spec.Strategy.Canary.MaxSurge = copyIntOrString(canary.MaxSurge)
+		out.Spec.Strategy.Canary.MaxUnavailable = copyIntOrString(canary.MaxUnavailable)
+	}
+	if blueGreen := in.Spec.Strategy.BlueGreen; blueGreen != nil {
+		out.Spec.Strategy.BlueGreen = &BlueGreenStrategy{
+			ActiveService:         blueGreen.ActiveService,
+			PreviewService:        blueGreen.PreviewService,
+			ScaleDownDelaySeconds: copyInt32(blueGreen.ScaleDownDelaySeconds),
+			PrePromotionAnalysis:  blueGreen.PrePromotionAnalysis.DeepCopy(),
+			PostPromotionAnalysis: blueGreen.PostPromotionAnalysis.DeepCopy(),
+		}
+		if blueGreen.AutoPromotionEnabled != nil {
+			enabled := *blueGreen.AutoPromotionEnabled
+			out.Spec.Strategy.BlueGreen.AutoPromotionEnabled = &enabled
+		}
+	}
+	return out
+}
+
+// DeepCopy copy RolloutAnalysis
+func (in *RolloutAnalysis) DeepCopy() *RolloutAnalysis {
+	if in == nil {
+		return nil
+	}
+	return &RolloutAnalysis{
+		Templates: append([]AnalysisTemplateRef(nil), in.Templates...),
+		Args:      append([]AnalysisRunArgument(nil), in.Args...),
+	}
+}
+
+func copyInt32(in *int32) *int32 {
+	if in == nil {
+		return nil
+	}
+	out := *in
+	return &out
+}
+
+func copyIntOrString(in *intstr.IntOrString) *intstr.IntOrString {
+	if in == nil {
+		return nil
+	}
+	out := *in
+	return &out
+}
+
+// Rollout include Rollout of Argo Rollouts and error.
+type Rollout struct {
+	ro  *RolloutObject
+	err error
+}
+
+// NewRollout create Argo Rollout and chain function call begin with this function.
+func NewRollout() *Rollout { return &Rollout{ro: &RolloutObject{}} }
+
+// Finish chain function call end with this function
+// return real Rollout and error
+// In the function, it will check necessary parameters、input the default field。
+func (obj *Rollout) Finish() (ro *RolloutObject, err error) {
+	err = appendError(obj.err, obj.verify())
+	return obj.ro, err
+}
+
+// Validate check Rollout necessary value like Finish(),and return the problems as FieldError,
+// but Rollout is not changed.
+func (obj *Rollout) Validate() []FieldError {
+	cp := &Rollout{ro: obj.ro.DeepCopy(), err: obj.err}
+	return FieldErrors(appendError(cp.err, cp.verify()))
+}
+
+// FinishObject same as Finish(), but return Rollout as runtime.Object,
+// so Rollout can be used as Builder, eg: add into Bundle.
+func (obj *Rollout) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// FinishUnchecked return Rollout without the checks and default fields of Finish(),only Kind and APIVersion are input,
+// it is for the partial Rollout which is patched onto the existing one,the error of the chain is still returned.
+func (obj *Rollout) FinishUnchecked() (*RolloutObject, error) {
+	obj.ro.Kind, obj.ro.APIVersion = "Rollout", "argoproj.io/v1alpha1"
+	return obj.ro, obj.err
+}
+
+// JSONNew use json data create Rollout
+func (obj *Rollout) JSONNew(jsonbyts []byte) *Rollout {
+	obj.error(decodeJSON(jsonbyts, obj.ro))
+	return obj
+}
+
+// YAMLNew use yaml data create Rollout
+func (obj *Rollout) YAMLNew(yamlbyts []byte) *Rollout {
+	obj.error(decodeYAML(yamlbyts, obj.ro))
+	return obj
+}
+
+// FromDeployment replace Rollout by Deployment,the pod template,replicas and selector are kept,
+// the rolling update of Deployment is converted into canary without steps,
+// so add the steps by AddCanaryWeight() and AddCanaryPause() after it.
+// Deployment with Recreate strategy is not supported,because Rollout has no Recreate strategy.
+func (obj *Rollout) FromDeployment(dp *appsv1.Deployment) *Rollout {
+	if dp == nil {
+		obj.error(fieldError("FromDeployment", "Deployment is not allowed to be nil"))
+		return obj
+	}
+	if dp.Spec.Strategy.Type == appsv1.RecreateDeploymentStrategyType {
+		obj.error(fieldError("FromDeployment", "Recreate strategy is not supported by Rollout"))
+		return obj
+	}
+	ro := &RolloutObject{
+		ObjectMeta: *dp.ObjectMeta.DeepCopy(),
+		Spec: RolloutSpec{
+			Replicas:             copyInt32(dp.Spec.Replicas),
+			Selector:             dp.Spec.Selector.DeepCopy(),
+			Template:             *dp.Spec.Template.DeepCopy(),
+			MinReadySeconds:      dp.Spec.MinReadySeconds,
+			RevisionHistoryLimit: copyInt32(dp.Spec.RevisionHistoryLimit),
+			Strategy:             RolloutStrategy{Canary: &CanaryStrategy{}},
+		},
+	}
+	ro.ResourceVersion, ro.UID = "", ""
+	delete(ro.Annotations, "deployment.kubernetes.io/revision")
+	if rolling := dp.Spec.Strategy.RollingUpdate; rolling != nil {
+		ro.Spec.Strategy.Canary.MaxSurge = copyIntOrString(rolling.MaxSurge)
+		ro.Spec.Strategy.Canary.MaxUnavailable = copyIntOrString(rolling.MaxUnavailable)
+	}
+	obj.ro = ro
+	return obj
+}
+
+// SetName set Rollout name
+func (obj *Rollout) SetName(name string) *Rollout {
+	obj.ro.SetName(name)
+	return obj
+}
+
+// SetNamespace set Rollout namespace
+func (obj *Rollout) SetNamespace(namespace string) *Rollout {
+	obj.ro.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set Rollout namespace and name
+func (obj *Rollout) SetNamespaceAndName(namespace, name string) *Rollout {
+	obj.ro.SetName(name)
+	obj.ro.SetNamespace(namespace)
+	return obj
+}
+
+// SetLabels set Rollout labels
+func (obj *Rollout) SetLabels(labels map[string]string) *Rollout {
+	obj.ro.SetLabels(labels)
+	return obj
+}
+
+// AddLabel add or overwrite one label of Rollout,the other labels are kept,
+// unlike SetLabels() which replaces all labels,eg: the labels loaded by YAMLNew().
+func (obj *Rollout) AddLabel(key, value string) *Rollout {
+	obj.error(addLabel(obj.ro, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of Rollout,the other annotations are kept
+func (obj *Rollout) AddAnnotation(key, value string) *Rollout {
+	obj.error(addAnnotation(obj.ro, key, value))
+	return obj
+}
+
+// AddPodLabel add or overwrite one label of the Pod template,the other labels are kept,
+// the selector set by SetSelector() or SetPodLabels() is not changed.
+func (obj *Rollout) AddPodLabel(key, value string) *Rollout {
+	obj.error(addLabel(&obj.ro.Spec.Template, key, value))
+	return obj
+}
+
+// AddPodAnnotation add or overwrite one annotation of the Pod template,the other annotations are kept
+func (obj *Rollout) AddPodAnnotation(key, value string) *Rollout {
+	obj.error(addAnnotation(&obj.ro.Spec.Template, key, value))
+	return obj
+}
+
+// SetReplicas set Rollout replicas default 1
+func (obj *Rollout) SetReplicas(replicas int32) *Rollout {
+	obj.ro.Spec.Replicas = &replicas
+	return obj
+}
+
+// SetSelector set Rollout labels selector and set Pod Labels
+func (obj *Rollout) SetSelector(labels map[string]string) *Rollout {
+	if len(labels) <= 0 {
+		obj.error(fieldError("SetSelector", "label is not allowed to be empty"))
+		return obj
+	}
+	if obj.ro.Spec.Selector == nil {
+		obj.ro.Spec.Selector = &metav1.LabelSelector{}
+	}
+	obj.ro.Spec.Selector.MatchLabels = labels
+	obj.ro.Spec.Template.SetLabels(copyLabels(labels))
+	return obj
+}
+
+// SetPodLabels set Pod labels and set Rollout selector
+func (obj *Rollout) SetPodLabels(labels map[string]string) *Rollout {
+	return obj.SetSelector(labels)
+}
+
+// SetContainer set Rollout container
+// name:name is container name ,default ""
+// image:image is image name ,must input image
+// containerPort: image expose containerPort,must input containerPort
+func (obj *Rollout) SetContainer(name, image string, containerPort int32) *Rollout {
+	obj.error(setContainer(&obj.ro.Spec.Template, name, image, containerPort))
+	return obj
+}
+
+// SetEnvs set Pod Environmental variable,the envs are added into all containers,
+// the variable with the same name is replaced and the others are kept.
+func (obj *Rollout) SetEnvs(envMap map[string]string) *Rollout {
+	obj.error(setEnvs(&obj.ro.Spec.Template, envMap))
+	return obj
+}
+
+// SetResourceLimit set container of Rollout resource limit,eg:CPU and MEMORY
+func (obj *Rollout) SetResourceLimit(limits map[ResourceName]string) *Rollout {
+	obj.error(setResourceLimit(&obj.ro.Spec.Template, limits))
+	return obj
+}
+
+// SetResourceRequests set container of Rollout resource request,only CPU and MEMORY
+func (obj *Rollout) SetResourceRequests(requests map[ResourceName]string) *Rollout {
+	obj.error(setResourceRequests(&obj.ro.Spec.Template, requests))
+	return obj
+}
+
+// SetCanary use canary strategy,the traffic of canaryService and stableService is shifted by the steps,
+// both of them can be empty, then the traffic is shifted by the replicas of the new version.
+func (obj *Rollout) SetCanary(canaryService, stableService string) *Rollout {
+	canary := obj.canary()
+	canary.CanaryService, canary.StableService = canaryService, stableService
+	return obj
+}
+
+// AddCanaryWeight add a canary step which shift weight percent of the traffic to the new version
+func (obj *Rollout) AddCanaryWeight(weight int32) *Rollout {
+	if weight < 0 || weight > 100 {
+		obj.error(fieldErrorf("AddCanaryWeight", "weight %d is not in range 0-100", weight))
+		return obj
+	}
+	canary := obj.canary()
+	canary.Steps = append(canary.Steps, CanaryStep{SetWeight: &weight})
+	return obj
+}
+
+// AddCanaryPause add a canary step which pause the rollout for duration,
+// 0 means the rollout is paused until it is promoted manually, eg: kubectl argo rollouts promote
+func (obj *Rollout) AddCanaryPause(duration time.Duration) *Rollout {
+	if duration < 0 {
+		obj.error(fieldError("AddCanaryPause", "duration is not allowed to be negative"))
+		return obj
+	}
+	pause := &RolloutPause{}
+	if duration > 0 {
+		// Argo Rollouts reads the integer duration as seconds
+		seconds := intstr.FromInt(int(duration / time.Second))
+		pause.Duration = &seconds
+	}
+	canary := obj.canary()
+	canary.Steps = append(canary.Steps, CanaryStep{Pause: pause})
+	return obj
+}
+
+// AddCanaryAnalysis add a canary step which run the AnalysisTemplates and wait for the result
+func (obj *Rollout) AddCanaryAnalysis(templates ...string) *Rollout {
+	analysis, err := newRolloutAnalysis(templates)
+	if err != nil {
+		obj.error(fieldErrorf("AddCanaryAnalysis", "%v", err))
+		return obj
+	}
+	canary := obj.canary()
+	canary.Steps = append(canary.Steps, CanaryStep{Analysis: analysis})
+	return obj
+}
+
+// SetCanaryBackgroundAnalysis run the AnalysisTemplates in background from the step startingStep(begin with 0) of canary,
+// the rollout is aborted at once when the analysis is failed.
+func (obj *Rollout) SetCanaryBackgroundAnalysis(startingStep int32, templates ...string) *Rollout {
+	analysis, err := newRolloutAnalysis(templates)
+	if err != nil {
+		obj.error(fieldErrorf("SetCanaryBackgroundAnalysis", "%v", err))
+		return obj
+	}
+	obj.canary().Analysis = &RolloutAnalysisBackground{RolloutAnalysis: *analysis, StartingStep: &startingStep}
+	return obj
+}
+
+// SetBlueGreen use blueGreen strategy,activeService serves the stable version and previewService serves the new version,
+// the new version is promoted to activeService automatically when autoPromotion is true.
+func (obj *Rollout) SetBlueGreen(activeService, previewService string, autoPromotion bool) *Rollout {
+	if !verifyString(activeService) {
+		obj.error(fieldError("SetBlueGreen", "activeService is not allowed to be empty"))
+		return obj
+	}
+	blueGreen := obj.blueGreen()
+	blueGreen.ActiveService, blueGreen.PreviewService = activeService, previewService
+	blueGreen.AutoPromotionEnabled = &autoPromotion
+	return obj
+}
+
+// SetPrePromotionAnalysis run the AnalysisTemplates before the new version is promoted to activeService
+func (obj *Rollout) SetPrePromotionAnalysis(templates ...string) *Rollout {
+	analysis, err := newRolloutAnalysis(templates)
+	if err != nil {
+		obj.error(fieldErrorf("SetPrePromotionAnalysis", "%v", err))
+		return obj
+	}
+	obj.blueGreen().PrePromotionAnalysis = analysis
+	return obj
+}
+
+// SetPostPromotionAnalysis run the AnalysisTemplates after the new version is promoted to activeService,
+// the rollout is rolled back when the analysis is failed.
+func (obj *Rollout) SetPostPromotionAnalysis(templates ...string) *Rollout {
+	analysis, err := newRolloutAnalysis(templates)
+	if err != nil {
+		obj.error(fieldErrorf("SetPostPromotionAnalysis", "%v", err))
+		return obj
+	}
+	obj.blueGreen().PostPromotionAnalysis = analysis
+	return obj
+}
+
+// canary get canary strategy,blueGreen strategy is removed because only one strategy can be used
+func (obj *Rollout) canary() *CanaryStrategy {
+	obj.ro.Spec.Strategy.BlueGreen = nil
+	if obj.ro.Spec.Strategy.Canary == nil {
+		obj.ro.Spec.Strategy.Canary = &CanaryStrategy{}
+	}
+	return obj.ro.Spec.Strategy.Canary
+}
+
+// blueGreen get blueGreen strategy,canary strategy is removed because only one strategy can be used
+func (obj *Rollout) blueGreen() *BlueGreenStrategy {
+	obj.ro.Spec.Strategy.Canary = nil
+	if obj.ro.Spec.Strategy.BlueGreen == nil {
+		obj.ro.Spec.Strategy.BlueGreen = &BlueGreenStrategy{}
+	}
+	return obj.ro.Spec.Strategy.BlueGreen
+}
+
+func newRolloutAnalysis(templates []string) (*RolloutAnalysis, error) {
+	if len(templates) <= 0 {
+		return nil, errors.New("templates is not allowed to be empty")
+	}
+	analysis := &RolloutAnalysis{Templates: make([]AnalysisTemplateRef, 0, len(templates))}
+	for _, name := range templates {
+		if !verifyString(name) {
+			return nil, errors.New("template name is not allowed to be empty")
+		}
+		analysis.Templates = append(analysis.Templates, AnalysisTemplateRef{TemplateName: name})
+	}
+	return analysis, nil
+}
+
+// String the current Rollout as yaml, the pending error is written on the top as comment.
+func (obj *Rollout) String() string { return dump("Rollout", obj.ro, obj.err) }
+
+// Dump print the current Rollout and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *Rollout) Dump() *Rollout {
+	fmt.Println(obj.String())
+	return obj
+}
+
+func (obj *Rollout) error(err error) {
+	obj.err = appendError(obj.err, err)
+}
+
+func (obj *Rollout) verify() error {
+	var errs error
+	if !verifyString(obj.ro.GetName()) {
+		errs = appendError(errs, fieldError("Rollout.Name", "is not allowed to be empty"))
+	}
+	if obj.ro.Spec.Selector == nil {
+		errs = appendError(errs, fieldError("Rollout.Spec.Selector", "is not allowed to be empty,you can call SetSelector()"))
+	} else {
+		errs = appendError(errs, verifySelector("Rollout", obj.ro.Spec.Selector, obj.ro.Spec.Template.GetLabels()))
+	}
+	if len(obj.ro.Spec.Template.Spec.Containers) < 1 {
+		errs = appendError(errs, fieldError("Rollout.Spec.Template.Spec.Containers", "is not allowed to be empty"))
+	} else {
+		errs = appendError(errs, inField("Rollout.Spec.Template.Spec", verifyContainers(obj.ro.Spec.Template.Spec)))
+	}
+	strategy := obj.ro.Spec.Strategy
+	switch {
+	case strategy.Canary == nil && strategy.BlueGreen == nil:
+		errs = appendError(errs, fieldError("Rollout.Spec.Strategy", "is not allowed to be empty,you can call SetCanary() or SetBlueGreen()"))
+	case strategy.Canary != nil && strategy.BlueGreen != nil:
+		errs = appendError(errs, fieldError("Rollout.Spec.Strategy", "can't use canary and blueGreen strategy at the same time"))
+	case strategy.BlueGreen != nil && !verifyString(strategy.BlueGreen.ActiveService):
+		errs = appendError(errs, fieldError("Rollout.Spec.Strategy.BlueGreen.ActiveService", "is not allowed to be empty"))
+	case strategy.Canary != nil && strategy.Canary.Analysis != nil && strategy.Canary.Analysis.StartingStep != nil:
+		if step := *strategy.Canary.Analysis.StartingStep; step < 0 || int(step) >= len(strategy.Canary.Steps) {
+			errs = appendError(errs, fieldErrorf("Rollout.Spec.Strategy.Canary.Analysis.StartingStep", "%d is out of the canary steps", step))
+		}
+	}
+	if errs != nil {
+		return errs
+	}
+	if obj.ro.Spec.Replicas == nil {
+		obj.ro.Spec.Replicas = int32Ptr(1)
+	}
+	obj.ro.Kind = "Rollout"
+	obj.ro.APIVersion = "argoproj.io/v1alpha1"
+	return nil
+}
@@ -0,0 +1,419 @@
+package beku
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// ArchLabel is the well-known node label of CPU architecture
+	ArchLabel = "kubernetes.io/arch"
+	// ZoneLabel is the well-known node label of the zone of cloud provider
+	ZoneLabel = "topology.kubernetes.io/zone"
+	// HostnameLabel is the well-known node label of the node name
+	HostnameLabel = "kubernetes.io/hostname"
+)
+
+// supportedArches is the architectures of GOARCH which Kubernetes nodes run on
+var supportedArches = sets.NewString("amd64", "arm64", "arm", "ppc64le", "s390x", "386")
+
+// setTargetArch require the Pod is scheduled on the nodes of arches by node affinity,
+// and tolerate the arch taint of them,eg: some clouds taint arm64 nodes with kubernetes.io/arch=arm64:NoSchedule.
+// the arch requirement is added into every node selector term,so the other requirements are kept.
+func setTargetArch(podTemp *v1.PodTemplateSpec, arches []string) error {
+	if len(arches) == 0 {
+		return fieldError("TargetArch", "arches are not allowed to be empty")
+	}
+	for _, arch := range arches {
+		if !supportedArches.Has(arch) {
+			return fieldErrorf("TargetArch", "arch %s is not supported,only %v", arch, supportedArches.List())
+		}
+	}
+	addRequiredNodeRequirement(&podTemp.Spec, v1.NodeSelectorRequirement{Key: ArchLabel, Operator: v1.NodeSelectorOpIn, Values: append([]string(nil), arches...)})
+	for _, arch := range arches {
+		addToleration(&podTemp.Spec, v1.Toleration{Key: ArchLabel, Operator: v1.TolerationOpEqual, Value: arch, Effect: v1.TaintEffectNoSchedule})
+	}
+	return nil
+}
+
+// nodeAffinity get the node affinity of the Pod,it is created when it is nil
+func nodeAffinity(spec *v1.PodSpec) *v1.NodeAffinity {
+	if spec.Affinity == nil {
+		spec.Affinity = &v1.Affinity{}
+	}
+	if spec.Affinity.NodeAffinity == nil {
+		spec.Affinity.NodeAffinity = &v1.NodeAffinity{}
+	}
+	return spec.Affinity.NodeAffinity
+}
+
+// requiredNodeSelector get the required node selector of node affinity,it is created when it is nil
+func requiredNodeSelector(spec *v1.PodSpec) *v1.NodeSelector {
+	affinity := nodeAffinity(spec)
+	if affinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		affinity.RequiredDuringSchedulingIgnoredDuringExecution = &v1.NodeSelector{}
+	}
+	return affinity.RequiredDuringSchedulingIgnoredDuringExecution
+}
+
+// addRequiredNodeRequirement add the requirement into every required node selector term,
+// the terms are ORed,so the requirement must be in all of them,the old requirement of the same key is replaced.
+func addRequiredNodeRequirement(spec *v1.PodSpec, requirement v1.NodeSelectorRequirement) {
+	selector := requiredNodeSelector(spec)
+	if len(selector.NodeSelectorTerms) == 0 {
+		selector.NodeSelectorTerms = []v1.NodeSelectorTerm{{}}
+	}
+	for index := range selector.NodeSelectorTerms {
+		term := &selector.NodeSelectorTerms[index]
+		term.MatchExpressions = append(withoutRequirement(term.MatchExpressions, requirement.Key), requirement)
+	}
+}
+
+// addPreferredNodeRequirement add the preferred term of the requirement,the old preferred term of the same key is replaced
+func addPreferredNodeRequirement(spec *v1.PodSpec, weight int32, requirement v1.NodeSelectorRequirement) {
+	affinity := nodeAffinity(spec)
+	var preferred []v1.PreferredSchedulingTerm
+	for _, term := range affinity.PreferredDuringSchedulingIgnoredDuringExecution {
+		if !hasRequirement(term.Preference.MatchExpressions, requirement.Key) {
+			preferred = append(preferred, term)
+		}
+	}
+	affinity.PreferredDuringSchedulingIgnoredDuringExecution = append(preferred, v1.PreferredSchedulingTerm{
+		Weight:     weight,
+		Preference: v1.NodeSelectorTerm{MatchExpressions: []v1.NodeSelectorRequirement{requirement}},
+	})
+}
+
+// withoutRequirement remove the requirements of the key,so setting it again replaces the old one
+func withoutRequirement(requirements []v1.NodeSelectorRequirement, key string) []v1.NodeSelectorRequirement {
+	kept := make([]v1.NodeSelectorRequirement, 0, len(requirements))
+	for _, requirement := range requirements {
+		if requirement.Key != key {
+			kept = append(kept, requirement)
+		}
+	}
+	return kept
+}
+
+// addToleration add the toleration when the same one doesn't exist
+func addToleration(spec *v1.PodSpec, toleration v1.Toleration) {
+	for _, existing := range spec.Tolerations {
+		if existing.MatchToleration(&toleration) {
+			return
+		}
+	}
+	spec.Tolerations = append(spec.Tolerations, toleration)
+}
+
+// SpotProvider is how one cloud provider marks its spot(preemptible) nodes,
+// NodeLabel=NodeLabelValue selects the spot nodes,Taint is the taint of them and nil when they are not tainted.
+type SpotProvider struct {
+	NodeLabel      string
+	NodeLabelValue string
+	Taint          *v1.Taint
+}
+
+// the spot providers of the well-known clouds,the taints are the ones added by the cloud or its documentation
+var (
+	SpotProviderGKE = SpotProvider{NodeLabel: "cloud.google.com/gke-spot", NodeLabelValue: "true",
+		Taint: &v1.Taint{Key: "cloud.google.com/gke-spot", Value: "true", Effect: v1.TaintEffectNoSchedule}}
+	SpotProviderEKS = SpotProvider{NodeLabel: "eks.amazonaws.com/capacityType", NodeLabelValue: "SPOT"}
+	SpotProviderAKS = SpotProvider{NodeLabel: "kubernetes.azure.com/scalesetpriority", NodeLabelValue: "spot",
+		Taint: &v1.Taint{Key: "kubernetes.azure.com/scalesetpriority", Value: "spot", Effect: v1.TaintEffectNoSchedule}}
+	SpotProviderKarpenter = SpotProvider{NodeLabel: "karpenter.sh/capacity-type", NodeLabelValue: "spot"}
+)
+
+// spotProvider is the provider registered by RegisterSpotProvider()
+var spotProvider = struct {
+	sync.RWMutex
+	provider *SpotProvider
+}{}
+
+// RegisterSpotProvider register the spot provider of the cluster which is used by RunOnSpot(),
+// eg: SpotProviderGKE,or a custom one for the self-managed spot node pools.
+func RegisterSpotProvider(provider SpotProvider) error {
+	if !verifyString(provider.NodeLabel) || !verifyString(provider.NodeLabelValue) {
+		return errors.New("RegisterSpotProvider err,NodeLabel and NodeLabelValue are not allowed to be empty")
+	}
+	if provider.Taint != nil && (!verifyString(provider.Taint.Key) || provider.Taint.Effect == "") {
+		return errors.New("RegisterSpotProvider err,Taint.Key and Taint.Effect are not allowed to be empty")
+	}
+	spotProvider.Lock()
+	defer spotProvider.Unlock()
+	spotProvider.provider = &provider
+	return nil
+}
+
+func registeredSpotProvider() *SpotProvider {
+	spotProvider.RLock()
+	defer spotProvider.RUnlock()
+	return spotProvider.provider
+}
+
+// setRunOnSpot tolerate the spot taint of the registered provider,and select the spot nodes by node affinity:
+// required is true the Pod only runs on spot nodes,false it prefers spot nodes but can fall back to on-demand nodes.
+func setRunOnSpot(podTemp *v1.PodTemplateSpec, required bool) error {
+	provider := registeredSpotProvider()
+	if provider == nil {
+		return fieldError("RunOnSpot", "spot provider is not registered,you can call func RegisterSpotProvider() register")
+	}
+	requirement := v1.NodeSelectorRequirement{Key: provider.NodeLabel, Operator: v1.NodeSelectorOpIn, Values: []string{provider.NodeLabelValue}}
+	if required {
+		addRequiredNodeRequirement(&podTemp.Spec, requirement)
+	} else {
+		addPreferredNodeRequirement(&podTemp.Spec, 100, requirement)
+	}
+	if provider.Taint != nil {
+		addToleration(&podTemp.Spec, v1.Toleration{Key: provider.Taint.Key, Operator: v1.TolerationOpEqual, Value: provider.Taint.Value, Effect: provider.Taint.Effect})
+	}
+	return nil
+}
+
+// runsOnSpot the Pod selects the spot nodes of the registered provider by node affinity
+func runsOnSpot(spec *v1.PodSpec) bool {
+	provider := registeredSpotProvider()
+	if provider == nil || spec.Affinity == nil || spec.Affinity.NodeAffinity == nil {
+		return false
+	}
+	affinity := spec.Affinity.NodeAffinity
+	if affinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		for _, term := range affinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+			if hasRequirement(term.MatchExpressions, provider.NodeLabel) {
+				return true
+			}
+		}
+	}
+	for _, term := range affinity.PreferredDuringSchedulingIgnoredDuringExecution {
+		if hasRequirement(term.Preference.MatchExpressions, provider.NodeLabel) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasRequirement(requirements []v1.NodeSelectorRequirement, key string) bool {
+	for _, requirement := range requirements {
+		if requirement.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// setSpreadAcrossZones spread the Pods evenly across zones by topology spread constraint,
+// the Pods are selected by the labels of the Pod template,so call it after the Pod labels are set.
+// ScheduleAnyway is used,so the Pods are still scheduled when one zone is unavailable.
+func setSpreadAcrossZones(podTemp *v1.PodTemplateSpec, maxSkew int) error {
+	return setTopologySpreadConstraint(podTemp, "SpreadAcrossZones", maxSkew, ZoneLabel, string(v1.ScheduleAnyway))
+}
+
+// setTopologySpreadConstraint spread the Pods with the labels of Pod template across the domains of topologyKey,
+// the constraint of the same topologyKey is replaced,whenUnsatisfiable is DoNotSchedule or ScheduleAnyway.
+func setTopologySpreadConstraint(podTemp *v1.PodTemplateSpec, method string, maxSkew int, topologyKey, whenUnsatisfiable string) error {
+	if maxSkew < 1 {
+		return fieldErrorf(method, "maxSkew must be greater than 0")
+	}
+	if errs := validation.IsQualifiedName(topologyKey); len(errs) > 0 {
+		return fieldErrorf(method, "topologyKey %q is not allowed:%s", topologyKey, strings.Join(errs, ","))
+	}
+	action := v1.UnsatisfiableConstraintAction(whenUnsatisfiable)
+	if action != v1.DoNotSchedule && action != v1.ScheduleAnyway {
+		return fieldErrorf(method, "whenUnsatisfiable %s is not allowed,only DoNotSchedule and ScheduleAnyway", whenUnsatisfiable)
+	}
+	if len(podTemp.GetLabels()) == 0 {
+		return fieldErrorf(method, "Pod labels are not set,you can call SetPodLabels() first")
+	}
+	constraint := v1.TopologySpreadConstraint{
+		MaxSkew:           int32(maxSkew),
+		TopologyKey:       topologyKey,
+		WhenUnsatisfiable: action,
+		LabelSelector:     &metav1.LabelSelector{MatchLabels: copyLabels(podTemp.GetLabels())},
+	}
+	for index, existing := range podTemp.Spec.TopologySpreadConstraints {
+		if existing.TopologyKey == topologyKey {
+			podTemp.Spec.TopologySpreadConstraints[index] = constraint
+			return nil
+		}
+	}
+	podTemp.Spec.TopologySpreadConstraints = append(podTemp.Spec.TopologySpreadConstraints, constraint)
+	return nil
+}
+
+// setNodeName assign the Pod to the node directly,the scheduler is bypassed,
+// so node affinity,taints and resources of the node are not checked,empty name removes it.
+func setNodeName(podTemp *v1.PodTemplateSpec, name string) error {
+	if name != "" {
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return fieldErrorf("SetNodeName", "name %q is not allowed:%s", name, strings.Join(errs, ","))
+		}
+	}
+	podTemp.Spec.NodeName = name
+	return nil
+}
+
+// Toleration allow the Pod to be scheduled on or keep running on the nodes with the matching taint,
+// Operator is Equal or Exists,it is Equal when it is empty,the empty Key with Exists tolerates all taints,
+// TolerationSeconds is how long the Pod keeps running after the NoExecute taint is added,0 is forever.
+type Toleration struct {
+	Key               string
+	Operator          string
+	Value             string
+	Effect            string
+	TolerationSeconds int64
+}
+
+// setNodeSelector set the node labels which the node must have to run the Pod,nil removes it
+func setNodeSelector(podTemp *v1.PodTemplateSpec, selector map[string]string) error {
+	for key, value := range selector {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fieldErrorf("SetNodeSelector", "key %q is not allowed:%s", key, strings.Join(errs, ","))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fieldErrorf("SetNodeSelector", "value %q of key %s is not allowed:%s", value, key, strings.Join(errs, ","))
+		}
+	}
+	if len(selector) == 0 {
+		podTemp.Spec.NodeSelector = nil
+		return nil
+	}
+	podTemp.Spec.NodeSelector = copyLabels(selector)
+	return nil
+}
+
+// setNodeAffinity require or prefer the nodes whose label key matches the operator and values,
+// weight is 1-100 for preferred and 0 for required.
+func setNodeAffinity(podTemp *v1.PodTemplateSpec, method string, weight int32, key, operator string, values []string) error {
+	requirement, err := nodeRequirement(method, key, operator, values)
+	if err != nil {
+		return err
+	}
+	if weight == 0 {
+		addRequiredNodeRequirement(&podTemp.Spec, requirement)
+		return nil
+	}
+	if weight < 1 || weight > 100 {
+		return fieldErrorf(method, "weight %d is not allowed,it should be 1-100", weight)
+	}
+	addPreferredNodeRequirement(&podTemp.Spec, weight, requirement)
+	return nil
+}
+
+// nodeRequirement check the operator and values of the node selector requirement:
+// In and NotIn need values,Exists and DoesNotExist need no value,Gt and Lt need one integer.
+func nodeRequirement(method, key, operator string, values []string) (v1.NodeSelectorRequirement, error) {
+	requirement := v1.NodeSelectorRequirement{Key: key, Operator: v1.NodeSelectorOperator(operator), Values: append([]string(nil), values...)}
+	if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+		return requirement, fieldErrorf(method, "key %q is not allowed:%s", key, strings.Join(errs, ","))
+	}
+	switch requirement.Operator {
+	case v1.NodeSelectorOpIn, v1.NodeSelectorOpNotIn:
+		if len(values) == 0 {
+			return requirement, fieldErrorf(method, "values are not allowed to be empty with operator %s", operator)
+		}
+	case v1.NodeSelectorOpExists, v1.NodeSelectorOpDoesNotExist:
+		if len(values) != 0 {
+			return requirement, fieldErrorf(method, "values are not allowed with operator %s", operator)
+		}
+	case v1.NodeSelectorOpGt, v1.NodeSelectorOpLt:
+		if len(values) != 1 {
+			return requirement, fieldErrorf(method, "only one value is allowed with operator %s", operator)
+		}
+		if _, err := strconv.ParseInt(values[0], 10, 64); err != nil {
+			return requirement, fieldErrorf(method, "value %q is not allowed with operator %s,it should be an integer", values[0], operator)
+		}
+	default:
+		return requirement, fieldErrorf(method, "operator %s is not allowed,only In,NotIn,Exists,DoesNotExist,Gt and Lt", operator)
+	}
+	return requirement, nil
+}
+
+// setPodAffinity require or prefer the Pod is scheduled with(or away from when anti is true) the Pods selected by labels
+// in the same topology domain of topologyKey,eg: kubernetes.io/hostname for node and topology.kubernetes.io/zone for zone.
+// weight is 1-100 for preferred and 0 for required,the Pods are selected in the same namespace.
+func setPodAffinity(podTemp *v1.PodTemplateSpec, method string, anti bool, weight int32, topologyKey string, labels map[string]string) error {
+	if !verifyString(topologyKey) {
+		return fieldErrorf(method, "topologyKey is not allowed to be empty")
+	}
+	if len(labels) == 0 {
+		return fieldErrorf(method, "labels are not allowed to be empty,they select the Pods")
+	}
+	if weight < 0 || weight > 100 {
+		return fieldErrorf(method, "weight %d is not allowed,it should be 1-100", weight)
+	}
+	term := v1.PodAffinityTerm{LabelSelector: &metav1.LabelSelector{MatchLabels: copyLabels(labels)}, TopologyKey: topologyKey}
+	if podTemp.Spec.Affinity == nil {
+		podTemp.Spec.Affinity = &v1.Affinity{}
+	}
+	var required *[]v1.PodAffinityTerm
+	var preferred *[]v1.WeightedPodAffinityTerm
+	if anti {
+		if podTemp.Spec.Affinity.PodAntiAffinity == nil {
+			podTemp.Spec.Affinity.PodAntiAffinity = &v1.PodAntiAffinity{}
+		}
+		required = &podTemp.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+		preferred = &podTemp.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	} else {
+		if podTemp.Spec.Affinity.PodAffinity == nil {
+			podTemp.Spec.Affinity.PodAffinity = &v1.PodAffinity{}
+		}
+		required = &podTemp.Spec.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+		preferred = &podTemp.Spec.Affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	}
+	if weight == 0 {
+		*required = append(*required, term)
+		return nil
+	}
+	*preferred = append(*preferred, v1.WeightedPodAffinityTerm{Weight: weight, PodAffinityTerm: term})
+	return nil
+}
+
+// setTolerations replace the tolerations of the Pod,nil removes them
+func setTolerations(podTemp *v1.PodTemplateSpec, tolerations []Toleration) error {
+	result := make([]v1.Toleration, 0, len(tolerations))
+	for index, toleration := range tolerations {
+		item := v1.Toleration{
+			Key:      toleration.Key,
+			Operator: v1.TolerationOperator(toleration.Operator),
+			Value:    toleration.Value,
+			Effect:   v1.TaintEffect(toleration.Effect),
+		}
+		switch item.Operator {
+		case "", v1.TolerationOpEqual:
+			item.Operator = v1.TolerationOpEqual
+			if !verifyString(item.Key) {
+				return fieldErrorf("SetTolerations", "tolerations[%d] key is not allowed to be empty with operator Equal", index)
+			}
+		case v1.TolerationOpExists:
+			if item.Value != "" {
+				return fieldErrorf("SetTolerations", "tolerations[%d] value is not allowed with operator Exists", index)
+			}
+		default:
+			return fieldErrorf("SetTolerations", "tolerations[%d] operator %s is not allowed,only Equal and Exists", index, toleration.Operator)
+		}
+		switch item.Effect {
+		case "", v1.TaintEffectNoSchedule, v1.TaintEffectPreferNoSchedule, v1.TaintEffectNoExecute:
+		default:
+			return fieldErrorf("SetTolerations", "tolerations[%d] effect %s is not allowed,only NoSchedule,PreferNoSchedule and NoExecute", index, toleration.Effect)
+		}
+		if toleration.TolerationSeconds != 0 {
+			if item.Effect != v1.TaintEffectNoExecute {
+				return fieldErrorf("SetTolerations", "tolerations[%d] TolerationSeconds is only allowed with effect NoExecute", index)
+			}
+			seconds := toleration.TolerationSeconds
+			item.TolerationSeconds = &seconds
+		}
+		result = append(result, item)
+	}
+	if len(result) == 0 {
+		result = nil
+	}
+	podTemp.Spec.Tolerations = result
+	return nil
+}
@@ -0,0 +1,190 @@
+package beku
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// SchemaKinds get the kinds which SchemaForKind() supports,in alphabetical order of kind,then group and version
+func SchemaKinds() []schema.GroupVersionKind {
+	kindRegistry.RLock()
+	defer kindRegistry.RUnlock()
+	kinds := make([]schema.GroupVersionKind, 0, len(kindRegistry.kinds))
+	for gvk, entry := range kindRegistry.kinds {
+		if entry.obj != nil {
+			kinds = append(kinds, gvk)
+		}
+	}
+	sortKinds(kinds)
+	return kinds
+}
+
+// SchemaForKind get JSON Schema(draft 2020-12) of the yaml or json which the builder of gvk accepts,eg: YAMLNew(),
+// it is generated from the Go types,so editors can complete the fields and other languages can validate the input.
+// status is not included,because it is written by Kubernetes.
+func SchemaForKind(gvk schema.GroupVersionKind) ([]byte, error) {
+	sk, err := lookupKind(gvk)
+	if err != nil {
+		return nil, fmt.Errorf("SchemaForKind err,%v", err)
+	}
+	if sk.obj == nil {
+		return nil, fmt.Errorf("SchemaForKind err,kind %s of %s has no schema", gvk.Kind, gvk.GroupVersion().String())
+	}
+	gen := &schemaGenerator{defs: make(map[string]interface{})}
+	t := reflect.TypeOf(sk.obj)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	root := gen.structSchema(t)
+	properties := root["properties"].(map[string]interface{})
+	delete(properties, "status")
+	properties["apiVersion"] = map[string]interface{}{"type": "string", "const": gvk.GroupVersion().String()}
+	properties["kind"] = map[string]interface{}{"type": "string", "const": gvk.Kind}
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["title"] = gvk.Kind
+	root["required"] = mergeRequired(root["required"], "apiVersion", "kind", "metadata")
+	if len(gen.defs) > 0 {
+		root["$defs"] = gen.defs
+	}
+	return json.MarshalIndent(root, "", "  ")
+}
+
+// schemaGenerator generate JSON Schema by reflection,the named struct types are in $defs,
+// so recursive types are supported.
+type schemaGenerator struct {
+	defs map[string]interface{}
+}
+
+var (
+	timeType        = reflect.TypeOf(metav1.Time{})
+	microTimeType   = reflect.TypeOf(metav1.MicroTime{})
+	durationType    = reflect.TypeOf(metav1.Duration{})
+	quantityType    = reflect.TypeOf(resource.Quantity{})
+	intOrStringType = reflect.TypeOf(intstr.IntOrString{})
+	rawType         = reflect.TypeOf(runtime.RawExtension{})
+)
+
+func (gen *schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType, microTimeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case durationType:
+		return map[string]interface{}{"type": "string"}
+	case quantityType:
+		return map[string]interface{}{"oneOf": []interface{}{
+			map[string]interface{}{"type": "string"}, map[string]interface{}{"type": "number"},
+		}}
+	case intOrStringType:
+		return map[string]interface{}{"oneOf": []interface{}{
+			map[string]interface{}{"type": "integer"}, map[string]interface{}{"type": "string"},
+		}}
+	case rawType:
+		return map[string]interface{}{}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": gen.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": gen.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return gen.structSchema(t)
+		}
+		name := strings.NewReplacer("/", ".", "~", ".").Replace(t.PkgPath()) + "." + t.Name()
+		if _, ok := gen.defs[name]; !ok {
+			// placeholder first,the recursive fields refer to it before it is finished
+			gen.defs[name] = nil
+			gen.defs[name] = gen.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + name}
+	}
+	// interface,eg: oneof of protobuf,any value is allowed
+	return map[string]interface{}{}
+}
+
+// structSchema get the schema of struct,the inline fields are merged into it
+func (gen *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	gen.addFields(t, properties, &required)
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (gen *schemaGenerator) addFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (field.PkgPath != "" && !field.Anonymous) {
+			continue
+		}
+		name, opts := tag, ""
+		if index := strings.Index(tag, ","); index >= 0 {
+			name, opts = tag[:index], tag[index:]
+		}
+		if field.Anonymous && (name == "" || strings.Contains(opts, ",inline")) {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				gen.addFields(embedded, properties, required)
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = gen.schema(field.Type)
+		if !strings.Contains(opts, ",omitempty") && field.Type.Kind() != reflect.Ptr {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func mergeRequired(existing interface{}, names ...string) []string {
+	required, _ := existing.([]string)
+	seen := make(map[string]bool, len(required))
+	for _, name := range required {
+		seen[name] = true
+	}
+	for _, name := range names {
+		if !seen[name] {
+			required = append(required, name)
+		}
+	}
+	sort.Strings(required)
+	return required
+}
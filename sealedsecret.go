@@ -0,0 +1,355 @@
+package beku
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// SealedSecretObject is Bitnami SealedSecret(bitnami.com/v1alpha1),
+// the values are encrypted by the public key of sealed-secrets controller,only the controller can decrypt them,
+// so it can be stored in git.
+type SealedSecretObject struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              SealedSecretSpec `json:"spec"`
+}
+
+// SealedSecretSpec is the spec of SealedSecret
+type SealedSecretSpec struct {
+	// Template is the metadata and type of the Secret created by the controller
+	Template      SealedSecretTemplate `json:"template,omitempty"`
+	EncryptedData map[string]string    `json:"encryptedData"`
+}
+
+// SealedSecretTemplate is the metadata and type of the Secret created by the controller
+type SealedSecretTemplate struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Type              v1.SecretType `json:"type,omitempty"`
+}
+
+// DeepCopyObject implement runtime.Object
+func (in *SealedSecretObject) DeepCopyObject() runtime.Object { return in.DeepCopy() }
+
+// DeepCopy copy SealedSecret
+func (in *SealedSecretObject) DeepCopy() *SealedSecretObject {
+	if in == nil {
+		return nil
+	}
+	out := &SealedSecretObject{TypeMeta: in.TypeMeta}
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.Template.ObjectMeta.DeepCopyInto(&out.Spec.Template.ObjectMeta)
+	out.Spec.Template.Type = in.Spec.Template.Type
+	if in.Spec.EncryptedData != nil {
+		out.Spec.EncryptedData = make(map[string]string, len(in.Spec.EncryptedData))
+		for key, value := range in.Spec.EncryptedData {
+			out.Spec.EncryptedData[key] = value
+		}
+	}
+	return out
+}
+
+// SealingScope is the scope which the SealedSecret can be decrypted in,
+// it is the label of RSA-OAEP encryption, so the sealed value can't be moved to another scope.
+type SealingScope string
+
+const (
+	// SealingScopeStrict the SealedSecret can only be decrypted with the same name and namespace,default
+	SealingScopeStrict SealingScope = "strict"
+	// SealingScopeNamespaceWide the SealedSecret can be renamed in the same namespace
+	SealingScopeNamespaceWide SealingScope = "namespace-wide"
+	// SealingScopeClusterWide the SealedSecret can be renamed and moved to any namespace
+	SealingScopeClusterWide SealingScope = "cluster-wide"
+)
+
+// annotations of sealed-secrets controller for the scope
+const (
+	sealedNamespaceWideKey = "sealedsecrets.bitnami.com/namespace-wide"
+	sealedClusterWideKey   = "sealedsecrets.bitnami.com/cluster-wide"
+)
+
+// SealedSecret include Bitnami SealedSecret and error,
+// the plain values are sealed by the certificate of the controller in Finish(),they are never in the object.
+type SealedSecret struct {
+	ss    *SealedSecretObject
+	key   *rsa.PublicKey
+	scope SealingScope
+	plain map[string][]byte
+	err   error
+}
+
+// NewSealedSecret create SealedSecret and chain function call begin with this function.
+func NewSealedSecret() *SealedSecret {
+	return &SealedSecret{ss: &SealedSecretObject{}, scope: SealingScopeStrict}
+}
+
+// Finish chain function call end with this function
+// return real SealedSecret and error,the plain values are sealed in the function.
+func (obj *SealedSecret) Finish() (ss *SealedSecretObject, err error) {
+	err = appendError(obj.err, obj.verify())
+	return obj.ss, err
+}
+
+// Validate check SealedSecret necessary value like Finish(),and return the problems as FieldError,
+// but SealedSecret is not changed and the values are not sealed.
+func (obj *SealedSecret) Validate() []FieldError {
+	cp := &SealedSecret{ss: obj.ss.DeepCopy(), key: obj.key, scope: obj.scope, plain: obj.plain, err: obj.err}
+	return FieldErrors(appendError(cp.err, cp.check()))
+}
+
+// FinishObject same as Finish(), but return SealedSecret as runtime.Object,
+// so SealedSecret can be used as Builder, eg: add into Bundle.
+func (obj *SealedSecret) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// FinishUnchecked return SealedSecret without the checks and default fields of Finish(),only Kind and APIVersion are input,
+// it is for the partial SealedSecret which is patched onto the existing one,the error of the chain is still returned.
+func (obj *SealedSecret) FinishUnchecked() (*SealedSecretObject, error) {
+	obj.ss.Kind, obj.ss.APIVersion = "SealedSecret", "bitnami.com/v1alpha1"
+	return obj.ss, obj.err
+}
+
+// JSONNew use json data create SealedSecret
+func (obj *SealedSecret) JSONNew(jsonbyts []byte) *SealedSecret {
+	obj.error(decodeJSON(jsonbyts, obj.ss))
+	return obj
+}
+
+// YAMLNew use yaml data create SealedSecret
+func (obj *SealedSecret) YAMLNew(yamlbyts []byte) *SealedSecret {
+	obj.error(decodeYAML(yamlbyts, obj.ss))
+	return obj
+}
+
+// SetName set SealedSecret name
+func (obj *SealedSecret) SetName(name string) *SealedSecret {
+	obj.ss.SetName(name)
+	return obj
+}
+
+// SetNamespace set SealedSecret namespace
+func (obj *SealedSecret) SetNamespace(namespace string) *SealedSecret {
+	obj.ss.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set SealedSecret namespace and name
+func (obj *SealedSecret) SetNamespaceAndName(namespace, name string) *SealedSecret {
+	obj.ss.SetName(name)
+	obj.ss.SetNamespace(namespace)
+	return obj
+}
+
+// SetLabels set SealedSecret labels and the labels of the Secret created by the controller
+func (obj *SealedSecret) SetLabels(labels map[string]string) *SealedSecret {
+	obj.ss.SetLabels(labels)
+	obj.ss.Spec.Template.SetLabels(labels)
+	return obj
+}
+
+// AddLabel add or overwrite one label of SealedSecret,the other labels are kept,
+// unlike SetLabels() which replaces all labels,eg: the labels loaded by YAMLNew().
+func (obj *SealedSecret) AddLabel(key, value string) *SealedSecret {
+	obj.error(addLabel(obj.ss, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of SealedSecret,the other annotations are kept
+func (obj *SealedSecret) AddAnnotation(key, value string) *SealedSecret {
+	obj.error(addAnnotation(obj.ss, key, value))
+	return obj
+}
+
+// SetType set the type of the Secret created by the controller,default Opaque
+func (obj *SealedSecret) SetType(secType SecretType) *SealedSecret {
+	obj.ss.Spec.Template.Type = secType.ToK8s()
+	return obj
+}
+
+// SetScope set the sealing scope,default strict
+func (obj *SealedSecret) SetScope(scope SealingScope) *SealedSecret {
+	switch scope {
+	case SealingScopeStrict, SealingScopeNamespaceWide, SealingScopeClusterWide:
+		obj.scope = scope
+	default:
+		obj.error(fieldErrorf("SetScope", "scope %s is not supported", scope))
+	}
+	return obj
+}
+
+// SetCert set the PEM certificate of sealed-secrets controller,
+// get it by: kubeseal --fetch-cert
+func (obj *SealedSecret) SetCert(certPEM []byte) *SealedSecret {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		obj.error(fieldError("SetCert", "certificate is not PEM encoded"))
+		return obj
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		obj.error(fieldErrorf("SetCert", "%v", err))
+		return obj
+	}
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		obj.error(fieldError("SetCert", "the public key of certificate is not RSA"))
+		return obj
+	}
+	obj.key = key
+	return obj
+}
+
+// SetDataString set the plain values,they are sealed in Finish()
+func (obj *SealedSecret) SetDataString(datas map[string]string) *SealedSecret {
+	for key, value := range datas {
+		obj.setData(key, []byte(value))
+	}
+	return obj
+}
+
+// SetDataBytes set the plain values,they are sealed in Finish()
+func (obj *SealedSecret) SetDataBytes(datas map[string][]byte) *SealedSecret {
+	for key, value := range datas {
+		obj.setData(key, value)
+	}
+	return obj
+}
+
+// SetEncryptedData set the values which are sealed already,eg: by kubeseal --raw
+func (obj *SealedSecret) SetEncryptedData(datas map[string]string) *SealedSecret {
+	if obj.ss.Spec.EncryptedData == nil {
+		obj.ss.Spec.EncryptedData = make(map[string]string, len(datas))
+	}
+	for key, value := range datas {
+		obj.ss.Spec.EncryptedData[key] = value
+	}
+	return obj
+}
+
+func (obj *SealedSecret) setData(key string, value []byte) {
+	if obj.plain == nil {
+		obj.plain = make(map[string][]byte)
+	}
+	obj.plain[key] = value
+}
+
+// String the current SealedSecret as yaml, the pending error is written on the top as comment.
+// the plain values are not in it.
+func (obj *SealedSecret) String() string { return dump("SealedSecret", obj.ss, obj.err) }
+
+// Dump print the current SealedSecret and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *SealedSecret) Dump() *SealedSecret {
+	fmt.Println(obj.String())
+	return obj
+}
+
+func (obj *SealedSecret) error(err error) {
+	obj.err = appendError(obj.err, err)
+}
+
+// check check necessary value without sealing
+func (obj *SealedSecret) check() error {
+	var errs error
+	if !verifyString(obj.ss.GetName()) {
+		errs = appendError(errs, fieldError("SealedSecret.Name", "is not allowed to be empty"))
+	}
+	if obj.scope != SealingScopeClusterWide && !verifyString(obj.ss.GetNamespace()) {
+		errs = appendError(errs, fieldErrorf("SealedSecret.Namespace", "is not allowed to be empty when scope is %s", obj.scope))
+	}
+	if len(obj.plain) > 0 && obj.key == nil {
+		errs = appendError(errs, fieldError("SealedSecret.Certificate", "is not allowed to be empty,you can call SetCert()"))
+	}
+	if len(obj.plain) <= 0 && len(obj.ss.Spec.EncryptedData) <= 0 {
+		errs = appendError(errs, fieldError("SealedSecret.Spec.EncryptedData", "is not allowed to be empty"))
+	}
+	return errs
+}
+
+func (obj *SealedSecret) verify() error {
+	// the values are only sealed when there is no problem,so the plain values are kept for the next Finish()
+	if errs := obj.check(); errs != nil || obj.err != nil {
+		return errs
+	}
+	label := sealingLabel(obj.scope, obj.ss.GetNamespace(), obj.ss.GetName())
+	if obj.ss.Spec.EncryptedData == nil {
+		obj.ss.Spec.EncryptedData = make(map[string]string, len(obj.plain))
+	}
+	for key, value := range obj.plain {
+		sealed, err := hybridEncrypt(rand.Reader, obj.key, value, label)
+		if err != nil {
+			return fmt.Errorf("SealedSecret seal %s failed:%v", key, err)
+		}
+		obj.ss.Spec.EncryptedData[key] = base64.StdEncoding.EncodeToString(sealed)
+	}
+	obj.plain = nil
+	annotations := obj.ss.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	switch obj.scope {
+	case SealingScopeNamespaceWide:
+		annotations[sealedNamespaceWideKey] = "true"
+	case SealingScopeClusterWide:
+		annotations[sealedClusterWideKey] = "true"
+	}
+	if len(annotations) > 0 {
+		obj.ss.SetAnnotations(annotations)
+		obj.ss.Spec.Template.SetAnnotations(annotations)
+	}
+	obj.ss.Spec.Template.SetName(obj.ss.GetName())
+	obj.ss.Spec.Template.SetNamespace(obj.ss.GetNamespace())
+	obj.ss.Kind = "SealedSecret"
+	obj.ss.APIVersion = "bitnami.com/v1alpha1"
+	return nil
+}
+
+// sealingLabel get the label of RSA-OAEP encryption of the scope, it is the same as kubeseal
+func sealingLabel(scope SealingScope, namespace, name string) []byte {
+	switch scope {
+	case SealingScopeClusterWide:
+		return []byte("")
+	case SealingScopeNamespaceWide:
+		return []byte(namespace)
+	}
+	return []byte(namespace + "/" + name)
+}
+
+// hybridEncrypt encrypt the plain value the same as kubeseal:
+// a random AES-256-GCM session key encrypts the value and RSA-OAEP(SHA256) encrypts the session key,
+// the result is: 2 bytes length of the encrypted session key + encrypted session key + encrypted value
+func hybridEncrypt(r io.Reader, key *rsa.PublicKey, plain, label []byte) ([]byte, error) {
+	sessionKey := make([]byte, 32)
+	if _, err := io.ReadFull(r, sessionKey); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	aed, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	rsaCipher, err := rsa.EncryptOAEP(sha256.New(), r, key, sessionKey, label)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 2, 2+len(rsaCipher)+len(plain)+aed.Overhead())
+	binary.BigEndian.PutUint16(out, uint16(len(rsaCipher)))
+	out = append(out, rsaCipher...)
+	// the session key is used only once, so the zero nonce is safe
+	zeroNonce := make([]byte, aed.NonceSize())
+	return aed.Seal(out, zeroNonce, plain, nil), nil
+}
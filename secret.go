@@ -1,40 +1,71 @@
 package beku
 
 import (
-	"encoding/json"
-	"errors"
+	"context"
+	"fmt"
 
-	"github.com/ghodss/yaml"
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 )
 
 // Secret include Kuebernetes resource object Secret and error.
 type Secret struct {
-	sc  *v1.Secret
-	err error
+	sc        *v1.Secret
+	err       error
+	verifiers []func(*v1.Secret) error
 }
 
 // NewSecret create Secret and chain function call begin with this function.
 func NewSecret() *Secret { return &Secret{sc: &v1.Secret{}} }
 
+// SecretFrom create Secret from the existing Secret,eg: the one got by client,
+// so it can be changed by the chain function and released again,
+// sc is deep copied and the fields which are not changed by the chain function are kept.
+func SecretFrom(sc *v1.Secret) *Secret {
+	if sc == nil {
+		return NewSecret()
+	}
+	return &Secret{sc: sc.DeepCopy()}
+}
+
 // Finish chain function call end with this function.
 // return obj(Kubernetes resource object) and error
 // In the function, it will check necessary parameters、input the default field。
 func (obj *Secret) Finish() (*v1.Secret, error) {
-	obj.verify()
+	err := appendError(obj.err, obj.verify())
+	return obj.sc, err
+}
+
+// Validate check Secret necessary value like Finish(),and return the problems as FieldError,
+// but Secret is not changed, no Kind,APIVersion or default field is input,
+// so it can be used to check a partially built Secret.
+func (obj *Secret) Validate() []FieldError {
+	cp := &Secret{sc: obj.sc.DeepCopy(), verifiers: obj.verifiers, err: obj.err}
+	return FieldErrors(appendError(cp.err, cp.verify()))
+}
+
+// FinishObject same as Finish(), but return Secret as runtime.Object,
+// so Secret can be used as Builder, eg: add into Bundle.
+func (obj *Secret) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// FinishUnchecked return Secret without the checks and default fields of Finish(),only Kind and APIVersion are input,
+// it is for the partial Secret which is patched onto the existing one,the error of the chain is still returned.
+func (obj *Secret) FinishUnchecked() (*v1.Secret, error) {
+	obj.sc.Kind, obj.sc.APIVersion = "Secret", "v1"
 	return obj.sc, obj.err
 }
 
 // JSONNew use json data create Secret
 func (obj *Secret) JSONNew(jsonbyts []byte) *Secret {
-	obj.error(json.Unmarshal(jsonbyts, obj.sc))
+	obj.error(decodeJSON(jsonbyts, obj.sc))
 	return obj
 }
 
 // YAMLNew use yaml data create Secret
 func (obj *Secret) YAMLNew(yamlbyts []byte) *Secret {
-	obj.error(yaml.Unmarshal(yamlbyts, obj.sc))
+	obj.error(decodeYAML(yamlbyts, obj.sc))
 	return obj
 }
 
@@ -71,6 +102,19 @@ func (obj *Secret) SetLabels(labels map[string]string) *Secret {
 	return obj
 }
 
+// AddLabel add or overwrite one label of Secret,the other labels are kept,
+// unlike SetLabels() which replaces all labels,eg: the labels loaded by YAMLNew().
+func (obj *Secret) AddLabel(key, value string) *Secret {
+	obj.error(addLabel(obj.sc, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of Secret,the other annotations are kept
+func (obj *Secret) AddAnnotation(key, value string) *Secret {
+	obj.error(addAnnotation(obj.sc, key, value))
+	return obj
+}
+
 // SetDataString set Secret data, and Don't need to encode base64,because K8S will automatically encrypt
 func (obj *Secret) SetDataString(datas map[string]string) *Secret {
 	obj.sc.StringData = datas
@@ -97,11 +141,11 @@ func (obj *Secret) Release() (*v1.Secret, error) {
 	if err != nil {
 		return nil, err
 	}
-	client, err := GetKubeClient()
+	client, err := getKubeInterface()
 	if err != nil {
 		return nil, err
 	}
-	return client.CoreV1().Secrets(sec.GetNamespace()).Create(sec)
+	return client.CoreV1().Secrets(sec.GetNamespace()).Create(context.TODO(), sec, metav1.CreateOptions{})
 }
 
 // Apply  it will be updated when this resource object exists in K8s,
@@ -111,38 +155,98 @@ func (obj *Secret) Apply() (*v1.Secret, error) {
 	if err != nil {
 		return nil, err
 	}
-	client, err := GetKubeClient()
+	client, err := getKubeInterface()
 	if err != nil {
 		return nil, err
 	}
-	_, err = client.CoreV1().Secrets(sec.GetNamespace()).Get(sec.GetName(), metav1.GetOptions{})
+	_, err = client.CoreV1().Secrets(sec.GetNamespace()).Get(context.TODO(), sec.GetName(), metav1.GetOptions{})
 	if err != nil {
-		return client.CoreV1().Secrets(sec.GetNamespace()).Create(sec)
+		return client.CoreV1().Secrets(sec.GetNamespace()).Create(context.TODO(), sec, metav1.CreateOptions{})
 	}
-	return client.CoreV1().Secrets(sec.GetNamespace()).Update(sec)
+	return client.CoreV1().Secrets(sec.GetNamespace()).Update(context.TODO(), sec, metav1.UpdateOptions{})
 }
 
-func (obj *Secret) error(err error) {
+// Watch watch Secret on Kubernetes by its namespace and name,and call handler when it is added,updated or deleted,
+// client is the Kubernetes clientset,the registered one is used when it is nil,it blocks until ctx is done.
+func (obj *Secret) Watch(ctx context.Context, client kubernetes.Interface, handler EventHandler) error {
 	if obj.err != nil {
-		return
+		return obj.err
 	}
-	obj.err = err
+	return watchObjects(ctx, client, []runtime.Object{obj.sc}, handler)
 }
 
-// verify check Secret necessary value, input the default field and input related data.
-func (obj *Secret) verify() {
+// Delete delete Secret on Kubernetes by its name and namespace,
+// opts[0] sets the propagation policy and grace period.
+func (obj *Secret) Delete(opts ...DeleteOptions) error {
 	if obj.err != nil {
-		return
+		return obj.err
+	}
+	options, err := newDeleteOptions("Secret", obj.sc.GetName(), opts)
+	if err != nil {
+		return err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return err
+	}
+	return client.CoreV1().Secrets(obj.sc.GetNamespace()).Delete(context.TODO(), obj.sc.GetName(), *options)
+}
+
+// String the current Secret as yaml, the pending error is written on the top as comment.
+// it doesn't check and input default field like Finish(), so the Secret may be incomplete.
+func (obj *Secret) String() string { return dump("Secret", obj.sc, obj.err) }
+
+// Dump print the current Secret and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *Secret) Dump() *Secret {
+	fmt.Println(obj.String())
+	return obj
+}
+
+// WithVerifier add the verifier which checks Secret in Finish() and Validate() after the checks of beku,
+// it only affects this builder,so one call site can enforce extra invariants,eg: the image must be from the company registry.
+func (obj *Secret) WithVerifier(verifier func(*v1.Secret) error) *Secret {
+	if verifier == nil {
+		obj.error(fieldError("WithVerifier", "verifier is not allowed to be nil"))
+		return obj
 	}
+	obj.verifiers = append(obj.verifiers, verifier)
+	return obj
+}
+
+func (obj *Secret) error(err error) {
+	obj.err = appendError(obj.err, err)
+}
+
+// verify check Secret necessary value, input the default field and input related data.
+func (obj *Secret) verify() error {
+	var errs error
 	if !verifyString(obj.sc.Name) {
-		obj.err = errors.New("secret name not allow empty")
-		return
+		errs = appendError(errs, fieldError("Secret.Name", "is not allowed to be empty"))
 	}
 	if len(obj.sc.Data) <= 0 && len(obj.sc.StringData) <= 0 {
-		obj.err = errors.New("secret data is not allowed to be empty")
-		return
+		errs = appendError(errs, fieldError("Secret.Data", "is not allowed to be empty"))
+	}
+	if errs != nil {
+		return errs
 	}
 	obj.sc.Kind = "Secret"
 	obj.sc.APIVersion = "v1"
-
+	// the verifiers check the finished object,so they are run after all checks and default fields
+	return obj.runVerifiers()
+}
+
+// runVerifiers run the verifiers added by WithVerifier() in order when Secret is valid,
+// then the verifiers registered by RegisterVerifier().
+func (obj *Secret) runVerifiers() error {
+	var errs error
+	for _, verifier := range obj.verifiers {
+		if err := verifier(obj.sc); err != nil {
+			errs = appendError(errs, fmt.Errorf("Secret %s verifier failed:%v", obj.sc.GetName(), err))
+		}
+	}
+	if err := runRegisteredVerifiers(obj.sc); err != nil {
+		errs = appendError(errs, fmt.Errorf("Secret %s %v", obj.sc.GetName(), err))
+	}
+	return errs
 }
@@ -0,0 +1,314 @@
+package beku
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s.io/api/core/v1"
+)
+
+const (
+	// FSGroupChangeOnRootMismatch change the ownership of volume only when the root directory doesn't match fsGroup,
+	// it is much faster for the large volumes.
+	FSGroupChangeOnRootMismatch = "OnRootMismatch"
+	// FSGroupChangeAlways always change the ownership of volume when it is mounted
+	FSGroupChangeAlways = "Always"
+)
+
+// podSecurityContext get the security context of Pod,it is created when it is nil
+func podSecurityContext(podTemp *v1.PodTemplateSpec) *v1.PodSecurityContext {
+	if podTemp.Spec.SecurityContext == nil {
+		podTemp.Spec.SecurityContext = &v1.PodSecurityContext{}
+	}
+	return podTemp.Spec.SecurityContext
+}
+
+func setFSGroup(podTemp *v1.PodTemplateSpec, gid int64) error {
+	if gid < 0 {
+		return fieldErrorf("SetFSGroup", "gid %d is not allowed to be negative", gid)
+	}
+	podSecurityContext(podTemp).FSGroup = &gid
+	return nil
+}
+
+func setFSGroupChangePolicy(podTemp *v1.PodTemplateSpec, policy string) error {
+	if policy != FSGroupChangeOnRootMismatch && policy != FSGroupChangeAlways {
+		return fieldErrorf("SetFSGroupChangePolicy", "policy %s is not allowed,only %s or %s", policy, FSGroupChangeOnRootMismatch, FSGroupChangeAlways)
+	}
+	changePolicy := v1.PodFSGroupChangePolicy(policy)
+	podSecurityContext(podTemp).FSGroupChangePolicy = &changePolicy
+	return nil
+}
+
+func setSupplementalGroups(podTemp *v1.PodTemplateSpec, gids []int64) error {
+	if len(gids) <= 0 {
+		return fieldError("SetSupplementalGroups", "gids is not allowed to be empty")
+	}
+	for _, gid := range gids {
+		if gid < 0 {
+			return fieldErrorf("SetSupplementalGroups", "gid %d is not allowed to be negative", gid)
+		}
+	}
+	podSecurityContext(podTemp).SupplementalGroups = append([]int64(nil), gids...)
+	return nil
+}
+
+// setPodSecurityContext set the users and groups of all containers of Pod,
+// runAsNonRoot is true the kubelet refuses to start the container running as root.
+// zero id and false runAsNonRoot are unset,so the ones set before are kept,eg: the fsGroup of SetFSGroup(),
+// runAsNonRoot without runAsUser makes the kubelet check the user of the image.
+func setPodSecurityContext(podTemp *v1.PodTemplateSpec, runAsUser, runAsGroup, fsGroup int64, runAsNonRoot bool) error {
+	if runAsUser < 0 || runAsGroup < 0 || fsGroup < 0 {
+		return fieldError("SetPodSecurityContext", "runAsUser,runAsGroup and fsGroup are not allowed to be negative")
+	}
+	sc := podSecurityContext(podTemp)
+	if runAsUser > 0 {
+		sc.RunAsUser = &runAsUser
+	}
+	if runAsGroup > 0 {
+		sc.RunAsGroup = &runAsGroup
+	}
+	if fsGroup > 0 {
+		sc.FSGroup = &fsGroup
+	}
+	if runAsNonRoot {
+		sc.RunAsNonRoot = &runAsNonRoot
+	}
+	return nil
+}
+
+// setContainerSecurityContext set the user and group of the container,they override the ones of Pod
+func setContainerSecurityContext(podTemp *v1.PodTemplateSpec, container string, runAsUser, runAsGroup int64, runAsNonRoot bool) error {
+	index := containerIndex(podTemp.Spec.Containers, container)
+	if index < 0 {
+		return fieldErrorf("SetContainerSecurityContext", "container %s is not found", container)
+	}
+	if runAsUser < 0 || runAsGroup < 0 {
+		return fieldError("SetContainerSecurityContext", "runAsUser and runAsGroup are not allowed to be negative")
+	}
+	if runAsNonRoot && runAsUser == 0 {
+		return fieldError("SetContainerSecurityContext", "runAsUser 0 is root,it is not allowed with runAsNonRoot")
+	}
+	sc := containerSecurityContext(&podTemp.Spec.Containers[index])
+	sc.RunAsUser, sc.RunAsGroup, sc.RunAsNonRoot = &runAsUser, &runAsGroup, &runAsNonRoot
+	return nil
+}
+
+// addCapabilities add the linux capabilities into the add or drop list of the container,
+// the capabilities set before are kept,unlike setCapabilities() which replaces them.
+func addCapabilities(podTemp *v1.PodTemplateSpec, method, container string, names []string, drop bool) error {
+	index := containerIndex(podTemp.Spec.Containers, container)
+	if index < 0 {
+		return fieldErrorf(method, "container %s is not found", container)
+	}
+	if len(names) <= 0 {
+		return fieldErrorf(method, "capabilities are not allowed to be empty")
+	}
+	capabilities, err := toCapabilities(names)
+	if err != nil {
+		return fieldErrorf(method, "%v", err)
+	}
+	sc := containerSecurityContext(&podTemp.Spec.Containers[index])
+	if sc.Capabilities == nil {
+		sc.Capabilities = &v1.Capabilities{}
+	}
+	list, other := &sc.Capabilities.Add, sc.Capabilities.Drop
+	if drop {
+		list, other = &sc.Capabilities.Drop, sc.Capabilities.Add
+	}
+	for _, capability := range capabilities {
+		if containsCapability(other, capability) {
+			return fieldErrorf(method, "capability %s is not allowed to be added and dropped both", capability)
+		}
+		if !containsCapability(*list, capability) {
+			*list = append(*list, capability)
+		}
+	}
+	return nil
+}
+
+func containsCapability(capabilities []v1.Capability, capability v1.Capability) bool {
+	for _, c := range capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// verifySecurityContext check the security contexts of Pod and containers which Kubernetes apiServer or kubelet rejects:
+// fsGroupChangePolicy is ignored without fsGroup,privileged container must allow privilege escalation,
+// runAsNonRoot container can't run as user 0.
+func verifySecurityContext(pod v1.PodSpec) error {
+	sc := pod.SecurityContext
+	if sc != nil && sc.FSGroupChangePolicy != nil && sc.FSGroup == nil {
+		return errors.New("fsGroupChangePolicy is not allowed without fsGroup,you can call SetFSGroup() set it")
+	}
+	for _, container := range append(append([]v1.Container(nil), pod.InitContainers...), pod.Containers...) {
+		if runsAsRootUser(sc, container.SecurityContext) {
+			return fmt.Errorf("container %q runs as user 0 with runAsNonRoot,it is not allowed to start", container.Name)
+		}
+		csc := container.SecurityContext
+		if csc == nil || csc.Privileged == nil || !*csc.Privileged {
+			continue
+		}
+		if csc.AllowPrivilegeEscalation != nil && !*csc.AllowPrivilegeEscalation {
+			return fmt.Errorf("container %q is privileged,allowPrivilegeEscalation is not allowed to be false", container.Name)
+		}
+	}
+	return nil
+}
+
+// runsAsRootUser check the container runs as user 0 with runAsNonRoot,the container fields override the Pod ones
+func runsAsRootUser(pod *v1.PodSecurityContext, container *v1.SecurityContext) bool {
+	var runAsUser *int64
+	var runAsNonRoot *bool
+	if pod != nil {
+		runAsUser, runAsNonRoot = pod.RunAsUser, pod.RunAsNonRoot
+	}
+	if container != nil {
+		if container.RunAsUser != nil {
+			runAsUser = container.RunAsUser
+		}
+		if container.RunAsNonRoot != nil {
+			runAsNonRoot = container.RunAsNonRoot
+		}
+	}
+	return runAsUser != nil && *runAsUser == 0 && runAsNonRoot != nil && *runAsNonRoot
+}
+
+// containerSecurityContext get the security context of the container,it is created when it is nil
+func containerSecurityContext(container *v1.Container) *v1.SecurityContext {
+	if container.SecurityContext == nil {
+		container.SecurityContext = &v1.SecurityContext{}
+	}
+	return container.SecurityContext
+}
+
+// applyRestrictedSecurityProfile set the security context of Pod and all containers to match
+// the restricted Pod Security Standard,the containers must be set first.
+func applyRestrictedSecurityProfile(podTemp *v1.PodTemplateSpec) error {
+	if len(podTemp.Spec.Containers) <= 0 {
+		return fieldError("ApplyRestrictedSecurityProfile", "the containers are not set,you can call SetContainer() first")
+	}
+	runAsNonRoot := true
+	podSC := podSecurityContext(podTemp)
+	podSC.RunAsNonRoot = &runAsNonRoot
+	podSC.SeccompProfile = &v1.SeccompProfile{Type: v1.SeccompProfileTypeRuntimeDefault}
+	restrict := func(container *v1.Container) {
+		allowPrivilegeEscalation, readOnlyRootFilesystem, privileged := false, true, false
+		sc := containerSecurityContext(container)
+		sc.RunAsNonRoot = &runAsNonRoot
+		sc.Privileged = &privileged
+		sc.AllowPrivilegeEscalation = &allowPrivilegeEscalation
+		sc.ReadOnlyRootFilesystem = &readOnlyRootFilesystem
+		// the container profile overrides the Pod one,so Unconfined in container is replaced too
+		sc.SeccompProfile = &v1.SeccompProfile{Type: v1.SeccompProfileTypeRuntimeDefault}
+		if sc.Capabilities == nil {
+			sc.Capabilities = &v1.Capabilities{}
+		}
+		// only NET_BIND_SERVICE is allowed to be added by the restricted standard
+		var add []v1.Capability
+		for _, capability := range sc.Capabilities.Add {
+			if capability == "NET_BIND_SERVICE" {
+				add = append(add, capability)
+			}
+		}
+		sc.Capabilities.Add = add
+		sc.Capabilities.Drop = []v1.Capability{"ALL"}
+	}
+	for index := range podTemp.Spec.InitContainers {
+		restrict(&podTemp.Spec.InitContainers[index])
+	}
+	for index := range podTemp.Spec.Containers {
+		restrict(&podTemp.Spec.Containers[index])
+	}
+	return nil
+}
+
+// setCapabilities set the linux capabilities to add and drop of the container,
+// the names are upper case without "CAP_" prefix,eg: NET_ADMIN, "ALL" drops all capabilities.
+func setCapabilities(podTemp *v1.PodTemplateSpec, container string, add, drop []string) error {
+	index := containerIndex(podTemp.Spec.Containers, container)
+	if index < 0 {
+		return fieldErrorf("SetCapabilities", "container %s is not found", container)
+	}
+	if len(add) <= 0 && len(drop) <= 0 {
+		return fieldError("SetCapabilities", "add and drop are not allowed to be empty both")
+	}
+	adds, err := toCapabilities(add)
+	if err != nil {
+		return fieldErrorf("SetCapabilities", "%v", err)
+	}
+	drops, err := toCapabilities(drop)
+	if err != nil {
+		return fieldErrorf("SetCapabilities", "%v", err)
+	}
+	for _, a := range adds {
+		for _, d := range drops {
+			if a == d {
+				return fieldErrorf("SetCapabilities", "capability %s is not allowed to be added and dropped both", a)
+			}
+		}
+	}
+	containerSecurityContext(&podTemp.Spec.Containers[index]).Capabilities = &v1.Capabilities{Add: adds, Drop: drops}
+	return nil
+}
+
+func toCapabilities(names []string) ([]v1.Capability, error) {
+	var capabilities []v1.Capability
+	for _, name := range names {
+		name = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(name)), "CAP_")
+		if name == "" {
+			return nil, errors.New("capability is not allowed to be empty")
+		}
+		for _, c := range name {
+			if (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '_' {
+				return nil, fmt.Errorf("capability %s is not allowed,it should be like NET_ADMIN", name)
+			}
+		}
+		capabilities = append(capabilities, v1.Capability(name))
+	}
+	return capabilities, nil
+}
+
+// setContainerSecurityFlag set the bool flag of the security context of the container by set
+func setContainerSecurityFlag(podTemp *v1.PodTemplateSpec, method, container string, value bool, set func(sc *v1.SecurityContext, value *bool)) error {
+	index := containerIndex(podTemp.Spec.Containers, container)
+	if index < 0 {
+		return fieldErrorf(method, "container %s is not found", container)
+	}
+	set(containerSecurityContext(&podTemp.Spec.Containers[index]), &value)
+	return nil
+}
+
+// newSELinuxOptions create SELinux options,at least one of them is not empty
+func newSELinuxOptions(method, user, role, typ, level string) (*v1.SELinuxOptions, error) {
+	if user == "" && role == "" && typ == "" && level == "" {
+		return nil, fieldErrorf(method, "user,role,type and level are not allowed to be empty all")
+	}
+	return &v1.SELinuxOptions{User: user, Role: role, Type: typ, Level: level}, nil
+}
+
+func setPodSELinuxOptions(podTemp *v1.PodTemplateSpec, user, role, typ, level string) error {
+	options, err := newSELinuxOptions("SetSELinuxOptions", user, role, typ, level)
+	if err != nil {
+		return err
+	}
+	podSecurityContext(podTemp).SELinuxOptions = options
+	return nil
+}
+
+func setContainerSELinuxOptions(podTemp *v1.PodTemplateSpec, container, user, role, typ, level string) error {
+	index := containerIndex(podTemp.Spec.Containers, container)
+	if index < 0 {
+		return fieldErrorf("SetContainerSELinuxOptions", "container %s is not found", container)
+	}
+	options, err := newSELinuxOptions("SetContainerSELinuxOptions", user, role, typ, level)
+	if err != nil {
+		return err
+	}
+	containerSecurityContext(&podTemp.Spec.Containers[index]).SELinuxOptions = options
+	return nil
+}
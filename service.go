@@ -1,42 +1,96 @@
 package beku
 
 import (
-	"encoding/json"
-	"errors"
+	"context"
 	"fmt"
+	"strings"
 
-	"github.com/ghodss/yaml"
 	"k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
 )
 
 // Service include Kubernetes resource object Service and error
 type Service struct {
-	svc *v1.Service
-	err error
+	svc       *v1.Service
+	err       error
+	verifiers []func(*v1.Service) error
 }
 
 // NewSvc create service(svc) and chain function call begin with this function.
 func NewSvc() *Service { return &Service{svc: &v1.Service{}} }
 
+// ServiceFrom create Service from the existing Service,eg: the one got by client,
+// so it can be changed by the chain function and released again,
+// svc is deep copied and the fields which are not changed by the chain function are kept.
+func ServiceFrom(svc *v1.Service) *Service {
+	if svc == nil {
+		return NewSvc()
+	}
+	return &Service{svc: svc.DeepCopy()}
+}
+
 // Finish Chain function call end with this function
 // return real service(really service is kubernetes resource object Service and error
 // In the function, it will check necessary parametersainput the default field
 func (obj *Service) Finish() (svc *v1.Service, err error) {
-	obj.verify()
-	svc, err = obj.svc, obj.err
-	return
+	err = appendError(obj.err, obj.verify())
+	return obj.svc, err
+}
+
+// Validate check Service necessary value like Finish(),and return the problems as FieldError,
+// but Service is not changed, no Kind,APIVersion or default field is input,
+// so it can be used to check a partially built Service.
+func (obj *Service) Validate() []FieldError {
+	cp := &Service{svc: obj.svc.DeepCopy(), verifiers: obj.verifiers, err: obj.err}
+	return FieldErrors(appendError(cp.err, cp.verify()))
+}
+
+// Clone deep copy Service with the pending error,the clone and Service don't share any field,eg: the ports,
+// so the clones of one base builder can be specialized in parallel goroutines,eg: per-tenant Services.
+// the builder itself is not safe for concurrent use,clone it for every goroutine.
+func (obj *Service) Clone() *Service {
+	return &Service{svc: obj.svc.DeepCopy(), err: obj.err, verifiers: append([]func(*v1.Service) error(nil), obj.verifiers...)}
+}
+
+// Reset clear Service to the state of NewSvc(),so the builder can be reused for the next Service,
+// the capacity of the ports is kept,so the Service returned by Finish() must not be used after it.
+func (obj *Service) Reset() *Service {
+	if obj.svc == nil {
+		*obj = Service{svc: &v1.Service{}}
+		return obj
+	}
+	ports := obj.svc.Spec.Ports[:0]
+	*obj.svc = v1.Service{}
+	obj.svc.Spec.Ports = ports
+	*obj = Service{svc: obj.svc}
+	return obj
+}
+
+// FinishObject same as Finish(), but return Service as runtime.Object,
+// so Service can be used as Builder, eg: add into Bundle.
+func (obj *Service) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// FinishUnchecked return Service without the checks and default fields of Finish(),only Kind and APIVersion are input,
+// it is for the partial Service which is patched onto the existing one,the error of the chain is still returned.
+func (obj *Service) FinishUnchecked() (*v1.Service, error) {
+	obj.svc.Kind, obj.svc.APIVersion = "Service", "v1"
+	return obj.svc, obj.err
 }
 
 // JSONNew use json data create service(svc)
 func (obj *Service) JSONNew(jsonbyts []byte) *Service {
-	obj.error(json.Unmarshal(jsonbyts, obj.svc))
+	obj.error(decodeJSON(jsonbyts, obj.svc))
 	return obj
 }
 
 // YAMLNew use yaml data create service(svc)
 func (obj *Service) YAMLNew(yamlbyts []byte) *Service {
-	obj.error(yaml.Unmarshal(yamlbyts, obj.svc))
+	obj.error(decodeYAML(yamlbyts, obj.svc))
 	return obj
 }
 
@@ -74,6 +128,56 @@ func (obj *Service) SetLabels(labels map[string]string) *Service {
 	return obj
 }
 
+// AddLabel add or overwrite one label of Service,the other labels are kept,
+// unlike SetLabels() which replaces all labels,eg: the labels loaded by YAMLNew().
+func (obj *Service) AddLabel(key, value string) *Service {
+	obj.error(addLabel(obj.svc, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of Service,the other annotations are kept
+func (obj *Service) AddAnnotation(key, value string) *Service {
+	obj.error(addAnnotation(obj.svc, key, value))
+	return obj
+}
+
+// MergeLabels add or overwrite the labels of Service,the other labels are kept,unlike SetLabels() which replaces all labels
+func (obj *Service) MergeLabels(labels map[string]string) *Service {
+	obj.error(mergeLabels(obj.svc, labels))
+	return obj
+}
+
+// MergeAnnotations add or overwrite the annotations of Service,the other annotations are kept,
+// unlike SetAnnotations() which replaces all annotations.
+func (obj *Service) MergeAnnotations(annotations map[string]string) *Service {
+	obj.error(mergeAnnotations(obj.svc, annotations))
+	return obj
+}
+
+// SetAppKubernetesLabels set the recommended app.kubernetes.io labels on Service,eg: name mysql,
+// version 5.7.21,component database and part-of wordpress,the empty values except name are skipped,the selector is not changed.
+func (obj *Service) SetAppKubernetesLabels(name, version, component, partOf string) *Service {
+	labels, err := appKubernetesLabels(name, version, component, partOf)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.error(mergeLabels(obj.svc, labels))
+	return obj
+}
+
+// SetPrometheusScrape set the prometheus.io annotations of Service,so the endpoints of Service are scraped by
+// the Prometheus annotation-based discovery,port is the metrics port,path default /metrics.
+func (obj *Service) SetPrometheusScrape(port int32, path string) *Service {
+	annotations, err := prometheusScrapeAnnotations(port, path)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.error(mergeAnnotations(obj.svc, annotations))
+	return obj
+}
+
 // SetSelector set service(svc) seletor
 // The Pod that matches the selector will be selected
 // the function Required call when you create service(svc)
@@ -123,11 +227,32 @@ func (obj *Service) SetPort(sp ServicePort) *Service {
 		TargetPort: FromInt(sp.TargetPort),
 		NodePort:   sp.NodePort,
 	}
-	if len(obj.svc.Spec.Ports) > 0 {
-		obj.svc.Spec.Ports = append(obj.svc.Spec.Ports, sPort)
+	obj.svc.Spec.Ports = append(obj.svc.Spec.Ports, sPort)
+	return obj
+}
+
+// SetPortByName set service(svc) port which targets the named container port,eg: SetPortByName("grpc", 80, "grpc", "TCP"),
+// so the container port number can be changed without changing Service,protocol is TCP,UDP or SCTP,default TCP.
+func (obj *Service) SetPortByName(name string, port int32, targetPort, protocol string) *Service {
+	if port <= 0 || port >= 65536 {
+		obj.error(fieldErrorf("SetPortByName", "port %d is not allowed,range: 0 < port < 65536", port))
+		return obj
+	}
+	if errs := validation.IsValidPortName(targetPort); len(errs) > 0 {
+		obj.error(fieldErrorf("SetPortByName", "targetPort %q is not allowed,it must be the name of container port:%s", targetPort, strings.Join(errs, ",")))
 		return obj
 	}
-	obj.svc.Spec.Ports = []v1.ServicePort{sPort}
+	proto, err := protocolOf("SetPortByName", protocol)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.svc.Spec.Ports = append(obj.svc.Spec.Ports, v1.ServicePort{
+		Name:       name,
+		Protocol:   proto,
+		Port:       port,
+		TargetPort: intstr.FromString(targetPort),
+	})
 	return obj
 }
 
@@ -143,17 +268,77 @@ func (obj *Service) Headless() *Service {
 	return obj
 }
 
+// FromDeployment derive Service from Deployment,the selector of Deployment is the selector of Service,
+// and the container ports are exposed as service ports,the named container port is the target port by name.
+// namespace and name are copied when they are not set,so the Service matching Deployment can be built in one call,
+// the ports without name are named <container>-<port> when more than one port is exposed.
+func (obj *Service) FromDeployment(dp *Deployment) *Service {
+	if dp == nil {
+		obj.error(fieldError("FromDeployment", "Deployment is not allowed to be nil"))
+		return obj
+	}
+	if dp.err != nil {
+		obj.error(fieldErrorf("FromDeployment", "Deployment has error:%v", dp.err))
+		return obj
+	}
+	if !verifyString(obj.svc.GetNamespace()) {
+		obj.svc.SetNamespace(dp.dp.GetNamespace())
+	}
+	if !verifyString(obj.svc.GetName()) {
+		obj.svc.SetName(dp.dp.GetName())
+	}
+	selector := dp.dp.Spec.Template.GetLabels()
+	if dp.dp.Spec.Selector != nil && len(dp.dp.Spec.Selector.MatchLabels) > 0 {
+		selector = dp.dp.Spec.Selector.MatchLabels
+	}
+	if len(selector) == 0 {
+		obj.error(fieldError("FromDeployment", "Deployment has no Pod labels,you can call SetPodLabels() first"))
+		return obj
+	}
+	obj.svc.Spec.Selector = copyLabels(selector)
+	var ports []v1.ServicePort
+	for _, container := range dp.dp.Spec.Template.Spec.Containers {
+		for _, port := range container.Ports {
+			servicePort := v1.ServicePort{
+				Name:       port.Name,
+				Protocol:   port.Protocol,
+				Port:       port.ContainerPort,
+				TargetPort: FromInt(int(port.ContainerPort)),
+			}
+			if verifyString(port.Name) {
+				servicePort.TargetPort = FromString(port.Name)
+			} else {
+				servicePort.Name = fmt.Sprintf("%s-%d", container.Name, port.ContainerPort)
+			}
+			if servicePort.Protocol == "" {
+				servicePort.Protocol = v1.ProtocolTCP
+			}
+			ports = append(ports, servicePort)
+		}
+	}
+	if len(ports) == 0 {
+		obj.error(fieldError("FromDeployment", "Deployment has no container port to expose"))
+		return obj
+	}
+	// the generated name is only required when more than one port is exposed
+	if len(ports) == 1 && ports[0].TargetPort.Type == intstr.Int {
+		ports[0].Name = ""
+	}
+	obj.svc.Spec.Ports = ports
+	return obj
+}
+
 // Release release Service on Kubernetes
 func (obj *Service) Release() (*v1.Service, error) {
 	svc, err := obj.Finish()
 	if err != nil {
 		return nil, err
 	}
-	client, err := GetKubeClient()
+	client, err := getKubeInterface()
 	if err != nil {
 		return nil, err
 	}
-	return client.CoreV1().Services(svc.GetNamespace()).Create(svc)
+	return client.CoreV1().Services(svc.GetNamespace()).Create(context.TODO(), svc, metav1.CreateOptions{})
 }
 
 // Apply  it will be updated when this resource object exists in K8s,
@@ -163,54 +348,134 @@ func (obj *Service) Apply() (*v1.Service, error) {
 	if err != nil {
 		return nil, err
 	}
-	client, err := GetKubeClient()
+	client, err := getKubeInterface()
 	if err != nil {
 		return nil, err
 	}
-	_, err = client.CoreV1().Services(svc.GetNamespace()).Get(svc.GetName(), metav1.GetOptions{})
+	_, err = client.CoreV1().Services(svc.GetNamespace()).Get(context.TODO(), svc.GetName(), metav1.GetOptions{})
 	if err != nil {
-		return client.CoreV1().Services(svc.GetNamespace()).Create(svc)
+		return client.CoreV1().Services(svc.GetNamespace()).Create(context.TODO(), svc, metav1.CreateOptions{})
 	}
-	return client.CoreV1().Services(svc.GetNamespace()).Update(svc)
+	return client.CoreV1().Services(svc.GetNamespace()).Update(context.TODO(), svc, metav1.UpdateOptions{})
 }
 
-func (obj *Service) error(err error) {
+// DryRunApply finish Service and apply it by server-side apply with dryRun=All,so it is checked by Kubernetes but not persisted,
+// client is the Client of Kubernetes,the registered cluster is used when it is nil.
+func (obj *Service) DryRunApply(ctx context.Context, client *Client) (*unstructured.Unstructured, error) {
+	svc, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		if client, err = NewClient(nil, nil); err != nil {
+			return nil, err
+		}
+	}
+	return client.DryRunApply(ctx, svc)
+}
+
+// ValidateAgainstCluster check Service by the schema,admission webhooks and quota of Kubernetes like DryRunApply(),
+// the rejected fields are returned as FieldError,so they can be split by FieldErrors().
+func (obj *Service) ValidateAgainstCluster(ctx context.Context, client *Client) error {
+	_, err := obj.DryRunApply(ctx, client)
+	return err
+}
+
+// Watch watch Service on Kubernetes by its namespace and name,and call handler when it is added,updated or deleted,
+// client is the Kubernetes clientset,the registered one is used when it is nil,it blocks until ctx is done.
+func (obj *Service) Watch(ctx context.Context, client kubernetes.Interface, handler EventHandler) error {
 	if obj.err != nil {
-		return
+		return obj.err
 	}
-	obj.err = err
+	return watchObjects(ctx, client, []runtime.Object{obj.svc}, handler)
 }
 
-// verify check service necessary value, input the default field and input related data.
-func (obj *Service) verify() {
+// Delete delete Service on Kubernetes by its name and namespace,
+// opts[0] sets the propagation policy and grace period.
+func (obj *Service) Delete(opts ...DeleteOptions) error {
 	if obj.err != nil {
-		return
+		return obj.err
+	}
+	options, err := newDeleteOptions("Service", obj.svc.GetName(), opts)
+	if err != nil {
+		return err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return err
+	}
+	return client.CoreV1().Services(obj.svc.GetNamespace()).Delete(context.TODO(), obj.svc.GetName(), *options)
+}
+
+// String the current Service as yaml, the pending error is written on the top as comment.
+// it doesn't check and input default field like Finish(), so the Service may be incomplete.
+func (obj *Service) String() string { return dump("Service", obj.svc, obj.err) }
+
+// Dump print the current Service and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *Service) Dump() *Service {
+	fmt.Println(obj.String())
+	return obj
+}
+
+// WithVerifier add the verifier which checks Service in Finish() and Validate() after the checks of beku,
+// it only affects this builder,so one call site can enforce extra invariants,eg: the image must be from the company registry.
+func (obj *Service) WithVerifier(verifier func(*v1.Service) error) *Service {
+	if verifier == nil {
+		obj.error(fieldError("WithVerifier", "verifier is not allowed to be nil"))
+		return obj
 	}
+	obj.verifiers = append(obj.verifiers, verifier)
+	return obj
+}
+
+func (obj *Service) error(err error) {
+	obj.err = appendError(obj.err, err)
+}
+
+// verify check service necessary value, input the default field and input related data.
+func (obj *Service) verify() error {
+	var errs error
 	if !verifyString(obj.svc.GetName()) {
-		obj.err = errors.New("svc.Name is not allowed to be empty")
-		return
+		errs = appendError(errs, fieldError("Service.Name", "is not allowed to be empty"))
 	}
 	portLen := len(obj.svc.Spec.Ports)
-	if verifyMap(obj.svc.Spec.Selector) {
-		if portLen < 1 {
-			obj.err = errors.New("svc.Spec.Ports is not allowed be empty when svc.Spec.Selector exist")
-			return
-		}
+	if verifyMap(obj.svc.Spec.Selector) && portLen < 1 {
+		errs = appendError(errs, fieldError("Service.Spec.Ports", "is not allowed be empty when Service.Spec.Selector exist"))
 	}
 	if portLen > 1 {
-		nameMaps := make(map[string]bool, 0)
+		nameMaps := make(map[string]bool, portLen)
 		for index, data := range obj.svc.Spec.Ports {
 			if !verifyString(data.Name) {
-				obj.err = fmt.Errorf("svc.Spec.port[%d].name is not allowed be empty when len(svc.Spec.Ports) > 1", index)
-				return
+				errs = appendError(errs, fieldErrorf("Service.Spec.Ports", "port[%d] name is not allowed be empty when there are more than one port", index))
+				continue
+			}
+			if nameMaps[data.Name] {
+				errs = appendError(errs, fieldErrorf("Service.Spec.Ports", "port[%d] name %s is duplicated", index, data.Name))
 			}
 			nameMaps[data.Name] = true
 		}
-		if len(nameMaps) != portLen {
-			obj.err = errors.New("len(svc.Spec.Ports.Name) != len(svc.Spec.Ports) is not allowed when len(svc.Sepc.Ports) > 1")
-			return
-		}
+	}
+	if errs != nil {
+		return errs
 	}
 	obj.svc.Kind = "Service"
 	obj.svc.APIVersion = "v1"
+	// the verifiers check the finished object,so they are run after all checks and default fields
+	return obj.runVerifiers()
+}
+
+// runVerifiers run the verifiers added by WithVerifier() in order when Service is valid,
+// then the verifiers registered by RegisterVerifier().
+func (obj *Service) runVerifiers() error {
+	var errs error
+	for _, verifier := range obj.verifiers {
+		if err := verifier(obj.svc); err != nil {
+			errs = appendError(errs, fmt.Errorf("Service %s verifier failed:%v", obj.svc.GetName(), err))
+		}
+	}
+	if err := runRegisteredVerifiers(obj.svc); err != nil {
+		errs = appendError(errs, fmt.Errorf("Service %s %v", obj.svc.GetName(), err))
+	}
+	return errs
 }
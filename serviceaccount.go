@@ -0,0 +1,202 @@
+package beku
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ServiceAccount include Kubernetes resource object ServiceAccount and error
+type ServiceAccount struct {
+	sa  *v1.ServiceAccount
+	err error
+}
+
+// NewServiceAccount create ServiceAccount and chain function call begin with this function,
+// the Pods use it by SetServiceAccount() of workloads and it is granted by RoleBinding or ClusterRoleBinding.
+func NewServiceAccount() *ServiceAccount { return &ServiceAccount{sa: &v1.ServiceAccount{}} }
+
+// ServiceAccountFrom create ServiceAccount from the existing ServiceAccount,eg: the one got by client,
+// so it can be changed by the chain function and released again,
+// sa is deep copied and the fields which are not changed by the chain function are kept.
+func ServiceAccountFrom(sa *v1.ServiceAccount) *ServiceAccount {
+	if sa == nil {
+		return NewServiceAccount()
+	}
+	return &ServiceAccount{sa: sa.DeepCopy()}
+}
+
+// Finish Chain function call end with this function
+// return real ServiceAccount(really ServiceAccount is kubernetes resource object ServiceAccount and error
+// In the function, it will check necessary parameters、input the default field
+func (obj *ServiceAccount) Finish() (*v1.ServiceAccount, error) {
+	err := appendError(obj.err, obj.verify())
+	return obj.sa, err
+}
+
+// Validate check ServiceAccount necessary value like Finish(),and return the problems as FieldError,
+// but ServiceAccount is not changed, no Kind,APIVersion or default field is input,
+// so it can be used to check a partially built ServiceAccount.
+func (obj *ServiceAccount) Validate() []FieldError {
+	cp := &ServiceAccount{sa: obj.sa.DeepCopy(), err: obj.err}
+	return FieldErrors(appendError(cp.err, cp.verify()))
+}
+
+// FinishObject same as Finish(), but return ServiceAccount as runtime.Object,
+// so ServiceAccount can be used as Builder, eg: add into Bundle.
+func (obj *ServiceAccount) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// JSONNew use json data create ServiceAccount
+func (obj *ServiceAccount) JSONNew(jsonbyts []byte) *ServiceAccount {
+	obj.error(decodeJSON(jsonbyts, obj.sa))
+	return obj
+}
+
+// YAMLNew use yaml data create ServiceAccount
+func (obj *ServiceAccount) YAMLNew(yamlbyts []byte) *ServiceAccount {
+	obj.error(decodeYAML(yamlbyts, obj.sa))
+	return obj
+}
+
+// Replace replace ServiceAccount by Kubernetes resource object
+func (obj *ServiceAccount) Replace(sa *v1.ServiceAccount) *ServiceAccount {
+	if sa != nil {
+		obj.sa = sa
+	}
+	return obj
+}
+
+// SetName set ServiceAccount name
+func (obj *ServiceAccount) SetName(name string) *ServiceAccount {
+	obj.sa.SetName(name)
+	return obj
+}
+
+// SetNamespace set ServiceAccount namespace,default namespace is 'default'
+func (obj *ServiceAccount) SetNamespace(namespace string) *ServiceAccount {
+	obj.sa.SetNamespace(namespace)
+	return obj
+}
+
+// SetNamespaceAndName set ServiceAccount namespace and name
+func (obj *ServiceAccount) SetNamespaceAndName(namespace, name string) *ServiceAccount {
+	obj.sa.SetName(name)
+	obj.sa.SetNamespace(namespace)
+	return obj
+}
+
+// SetLabels set ServiceAccount labels
+func (obj *ServiceAccount) SetLabels(labels map[string]string) *ServiceAccount {
+	obj.sa.SetLabels(labels)
+	return obj
+}
+
+// AddLabel add or overwrite one label of ServiceAccount,the other labels are kept
+func (obj *ServiceAccount) AddLabel(key, value string) *ServiceAccount {
+	obj.error(addLabel(obj.sa, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of ServiceAccount,the other annotations are kept,
+// eg: eks.amazonaws.com/role-arn binds the IAM role to ServiceAccount.
+func (obj *ServiceAccount) AddAnnotation(key, value string) *ServiceAccount {
+	obj.error(addAnnotation(obj.sa, key, value))
+	return obj
+}
+
+// SetImagePullSecrets set the Secrets which are used to pull the images of the Pods using ServiceAccount
+func (obj *ServiceAccount) SetImagePullSecrets(secretNames ...string) *ServiceAccount {
+	refs := make([]v1.LocalObjectReference, 0, len(secretNames))
+	for _, name := range secretNames {
+		if !verifyString(name) {
+			obj.error(fieldError("SetImagePullSecrets", "secret name is not allowed to be empty"))
+			return obj
+		}
+		refs = append(refs, v1.LocalObjectReference{Name: name})
+	}
+	obj.sa.ImagePullSecrets = refs
+	return obj
+}
+
+// SetAutomountToken set whether the token of ServiceAccount is mounted into the Pods,
+// false is recommended when the Pods don't call Kubernetes API.
+func (obj *ServiceAccount) SetAutomountToken(automount bool) *ServiceAccount {
+	obj.sa.AutomountServiceAccountToken = &automount
+	return obj
+}
+
+// Release release ServiceAccount on Kubernetes
+func (obj *ServiceAccount) Release() (*v1.ServiceAccount, error) {
+	sa, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return nil, err
+	}
+	return client.CoreV1().ServiceAccounts(sa.GetNamespace()).Create(context.TODO(), sa, metav1.CreateOptions{})
+}
+
+// Apply  it will be updated when this resource object exists in K8s,
+// it will be created when it does not exist.
+func (obj *ServiceAccount) Apply() (*v1.ServiceAccount, error) {
+	sa, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return nil, err
+	}
+	_, err = client.CoreV1().ServiceAccounts(sa.GetNamespace()).Get(context.TODO(), sa.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return client.CoreV1().ServiceAccounts(sa.GetNamespace()).Create(context.TODO(), sa, metav1.CreateOptions{})
+	}
+	return client.CoreV1().ServiceAccounts(sa.GetNamespace()).Update(context.TODO(), sa, metav1.UpdateOptions{})
+}
+
+// Delete delete ServiceAccount on Kubernetes by its name and namespace,
+// opts[0] sets the propagation policy and grace period.
+func (obj *ServiceAccount) Delete(opts ...DeleteOptions) error {
+	if obj.err != nil {
+		return obj.err
+	}
+	options, err := newDeleteOptions("ServiceAccount", obj.sa.GetName(), opts)
+	if err != nil {
+		return err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return err
+	}
+	return client.CoreV1().ServiceAccounts(obj.sa.GetNamespace()).Delete(context.TODO(), obj.sa.GetName(), *options)
+}
+
+// String the current ServiceAccount as yaml, the pending error is written on the top as comment.
+// it doesn't check and input default field like Finish(), so the ServiceAccount may be incomplete.
+func (obj *ServiceAccount) String() string { return dump("ServiceAccount", obj.sa, obj.err) }
+
+// Dump print the current ServiceAccount and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *ServiceAccount) Dump() *ServiceAccount {
+	fmt.Println(obj.String())
+	return obj
+}
+
+func (obj *ServiceAccount) error(err error) {
+	obj.err = appendError(obj.err, err)
+}
+
+// verify check ServiceAccount necessary value, input the default field.
+func (obj *ServiceAccount) verify() error {
+	if !verifyString(obj.sa.GetName()) {
+		return fieldError("ServiceAccount.Name", "is not allowed to be empty")
+	}
+	obj.sa.Kind = "ServiceAccount"
+	obj.sa.APIVersion = "v1"
+	return nil
+}
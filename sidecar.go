@@ -0,0 +1,119 @@
+package beku
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Sidecar is the container defined once and injected into any workload by InjectSidecar(),
+// eg: the log shipper and the metrics exporter,the zero fields are not set on the container.
+type Sidecar struct {
+	// Name is the container name,it must be unique in the Pod
+	Name string
+	// Image is the image of the sidecar,eg: fluent/fluent-bit:2.2
+	Image   string
+	Command []string
+	Args    []string
+	// Env is the envs of the sidecar,eg: {"LOG_LEVEL": "info"}
+	Env map[string]string
+	// Ports is the ports of the sidecar,eg: {Name: "metrics", ContainerPort: 9100},
+	// they must not conflict with the ports of the other containers,it is checked by Finish().
+	Ports []v1.ContainerPort
+	// Limits is the resource limits of the sidecar,eg: {"cpu": "100m", "memory": "64Mi"}
+	Limits map[ResourceName]string
+	// Mounts is the existing volumes of the Pod mounted on the sidecar,volume name to mount path,eg: the ConfigMap of the config.
+	Mounts map[string]string
+	// SharedVolumes is the emptyDir volumes shared by the sidecar and all containers of the Pod,volume name to mount path,
+	// eg: {"app-logs": "/var/log/app"} for the log shipper,the volume is created when the Pod doesn't have it.
+	SharedVolumes map[string]string
+}
+
+// injectSidecar append the container of sidecar into the Pod,and mount the shared volumes on it and the other containers,
+// the containers set after it don't have the shared volumes,so call InjectSidecar() after SetContainer().
+func injectSidecar(podTemp *v1.PodTemplateSpec, sidecar Sidecar) error {
+	if errs := validation.IsDNS1123Label(sidecar.Name); len(errs) > 0 {
+		return fieldErrorf("InjectSidecar", "name %q is not allowed:%s", sidecar.Name, strings.Join(errs, ","))
+	}
+	if !verifyString(sidecar.Image) {
+		return fieldErrorf("InjectSidecar", "image of sidecar %s is not allowed to be empty", sidecar.Name)
+	}
+	if containerIndex(podTemp.Spec.Containers, sidecar.Name) >= 0 || containerIndex(podTemp.Spec.InitContainers, sidecar.Name) >= 0 {
+		return fieldErrorf("InjectSidecar", "container name %q is duplicated", sidecar.Name)
+	}
+	container := v1.Container{
+		Name:    sidecar.Name,
+		Image:   sidecar.Image,
+		Command: append([]string(nil), sidecar.Command...),
+		Args:    append([]string(nil), sidecar.Args...),
+		Ports:   append([]v1.ContainerPort(nil), sidecar.Ports...),
+	}
+	for _, port := range container.Ports {
+		if port.ContainerPort <= 0 || port.ContainerPort >= 65536 {
+			return fieldErrorf("InjectSidecar", "port %d of sidecar %s is not allowed,range: 0 < containerPort < 65536", port.ContainerPort, sidecar.Name)
+		}
+	}
+	if len(sidecar.Env) > 0 {
+		if err := mergeEnvs(&container, sidecar.Env); err != nil {
+			return fieldErrorf("InjectSidecar", "%v", err)
+		}
+	}
+	if len(sidecar.Limits) > 0 {
+		limits, err := ResourceMapsToK8s(sidecar.Limits)
+		if err != nil {
+			return fieldErrorf("InjectSidecar", "%v", err)
+		}
+		container.Resources.Limits = limits
+	}
+	for _, volumeName := range sortedKeys(sidecar.Mounts) {
+		mountPath := sidecar.Mounts[volumeName]
+		if !verifyString(volumeName) || !strings.HasPrefix(mountPath, "/") {
+			return fieldError("InjectSidecar", "volume name of Mounts is not allowed to be empty and mount path must be absolute")
+		}
+		container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{Name: volumeName, MountPath: mountPath})
+	}
+	for _, volumeName := range sortedKeys(sidecar.SharedVolumes) {
+		mountPath := sidecar.SharedVolumes[volumeName]
+		if errs := validation.IsDNS1123Label(volumeName); len(errs) > 0 || !strings.HasPrefix(mountPath, "/") {
+			return fieldErrorf("InjectSidecar", "shared volume %q is not allowed,the name must be DNS label and mount path must be absolute", volumeName)
+		}
+		if err := shareVolume(podTemp, volumeName, mountPath); err != nil {
+			return fieldErrorf("InjectSidecar", "%v", err)
+		}
+		container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{Name: volumeName, MountPath: mountPath})
+	}
+	podTemp.Spec.Containers = append(podTemp.Spec.Containers, container)
+	return nil
+}
+
+// shareVolume add the emptyDir volume when the Pod doesn't have it,and mount it on the containers which don't mount it
+func shareVolume(podTemp *v1.PodTemplateSpec, volumeName, mountPath string) error {
+	exists := false
+	for _, volume := range podTemp.Spec.Volumes {
+		if volume.Name == volumeName {
+			if volume.EmptyDir == nil {
+				return fmt.Errorf("volume %s is not emptyDir,it can't be shared", volumeName)
+			}
+			exists = true
+		}
+	}
+	if !exists {
+		podTemp.Spec.Volumes = append(podTemp.Spec.Volumes, v1.Volume{
+			Name:         volumeName,
+			VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+		})
+	}
+	for index := range podTemp.Spec.Containers {
+		container := &podTemp.Spec.Containers[index]
+		mounted := false
+		for _, mount := range container.VolumeMounts {
+			mounted = mounted || mount.Name == volumeName
+		}
+		if !mounted {
+			container.VolumeMounts = append(container.VolumeMounts, v1.VolumeMount{Name: volumeName, MountPath: mountPath})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+package beku
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Policy is admission-like policy which Simulate() runs locally,
+// Mutate is like mutating admission webhook and Validate is like validating admission webhook,
+// any of them can be nil.
+type Policy struct {
+	Name     string
+	Mutate   func(obj runtime.Object) error
+	Validate func(obj runtime.Object) error
+}
+
+var policyRegistry = struct {
+	sync.RWMutex
+	policies []Policy
+}{}
+
+// RegisterPolicy register admission-like policy on Beku, Simulate() runs it before the policies passed in,
+// eg: register the same checks as the webhooks of your cluster once at init.
+// the name is used in the error and by UnregisterPolicy().
+func RegisterPolicy(policy Policy) error {
+	if !verifyString(policy.Name) {
+		return fmt.Errorf("RegisterPolicy failed,name is not allowed to be empty")
+	}
+	if policy.Mutate == nil && policy.Validate == nil {
+		return fmt.Errorf("RegisterPolicy failed,policy %s has no Mutate and Validate", policy.Name)
+	}
+	policyRegistry.Lock()
+	defer policyRegistry.Unlock()
+	for _, registered := range policyRegistry.policies {
+		if registered.Name == policy.Name {
+			return fmt.Errorf("RegisterPolicy failed,policy %s is already registered", policy.Name)
+		}
+	}
+	policyRegistry.policies = append(policyRegistry.policies, policy)
+	return nil
+}
+
+// UnregisterPolicy remove the policy registered by RegisterPolicy(),it does nothing when name is not registered,
+// eg: the tests remove the policies they registered.
+func UnregisterPolicy(name string) {
+	policyRegistry.Lock()
+	defer policyRegistry.Unlock()
+	policies := policyRegistry.policies[:0:0]
+	for _, registered := range policyRegistry.policies {
+		if registered.Name != name {
+			policies = append(policies, registered)
+		}
+	}
+	policyRegistry.policies = policies
+}
+
+// Simulate approximate what apiServer does with the object before anything is sent to a cluster:
+// 1. set the default values as apiServer defaulting
+// 2. run Mutate of the registered policies and the policies passed in,in order
+// 3. validate names,labels,containers and ports as apiServer schema validation
+// 4. run Validate of the policies
+// it returns the mutated copy of obj,obj is not changed. all validation errors are returned together.
+func Simulate(obj runtime.Object, extra ...Policy) (runtime.Object, error) {
+	if obj == nil {
+		return nil, fmt.Errorf("Simulate err,obj is not allowed to be nil")
+	}
+	obj = obj.DeepCopyObject()
+	policyRegistry.RLock()
+	all := append(append([]Policy{}, policyRegistry.policies...), extra...)
+	policyRegistry.RUnlock()
+
+	setDefaults(obj)
+	for _, policy := range all {
+		if policy.Mutate == nil {
+			continue
+		}
+		if err := policy.Mutate(obj); err != nil {
+			return obj, fmt.Errorf("Simulate err,policy %s denied the object:%v", policy.Name, err)
+		}
+	}
+	// the mutated fields are defaulted again, apiServer decodes the patched object with defaulting too
+	setDefaults(obj)
+
+	if errs := validateObject(obj); len(errs) > 0 {
+		return obj, fmt.Errorf("Simulate err,%v", errs.ToAggregate())
+	}
+	var denied []string
+	for _, policy := range all {
+		if policy.Validate == nil {
+			continue
+		}
+		if err := policy.Validate(obj); err != nil {
+			denied = append(denied, fmt.Sprintf("policy %s denied the object:%v", policy.Name, err))
+		}
+	}
+	if len(denied) > 0 {
+		return obj, fmt.Errorf("Simulate err,%s", strings.Join(denied, "; "))
+	}
+	return obj, nil
+}
+
+// setDefaults set the common default values of apiServer,it is not all of the defaulting of apiServer.
+func setDefaults(obj runtime.Object) {
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		if o.Spec.Replicas == nil {
+			o.Spec.Replicas = int32Ptr(1)
+		}
+		if o.Spec.Strategy.Type == "" {
+			o.Spec.Strategy.Type = appsv1.RollingUpdateDeploymentStrategyType
+		}
+		if o.Spec.Strategy.Type == appsv1.RollingUpdateDeploymentStrategyType && o.Spec.Strategy.RollingUpdate == nil {
+			percent := intstr.FromString("25%")
+			o.Spec.Strategy.RollingUpdate = &appsv1.RollingUpdateDeployment{MaxSurge: &percent, MaxUnavailable: &percent}
+		}
+		if o.Spec.RevisionHistoryLimit == nil {
+			o.Spec.RevisionHistoryLimit = int32Ptr(10)
+		}
+		if o.Spec.ProgressDeadlineSeconds == nil {
+			o.Spec.ProgressDeadlineSeconds = int32Ptr(600)
+		}
+		setPodDefaults(&o.Spec.Template.Spec)
+	case *appsv1.StatefulSet:
+		if o.Spec.Replicas == nil {
+			o.Spec.Replicas = int32Ptr(1)
+		}
+		if o.Spec.PodManagementPolicy == "" {
+			o.Spec.PodManagementPolicy = appsv1.OrderedReadyPodManagement
+		}
+		if o.Spec.UpdateStrategy.Type == "" {
+			o.Spec.UpdateStrategy.Type = appsv1.RollingUpdateStatefulSetStrategyType
+		}
+		if o.Spec.RevisionHistoryLimit == nil {
+			o.Spec.RevisionHistoryLimit = int32Ptr(10)
+		}
+		setPodDefaults(&o.Spec.Template.Spec)
+	case *appsv1.DaemonSet:
+		if o.Spec.UpdateStrategy.Type == "" {
+			o.Spec.UpdateStrategy.Type = appsv1.RollingUpdateDaemonSetStrategyType
+		}
+		if o.Spec.RevisionHistoryLimit == nil {
+			o.Spec.RevisionHistoryLimit = int32Ptr(10)
+		}
+		setPodDefaults(&o.Spec.Template.Spec)
+	case *v1.Service:
+		if o.Spec.Type == "" {
+			o.Spec.Type = v1.ServiceTypeClusterIP
+		}
+		if o.Spec.SessionAffinity == "" {
+			o.Spec.SessionAffinity = v1.ServiceAffinityNone
+		}
+		for index := range o.Spec.Ports {
+			port := &o.Spec.Ports[index]
+			if port.Protocol == "" {
+				port.Protocol = v1.ProtocolTCP
+			}
+			if port.TargetPort.Type == intstr.Int && port.TargetPort.IntVal == 0 {
+				port.TargetPort = intstr.FromInt(int(port.Port))
+			}
+		}
+	case *v1.Secret:
+		if o.Type == "" {
+			o.Type = v1.SecretTypeOpaque
+		}
+	}
+}
+
+func setPodDefaults(pod *v1.PodSpec) {
+	if pod.RestartPolicy == "" {
+		pod.RestartPolicy = v1.RestartPolicyAlways
+	}
+	if pod.DNSPolicy == "" {
+		pod.DNSPolicy = v1.DNSClusterFirst
+	}
+	if pod.SchedulerName == "" {
+		pod.SchedulerName = v1.DefaultSchedulerName
+	}
+	if pod.TerminationGracePeriodSeconds == nil {
+		grace := int64(v1.DefaultTerminationGracePeriodSeconds)
+		pod.TerminationGracePeriodSeconds = &grace
+	}
+	for index := range pod.InitContainers {
+		setContainerDefaults(&pod.InitContainers[index])
+	}
+	for index := range pod.Containers {
+		setContainerDefaults(&pod.Containers[index])
+	}
+}
+
+func setContainerDefaults(container *v1.Container) {
+	if container.TerminationMessagePath == "" {
+		container.TerminationMessagePath = v1.TerminationMessagePathDefault
+	}
+	if container.TerminationMessagePolicy == "" {
+		container.TerminationMessagePolicy = v1.TerminationMessageReadFile
+	}
+	if container.ImagePullPolicy == "" {
+		container.ImagePullPolicy = v1.PullIfNotPresent
+		if isLatestImage(container.Image) {
+			container.ImagePullPolicy = v1.PullAlways
+		}
+	}
+	for portIndex := range container.Ports {
+		if container.Ports[portIndex].Protocol == "" {
+			container.Ports[portIndex].Protocol = v1.ProtocolTCP
+		}
+	}
+	for _, probe := range []*v1.Probe{container.LivenessProbe, container.ReadinessProbe, container.StartupProbe} {
+		if probe == nil {
+			continue
+		}
+		if probe.TimeoutSeconds == 0 {
+			probe.TimeoutSeconds = 1
+		}
+		if probe.PeriodSeconds == 0 {
+			probe.PeriodSeconds = 10
+		}
+		if probe.SuccessThreshold == 0 {
+			probe.SuccessThreshold = 1
+		}
+		if probe.FailureThreshold == 0 {
+			probe.FailureThreshold = 3
+		}
+		if probe.HTTPGet != nil && probe.HTTPGet.Scheme == "" {
+			probe.HTTPGet.Scheme = v1.URISchemeHTTP
+		}
+	}
+}
+
+// validateObject validate the names,labels,containers and ports like apiServer,
+// it is not all of the validation of apiServer.
+func validateObject(obj runtime.Object) field.ErrorList {
+	var errs field.ErrorList
+	metaPath := field.NewPath("metadata")
+	if accessor, ok := obj.(metav1.Object); ok {
+		for _, msg := range validation.IsDNS1123Subdomain(accessor.GetName()) {
+			errs = append(errs, field.Invalid(metaPath.Child("name"), accessor.GetName(), msg))
+		}
+		if ns := accessor.GetNamespace(); ns != "" {
+			for _, msg := range validation.IsDNS1123Label(ns) {
+				errs = append(errs, field.Invalid(metaPath.Child("namespace"), ns, msg))
+			}
+		}
+		errs = append(errs, metav1validation.ValidateLabels(accessor.GetLabels(), metaPath.Child("labels"))...)
+	}
+	specPath := field.NewPath("spec")
+	switch o := obj.(type) {
+	case *appsv1.Deployment:
+		errs = append(errs, validatePod(&o.Spec.Template, specPath.Child("template"))...)
+	case *appsv1.StatefulSet:
+		errs = append(errs, validatePod(&o.Spec.Template, specPath.Child("template"))...)
+	case *appsv1.DaemonSet:
+		errs = append(errs, validatePod(&o.Spec.Template, specPath.Child("template"))...)
+	case *v1.Service:
+		for index, port := range o.Spec.Ports {
+			for _, msg := range validation.IsValidPortNum(int(port.Port)) {
+				errs = append(errs, field.Invalid(specPath.Child("ports").Index(index).Child("port"), port.Port, msg))
+			}
+		}
+	}
+	return errs
+}
+
+func validatePod(temp *v1.PodTemplateSpec, path *field.Path) field.ErrorList {
+	errs := metav1validation.ValidateLabels(temp.Labels, path.Child("metadata", "labels"))
+	containersPath := path.Child("spec", "containers")
+	if len(temp.Spec.Containers) == 0 {
+		errs = append(errs, field.Required(containersPath, ""))
+	}
+	for index, container := range temp.Spec.Containers {
+		containerPath := containersPath.Index(index)
+		for _, msg := range validation.IsDNS1123Label(container.Name) {
+			errs = append(errs, field.Invalid(containerPath.Child("name"), container.Name, msg))
+		}
+		if strings.TrimSpace(container.Image) == "" {
+			errs = append(errs, field.Required(containerPath.Child("image"), ""))
+		}
+		for portIndex, port := range container.Ports {
+			for _, msg := range validation.IsValidPortNum(int(port.ContainerPort)) {
+				errs = append(errs, field.Invalid(containerPath.Child("ports").Index(portIndex).Child("containerPort"), port.ContainerPort, msg))
+			}
+			if port.Name != "" {
+				for _, msg := range validation.IsValidPortName(port.Name) {
+					errs = append(errs, field.Invalid(containerPath.Child("ports").Index(portIndex).Child("name"), port.Name, msg))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+func int32Ptr(i int32) *int32 { return &i }
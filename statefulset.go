@@ -1,42 +1,92 @@
 package beku
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
+	"fmt"
 
-	"github.com/ghodss/yaml"
 	"k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 // StatefulSet include kubernetes resource object StatefulSet(sts) and error
 type StatefulSet struct {
-	sts *v1.StatefulSet
-	err error
+	PodSetters[*StatefulSet]
+	sts       *v1.StatefulSet
+	err       error
+	verifiers []func(*v1.StatefulSet) error
 }
 
 // NewSts  create StatefulSet(sts) and chain function call begin with this function.
-func NewSts() *StatefulSet { return &StatefulSet{sts: &v1.StatefulSet{}} }
+func NewSts() *StatefulSet { return (&StatefulSet{sts: &v1.StatefulSet{}}).withPodSetters() }
+
+// StatefulSetFrom create StatefulSet from the existing StatefulSet,eg: the one got by client,
+// so it can be changed by the chain function and released again,
+// sts is deep copied and the fields which are not changed by the chain function are kept.
+func StatefulSetFrom(sts *v1.StatefulSet) *StatefulSet {
+	if sts == nil {
+		return NewSts()
+	}
+	return (&StatefulSet{sts: sts.DeepCopy()}).withPodSetters()
+}
 
 // Finish Chain function call end with this function
 // return Kubernetes resource object StatefulSet and error.
 // In the function, it will check necessary parameters、input the default field。
 func (obj *StatefulSet) Finish() (*v1.StatefulSet, error) {
-	obj.verify()
+	return obj.sts, appendError(obj.err, obj.verify())
+}
+
+// Validate check StatefulSet necessary value like Finish(),and return the problems as FieldError,
+// but StatefulSet is not changed, no Kind,APIVersion or default field is input,
+// so it can be used to check a partially built StatefulSet.
+func (obj *StatefulSet) Validate() []FieldError {
+	cp := (&StatefulSet{sts: obj.sts.DeepCopy(), verifiers: obj.verifiers, err: obj.err}).withPodSetters()
+	return FieldErrors(appendError(cp.err, cp.verify()))
+}
+
+// Clone deep copy StatefulSet with the pending error,the clone and StatefulSet don't share any field,eg: containers and volumes,
+// so the clones of one base builder can be specialized in parallel goroutines,eg: per-tenant StatefulSets.
+// the builder itself is not safe for concurrent use,clone it for every goroutine.
+func (obj *StatefulSet) Clone() *StatefulSet {
+	return (&StatefulSet{sts: obj.sts.DeepCopy(), err: obj.err, verifiers: append([]func(*v1.StatefulSet) error(nil), obj.verifiers...)}).withPodSetters()
+}
+
+// FinishObject same as Finish(), but return StatefulSet as runtime.Object,
+// so StatefulSet can be used as Builder, eg: add into Bundle.
+func (obj *StatefulSet) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// FinishFor same as Finish(), but output StatefulSet as the apiVersion selected by opts,eg: apps/v1beta2 for the old clusters,
+// WithAPIVersion() select it directly,WithClusterVersion() and WithClusterOf() select the newest one the cluster serves.
+func (obj *StatefulSet) FinishFor(opts ...FinishOption) (runtime.Object, error) {
+	sts, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	return finishFor(sts, opts)
+}
+
+// FinishUnchecked return StatefulSet without the checks and default fields of Finish(),only Kind and APIVersion are input,
+// it is for the partial StatefulSet which is patched onto the existing one,the error of the chain is still returned.
+func (obj *StatefulSet) FinishUnchecked() (*v1.StatefulSet, error) {
+	obj.sts.Kind, obj.sts.APIVersion = "StatefulSet", "apps/v1"
 	return obj.sts, obj.err
 }
 
 // JSONNew use json data create StatelfulSet
 func (obj *StatefulSet) JSONNew(jsonbyts []byte) *StatefulSet {
-	obj.error(json.Unmarshal(jsonbyts, obj.sts))
+	obj.error(decodeJSON(jsonbyts, obj.sts))
 	return obj
 }
 
 // YAMLNew use yaml data create StatefulSet
 func (obj *StatefulSet) YAMLNew(yamlbyts []byte) *StatefulSet {
-	obj.error(yaml.Unmarshal(yamlbyts, obj.sts))
+	obj.error(decodeYAML(yamlbyts, obj.sts))
 	return obj
 }
 
@@ -73,6 +123,76 @@ func (obj *StatefulSet) SetLabels(labels map[string]string) *StatefulSet {
 	return obj
 }
 
+// AddLabel add or overwrite one label of StatefulSet,the other labels are kept,
+// unlike SetLabels() which replaces all labels,eg: the labels loaded by YAMLNew().
+func (obj *StatefulSet) AddLabel(key, value string) *StatefulSet {
+	obj.error(addLabel(obj.sts, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of StatefulSet,the other annotations are kept
+func (obj *StatefulSet) AddAnnotation(key, value string) *StatefulSet {
+	obj.error(addAnnotation(obj.sts, key, value))
+	return obj
+}
+
+// MergeLabels add or overwrite the labels of StatefulSet,the other labels are kept,unlike SetLabels() which replaces all labels
+func (obj *StatefulSet) MergeLabels(labels map[string]string) *StatefulSet {
+	obj.error(mergeLabels(obj.sts, labels))
+	return obj
+}
+
+// MergeAnnotations add or overwrite the annotations of StatefulSet,the other annotations are kept
+func (obj *StatefulSet) MergeAnnotations(annotations map[string]string) *StatefulSet {
+	obj.error(mergeAnnotations(obj.sts, annotations))
+	return obj
+}
+
+// MergePodLabels add or overwrite the labels of the Pod template,the other labels and the selector are kept,
+// the labels of the selector are not allowed to be changed,otherwise the Pods are not selected any more.
+func (obj *StatefulSet) MergePodLabels(labels map[string]string) *StatefulSet {
+	obj.error(mergePodLabels(&obj.sts.Spec.Template, obj.sts.Spec.Selector, labels))
+	return obj
+}
+
+// SetAppKubernetesLabels set the recommended app.kubernetes.io labels on StatefulSet and the Pod template,eg: name mysql,
+// version 5.7.21,component database and part-of wordpress,the empty values except name are skipped,the selector is not changed.
+func (obj *StatefulSet) SetAppKubernetesLabels(name, version, component, partOf string) *StatefulSet {
+	labels, err := appKubernetesLabels(name, version, component, partOf)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.error(mergeLabels(obj.sts, labels))
+	obj.error(mergePodLabels(&obj.sts.Spec.Template, obj.sts.Spec.Selector, labels))
+	return obj
+}
+
+// SetPrometheusScrape set the prometheus.io annotations of the Pod template,so the Pods are scraped by
+// the Prometheus annotation-based discovery,port is the metrics port,path default /metrics.
+func (obj *StatefulSet) SetPrometheusScrape(port int32, path string) *StatefulSet {
+	annotations, err := prometheusScrapeAnnotations(port, path)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.error(mergeAnnotations(&obj.sts.Spec.Template, annotations))
+	return obj
+}
+
+// AnnotateConfigChecksum write the checksum of the data of the ConfigMaps and Secrets used by the Pods
+// as Pod template annotation checksum/config,so the Pods are restarted when the config is changed without renaming them.
+// call it after the data of the ConfigMaps and Secrets are set.
+func (obj *StatefulSet) AnnotateConfigChecksum(cms []*ConfigMap, secrets []*Secret) *StatefulSet {
+	checksum, err := configChecksum(cms, secrets)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.error(addAnnotation(&obj.sts.Spec.Template, ConfigChecksumAnnotation, checksum))
+	return obj
+}
+
 // SetReplicas set StatefulSet(sts) replicas default 1
 func (obj *StatefulSet) SetReplicas(replicas int32) *StatefulSet {
 	obj.sts.Spec.Replicas = &replicas
@@ -82,7 +202,7 @@ func (obj *StatefulSet) SetReplicas(replicas int32) *StatefulSet {
 // SetSelector set StatefulSet(sts) labels selector and set Pod Labels
 func (obj *StatefulSet) SetSelector(labels map[string]string) *StatefulSet {
 	if len(labels) <= 0 {
-		obj.error(errors.New("SetSelector err,label is not allowed to be empty"))
+		obj.error(fieldError("SetSelector", "label is not allowed to be empty"))
 		return obj
 	}
 	if obj.sts.Spec.Selector == nil {
@@ -90,11 +210,11 @@ func (obj *StatefulSet) SetSelector(labels map[string]string) *StatefulSet {
 			MatchLabels: labels,
 		}
 		obj.sts.Spec.Selector.MatchLabels = labels
-		obj.sts.Spec.Template.SetLabels(labels)
+		obj.sts.Spec.Template.SetLabels(copyLabels(labels))
 		return obj
 	}
 	obj.sts.Spec.Selector.MatchLabels = labels
-	obj.sts.Spec.Template.SetLabels(labels)
+	obj.sts.Spec.Template.SetLabels(copyLabels(labels))
 	return obj
 }
 
@@ -110,6 +230,55 @@ func (obj *StatefulSet) SetAnnotations(annotations map[string]string) *StatefulS
 	return obj
 }
 
+// SetChangeCause set the change-cause annotation of StatefulSet,
+// it is shown as CHANGE-CAUSE of the revision by `kubectl rollout history`,eg: "release v1.2: fix login".
+func (obj *StatefulSet) SetChangeCause(msg string) *StatefulSet {
+	if !verifyString(msg) {
+		obj.error(fieldError("SetChangeCause", "msg is not allowed to be empty"))
+		return obj
+	}
+	obj.sts.Annotations = setChangeCause(obj.sts.Annotations, msg)
+	return obj
+}
+
+// History get the rollout history of StatefulSet on Kubernetes in ascending order of revision,like `kubectl rollout history`,
+// client is the Kubernetes clientset,the registered one is used when it is nil.
+func (obj *StatefulSet) History(client kubernetes.Interface) ([]RolloutRevision, error) {
+	if !verifyString(obj.sts.GetName()) {
+		return nil, errors.New("StatefulSet History err,name is not allowed to be empty")
+	}
+	client, err := clientOrRegistered(client)
+	if err != nil {
+		return nil, err
+	}
+	return statefulSetHistory(client, obj.sts.GetNamespace(), obj.sts.GetName())
+}
+
+// MatchIn add a match expression on StatefulSet selector: the value of label key is one of values,
+// it can be called many times and all the expressions are ANDed.
+func (obj *StatefulSet) MatchIn(key string, values ...string) *StatefulSet {
+	obj.error(addMatchExpression(&obj.sts.Spec.Selector, key, LabelSelectorOpIn, values))
+	return obj
+}
+
+// MatchNotIn add a match expression on StatefulSet selector: the value of label key is not any of values
+func (obj *StatefulSet) MatchNotIn(key string, values ...string) *StatefulSet {
+	obj.error(addMatchExpression(&obj.sts.Spec.Selector, key, LabelSelectorOpNotIn, values))
+	return obj
+}
+
+// MatchExists add a match expression on StatefulSet selector: the label key exists
+func (obj *StatefulSet) MatchExists(key string) *StatefulSet {
+	obj.error(addMatchExpression(&obj.sts.Spec.Selector, key, LabelSelectorOpExists, nil))
+	return obj
+}
+
+// MatchDoesNotExist add a match expression on StatefulSet selector: the label key does not exist
+func (obj *StatefulSet) MatchDoesNotExist(key string) *StatefulSet {
+	obj.error(addMatchExpression(&obj.sts.Spec.Selector, key, LabelSelectorOpDoesNotExist, nil))
+	return obj
+}
+
 // GetPodLabel get Pod labels
 func (obj *StatefulSet) GetPodLabel() map[string]string { return obj.sts.Spec.Template.GetLabels() }
 
@@ -119,6 +288,22 @@ func (obj *StatefulSet) SetPodLabels(labels map[string]string) *StatefulSet {
 	return obj
 }
 
+// SetPodTemplate replace the Pod template of StatefulSet by PodTemplate which is built by NewPodTemplate(),
+// PodTemplate is copied,so it can be attached to many workloads,
+// the labels of PodTemplate are used as selector when the selector is not set.
+func (obj *StatefulSet) SetPodTemplate(tpl *PodTemplate) *StatefulSet {
+	template, err := podTemplateOf(tpl)
+	if err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.sts.Spec.Template = *template
+	if obj.sts.Spec.Selector == nil && len(template.GetLabels()) > 0 {
+		obj.SetSelector(template.GetLabels())
+	}
+	return obj
+}
+
 // SetContainer set StatefulSet(sts) container
 // name:name is container name ,default ""
 // image:image is image name ,must input image
@@ -134,49 +319,88 @@ func (obj *StatefulSet) SetResourceLimit(limits map[ResourceName]string) *Statef
 	return obj
 }
 
-// SetPodPriorityClass set StatefulSet Pod Priority
-// priorityClassName is Kubernetes resource object PriorityClass name
-// priorityClassName must already exists in kubernetes cluster
-func (obj *StatefulSet) SetPodPriorityClass(priorityClassName string) *StatefulSet {
-	obj.error(setPodPriorityClass(&obj.sts.Spec.Template, priorityClassName))
+// PatchAgainst finish StatefulSet and generate the strategic merge patch against the existing StatefulSet,eg: the one got by client,
+// the patch only changes the fields set by the chain function,the other fields of existing are kept,
+// so the fields managed by the other actors are not stomped,send it by Patch() with types.StrategicMergePatchType.
+func (obj *StatefulSet) PatchAgainst(existing *v1.StatefulSet) ([]byte, error) {
+	sts, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, errors.New("PatchAgainst err,existing StatefulSet is not allowed to be nil")
+	}
+	return patchAgainst(existing, sts)
+}
+
+// String the current StatefulSet as yaml, the pending error is written on the top as comment.
+// it doesn't check and input default field like Finish(), so the StatefulSet may be incomplete.
+func (obj *StatefulSet) String() string { return dump("StatefulSet", obj.sts, obj.err) }
+
+// Dump print the current StatefulSet and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *StatefulSet) Dump() *StatefulSet {
+	fmt.Println(obj.String())
 	return obj
 }
 
-func (obj *StatefulSet) error(err error) {
-	if obj.err != nil {
-		return
+// WithVerifier add the verifier which checks StatefulSet in Finish() and Validate() after the checks of beku,
+// it only affects this builder,so one call site can enforce extra invariants,eg: the image must be from the company registry.
+func (obj *StatefulSet) WithVerifier(verifier func(*v1.StatefulSet) error) *StatefulSet {
+	if verifier == nil {
+		obj.error(fieldError("WithVerifier", "verifier is not allowed to be nil"))
+		return obj
 	}
-	obj.err = err
+	obj.verifiers = append(obj.verifiers, verifier)
+	return obj
+}
+
+func (obj *StatefulSet) error(err error) {
+	obj.err = appendError(obj.err, err)
 }
 
+// withPodSetters bind the pod-level setters to StatefulSet
+func (obj *StatefulSet) withPodSetters() *StatefulSet {
+	obj.PodSetters = PodSetters[*StatefulSet]{builder: obj}
+	return obj
+}
+
+// podTemplate get Pod template to change it
+func (obj *StatefulSet) podTemplate() *corev1.PodTemplateSpec { return &obj.sts.Spec.Template }
+
 // SetResourceRequst set container of StatefulSet resource request,only CPU and MEMORY
 func (obj *StatefulSet) SetResourceRequst(requests map[ResourceName]string) *StatefulSet {
 	obj.error(setResourceRequests(&obj.sts.Spec.Template, requests))
 	return obj
 }
 
-// SetEnvs set Pod Environmental variable
-func (obj *StatefulSet) SetEnvs(envMap map[string]string) *StatefulSet {
-	obj.error(setEnvs(&obj.sts.Spec.Template, envMap))
-	return obj
+// Resize resize the container of the running Pods of StatefulSet on Kubernetes in place,
+// the container is restarted only when its resize policy of the changed resource is RestartContainer.
+// the Pod template is not changed, call SetResourceLimit() and Apply() to keep the resources in the next rollout.
+// client is the Kubernetes clientset,the registered one is used when it is nil,Kubernetes 1.33+ is required.
+func (obj *StatefulSet) Resize(ctx context.Context, client kubernetes.Interface, container string, newResources ResourceRequirements) error {
+	selector := obj.sts.Spec.Selector
+	if selector == nil {
+		selector = &metav1.LabelSelector{MatchLabels: obj.sts.Spec.Template.GetLabels()}
+	}
+	client, err := clientOrRegistered(client)
+	if err != nil {
+		return err
+	}
+	return resizePods(ctx, client, obj.sts.GetNamespace(), selector, container, newResources)
 }
 
-// SetPVClaim set StatefulSet PersistentVolumeClaimVolumeSource
-// params:
-// volumeName: this is Custom field,you can define VolumeSource name,will be used of the container MountPath,
-// claimName: this is PersistentVolumeClaim(PVC) name,the PVC and StatefulSet must on same namespace and exist.
-func (obj *StatefulSet) SetPVClaim(volumeName, claimName string) *StatefulSet {
-	obj.error(setPVClaim(&obj.sts.Spec.Template, volumeName, claimName))
+// SpreadAcrossZones spread the Pods evenly across topology.kubernetes.io/zone,
+// the number of Pods in any two zones differs by at most maxSkew,call it after SetPodLabels().
+func (obj *StatefulSet) SpreadAcrossZones(maxSkew int) *StatefulSet {
+	obj.error(setSpreadAcrossZones(&obj.sts.Spec.Template, maxSkew))
 	return obj
 }
 
-//SetPVCMounts mount PersistentVolumeClaim on container
-// params:
-// volumeName:the param is SetPVClaim() function volumeName,and when you call SetPVCMounts function you must call SetPVClaim function,and no order.
-// on the other hand SetPVCMounts() function only mount first Container,and On the Container you can volumeMount many PersistentVolumeClaim.
-// mountPath: runtime container dir eg:/var/lib/mysql
-func (obj *StatefulSet) SetPVCMounts(volumeName, mountPath string) *StatefulSet {
-	obj.error(setPVCMounts(&obj.sts.Spec.Template, volumeName, mountPath))
+// SetNodeName run the Pods on the node directly without the scheduler,eg: debug or node-pinned utility Pod,
+// the taints and resources of the node are not checked,so use node affinity in normal cases,empty name removes it.
+func (obj *StatefulSet) SetNodeName(name string) *StatefulSet {
+	obj.error(setNodeName(&obj.sts.Spec.Template, name))
 	return obj
 }
 
@@ -203,7 +427,7 @@ func (obj *StatefulSet) SetPVCTemp(pvcName, mountPath string, mode PersistentVol
 		ObjectMeta: metav1.ObjectMeta{Name: pvcName},
 		Spec: corev1.PersistentVolumeClaimSpec{
 			AccessModes: []corev1.PersistentVolumeAccessMode{mode.ToK8s()},
-			Resources:   corev1.ResourceRequirements{Requests: reqs},
+			Resources:   corev1.VolumeResourceRequirements{Requests: reqs},
 		},
 	}
 	if len(obj.sts.Spec.VolumeClaimTemplates) <= 0 {
@@ -215,75 +439,6 @@ func (obj *StatefulSet) SetPVCTemp(pvcName, mountPath string, mode PersistentVol
 
 }
 
-// SetHTTPLiveness set container liveness of http style
-// port: required
-// path: http request URL,eg: /api/v1/posts/1
-// initDelaySec: how long time after the first start of the program the probe is executed for the first time.(sec)
-// timeoutSec: http request timeout seconds,defaults to 1 second. Minimum value is 1.
-// periodSec: how often does the probe??defaults to 1 second. Minimum value is 1,Except for the first time?
-// headers: headers[0] is HTTP Header, do not fill if you do not need to set
-// on the other hand, only **first container** will be set livenessProbe
-func (obj *StatefulSet) SetHTTPLiveness(port int, path string, initDelaySec, timeoutSec, periodSec int32, headers ...map[string]string) *StatefulSet {
-	setLiveness(&obj.sts.Spec.Template, httpProbe(port, path, initDelaySec, timeoutSec, periodSec, headers...))
-	return obj
-}
-
-// SetCMDLiveness set container liveness of cmd style
-// cmd: execute liveness probe as commond line
-// timeoutSec: http request timeout seconds,defaults to 1 second. Minimum value is 1.
-// periodSec: how often does the probe??defaults to 1 second. Minimum value is 1,Except for the first time?
-// headers: headers[0] is HTTP Header, do not fill if you do not need to set
-// on the other hand, only **first container** will be set livenessProbe
-func (obj *StatefulSet) SetCMDLiveness(cmd []string, initDelaySec, timeoutSec, periodSec int32) *StatefulSet {
-	setLiveness(&obj.sts.Spec.Template, cmdProbe(cmd, initDelaySec, timeoutSec, periodSec))
-	return obj
-}
-
-// SetTCPLiveness set container liveness of tcp style
-// host: default is ""
-// port: required
-// timeoutSec: http request timeout seconds,defaults to 1 second. Minimum value is 1.
-// periodSec: how often does the probe??defaults to 1 second. Minimum value is 1,Except for the first time?
-// headers: headers[0] is HTTP Header, do not fill if you do not need to set
-// on the other hand, only **first container** will be set livenessProbe
-func (obj *StatefulSet) SetTCPLiveness(host string, port int, initDelaySec, timeoutSec, periodSec int32) *StatefulSet {
-	setLiveness(&obj.sts.Spec.Template, tcpProbe(host, port, initDelaySec, timeoutSec, periodSec))
-	return obj
-}
-
-// SetHTTPReadness set container readness
-// initDelaySec: how long time after the first start of the program the probe is executed for the first time.(sec)
-// timeoutSec: http request timeout seconds,defaults to 1 second. Minimum value is 1.
-// periodSec: how often does the probe??defaults to 1 second. Minimum value is 1,Except for the first time?
-// on the other hand, only **first container** will be set livenessProbe
-func (obj *StatefulSet) SetHTTPReadness(port int, path string, initDelaySec, timeoutSec, periodSec int32, headers ...map[string]string) *StatefulSet {
-	setReadness(&obj.sts.Spec.Template, httpProbe(port, path, initDelaySec, timeoutSec, periodSec, headers...))
-	return obj
-}
-
-// SetCMDReadness set container readness of cmd style
-// cmd: execute readness probe as commond line
-// timeoutSec: http request timeout seconds,defaults to 1 second. Minimum value is 1.
-// periodSec: how often does the probe? defaults to 1 second. Minimum value is 1,Except for the first time?
-// headers: headers[0] is HTTP Header, do not fill if you do not need to set
-// on the other hand, only **first container** will be set livenessProbe
-func (obj *StatefulSet) SetCMDReadness(cmd []string, initDelaySec, timeoutSec, periodSec int32) *StatefulSet {
-	setReadness(&obj.sts.Spec.Template, cmdProbe(cmd, initDelaySec, timeoutSec, periodSec))
-	return obj
-}
-
-// SetTCPReadness set container readness of tcp style
-// host: default is ""
-// port: required
-// timeoutSec: http request timeout seconds,defaults to 1 second. Minimum value is 1.
-// periodSec: how often does the probe? defaults to 1 second. Minimum value is 1,Except for the first time?
-// headers: headers[0] is HTTP Header, do not fill if you do not need to set
-// on the other hand, only **first container** will be set livenessProbe
-func (obj *StatefulSet) SetTCPReadness(host string, port int, initDelaySec, timeoutSec, periodSec int32) *StatefulSet {
-	setReadness(&obj.sts.Spec.Template, tcpProbe(host, port, initDelaySec, timeoutSec, periodSec))
-	return obj
-}
-
 // SetPodQos set pod  quality of service
 // qosClass: is quality of service,the value only 'Guaranteed','Burstable' and 'BestEffort'
 // autoSet: If your previous settings do not meet the requirements of PodQoS, we will automatically set
@@ -292,9 +447,14 @@ func (obj *StatefulSet) SetPodQos(qosClass string, autoSet ...bool) *StatefulSet
 	return obj
 }
 
-// SetImagePullSecrets set pod pull secret
-func (obj *StatefulSet) SetImagePullSecrets(secretName string) *StatefulSet {
-	setImagePullSecrets(&obj.sts.Spec.Template, secretName)
+// SetImagePullPolicy set the pull policy of all containers of StatefulSet,policy is Always,Never or IfNotPresent,
+// it is the same as ImagePullPolicy() but the policy is checked,default is IfNotPresent.
+func (obj *StatefulSet) SetImagePullPolicy(policy string) *StatefulSet {
+	if err := verifyPullPolicy("SetImagePullPolicy", policy); err != nil {
+		obj.error(err)
+		return obj
+	}
+	obj.error(addAnnotation(obj.sts, ImagePullPolicyKey, policy))
 	return obj
 }
 
@@ -313,11 +473,11 @@ func (obj *StatefulSet) Release() (*v1.StatefulSet, error) {
 	if err != nil {
 		return nil, err
 	}
-	client, err := GetKubeClient()
+	client, err := getKubeInterface()
 	if err != nil {
 		return nil, err
 	}
-	return client.AppsV1().StatefulSets(sts.GetNamespace()).Create(sts)
+	return client.AppsV1().StatefulSets(sts.GetNamespace()).Create(context.TODO(), sts, metav1.CreateOptions{})
 }
 
 // Apply  it will be updated when this resource object exists in K8s,
@@ -327,61 +487,156 @@ func (obj *StatefulSet) Apply() (*v1.StatefulSet, error) {
 	if err != nil {
 		return nil, err
 	}
-	client, err := GetKubeClient()
+	client, err := getKubeInterface()
 	if err != nil {
 		return nil, err
 	}
-	_, err = client.AppsV1().StatefulSets(sts.GetNamespace()).Get(sts.GetName(), metav1.GetOptions{})
+	existing, err := client.AppsV1().StatefulSets(sts.GetNamespace()).Get(context.TODO(), sts.GetName(), metav1.GetOptions{})
 	if err != nil {
-		return client.AppsV1().StatefulSets(sts.GetNamespace()).Create(sts)
+		return client.AppsV1().StatefulSets(sts.GetNamespace()).Create(context.TODO(), sts, metav1.CreateOptions{})
 	}
-	return client.AppsV1().StatefulSets(sts.GetNamespace()).Update(sts)
+	if err := verifySelectorUnchanged("StatefulSet", existing.Spec.Selector, sts.Spec.Selector); err != nil {
+		return nil, err
+	}
+	return client.AppsV1().StatefulSets(sts.GetNamespace()).Update(context.TODO(), sts, metav1.UpdateOptions{})
 }
 
-// verify check service necessary value, input the default field and input related data.
-func (obj *StatefulSet) verify() {
+// DryRunApply finish StatefulSet and apply it by server-side apply with dryRun=All,so it is checked by Kubernetes but not persisted,
+// client is the Client of Kubernetes,the registered cluster is used when it is nil.
+func (obj *StatefulSet) DryRunApply(ctx context.Context, client *Client) (*unstructured.Unstructured, error) {
+	sts, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		if client, err = NewClient(nil, nil); err != nil {
+			return nil, err
+		}
+	}
+	return client.DryRunApply(ctx, sts)
+}
+
+// ValidateAgainstCluster check StatefulSet by the schema,admission webhooks and quota of Kubernetes like DryRunApply(),
+// the rejected fields are returned as FieldError,so they can be split by FieldErrors().
+func (obj *StatefulSet) ValidateAgainstCluster(ctx context.Context, client *Client) error {
+	_, err := obj.DryRunApply(ctx, client)
+	return err
+}
+
+// ApplyRecreate apply StatefulSet like Apply(),but when its selector is changed,which Apply() rejects because it is immutable,
+// the existing one is deleted in foreground and StatefulSet is created after its Pods are deleted,so the Pods are unavailable meanwhile.
+// client is the Client of Kubernetes,the registered cluster is used when it is nil,the wait of deletion is controlled by ctx.
+func (obj *StatefulSet) ApplyRecreate(ctx context.Context, client *Client) (*v1.StatefulSet, error) {
+	sts, err := obj.Finish()
+	if err != nil {
+		return nil, err
+	}
+	client, err = clientOrDefault(client)
+	if err != nil {
+		return nil, err
+	}
+	if err := recreateOnSelectorChange(ctx, client, sts); err != nil {
+		return nil, err
+	}
+	result, err := applyRecreated(ctx, client, sts)
+	if err != nil {
+		return nil, err
+	}
+	return result.(*v1.StatefulSet), nil
+}
+
+// Watch watch StatefulSet on Kubernetes by its namespace and name,and call handler when it is added,updated or deleted,
+// client is the Kubernetes clientset,the registered one is used when it is nil,it blocks until ctx is done.
+func (obj *StatefulSet) Watch(ctx context.Context, client kubernetes.Interface, handler EventHandler) error {
 	if obj.err != nil {
-		return
+		return obj.err
 	}
+	return watchObjects(ctx, client, []runtime.Object{obj.sts}, handler)
+}
+
+// WatchPods watch the Pods selected by the selector of StatefulSet and call handler when they are added,updated or deleted,
+// eg: restart counting or log collection,the usage of client and ctx is the same as Watch().
+func (obj *StatefulSet) WatchPods(ctx context.Context, client kubernetes.Interface, handler EventHandler) error {
+	if obj.err != nil {
+		return obj.err
+	}
+	return watchPods(ctx, client, "StatefulSet", obj.sts.GetNamespace(), obj.sts.Spec.Selector, handler)
+}
+
+// Delete delete StatefulSet on Kubernetes by its name and namespace,
+// opts[0] sets the propagation policy and grace period,
+// eg: DeleteOptions{Propagation: PropagationOrphan} deletes StatefulSet and keeps its Pods.
+func (obj *StatefulSet) Delete(opts ...DeleteOptions) error {
+	if obj.err != nil {
+		return obj.err
+	}
+	options, err := newDeleteOptions("StatefulSet", obj.sts.GetName(), opts)
+	if err != nil {
+		return err
+	}
+	client, err := getKubeInterface()
+	if err != nil {
+		return err
+	}
+	return client.AppsV1().StatefulSets(obj.sts.GetNamespace()).Delete(context.TODO(), obj.sts.GetName(), *options)
+}
+
+// verify check service necessary value, input the default field and input related data.
+func (obj *StatefulSet) verify() error {
+	var errs error
 	if !verifyString(obj.sts.GetName()) {
-		obj.err = errors.New("StatefulSet.Name is not allowed to be empty")
-		return
+		errs = appendError(errs, fieldError("StatefulSet.Name", "is not allowed to be empty"))
 	}
 	if obj.sts.Spec.Selector == nil {
-		obj.err = errors.New("StatefulSet.Spec.Selector.MatchLabels is not allowed to be empty")
-		return
-	}
-	if len(obj.sts.Spec.Template.Spec.Containers) < 1 {
-		obj.err = errors.New("StatefulSet.Container is not allowed to be empty")
-		return
+		errs = appendError(errs, fieldError("StatefulSet.Spec.Selector.MatchLabels", "is not allowed to be empty"))
+	} else {
+		errs = appendError(errs, verifySelector("StatefulSet", obj.sts.Spec.Selector, obj.GetPodLabel()))
 	}
+	errs = appendError(errs, verifyPodSpec("StatefulSet.Spec.Template.Spec", obj.sts.Spec.Template.Spec, claimTemplateNames(obj.sts.Spec.VolumeClaimTemplates)...))
+	errs = appendError(errs, inField("StatefulSet.Spec.Template.Spec", verifyHostPorts("StatefulSet", obj.sts.Spec.Template.Spec)))
 	//check qos set,if err!=nil, check need auto set qos
 	presentQos, err := qosCheck(obj.sts.Annotations[qosKey], obj.sts.Spec.Template.Spec)
-	if err != nil {
-		if obj.sts.Annotations[autoQosKey] == "true" {
-			err := obj.autoSetQos(presentQos)
-			if err != nil {
-				obj.err = err
-				return
-			}
-		} else {
-			obj.err = err
-			return
-		}
+	if err != nil && obj.sts.Annotations[autoQosKey] == "true" {
+		err = obj.autoSetQos(presentQos)
 	}
+	errs = appendError(errs, inField("StatefulSet.Spec.Template.Spec", err))
+	if errs != nil {
+		return errs
+	}
+	rewritePodImages(&obj.sts.Spec.Template.Spec, func() *corev1.PodSpec { return &obj.sts.Spec.Template.Spec })
 	obj.sts.Kind = "StatefulSet"
 	obj.sts.APIVersion = "apps/v1"
-	if obj.sts.Annotations[ImagePullPolicyKey] == "" {
-		for index := range obj.sts.Spec.Template.Spec.Containers {
-			obj.sts.Spec.Template.Spec.Containers[index].ImagePullPolicy = corev1.PullIfNotPresent
-		}
-		return
-	}
-	policy := PullPolicy(obj.sts.Annotations[ImagePullPolicyKey]).ToK8s()
 	for index := range obj.sts.Spec.Template.Spec.Containers {
-		obj.sts.Spec.Template.Spec.Containers[index].ImagePullPolicy = policy
+		container := &obj.sts.Spec.Template.Spec.Containers[index]
+		container.ImagePullPolicy = containerPullPolicy(obj.sts.Annotations, container.ImagePullPolicy)
 	}
 	delete(obj.sts.Annotations, ImagePullPolicyKey)
+	// the verifiers check the finished object,so they are run after all checks and default fields
+	return obj.runVerifiers()
+}
+
+// runVerifiers run the verifiers added by WithVerifier() in order when StatefulSet is valid,
+// then the verifiers registered by RegisterVerifier().
+func (obj *StatefulSet) runVerifiers() error {
+	var errs error
+	for _, verifier := range obj.verifiers {
+		if err := verifier(obj.sts); err != nil {
+			errs = appendError(errs, fmt.Errorf("StatefulSet %s verifier failed:%v", obj.sts.GetName(), err))
+		}
+	}
+	if err := runRegisteredVerifiers(obj.sts); err != nil {
+		errs = appendError(errs, fmt.Errorf("StatefulSet %s %v", obj.sts.GetName(), err))
+	}
+	return errs
+}
+
+// claimTemplateNames get names of volumeClaimTemplates
+func claimTemplateNames(temps []corev1.PersistentVolumeClaim) []string {
+	names := make([]string, 0, len(temps))
+	for _, temp := range temps {
+		names = append(names, temp.GetName())
+	}
+	return names
 }
 
 // autoSetQos auto set Pod of StatefulSet QOS
@@ -0,0 +1,77 @@
+package beku
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// StreamManifests read yaml or json documents from r one at a time, create the builder of every document
+// and call fn with it, so very large manifest files can be handled with bounded memory.
+// documents are separated by '---', empty documents are skipped.
+// the supported kinds are listed by Kinds().
+// it stops at the first error returned by decoding or fn,eg: the document is larger than MaxDocumentSize,
+// which is checked while reading,so the large document isn't buffered before it is refused.
+func StreamManifests(r io.Reader, fn func(Builder) error) error {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(&documentLimitReader{r: r}))
+	for index := 0; ; index++ {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("StreamManifests err,read document[%d] failed:%v", index, err)
+		}
+		if len(bytes.TrimSpace(doc)) <= 0 {
+			continue
+		}
+		builder, err := NewBuilder(doc)
+		if err != nil {
+			return fmt.Errorf("StreamManifests err,document[%d]:%v", index, err)
+		}
+		if builder == nil {
+			continue
+		}
+		if err := fn(builder); err != nil {
+			return err
+		}
+	}
+}
+
+// documentLimitReader fail when the document being read is larger than MaxDocumentSize,
+// the documents are split by the lines beginning with '---' like the yaml reader.
+type documentLimitReader struct {
+	r io.Reader
+	// size is the bytes of the current document
+	size int
+	// column is the bytes read from the current line
+	column int
+	// separator is true when the current line begins with '-' so far
+	separator bool
+}
+
+func (l *documentLimitReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	for _, b := range p[:n] {
+		if b == '\n' {
+			l.column = 0
+		} else {
+			if l.column < len("---") {
+				l.separator = b == '-' && (l.column == 0 || l.separator)
+			}
+			l.column++
+			if l.column == len("---") && l.separator {
+				// the next document begins
+				l.size = 0
+			}
+		}
+		l.size++
+		if l.size > MaxDocumentSize {
+			return 0, fmt.Errorf("document size exceeds the limit %d bytes", MaxDocumentSize)
+		}
+	}
+	return n, err
+}
@@ -1,11 +1,10 @@
 package beku
 
 import (
-	"encoding/json"
-	"errors"
+	"fmt"
 
-	"github.com/ghodss/yaml"
 	"k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // StorageClass include Kubernetes resource object StorageClass and error.
@@ -17,23 +16,52 @@ type StorageClass struct {
 // NewStorageClass create StorageClass and chain function call begin with this function.
 func NewStorageClass() *StorageClass { return &StorageClass{sc: &v1.StorageClass{}} }
 
+// StorageClassFrom create StorageClass from the existing StorageClass,eg: the one got by client,
+// so it can be changed by the chain function and released again,
+// sc is deep copied and the fields which are not changed by the chain function are kept.
+func StorageClassFrom(sc *v1.StorageClass) *StorageClass {
+	if sc == nil {
+		return NewStorageClass()
+	}
+	return &StorageClass{sc: sc.DeepCopy()}
+}
+
 // Finish chain function call end with this function
 // return Kubernetes resource object StorageClass and error.
 // In the function, it will check necessary parameters,input the default field.
 func (obj *StorageClass) Finish() (*v1.StorageClass, error) {
-	obj.verify()
+	err := appendError(obj.err, obj.verify())
+	return obj.sc, err
+}
+
+// Validate check StorageClass necessary value like Finish(),and return the problems as FieldError,
+// but StorageClass is not changed, no Kind,APIVersion or default field is input,
+// so it can be used to check a partially built StorageClass.
+func (obj *StorageClass) Validate() []FieldError {
+	cp := &StorageClass{sc: obj.sc.DeepCopy(), err: obj.err}
+	return FieldErrors(appendError(cp.err, cp.verify()))
+}
+
+// FinishObject same as Finish(), but return StorageClass as runtime.Object,
+// so StorageClass can be used as Builder, eg: add into Bundle.
+func (obj *StorageClass) FinishObject() (runtime.Object, error) { return obj.Finish() }
+
+// FinishUnchecked return StorageClass without the checks and default fields of Finish(),only Kind and APIVersion are input,
+// it is for the partial StorageClass which is patched onto the existing one,the error of the chain is still returned.
+func (obj *StorageClass) FinishUnchecked() (*v1.StorageClass, error) {
+	obj.sc.Kind, obj.sc.APIVersion = "StorageClass", "storage.k8s.io/v1"
 	return obj.sc, obj.err
 }
 
 // JSONNew use json data create StorageClass
 func (obj *StorageClass) JSONNew(jsonbyte []byte) *StorageClass {
-	obj.error(json.Unmarshal(jsonbyte, obj.sc))
+	obj.error(decodeJSON(jsonbyte, obj.sc))
 	return obj
 }
 
 // YAMLNew use yaml data create StorageClass
 func (obj *StorageClass) YAMLNew(yamlbyts []byte) *StorageClass {
-	obj.error(yaml.Unmarshal(yamlbyts, obj.sc))
+	obj.error(decodeYAML(yamlbyts, obj.sc))
 	return obj
 }
 
@@ -83,21 +111,38 @@ func (obj *StorageClass) SetLabels(labels map[string]string) *StorageClass {
 	return obj
 }
 
-func (obj *StorageClass) verify() {
-	if obj.err != nil {
-		return
-	}
+// AddLabel add or overwrite one label of StorageClass,the other labels are kept,
+// unlike SetLabels() which replaces all labels,eg: the labels loaded by YAMLNew().
+func (obj *StorageClass) AddLabel(key, value string) *StorageClass {
+	obj.error(addLabel(obj.sc, key, value))
+	return obj
+}
+
+// AddAnnotation add or overwrite one annotation of StorageClass,the other annotations are kept
+func (obj *StorageClass) AddAnnotation(key, value string) *StorageClass {
+	obj.error(addAnnotation(obj.sc, key, value))
+	return obj
+}
+
+// String the current StorageClass as yaml, the pending error is written on the top as comment.
+// it doesn't check and input default field like Finish(), so the StorageClass may be incomplete.
+func (obj *StorageClass) String() string { return dump("StorageClass", obj.sc, obj.err) }
+
+// Dump print the current StorageClass and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (obj *StorageClass) Dump() *StorageClass {
+	fmt.Println(obj.String())
+	return obj
+}
+
+func (obj *StorageClass) verify() error {
 	if obj.sc.Provisioner == "" {
-		obj.err = errors.New("StorageClass.spec.Provisioner is not allowed to be empty")
-		return
+		return fieldError("StorageClass.spec.Provisioner", "is not allowed to be empty")
 	}
 	obj.sc.APIVersion = "storage.k8s.io/v1"
 	obj.sc.Kind = "StorageClass"
-	return
+	return nil
 }
 func (obj *StorageClass) error(err error) {
-	if obj.err != nil {
-		return
-	}
-	obj.err = err
+	obj.err = appendError(obj.err, err)
 }
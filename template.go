@@ -0,0 +1,35 @@
+package beku
+
+import (
+	"k8s.io/api/apps/v1"
+)
+
+// Template is a frozen Deployment which is fully configured,
+// it is used to create many Deployments which only differ in name and namespace,eg: per-tenant Deployments.
+// Instantiate() doesn't deep copy the spec, the spec is shared until the instance changes it or Finish() is called.
+type Template struct {
+	dp *v1.Deployment
+}
+
+// NewTemplate freeze the Deployment builder into Template,
+// it returns error when Finish() of the builder is failed.
+// the builder can still be changed after NewTemplate(), the Template is not affected.
+func NewTemplate(builder *Deployment) (*Template, error) {
+	dp, err := builder.Finish()
+	if err != nil {
+		return nil, err
+	}
+	return &Template{dp: dp.DeepCopy()}, nil
+}
+
+// Instantiate create Deployment builder with name and namespace from Template,
+// the spec is shared with Template and is copied before the first change of the builder or Finish(),
+// so the Deployments of the instances never share slices with Template or with each other.
+func (t *Template) Instantiate(name, namespace string) *Deployment {
+	dp := &v1.Deployment{
+		TypeMeta: t.dp.TypeMeta,
+		Spec:     t.dp.Spec,
+	}
+	t.dp.ObjectMeta.DeepCopyInto(&dp.ObjectMeta)
+	return (&Deployment{dp: dp, shared: true}).withPodSetters().SetNamespaceAndName(namespace, name)
+}
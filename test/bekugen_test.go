@@ -0,0 +1,111 @@
+package test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	"github.com/yulibaozi/beku/bekugen"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func Test_GenerateCode(t *testing.T) {
+	code, err := bekugen.GenerateCode([]byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx
+  namespace: web
+spec:
+  replicas: 2
+  selector:
+    matchLabels: {app: nginx}
+  template:
+    metadata:
+      labels: {app: nginx}
+    spec:
+      nodeSelector: {disk: ssd}
+      containers:
+      - name: nginx
+        image: nginx:1.15
+        ports: [{containerPort: 80}]
+        env: [{name: TZ, value: UTC}]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`SetNamespaceAndName("web", "nginx")`,
+		`SetSelector(map[string]string{"app": "nginx"})`,
+		`SetReplicas(2)`,
+		`SetContainer("nginx", "nginx:1.15", 80)`,
+		`SetEnvs(map[string]string{"TZ": "UTC"})`,
+		`disk: ssd`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code should contain %s:\n%s", want, code)
+		}
+	}
+}
+
+func Test_GenerateFile(t *testing.T) {
+	file, err := bekugen.GenerateFile("manifests", []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+  namespace: web
+data: {mode: prod}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(file, "// generated from the manifests by bekugen\n\npackage manifests\n") {
+		t.Fatalf("unexpected header of the generated file:\n%s", file)
+	}
+	if strings.Contains(file, "DO NOT EDIT") {
+		t.Fatalf("the generated file is the starting point of the code,it should not be marked as DO NOT EDIT:\n%s", file)
+	}
+}
+
+func Test_GenerateCodeFromCluster(t *testing.T) {
+	dp, err := beku.NewDeployment().SetNamespaceAndName("web", "nginx").SetSelector(map[string]string{"app": "nginx"}).
+		SetContainer("nginx", "nginx:1.15", 80).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the fields populated by apiServer and controllers
+	dp.UID, dp.ResourceVersion, dp.Generation = "9f1c", "42", 3
+	dp.Annotations = map[string]string{"deployment.kubernetes.io/revision": "3"}
+	dp.Status.ReadyReplicas = 1
+	cs := fake.NewSimpleClientset(dp)
+	gvk := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	code, err := bekugen.GenerateCodeFromCluster(context.Background(), cs, gvk, "web", "nginx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(code, `SetContainer("nginx", "nginx:1.15", 80)`) {
+		t.Fatalf("generated code should contain the container:\n%s", code)
+	}
+	for _, server := range []string{"9f1c", "deployment.kubernetes.io/revision", "ReadyReplicas"} {
+		if strings.Contains(code, server) {
+			t.Errorf("generated code should not contain %s populated by the server:\n%s", server, code)
+		}
+	}
+	if _, err := bekugen.GenerateCodeFromCluster(context.Background(), cs, gvk, "web", "missing"); !apierrors.IsNotFound(err) {
+		t.Fatalf("expect NotFound,got %v", err)
+	}
+	cs.PrependReactor("get", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: "apps", Resource: "deployments"}, "nginx", nil)
+	})
+	if _, err := bekugen.GenerateCodeFromCluster(context.Background(), cs, gvk, "web", "nginx"); !apierrors.IsForbidden(err) {
+		t.Fatalf("expect Forbidden,got %v", err)
+	}
+	_, err = bekugen.GenerateCodeFromCluster(context.Background(), cs, schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, "web", "nginx")
+	if err == nil || !strings.Contains(err.Error(), "not supported") {
+		t.Fatalf("Pod is not supported,got %v", err)
+	}
+}
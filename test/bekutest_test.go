@@ -0,0 +1,23 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	"github.com/yulibaozi/beku/bekutest"
+)
+
+func Test_ApplyWithFakeClient(t *testing.T) {
+	cs := bekutest.NewFakeClient(t)
+	obj := bekutest.ApplyAndGet(t, cs, beku.NewDeployment().SetNamespaceAndName("roc", "http").
+		SetPodLabels(map[string]string{"app": "http"}).SetContainer("http", "nginx", 80).
+		SetHTTPLiveness(80, "/healthz", 10, 1, 5))
+	bekutest.AssertHasProbe(t, obj, bekutest.LivenessProbe)
+	bekutest.AssertSelectorMatchesLabels(t, obj)
+}
+
+func Test_MatchGolden(t *testing.T) {
+	bekutest.MatchGolden(t, beku.NewSvc().SetNamespaceAndName("roc", "nginx").
+		SetSelector(map[string]string{"app": "nginx"}).
+		SetPort(beku.ServicePort{Port: 80, TargetPort: 80}), "testdata/nginx-svc.yaml")
+}
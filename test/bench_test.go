@@ -0,0 +1,76 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+func benchDeployment() *beku.Deployment {
+	return beku.NewDeployment().SetNamespaceAndName("roc", "http").
+		SetPodLabels(map[string]string{"app": "http"}).SetContainer("http", "nginx", 80).
+		SetEnvs(map[string]string{"ENV": "prod", "LOG_LEVEL": "info"}).SetHTTPLiveness(80, "/healthz", 10, 1, 5)
+}
+
+func Benchmark_DeploymentFinish(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := benchDeployment().Finish(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Benchmark_ToYAML(b *testing.B) {
+	dp, err := benchDeployment().Finish()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := beku.ToYAML(dp); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Benchmark_ToJSON(b *testing.B) {
+	dp, err := benchDeployment().Finish()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := beku.ToJSON(dp); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Benchmark_DeploymentPool(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		dp := beku.AcquireDeployment()
+		if _, err := dp.SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+			SetContainer("http", "nginx", 80).Finish(); err != nil {
+			b.Fatal(err)
+		}
+		beku.ReleaseDeployment(dp)
+	}
+}
+
+func Benchmark_TemplateFinish(b *testing.B) {
+	tmpl, err := beku.NewTemplate(benchDeployment())
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := tmpl.Instantiate("http", "roc").Finish(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func Test_BundleFinishAll(t *testing.T) {
+	bundle := beku.NewBundle().AddBuilders(
+		beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).SetContainer("http", "nginx", 80),
+		beku.NewSvc().SetNamespaceAndName("roc", "http").SetSelector(map[string]string{"app": "http"}).SetPort(beku.ServicePort{Port: 80}),
+		beku.NewCM().SetNamespaceAndName("roc", "http").SetData(map[string]string{"key": "value"}),
+	)
+	if err := bundle.FinishAll(context.Background(), 2); err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Len() != 3 {
+		t.Fatalf("expect 3 objects, got %d", bundle.Len())
+	}
+	var buf bytes.Buffer
+	if err := bundle.Summary(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "80/TCP") {
+		t.Fatalf("summary should include service ports:\n%s", buf.String())
+	}
+	err := beku.NewBundle().AddBuilders(beku.NewDeployment(), beku.NewSvc()).FinishAll(context.Background(), 0)
+	if err == nil || !strings.Contains(err.Error(), "builder[1]") {
+		t.Fatalf("expect aggregated errors, got %v", err)
+	}
+}
+
+func Test_BundleSummary(t *testing.T) {
+	replicas := int32(3)
+	dp, _ := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).SetContainer("http", "nginx", 80).
+		SetReplicas(replicas).Finish()
+	svc, _ := beku.NewSvc().SetNamespaceAndName("roc", "http").SetSelector(map[string]string{"app": "http"}).
+		SetServiceType(beku.ServiceTypeNodePort).SetPort(beku.ServicePort{Port: 80, NodePort: 30080}).Finish()
+	ns, _ := beku.NewNs().SetName("roc").Finish()
+	for _, c := range []struct {
+		name   string
+		bundle *beku.Bundle
+		lines  []string
+		err    bool
+	}{
+		{"normal", beku.NewBundle().Add(dp, svc, ns), []string{
+			"KIND         NAMESPACE   NAME   REPLICAS/PORTS",
+			"Deployment   roc         http   3",
+			"Service      roc         http   80:30080/TCP",
+			"Namespace    -           roc    -",
+		}, false},
+		{"empty", beku.NewBundle(), []string{"KIND   NAMESPACE   NAME   REPLICAS/PORTS"}, false},
+		{"errors", beku.NewBundle().Add(dp).SetRegistryRewrite("", "mirror.local"), nil, true},
+	} {
+		var buf bytes.Buffer
+		err := c.bundle.Summary(&buf)
+		if (err != nil) != c.err {
+			t.Errorf("%s: expect error %v,got %v", c.name, c.err, err)
+			continue
+		}
+		var lines []string
+		if buf.Len() > 0 {
+			lines = strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		}
+		if strings.Join(lines, "\n") != strings.Join(c.lines, "\n") {
+			t.Errorf("%s: unexpected summary:\n%s", c.name, buf.String())
+		}
+	}
+}
+
+func Test_BundleStages(t *testing.T) {
+	ns, _ := beku.NewNs().SetName("roc").Finish()
+	cm, _ := beku.NewCM().SetNamespaceAndName("roc", "http").SetData(map[string]string{"key": "value"}).Finish()
+	dp, _ := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).SetContainer("http", "nginx", 80).Finish()
+	svc, _ := beku.NewSvc().SetNamespaceAndName("roc", "http").SetSelector(map[string]string{"app": "http"}).SetPort(beku.ServicePort{Port: 80}).Finish()
+	stages, err := beku.NewBundle().Add(dp).After(cm).Add(svc, cm).Add(ns).Stages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ns -> svc,cm -> dp
+	if len(stages) != 3 || stages[0][0] != ns || len(stages[1]) != 2 || stages[2][0] != dp {
+		t.Fatalf("unexpected stages:%v", stages)
+	}
+	_, err = beku.NewBundle().Add(dp).After(cm).Add(cm).After(dp).Stages()
+	if err == nil {
+		t.Fatal("dependencies are cyclic, Stages should return error")
+	}
+}
+
+func Test_BundleApplyAtomicRollback(t *testing.T) {
+	client, dynamic := fakeClient(t)
+	dynamic.PrependReactor("create", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("admission webhook denied the request")
+	})
+	cm, _ := beku.NewCM().SetNamespaceAndName("roc", "http").SetData(map[string]string{"key": "value"}).Finish()
+	dp, _ := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).SetContainer("http", "nginx", 80).Finish()
+	err := beku.NewBundle().Add(cm, dp).ApplyAtomic(context.Background(), client)
+	if err == nil {
+		t.Fatal("Deployment is denied, ApplyAtomic should return error")
+	}
+	if _, err := client.Get(context.TODO(), cm); !apierrors.IsNotFound(err) {
+		t.Fatalf("the created ConfigMap should be deleted by rollback,got %v", err)
+	}
+}
+
+// applyRecorder record the server-side apply patches,the fake dynamic client drops the options
+type applyRecorder struct {
+	dynamic.Interface
+	patches *[]appliedPatch
+}
+
+type appliedPatch struct {
+	patchType types.PatchType
+	data      []byte
+	options   metav1.PatchOptions
+}
+
+func (r applyRecorder) Resource(resource schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return applyResource{NamespaceableResourceInterface: r.Interface.Resource(resource), patches: r.patches}
+}
+
+type applyResource struct {
+	dynamic.NamespaceableResourceInterface
+	patches *[]appliedPatch
+}
+
+func (r applyResource) Namespace(namespace string) dynamic.ResourceInterface {
+	return applyNamespaced{ResourceInterface: r.NamespaceableResourceInterface.Namespace(namespace), patches: r.patches}
+}
+
+type applyNamespaced struct {
+	dynamic.ResourceInterface
+	patches *[]appliedPatch
+}
+
+func (r applyNamespaced) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	*r.patches = append(*r.patches, appliedPatch{patchType: pt, data: data, options: opts})
+	return r.ResourceInterface.Patch(ctx, name, pt, data, opts, subresources...)
+}
+
+func Test_BundleApplyAtomicRestore(t *testing.T) {
+	existing := &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "roc", Name: "http", ResourceVersion: "7"}, Data: map[string]string{"key": "old"}}
+	fakeDynamic := dynamicfake.NewSimpleDynamicClient(scheme.Scheme, existing)
+	var patches []appliedPatch
+	client, err := beku.NewClient(fakeDiscovery(), applyRecorder{Interface: fakeDynamic, patches: &patches})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fakeDynamic.PrependReactor("create", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("admission webhook denied the request")
+	})
+	// the tracker of the fake dynamic client can not apply onto unstructured objects,the applied object replaces the stored one
+	fakeDynamic.PrependReactor("patch", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch := action.(k8stesting.PatchAction)
+		obj := &unstructured.Unstructured{}
+		if err := json.Unmarshal(patch.GetPatch(), &obj.Object); err != nil {
+			return true, nil, err
+		}
+		return true, obj, fakeDynamic.Tracker().Update(patch.GetResource(), obj, patch.GetNamespace())
+	})
+	cm, _ := beku.NewCM().SetNamespaceAndName("roc", "http").SetData(map[string]string{"key": "new"}).Finish()
+	dp, _ := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).SetContainer("http", "nginx", 80).Finish()
+	if err := beku.NewBundle().Add(cm, dp).ApplyAtomic(context.Background(), client); err == nil {
+		t.Fatal("Deployment is denied, ApplyAtomic should return error")
+	}
+	// the ConfigMap is applied and then restored,both by server-side apply of the field manager
+	if len(patches) != 2 {
+		t.Fatalf("expect 2 patches of apply and restore,got %d", len(patches))
+	}
+	for _, patch := range patches {
+		if patch.patchType != types.ApplyPatchType || patch.options.FieldManager != beku.DefaultFieldManager {
+			t.Fatalf("expect server-side apply of %s,got %s %s", beku.DefaultFieldManager, patch.patchType, patch.options.FieldManager)
+		}
+	}
+	restore := &unstructured.Unstructured{}
+	if err := json.Unmarshal(patches[1].data, &restore.Object); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := restore.Object["status"]; ok || restore.GetResourceVersion() != "" || restore.GetManagedFields() != nil {
+		t.Fatalf("status,managedFields and resourceVersion of the pre-image should be removed,got %s", patches[1].data)
+	}
+	if force := patches[1].options.Force; force == nil || !*force {
+		t.Fatal("the pre-image should be restored with force")
+	}
+	got, err := client.Get(context.TODO(), cm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _, _ := unstructured.NestedStringMap(got.Object, "data"); data["key"] != "old" {
+		t.Fatalf("the ConfigMap should be restored to the pre-image,got %v", data)
+	}
+}
+
+func Test_BundleExport(t *testing.T) {
+	cm, _ := beku.NewCM().SetNamespaceAndName("roc", "http").SetData(map[string]string{"key": "value"}).Finish()
+	svc, _ := beku.NewSvc().SetNamespaceAndName("roc", "http").SetSelector(map[string]string{"app": "http"}).SetPort(beku.ServicePort{Port: 80}).Finish()
+	bundle := beku.NewBundle().Add(cm).Add(svc)
+	yamlbyts, err := bundle.ToYAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs := strings.Split(string(yamlbyts), "---\n"); len(docs) != 2 || !strings.Contains(docs[1], "kind: Service") {
+		t.Fatalf("expect 2 yaml documents, got:\n%s", yamlbyts)
+	}
+	jsonbyts, err := bundle.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(jsonbyts), `"kind": "List"`) || !strings.Contains(string(jsonbyts), `"kind": "ConfigMap"`) {
+		t.Fatalf("expect v1 List of the objects, got:\n%s", jsonbyts)
+	}
+	if _, err := beku.NewBundle().AddBuilders(beku.NewCM()).ToYAML(); err == nil {
+		t.Fatal("unfinished builders should fail")
+	}
+}
+
+func Test_BundleWriteYAMLTo(t *testing.T) {
+	bundle := beku.NewBundle()
+	for _, tenant := range []string{"alpha", "beta", "gamma"} {
+		bundle.AddBuilders(
+			beku.NewDeployment().SetNamespaceAndName(tenant, "web").SetPodLabels(map[string]string{"app": "web"}).SetContainer("web", "nginx:1.25", 80),
+			beku.NewSvc().SetNamespaceAndName(tenant, "web").SetSelector(map[string]string{"app": "web"}).SetPort(beku.ServicePort{Port: 80}),
+		)
+	}
+	var buf bytes.Buffer
+	if err := bundle.WriteYAMLTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if docs := strings.Count(buf.String(), "---\n"); docs != 5 {
+		t.Fatalf("expect 6 documents separated by 5 '---',got %d", docs)
+	}
+	if !strings.Contains(buf.String(), "namespace: gamma") {
+		t.Fatalf("the builders should be finished and written,got:\n%s", buf.String())
+	}
+	if err := beku.NewBundle().AddBuilders(beku.NewCM()).WriteYAMLTo(&buf); err == nil {
+		t.Fatal("the builder failed to finish should return error")
+	}
+}
+
+// failWriter fail the writes after the first one
+type failWriter struct {
+	writes int
+}
+
+func (w *failWriter) Write(p []byte) (int, error) {
+	w.writes++
+	if w.writes > 1 {
+		return 0, errors.New("disk is full")
+	}
+	return len(p), nil
+}
+
+func Test_BundleWriteYAMLToFailedWriter(t *testing.T) {
+	bundle := beku.NewBundle().AddBuilders(
+		beku.NewDeployment().SetNamespaceAndName("roc", "web").SetPodLabels(map[string]string{"app": "web"}).SetContainer("web", "nginx:1.25", 80),
+		beku.NewSvc().SetNamespaceAndName("roc", "web").SetSelector(map[string]string{"app": "web"}).SetPort(beku.ServicePort{Port: 80}),
+		beku.NewCM().SetNamespaceAndName("roc", "web").SetData(map[string]string{"key": "value"}),
+	)
+	if err := bundle.WriteYAMLTo(&failWriter{}); err == nil {
+		t.Fatal("the error of writer should be returned")
+	}
+	// the written Deployment is dropped,the failed Service and the ConfigMap are kept
+	if err := bundle.FinishAll(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Len() != 2 {
+		t.Fatalf("expect the 2 builders not written,got %d objects", bundle.Len())
+	}
+	var buf bytes.Buffer
+	if err := bundle.WriteYAMLTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "kind: Service") || !strings.Contains(buf.String(), "kind: ConfigMap") {
+		t.Fatalf("the kept builders should be written,got:\n%s", buf.String())
+	}
+}
+
+func Test_YAMLWriterWithPool(t *testing.T) {
+	var buf bytes.Buffer
+	yw := beku.NewYAMLWriter(&buf)
+	for _, tenant := range []string{"alpha", "beta"} {
+		dp := beku.AcquireDeployment()
+		obj, err := dp.SetNamespaceAndName(tenant, "web").SetPodLabels(map[string]string{"app": "web"}).SetContainer("web", "nginx:1.25", 80).Finish()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(obj.Spec.Template.Spec.Containers) != 1 {
+			t.Fatalf("the reused builder should not keep the containers,got %v", obj.Spec.Template.Spec.Containers)
+		}
+		if err := yw.Write(obj); err != nil {
+			t.Fatal(err)
+		}
+		beku.ReleaseDeployment(dp)
+	}
+	if yw.Count() != 2 || !strings.Contains(buf.String(), "namespace: alpha") || !strings.Contains(buf.String(), "namespace: beta") {
+		t.Fatalf("expect 2 documents of alpha and beta,got:\n%s", buf.String())
+	}
+	dp, err := beku.NewDeployment().SetName("web").SetPodLabels(map[string]string{"app": "web"}).SetContainer("web", "nginx:1.25", 80).
+		Reset().Finish()
+	if err == nil {
+		t.Fatalf("Reset() should clear the builder,got %v", dp)
+	}
+}
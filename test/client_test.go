@@ -0,0 +1,119 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func Test_ClientCreateGetDelete(t *testing.T) {
+	kube := fake.NewSimpleClientset()
+	kube.Fake.Resources = []*metav1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{{Name: "configmaps", Kind: "ConfigMap", Namespaced: true}},
+	}}
+	client, err := beku.NewClient(kube, dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm, err := beku.NewCM().SetNamespaceAndName("roc", "conf").SetData(map[string]string{"k": "v"}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := client.Create(ctx, cm); err != nil {
+		t.Fatal(err)
+	}
+	got, err := client.Get(ctx, cm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.GetNamespace() != "roc" || got.GetName() != "conf" {
+		t.Fatalf("expect ConfigMap roc/conf, got %s/%s", got.GetNamespace(), got.GetName())
+	}
+	if err := client.Delete(ctx, cm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Get(ctx, cm); err == nil {
+		t.Fatal("ConfigMap should be deleted")
+	}
+}
+
+func Test_ClientDiscoveryCached(t *testing.T) {
+	kube := fake.NewSimpleClientset()
+	kube.Fake.Resources = []*metav1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{{Name: "configmaps", Kind: "ConfigMap", Namespaced: true}},
+	}}
+	client, err := beku.NewClient(kube, dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm, err := beku.NewCM().SetNamespaceAndName("roc", "conf").SetData(map[string]string{"k": "v"}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := client.Create(ctx, cm); err != nil {
+		t.Fatal(err)
+	}
+	discovery := len(kube.Actions())
+	if _, err := client.Get(ctx, cm); err != nil {
+		t.Fatal(err)
+	}
+	if len(kube.Actions()) != discovery {
+		t.Fatalf("the discovery should be cached by Client, got actions %v", kube.Actions()[discovery:])
+	}
+	// the kind created after the first discovery is found by refreshing the discovery
+	kube.Fake.Resources[0].APIResources = append(kube.Fake.Resources[0].APIResources,
+		metav1.APIResource{Name: "secrets", Kind: "Secret", Namespaced: true})
+	secret, err := beku.NewSecret().SetNamespaceAndName("roc", "token").SetDataString(map[string]string{"k": "v"}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Create(ctx, secret); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fakeClient create the Client of the fake cluster which has objs,the kinds used by the tests are discovered
+func fakeClient(t *testing.T, objs ...runtime.Object) (*beku.Client, *dynamicfake.FakeDynamicClient) {
+	dynamic := dynamicfake.NewSimpleDynamicClient(scheme.Scheme, objs...)
+	client, err := beku.NewClient(fakeDiscovery(), dynamic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, dynamic
+}
+
+// fakeDiscovery create the fake clientset which discovers the kinds used by the tests
+func fakeDiscovery() *fake.Clientset {
+	kube := fake.NewSimpleClientset()
+	kube.Fake.Resources = []*metav1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{
+			{Name: "configmaps", Kind: "ConfigMap", Namespaced: true},
+			{Name: "secrets", Kind: "Secret", Namespaced: true},
+			{Name: "services", Kind: "Service", Namespaced: true},
+			{Name: "pods", Kind: "Pod", Namespaced: true},
+			{Name: "namespaces", Kind: "Namespace"},
+		},
+	}, {
+		GroupVersion: "apps/v1",
+		APIResources: []metav1.APIResource{
+			{Name: "deployments", Kind: "Deployment", Namespaced: true},
+			{Name: "statefulsets", Kind: "StatefulSet", Namespaced: true},
+			{Name: "daemonsets", Kind: "DaemonSet", Namespaced: true},
+		},
+	}, {
+		GroupVersion: "batch/v1",
+		APIResources: []metav1.APIResource{{Name: "jobs", Kind: "Job", Namespaced: true}},
+	}}
+	return kube
+}
@@ -0,0 +1,18 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_ConfigMapBinaryData(t *testing.T) {
+	if _, err := beku.NewCM().SetName("certs").SetBinaryData(map[string][]byte{"ca.der": {0x30, 0x82}}).Finish(); err != nil {
+		t.Fatal(err)
+	}
+	_, err := beku.NewCM().SetName("certs").SetData(map[string]string{"ca.der": "x"}).
+		SetBinaryData(map[string][]byte{"ca.der": {0x30}}).Finish()
+	if err == nil {
+		t.Fatal("key in both Data and BinaryData should fail")
+	}
+}
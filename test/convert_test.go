@@ -0,0 +1,85 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	appsv1 "k8s.io/api/apps/v1"
+	appsv1beta1 "k8s.io/api/apps/v1beta1"
+	appsv1beta2 "k8s.io/api/apps/v1beta2"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_DeploymentFinishFor(t *testing.T) {
+	builder := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80)
+	obj, err := builder.FinishFor(beku.WithAPIVersion("apps/v1beta2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dp, ok := obj.(*appsv1beta2.Deployment)
+	if !ok || dp.APIVersion != "apps/v1beta2" || dp.Spec.Template.Spec.Containers[0].Image != "nginx" {
+		t.Fatalf("expect apps/v1beta2 Deployment of nginx,got %#v", obj)
+	}
+	if obj, err = builder.FinishFor(beku.WithClusterVersion("v1.7.16")); err != nil {
+		t.Fatal(err)
+	}
+	// apps/v1beta1 is served since v1.6,it is the newest apiVersion of Deployment on v1.7
+	if _, ok := obj.(*appsv1beta1.Deployment); !ok {
+		t.Fatalf("expect apps/v1beta1 Deployment for v1.7,got %T", obj)
+	}
+	if obj, err = builder.FinishFor(beku.WithClusterVersion("v1.5.8")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := obj.(*extensionsv1beta1.Deployment); !ok {
+		t.Fatalf("expect extensions/v1beta1 Deployment for v1.5,got %T", obj)
+	}
+	if obj, err = builder.FinishFor(beku.WithClusterVersion("v1.14.10")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := obj.(*appsv1.Deployment); !ok {
+		t.Fatalf("expect apps/v1 Deployment for v1.14,got %T", obj)
+	}
+	if _, err = builder.FinishFor(beku.WithAPIVersion("networking.k8s.io/v1")); err == nil {
+		t.Fatal("Deployment can't be networking.k8s.io/v1, FinishFor should return error")
+	}
+}
+
+func Test_FinishForClusterOf(t *testing.T) {
+	builder := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80)
+	if _, err := builder.FinishFor(beku.WithClusterOf(nil)); err == nil {
+		t.Fatal("no client is registered,FinishFor should return the error of WithClusterOf")
+	}
+	cs := fake.NewSimpleClientset()
+	cs.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: "v1.7.16"}
+	beku.RegisterClientset(cs)
+	defer beku.RegisterClientset(nil)
+	obj, err := builder.FinishFor(beku.WithClusterOf(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := obj.(*appsv1beta1.Deployment); !ok {
+		t.Fatalf("expect apps/v1beta1 Deployment for the registered cluster of v1.7,got %T", obj)
+	}
+}
+
+func Test_IngressFinishFor(t *testing.T) {
+	obj, err := beku.NewIngress().SetNamespaceAndName("roc", "web").AddRule("example.com", "/api", "Prefix", "api", 8080).
+		FinishFor(beku.WithClusterVersion("v1.14.10"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ing, ok := obj.(*networkingv1beta1.Ingress)
+	if !ok {
+		t.Fatalf("expect networking.k8s.io/v1beta1 Ingress for v1.14,got %T", obj)
+	}
+	backend := ing.Spec.Rules[0].HTTP.Paths[0].Backend
+	if backend.ServiceName != "api" || backend.ServicePort.IntValue() != 8080 {
+		t.Fatalf("expect backend api:8080,got %v", backend)
+	}
+}
@@ -0,0 +1,62 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_CRD(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"spec": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"cronSpec": map[string]interface{}{"type": "string"}},
+			},
+		},
+	}
+	crd, err := beku.NewCRD().SetGroup("stable.example.com").SetNames("CronTab", "crontabs", "", "ct").
+		AddVersion("v1beta1", true, false, nil).AddVersion("v1", true, true, schema).SetStatusSubresource("v1").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crd.GetName() != "crontabs.stable.example.com" {
+		t.Fatalf("name should be crontabs.stable.example.com,got %s", crd.GetName())
+	}
+	if scope, _, _ := unstructured.NestedString(crd.Object, "spec", "scope"); scope != "Namespaced" {
+		t.Fatalf("scope should be Namespaced by default,got %s", scope)
+	}
+	if singular, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "singular"); singular != "crontab" {
+		t.Fatalf("singular should be crontab,got %s", singular)
+	}
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+	if len(versions) != 2 {
+		t.Fatalf("there should be 2 versions,got %d", len(versions))
+	}
+	if _, found, _ := unstructured.NestedMap(versions[1].(map[string]interface{}), "subresources", "status"); !found {
+		t.Fatal("status subresource of v1 should be enabled")
+	}
+
+	_, err = beku.NewCRD().SetGroup("stable.example.com").SetNames("CronTab", "crontabs", "").
+		AddVersion("v1beta1", true, true, nil).AddVersion("v1", true, true, nil).Finish()
+	if err == nil {
+		t.Fatal("two storage versions are not allowed, Finish should return error")
+	}
+}
+
+func Test_UnstructuredSetNestedField(t *testing.T) {
+	cr, err := beku.NewUnstructured("stable.example.com", "v1", "CronTab").SetNamespaceAndName("roc", "cron").
+		SetLabels(map[string]string{"app": "cron"}).SetNestedField("spec.cronSpec", "* * * * */5").
+		SetNestedField("spec.replicas", 2).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cr.GetAPIVersion() != "stable.example.com/v1" || cr.GetKind() != "CronTab" {
+		t.Fatalf("gvk is not set,got %s %s", cr.GetAPIVersion(), cr.GetKind())
+	}
+	if replicas, _, _ := unstructured.NestedInt64(cr.Object, "spec", "replicas"); replicas != 2 {
+		t.Fatalf("spec.replicas should be 2,got %d", replicas)
+	}
+}
@@ -0,0 +1,40 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var certificateGVK = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+
+func Test_CustomResourceSetField(t *testing.T) {
+	cr, err := beku.NewCustomResource(certificateGVK).SetNamespaceAndName("roc", "web").
+		SetField("spec.secretName", "web-tls").SetField("spec.dnsNames", []string{"web.example.com"}).
+		SetField("spec.renewBefore\\.days", 30).
+		SetNestedMap("spec.issuerRef", map[string]interface{}{"name": "letsencrypt", "kind": "ClusterIssuer"}).
+		RequireFields("spec.secretName", "spec.issuerRef.name").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if days, found, _ := unstructured.NestedInt64(cr.Object, "spec", "renewBefore.days"); !found || days != 30 {
+		t.Fatalf("spec.renewBefore.days should be 30,got %d", days)
+	}
+	if cr.GetAPIVersion() != "cert-manager.io/v1" || cr.GetKind() != "Certificate" {
+		t.Fatalf("gvk is not set,got %s %s", cr.GetAPIVersion(), cr.GetKind())
+	}
+}
+
+func Test_CustomResourceRequireFields(t *testing.T) {
+	_, err := beku.NewCustomResource(certificateGVK).SetNamespaceAndName("roc", "web").
+		RequireFields("spec.secretName").Finish()
+	if err == nil {
+		t.Fatal("spec.secretName is required, Finish should return error")
+	}
+	_, err = beku.NewCustomResource(certificateGVK).YAMLNew([]byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\n")).Finish()
+	if err == nil {
+		t.Fatal("the kind of data is not Certificate, Finish should return error")
+	}
+}
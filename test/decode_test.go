@@ -0,0 +1,22 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_YAMLNewLimits(t *testing.T) {
+	deep := "metadata: " + strings.Repeat("[", beku.MaxDocumentDepth+1) + strings.Repeat("]", beku.MaxDocumentDepth+1)
+	if _, err := beku.NewDeployment().YAMLNew([]byte(deep)).Finish(); err == nil {
+		t.Fatal("the document nesting depth exceeds the limit, YAMLNew should return error")
+	}
+	large := []byte(`{"metadata":{"name":"` + strings.Repeat("a", beku.MaxDocumentSize) + `"}}`)
+	if _, err := beku.NewCM().JSONNew(large).Finish(); err == nil {
+		t.Fatal("the document size exceeds the limit, JSONNew should return error")
+	}
+	if _, err := beku.NewSvc().YAMLNew([]byte("kind: [")).Finish(); err == nil {
+		t.Fatal("the document is not valid yaml, YAMLNew should return error")
+	}
+}
@@ -0,0 +1,41 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_PodDeletionCost(t *testing.T) {
+	builder := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80)
+	dp, err := builder.SetPodDeletionCost(-10).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost := dp.Spec.Template.Annotations[beku.PodDeletionCostAnnotation]; cost != "-10" {
+		t.Fatalf("expect deletion cost -10, got %q", cost)
+	}
+
+	pod := func(name, app string) *v1.Pod {
+		return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "roc", Name: name, Labels: map[string]string{"app": app}}}
+	}
+	client := fake.NewSimpleClientset(pod("http-a", "http"), pod("db-a", "db"))
+	if err := builder.SetPodsDeletionCost(client, map[string]int{"http-a": 100}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := client.CoreV1().Pods("roc").Get(context.TODO(), "http-a", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Annotations[beku.PodDeletionCostAnnotation] != "100" {
+		t.Fatalf("expect deletion cost 100, got %v", got.Annotations)
+	}
+	if err := builder.SetPodsDeletionCost(client, map[string]int{"db-a": 1}); err == nil {
+		t.Fatal("the Pod of other workload should be error")
+	}
+}
@@ -1,9 +1,14 @@
 package test
 
 import (
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/yulibaozi/beku"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 )
 
 func Test_DeploymentCreate(t *testing.T) {
@@ -20,3 +25,774 @@ func Test_DeploymentCreate(t *testing.T) {
 	}
 	t.Error(string(data))
 }
+
+func Test_DeploymentNamedProbe(t *testing.T) {
+	_, err := beku.NewDeployment().SetNamespaceAndName("litest", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainerOne(corev1.Container{Name: "http", Image: "nginx", Ports: []corev1.ContainerPort{{Name: "web", ContainerPort: 80}}}).
+		SetNamedHTTPLiveness("web", "/healthz", 10, 1, 5).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = beku.NewDeployment().SetNamespaceAndName("litest", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetNamedTCPReadness("", "web", 10, 1, 5).Finish()
+	if err == nil {
+		t.Fatal("probe port name web is not declared on the container, Finish should return error")
+	}
+}
+
+func Test_DeploymentSelectorConflict(t *testing.T) {
+	_, err := beku.NewDeployment().SetNamespaceAndName("litest", "http").SetPodLabels(map[string]string{"app": "http"}).
+		MatchIn("app", "web").SetContainer("http", "nginx", 80).Finish()
+	if err == nil {
+		t.Fatal("selector app in (web) does not match Pod labels app=http, Finish should return error")
+	}
+}
+
+func Test_DeploymentMatchValuesCopied(t *testing.T) {
+	values := []string{"http", "web"}
+	dp, err := beku.NewDeployment().SetNamespaceAndName("litest", "http").SetPodLabels(map[string]string{"app": "http"}).
+		MatchIn("app", values...).SetContainer("http", "nginx", 80).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values[0] = "changed"
+	if got := dp.Spec.Selector.MatchExpressions[0].Values; got[0] != "http" {
+		t.Fatalf("the values of MatchIn should be copied,got %v", got)
+	}
+}
+
+func Test_DeploymentContainerConflict(t *testing.T) {
+	_, err := beku.NewDeployment().SetNamespaceAndName("litest", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetContainer("http", "envoy", 9901).Finish()
+	if err == nil {
+		t.Fatal("duplicated container name, Finish should return error")
+	}
+	_, err = beku.NewDeployment().SetNamespaceAndName("litest", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetPVCMounts("data", "/data").Finish()
+	if err == nil {
+		t.Fatal("volume data is not declared, Finish should return error")
+	}
+}
+
+func Test_DeploymentResourceClaim(t *testing.T) {
+	dep, err := beku.NewDeployment().SetNamespaceAndName("roc", "infer").SetPodLabels(map[string]string{"app": "infer"}).
+		SetContainer("infer", "infer:v1", 8080).AddResourceClaim("gpu", "single-gpu").UseResourceClaim("gpu").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := dep.Spec.Template.Spec.ResourceClaims
+	if len(claims) != 1 || claims[0].ResourceClaimTemplateName == nil || *claims[0].ResourceClaimTemplateName != "single-gpu" {
+		t.Fatalf("unexpected pod resource claims:%v", claims)
+	}
+	if used := dep.Spec.Template.Spec.Containers[0].Resources.Claims; len(used) != 1 || used[0].Name != "gpu" {
+		t.Fatalf("unexpected container resource claims:%v", used)
+	}
+	_, err = beku.NewDeployment().SetNamespaceAndName("roc", "infer").SetPodLabels(map[string]string{"app": "infer"}).
+		SetContainer("infer", "infer:v1", 8080).UseResourceClaim("gpu").Finish()
+	if err == nil {
+		t.Fatal("undeclared resource claim should be error")
+	}
+}
+
+func Test_DeploymentLivenessOptions(t *testing.T) {
+	dep, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetHTTPLiveness(80, "/healthz", 10, 1, 5).
+		SetLivenessOptions(beku.ProbeOptions{TerminationGracePeriodSeconds: 120}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	grace := dep.Spec.Template.Spec.Containers[0].LivenessProbe.TerminationGracePeriodSeconds
+	if grace == nil || *grace != 120 {
+		t.Fatalf("expect probe terminationGracePeriodSeconds 120, got %v", grace)
+	}
+	_, err = beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetLivenessOptions(beku.ProbeOptions{TerminationGracePeriodSeconds: 120}).Finish()
+	if err == nil {
+		t.Fatal("options without liveness probe should be error")
+	}
+}
+
+func Test_DeploymentStartupProbe(t *testing.T) {
+	dep, err := beku.NewDeployment().SetNamespaceAndName("roc", "jvm").SetPodLabels(map[string]string{"app": "jvm"}).
+		SetContainer("jvm", "tomcat:9", 8080).SetHTTPStartup(8080, "/health", 30, 10).
+		SetHTTPReadness(8080, "/health", 5, 1, 5).SetReadnessOptions(beku.ProbeOptions{SuccessThreshold: 2, FailureThreshold: 6}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	container := dep.Spec.Template.Spec.Containers[0]
+	startup := container.StartupProbe
+	if startup == nil || startup.FailureThreshold != 30 || startup.PeriodSeconds != 10 || startup.InitialDelaySeconds != 0 {
+		t.Fatalf("unexpected startup probe:%v", startup)
+	}
+	if container.ReadinessProbe.SuccessThreshold != 2 || container.ReadinessProbe.FailureThreshold != 6 {
+		t.Fatalf("unexpected readiness probe:%v", container.ReadinessProbe)
+	}
+	_, err = beku.NewDeployment().SetNamespaceAndName("roc", "jvm").SetPodLabels(map[string]string{"app": "jvm"}).
+		SetContainer("jvm", "tomcat:9", 8080).SetTCPStartup("", 8080, 30, 10).
+		SetStartupOptions(beku.ProbeOptions{SuccessThreshold: 2}).Finish()
+	if err == nil {
+		t.Fatal("successThreshold more than 1 of startup probe should be error")
+	}
+}
+
+func Test_DeploymentEnvFromResourceField(t *testing.T) {
+	dep, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetEnvs(map[string]string{"MODE": "prod"}).
+		SetEnvFromResourceField("GOMAXPROCS", "http", "limits.cpu", "1").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	envs := dep.Spec.Template.Spec.Containers[0].Env
+	if len(envs) != 2 || envs[1].ValueFrom == nil || envs[1].ValueFrom.ResourceFieldRef.Resource != "limits.cpu" {
+		t.Fatalf("unexpected envs:%v", envs)
+	}
+	if _, err := beku.NewDeployment().SetContainer("http", "nginx", 80).SetEnvFromResourceField("X", "http", "limits.gpu", "").Finish(); err == nil {
+		t.Fatal("unsupported resource should be error")
+	}
+}
+
+func Test_DeploymentEnvsAndHeadersOrder(t *testing.T) {
+	dep, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetEnvs(map[string]string{"C": "3", "A": "1", "D": "4", "B": "2"}).
+		SetHTTPLiveness(80, "/healthz", 5, 1, 5, map[string]string{"X-C": "3", "X-A": "1", "X-B": "2"}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	container := dep.Spec.Template.Spec.Containers[0]
+	for index, name := range []string{"A", "B", "C", "D"} {
+		if container.Env[index].Name != name {
+			t.Fatalf("envs should be sorted by name:%v", container.Env)
+		}
+	}
+	for index, name := range []string{"X-A", "X-B", "X-C"} {
+		if container.LivenessProbe.HTTPGet.HTTPHeaders[index].Name != name {
+			t.Fatalf("headers should be sorted by name:%v", container.LivenessProbe.HTTPGet.HTTPHeaders)
+		}
+	}
+}
+
+func Test_DeploymentProbesAndContainers(t *testing.T) {
+	dep, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetContainer("metrics", "exporter", 9100).
+		SetCMDLiveness([]string{"cat", "/tmp/healthy"}, 0, 1, 5).SetTCPReadness("", 80, 5, 2, 10).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	containers := dep.Spec.Template.Spec.Containers
+	if len(containers) != 2 || containers[0].Ports[0].ContainerPort != 80 || containers[1].Ports[0].ContainerPort != 9100 {
+		t.Fatalf("unexpected containers:%v", containers)
+	}
+	liveness, readiness := containers[0].LivenessProbe, containers[0].ReadinessProbe
+	if liveness.Exec == nil || liveness.Exec.Command[1] != "/tmp/healthy" || liveness.HTTPGet != nil || liveness.TCPSocket != nil {
+		t.Fatalf("unexpected liveness probe:%v", liveness)
+	}
+	// initDelaySec <= 0 is defaulted to 30
+	if liveness.InitialDelaySeconds != 30 || liveness.TimeoutSeconds != 1 || liveness.PeriodSeconds != 5 {
+		t.Fatalf("unexpected liveness timing:%v", liveness)
+	}
+	if readiness.TCPSocket == nil || readiness.TCPSocket.Port.IntValue() != 80 || readiness.Exec != nil ||
+		readiness.InitialDelaySeconds != 5 || readiness.TimeoutSeconds != 2 || readiness.PeriodSeconds != 10 {
+		t.Fatalf("unexpected readiness probe:%v", readiness)
+	}
+	dep, err = beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetHTTPLiveness(80, "/healthz", 5, 1, 5).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if httpGet := dep.Spec.Template.Spec.Containers[0].LivenessProbe.HTTPGet; httpGet.Path != "/healthz" || httpGet.HTTPHeaders != nil {
+		t.Fatalf("the probe without headers should have no headers:%v", httpGet)
+	}
+	for _, envs := range []map[string]string{{}, {"MODE": " "}, {" ": "prod"}} {
+		if _, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+			SetContainer("http", "nginx", 80).SetEnvs(envs).Finish(); err == nil {
+			t.Fatalf("envs %v should be error", envs)
+		}
+	}
+}
+
+func Test_DeploymentConfigMapAndSecretVolume(t *testing.T) {
+	dep, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).
+		SetConfigMapVolume("scripts", "http-scripts", beku.VolumeOptions{DefaultMode: 0755}).
+		SetSecretVolume("tls", "http-tls", beku.VolumeOptions{Items: []beku.KeyToPath{{Key: "tls.key", Path: "private/tls.key", Mode: 0400}}}).
+		SetPVCMounts("scripts", "/opt/scripts").SetPVCMounts("tls", "/etc/tls").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	volumes := dep.Spec.Template.Spec.Volumes
+	if len(volumes) != 2 || *volumes[0].ConfigMap.DefaultMode != 0755 || *volumes[1].Secret.Items[0].Mode != 0400 {
+		t.Fatalf("unexpected volumes:%v", volumes)
+	}
+	_, err = beku.NewDeployment().SetContainer("http", "nginx", 80).
+		SetSecretVolume("tls", "http-tls", beku.VolumeOptions{Items: []beku.KeyToPath{{Key: "tls.key", Path: "../tls.key"}}}).Finish()
+	if err == nil {
+		t.Fatal("path with '..' should be error")
+	}
+}
+
+func Test_DeploymentFSGroup(t *testing.T) {
+	dep, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetFSGroup(2000).SetFSGroupChangePolicy(beku.FSGroupChangeOnRootMismatch).
+		SetSupplementalGroups(3000, 4000).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := dep.Spec.Template.Spec.SecurityContext
+	if *sc.FSGroup != 2000 || string(*sc.FSGroupChangePolicy) != beku.FSGroupChangeOnRootMismatch || len(sc.SupplementalGroups) != 2 {
+		t.Fatalf("unexpected security context:%v", sc)
+	}
+	_, err = beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetFSGroupChangePolicy(beku.FSGroupChangeAlways).Finish()
+	if err == nil {
+		t.Fatal("fsGroupChangePolicy without fsGroup should be error")
+	}
+}
+
+func Test_DeploymentRestrictedSecurityProfile(t *testing.T) {
+	dep, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).ApplyRestrictedSecurityProfile().Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc := dep.Spec.Template.Spec.SecurityContext; sc == nil || !*sc.RunAsNonRoot || sc.SeccompProfile.Type != corev1.SeccompProfileTypeRuntimeDefault {
+		t.Fatalf("unexpected pod security context:%v", sc)
+	}
+	sc := dep.Spec.Template.Spec.Containers[0].SecurityContext
+	if sc == nil || *sc.AllowPrivilegeEscalation || !*sc.ReadOnlyRootFilesystem || len(sc.Capabilities.Drop) != 1 || sc.Capabilities.Drop[0] != "ALL" {
+		t.Fatalf("unexpected container security context:%v", sc)
+	}
+	if _, err := beku.NewDeployment().ApplyRestrictedSecurityProfile().Finish(); err == nil {
+		t.Fatal("profile without containers should be error")
+	}
+}
+
+func Test_DeploymentCapabilities(t *testing.T) {
+	dep, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetCapabilities("http", []string{"cap_net_bind_service"}, []string{"ALL"}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	capabilities := dep.Spec.Template.Spec.Containers[0].SecurityContext.Capabilities
+	if len(capabilities.Add) != 1 || capabilities.Add[0] != "NET_BIND_SERVICE" || capabilities.Drop[0] != "ALL" {
+		t.Fatalf("unexpected capabilities:%v", capabilities)
+	}
+	_, err = beku.NewDeployment().SetContainer("http", "nginx", 80).
+		SetCapabilities("http", []string{"NET_ADMIN"}, []string{"NET_ADMIN"}).Finish()
+	if err == nil {
+		t.Fatal("capability added and dropped both should be error")
+	}
+}
+
+func Test_DeploymentPrivilegedLint(t *testing.T) {
+	dep, err := beku.NewDeployment().SetNamespaceAndName("roc", "agent").SetPodLabels(map[string]string{"app": "agent"}).
+		SetContainer("agent", "agent:v1", 9100).SetResourceLimit(map[beku.ResourceName]string{beku.ResourceCPU: "1", beku.ResourceMemory: "1Gi"}).
+		SetPrivileged("agent", true).SetReadOnlyRootFilesystem("agent", true).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := beku.Lint(dep); !result.OK() || len(result.Warnings) != 1 {
+		t.Fatalf("expect 1 privileged warning, got %v", result)
+	}
+	_, err = beku.NewDeployment().SetNamespaceAndName("roc", "agent").SetPodLabels(map[string]string{"app": "agent"}).
+		SetContainer("agent", "agent:v1", 9100).SetPrivileged("agent", true).SetAllowPrivilegeEscalation("agent", false).Finish()
+	if err == nil {
+		t.Fatal("privileged container without privilege escalation should be error")
+	}
+}
+
+func Test_DeploymentCheck(t *testing.T) {
+	result := beku.Check(beku.NewDeployment().SetNamespaceAndName("roc", "http").SetContainer("http", "nginx", 80))
+	if result.OK() || result.Err() == nil {
+		t.Fatal("Deployment without pod labels should be error")
+	}
+	result = beku.Check(beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).SetContainer("http", "nginx", 80))
+	if !result.OK() || len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0], "no resource limits") {
+		t.Fatalf("expect only resource limits warning, got %v", result)
+	}
+}
+
+func Test_DeploymentSELinuxOptions(t *testing.T) {
+	dep, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetSELinuxOptions("", "", "", "s0:c123,c456").
+		SetContainerSELinuxOptions("http", "", "", "container_t", "").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if level := dep.Spec.Template.Spec.SecurityContext.SELinuxOptions.Level; level != "s0:c123,c456" {
+		t.Fatalf("expect pod SELinux level s0:c123,c456, got %s", level)
+	}
+	if typ := dep.Spec.Template.Spec.Containers[0].SecurityContext.SELinuxOptions.Type; typ != "container_t" {
+		t.Fatalf("expect container SELinux type container_t, got %s", typ)
+	}
+	if _, err := beku.NewDeployment().SetSELinuxOptions("", "", "", "").Finish(); err == nil {
+		t.Fatal("empty SELinux options should be error")
+	}
+}
+
+func Test_DeploymentAddLabelAndAnnotation(t *testing.T) {
+	yaml := []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: http
+  labels:
+    app: http
+spec:
+  template:
+    metadata:
+      labels:
+        app: http
+    spec:
+      containers:
+      - name: http
+        image: nginx
+`)
+	dep, err := beku.NewDeployment().YAMLNew(yaml).AddLabel("team", "infra").AddAnnotation("owner", "roc").
+		AddPodLabel("version", "v1").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dep.Labels["app"] != "http" || dep.Labels["team"] != "infra" || dep.Annotations["owner"] != "roc" {
+		t.Fatalf("labels or annotations are not merged:%v,%v", dep.Labels, dep.Annotations)
+	}
+	if dep.Spec.Template.Labels["app"] != "http" || dep.Spec.Template.Labels["version"] != "v1" {
+		t.Fatalf("pod labels are not merged:%v", dep.Spec.Template.Labels)
+	}
+	if _, err := beku.NewDeployment().AddLabel("bad key", "v").Finish(); err == nil {
+		t.Fatal("invalid label key should be error")
+	}
+}
+
+func Test_DeploymentDeleteOptions(t *testing.T) {
+	if err := beku.NewDeployment().SetNamespace("roc").Delete(); err == nil {
+		t.Fatal("delete without name should be error")
+	}
+	err := beku.NewDeployment().SetNamespaceAndName("roc", "http").Delete(beku.DeleteOptions{Propagation: "Cascade"})
+	if err == nil {
+		t.Fatal("unknown propagation should be error")
+	}
+}
+
+func Test_DeploymentFinishUnchecked(t *testing.T) {
+	dep, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetReplicas(3).FinishUnchecked()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dep.Kind != "Deployment" || len(dep.Spec.Template.Spec.Containers) != 0 || *dep.Spec.Replicas != 3 {
+		t.Fatalf("expect partial Deployment with 3 replicas, got %v", dep)
+	}
+}
+
+func Test_DeploymentWithVerifier(t *testing.T) {
+	builder := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).WithVerifier(func(dep *appsv1.Deployment) error {
+		for _, container := range dep.Spec.Template.Spec.Containers {
+			if !strings.HasPrefix(container.Image, "registry.example.com/") {
+				return fmt.Errorf("image %s is not from registry.example.com", container.Image)
+			}
+		}
+		return nil
+	})
+	if fieldErrs := builder.Validate(); len(fieldErrs) != 1 || !strings.Contains(fieldErrs[0].Error(), "verifier failed") {
+		t.Fatalf("expect verifier error, got %v", fieldErrs)
+	}
+	if _, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).Finish(); err != nil {
+		t.Fatalf("the verifier should only affect its builder, got %v", err)
+	}
+}
+
+func Test_DeploymentTargetArch(t *testing.T) {
+	dep, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).TargetArch("amd64").TargetArch("arm64").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	terms := dep.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) != 1 || len(terms[0].MatchExpressions) != 1 || terms[0].MatchExpressions[0].Values[0] != "arm64" {
+		t.Fatalf("expect only arm64 arch requirement, got %v", terms)
+	}
+	if _, err := beku.NewDeployment().TargetArch("sparc").Finish(); err == nil {
+		t.Fatal("unsupported arch should be error")
+	}
+}
+
+func Test_DeploymentAnnotateConfigChecksum(t *testing.T) {
+	checksum := func(value string) string {
+		cm := beku.NewCM().SetNamespaceAndName("roc", "http").SetData(map[string]string{"nginx.conf": value})
+		secret := beku.NewSecret().SetNamespaceAndName("roc", "http").SetDataString(map[string]string{"password": "secret"})
+		dep, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+			SetContainer("http", "nginx", 80).AnnotateConfigChecksum([]*beku.ConfigMap{cm}, []*beku.Secret{secret}).Finish()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return dep.Spec.Template.Annotations[beku.ConfigChecksumAnnotation]
+	}
+	first, same, changed := checksum("worker_processes 1;"), checksum("worker_processes 1;"), checksum("worker_processes 2;")
+	if first == "" || first != same || first == changed {
+		t.Fatalf("checksum should only change with the config: %s %s %s", first, same, changed)
+	}
+}
+
+func Test_DeploymentSidecarSetters(t *testing.T) {
+	dp, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("app", "nginx", 80).SetContainer("envoy", "envoyproxy/envoy", 9901).
+		SetHTTPLivenessFor("envoy", 9901, "/ready", 5, 1, 10).SetEnvsFor("envoy", map[string]string{"ENVOY_UID": "0"}).
+		SetResourceLimitFor("envoy", map[beku.ResourceName]string{beku.ResourceCPU: "100m"}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	app, envoy := dp.Spec.Template.Spec.Containers[0], dp.Spec.Template.Spec.Containers[1]
+	if app.LivenessProbe != nil || len(app.Env) != 0 || app.Resources.Limits != nil {
+		t.Fatalf("expect app container unchanged, got %v", app)
+	}
+	if envoy.LivenessProbe == nil || len(envoy.Env) != 1 || envoy.Resources.Limits.Cpu().String() != "100m" {
+		t.Fatalf("expect envoy container set, got %v", envoy)
+	}
+	if _, err := beku.NewDeployment().SetName("http").SetEnvsFor("missing", map[string]string{"K": "V"}).Finish(); err == nil {
+		t.Fatal("setter for missing container should fail")
+	}
+}
+
+func Test_DeploymentResourcesAndGPU(t *testing.T) {
+	dp, err := beku.NewDeployment().SetNamespaceAndName("roc", "infer").SetPodLabels(map[string]string{"app": "infer"}).
+		SetContainer("infer", "triton", 8000).SetResourceRequests("500m", "1Gi").SetResourceLimits("2", "4Gi").
+		SetExtendedResource(beku.ResourceGPU, "1").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resources := dp.Spec.Template.Spec.Containers[0].Resources
+	if resources.Limits.Cpu().String() != "2" || resources.Requests.Memory().String() != "1Gi" {
+		t.Fatalf("expect cpu limit 2 and memory request 1Gi, got %v", resources)
+	}
+	gpu := resources.Limits["nvidia.com/gpu"]
+	if gpu.String() != "1" || resources.Requests.Name("nvidia.com/gpu", "").String() != "1" {
+		t.Fatalf("expect gpu limit and request 1, got %v", resources)
+	}
+	if fieldErrs := beku.NewDeployment().SetResourceLimits("two", "").Validate(); len(fieldErrs) == 0 {
+		t.Fatal("invalid cpu quantity should fail")
+	}
+	if fieldErrs := beku.NewDeployment().SetExtendedResource(beku.ResourceGPU, "0.5").Validate(); len(fieldErrs) == 0 {
+		t.Fatal("fractional gpu should fail")
+	}
+}
+
+func Test_DeploymentEnvFrom(t *testing.T) {
+	dp, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetEnvFromConfigMap("http-conf").SetEnvFromSecret("http-secret").
+		SetEnvFromConfigMap("http-conf").SetConfigMapVolume("conf", "http-conf").SetPVCMounts("conf", "/etc/http").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	envFrom := dp.Spec.Template.Spec.Containers[0].EnvFrom
+	if len(envFrom) != 2 || envFrom[0].ConfigMapRef.Name != "http-conf" || envFrom[1].SecretRef.Name != "http-secret" {
+		t.Fatalf("expect envFrom of ConfigMap and Secret, got %v", envFrom)
+	}
+	if fieldErrs := beku.NewDeployment().SetEnvFromSecret("Bad_Name").Validate(); len(fieldErrs) == 0 {
+		t.Fatal("invalid Secret name should fail")
+	}
+}
+
+func Test_DeploymentStrategy(t *testing.T) {
+	builder := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80)
+	dp, err := builder.SetStrategyRollingUpdate("25%", "0").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rolling := dp.Spec.Strategy.RollingUpdate
+	if rolling == nil || rolling.MaxSurge.String() != "25%" || rolling.MaxUnavailable.IntValue() != 0 {
+		t.Fatalf("expect rolling update 25%%/0, got %v", dp.Spec.Strategy)
+	}
+	if dp, _ = builder.SetStrategyRecreate().Finish(); dp.Spec.Strategy.Type != "Recreate" || dp.Spec.Strategy.RollingUpdate != nil {
+		t.Fatalf("expect Recreate strategy, got %v", dp.Spec.Strategy)
+	}
+	for _, values := range [][2]string{{"0", "0%"}, {"120%", "1"}, {"-1", "1"}, {"a", "1"}} {
+		if fieldErrs := beku.NewDeployment().SetStrategyRollingUpdate(values[0], values[1]).Validate(); len(fieldErrs) == 0 {
+			t.Fatalf("maxSurge %s maxUnavailable %s should fail", values[0], values[1])
+		}
+	}
+}
+
+func Test_DeploymentSecurityContext(t *testing.T) {
+	dp, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetPodSecurityContext(1000, 3000, 2000, true).SetContainerSecurityContext("http", 101, 101, true).
+		DropCapabilities("http", "ALL").AddCapabilities("http", "NET_BIND_SERVICE", "CAP_NET_BIND_SERVICE").SetReadOnlyRootFilesystem("http", true).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod := dp.Spec.Template.Spec
+	if *pod.SecurityContext.RunAsUser != 1000 || *pod.SecurityContext.FSGroup != 2000 || !*pod.SecurityContext.RunAsNonRoot {
+		t.Fatalf("unexpected Pod security context %v", pod.SecurityContext)
+	}
+	sc := pod.Containers[0].SecurityContext
+	if *sc.RunAsUser != 101 || len(sc.Capabilities.Add) != 1 || sc.Capabilities.Drop[0] != "ALL" || !*sc.ReadOnlyRootFilesystem {
+		t.Fatalf("unexpected container security context %v", sc)
+	}
+	dp, err = beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetFSGroup(2000).SetPodSecurityContext(1000, 0, 0, true).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if psc := dp.Spec.Template.Spec.SecurityContext; *psc.FSGroup != 2000 || psc.RunAsGroup != nil || *psc.RunAsUser != 1000 {
+		t.Fatalf("zero id of SetPodSecurityContext should be unset:%v", psc)
+	}
+	_, err = beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetPodSecurityContext(1000, 1000, 1000, true).SetContainerSecurityContext("http", 0, 0, false).
+		AddCapabilities("http", "NET_ADMIN").DropCapabilities("http", "NET_ADMIN").Finish()
+	if err == nil {
+		t.Fatal("capability added and dropped both should fail")
+	}
+}
+
+func Test_DeploymentImagePull(t *testing.T) {
+	dp, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "harbor.local/nginx", 80).SetContainer("proxy", "envoy:latest", 9901).
+		SetImagePullSecrets("harbor-secret", "ecr-secret").SetImagePullSecrets("harbor-secret").SetImagePullPolicyFor("proxy", "Always").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod := dp.Spec.Template.Spec
+	if len(pod.ImagePullSecrets) != 2 {
+		t.Fatalf("expect 2 pull secrets, got %v", pod.ImagePullSecrets)
+	}
+	if pod.Containers[0].ImagePullPolicy != "IfNotPresent" || pod.Containers[1].ImagePullPolicy != "Always" {
+		t.Fatalf("expect default IfNotPresent and Always of proxy, got %v", pod.Containers)
+	}
+	dp, err = beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetImagePullPolicy("Never").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dp.Spec.Template.Spec.Containers[0].ImagePullPolicy != "Never" || dp.Annotations["imagePullPolicy"] != "" {
+		t.Fatalf("expect Never without the annotation, got %v", dp)
+	}
+	if fieldErrs := beku.NewDeployment().SetImagePullPolicy("Sometimes").Validate(); len(fieldErrs) == 0 {
+		t.Fatal("unknown pull policy should fail")
+	}
+}
+
+func Test_DeploymentCommandAndLifecycle(t *testing.T) {
+	dep, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetCommand([]string{"nginx"}).SetArgs([]string{"-g", "daemon off;"}).
+		SetPreStopExec([]string{"sleep", "15"}).SetPostStartHTTP(80, "/warmup").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	container := dep.Spec.Template.Spec.Containers[0]
+	if len(container.Command) != 1 || len(container.Args) != 2 {
+		t.Fatalf("unexpected command %v and args %v", container.Command, container.Args)
+	}
+	if container.Lifecycle == nil || container.Lifecycle.PreStop.Exec == nil || container.Lifecycle.PostStart.HTTPGet.Path != "/warmup" {
+		t.Fatalf("unexpected lifecycle:%v", container.Lifecycle)
+	}
+	_, err = beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetPreStopExec(nil).Finish()
+	if err == nil {
+		t.Fatal("empty preStop cmd should be error")
+	}
+}
+
+func Test_DeploymentGenericVolumes(t *testing.T) {
+	dep, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetEmptyDirVolume("cache", "Memory", "256Mi").SetVolumeMount("cache", "/var/cache/nginx").
+		SetDownwardAPIVolume("podinfo", map[string]string{"labels": "metadata.labels"}).SetVolumeMount("podinfo", "/etc/podinfo").
+		SetProjectedVolume("all", beku.ProjectedSource{ConfigMap: "conf"}, beku.ProjectedSource{ServiceAccountToken: "token", ExpirationSeconds: 3600}).
+		Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	volumes := dep.Spec.Template.Spec.Volumes
+	if len(volumes) != 3 || volumes[0].EmptyDir == nil || volumes[0].EmptyDir.SizeLimit.String() != "256Mi" ||
+		volumes[1].DownwardAPI == nil || len(volumes[2].Projected.Sources) != 2 {
+		t.Fatalf("unexpected volumes:%v", volumes)
+	}
+	if mounts := dep.Spec.Template.Spec.Containers[0].VolumeMounts; len(mounts) != 2 {
+		t.Fatalf("unexpected volume mounts:%v", mounts)
+	}
+	_, err = beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetHostPathVolume("logs", "var/log", "Directory").Finish()
+	if err == nil {
+		t.Fatal("relative host path should be error")
+	}
+}
+
+func Test_DeploymentFrom(t *testing.T) {
+	live, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	live.ResourceVersion = "42"
+	live.Spec.MinReadySeconds = 10
+	live.Status.ReadyReplicas = 1
+	dp, err := beku.DeploymentFrom(live).SetEnvs(map[string]string{"MODE": "prod"}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dp.ResourceVersion != "42" || dp.Spec.MinReadySeconds != 10 || dp.Status.ReadyReplicas != 1 {
+		t.Fatalf("the fields of the existing Deployment should be kept:%v", dp)
+	}
+	if len(live.Spec.Template.Spec.Containers[0].Env) != 0 {
+		t.Fatal("the existing Deployment should not be changed")
+	}
+}
+
+func Test_DeploymentInitContainer(t *testing.T) {
+	dep, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "app:v1", 80).SetEmptyDirVolume("shared", "", "").SetVolumeMount("shared", "/data").
+		SetInitContainer("migrate", "app:v1", []string{"./migrate", "up"}).SetInitEnvs("migrate", map[string]string{"DB": "mysql"}).
+		SetInitVolumeMount("migrate", "shared", "/data").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	inits := dep.Spec.Template.Spec.InitContainers
+	if len(inits) != 1 || inits[0].Env[0].Name != "DB" || inits[0].VolumeMounts[0].Name != "shared" {
+		t.Fatalf("unexpected init containers:%v", inits)
+	}
+	_, err = beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "app:v1", 80).SetInitContainer("http", "app:v1", nil).Finish()
+	if err == nil {
+		t.Fatal("init container with the name of container should be error")
+	}
+}
+
+func Test_DeploymentStructuredEnvs(t *testing.T) {
+	dep, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetEnvs(map[string]string{"MODE": "prod"}).SetEnvs(map[string]string{"LOG": "info"}).
+		AddEnv("MODE", "dev").AddEnvFromFieldRef("POD_IP", "status.podIP").AddEnvFromSecretKey("DB_PASSWORD", "mysql", "password").
+		AddEnvFromConfigMapKey("DB_HOST", "mysql", "host").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	envs := map[string]corev1.EnvVar{}
+	for _, env := range dep.Spec.Template.Spec.Containers[0].Env {
+		envs[env.Name] = env
+	}
+	if len(envs) != 5 || envs["MODE"].Value != "dev" || envs["LOG"].Value != "info" ||
+		envs["POD_IP"].ValueFrom.FieldRef.FieldPath != "status.podIP" || envs["DB_PASSWORD"].ValueFrom.SecretKeyRef.Name != "mysql" {
+		t.Fatalf("unexpected envs:%v", envs)
+	}
+	_, err = beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).AddEnvFromFieldRef("NODE", "spec.hostname").Finish()
+	if err == nil {
+		t.Fatal("unsupported fieldPath should be error")
+	}
+}
+
+func Test_DeploymentHostAliasesAndDNS(t *testing.T) {
+	dp, err := beku.NewDeployment().SetNamespaceAndName("roc", "legacy").SetPodLabels(map[string]string{"app": "legacy"}).
+		SetContainer("legacy", "legacy:1.0", 8080).AddHostAlias("10.0.0.8", "db.internal").AddHostAlias("10.0.0.8", "db.internal", "cache.internal").
+		SetDNSConfig([]string{"10.0.0.2"}, []string{"corp.example.com"}, map[string]string{"ndots": "2", "single-request": ""}).
+		SetHostname("legacy").SetSubdomain("legacy-headless").SetHostNetwork(true).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod := dp.Spec.Template.Spec
+	if len(pod.HostAliases) != 1 || len(pod.HostAliases[0].Hostnames) != 2 {
+		t.Fatalf("expect one host alias of 2 hostnames,got %v", pod.HostAliases)
+	}
+	if pod.DNSPolicy != "ClusterFirstWithHostNet" || len(pod.DNSConfig.Options) != 2 || pod.DNSConfig.Options[1].Value != nil {
+		t.Fatalf("expect ClusterFirstWithHostNet and 2 dns options,got %s %v", pod.DNSPolicy, pod.DNSConfig)
+	}
+	if pod.Hostname != "legacy" || pod.Subdomain != "legacy-headless" {
+		t.Fatalf("expect hostname legacy.legacy-headless,got %s.%s", pod.Hostname, pod.Subdomain)
+	}
+	_, err = beku.NewDeployment().SetNamespaceAndName("roc", "legacy").SetPodLabels(map[string]string{"app": "legacy"}).
+		SetContainer("legacy", "legacy:1.0", 8080).SetDNSPolicy("None").Finish()
+	if err == nil {
+		t.Fatal("DNS policy None without nameservers should return error")
+	}
+}
+
+func Test_DeploymentClone(t *testing.T) {
+	base := beku.NewDeployment().SetName("tenant").SetPodLabels(map[string]string{"app": "tenant"}).
+		SetContainer("tenant", "tenant:1.0", 8080)
+	tenants := []string{"alpha", "beta", "gamma", "delta"}
+	dps := make([]*appsv1.Deployment, len(tenants))
+	errs := make([]error, len(tenants))
+	var wg sync.WaitGroup
+	for i, tenant := range tenants {
+		wg.Add(1)
+		go func(i int, tenant string) {
+			defer wg.Done()
+			dps[i], errs[i] = base.Clone().SetNamespace(tenant).AddLabel("tenant", tenant).
+				SetEnvs(map[string]string{"TENANT": tenant}).Finish()
+		}(i, tenant)
+	}
+	wg.Wait()
+	for i, tenant := range tenants {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		envs := dps[i].Spec.Template.Spec.Containers[0].Env
+		if dps[i].GetNamespace() != tenant || dps[i].GetLabels()["tenant"] != tenant || len(envs) != 1 || envs[0].Value != tenant {
+			t.Fatalf("clone of %s is polluted:%s %v %v", tenant, dps[i].GetNamespace(), dps[i].GetLabels(), envs)
+		}
+	}
+	dp, err := base.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dp.GetLabels()["tenant"] != "" || len(dp.Spec.Template.Spec.Containers[0].Env) != 0 {
+		t.Fatalf("base should not be changed by the clones,got %v %v", dp.GetLabels(), dp.Spec.Template.Spec.Containers[0].Env)
+	}
+}
+
+func Test_DeploymentMergeLabels(t *testing.T) {
+	dp, err := beku.NewDeployment().SetNamespaceAndName("roc", "mysql").SetPodLabels(map[string]string{"app": "mysql"}).
+		SetContainer("mysql", "mysql:5.7.21", 3306).SetLabels(map[string]string{"team": "dba"}).
+		MergeLabels(map[string]string{"cost-center": "cc-42"}).MergeAnnotations(map[string]string{"owner": "dba@example.com"}).
+		SetAppKubernetesLabels("mysql", "5.7.21", "database", "wordpress").SetPrometheusScrape(9104, "").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dp.Labels["team"] != "dba" || dp.Labels["cost-center"] != "cc-42" || dp.Labels[beku.AppPartOfLabel] != "wordpress" {
+		t.Fatalf("labels should be merged,got %v", dp.Labels)
+	}
+	if len(dp.Spec.Selector.MatchLabels) != 1 || dp.Spec.Template.Labels[beku.AppVersionLabel] != "5.7.21" || dp.Spec.Template.Labels["app"] != "mysql" {
+		t.Fatalf("app.kubernetes.io labels should be on the Pod template but not the selector,got %v %v",
+			dp.Spec.Selector.MatchLabels, dp.Spec.Template.Labels)
+	}
+	annotations := dp.Spec.Template.Annotations
+	if annotations[beku.PrometheusScrapeAnnotation] != "true" || annotations[beku.PrometheusPortAnnotation] != "9104" || annotations[beku.PrometheusPathAnnotation] != "/metrics" {
+		t.Fatalf("unexpected prometheus annotations:%v", annotations)
+	}
+	_, err = beku.NewDeployment().SetNamespaceAndName("roc", "mysql").SetPodLabels(map[string]string{"app": "mysql"}).
+		SetContainer("mysql", "mysql:5.7.21", 3306).MergePodLabels(map[string]string{"app": "redis"}).Finish()
+	if err == nil {
+		t.Fatal("changing the selector label of the Pod template should return error")
+	}
+}
+
+func Test_DeploymentContainerPorts(t *testing.T) {
+	dp, err := beku.NewDeployment().SetNamespaceAndName("roc", "api").SetPodLabels(map[string]string{"app": "api"}).
+		SetContainer("api", "api:1.0", 8080).AddContainerPort("http", 8080, "").AddContainerPort("grpc", 9090, "TCP").
+		AddContainerPort("metrics", 9100, "").AddContainerPort("discovery", 5353, "udp").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ports := dp.Spec.Template.Spec.Containers[0].Ports
+	if len(ports) != 4 || ports[0].Name != "http" || ports[0].Protocol != corev1.ProtocolTCP || ports[3].Protocol != corev1.ProtocolUDP {
+		t.Fatalf("expect http,grpc,metrics and discovery ports,got %v", ports)
+	}
+	_, err = beku.NewDeployment().SetNamespaceAndName("roc", "api").SetPodLabels(map[string]string{"app": "api"}).
+		SetContainer("api", "api:1.0", 8080).AddContainerPort("grpc", 9090, "").AddContainerPort("grpc", 9091, "").Finish()
+	if err == nil {
+		t.Fatal("duplicated port name should return error")
+	}
+	_, err = beku.NewDeployment().SetNamespaceAndName("roc", "api").SetPodLabels(map[string]string{"app": "api"}).
+		SetContainer("api", "api:1.0", 8080).AddContainerPort("quic", 4433, "QUIC").Finish()
+	if err == nil {
+		t.Fatal("unsupported protocol should return error")
+	}
+
+	svc, err := beku.NewSvc().SetNamespaceAndName("roc", "api").SetSelector(map[string]string{"app": "api"}).
+		SetPortByName("grpc", 80, "grpc", "").SetPortByName("metrics", 9100, "metrics", "").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(svc.Spec.Ports) != 2 || svc.Spec.Ports[0].TargetPort.StrVal != "grpc" {
+		t.Fatalf("expect the ports targeting the named container ports,got %v", svc.Spec.Ports)
+	}
+}
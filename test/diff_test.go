@@ -0,0 +1,58 @@
+package test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func Test_Diff(t *testing.T) {
+	oldDp, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx:1.14", 80).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	newDp, err := beku.DeploymentFrom(oldDp).SetReplicas(3).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	patch, err := beku.Diff(oldDp, newDp, types.JSONPatchType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ops []map[string]interface{}
+	if err = json.Unmarshal(patch, &ops); err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 1 || ops[0]["path"] != "/spec/replicas" {
+		t.Fatalf("unexpected json patch:%s", patch)
+	}
+	patch, err = beku.Diff(oldDp, newDp, types.StrategicMergePatchType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(patch) != `{"spec":{"replicas":3}}` {
+		t.Fatalf("unexpected strategic merge patch:%s", patch)
+	}
+}
+
+func Test_DeploymentPatchAgainst(t *testing.T) {
+	existing, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx:1.14", 80).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the annotation is managed by the other actor
+	existing.Annotations = map[string]string{"deployment.kubernetes.io/revision": "3"}
+	patch, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx:1.15", 80).PatchAgainst(existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(patch), "nginx:1.15") || strings.Contains(string(patch), "revision") {
+		t.Fatalf("unexpected patch:%s", patch)
+	}
+}
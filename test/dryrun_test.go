@@ -0,0 +1,121 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func dryRunDeployment() *beku.Deployment {
+	return beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx:1.25", 80)
+}
+
+// dryRunRecorder record the dryRun option of the patches,the fake dynamic client drops the options
+type dryRunRecorder struct {
+	dynamic.Interface
+	dryRun *[]string
+}
+
+func (r dryRunRecorder) Resource(resource schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return dryRunResource{NamespaceableResourceInterface: r.Interface.Resource(resource), dryRun: r.dryRun}
+}
+
+type dryRunResource struct {
+	dynamic.NamespaceableResourceInterface
+	dryRun *[]string
+}
+
+func (r dryRunResource) Namespace(namespace string) dynamic.ResourceInterface {
+	return dryRunNamespaced{ResourceInterface: r.NamespaceableResourceInterface.Namespace(namespace), dryRun: r.dryRun}
+}
+
+type dryRunNamespaced struct {
+	dynamic.ResourceInterface
+	dryRun *[]string
+}
+
+func (r dryRunNamespaced) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	*r.dryRun = opts.DryRun
+	return r.ResourceInterface.Patch(ctx, name, pt, data, opts, subresources...)
+}
+
+func Test_DryRunApply(t *testing.T) {
+	fakeDynamic := dynamicfake.NewSimpleDynamicClient(scheme.Scheme)
+	var dryRun []string
+	client, err := beku.NewClient(fakeDiscovery(), dryRunRecorder{Interface: fakeDynamic, dryRun: &dryRun})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fakeDynamic.PrependReactor("patch", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		obj := &unstructured.Unstructured{}
+		if err := json.Unmarshal(action.(k8stesting.PatchAction).GetPatch(), &obj.Object); err != nil {
+			return true, nil, err
+		}
+		obj.SetUID("9f1c")
+		return true, obj, nil
+	})
+	result, err := dryRunDeployment().DryRunApply(context.Background(), client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dryRun) != 1 || dryRun[0] != metav1.DryRunAll || result.GetUID() != "9f1c" {
+		t.Fatalf("expect the object of dryRun=All,got dryRun %v,%v", dryRun, result)
+	}
+	if err := dryRunDeployment().ValidateAgainstCluster(context.Background(), client); err != nil {
+		t.Fatal(err)
+	}
+	if err := beku.NewDeployment().SetNamespaceAndName("roc", "http").ValidateAgainstCluster(context.Background(), client); err == nil {
+		t.Fatal("the error of Finish() should be returned before dry-run")
+	}
+}
+
+func Test_DryRunApplyRejected(t *testing.T) {
+	client, dynamic := fakeClient(t)
+	gr := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	reject := func(err error) {
+		dynamic.PrependReactor("patch", "deployments", func(k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, err
+		})
+	}
+	reject(apierrors.NewInvalid(schema.GroupKind{Group: "apps", Kind: "Deployment"}, "http", field.ErrorList{
+		field.Invalid(field.NewPath("spec", "template", "spec", "containers").Index(0).Child("image"), "nginx:1.25", "denied by webhook"),
+		field.Required(field.NewPath("metadata", "labels").Key("team"), "required by policy"),
+	}))
+	err := dryRunDeployment().ValidateAgainstCluster(context.Background(), client)
+	fieldErrs := beku.FieldErrors(err)
+	if len(fieldErrs) != 2 || fieldErrs[0].Field != "Deployment.spec.template.spec.containers[0].image" ||
+		fieldErrs[1].Field != "Deployment.metadata.labels[team]" {
+		t.Fatalf("expect the rejected fields as FieldError,got %v", fieldErrs)
+	}
+	reject(apierrors.NewForbidden(gr, "http", nil))
+	if err := dryRunDeployment().ValidateAgainstCluster(context.Background(), client); !apierrors.IsForbidden(err) {
+		t.Fatalf("expect Forbidden,got %v", err)
+	}
+	reject(apierrors.NewNotFound(schema.GroupResource{Resource: "namespaces"}, "roc"))
+	if _, err := client.DryRunApply(context.Background(), mustFinish(t, dryRunDeployment())); !apierrors.IsNotFound(err) {
+		t.Fatalf("expect NotFound,got %v", err)
+	}
+}
+
+func mustFinish(t *testing.T, builder beku.Builder) runtime.Object {
+	t.Helper()
+	obj, err := builder.FinishObject()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return obj
+}
@@ -1,9 +1,11 @@
 package test
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/yulibaozi/beku"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
@@ -39,7 +41,7 @@ func Example_beku_PushApp() {
 	}
 
 	// push Namespace
-	ns, err = client.CoreV1().Namespaces().Create(ns)
+	ns, err = client.CoreV1().Namespaces().Create(context.TODO(), ns, metav1.CreateOptions{})
 	if err != nil {
 		panic(err)
 	}
@@ -52,7 +54,7 @@ func Example_beku_PushApp() {
 	}
 
 	// push Deployment
-	dp, err = client.AppsV1().Deployments(dp.GetNamespace()).Create(dp)
+	dp, err = client.AppsV1().Deployments(dp.GetNamespace()).Create(context.TODO(), dp, metav1.CreateOptions{})
 	if err != nil {
 		panic(err)
 	}
@@ -63,7 +65,7 @@ func Example_beku_PushApp() {
 		panic(err)
 	}
 	//push Service
-	svc, err = client.CoreV1().Services(svc.GetNamespace()).Create(svc)
+	svc, err = client.CoreV1().Services(svc.GetNamespace()).Create(context.TODO(), svc, metav1.CreateOptions{})
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,80 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	"k8s.io/api/core/v1"
+)
+
+func Test_FieldErrors(t *testing.T) {
+	_, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetResourceLimits("two", "").
+		SetStrategyRollingUpdate("0", "0").SetServiceAccount("Bad_Name").Finish()
+	fieldErrs := beku.FieldErrors(err)
+	// the checks of Finish() are run after the failed setters,and all of them are returned
+	if len(fieldErrs) != 5 {
+		t.Fatalf("expect 3 setter errors and 2 errors of Finish, got %v", err)
+	}
+	fields := []string{"SetResourceLimits", "SetStrategyRollingUpdate", "SetServiceAccount",
+		"Deployment.Spec.Template.Labels", "Deployment.Spec.Template.Spec.Containers"}
+	for index, field := range fields {
+		if fieldErrs[index].Field != field {
+			t.Fatalf("expect field %s, got %v", field, fieldErrs[index])
+		}
+	}
+	fieldErrs = beku.NewIngress().Validate()
+	if len(fieldErrs) != 2 || fieldErrs[0].Field != "Ingress.Name" || fieldErrs[1].Field != "Ingress.Spec.Rules" {
+		t.Fatalf("expect the field paths of verify, got %v", fieldErrs)
+	}
+	fieldErrs = beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetContainer("http", "nginx", 8080).SetDNSPolicy("None").Validate()
+	if len(fieldErrs) != 2 || fieldErrs[0].Field != "Deployment.Spec.Template.Spec" {
+		t.Fatalf("expect the errors of the duplicated container and DNS policy, got %v", fieldErrs)
+	}
+	if beku.FieldErrors(nil) != nil {
+		t.Fatal("nil error should have no FieldError")
+	}
+}
+
+func Test_FieldErrorsCollectAll(t *testing.T) {
+	for _, c := range []struct {
+		builder beku.Builder
+		fields  []string
+	}{
+		{beku.NewSvc().SetSelector(map[string]string{"app": "http"}), []string{"Service.Name", "Service.Spec.Ports"}},
+		{beku.NewCM(), []string{"ConfigMap.Name", "ConfigMap.Data"}},
+		{beku.NewSecret(), []string{"Secret.Name", "Secret.Data"}},
+		{beku.NewPV(), []string{"PersistentVolume.Name", "PersistentVolume.Spec.AccessModes",
+			"PersistentVolume.Spec.Capacity", "PersistentVolume.Spec.PersistentVolumeSource"}},
+		{beku.NewPVC(), []string{"PersistentVolumeClaim.Name", "PersistentVolumeClaim.Spec.AccessModes",
+			"PersistentVolumeClaim.Spec.Resources"}},
+		{beku.NewRole(), []string{"Role.Name", "Role.Rules"}},
+		{beku.NewRoleBinding(), []string{"RoleBinding.Name", "RoleBinding.RoleRef", "RoleBinding.Subjects"}},
+		{beku.NewHPA(), []string{"HorizontalPodAutoscaler.Name", "HorizontalPodAutoscaler.Spec.ScaleTargetRef",
+			"HorizontalPodAutoscaler.Spec.MaxReplicas", "HorizontalPodAutoscaler.Spec.Metrics"}},
+	} {
+		fieldErrs := c.builder.Validate()
+		if len(fieldErrs) != len(c.fields) {
+			t.Errorf("%T: expect %v, got %v", c.builder, c.fields, fieldErrs)
+			continue
+		}
+		for index, field := range c.fields {
+			if fieldErrs[index].Field != field {
+				t.Errorf("%T: expect field %s, got %v", c.builder, field, fieldErrs[index])
+			}
+		}
+	}
+}
+
+func Test_RunVerifiersCollectAll(t *testing.T) {
+	failed := func(msg string) func(*v1.ConfigMap) error {
+		return func(*v1.ConfigMap) error { return errors.New(msg) }
+	}
+	_, err := beku.NewCM().SetNamespaceAndName("roc", "http").SetData(map[string]string{"key": "value"}).
+		WithVerifier(failed("no owner label")).WithVerifier(failed("no team annotation")).Finish()
+	if err == nil || !strings.Contains(err.Error(), "no owner label") || !strings.Contains(err.Error(), "no team annotation") {
+		t.Fatalf("all verifiers should be run, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+// fuzzSeeds add the documents of testdata and the malformed ones as the seed corpus
+func fuzzSeeds(f *testing.F) {
+	svc, err := os.ReadFile("testdata/nginx-svc.yaml")
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(svc)
+	for _, seed := range []string{
+		"",
+		"kind: [",
+		"metadata: [[[[[[]]]]]]",
+		"apiVersion: v1\nkind: ConfigMap\nmetadata: {name: app, namespace: web}\ndata: {mode: prod}\n",
+		`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web"},"spec":{"replicas":-1}}`,
+		"a: &a [*a, *a]\nb: *a\n",
+	} {
+		f.Add([]byte(seed))
+	}
+}
+
+// fuzzBuilders are the builders loading the fuzz input,they are new for every input
+func fuzzBuilders() []beku.Builder {
+	return []beku.Builder{beku.NewDeployment(), beku.NewSts(), beku.NewDS(), beku.NewSvc(), beku.NewCM(), beku.NewSecret(),
+		beku.NewPV(), beku.NewPVC(), beku.NewStorageClass()}
+}
+
+func FuzzYAMLNew(f *testing.F) {
+	fuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, builder := range fuzzBuilders() {
+			switch o := builder.(type) {
+			case *beku.Deployment:
+				o.YAMLNew(data)
+			case *beku.StatefulSet:
+				o.YAMLNew(data)
+			case *beku.DaemonSet:
+				o.YAMLNew(data)
+			case *beku.Service:
+				o.YAMLNew(data)
+			case *beku.ConfigMap:
+				o.YAMLNew(data)
+			case *beku.Secret:
+				o.YAMLNew(data)
+			case *beku.PersistentVolume:
+				o.YAMLNew(data)
+			case *beku.PersistentVolumeClaim:
+				o.YAMLNew(data)
+			case *beku.StorageClass:
+				o.YAMLNew(data)
+			}
+			builder.Validate()
+		}
+	})
+}
+
+func FuzzJSONNew(f *testing.F) {
+	fuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, builder := range fuzzBuilders() {
+			switch o := builder.(type) {
+			case *beku.Deployment:
+				o.JSONNew(data)
+			case *beku.StatefulSet:
+				o.JSONNew(data)
+			case *beku.DaemonSet:
+				o.JSONNew(data)
+			case *beku.Service:
+				o.JSONNew(data)
+			case *beku.ConfigMap:
+				o.JSONNew(data)
+			case *beku.Secret:
+				o.JSONNew(data)
+			case *beku.PersistentVolume:
+				o.JSONNew(data)
+			case *beku.PersistentVolumeClaim:
+				o.JSONNew(data)
+			case *beku.StorageClass:
+				o.JSONNew(data)
+			}
+			builder.Validate()
+		}
+	})
+}
+
+func FuzzStreamManifests(f *testing.F) {
+	fuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		beku.StreamManifests(bytes.NewReader(data), func(b beku.Builder) error {
+			b.Validate()
+			return nil
+		})
+	})
+}
@@ -0,0 +1,33 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+func Test_BundleHealth(t *testing.T) {
+	cm, _ := beku.NewCM().SetNamespaceAndName("roc", "http").SetData(map[string]string{"key": "value"}).Finish()
+	dp, _ := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).SetContainer("http", "nginx", 80).Finish()
+	running := dp.DeepCopy()
+	running.Status = appsv1.DeploymentStatus{UpdatedReplicas: 1, AvailableReplicas: 1}
+	client, _ := fakeClient(t, cm, running)
+
+	report, err := beku.NewBundle().Add(cm, dp).Health(context.Background(), client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Healthy() {
+		t.Fatalf("all objects are ready, the bundle should be healthy:%+v", report)
+	}
+	svc, _ := beku.NewSvc().SetNamespaceAndName("roc", "http").SetSelector(map[string]string{"app": "http"}).SetPort(beku.ServicePort{Port: 80}).Finish()
+	report, err = beku.NewBundle().Add(cm, dp, svc).Health(context.Background(), client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Status != beku.HealthNotFound || report.Resources[2].Status != beku.HealthNotFound {
+		t.Fatalf("Service is not created, the bundle should be NotFound:%+v", report)
+	}
+}
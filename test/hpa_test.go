@@ -0,0 +1,40 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_CreateHPA(t *testing.T) {
+	hpa, err := beku.NewHPA().SetNamespaceAndName("roc", "http").SetScaleTargetDeployment("http").SetMinMaxReplicas(2, 10).
+		SetTargetCPUUtilization(80).SetTargetMemoryAverage("512Mi").AddPodsMetric("http_requests_per_second", "100").
+		AddExternalMetric("queue_messages", map[string]string{"queue": "orders"}, "30").SetTargetCPUUtilization(70).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hpa.APIVersion != "autoscaling/v2" || hpa.Spec.ScaleTargetRef.Kind != "Deployment" || *hpa.Spec.MinReplicas != 2 {
+		t.Fatalf("unexpected HPA %v", hpa)
+	}
+	if len(hpa.Spec.Metrics) != 4 || *hpa.Spec.Metrics[0].Resource.Target.AverageUtilization != 70 {
+		t.Fatalf("expect 4 metrics and cpu target replaced by 70, got %v", hpa.Spec.Metrics)
+	}
+	if fieldErrs := beku.NewHPA().SetName("http").SetScaleTargetDeployment("http").SetMinMaxReplicas(5, 2).Validate(); len(fieldErrs) == 0 {
+		t.Fatal("min greater than max should fail")
+	}
+}
+
+func Test_DeploymentAutoscaleTo(t *testing.T) {
+	dp, hpa, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetResourceRequests("500m", "256Mi").AutoscaleTo(2, 10, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hpa.GetNamespace() != dp.GetNamespace() || hpa.Spec.ScaleTargetRef.Name != dp.GetName() || hpa.Spec.MaxReplicas != 10 {
+		t.Fatalf("HPA doesn't target Deployment, got %v", hpa.Spec)
+	}
+	if _, _, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).AutoscaleTo(2, 10, 80); err == nil {
+		t.Fatal("autoscaling without cpu requests should fail")
+	}
+}
@@ -0,0 +1,25 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_CreateIngress(t *testing.T) {
+	ing, err := beku.NewIngress().SetNamespaceAndName("roc", "web").SetIngressClass("nginx").
+		AddRule("example.com", "/", "", "web", 80).AddRule("example.com", "/api", "Prefix", "api", 8080).
+		SetTLS("example-tls", "example.com").AddAnnotation("nginx.ingress.kubernetes.io/ssl-redirect", "true").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ing.APIVersion != "networking.k8s.io/v1" || len(ing.Spec.Rules) != 1 || len(ing.Spec.Rules[0].HTTP.Paths) != 2 {
+		t.Fatalf("expect one rule of two paths, got %v", ing.Spec.Rules)
+	}
+	if *ing.Spec.Rules[0].HTTP.Paths[0].PathType != "Prefix" || ing.Spec.Rules[0].HTTP.Paths[1].Backend.Service.Port.Number != 8080 {
+		t.Fatalf("expect default Prefix path type and api port 8080, got %v", ing.Spec.Rules[0].HTTP.Paths)
+	}
+	if _, err := beku.NewIngress().SetName("web").AddRule("example.com", "/", "", "web", 80).SetTLS("tls", "other.com").Finish(); err == nil {
+		t.Fatal("TLS host without rule should fail")
+	}
+}
@@ -0,0 +1,40 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	"k8s.io/api/core/v1"
+)
+
+func Test_CreateJob(t *testing.T) {
+	job, err := beku.NewJob().SetNamespaceAndName("roc", "migrate").SetContainer("migrate", "migrate:v1").
+		SetEnvs(map[string]string{"DB": "mysql"}).SetCompletions(1).SetBackoffLimit(3).SetTTLAfterFinished(600).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Kind != "Job" || job.Spec.Template.Spec.RestartPolicy != v1.RestartPolicyOnFailure {
+		t.Fatalf("expect Job with default restart policy OnFailure, got %s %s", job.Kind, job.Spec.Template.Spec.RestartPolicy)
+	}
+	if *job.Spec.BackoffLimit != 3 || *job.Spec.TTLSecondsAfterFinished != 600 {
+		t.Fatalf("expect backoffLimit 3 and ttl 600, got %d %d", *job.Spec.BackoffLimit, *job.Spec.TTLSecondsAfterFinished)
+	}
+	if _, err := beku.NewJob().SetName("migrate").SetRestartPolicy("Always").Finish(); err == nil {
+		t.Fatal("restart policy Always should not be allowed by Job")
+	}
+}
+
+func Test_CreateCronJob(t *testing.T) {
+	job := beku.NewJob().SetContainer("backup", "backup:v1").SetRestartPolicy("Never")
+	cj, err := beku.NewCronJob().SetNamespaceAndName("roc", "backup").SetSchedule("0 3 * * *").
+		SetConcurrencyPolicy("Forbid").SetJobTemplate(job).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cj.Spec.ConcurrencyPolicy != "Forbid" || cj.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image != "backup:v1" {
+		t.Fatalf("expect CronJob with Forbid policy and Job template, got %v", cj.Spec)
+	}
+	if _, err := beku.NewCronJob().SetName("backup").SetSchedule("every day").SetJobTemplate(job).Finish(); err == nil {
+		t.Fatal("schedule without 5 fields should not be allowed")
+	}
+}
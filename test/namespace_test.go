@@ -0,0 +1,25 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_NamespacePodSecurityLevel(t *testing.T) {
+	ns, err := beku.NewNs().SetName("roc").SetPodSecurityLevel("baseline", "restricted:v1.28", "restricted").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels := ns.GetLabels()
+	if labels["pod-security.kubernetes.io/enforce"] != "baseline" || labels["pod-security.kubernetes.io/audit"] != "restricted" ||
+		labels["pod-security.kubernetes.io/audit-version"] != "v1.28" || labels["pod-security.kubernetes.io/warn"] != "restricted" {
+		t.Fatalf("unexpected pod security labels:%v", labels)
+	}
+	if _, err := beku.NewNs().SetName("roc").SetPodSecurityLevel("strict", "", "").Finish(); err == nil {
+		t.Fatal("unknown level should be error")
+	}
+	if _, err := beku.NewNs().SetName("roc").SetPodSecurityLevel("restricted:1.28", "", "").Finish(); err == nil {
+		t.Fatal("version without v prefix should be error")
+	}
+}
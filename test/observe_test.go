@@ -0,0 +1,54 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// recordObserver record the observations and their errors
+type recordObserver struct {
+	sync.Mutex
+	observations []beku.Observation
+	errs         []error
+}
+
+func (o *recordObserver) Start(ctx context.Context, observation beku.Observation) (context.Context, func(err error)) {
+	return ctx, func(err error) {
+		o.Lock()
+		defer o.Unlock()
+		o.observations = append(o.observations, observation)
+		o.errs = append(o.errs, err)
+	}
+}
+
+func Test_Observer(t *testing.T) {
+	observer := &recordObserver{}
+	beku.RegisterObserver(observer)
+	defer beku.RegisterObserver(nil)
+	bundle := beku.NewBundle().AddBuilders(
+		beku.NewCM().SetNamespaceAndName("roc", "http").SetData(map[string]string{"key": "value"}),
+	)
+	if err := bundle.FinishAll(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(observer.observations) != 1 || observer.observations[0] != (beku.Observation{Operation: beku.ObserveFinish, Kind: "ConfigMap"}) {
+		t.Fatalf("unexpected observations:%v", observer.observations)
+	}
+	client, dynamic := fakeClient(t)
+	dynamic.PrependReactor("create", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("admission webhook denied the request")
+	})
+	if err := bundle.ApplyAtomic(context.Background(), client); err == nil {
+		t.Fatal("ConfigMap is denied, ApplyAtomic should return error")
+	}
+	apply := beku.Observation{Operation: beku.ObserveApply, Kind: "ConfigMap", Namespace: "roc", Name: "http"}
+	if len(observer.observations) != 2 || observer.observations[1] != apply || observer.errs[1] == nil {
+		t.Fatalf("the failed apply should be observed with its error:%v %v", observer.observations, observer.errs)
+	}
+}
@@ -0,0 +1,54 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_ClientRollbackToRevision(t *testing.T) {
+	live, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx:1.25", 80).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	live.UID = "dp-uid"
+	owner := *metav1.NewControllerRef(live, appsv1.SchemeGroupVersion.WithKind("Deployment"))
+	replicaSet := func(name, revision, image, cause string) *appsv1.ReplicaSet {
+		rs := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "roc",
+			Name:            name,
+			Labels:          map[string]string{"app": "http"},
+			Annotations:     map[string]string{beku.RevisionAnnotation: revision, beku.ChangeCauseAnnotation: cause},
+			OwnerReferences: []metav1.OwnerReference{owner},
+		}}
+		live.Spec.Template.DeepCopyInto(&rs.Spec.Template)
+		rs.Spec.Template.Spec.Containers[0].Image = image
+		rs.Spec.Template.Labels[appsv1.DefaultDeploymentUniqueLabelKey] = name
+		return rs
+	}
+	kube := fake.NewSimpleClientset(live, replicaSet("http-1", "1", "nginx:1.24", "first"), replicaSet("http-2", "2", "nginx:1.25", "second"))
+	client, err := beku.NewClient(kube, dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dp, err := client.RollbackToRevision(context.Background(), live, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dp.Spec.Template.Spec.Containers[0].Image != "nginx:1.24" || dp.Annotations[beku.ChangeCauseAnnotation] != "first" {
+		t.Fatalf("expect rollback to revision 1 of nginx:1.24,got %s %v", dp.Spec.Template.Spec.Containers[0].Image, dp.Annotations)
+	}
+	if _, ok := dp.Spec.Template.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; ok {
+		t.Fatal("pod-template-hash should not be copied into Deployment")
+	}
+	if _, err := client.RollbackToRevision(context.Background(), live, 5); err == nil {
+		t.Fatal("revision 5 doesn't exist, RollbackToRevision should return error")
+	}
+}
@@ -0,0 +1,43 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+func webProgram() *beku.Parameterized {
+	return beku.Parameterize(func(p *beku.Params) beku.Builder {
+		return beku.NewDeployment().SetNamespaceAndName(p.String("env"), "web").SetReplicas(p.Int32("replicas")).
+			SetPodLabels(p.StringMap("labels")).SetContainer("web", p.String("image"), 80)
+	}).Default("replicas", 1).Default("labels", map[string]string{"app": "web"})
+}
+
+func Test_ParameterizedRender(t *testing.T) {
+	obj, err := webProgram().RenderYAML([]byte("env: prod\nreplicas: 3\nimage: nginx:1.25\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dp := obj.(*appsv1.Deployment)
+	if dp.Namespace != "prod" || *dp.Spec.Replicas != 3 || dp.Spec.Template.Spec.Containers[0].Image != "nginx:1.25" {
+		t.Fatalf("the values of prod are not rendered:%v", dp)
+	}
+	if obj, err = webProgram().Render(map[string]interface{}{"env": "dev", "image": "nginx"}); err != nil {
+		t.Fatal(err)
+	}
+	if *obj.(*appsv1.Deployment).Spec.Replicas != 1 {
+		t.Fatal("the default replicas should be used")
+	}
+}
+
+func Test_ParameterizedRenderErrors(t *testing.T) {
+	_, err := webProgram().Render(map[string]interface{}{"env": "dev", "image": "nginx", "replicas": "3", "replica": 2})
+	fields := map[string]bool{}
+	for _, fieldErr := range beku.FieldErrors(err) {
+		fields[fieldErr.Field] = true
+	}
+	if !fields["param.replicas"] || !fields["param.replica"] {
+		t.Fatalf("the string replicas and the unknown replica should be rejected,got %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_PDB(t *testing.T) {
+	pdb, err := beku.NewPDB().SetNamespaceAndName("roc", "http").SetSelector(map[string]string{"app": "http"}).
+		SetMaxUnavailable("25%").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pdb.Kind != "PodDisruptionBudget" || pdb.Spec.MaxUnavailable.String() != "25%" {
+		t.Fatalf("unexpected PodDisruptionBudget:%v", pdb)
+	}
+	_, err = beku.NewPDB().SetNamespaceAndName("roc", "http").SetSelector(map[string]string{"app": "http"}).
+		SetMinAvailable("1").SetMaxUnavailable("1").Finish()
+	if err == nil {
+		t.Fatal("both minAvailable and maxUnavailable should be error")
+	}
+}
+
+func Test_DeploymentTopologySpreadConstraint(t *testing.T) {
+	dp, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetTopologySpreadConstraint(1, "kubernetes.io/hostname", "DoNotSchedule").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	constraints := dp.Spec.Template.Spec.TopologySpreadConstraints
+	if len(constraints) != 1 || constraints[0].WhenUnsatisfiable != "DoNotSchedule" || constraints[0].LabelSelector.MatchLabels["app"] != "http" {
+		t.Fatalf("unexpected topology spread constraints:%v", constraints)
+	}
+}
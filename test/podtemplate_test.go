@@ -0,0 +1,64 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_PodTemplate(t *testing.T) {
+	tpl := beku.NewPodTemplate().SetLabels(map[string]string{"app": "api"}).SetContainer("api", "api:v1", 8080).
+		SetEnvs(map[string]string{"MODE": "prod"}).SetHTTPReadness(8080, "/ready", 5, 1, 5)
+	dp, err := beku.NewDeployment().SetNamespaceAndName("roc", "api").SetPodTemplate(tpl).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dp.Spec.Selector.MatchLabels["app"] != "api" || dp.Spec.Template.Spec.Containers[0].ReadinessProbe == nil {
+		t.Fatalf("unexpected deployment:%v", dp.Spec)
+	}
+	sts, err := beku.NewSts().SetNamespaceAndName("roc", "api").SetPodTemplate(tpl).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if &sts.Spec.Template.Spec.Containers[0] == &dp.Spec.Template.Spec.Containers[0] {
+		t.Fatal("PodTemplate should be copied into every workload")
+	}
+	_, err = beku.NewDeployment().SetNamespaceAndName("roc", "api").SetPodTemplate(beku.NewPodTemplate()).Finish()
+	if err == nil {
+		t.Fatal("PodTemplate without container should be error")
+	}
+}
+
+func Test_PodSettersBoundToBuilder(t *testing.T) {
+	base := beku.NewDeployment().SetNamespaceAndName("roc", "api").SetPodLabels(map[string]string{"app": "api"}).
+		SetContainer("api", "api:v1", 8080)
+	clone := base.Clone().SetServiceAccount("clone")
+	dp, err := base.SetServiceAccount("base").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cloneDp, err := clone.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dp.Spec.Template.Spec.ServiceAccountName != "base" || cloneDp.Spec.Template.Spec.ServiceAccountName != "clone" {
+		t.Fatalf("the setters of the clone should change the clone only:%s,%s",
+			dp.Spec.Template.Spec.ServiceAccountName, cloneDp.Spec.Template.Spec.ServiceAccountName)
+	}
+	reset, err := base.Reset().SetNamespaceAndName("roc", "web").SetPodLabels(map[string]string{"app": "web"}).
+		SetContainer("web", "web:v1", 80).SetServiceAccount("web").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reset.Spec.Template.Spec.ServiceAccountName != "web" {
+		t.Fatalf("the setters should be bound after Reset():%s", reset.Spec.Template.Spec.ServiceAccountName)
+	}
+	job, err := beku.NewJob().SetNamespaceAndName("roc", "migrate").SetContainer("migrate", "migrate:v1").
+		SetServiceAccount("migrate").SetNodeSelector(map[string]string{"pool": "batch"}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Spec.Template.Spec.ServiceAccountName != "migrate" || job.Spec.Template.Spec.NodeSelector["pool"] != "batch" {
+		t.Fatalf("unexpected job:%v", job.Spec.Template.Spec)
+	}
+}
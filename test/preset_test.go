@@ -0,0 +1,35 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_DeploymentPreset(t *testing.T) {
+	builder := beku.NewDeployment().SetNamespaceAndName("roc", "web").SetPodLabels(map[string]string{"app": "web"}).
+		WithPreset(beku.ProductionWebService).SetContainer("web", "web:v1", 8080).
+		SetResourceRequst(map[beku.ResourceName]string{beku.ResourceCPU: "500m"})
+	dp, err := builder.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := dp.Spec.Template.Spec
+	requests := spec.Containers[0].Resources.Requests
+	if requests.Cpu().String() != "500m" || requests.Memory().String() != "128Mi" {
+		t.Fatalf("the setter should override the preset,unexpected requests:%v", requests)
+	}
+	if spec.Containers[0].LivenessProbe == nil || spec.Containers[0].ReadinessProbe.HTTPGet.Path != "/healthz" {
+		t.Fatalf("unexpected probes:%v", spec.Containers[0])
+	}
+	if spec.Affinity == nil || spec.Affinity.PodAntiAffinity == nil || spec.SecurityContext == nil || !*spec.SecurityContext.RunAsNonRoot {
+		t.Fatalf("unexpected Pod spec:%v", spec)
+	}
+	pdb, err := builder.PDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pdb.Spec.MaxUnavailable.IntValue() != 1 || pdb.Spec.Selector.MatchLabels["app"] != "web" {
+		t.Fatalf("unexpected PodDisruptionBudget:%v", pdb)
+	}
+}
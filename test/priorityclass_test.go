@@ -0,0 +1,39 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_PriorityClass(t *testing.T) {
+	pc, err := beku.NewPriorityClass().SetNameAnddValue("batch-low", 1000).SetPreemptionPolicy("Never").
+		SetDescription("batch jobs which never preempt").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pc.APIVersion != "scheduling.k8s.io/v1" || pc.Value != 1000 || string(*pc.PreemptionPolicy) != "Never" {
+		t.Fatalf("expect scheduling.k8s.io/v1 PriorityClass of 1000 and Never,got %v", pc)
+	}
+	if _, err := beku.NewPriorityClass().SetNameAnddValue("system-high", 2000000000).Finish(); err == nil {
+		t.Fatal("value over 1000000000 is reserved, Finish should return error")
+	}
+}
+
+func Test_DeploymentSchedulingKnobs(t *testing.T) {
+	dp, err := beku.NewDeployment().SetNamespaceAndName("roc", "sandbox").SetPodLabels(map[string]string{"app": "sandbox"}).
+		SetContainer("sandbox", "nginx", 80).SetPriorityClassName("batch-low").SetRuntimeClassName("gvisor").
+		SetTerminationGracePeriodSeconds(60).SetSchedulerName("bin-packing").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod := dp.Spec.Template.Spec
+	if pod.PriorityClassName != "batch-low" || *pod.RuntimeClassName != "gvisor" || *pod.TerminationGracePeriodSeconds != 60 || pod.SchedulerName != "bin-packing" {
+		t.Fatalf("the scheduling knobs are not set:%v", pod)
+	}
+	fieldErrs := beku.NewDeployment().SetNamespaceAndName("roc", "batch").SetPodLabels(map[string]string{"app": "batch"}).
+		SetContainer("batch", "batch:v1", 80).SetTerminationGracePeriodSeconds(-1).SetRuntimeClassName("Not_Valid").Validate()
+	if len(fieldErrs) != 2 {
+		t.Fatalf("expect 2 errors of the negative grace period and invalid runtime class,got %v", fieldErrs)
+	}
+}
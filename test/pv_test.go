@@ -18,3 +18,25 @@ func Test_CreatePV(t *testing.T) {
 	}
 	t.Log(string(databyts))
 }
+
+func Test_PVSources(t *testing.T) {
+	pv, err := beku.NewPV().SetName("local").SetCapacity(map[beku.ResourceName]string{beku.ResourceStorage: "5Gi"}).SetAccessMode(beku.ReadWriteOnce).
+		SetHostPath("/data/local", "DirectoryOrCreate").SetStorageClassName("manual").SetVolumeMode(beku.PersistentVolumeFilesystem).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pv.Spec.HostPath.Path != "/data/local" || pv.Spec.StorageClassName != "manual" {
+		t.Fatalf("unexpected hostPath PV %v", pv.Spec)
+	}
+	pv, err = beku.NewPV().SetName("ebs").SetCapacity(map[beku.ResourceName]string{beku.ResourceStorage: "10Gi"}).SetAccessMode(beku.ReadWriteOnce).
+		SetCSI("ebs.csi.aws.com", "vol-0123", "ext4", nil).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pv.Spec.CSI.Driver != "ebs.csi.aws.com" || pv.Spec.CSI.VolumeHandle != "vol-0123" {
+		t.Fatalf("unexpected CSI PV %v", pv.Spec)
+	}
+	if fieldErrs := beku.NewPV().SetHostPath("data", "").Validate(); len(fieldErrs) == 0 {
+		t.Fatal("relative hostPath should fail")
+	}
+}
@@ -1,6 +1,7 @@
 package test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/yulibaozi/beku"
@@ -19,3 +20,76 @@ func Test_PVCCreate(t *testing.T) {
 	t.Log(string(databyts))
 
 }
+
+func Test_PVCMatchExpressions(t *testing.T) {
+	data, err := beku.NewPVC().SetName("yulibaozi-pv").SetAccessMode(beku.ReadWriteOnce).
+		SetResourceRequests(map[beku.ResourceName]string{beku.ResourceStorage: "5Gi"}).
+		MatchIn("env", "prod", "stage").MatchExists("team").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Spec.Selector.MatchExpressions) != 2 {
+		t.Fatalf("expect 2 match expressions, got %d", len(data.Spec.Selector.MatchExpressions))
+	}
+	_, err = beku.NewPVC().SetName("yulibaozi-pv").SetAccessMode(beku.ReadWriteOnce).
+		SetResourceRequests(map[beku.ResourceName]string{beku.ResourceStorage: "5Gi"}).MatchNotIn("env").Finish()
+	if err == nil {
+		t.Fatal("MatchNotIn without values should return error")
+	}
+}
+
+func Test_PVCAccessModes(t *testing.T) {
+	storage := map[beku.ResourceName]string{beku.ResourceStorage: "5Gi"}
+	if _, err := beku.NewPVC().SetName("data").SetAccessMode("RW").SetResourceRequests(storage).Finish(); err == nil {
+		t.Fatal("unknown access mode should be error")
+	}
+	if _, err := beku.NewPVC().SetName("data").SetAccessModes([]beku.PersistentVolumeAccessMode{beku.RWOP, beku.RWO}).SetResourceRequests(storage).Finish(); err == nil {
+		t.Fatal("ReadWriteOncePod with other modes should be error")
+	}
+	if err := beku.RegisterKubernetesVersion("v1.26.5"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := beku.NewPVC().SetName("data").SetAccessMode(beku.RWOP).SetResourceRequests(storage).Finish()
+	if err == nil {
+		t.Fatal("ReadWriteOncePod on Kubernetes 1.26 should be error")
+	}
+	if err := beku.RegisterKubernetesVersion("v1.29"); err != nil {
+		t.Fatal(err)
+	}
+	pvc, err := beku.NewPVC().SetName("data").SetAccessMode(beku.RWOP).SetResourceRequests(storage).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pvc.Spec.AccessModes[0] != "ReadWriteOncePod" {
+		t.Fatalf("expect ReadWriteOncePod, got %v", pvc.Spec.AccessModes)
+	}
+}
+
+func Test_PVCRequestStorage(t *testing.T) {
+	pvc, err := beku.NewPVC().SetName("data").SetAccessMode(beku.ReadWriteOnce).SetStorageClass("standard").SetRequestStorage("10Gi").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if storage := pvc.Spec.Resources.Requests.Storage(); storage.String() != "10Gi" || *pvc.Spec.StorageClassName != "standard" {
+		t.Fatalf("expect 10Gi of standard class, got %v", pvc.Spec)
+	}
+	if fieldErrs := beku.NewPVC().SetName("data").SetRequestStorage("0").Validate(); len(fieldErrs) == 0 {
+		t.Fatal("zero storage should fail")
+	}
+}
+
+func Test_PVCString(t *testing.T) {
+	pvc := beku.NewPVC().SetNamespaceAndName("roc", "data").SetAccessMode(beku.ReadWriteOnce).SetRequestStorage("10Gi")
+	dump := pvc.Dump().String()
+	if !strings.HasPrefix(dump, "# beku PersistentVolumeClaim\n") || strings.Contains(dump, "# error:") ||
+		!strings.Contains(dump, "name: data") || !strings.Contains(dump, "storage: 10Gi") {
+		t.Fatalf("unexpected dump of PersistentVolumeClaim:\n%s", dump)
+	}
+	if _, err := pvc.Finish(); err != nil {
+		t.Fatalf("String() and Dump() should not change the builder,got %v", err)
+	}
+	dump = beku.NewPVC().SetName("data").SetRequestStorage("abc").String()
+	if !strings.Contains(dump, "# error:") || !strings.Contains(dump, "name: data") {
+		t.Fatalf("the pending error should be written on the top,got:\n%s", dump)
+	}
+}
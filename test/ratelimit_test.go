@@ -0,0 +1,29 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_RegisterRateLimit(t *testing.T) {
+	if err := beku.RegisterRateLimit(-1, 10); err == nil {
+		t.Fatal("negative qps should be error")
+	}
+	if err := beku.RegisterOperationRateLimit("watch", 1, 1); err == nil {
+		t.Fatal("unknown operation should be error")
+	}
+	if err := beku.RegisterRateLimit(50, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := beku.RegisterOperationRateLimit(beku.OperationWrite, 2, 4); err != nil {
+		t.Fatal(err)
+	}
+	// remove the limits,so the other tests are not affected
+	if err := beku.RegisterRateLimit(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := beku.RegisterOperationRateLimit(beku.OperationWrite, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+}
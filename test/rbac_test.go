@@ -0,0 +1,44 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_CreateRBAC(t *testing.T) {
+	sa, err := beku.NewServiceAccount().SetNamespaceAndName("roc", "reader").SetAutomountToken(false).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	role, err := beku.NewRole().SetNamespaceAndName("roc", "pod-reader").
+		AddRule([]string{"get", "list", "watch"}, nil, []string{"pods", "pods/log"}).
+		AddResourceNameRule([]string{"get"}, []string{""}, []string{"configmaps"}, "app-config").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(role.Rules) != 2 || role.Rules[0].APIGroups[0] != "" || role.Rules[1].ResourceNames[0] != "app-config" {
+		t.Fatalf("unexpected rules %v", role.Rules)
+	}
+	rb, err := beku.NewRoleBinding().SetNamespaceAndName("roc", "pod-reader").SetRole(role.GetName()).
+		BindServiceAccount("", sa.GetName()).BindServiceAccount("", sa.GetName()).BindUser("jane").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rb.Subjects) != 2 || rb.Subjects[0].Namespace != "roc" || rb.Subjects[1].APIGroup != "rbac.authorization.k8s.io" {
+		t.Fatalf("unexpected subjects %v", rb.Subjects)
+	}
+	crb, err := beku.NewClusterRoleBinding().SetName("node-reader").SetClusterRole("node-reader").BindServiceAccount("roc", "reader").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crb.RoleRef.Kind != "ClusterRole" || crb.Subjects[0].Namespace != "roc" {
+		t.Fatalf("unexpected ClusterRoleBinding %v", crb)
+	}
+	if fieldErrs := beku.NewClusterRoleBinding().SetName("node-reader").SetClusterRole("node-reader").BindServiceAccount("", "reader").Validate(); len(fieldErrs) == 0 {
+		t.Fatal("ServiceAccount without namespace in ClusterRoleBinding should fail")
+	}
+	if fieldErrs := beku.NewClusterRole().SetName("empty").Validate(); len(fieldErrs) == 0 {
+		t.Fatal("ClusterRole without rules should fail")
+	}
+}
@@ -0,0 +1,30 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_DeploymentApplyRecreate(t *testing.T) {
+	old, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, _ := fakeClient(t, old)
+	builder := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http", "tier": "web"}).
+		SetContainer("http", "nginx", 80)
+	if _, err := builder.ApplyRecreate(context.Background(), client); err != nil {
+		t.Fatal(err)
+	}
+	current, err := client.Get(context.TODO(), old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tier, _, _ := unstructured.NestedString(current.Object, "spec", "selector", "matchLabels", "tier"); tier != "web" {
+		t.Fatalf("expect Deployment recreated with new selector, got %v", current.Object["spec"])
+	}
+}
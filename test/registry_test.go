@@ -0,0 +1,74 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+func Test_RegisterRegistryRewrite(t *testing.T) {
+	if err := beku.RegisterRegistryRewrite("docker.io", "registry.internal/mirror"); err != nil {
+		t.Fatal(err)
+	}
+	defer beku.RegisterRegistryRewrite("docker.io", "")
+	dep, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx:1.25", 80).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image := dep.Spec.Template.Spec.Containers[0].Image; image != "registry.internal/mirror/library/nginx:1.25" {
+		t.Fatalf("expect registry.internal/mirror/library/nginx:1.25, got %s", image)
+	}
+	dep, err = beku.NewDeployment().SetNamespaceAndName("roc", "etcd").SetPodLabels(map[string]string{"app": "etcd"}).
+		SetContainer("etcd", "quay.io/coreos/etcd:v3.5", 2379).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image := dep.Spec.Template.Spec.Containers[0].Image; image != "quay.io/coreos/etcd:v3.5" {
+		t.Fatalf("the image of other registry should not be rewritten, got %s", image)
+	}
+}
+
+func Test_BundleSetRegistryRewrite(t *testing.T) {
+	bundle := beku.NewBundle().SetRegistryRewrite("quay.io/", "registry.internal/quay").AddBuilders(
+		beku.NewDeployment().SetNamespaceAndName("roc", "etcd").SetPodLabels(map[string]string{"app": "etcd"}).
+			SetContainer("etcd", "quay.io/coreos/etcd:v3.5", 2379),
+	)
+	if err := bundle.FinishAll(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	dep := bundle.Objects()[0].(*appsv1.Deployment)
+	if image := dep.Spec.Template.Spec.Containers[0].Image; image != "registry.internal/quay/coreos/etcd:v3.5" {
+		t.Fatalf("expect registry.internal/quay/coreos/etcd:v3.5, got %s", image)
+	}
+	if err := beku.NewBundle().SetRegistryRewrite("", "registry.internal").FinishAll(context.Background(), 1); err == nil {
+		t.Fatal("empty from should be error")
+	}
+}
+
+func Test_BundleSetRegistryRewriteTemplate(t *testing.T) {
+	tpl, err := beku.NewTemplate(beku.NewDeployment().SetNamespaceAndName("roc", "etcd").SetPodLabels(map[string]string{"app": "etcd"}).
+		SetContainer("etcd", "quay.io/coreos/etcd:v3.5", 2379))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle := beku.NewBundle().SetRegistryRewrite("quay.io/", "registry.internal/quay").
+		AddBuilders(tpl.Instantiate("etcd-a", "roc"), tpl.Instantiate("etcd-b", "roc"))
+	if err := bundle.FinishAll(context.Background(), 2); err != nil {
+		t.Fatal(err)
+	}
+	for _, obj := range bundle.Objects() {
+		if image := obj.(*appsv1.Deployment).Spec.Template.Spec.Containers[0].Image; image != "registry.internal/quay/coreos/etcd:v3.5" {
+			t.Fatalf("expect registry.internal/quay/coreos/etcd:v3.5, got %s", image)
+		}
+	}
+	dp, err := tpl.Instantiate("etcd-c", "roc").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image := dp.Spec.Template.Spec.Containers[0].Image; image != "quay.io/coreos/etcd:v3.5" {
+		t.Fatalf("the rewrite of Bundle should not change Template, got %s", image)
+	}
+}
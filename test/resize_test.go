@@ -0,0 +1,49 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func Test_ResizePolicyAndResize(t *testing.T) {
+	builder := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetResizePolicy("http", beku.ResizeNotRequired, beku.ResizeRestartContainer)
+	dp, err := builder.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	policies := dp.Spec.Template.Spec.Containers[0].ResizePolicy
+	if len(policies) != 2 {
+		t.Fatalf("expect 2 resize policies, got %v", policies)
+	}
+	if _, err := beku.NewDeployment().SetContainer("http", "nginx", 80).SetResizePolicy("missing", beku.ResizeNotRequired, "").Finish(); err == nil {
+		t.Fatal("missing container should be error")
+	}
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "roc", Name: "http-a", Labels: map[string]string{"app": "http"}},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "http", Image: "nginx"}}},
+	}
+	client := fake.NewSimpleClientset(pod)
+	var subresources []string
+	client.PrependReactor("patch", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		subresources = append(subresources, action.GetSubresource())
+		return true, pod, nil
+	})
+	err = builder.Resize(context.Background(), client, "http", beku.ResourceRequirements{
+		Limits: map[beku.ResourceName]string{beku.ResourceCPU: "2"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subresources) != 1 || subresources[0] != "resize" {
+		t.Fatalf("expect Pod is patched by resize subresource, got %v", subresources)
+	}
+}
@@ -0,0 +1,42 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_DeploymentChangeCauseAndHistory(t *testing.T) {
+	dp, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetChangeCause("release v2").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dp.Annotations[beku.ChangeCauseAnnotation] != "release v2" {
+		t.Fatalf("change-cause is not set:%v", dp.Annotations)
+	}
+	if _, err := beku.NewDeployment().SetChangeCause("").Finish(); err == nil {
+		t.Fatal("empty change-cause should be error")
+	}
+
+	dp.UID = "dp-uid"
+	controller := true
+	owner := []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "http", UID: dp.UID, Controller: &controller}}
+	replicaSet := func(name, revision, cause string) *appsv1.ReplicaSet {
+		return &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roc", Name: name, Labels: map[string]string{"app": "http"}, OwnerReferences: owner,
+			Annotations: map[string]string{beku.RevisionAnnotation: revision, beku.ChangeCauseAnnotation: cause},
+		}}
+	}
+	client := fake.NewSimpleClientset(dp, replicaSet("http-2", "2", "release v2"), replicaSet("http-1", "1", "release v1"))
+	history, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").History(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Revision != 1 || history[1].ChangeCause != "release v2" {
+		t.Fatalf("unexpected history:%+v", history)
+	}
+}
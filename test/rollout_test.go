@@ -0,0 +1,43 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_RolloutFromDeployment(t *testing.T) {
+	dp, err := beku.NewDeployment().SetNamespaceAndName("roc", "nginx").SetSelector(map[string]string{"app": "nginx"}).
+		SetContainer("nginx", "nginx:1.15", 80).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ro, err := beku.NewRollout().FromDeployment(dp).AddCanaryWeight(20).AddCanaryPause(time.Minute).
+		AddCanaryAnalysis("success-rate").AddCanaryWeight(100).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ro.Spec.Strategy.Canary == nil || len(ro.Spec.Strategy.Canary.Steps) != 4 {
+		t.Fatal("the canary steps are not added")
+	}
+	if ro.Spec.Template.Spec.Containers[0].Image != "nginx:1.15" {
+		t.Fatalf("the pod template is not kept,got image %s", ro.Spec.Template.Spec.Containers[0].Image)
+	}
+	_, err = beku.NewRollout().FromDeployment(dp).SetCanaryBackgroundAnalysis(3, "success-rate").Finish()
+	if err == nil {
+		t.Fatal("startingStep is out of the canary steps, Finish should return error")
+	}
+}
+
+func Test_RolloutBlueGreen(t *testing.T) {
+	ro, err := beku.NewRollout().SetNamespaceAndName("roc", "nginx").SetSelector(map[string]string{"app": "nginx"}).
+		SetContainer("nginx", "nginx:1.15", 80).AddCanaryWeight(50).
+		SetBlueGreen("nginx-active", "nginx-preview", false).SetPrePromotionAnalysis("smoke").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ro.Spec.Strategy.Canary != nil {
+		t.Fatal("canary strategy should be removed by SetBlueGreen()")
+	}
+}
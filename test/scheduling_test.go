@@ -0,0 +1,111 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_RunOnSpot(t *testing.T) {
+	if err := beku.RegisterSpotProvider(beku.SpotProviderGKE); err != nil {
+		t.Fatal(err)
+	}
+	dep, err := beku.NewDeployment().SetNamespaceAndName("roc", "worker").SetPodLabels(map[string]string{"app": "worker"}).
+		SetContainer("worker", "worker:v1", 8080).RunOnSpot(false).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := dep.Spec.Template.Spec
+	preferred := spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	if len(preferred) != 1 || preferred[0].Preference.MatchExpressions[0].Key != "cloud.google.com/gke-spot" {
+		t.Fatalf("expect preferred spot node affinity, got %v", preferred)
+	}
+	if len(spec.Tolerations) != 1 || spec.Tolerations[0].Key != "cloud.google.com/gke-spot" {
+		t.Fatalf("expect spot toleration, got %v", spec.Tolerations)
+	}
+	found := false
+	for _, warning := range beku.Lint(dep).Warnings {
+		found = found || strings.Contains(warning, "PodDisruptionBudget")
+	}
+	if !found {
+		t.Fatal("Lint should suggest PodDisruptionBudget for Pod on spot nodes")
+	}
+}
+
+func Test_SpreadAcrossZones(t *testing.T) {
+	sts, err := beku.NewSts().SetNamespaceAndName("roc", "db").SetPodLabels(map[string]string{"app": "db"}).
+		SetContainer("db", "postgres", 5432).SpreadAcrossZones(1).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	constraints := sts.Spec.Template.Spec.TopologySpreadConstraints
+	if len(constraints) != 1 || constraints[0].TopologyKey != beku.ZoneLabel || constraints[0].LabelSelector.MatchLabels["app"] != "db" {
+		t.Fatalf("expect zone spread constraint selecting app=db, got %v", constraints)
+	}
+	if _, err := beku.NewDeployment().SpreadAcrossZones(1).Finish(); err == nil {
+		t.Fatal("SpreadAcrossZones without Pod labels should be error")
+	}
+}
+
+func Test_DaemonSetHostPort(t *testing.T) {
+	ds, err := beku.NewDS().SetNamespaceAndName("ingress", "nginx").SetPodLabels(map[string]string{"app": "nginx"}).
+		SetContainer("nginx", "nginx-ingress", 80).SetHostPort("nginx", 80, 80).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port := ds.Spec.Template.Spec.Containers[0].Ports[0]; port.HostPort != 80 {
+		t.Fatalf("expect hostPort 80, got %v", port)
+	}
+	if _, err := beku.NewDS().SetContainer("nginx", "nginx-ingress", 80).SetHostPort("nginx", 443, 443).Finish(); err == nil {
+		t.Fatal("hostPort of undeclared port should be error")
+	}
+	_, err = beku.NewDeployment().YAMLNew([]byte("spec:\n  template:\n    spec:\n      containers:\n      - name: nginx\n        image: nginx\n        ports:\n        - containerPort: 80\n          hostPort: 80\n")).
+		SetNamespaceAndName("ingress", "nginx").SetPodLabels(map[string]string{"app": "nginx"}).Finish()
+	if err == nil || !strings.Contains(err.Error(), "hostPort") {
+		t.Fatalf("hostPort in Deployment should be error, got %v", err)
+	}
+}
+
+func Test_SetNodeName(t *testing.T) {
+	dep, err := beku.NewDeployment().SetNamespaceAndName("roc", "debug").SetPodLabels(map[string]string{"app": "debug"}).
+		SetContainer("debug", "busybox", 8080).SetNodeName("node-1").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dep.Spec.Template.Spec.NodeName != "node-1" {
+		t.Fatalf("expect nodeName node-1, got %s", dep.Spec.Template.Spec.NodeName)
+	}
+	if _, err := beku.NewDeployment().SetNodeName("Node_1").Finish(); err == nil {
+		t.Fatal("invalid node name should be error")
+	}
+}
+
+func Test_DeploymentAffinityAndTolerations(t *testing.T) {
+	labels := map[string]string{"app": "http"}
+	dp, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(labels).SetContainer("http", "nginx", 80).
+		SetNodeSelector(map[string]string{"disktype": "ssd"}).SetNodeAffinity("node.kubernetes.io/instance-type", "In", "m5.large").
+		PreferPodAffinity(50, "kubernetes.io/hostname", map[string]string{"app": "cache"}).SetPodAntiAffinity("kubernetes.io/hostname", labels).
+		SetTolerations([]beku.Toleration{{Key: "dedicated", Value: "web", Effect: "NoSchedule"}}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := dp.Spec.Template.Spec
+	if spec.NodeSelector["disktype"] != "ssd" || len(spec.Tolerations) != 1 || spec.Tolerations[0].Operator != "Equal" {
+		t.Fatalf("expect node selector and toleration, got %v %v", spec.NodeSelector, spec.Tolerations)
+	}
+	terms := spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) != 1 || terms[0].MatchExpressions[0].Key != "node.kubernetes.io/instance-type" {
+		t.Fatalf("expect required node affinity, got %v", terms)
+	}
+	if len(spec.Affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution) != 1 ||
+		len(spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution) != 1 {
+		t.Fatalf("expect preferred pod affinity and required anti-affinity, got %v", spec.Affinity)
+	}
+	if fieldErrs := beku.NewDeployment().SetNodeAffinity("zone", "Gt", "a").Validate(); len(fieldErrs) == 0 {
+		t.Fatal("Gt with non-integer value should fail")
+	}
+	if fieldErrs := beku.NewDeployment().SetTolerations([]beku.Toleration{{Key: "k", Effect: "NoSchedule", TolerationSeconds: 30}}).Validate(); len(fieldErrs) == 0 {
+		t.Fatal("TolerationSeconds without NoExecute should fail")
+	}
+}
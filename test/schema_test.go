@@ -0,0 +1,47 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+func Test_SchemaForKind(t *testing.T) {
+	data, err := beku.SchemaForKind(appsv1.SchemeGroupVersion.WithKind("Deployment"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema struct {
+		Properties map[string]map[string]interface{} `json:"properties"`
+		Required   []string                          `json:"required"`
+		Defs       map[string]interface{}            `json:"$defs"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema.Properties["kind"]["const"] != "Deployment" || schema.Properties["apiVersion"]["const"] != "apps/v1" {
+		t.Errorf("kind and apiVersion are not constrained:%v,%v", schema.Properties["kind"], schema.Properties["apiVersion"])
+	}
+	if _, ok := schema.Properties["status"]; ok {
+		t.Error("status should not be in schema")
+	}
+	if _, ok := schema.Properties["spec"]["$ref"]; !ok {
+		t.Errorf("spec should refer to $defs:%v", schema.Properties["spec"])
+	}
+	if _, ok := schema.Defs["k8s.io.api.core.v1.Container"]; !ok {
+		t.Error("Container should be in $defs")
+	}
+	if _, err := beku.SchemaForKind(appsv1.SchemeGroupVersion.WithKind("Unknown")); err == nil {
+		t.Error("unknown kind should be error")
+	}
+	if _, err := beku.SchemaForKind(appsv1.SchemeGroupVersion.WithKind("Service")); err == nil {
+		t.Error("Service of apps/v1 should be error")
+	}
+	for _, kind := range beku.SchemaKinds() {
+		if _, err := beku.SchemaForKind(kind); err != nil {
+			t.Errorf("%s:%v", kind, err)
+		}
+	}
+}
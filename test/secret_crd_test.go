@@ -0,0 +1,55 @@
+package test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_SealedSecretSeal(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "sealed-secrets"},
+		NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	ss, err := beku.NewSealedSecret().SetNamespaceAndName("roc", "db").SetCert(cert).
+		SetDataString(map[string]string{"password": "123456"}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ss.Spec.EncryptedData["password"] == "" || ss.Spec.EncryptedData["password"] == "123456" {
+		t.Fatalf("password is not sealed:%q", ss.Spec.EncryptedData["password"])
+	}
+	_, err = beku.NewSealedSecret().SetNamespaceAndName("roc", "db").SetDataString(map[string]string{"password": "123456"}).Finish()
+	if err == nil {
+		t.Fatal("no cert is set, Finish should return error")
+	}
+}
+
+func Test_ExternalSecretData(t *testing.T) {
+	es, err := beku.NewExternalSecret().SetNamespaceAndName("roc", "db").SetStoreRef("vault", beku.SecretStoreKindCluster).
+		AddData("password", "secret/db", "password").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if es.Spec.Target.Name != "db" {
+		t.Fatalf("target should default to the name of ExternalSecret,got %s", es.Spec.Target.Name)
+	}
+	_, err = beku.NewExternalSecret().SetNamespaceAndName("roc", "db").AddDataFrom("secret/db").Finish()
+	if err == nil {
+		t.Fatal("secretStoreRef is not set, Finish should return error")
+	}
+}
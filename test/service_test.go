@@ -21,3 +21,39 @@ func Test_CreateSvc(t *testing.T) {
 	}
 	t.Error(string(data))
 }
+
+func Test_ValidateSvc(t *testing.T) {
+	builder := beku.NewSvc().SetNamespaceAndName("yulibaozi", "mysql-svc").SetSelector(map[string]string{"app": "mysql"})
+	if fieldErrs := builder.Validate(); len(fieldErrs) == 0 {
+		t.Fatal("svc without ports should not pass Validate")
+	}
+	builder.SetPort(beku.ServicePort{Port: 3306})
+	if fieldErrs := builder.Validate(); len(fieldErrs) > 0 {
+		t.Fatal(fieldErrs)
+	}
+	svc, err := builder.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if svc.Kind != "Service" {
+		t.Fatalf("Finish should input Kind, got %q", svc.Kind)
+	}
+}
+
+func Test_SvcFromDeployment(t *testing.T) {
+	dp := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80)
+	svc, err := beku.NewSvc().FromDeployment(dp).SetServiceType(beku.ServiceTypeNodePort).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if svc.GetNamespace() != "roc" || svc.GetName() != "http" || svc.Spec.Selector["app"] != "http" {
+		t.Fatalf("expect Service derived from Deployment roc/http, got %s/%s selector %v", svc.GetNamespace(), svc.GetName(), svc.Spec.Selector)
+	}
+	if len(svc.Spec.Ports) != 1 || svc.Spec.Ports[0].Port != 80 || svc.Spec.Ports[0].TargetPort.IntValue() != 80 {
+		t.Fatalf("expect container port 80 exposed, got %v", svc.Spec.Ports)
+	}
+	if _, err := beku.NewSvc().FromDeployment(beku.NewDeployment().SetName("http")).Finish(); err == nil {
+		t.Fatal("Deployment without Pod labels should not be derived")
+	}
+}
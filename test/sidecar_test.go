@@ -0,0 +1,47 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_InjectSidecar(t *testing.T) {
+	shipper := beku.Sidecar{
+		Name:          "log-shipper",
+		Image:         "fluent/fluent-bit:2.2",
+		Env:           map[string]string{"LOG_LEVEL": "info"},
+		Limits:        map[beku.ResourceName]string{beku.ResourceCPU: "100m", beku.ResourceMemory: "64Mi"},
+		SharedVolumes: map[string]string{"app-logs": "/var/log/app"},
+	}
+	exporter := beku.Sidecar{
+		Name:  "exporter",
+		Image: "prom/mysqld-exporter:v0.15.1",
+		Ports: []corev1.ContainerPort{{Name: "metrics", ContainerPort: 9104}},
+	}
+	for _, name := range []string{"http", "grpc"} {
+		dp, err := beku.NewDeployment().SetNamespaceAndName("roc", name).SetPodLabels(map[string]string{"app": name}).
+			SetContainer(name, name+":1.0", 8080).InjectSidecar(shipper).InjectSidecar(exporter).Finish()
+		if err != nil {
+			t.Fatal(err)
+		}
+		pod := dp.Spec.Template.Spec
+		if len(pod.Containers) != 3 || pod.Containers[1].Name != "log-shipper" || pod.Containers[2].Name != "exporter" {
+			t.Fatalf("expect the sidecars after the application container,got %v", pod.Containers)
+		}
+		if len(pod.Volumes) != 1 || pod.Volumes[0].EmptyDir == nil {
+			t.Fatalf("expect one shared emptyDir volume,got %v", pod.Volumes)
+		}
+		for _, container := range pod.Containers[:2] {
+			if len(container.VolumeMounts) != 1 || container.VolumeMounts[0].MountPath != "/var/log/app" {
+				t.Fatalf("container %s should mount the shared volume,got %v", container.Name, container.VolumeMounts)
+			}
+		}
+	}
+	_, err := beku.NewDeployment().SetNamespaceAndName("roc", "metrics").SetPodLabels(map[string]string{"app": "metrics"}).
+		SetContainer("metrics", "metrics:1.0", 9104).InjectSidecar(exporter).Finish()
+	if err == nil {
+		t.Fatal("the port conflicting with the application container should return error")
+	}
+}
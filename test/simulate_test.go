@@ -0,0 +1,93 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func simulateDeployment(t *testing.T) *appsv1.Deployment {
+	t.Helper()
+	dp, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx:1.25", 80).SetHTTPStartup(80, "/healthz", 30, 10).
+		SetInitContainer("init", "busybox:latest", []string{"sh", "-c", "true"}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dp
+}
+
+func Test_SimulateDefaults(t *testing.T) {
+	dp := simulateDeployment(t)
+	obj, err := beku.Simulate(dp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := obj.(*appsv1.Deployment)
+	if got == dp || dp.Spec.Template.Spec.SchedulerName != "" {
+		t.Fatal("Simulate should not change the object passed in")
+	}
+	pod := got.Spec.Template.Spec
+	if *got.Spec.Replicas != 1 || got.Spec.Strategy.RollingUpdate == nil || pod.DNSPolicy != v1.DNSClusterFirst {
+		t.Fatalf("unexpected defaults of Deployment:%v", got.Spec)
+	}
+	startup := pod.Containers[0].StartupProbe
+	if startup.TimeoutSeconds != 1 || startup.SuccessThreshold != 1 || startup.HTTPGet.Scheme != v1.URISchemeHTTP {
+		t.Fatalf("StartupProbe should be defaulted:%v", startup)
+	}
+	init := pod.InitContainers[0]
+	if init.ImagePullPolicy != v1.PullAlways || init.TerminationMessagePath != v1.TerminationMessagePathDefault {
+		t.Fatalf("InitContainers should be defaulted:%v", init)
+	}
+}
+
+func Test_SimulateValidation(t *testing.T) {
+	dp := simulateDeployment(t)
+	dp.Name = "Invalid_Name"
+	dp.Spec.Template.Spec.Containers[0].Ports[0].ContainerPort = 70000
+	_, err := beku.Simulate(dp)
+	if err == nil || !strings.Contains(err.Error(), "metadata.name") || !strings.Contains(err.Error(), "containerPort") {
+		t.Fatalf("all validation errors should be returned together,got %v", err)
+	}
+	if _, err := beku.Simulate(nil); err == nil {
+		t.Fatal("nil object should be error")
+	}
+}
+
+func Test_SimulatePolicy(t *testing.T) {
+	if err := beku.RegisterPolicy(beku.Policy{Name: "sidecar-label", Mutate: func(obj runtime.Object) error {
+		obj.(*appsv1.Deployment).Spec.Template.Labels["sidecar"] = "injected"
+		return nil
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	defer beku.UnregisterPolicy("sidecar-label")
+	if err := beku.RegisterPolicy(beku.Policy{Name: "sidecar-label", Validate: func(runtime.Object) error { return nil }}); err == nil {
+		t.Fatal("the policy registered twice should be error")
+	}
+	if err := beku.RegisterPolicy(beku.Policy{Name: "nothing"}); err == nil {
+		t.Fatal("the policy without Mutate and Validate should be error")
+	}
+	deny := beku.Policy{Name: "require-sidecar", Validate: func(obj runtime.Object) error {
+		if obj.(*appsv1.Deployment).Spec.Template.Labels["sidecar"] != "injected" {
+			return errors.New("sidecar is not injected")
+		}
+		return nil
+	}}
+	obj, err := beku.Simulate(simulateDeployment(t), deny)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.(*appsv1.Deployment).Spec.Template.Labels["sidecar"] != "injected" {
+		t.Fatal("the registered policy should mutate the object")
+	}
+	beku.UnregisterPolicy("sidecar-label")
+	if _, err := beku.Simulate(simulateDeployment(t), deny); err == nil || !strings.Contains(err.Error(), "require-sidecar") {
+		t.Fatalf("the unregistered policy should not run,got %v", err)
+	}
+}
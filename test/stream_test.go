@@ -0,0 +1,175 @@
+package test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const manifests = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: http
+data:
+  key: value
+---
+# only comment
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: http
+spec:
+  ports:
+  - port: 80
+`
+
+func Test_StreamManifests(t *testing.T) {
+	var kinds []string
+	err := beku.StreamManifests(strings.NewReader(manifests), func(builder beku.Builder) error {
+		obj, err := builder.FinishObject()
+		if err != nil {
+			return err
+		}
+		kinds = append(kinds, obj.GetObjectKind().GroupVersionKind().Kind)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(kinds, ",") != "ConfigMap,Service" {
+		t.Fatalf("expect ConfigMap,Service, got %v", kinds)
+	}
+}
+
+// endless is a reader which never ends,eg: a document uploaded without limit
+type endless byte
+
+func (e endless) Read(p []byte) (int, error) {
+	for index := range p {
+		p[index] = byte(e)
+	}
+	return len(p), nil
+}
+
+func Test_StreamManifestsMaxDocumentSize(t *testing.T) {
+	// the documents are smaller than MaxDocumentSize,but the stream is larger than it
+	stream := strings.Repeat(manifests+"---\n", beku.MaxDocumentSize/len(manifests)+1)
+	count := 0
+	if err := beku.StreamManifests(strings.NewReader(stream), func(beku.Builder) error { count++; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if count <= 2 {
+		t.Fatalf("every document should be streamed,got %d", count)
+	}
+	large := io.MultiReader(strings.NewReader("apiVersion: v1\nkind: ConfigMap\ndata:\n  large: "), endless('a'))
+	err := beku.StreamManifests(large, func(beku.Builder) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "exceeds the limit") {
+		t.Fatalf("the document larger than MaxDocumentSize should be refused while reading,got %v", err)
+	}
+}
+
+const rbacManifests = `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: web
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: http
+  namespace: web
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: reader
+  namespace: web
+rules:
+- apiGroups: [""]
+  resources: ["pods"]
+  verbs: ["get"]
+---
+apiVersion: scheduling.k8s.io/v1
+kind: PriorityClass
+metadata:
+  name: high
+value: 1000
+`
+
+func Test_StreamManifestsRegisteredKinds(t *testing.T) {
+	var builders []string
+	err := beku.StreamManifests(strings.NewReader(rbacManifests), func(builder beku.Builder) error {
+		builders = append(builders, fmt.Sprintf("%T", builder))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(builders, ","); got != "*beku.Namespace,*beku.ServiceAccount,*beku.Role,*beku.PriorityClass" {
+		t.Fatalf("unexpected builders:%s", got)
+	}
+	for _, gvk := range []schema.GroupVersionKind{
+		{Group: "batch", Version: "v1", Kind: "Job"},
+		{Group: "batch", Version: "v1", Kind: "CronJob"},
+		{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"},
+		{Group: "autoscaling", Version: "v2", Kind: "HorizontalPodAutoscaler"},
+		{Group: "policy", Version: "v1", Kind: "PodDisruptionBudget"},
+		{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "RoleBinding"},
+		{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"},
+	} {
+		if !beku.KindRegistered(gvk) {
+			t.Errorf("%s should be registered", gvk)
+		}
+	}
+	if beku.KindRegistered(schema.GroupVersionKind{Group: "serving.knative.dev", Version: "v1", Kind: "Service"}) {
+		t.Error("Service of knative should not be registered")
+	}
+}
+
+func Test_RegisterKind(t *testing.T) {
+	if err := beku.RegisterKind("Deployment", "apps/v1", nil, func(doc []byte) beku.Builder { return beku.NewDeployment() }); err == nil {
+		t.Error("registered kind should be error")
+	}
+	if err := beku.RegisterKind("Widget", "example.com/v1", nil, nil); err == nil {
+		t.Error("nil newBuilder should be error")
+	}
+}
+
+const knativeService = `apiVersion: serving.knative.dev/v1
+kind: Service
+metadata:
+  name: hello
+  namespace: roc
+spec:
+  template:
+    spec:
+      containers:
+      - image: ghcr.io/knative/helloworld-go:latest
+`
+
+func Test_NewBuilderGroupVersionKind(t *testing.T) {
+	_, err := beku.NewBuilder([]byte(knativeService))
+	if err == nil || !strings.Contains(err.Error(), "unsupported apiVersion serving.knative.dev/v1 of kind Service") {
+		t.Fatalf("Service of knative should not be built as v1 Service,got %v", err)
+	}
+	builder, err := beku.NewBuilder([]byte(strings.Replace(knativeService, "serving.knative.dev/v1", "v1", 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := builder.(*beku.Service); !ok {
+		t.Fatalf("expect *beku.Service,got %T", builder)
+	}
+	if _, err := beku.NewBuilder([]byte("kind: Service\nmetadata: {name: hello}\n")); err == nil {
+		t.Error("the document without apiVersion should be error")
+	}
+	if _, err := beku.NewBuilder([]byte("apiVersion: example.com/v1\nkind: Widget\n")); err == nil || !strings.Contains(err.Error(), "kind Widget is not supported") {
+		t.Errorf("unknown kind should be error,got %v", err)
+	}
+}
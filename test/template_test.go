@@ -0,0 +1,65 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_TemplateInstantiate(t *testing.T) {
+	tmpl, err := beku.NewTemplate(beku.NewDeployment().SetNamespaceAndName("base", "nginx").
+		SetSelector(map[string]string{"app": "nginx"}).SetContainer("nginx", "nginx:1.15", 80))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := tmpl.Instantiate("nginx-a", "tenant-a").SetEnvs(map[string]string{"TENANT": "a"}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := tmpl.Instantiate("nginx-b", "tenant-b").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.GetNamespace() != "tenant-a" || b.GetName() != "nginx-b" {
+		t.Fatalf("unexpected instances %s/%s %s/%s", a.GetNamespace(), a.GetName(), b.GetNamespace(), b.GetName())
+	}
+	if len(b.Spec.Template.Spec.Containers[0].Env) != 0 {
+		t.Fatal("the change of instance a should not affect instance b")
+	}
+	// the finished Deployment doesn't share the containers with Template
+	b.Spec.Template.Spec.Containers[0].Image = "nginx:1.16"
+	c, err := tmpl.Instantiate("nginx-c", "tenant-c").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image := c.Spec.Template.Spec.Containers[0].Image; image != "nginx:1.15" {
+		t.Fatalf("the change of finished instance b should not affect Template, got %s", image)
+	}
+}
+
+func Test_TemplateInstanceVerify(t *testing.T) {
+	tmpl, err := beku.NewTemplate(beku.NewDeployment().SetNamespaceAndName("base", "nginx").
+		SetSelector(map[string]string{"app": "nginx"}).SetContainer("nginx", "nginx:1.15", 80))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tmpl.Instantiate("", "tenant-a").Finish(); err == nil {
+		t.Fatal("the instance without name should be error")
+	}
+	// the changed spec is verified again
+	if _, err := tmpl.Instantiate("nginx-a", "tenant-a").MatchIn("tier", "web").Finish(); err == nil {
+		t.Fatal("the selector which doesn't match the pod labels should be error")
+	}
+	inst := tmpl.Instantiate("nginx-b", "tenant-b").
+		JSONNew([]byte(`{"spec":{"template":{"spec":{"containers":[{"name":"busybox","image":"busybox"}]}}}}`))
+	if _, err := inst.Finish(); err != nil {
+		t.Fatal(err)
+	}
+	c, err := tmpl.Instantiate("nginx-c", "tenant-c").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if container := c.Spec.Template.Spec.Containers[0]; container.Name != "nginx" || container.Image != "nginx:1.15" {
+		t.Fatalf("JSONNew of instance b should not affect Template, got %s %s", container.Name, container.Image)
+	}
+}
@@ -0,0 +1,69 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func Test_UpdateWithRetry(t *testing.T) {
+	dp, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, dynamic := fakeClient(t, dp)
+	conflicts := 2
+	dynamic.PrependReactor("update", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if conflicts > 0 {
+			conflicts--
+			return true, nil, apierrors.NewConflict(schema.GroupResource{Group: "apps", Resource: "deployments"}, "http", nil)
+		}
+		return false, nil, nil
+	})
+	calls := 0
+	_, err = beku.UpdateWithRetry(context.Background(), client, dp, func(b beku.Builder) {
+		calls++
+		b.(*beku.Deployment).SetReplicas(3)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Fatalf("expect mutate is called 3 times, got %d", calls)
+	}
+	got, err := client.Get(context.TODO(), dp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replicas, _, _ := unstructured.NestedInt64(got.Object, "spec", "replicas"); replicas != 3 {
+		t.Fatalf("expect replicas 3, got %d", replicas)
+	}
+}
+
+func Test_UpdateWithRetryJob(t *testing.T) {
+	job, err := beku.NewJob().SetNamespaceAndName("roc", "migrate").SetContainer("migrate", "migrate:v1").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, _ := fakeClient(t, job)
+	_, err = beku.UpdateWithRetry(context.Background(), client, job, func(b beku.Builder) {
+		b.(*beku.Job).SetBackoffLimit(2)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := client.Get(context.TODO(), job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limit, _, _ := unstructured.NestedInt64(got.Object, "spec", "backoffLimit"); limit != 2 {
+		t.Fatalf("expect backoffLimit 2, got %d", limit)
+	}
+}
@@ -0,0 +1,56 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yulibaozi/beku"
+)
+
+func Test_RegisterVerifier(t *testing.T) {
+	if err := beku.RegisterVerifier("no-latest", beku.DenyLatestTag()); err != nil {
+		t.Fatal(err)
+	}
+	defer beku.UnregisterVerifier("no-latest")
+	if err := beku.RegisterVerifier("limits", beku.RequireResourceLimits()); err != nil {
+		t.Fatal(err)
+	}
+	defer beku.UnregisterVerifier("limits")
+	if err := beku.RegisterVerifier("cost-center", beku.RequireLabels("team", "cost-center")); err != nil {
+		t.Fatal(err)
+	}
+	defer beku.UnregisterVerifier("cost-center")
+	if err := beku.RegisterVerifier("limits", beku.RequireResourceLimits()); err == nil {
+		t.Fatal("duplicate verifier name should return error")
+	}
+
+	_, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetResourceLimits("500m", "512Mi").Finish()
+	if err == nil || !strings.Contains(err.Error(), "no-latest") {
+		t.Fatalf("image without tag should be denied by no-latest,got %v", err)
+	}
+	_, err = beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx:1.25", 80).Finish()
+	if err == nil || !strings.Contains(err.Error(), "limits") {
+		t.Fatalf("container without limits should be denied by limits,got %v", err)
+	}
+	_, err = beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx:1.25", 80).SetResourceLimits("500m", "512Mi").AddLabel("team", "infra").Finish()
+	if err == nil || !strings.Contains(err.Error(), "cost-center") {
+		t.Fatalf("Deployment without cost-center label should be denied,got %v", err)
+	}
+	_, err = beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx:1.25", 80).SetResourceLimits("500m", "512Mi").
+		AddLabel("team", "infra").AddLabel("cost-center", "cc-42").Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	beku.UnregisterVerifier("cost-center")
+	_, err = beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx@sha256:0d17b565c37bcbd895e9d92315a05c1c3c9a29f762b011a10c54a66cd53c9b31", 80).
+		SetResourceLimits("500m", "512Mi").Finish()
+	if err != nil {
+		t.Fatalf("image of digest should be allowed,got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yulibaozi/beku"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func rolledDeployment(t *testing.T) *appsv1.Deployment {
+	dp, err := beku.NewDeployment().SetNamespaceAndName("roc", "http").SetPodLabels(map[string]string{"app": "http"}).
+		SetContainer("http", "nginx", 80).SetReplicas(2).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dp.Generation, dp.Status.ObservedGeneration = 2, 2
+	dp.Status.Replicas, dp.Status.UpdatedReplicas, dp.Status.AvailableReplicas = 2, 2, 2
+	return dp
+}
+
+func Test_WaitForRollout(t *testing.T) {
+	dp := rolledDeployment(t)
+	if err := beku.WaitForRollout(context.Background(), fake.NewSimpleClientset(dp), dp, time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_WaitForRolloutProgressDeadline(t *testing.T) {
+	dp := rolledDeployment(t)
+	dp.Status.AvailableReplicas = 1
+	dp.Status.Conditions = []appsv1.DeploymentCondition{{
+		Type:    appsv1.DeploymentProgressing,
+		Status:  corev1.ConditionFalse,
+		Reason:  "ProgressDeadlineExceeded",
+		Message: `ReplicaSet "http-1" has timed out progressing.`,
+	}}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "roc", Name: "http-1-abc", Labels: map[string]string{"app": "http"}},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name:  "http",
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ErrImagePull", Message: "not found"}},
+		}}},
+	}
+	err := beku.WaitForRollout(context.Background(), fake.NewSimpleClientset(dp, pod), dp, time.Second)
+	var waitErr *beku.WaitError
+	if !errors.As(err, &waitErr) || waitErr.Reason != "ProgressDeadlineExceeded" {
+		t.Fatalf("expect ProgressDeadlineExceeded WaitError,got %v", err)
+	}
+	if len(waitErr.PodIssues) == 0 || !strings.Contains(waitErr.PodIssues[0], "ErrImagePull") {
+		t.Fatalf("expect ErrImagePull pod issue,got %v", waitErr.PodIssues)
+	}
+}
+
+func Test_WaitForCondition(t *testing.T) {
+	dp := rolledDeployment(t)
+	dp.Status.Conditions = []appsv1.DeploymentCondition{{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue}}
+	client, _ := fakeClient(t, dp)
+	if err := beku.WaitForCondition(context.Background(), client, dp, "Available", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	err := beku.WaitForCondition(context.Background(), client, dp, "Paused", 10*time.Millisecond)
+	var waitErr *beku.WaitError
+	if !errors.As(err, &waitErr) || waitErr.Reason != "Timeout" {
+		t.Fatalf("expect Timeout WaitError,got %v", err)
+	}
+}
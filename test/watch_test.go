@@ -0,0 +1,40 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yulibaozi/beku"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_ConfigMapWatch(t *testing.T) {
+	cm, err := beku.NewCM().SetNamespaceAndName("roc", "conf").SetData(map[string]string{"k": "v"}).Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := fake.NewSimpleClientset(cm)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	added := make(chan runtime.Object, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- beku.NewCM().SetNamespaceAndName("roc", "conf").Watch(ctx, client, beku.EventHandler{
+			OnAdd: func(obj runtime.Object) { added <- obj },
+		})
+	}()
+	select {
+	case obj := <-added:
+		if name := obj.(interface{ GetName() string }).GetName(); name != "conf" {
+			t.Fatalf("expect ConfigMap conf watched, got %s", name)
+		}
+	case <-ctx.Done():
+		t.Fatal("expect OnAdd called for ConfigMap conf")
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -124,11 +124,14 @@ const (
 	ProtocolTCP Protocol = "TCP"
 	// ProtocolUDP is the UDP protocol.
 	ProtocolUDP Protocol = "UDP"
+	// ProtocolSCTP is the SCTP protocol.
+	ProtocolSCTP Protocol = "SCTP"
 )
 
 var pros = map[Protocol]v1.Protocol{
-	"TCP": v1.ProtocolTCP,
-	"UDP": v1.ProtocolUDP,
+	"TCP":  v1.ProtocolTCP,
+	"UDP":  v1.ProtocolUDP,
+	"SCTP": v1.ProtocolSCTP,
 }
 
 // ToK8s translate into Kubernetes Protocol
@@ -282,6 +285,8 @@ const (
 	ResourceEphemeralStorage ResourceName = "ephemeral-storage"
 	// NVIDIA GPU, in devices. Alpha, might change: although fractional and allowing values >1, only one whole device per node is assigned.
 	ResourceNvidiaGPU ResourceName = "alpha.kubernetes.io/nvidia-gpu"
+	// GPU of NVIDIA device plugin, in devices. it is extended resource,set it by SetExtendedResource().
+	ResourceGPU ResourceName = "nvidia.com/gpu"
 )
 
 // ToK8s translate into k8s ResourceName
@@ -289,6 +294,67 @@ func (r ResourceName) ToK8s() v1.ResourceName {
 	return v1.ResourceName(stringToResourceName(string(r)))
 }
 
+// VolumeOptions is the options of ConfigMap and Secret volume,the zero fields are not set
+type VolumeOptions struct {
+	// DefaultMode is the mode of the files in the volume,eg: 0400 for private keys,defaults to 0644.
+	DefaultMode int32
+	// Items project the keys into the paths,only the keys in Items are in the volume when it is not empty.
+	Items []KeyToPath
+}
+
+// KeyToPath project the key of ConfigMap or Secret into the file path in volume
+type KeyToPath struct {
+	Key string
+	// Path is the relative file path,eg: bin/start.sh
+	Path string
+	// Mode is the mode of the file,eg: 0755 for scripts,DefaultMode is used when it is 0.
+	Mode int32
+}
+
+// ProjectedSource is one source of projected volume,only one of ConfigMap,Secret and ServiceAccountToken is set
+type ProjectedSource struct {
+	// ConfigMap is the name of ConfigMap projected into the volume
+	ConfigMap string
+	// Secret is the name of Secret projected into the volume
+	Secret string
+	// Items project the keys of ConfigMap or Secret into the paths,all keys are projected when it is empty.
+	Items []KeyToPath
+	// ServiceAccountToken is the relative path of the token of Pod service account,eg: token
+	ServiceAccountToken string
+	// Audience is the audience of the token,defaults to the audience of API server.
+	Audience string
+	// ExpirationSeconds is the lifetime of the token,at least 600,defaults to 1 hour.
+	ExpirationSeconds int64
+}
+
+// ProbeOptions is the options of probe which are not in SetXXXLiveness(),SetXXXReadness() or SetXXXStartup(),
+// the zero fields are not set
+type ProbeOptions struct {
+	// TerminationGracePeriodSeconds is the grace period of the container killed by the failed liveness or startup probe,
+	// it overrides terminationGracePeriodSeconds of Pod,so long-draining container isn't killed abruptly,
+	// it is not allowed on readiness probe.
+	TerminationGracePeriodSeconds int64
+	// FailureThreshold is the consecutive failures of the probe before the container is killed or unready,defaults to 3,
+	// FailureThreshold*periodSeconds of startup probe is the longest time the container has to start.
+	FailureThreshold int32
+	// SuccessThreshold is the consecutive successes of the probe after failure before it is considered successful,
+	// defaults to 1,only readiness probe allows more than 1.
+	SuccessThreshold int32
+}
+
+// ResourceRequirements is the new resources of container in Resize()
+type ResourceRequirements struct {
+	Limits   map[ResourceName]string
+	Requests map[ResourceName]string
+}
+
+const (
+	// ResizeNotRequired the container is resized in place without restart
+	ResizeNotRequired = "NotRequired"
+	// ResizeRestartContainer the container is restarted to apply the new resources
+	ResizeRestartContainer = "RestartContainer"
+)
+
 // resources include k8s support Resource object
 var resources = map[ResourceName]v1.ResourceName{
 	"cpu":               v1.ResourceCPU,
@@ -316,18 +382,23 @@ const (
 	ReadOnlyMany PersistentVolumeAccessMode = "ReadOnlyMany"
 	// can be mounted in read/write mode to many hosts
 	ReadWriteMany PersistentVolumeAccessMode = "ReadWriteMany"
-	RWO           PersistentVolumeAccessMode = "RWO"
-	ROX           PersistentVolumeAccessMode = "ROX"
-	RWX           PersistentVolumeAccessMode = "RWX"
+	// can be mounted in read/write mode to exactly 1 pod,it needs Kubernetes 1.27+
+	ReadWriteOncePod PersistentVolumeAccessMode = "ReadWriteOncePod"
+	RWO              PersistentVolumeAccessMode = "RWO"
+	ROX              PersistentVolumeAccessMode = "ROX"
+	RWX              PersistentVolumeAccessMode = "RWX"
+	RWOP             PersistentVolumeAccessMode = "RWOP"
 )
 
 var accessModes = map[PersistentVolumeAccessMode]v1.PersistentVolumeAccessMode{
-	"RWO":           v1.ReadWriteOnce,
-	"ReadWriteOnce": v1.ReadWriteOnce,
-	"ROX":           v1.ReadOnlyMany,
-	"ReadOnlyMany":  v1.ReadOnlyMany,
-	"RWX":           v1.ReadWriteMany,
-	"ReadWriteMany": v1.ReadWriteMany,
+	"RWO":              v1.ReadWriteOnce,
+	"ReadWriteOnce":    v1.ReadWriteOnce,
+	"ROX":              v1.ReadOnlyMany,
+	"ReadOnlyMany":     v1.ReadOnlyMany,
+	"RWX":              v1.ReadWriteMany,
+	"ReadWriteMany":    v1.ReadWriteMany,
+	"RWOP":             v1.ReadWriteOncePod,
+	"ReadWriteOncePod": v1.ReadWriteOncePod,
 }
 
 // ToK8s translate into k8s accessMode
@@ -335,6 +406,31 @@ func (pvm PersistentVolumeAccessMode) ToK8s() v1.PersistentVolumeAccessMode {
 	return accessModes[pvm]
 }
 
+// toAccessModes translate into k8s accessModes and check them,
+// ReadWriteOncePod can't be used with other modes and needs the registered Kubernetes version is 1.27+.
+func toAccessModes(method string, modes []PersistentVolumeAccessMode) ([]v1.PersistentVolumeAccessMode, error) {
+	if len(modes) == 0 {
+		return nil, fieldErrorf(method, "modes are not allowed to be empty")
+	}
+	k8sModes := make([]v1.PersistentVolumeAccessMode, 0, len(modes))
+	for _, mode := range modes {
+		k8sMode := mode.ToK8s()
+		if k8sMode == "" {
+			return nil, fieldErrorf(method, "access mode %q is not allowed,only RWO,ROX,RWX,RWOP or their full names", mode)
+		}
+		if k8sMode == v1.ReadWriteOncePod {
+			if len(modes) > 1 {
+				return nil, fieldErrorf(method, "ReadWriteOncePod can't be used with other access modes")
+			}
+			if !kubernetesVersionAtLeast(1, 27) {
+				return nil, fieldErrorf(method, "ReadWriteOncePod needs Kubernetes 1.27+,the registered version is %s", registeredKubernetesVersion())
+			}
+		}
+		k8sModes = append(k8sModes, k8sMode)
+	}
+	return k8sModes, nil
+}
+
 // RBDPersistentVolumeSource Represents a Rados Block Device mount that lasts the lifetime of a pod.
 // RBD volumes support ownership management and SELinux relabeling.
 type RBDPersistentVolumeSource struct {
@@ -439,7 +535,7 @@ func MapsToResources(resources map[string]string) map[ResourceName]string {
 	if resources == nil {
 		return nil
 	}
-	rns := make(map[ResourceName]string, 0)
+	rns := make(map[ResourceName]string, len(resources))
 	for k, data := range resources {
 		name := stringToResourceName(k)
 		if name == "" {
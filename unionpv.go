@@ -1,10 +1,12 @@
 package beku
 
 import (
-	"errors"
+	"context"
+	"fmt"
 	"reflect"
 
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // UnionPV output pvc and pv
@@ -22,19 +24,26 @@ func NewUnionPV() *UnionPV { return &UnionPV{pv: NewPV(), pvc: NewPVC()} }
 // return Kubernetes resource object(PersistentVolume,PersistentVolumeClaim) and error
 // In the function, it will check necessary parametersainput the default field
 func (un *UnionPV) Finish() (pv *v1.PersistentVolume, pvc *v1.PersistentVolumeClaim, err error) {
-	un.verify()
-	if un.err != nil {
-		err = un.err
-		return
-	}
-	pv, err = un.pv.Finish()
-	if err != nil {
-		return
-	}
-	pvc, err = un.pvc.Finish()
+	err = appendError(un.err, un.verify())
+	pv, pvErr := un.pv.Finish()
+	pvc, pvcErr := un.pvc.Finish()
+	err = appendError(appendError(err, pvErr), pvcErr)
 	return
 }
 
+// Validate check PersistentVolume and PersistentVolumeClaim necessary value like Finish(),and return the problems as FieldError,
+// but UnionPV is not changed, so it can be used to check a partially built UnionPV.
+func (un *UnionPV) Validate() []FieldError {
+	cp := &UnionPV{
+		pv:  &PersistentVolume{pv: un.pv.pv.DeepCopy(), err: un.pv.err},
+		pvc: &PersistentVolumeClaim{pvc: un.pvc.pvc.DeepCopy(), err: un.pvc.err},
+		err: un.err,
+	}
+	fieldErrs := FieldErrors(appendError(cp.err, cp.verify()))
+	fieldErrs = append(fieldErrs, cp.pv.Validate()...)
+	return append(fieldErrs, cp.pvc.Validate()...)
+}
+
 // SetName set PersistentVolume and PersistentVolumeClaim name
 func (un *UnionPV) SetName(name string) *UnionPV {
 	un.pv.SetName(name)
@@ -117,33 +126,48 @@ func (un *UnionPV) Release() (pv *v1.PersistentVolume, pvc *v1.PersistentVolumeC
 	if err != nil {
 		return
 	}
-	client, err := GetKubeClient()
+	client, err := getKubeInterface()
 	if err != nil {
 		return
 	}
-	pv, err = client.CoreV1().PersistentVolumes().Create(pv)
+	pv, err = client.CoreV1().PersistentVolumes().Create(context.TODO(), pv, metav1.CreateOptions{})
 	if err != nil {
 		return
 	}
-	pvc, err = client.CoreV1().PersistentVolumeClaims(pvc.GetNamespace()).Create(pvc)
+	pvc, err = client.CoreV1().PersistentVolumeClaims(pvc.GetNamespace()).Create(context.TODO(), pvc, metav1.CreateOptions{})
 	return
 }
 
-// verify check UnionPV necessary value, input the default field and input related data.
-func (un *UnionPV) verify() {
+// String the current PersistentVolume and PersistentVolumeClaim as yaml,
+// the pending error is written on the top as comment.
+func (un *UnionPV) String() string {
 	if un.err != nil {
-		return
+		return fmt.Sprintf("# beku UnionPV\n# error: %v\n%s---\n%s", un.err, un.pv, un.pvc)
 	}
+	return fmt.Sprintf("%s---\n%s", un.pv, un.pvc)
+}
+
+// Dump print the current PersistentVolume,PersistentVolumeClaim and pending error,and return itself,
+// so you can call it anywhere in the chain to debug.
+func (un *UnionPV) Dump() *UnionPV {
+	fmt.Println(un.String())
+	return un
+}
+
+// verify check UnionPV necessary value, input the default field and input related data.
+func (un *UnionPV) verify() error {
+	var errs error
 	pvname, pvcname := un.pv.GetName(), un.pvc.GetName()
 	if !verifyString(pvname) || !verifyString(pvcname) {
-		un.err = errors.New("pvc or pv name is empty not allow")
-		return
+		errs = appendError(errs, fieldError("UnionPV.Name", "pvc or pv name is empty not allow"))
 	}
 	//check labels and selector
 	pvlabels, pvclabels, pvcselector := un.pv.GetLabels(), un.pvc.GetLabels(), un.pvc.GetSelector()
 	if !reflect.DeepEqual(pvcselector, pvlabels) {
-		un.err = errors.New("UnionPV, it is not allow to pvc selector and pv labels not equal")
-		return
+		errs = appendError(errs, fieldError("UnionPV.Labels", "it is not allow to pvc selector and pv labels not equal"))
+	}
+	if errs != nil {
+		return errs
 	}
 	if !verifyString(un.pvc.GetNamespace()) {
 		un.SetNamespace("default")
@@ -158,4 +182,5 @@ func (un *UnionPV) verify() {
 		pvclabels = map[string]string{"name": pvcname}
 		un.pvc.SetLabels(pvclabels)
 	}
+	return nil
 }
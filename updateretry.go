@@ -0,0 +1,111 @@
+package beku
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// UpdateRetryBackoff is the backoff of UpdateWithRetry() on conflict,
+// the default retries 5 times after 10ms,20ms,40ms,80ms and 160ms.
+var UpdateRetryBackoff = wait.Backoff{Duration: 10 * time.Millisecond, Factor: 2, Jitter: 0.1, Steps: 6}
+
+// UpdateWithRetry update the object on Kubernetes by the get-mutate-update loop:
+// get the current object of the kind,namespace and name of obj, call mutate with the builder of it,eg: *Deployment,
+// finish the builder and update it, the loop is retried with UpdateRetryBackoff when the update is conflict(409),
+// so the changes of others between get and update are not overwritten.
+// mutate is called once every loop and it should only change the builder,eg:
+//
+//	UpdateWithRetry(ctx, nil, dp, func(b Builder) { b.(*Deployment).SetReplicas(3) })
+//
+// client is the Client of Kubernetes,the registered cluster is used when it is nil. the updated object is returned.
+func UpdateWithRetry(ctx context.Context, client *Client, obj runtime.Object, mutate func(Builder)) (runtime.Object, error) {
+	if mutate == nil {
+		return nil, fmt.Errorf("UpdateWithRetry err,mutate is not allowed to be nil")
+	}
+	client, err := clientOrDefault(client)
+	if err != nil {
+		return nil, err
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, fmt.Errorf("UpdateWithRetry err,%v", err)
+	}
+	rc, err := client.resourceClient(obj)
+	if err != nil {
+		return nil, fmt.Errorf("UpdateWithRetry %s err,%v", objectName(obj), err)
+	}
+	backoff := UpdateRetryBackoff
+	for {
+		current, err := rc.get(ctx, accessor.GetName())
+		if err != nil {
+			return nil, fmt.Errorf("UpdateWithRetry get %s err,%v", objectName(obj), err)
+		}
+		builder, err := builderOf(current)
+		if err != nil {
+			return nil, fmt.Errorf("UpdateWithRetry %s err,%v", objectName(obj), err)
+		}
+		mutate(builder)
+		updated, err := builder.FinishObject()
+		if err != nil {
+			return nil, fmt.Errorf("UpdateWithRetry %s err,%v", objectName(obj), err)
+		}
+		updatedAccessor, err := meta.Accessor(updated)
+		if err != nil {
+			return nil, err
+		}
+		if updatedAccessor.GetName() != accessor.GetName() || updatedAccessor.GetNamespace() != accessor.GetNamespace() {
+			return nil, fmt.Errorf("UpdateWithRetry %s err,mutate is not allowed to change name or namespace", objectName(obj))
+		}
+		result, err := rc.update(ctx, updated)
+		if err == nil {
+			return result, nil
+		}
+		if !apierrors.IsConflict(err) || backoff.Steps <= 1 {
+			return nil, fmt.Errorf("UpdateWithRetry update %s err,%v", objectName(obj), err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff.Step()):
+		}
+	}
+}
+
+// builderOf create the builder of the object got from Kubernetes
+func builderOf(obj runtime.Object) (Builder, error) {
+	switch o := obj.(type) {
+	case *v1.Namespace:
+		return NewNs().Replace(o), nil
+	case *v1.ConfigMap:
+		return NewCM().Replace(o), nil
+	case *v1.Secret:
+		return NewSecret().Replace(o), nil
+	case *v1.Service:
+		return NewSvc().Replace(o), nil
+	case *v1.PersistentVolume:
+		return NewPV().Replace(o), nil
+	case *v1.PersistentVolumeClaim:
+		return NewPVC().Replace(o), nil
+	case *appsv1.Deployment:
+		return NewDeployment().Replace(o), nil
+	case *appsv1.StatefulSet:
+		return NewSts().Replace(o), nil
+	case *appsv1.DaemonSet:
+		return NewDS().Replace(o), nil
+	case *batchv1.Job:
+		return NewJob().Replace(o), nil
+	case *unstructured.Unstructured:
+		return NewCustomResource(o.GroupVersionKind()).Replace(o), nil
+	}
+	return nil, fmt.Errorf("%s has no builder in beku", objectKind(obj))
+}
@@ -0,0 +1,141 @@
+package beku
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// namedVerifier is the verifier registered by RegisterVerifier()
+type namedVerifier struct {
+	name     string
+	verifier func(obj runtime.Object) error
+}
+
+var verifierRegistry = struct {
+	sync.RWMutex
+	verifiers []namedVerifier
+}{}
+
+// RegisterVerifier register the verifier which checks the objects in Finish() and Validate() of Deployment,StatefulSet,DaemonSet,
+// Job,CronJob,Service,Secret and ConfigMap,after the checks of beku and the verifiers added by WithVerifier(),
+// so the policies of the organization are enforced at build time,eg:
+//
+//	beku.RegisterVerifier("no-latest", beku.DenyLatestTag())
+//	beku.RegisterVerifier("cost-center", beku.RequireLabels("team", "cost-center"))
+//
+// the verifiers are run in the order of registration,the name is used in the error and by UnregisterVerifier().
+func RegisterVerifier(name string, verifier func(obj runtime.Object) error) error {
+	if !verifyString(name) {
+		return fmt.Errorf("RegisterVerifier failed,name is not allowed to be empty")
+	}
+	if verifier == nil {
+		return fmt.Errorf("RegisterVerifier failed,verifier %s is not allowed to be nil", name)
+	}
+	verifierRegistry.Lock()
+	defer verifierRegistry.Unlock()
+	for _, registered := range verifierRegistry.verifiers {
+		if registered.name == name {
+			return fmt.Errorf("RegisterVerifier failed,verifier %s is already registered", name)
+		}
+	}
+	verifierRegistry.verifiers = append(verifierRegistry.verifiers, namedVerifier{name: name, verifier: verifier})
+	return nil
+}
+
+// UnregisterVerifier remove the verifier registered by RegisterVerifier(),it does nothing when name is not registered
+func UnregisterVerifier(name string) {
+	verifierRegistry.Lock()
+	defer verifierRegistry.Unlock()
+	verifiers := verifierRegistry.verifiers[:0:0]
+	for _, registered := range verifierRegistry.verifiers {
+		if registered.name != name {
+			verifiers = append(verifiers, registered)
+		}
+	}
+	verifierRegistry.verifiers = verifiers
+}
+
+// runRegisteredVerifiers run the verifiers registered by RegisterVerifier() on obj in order,
+// all of them are run,and the errors of the failed verifiers are returned together.
+func runRegisteredVerifiers(obj runtime.Object) error {
+	verifierRegistry.RLock()
+	verifiers := verifierRegistry.verifiers
+	verifierRegistry.RUnlock()
+	var errs error
+	for _, registered := range verifiers {
+		if err := registered.verifier(obj); err != nil {
+			errs = appendError(errs, fmt.Errorf("verifier %s failed:%v", registered.name, err))
+		}
+	}
+	return errs
+}
+
+// RequireResourceLimits get the verifier which rejects the containers without cpu and memory limits,
+// the objects without Pod template are allowed,eg: Service.
+func RequireResourceLimits() func(obj runtime.Object) error {
+	return func(obj runtime.Object) error {
+		spec := podSpecOf(obj)
+		if spec == nil {
+			return nil
+		}
+		for _, container := range append(append([]v1.Container(nil), spec.InitContainers...), spec.Containers...) {
+			for _, resource := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+				if _, ok := container.Resources.Limits[resource]; !ok {
+					return fmt.Errorf("container %q has no %s limit", container.Name, resource)
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// DenyLatestTag get the verifier which rejects the images of latest tag or without tag,eg: nginx and nginx:latest,
+// the images of digest are allowed,eg: nginx@sha256:0d17b565c37bcbd895e9d92315a05c1c3c9a29f762b011a10c54a66cd53c9b31.
+func DenyLatestTag() func(obj runtime.Object) error {
+	return func(obj runtime.Object) error {
+		spec := podSpecOf(obj)
+		if spec == nil {
+			return nil
+		}
+		for _, container := range append(append([]v1.Container(nil), spec.InitContainers...), spec.Containers...) {
+			if isLatestImage(container.Image) {
+				return fmt.Errorf("container %q image %q is not allowed,the image must have the tag which is not latest", container.Name, container.Image)
+			}
+		}
+		return nil
+	}
+}
+
+// RequireLabels get the verifier which rejects the objects without the labels of keys,eg: team and cost-center
+func RequireLabels(keys ...string) func(obj runtime.Object) error {
+	return func(obj runtime.Object) error {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return err
+		}
+		labels := accessor.GetLabels()
+		var missing []string
+		for _, key := range keys {
+			if !verifyString(labels[key]) {
+				missing = append(missing, key)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("labels %s are required", strings.Join(missing, ","))
+		}
+		return nil
+	}
+}
+
+// isLatestImage the image is pulled by latest tag,it has no tag or the tag is latest
+func isLatestImage(image string) bool {
+	if strings.Contains(image, "@") {
+		return false
+	}
+	return !strings.Contains(image[strings.LastIndex(image, "/")+1:], ":") || strings.HasSuffix(image, ":latest")
+}
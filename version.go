@@ -0,0 +1,53 @@
+package beku
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// kubernetesVersionPattern match the major and minor of Kubernetes version,eg: v1.27.3,1.27,v1.28.2-gke.1
+var kubernetesVersionPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)(\.\d+)?([-+].*)?$`)
+
+// kubernetesVersion is the version registered by RegisterKubernetesVersion()
+var kubernetesVersion = struct {
+	sync.RWMutex
+	raw          string
+	major, minor int
+}{}
+
+// RegisterKubernetesVersion register the version of the target Kubernetes cluster,eg: v1.27,
+// the features which need newer Kubernetes are rejected in Finish(),eg: ReadWriteOncePod access mode needs 1.27+.
+// the features are allowed when the version is not registered.
+func RegisterKubernetesVersion(version string) error {
+	match := kubernetesVersionPattern.FindStringSubmatch(version)
+	if match == nil {
+		return fmt.Errorf("RegisterKubernetesVersion err,version %q is not allowed,eg: v1.27", version)
+	}
+	major, _ := strconv.Atoi(match[1])
+	minor, _ := strconv.Atoi(match[2])
+	kubernetesVersion.Lock()
+	defer kubernetesVersion.Unlock()
+	kubernetesVersion.raw, kubernetesVersion.major, kubernetesVersion.minor = version, major, minor
+	return nil
+}
+
+// kubernetesVersionAtLeast the registered version is major.minor or newer,true when the version is not registered
+func kubernetesVersionAtLeast(major, minor int) bool {
+	kubernetesVersion.RLock()
+	defer kubernetesVersion.RUnlock()
+	if kubernetesVersion.raw == "" {
+		return true
+	}
+	if kubernetesVersion.major != major {
+		return kubernetesVersion.major > major
+	}
+	return kubernetesVersion.minor >= minor
+}
+
+func registeredKubernetesVersion() string {
+	kubernetesVersion.RLock()
+	defer kubernetesVersion.RUnlock()
+	return kubernetesVersion.raw
+}
@@ -0,0 +1,260 @@
+package beku
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+)
+
+// maxPodIssues is the max number of the Pod issues in WaitError
+const maxPodIssues = 10
+
+// WaitError is returned when the wait is failed or timeout,Reason is the reason of the failure,
+// eg: ProgressDeadlineExceeded or Timeout,PodIssues are the problems of the Pods,eg: the image pull errors.
+type WaitError struct {
+	Object    string
+	Reason    string
+	Message   string
+	PodIssues []string
+}
+
+// Error implement error
+func (e *WaitError) Error() string {
+	msg := fmt.Sprintf("%s wait failed,%s:%s", e.Object, e.Reason, e.Message)
+	if len(e.PodIssues) > 0 {
+		msg += ",pod issues:" + strings.Join(e.PodIssues, ";")
+	}
+	return msg
+}
+
+// WaitForRollout block until the rollout of the Deployment on Kubernetes is complete,like `kubectl rollout status`,
+// the rollout is complete when the new spec is observed,all replicas are updated and available and old replicas are gone.
+// *WaitError is returned when the progress deadline is exceeded or timeout,with the issues of the Pods,
+// eg: ErrImagePull,CrashLoopBackOff,Unschedulable and the warning events,timeout <= 0 means it is controlled by ctx.
+// client is the Kubernetes clientset,the registered one is used when it is nil.
+func WaitForRollout(ctx context.Context, client kubernetes.Interface, dp *appsv1.Deployment, timeout time.Duration) (err error) {
+	if dp == nil || !verifyString(dp.GetName()) {
+		return fmt.Errorf("WaitForRollout err,Deployment name is not allowed to be empty")
+	}
+	client, err = clientOrRegistered(client)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := waitContext(ctx, timeout)
+	defer cancel()
+	ctx, done := observeObject(ctx, ObserveWait, dp)
+	defer func() { done(err) }()
+	deployments := client.AppsV1().Deployments(dp.GetNamespace())
+	ticker := time.NewTicker(ReadyPollInterval)
+	defer ticker.Stop()
+	for {
+		live, err := deployments.Get(ctx, dp.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		complete, reason, message := rolloutStatus(live)
+		if complete {
+			return nil
+		}
+		if reason != "" {
+			return &WaitError{Object: objectName(live), Reason: reason, Message: message,
+				PodIssues: podIssues(client, live.GetNamespace(), live.Spec.Selector)}
+		}
+		select {
+		case <-ctx.Done():
+			return &WaitError{Object: objectName(live), Reason: "Timeout", Message: message,
+				PodIssues: podIssues(client, live.GetNamespace(), live.Spec.Selector)}
+		case <-ticker.C:
+		}
+	}
+}
+
+// rolloutStatus get whether the rollout of the Deployment is complete,
+// reason is not empty when the rollout is failed,message is the progress.
+func rolloutStatus(dp *appsv1.Deployment) (complete bool, reason, message string) {
+	if dp.Status.ObservedGeneration < dp.Generation {
+		return false, "", "the new spec is not observed"
+	}
+	for _, condition := range dp.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
+			return false, condition.Reason, condition.Message
+		}
+	}
+	replicas := replicasOrDefault(dp.Spec.Replicas)
+	switch {
+	case dp.Status.UpdatedReplicas < replicas:
+		return false, "", fmt.Sprintf("%d of %d replicas are updated", dp.Status.UpdatedReplicas, replicas)
+	case dp.Status.Replicas > dp.Status.UpdatedReplicas:
+		return false, "", fmt.Sprintf("%d old replicas are pending termination", dp.Status.Replicas-dp.Status.UpdatedReplicas)
+	case dp.Status.AvailableReplicas < dp.Status.UpdatedReplicas:
+		return false, "", fmt.Sprintf("%d of %d updated replicas are available", dp.Status.AvailableReplicas, dp.Status.UpdatedReplicas)
+	}
+	return true, "", ""
+}
+
+// WaitForCondition block until the condition of the object on Kubernetes is True,eg: Available of Deployment,
+// Complete of Job or Ready of the custom resource,the condition of the old generation is not used.
+// *WaitError is returned when the object is failed,eg: Job is Failed,Stalled is True or the progress deadline is exceeded,
+// or timeout,timeout <= 0 means it is controlled by ctx,client is the Client of Kubernetes,the registered cluster is used when it is nil.
+func WaitForCondition(ctx context.Context, client *Client, obj runtime.Object, conditionType string, timeout time.Duration) (err error) {
+	if obj == nil || !verifyString(conditionType) {
+		return fmt.Errorf("WaitForCondition err,object and conditionType are not allowed to be empty")
+	}
+	client, err = clientOrDefault(client)
+	if err != nil {
+		return err
+	}
+	rc, err := client.resourceClient(obj)
+	if err != nil {
+		return err
+	}
+	name := objectName(obj)
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := waitContext(ctx, timeout)
+	defer cancel()
+	ctx, done := observeObject(ctx, ObserveWait, obj)
+	defer func() { done(err) }()
+	ticker := time.NewTicker(ReadyPollInterval)
+	defer ticker.Stop()
+	for {
+		current, err := rc.get(ctx, accessor.GetName())
+		if err != nil {
+			return err
+		}
+		content, err := unstructuredContent(current)
+		if err != nil {
+			return err
+		}
+		met, reason, message := conditionStatus(content, conditionType)
+		if met {
+			return nil
+		}
+		if reason != "" {
+			return &WaitError{Object: name, Reason: reason, Message: message}
+		}
+		select {
+		case <-ctx.Done():
+			return &WaitError{Object: name, Reason: "Timeout", Message: message}
+		case <-ticker.C:
+		}
+	}
+}
+
+// conditionStatus get whether the condition of the object is True,
+// reason is not empty when the object is failed,message is the state of the condition.
+func conditionStatus(content map[string]interface{}, conditionType string) (met bool, reason, message string) {
+	conditions := objectConditions(content)
+	generation, _, _ := unstructured.NestedInt64(content, "metadata", "generation")
+	if observed, found, _ := unstructured.NestedInt64(content, "status", "observedGeneration"); found && observed < generation {
+		return false, "", "the new spec is not observed"
+	}
+	condition, ok := conditions[conditionType]
+	if ok {
+		observed, found, _ := unstructured.NestedInt64(condition, "observedGeneration")
+		if condition["status"] == "True" && (!found || observed >= generation) {
+			return true, "", ""
+		}
+		message = fmt.Sprintf("%s is %v", conditionType, condition["status"])
+		if msg, _ := condition["message"].(string); msg != "" {
+			message += "," + msg
+		}
+	} else {
+		message = fmt.Sprintf("%s is not found", conditionType)
+	}
+	for _, failed := range []string{"Failed", "Stalled"} {
+		if condition := conditions[failed]; failed != conditionType && condition != nil && condition["status"] == "True" {
+			msg, _ := condition["message"].(string)
+			return false, failed, msg
+		}
+	}
+	if condition := conditions["Progressing"]; conditionType != "Progressing" && condition != nil && condition["reason"] == "ProgressDeadlineExceeded" {
+		msg, _ := condition["message"].(string)
+		return false, "ProgressDeadlineExceeded", msg
+	}
+	return false, "", message
+}
+
+// objectConditions get status.conditions of the object by type
+func objectConditions(content map[string]interface{}) map[string]map[string]interface{} {
+	conditions, _, _ := unstructured.NestedSlice(content, "status", "conditions")
+	status := make(map[string]map[string]interface{}, len(conditions))
+	for _, item := range conditions {
+		if condition, ok := item.(map[string]interface{}); ok {
+			if conditionType, ok := condition["type"].(string); ok {
+				status[conditionType] = condition
+			}
+		}
+	}
+	return status
+}
+
+// podIssues get the problems of the Pods selected by selector,eg: ErrImagePull,CrashLoopBackOff,Unschedulable,
+// and the warning events of the Pods,the errors of getting them are ignored because they are only diagnosis.
+// it doesn't use the context of the wait,because the problems are still wanted after the wait is timeout.
+func podIssues(client kubernetes.Interface, namespace string, selector *metav1.LabelSelector) []string {
+	labelSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil
+	}
+	pods, err := client.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector.String()})
+	if err != nil {
+		return nil
+	}
+	var issues []string
+	seen := make(map[string]bool)
+	add := func(issue string) {
+		if !seen[issue] && len(issues) < maxPodIssues {
+			seen[issue] = true
+			issues = append(issues, issue)
+		}
+	}
+	for index := range pods.Items {
+		pod := &pods.Items[index]
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == v1.PodScheduled && condition.Status == v1.ConditionFalse {
+				add(fmt.Sprintf("pod %s %s:%s", pod.Name, condition.Reason, condition.Message))
+			}
+		}
+		statuses := append(append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, status := range statuses {
+			waiting := status.State.Waiting
+			if waiting == nil || waiting.Reason == "ContainerCreating" || waiting.Reason == "PodInitializing" {
+				continue
+			}
+			add(fmt.Sprintf("pod %s container %s %s:%s", pod.Name, status.Name, waiting.Reason, waiting.Message))
+		}
+		events, err := client.CoreV1().Events(namespace).List(context.TODO(), metav1.ListOptions{FieldSelector: fields.Set{
+			"involvedObject.kind": "Pod",
+			"involvedObject.name": pod.Name,
+			"type":                v1.EventTypeWarning,
+		}.String()})
+		if err != nil {
+			continue
+		}
+		for _, event := range events.Items {
+			add(fmt.Sprintf("pod %s event %s:%s", pod.Name, event.Reason, event.Message))
+		}
+	}
+	return issues
+}
+
+// waitContext get the context of the wait,timeout <= 0 means the context is not changed
+func waitContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,157 @@
+package beku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// EventHandler is called when the watched objects are added,updated or deleted on Kubernetes,
+// the nil functions are skipped,the objects are from the cache of informer,they should not be changed.
+type EventHandler struct {
+	OnAdd    func(obj runtime.Object)
+	OnUpdate func(oldObj, newObj runtime.Object)
+	OnDelete func(obj runtime.Object)
+}
+
+// resourceEventHandler translate EventHandler into the handler of informer
+func (h EventHandler) resourceEventHandler() cache.ResourceEventHandlerFuncs {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if o, ok := obj.(runtime.Object); ok && h.OnAdd != nil {
+				h.OnAdd(o)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			o, oldOK := oldObj.(runtime.Object)
+			n, newOK := newObj.(runtime.Object)
+			if oldOK && newOK && h.OnUpdate != nil {
+				h.OnUpdate(o, n)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			// the object is missed when the watch is broken,only its final state is known
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if o, ok := obj.(runtime.Object); ok && h.OnDelete != nil {
+				h.OnDelete(o)
+			}
+		},
+	}
+}
+
+// watchObjects watch the objects on Kubernetes by informers filtered by their namespace and name,
+// it blocks until ctx is done,so lightweight controllers can be written on it,nil is returned when ctx is done.
+func watchObjects(ctx context.Context, client kubernetes.Interface, objs []runtime.Object, handler EventHandler) error {
+	client, err := clientOrRegistered(client)
+	if err != nil {
+		return err
+	}
+	var synced []cache.InformerSynced
+	for _, obj := range objs {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return err
+		}
+		if !verifyString(accessor.GetName()) {
+			return fmt.Errorf("Watch err,the name of %s is not allowed to be empty", objectKind(obj))
+		}
+		name := accessor.GetName()
+		factory := informers.NewSharedInformerFactoryWithOptions(client, 0, informers.WithNamespace(accessor.GetNamespace()),
+			informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+				opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+			}))
+		informer, err := objectInformer(factory, obj)
+		if err != nil {
+			return err
+		}
+		informer.AddEventHandler(handler.resourceEventHandler())
+		factory.Start(ctx.Done())
+		synced = append(synced, informer.HasSynced)
+	}
+	return runInformers(ctx, synced)
+}
+
+// watchPods watch the Pods selected by the selector of workload
+func watchPods(ctx context.Context, client kubernetes.Interface, kind, namespace string, selector *metav1.LabelSelector, handler EventHandler) error {
+	sel, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return fmt.Errorf("%s WatchPods err,the selector is not allowed:%v", kind, err)
+	}
+	if sel.Empty() {
+		return fmt.Errorf("%s WatchPods err,the selector is not allowed to be empty,it selects all Pods", kind)
+	}
+	client, err = clientOrRegistered(client)
+	if err != nil {
+		return err
+	}
+	factory := informers.NewSharedInformerFactoryWithOptions(client, 0, informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) { opts.LabelSelector = sel.String() }))
+	informer := factory.Core().V1().Pods().Informer()
+	informer.AddEventHandler(handler.resourceEventHandler())
+	factory.Start(ctx.Done())
+	return runInformers(ctx, []cache.InformerSynced{informer.HasSynced})
+}
+
+// runInformers wait the caches of the started informers are synced and block until ctx is done
+func runInformers(ctx context.Context, synced []cache.InformerSynced) error {
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
+		if err := ctx.Err(); err != nil {
+			return nil
+		}
+		return errors.New("Watch err,the caches of informers are not synced")
+	}
+	<-ctx.Done()
+	return nil
+}
+
+// objectInformer get the informer of the kind of the object,only the Kubernetes built-in kinds have informer
+func objectInformer(factory informers.SharedInformerFactory, obj runtime.Object) (cache.SharedIndexInformer, error) {
+	switch obj.(type) {
+	case *v1.Namespace:
+		return factory.Core().V1().Namespaces().Informer(), nil
+	case *v1.ConfigMap:
+		return factory.Core().V1().ConfigMaps().Informer(), nil
+	case *v1.Secret:
+		return factory.Core().V1().Secrets().Informer(), nil
+	case *v1.Service:
+		return factory.Core().V1().Services().Informer(), nil
+	case *v1.PersistentVolume:
+		return factory.Core().V1().PersistentVolumes().Informer(), nil
+	case *v1.PersistentVolumeClaim:
+		return factory.Core().V1().PersistentVolumeClaims().Informer(), nil
+	case *appsv1.Deployment:
+		return factory.Apps().V1().Deployments().Informer(), nil
+	case *appsv1.StatefulSet:
+		return factory.Apps().V1().StatefulSets().Informer(), nil
+	case *appsv1.DaemonSet:
+		return factory.Apps().V1().DaemonSets().Informer(), nil
+	case *batchv1.Job:
+		return factory.Batch().V1().Jobs().Informer(), nil
+	case *batchv1.CronJob:
+		return factory.Batch().V1().CronJobs().Informer(), nil
+	}
+	return nil, fmt.Errorf("Watch err,%s has no informer,only the Kubernetes built-in kinds are supported", objectName(obj))
+}
+
+// Watch watch the objects of Bundle on Kubernetes and call handler when they are added,updated or deleted,
+// client is the Kubernetes clientset,the registered one is used when it is nil.
+// it blocks until ctx is done,nil is returned when ctx is done.
+func (b *Bundle) Watch(ctx context.Context, client kubernetes.Interface, handler EventHandler) error {
+	if len(b.builders) > 0 {
+		return errors.New("Bundle Watch err,the builders are not finished,you should call FinishAll() first")
+	}
+	return watchObjects(ctx, client, b.objs, handler)
+}